@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/richgo/flo/pkg/approval"
+	"github.com/richgo/flo/pkg/tui"
+	"github.com/spf13/cobra"
+)
+
+var approvalsList bool
+
+var approvalsCmd = &cobra.Command{
+	Use:   "approvals",
+	Short: "Review pending approvals",
+	Long: `Open an interactive screen listing pending approvals and reviews.
+
+Each entry shows the task, gate results and cost; press d to preview the
+diff. Decisions are a single key:
+
+  j/k, arrows   move between requests
+  d, enter      toggle diff preview
+  a             approve
+  r             reject (optional reason)
+  f             request changes with feedback for the agent
+  q             quit
+
+Use --list (or run without a terminal) for a plain listing.`,
+	RunE: runApprovals,
+}
+
+var approvalsApproveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve a pending request",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		if err := ws.Approvals().Approve(args[0], reviewerName()); err != nil {
+			return err
+		}
+		fmt.Printf("Approved %s\n", args[0])
+		return nil
+	},
+}
+
+var approvalsRejectReason string
+
+var approvalsRejectCmd = &cobra.Command{
+	Use:   "reject <id>",
+	Short: "Reject a pending request",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		if err := ws.Approvals().Reject(args[0], reviewerName(), approvalsRejectReason); err != nil {
+			return err
+		}
+		fmt.Printf("Rejected %s\n", args[0])
+		return nil
+	},
+}
+
+var approvalsFeedbackCmd = &cobra.Command{
+	Use:   "feedback <id> <message>",
+	Short: "Request changes on a pending request",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		feedback := strings.Join(args[1:], " ")
+		if err := ws.Approvals().RequestChanges(args[0], reviewerName(), feedback); err != nil {
+			return err
+		}
+		fmt.Printf("Requested changes on %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	approvalsCmd.Flags().BoolVar(&approvalsList, "list", false, "Print pending approvals instead of opening the interactive screen")
+	approvalsRejectCmd.Flags().StringVar(&approvalsRejectReason, "reason", "", "Reason for rejection")
+
+	approvalsCmd.AddCommand(approvalsApproveCmd)
+	approvalsCmd.AddCommand(approvalsRejectCmd)
+	approvalsCmd.AddCommand(approvalsFeedbackCmd)
+	rootCmd.AddCommand(approvalsCmd)
+}
+
+func runApprovals(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+	queue := ws.Approvals()
+
+	if !approvalsList && !outputFormat.Machine() {
+		term, err := tui.OpenTerminal(os.Stdout)
+		if err == nil {
+			defer term.Close()
+			return tui.RunApprovalScreen(term, tui.NewApprovalScreen(queue, reviewerName()))
+		}
+		if !errors.Is(err, tui.ErrNoTTY) {
+			return err
+		}
+	}
+
+	return printApprovals(queue)
+}
+
+func printApprovals(queue *approval.Queue) error {
+	pending, err := queue.Pending()
+	if err != nil {
+		return err
+	}
+	if pending == nil {
+		pending = []*approval.Request{}
+	}
+	return render(pending, func() error {
+		if len(pending) == 0 {
+			fmt.Println("No pending approvals.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTASK\tKIND\tGATES\tCOST\tSUMMARY")
+		for _, req := range pending {
+			passed := 0
+			for _, g := range req.Gates {
+				if g.Passed {
+					passed++
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t$%.2f\t%s\n",
+				req.ID, req.TaskID, req.Kind, passed, len(req.Gates), req.Cost, req.Summary)
+		}
+		return w.Flush()
+	})
+}
+
+// reviewerName identifies the human recording a decision.
+func reviewerName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/redact"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit log commands",
+	Long:  `Commands for inspecting the workspace audit log (.flo/audit.log).`,
+}
+
+// Tail flags
+var (
+	auditTailLines  int
+	auditTailFollow bool
+	auditTailRaw    bool
+	// auditTailRun and auditTailExecution show only the events of one run
+	// or task attempt.
+	auditTailRun       string
+	auditTailExecution string
+)
+
+var auditTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Show the most recent audit events",
+	Long: `Show the most recent audit events with secret values redacted.
+
+Redaction is applied at display time, so entries written before redaction
+was enabled are also scrubbed. Use --raw to disable redaction; this requires
+logs.allow_raw: true in .flo/config.yaml.
+
+Events recorded during 'flo work' carry the run ID it prints and the
+execution ID of each attempt at the task; --run and --execution show only
+those events.`,
+	RunE: runAuditTail,
+}
+
+var auditVerifyJSON bool
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the audit log for tampering",
+	Long: `Check a tamper-evident audit log (logs.tamper_evident: true in
+.flo/config.yaml): every event's hash and its link to the event before,
+unbroken sequence numbers, the signatures of checkpoints (with the workspace
+key, from .flo/keys/workspace.key or FLO_WORKSPACE_KEY), and that the log
+still reaches the last checkpoint in .flo/audit.head.
+
+Exits with an error when tampering or truncation is found.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runAuditVerify,
+}
+
+func init() {
+	auditVerifyCmd.Flags().BoolVar(&auditVerifyJSON, "json", false, "Output as JSON")
+	auditCmd.AddCommand(auditVerifyCmd)
+	auditTailCmd.Flags().IntVarP(&auditTailLines, "lines", "n", 20, "Number of events to show")
+	auditTailCmd.Flags().BoolVarP(&auditTailFollow, "follow", "f", false, "Keep printing new events as they are written")
+	auditTailCmd.Flags().BoolVar(&auditTailRaw, "raw", false, "Show events without redaction (requires logs.allow_raw)")
+	auditTailCmd.Flags().StringVar(&auditTailRun, "run", "", "Show only events of this run ID")
+	auditTailCmd.Flags().StringVar(&auditTailExecution, "execution", "", "Show only events of this execution ID")
+
+	auditCmd.AddCommand(auditTailCmd)
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAuditTail(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	redactor, err := displayRedactor(ws, auditTailRaw)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(ws.Root, ".flo", "audit.log")
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	// Keep only the last N lines
+	var lines []string
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" && auditLineMatches(line) {
+			lines = append(lines, strings.TrimRight(line, "\n"))
+			if len(lines) > auditTailLines {
+				lines = lines[1:]
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+
+	for _, line := range lines {
+		fmt.Println(redactor.String(line))
+	}
+
+	if !auditTailFollow {
+		return nil
+	}
+
+	var partial string
+	for {
+		line, err := reader.ReadString('\n')
+		partial += line
+		if err == io.EOF {
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read audit log: %w", err)
+		}
+		if auditLineMatches(partial) {
+			fmt.Println(redactor.String(strings.TrimRight(partial, "\n")))
+		}
+		partial = ""
+	}
+}
+
+func runAuditVerify(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+	report, err := ws.VerifyAudit()
+	if err != nil {
+		return err
+	}
+
+	jsonAlias(auditVerifyJSON)
+	if err := render(report, func() error {
+		chained := report.Events - report.Unchained
+		fmt.Printf("Events:      %d (%d chained", report.Events, chained)
+		if report.Unchained > 0 {
+			fmt.Printf(", %d written before chaining", report.Unchained)
+		}
+		fmt.Println(")")
+		fmt.Printf("Checkpoints: %d verified", report.Checkpoints)
+		if report.Unsigned > 0 {
+			fmt.Printf(", %d unchecked (no workspace key)", report.Unsigned)
+		}
+		fmt.Println()
+		if chained == 0 && !ws.Config.Logs.TamperEvident {
+			fmt.Println("\nThe log is not chained; set logs.tamper_evident: true in .flo/config.yaml.")
+		}
+		for _, p := range report.Problems {
+			fmt.Printf("✗ %s\n", p)
+		}
+		if report.OK() && chained > 0 {
+			fmt.Printf("✓ Chain intact through event %d\n", report.LastSeq)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if !report.OK() {
+		audit.Warn("audit.verify", "Audit log failed verification", map[string]interface{}{
+			"problems": report.Problems,
+		})
+		return withExitCode(ExitValidation,
+			fmt.Errorf("audit log failed verification: %d problem(s)", len(report.Problems)))
+	}
+	return nil
+}
+
+// auditLineMatches reports whether an audit log line belongs to the run
+// and execution selected with --run and --execution.
+func auditLineMatches(line string) bool {
+	if auditTailRun == "" && auditTailExecution == "" {
+		return true
+	}
+	event, err := audit.ParseEvent([]byte(line))
+	if err != nil {
+		return false
+	}
+	return (auditTailRun == "" || event.RunID == auditTailRun) &&
+		(auditTailExecution == "" || event.ExecutionID == auditTailExecution)
+}
+
+// displayRedactor returns the redactor used when printing logs. Raw output
+// (a nil redactor) is only allowed when the workspace policy permits it.
+func displayRedactor(ws *workspace.Workspace, raw bool) (*redact.Redactor, error) {
+	if raw {
+		if !ws.Config.Logs.AllowRaw {
+			return nil, fmt.Errorf("raw log output is disabled by workspace policy (set logs.allow_raw: true in .flo/config.yaml)")
+		}
+		return nil, nil
+	}
+
+	manager, err := loadSecrets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secrets: %w", err)
+	}
+	return manager.Redactor(), nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var backendStatusJSON bool
+
+var backendCmd = &cobra.Command{
+	Use:   "backend",
+	Short: "Agent backend commands",
+}
+
+var backendStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show recent success rate, latency and retries per backend",
+	Long: `Show how each agent backend has behaved over its recent runs (the last
+100 runs within 7 days): success rate, median run time, how often runs
+needed retries, and how often the circuit breaker opened.
+
+The statistics are kept in .flo/health.json and updated by every 'flo work'
+run. --json (or --output json/yaml) prints them for dashboards and scripts.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		stats, err := ws.Health().Stats()
+		if err != nil {
+			return err
+		}
+
+		jsonAlias(backendStatusJSON)
+		return render(stats, func() error {
+			if len(stats) == 0 {
+				fmt.Println("No backend runs recorded yet.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "BACKEND\tRUNS\tSUCCESS\tMEDIAN\tRETRIED\tTRIPS\tBREAKER\tLAST RUN")
+			for _, st := range stats {
+				fmt.Fprintf(w, "%s\t%d\t%.0f%%\t%s\t%.0f%%\t%d\t%s\t%s\n",
+					st.Backend,
+					st.Runs,
+					st.SuccessRate*100,
+					st.MedianLatency.Round(time.Second),
+					st.RetryRate*100,
+					st.BreakerTrips,
+					st.Breaker,
+					formatRelativeTime(st.LastRun),
+				)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+func init() {
+	backendStatusCmd.Flags().BoolVar(&backendStatusJSON, "json", false, "Output as JSON")
+	backendCmd.AddCommand(backendStatusCmd)
+	rootCmd.AddCommand(backendCmd)
+}
@@ -35,7 +35,7 @@ func init() {
 
 func runConfigShow(cmd *cobra.Command, args []string) error {
 	// Load secrets from .env files
-	manager, err := secrets.LoadDefault()
+	manager, err := loadSecrets()
 	if err != nil {
 		return fmt.Errorf("failed to load secrets: %w", err)
 	}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/workspace"
+)
+
+// Exit codes flo returns, so scripts and CI can branch on the outcome.
+const (
+	ExitOK = 0
+	// ExitFailure is any error without a more specific code.
+	ExitFailure = 1
+	// ExitValidation is a spec, gate, audit log or staged file check that
+	// failed, or an invalid flag.
+	ExitValidation = 2
+	// ExitTasksFailed is an agent run that left its task failed.
+	ExitTasksFailed = 3
+	// ExitQuotaExhausted is a run stopped because every backend it could
+	// use was out of quota.
+	ExitQuotaExhausted = 4
+	// ExitNoWorkspace is a command that needs a workspace run outside one.
+	ExitNoWorkspace = 5
+)
+
+// exitError gives err the exit code flo returns for it.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode returns err with the exit code flo returns for it.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	switch {
+	case errors.Is(err, workspace.ErrNoWorkspace):
+		return ExitNoWorkspace
+	case errors.Is(err, quota.ErrQuotaExhausted):
+		return ExitQuotaExhausted
+	}
+	return ExitFailure
+}
+
+// quiet is the global --quiet flag.
+var quiet bool
+
+// stdout is the real standard output while --quiet discards the command's.
+var stdout *os.File
+
+// silenceStdout discards what the command prints to standard output, as
+// --quiet does for table output. Errors and warnings on standard error, and
+// JSON or YAML output, are still printed.
+func silenceStdout() {
+	if !quiet || outputFormat.Machine() || stdout != nil {
+		return
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	stdout, os.Stdout = os.Stdout, devNull
+}
+
+// restoreStdout undoes silenceStdout.
+func restoreStdout() {
+	if stdout == nil {
+		return
+	}
+	os.Stdout.Close()
+	os.Stdout, stdout = stdout, nil
+}
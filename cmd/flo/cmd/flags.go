@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/richgo/flo/pkg/flags"
+	"github.com/spf13/cobra"
+)
+
+var flagsCmd = &cobra.Command{
+	Use:   "flags",
+	Short: "Feature flags for experimental subsystems",
+	Long: `Experimental subsystems ship behind feature flags and stay off until
+enabled for a workspace in .flo/config.yaml:
+
+  features:
+    experimental_backends: true
+
+or for a single process with FLO_FEATURES, a comma-separated list where a
+leading "-" turns a flag off (FLO_FEATURES=auto_merge,-experimental_backends).
+FLO_FEATURES overrides the config.`,
+}
+
+var flagsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show which feature flags are on",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var (
+			features *flags.Set
+			err      error
+		)
+		if ws, wsErr := loadWorkspace(); wsErr == nil {
+			features, err = ws.Features()
+		} else {
+			features, err = flags.Resolve(nil, os.Getenv(flags.EnvVar))
+		}
+		if err != nil {
+			return err
+		}
+
+		var states []flagState
+		for _, state := range features.List() {
+			states = append(states, flagState{
+				Name:        state.Name,
+				Enabled:     state.Enabled,
+				Source:      state.Source,
+				Description: state.Description,
+			})
+		}
+		return render(states, func() error {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "FLAG\tSTATE\tSOURCE\tDESCRIPTION")
+			for _, state := range states {
+				on := "off"
+				if state.Enabled {
+					on = "on"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", state.Name, on, state.Source, state.Description)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+// flagState is a feature flag in `flo flags list`.
+type flagState struct {
+	Name        string `json:"name"`
+	Enabled     bool   `json:"enabled"`
+	Source      string `json:"source"`
+	Description string `json:"description"`
+}
+
+func init() {
+	flagsCmd.AddCommand(flagsListCmd)
+	rootCmd.AddCommand(flagsCmd)
+}
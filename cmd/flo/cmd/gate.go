@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/richgo/flo/pkg/gate"
+	"github.com/spf13/cobra"
+)
+
+var gateCmd = &cobra.Command{
+	Use:   "gate",
+	Short: "Custom completion gates",
+	Long: `Commands for the custom completion gates configured under 'gates' in
+.flo/config.yaml (or per task type under taskTypes.<type>.gates).
+
+Exec gates run a plugin in the worktree: a path, an executable in
+.flo/plugins/, or flo-gate-<name> on PATH. The plugin reads a JSON request
+(gate, worktree, task, options) on stdin and prints
+{"passed": bool, "findings": [{"severity", "rule", "message", "file", "line"}]}
+on stdout. A non-zero exit, or a finding at or above fail_on, fails the gate.`,
+}
+
+var gateListCmd = &cobra.Command{
+	Use:   "list [task-type]",
+	Short: "List the gates configured for a task type",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		taskType := ""
+		if len(args) > 0 {
+			taskType = args[0]
+		}
+		gates := ws.Config.GateConfigs(taskType)
+		if len(gates) == 0 {
+			fmt.Println("No custom gates configured.")
+			return nil
+		}
+		for _, g := range gates {
+			kind := g.Kind
+			if kind == "" {
+				kind = gate.DefaultKind
+			}
+			failOn := g.FailOn
+			if failOn == "" {
+				failOn = string(gate.SeverityError)
+			}
+			fmt.Printf("  %-16s %-6s %s (fail on %s)\n", g.Name, kind, g.Plugin, failOn)
+		}
+		return nil
+	},
+}
+
+var gateRunJSON bool
+
+var gateRunCmd = &cobra.Command{
+	Use:          "run <task-id>",
+	Short:        "Run the custom gates for a task and show findings",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		t, err := ws.GetTask(args[0])
+		if err != nil {
+			return err
+		}
+		suite, err := ws.Gates(t)
+		if err != nil {
+			return err
+		}
+		if suite.Empty() {
+			fmt.Println("No custom gates configured.")
+			return nil
+		}
+
+		result := suite.Run(context.Background(), ws.GateContext(t))
+		jsonAlias(gateRunJSON)
+		if err := render(result, func() error {
+			printGateResults(result, "")
+			return nil
+		}); err != nil {
+			return err
+		}
+		return withExitCode(ExitValidation, result.Err())
+	},
+}
+
+func init() {
+	gateRunCmd.Flags().BoolVar(&gateRunJSON, "json", false, "Output results as JSON")
+
+	gateCmd.AddCommand(gateListCmd)
+	gateCmd.AddCommand(gateRunCmd)
+	rootCmd.AddCommand(gateCmd)
+}
+
+// printGateResults prints each gate with its findings.
+func printGateResults(result *gate.SuiteResult, indent string) {
+	for _, g := range result.Gates {
+		mark := "✓"
+		if !g.Passed {
+			mark = "✗"
+		}
+		fmt.Printf("%s%s gate: %s (%d findings)\n", indent, mark, g.Name, len(g.Findings))
+		if g.Error != "" {
+			fmt.Printf("%s    error: %s\n", indent, g.Error)
+		}
+		for _, f := range g.Findings {
+			fmt.Printf("%s    %s\n", indent, f)
+		}
+	}
+}
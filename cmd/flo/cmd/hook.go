@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Git hooks that guard workspace state",
+	Long: `Commands for the git pre-commit hook that checks staged .flo changes:
+SPEC.md against the required sections, the task manifest the way flo
+loads it (valid tasks, existing dependencies, no cycles), config.yaml
+against its validation rules, and every staged .flo file for secrets.
+
+Only staged .flo files are checked, as they will be committed. Commit
+with --no-verify to skip the hook.`,
+}
+
+var hookInstallForce bool
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the pre-commit hook",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := hook.NewRepo(".").Install(context.Background(), hookInstallForce)
+		if err != nil {
+			return err
+		}
+		audit.Info("hook.install", "Pre-commit hook installed", map[string]interface{}{
+			"path": path,
+		})
+		fmt.Printf("✓ Installed pre-commit hook at %s\n", path)
+		return nil
+	},
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the pre-commit hook",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := hook.NewRepo(".").Uninstall(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Removed pre-commit hook at %s\n", path)
+		return nil
+	},
+}
+
+var hookPreCommitCmd = &cobra.Command{
+	Use:   "pre-commit",
+	Short: "Check staged .flo changes (run by the pre-commit hook)",
+	Args:  cobra.NoArgs,
+	// Findings are printed above; usage would bury them in the commit output
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := hook.Options{}
+		if ws, err := loadWorkspace(); err == nil {
+			opts.SpecSections = ws.Config.Spec.RequiredSections
+		}
+		manager, err := loadSecrets()
+		if err != nil {
+			return fmt.Errorf("failed to load secrets: %w", err)
+		}
+		opts.Redactor = manager.Redactor()
+
+		findings, err := hook.NewRepo(".").Check(context.Background(), opts)
+		if err != nil {
+			return err
+		}
+		if len(findings) == 0 {
+			return nil
+		}
+
+		fmt.Fprintln(os.Stderr, "flo: staged .flo changes failed pre-commit checks:")
+		for _, f := range findings {
+			fmt.Fprintf(os.Stderr, "  %s\n", f)
+		}
+		audit.Warn("hook.pre_commit", "Commit blocked by pre-commit checks", map[string]interface{}{
+			"findings": len(findings),
+		})
+		return withExitCode(ExitValidation,
+			fmt.Errorf("%d problem(s) in staged .flo files (commit with --no-verify to skip)", len(findings)))
+	},
+}
+
+func init() {
+	hookInstallCmd.Flags().BoolVar(&hookInstallForce, "force", false, "Replace an existing pre-commit hook")
+
+	hookCmd.AddCommand(hookInstallCmd)
+	hookCmd.AddCommand(hookUninstallCmd)
+	hookCmd.AddCommand(hookPreCommitCmd)
+	rootCmd.AddCommand(hookCmd)
+}
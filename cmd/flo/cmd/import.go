@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/issues"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importGitHubRepo    string
+	importGitHubLabels  []string
+	importLinearTeam    string
+	importLinearProject string
+	importLinearLabels  []string
+	importSync          bool
+	importDryRun        bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import tasks from issue trackers",
+	Long:  `Commands for creating tasks from the issues of an issue tracker.`,
+}
+
+var importGitHubCmd = &cobra.Command{
+	Use:   "github",
+	Short: "Create tasks from GitHub issues",
+	Long: `Create a task for each open issue of a GitHub repository carrying all of
+the given labels, and refresh the tasks of issues imported before.
+
+A label naming a task type sets the task's type, and a "priority:N" or "pN"
+label its priority. Assignees are kept on the task, and "depends on #12" or
+"blocked by org/name#12" in an issue becomes a task dependency when that
+issue is imported too.
+
+--repo and --label are saved to issues.github in .flo/config.yaml, so later
+imports need no flags. With --sync, an issue is closed when its task
+completes. The token is GITHUB_TOKEN (or issues.github.token_env), resolved
+like CLAUDE_API_KEY.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		cfg := ws.Config.Issues.GitHub
+		if cfg == nil {
+			cfg = &config.GitHubIssues{}
+		}
+		updated := *cfg
+		if importGitHubRepo != "" {
+			updated.Repo = importGitHubRepo
+		}
+		if cmd.Flags().Changed("label") {
+			updated.Labels = importGitHubLabels
+		}
+		if importSync {
+			updated.Sync = true
+		}
+		if updated.Repo == "" {
+			return withExitCode(ExitValidation, fmt.Errorf("--repo is required (org/name)"))
+		}
+		if !issues.ValidRepo(updated.Repo) {
+			return withExitCode(ExitValidation, fmt.Errorf("--repo must be org/name, got '%s'", updated.Repo))
+		}
+		ws.Config.Issues.GitHub = &updated
+
+		found, err := ws.GitHubTracker().Issues(context.Background())
+		if err != nil {
+			return err
+		}
+		return importIssues(ws, "GitHub", found)
+	},
+}
+
+var importLinearCmd = &cobra.Command{
+	Use:   "linear",
+	Short: "Create tasks from Linear issues",
+	Long: `Create a task for each open Linear issue of a team, optionally limited to a
+project and issues carrying all of the given labels, and refresh the tasks
+of issues imported before.
+
+A label naming a task type sets the task's type; otherwise the issue's
+Linear priority (1 urgent to 4 low) sets the task's. The assignee is kept
+on the task, and issues that block an issue become its task's dependencies
+when they are imported too.
+
+--team, --project and --label are saved to issues.linear in
+.flo/config.yaml, so later imports need no flags. With --sync, issues move
+to the team's started and completed states as their tasks start and
+complete (issues.linear.states names others). The key is LINEAR_API_KEY
+(or issues.linear.token_env), resolved like CLAUDE_API_KEY.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		cfg := ws.Config.Issues.Linear
+		if cfg == nil {
+			cfg = &config.LinearIssues{}
+		}
+		updated := *cfg
+		if importLinearTeam != "" {
+			updated.Team = importLinearTeam
+		}
+		if cmd.Flags().Changed("project") {
+			updated.Project = importLinearProject
+		}
+		if cmd.Flags().Changed("label") {
+			updated.Labels = importLinearLabels
+		}
+		if importSync {
+			updated.Sync = true
+		}
+		if updated.Team == "" {
+			return withExitCode(ExitValidation, fmt.Errorf("--team is required (the team key, e.g. ENG)"))
+		}
+		ws.Config.Issues.Linear = &updated
+
+		found, err := ws.LinearTracker().Issues(context.Background())
+		if err != nil {
+			return err
+		}
+		return importIssues(ws, "Linear", found)
+	},
+}
+
+func init() {
+	importGitHubCmd.Flags().StringVar(&importGitHubRepo, "repo", "", "Repository to import from, org/name (default issues.github.repo)")
+	importGitHubCmd.Flags().StringSliceVar(&importGitHubLabels, "label", nil, "Only import issues with this label (repeatable)")
+	importLinearCmd.Flags().StringVar(&importLinearTeam, "team", "", "Team key to import from, e.g. ENG (default issues.linear.team)")
+	importLinearCmd.Flags().StringVar(&importLinearProject, "project", "", "Only import issues in this project")
+	importLinearCmd.Flags().StringSliceVar(&importLinearLabels, "label", nil, "Only import issues with this label (repeatable)")
+	importCmd.PersistentFlags().BoolVar(&importSync, "sync", false, "Update issues when their tasks' status changes")
+	importCmd.PersistentFlags().BoolVar(&importDryRun, "dry-run", false, "List the issues that would be imported without changing anything")
+	importCmd.AddCommand(importGitHubCmd, importLinearCmd)
+	rootCmd.AddCommand(importCmd)
+}
+
+// importIssues imports issues found in tracker into ws, saving the
+// tracker's config with them, or lists them with --dry-run.
+func importIssues(ws *workspace.Workspace, tracker string, found []issues.Issue) error {
+	if importDryRun {
+		return render(found, func() error {
+			if len(found) == 0 {
+				fmt.Printf("No open %s issues match.\n", tracker)
+				return nil
+			}
+			imported := make(map[string]string)
+			for _, t := range ws.ListTasks("", "") {
+				if t.Issue != "" {
+					imported[t.Issue] = t.ID
+				}
+			}
+			fmt.Printf("Would import %d %s issue(s):\n", len(found), tracker)
+			for _, is := range found {
+				note := ""
+				if id, ok := imported[is.Ref]; ok {
+					note = fmt.Sprintf(" (updates %s)", id)
+				}
+				fmt.Printf("  %s %s%s\n", is.Ref, is.Title, note)
+			}
+			return nil
+		})
+	}
+
+	result, err := ws.ImportIssues(found)
+	if err != nil {
+		return err
+	}
+	return render(result, func() error {
+		if len(found) == 0 {
+			fmt.Printf("No open %s issues match.\n", tracker)
+		}
+		printImported("Created", result.Created)
+		printImported("Updated", result.Updated)
+		if len(found) > 0 && len(result.Created)+len(result.Updated) == 0 {
+			fmt.Printf("All %d %s issue(s) are up to date.\n", len(found), tracker)
+		}
+		return nil
+	})
+}
+
+func printImported(verb string, tasks []*task.Task) {
+	if len(tasks) == 0 {
+		return
+	}
+	fmt.Printf("%s %d task(s):\n", verb, len(tasks))
+	for _, t := range tasks {
+		extra := ""
+		if len(t.Assignees) > 0 {
+			extra = fmt.Sprintf(" @%s", strings.Join(t.Assignees, " @"))
+		}
+		if len(t.Deps) > 0 {
+			extra += fmt.Sprintf(" [deps: %s]", strings.Join(t.Deps, ", "))
+		}
+		fmt.Printf("  %s %s ← %s%s\n", t.ID, t.Title, t.Issue, extra)
+	}
+}
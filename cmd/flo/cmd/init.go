@@ -9,6 +9,7 @@ import (
 )
 
 var initBackend string
+var initTemplate string
 
 var initCmd = &cobra.Command{
 	Use:   "init <feature-name>",
@@ -18,7 +19,9 @@ var initCmd = &cobra.Command{
 Creates:
   .flo/config.yaml    - Feature configuration
   .flo/SPEC.md        - Feature specification template
-  .flo/tasks/         - Task manifest directory`,
+  .flo/tasks/         - Task manifest directory
+
+Use --template to start SPEC.md from a spec template (see 'flo spec templates').`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		featureName := args[0]
@@ -27,10 +30,11 @@ Creates:
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 
-		ws, err := workspace.Init(cwd, featureName, initBackend)
+		ws, err := workspace.InitWithTemplate(cwd, featureName, initBackend, initTemplate)
 		if err != nil {
 			return err
 		}
+		trackWorkspace(ws)
 
 		fmt.Printf("✓ Initialized workspace for feature: %s\n", ws.Feature)
 		fmt.Printf("  Backend: %s\n", ws.Backend)
@@ -48,4 +52,5 @@ Creates:
 
 func init() {
 	initCmd.Flags().StringVar(&initBackend, "backend", "claude", "Agent backend (claude or copilot)")
+	initCmd.Flags().StringVar(&initTemplate, "template", "default", "Spec template for SPEC.md (e.g., api, bugfix, migration, mobile)")
 }
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/mcp/client"
+	"github.com/richgo/flo/pkg/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mcpInspectURL   string
+	mcpInspectToken string
+	mcpInspectTrace bool
+)
+
+var mcpInspectCmd = &cobra.Command{
+	Use:   "inspect [-- command [args...]]",
+	Short: "Interactively test an MCP server",
+	Long: `Connect to an MCP server and explore it from a prompt: list tools and
+resources, call tools with JSON arguments and watch the raw protocol
+traffic.
+
+By default this starts the workspace's own server ('flo mcp serve').
+Give a command after -- to start any stdio server instead, or --url to
+connect to an HTTP server (the token defaults to FLO_MCP_TOKEN).
+
+Commands at the prompt:
+
+  tools                     list tools
+  resources                 list resources
+  call <tool> [json-args]   call a tool, e.g. call eas_task_get {"task_id": "t-001"}
+  read <uri>                read a resource
+  raw <method> [json]       send any request and print the raw result
+  ping                      check the server is alive and show the round trip
+  trace [on|off]            show raw JSON-RPC traffic
+  quit                      exit
+
+Commands can also be piped in, one per line.`,
+	Example: `  flo mcp inspect
+  flo mcp inspect --trace -- npx -y @modelcontextprotocol/server-filesystem .
+  flo mcp inspect --url http://127.0.0.1:7777/mcp`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := connectInspector(args)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		insp := &inspector{client: c, out: os.Stdout, trace: mcpInspectTrace}
+		c.SetTrace(insp.traceMessage)
+		c.OnNotification(insp.notification)
+
+		info, err := c.Initialize(context.Background())
+		if err != nil {
+			return err
+		}
+		insp.printf("Connected to %s %s (protocol %s)\n", info.ServerInfo.Name, info.ServerInfo.Version, info.ProtocolVersion)
+		return insp.run(os.Stdin)
+	},
+}
+
+// connectInspector starts or connects to the server to inspect.
+func connectInspector(args []string) (*client.Client, error) {
+	if mcpInspectURL != "" {
+		token := mcpInspectToken
+		if token == "" {
+			if manager, err := loadSecrets(); err == nil {
+				token = manager.Get(secrets.MCPTokenKey)
+			}
+		}
+		return client.NewHTTP(mcpInspectURL, token), nil
+	}
+
+	if len(args) > 0 {
+		return client.NewStdio(exec.Command(args[0], args[1:]...), os.Stderr)
+	}
+
+	ws, err := loadWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate flo: %w", err)
+	}
+	server := exec.Command(exe, "mcp", "serve")
+	server.Dir = ws.Root
+	return client.NewStdio(server, os.Stderr)
+}
+
+// inspector runs the interactive prompt.
+type inspector struct {
+	client *client.Client
+
+	mu    sync.Mutex
+	out   io.Writer
+	trace bool
+}
+
+func (in *inspector) printf(format string, args ...any) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	fmt.Fprintf(in.out, format, args...)
+}
+
+func (in *inspector) traceMessage(outgoing bool, data []byte) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if !in.trace {
+		return
+	}
+	arrow := "<-"
+	if outgoing {
+		arrow = "->"
+	}
+	fmt.Fprintf(in.out, "%s %s\n", arrow, data)
+}
+
+func (in *inspector) notification(method string, params json.RawMessage) {
+	in.mu.Lock()
+	trace := in.trace
+	in.mu.Unlock()
+	if !trace {
+		// Traced notifications have already been shown raw.
+		in.printf("notification: %s %s\n", method, params)
+	}
+}
+
+// run reads commands until EOF or quit.
+func (in *inspector) run(input io.Reader) error {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for {
+		in.printf("mcp> ")
+		if !scanner.Scan() {
+			in.printf("\n")
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "quit" || line == "exit" {
+			return nil
+		}
+		if err := in.exec(line); err != nil {
+			in.printf("error: %v\n", err)
+		}
+	}
+}
+
+// exec runs one prompt command.
+func (in *inspector) exec(line string) error {
+	ctx := context.Background()
+	name, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch name {
+	case "help":
+		in.printf("commands: tools, resources, call <tool> [json-args], read <uri>, raw <method> [json], ping, trace [on|off], quit\n")
+	case "tools":
+		tools, err := in.client.ListTools(ctx)
+		if err != nil {
+			return err
+		}
+		for _, t := range tools {
+			in.printf("  %-24s %s\n", t.Name, t.Description)
+		}
+		in.printf("%d tools\n", len(tools))
+	case "resources":
+		resources, err := in.client.ListResources(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range resources {
+			in.printf("  %-28s %s\n", r.URI, r.Description)
+		}
+		in.printf("%d resources\n", len(resources))
+	case "call":
+		tool, rawArgs, _ := strings.Cut(rest, " ")
+		if tool == "" {
+			return fmt.Errorf("usage: call <tool> [json-args]")
+		}
+		var args map[string]any
+		if s := strings.TrimSpace(rawArgs); s != "" {
+			if err := json.Unmarshal([]byte(s), &args); err != nil {
+				return fmt.Errorf("arguments must be a JSON object: %w", err)
+			}
+		}
+		result, err := in.client.CallTool(ctx, tool, args)
+		if err != nil {
+			return err
+		}
+		if result.IsError {
+			in.printf("tool error: ")
+		}
+		in.printf("%s\n", result.Text())
+	case "read":
+		if rest == "" {
+			return fmt.Errorf("usage: read <uri>")
+		}
+		contents, err := in.client.ReadResource(ctx, rest)
+		if err != nil {
+			return err
+		}
+		for _, c := range contents {
+			in.printf("%s\n", c.Text)
+		}
+	case "raw":
+		method, rawParams, _ := strings.Cut(rest, " ")
+		if method == "" {
+			return fmt.Errorf("usage: raw <method> [json]")
+		}
+		var params any
+		if s := strings.TrimSpace(rawParams); s != "" {
+			if err := json.Unmarshal([]byte(s), &params); err != nil {
+				return fmt.Errorf("params must be JSON: %w", err)
+			}
+		}
+		result, err := in.client.Call(ctx, method, params)
+		if err != nil {
+			return err
+		}
+		pretty, _ := json.MarshalIndent(json.RawMessage(result), "", "  ")
+		in.printf("%s\n", pretty)
+	case "ping":
+		start := time.Now()
+		if err := in.client.Ping(ctx); err != nil {
+			return err
+		}
+		in.printf("pong in %s\n", time.Since(start).Round(time.Microsecond))
+	case "trace":
+		in.mu.Lock()
+		switch rest {
+		case "on":
+			in.trace = true
+		case "off":
+			in.trace = false
+		case "":
+			in.trace = !in.trace
+		default:
+			in.mu.Unlock()
+			return fmt.Errorf("usage: trace [on|off]")
+		}
+		on := in.trace
+		in.mu.Unlock()
+		in.printf("trace %s\n", map[bool]string{true: "on", false: "off"}[on])
+	default:
+		return fmt.Errorf("unknown command '%s' (try help)", name)
+	}
+	return nil
+}
+
+func init() {
+	mcpInspectCmd.Flags().StringVar(&mcpInspectURL, "url", "", "Connect to an HTTP MCP server at this URL")
+	mcpInspectCmd.Flags().StringVar(&mcpInspectToken, "token", "", "Bearer token for --url (default FLO_MCP_TOKEN)")
+	mcpInspectCmd.Flags().BoolVar(&mcpInspectTrace, "trace", false, "Show raw JSON-RPC traffic")
+	mcpCmd.AddCommand(mcpInspectCmd)
+}
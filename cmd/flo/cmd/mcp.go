@@ -1,11 +1,31 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
 	"github.com/richgo/flo/pkg/mcp"
+	"github.com/richgo/flo/pkg/mcp/client"
+	"github.com/richgo/flo/pkg/policy"
+	"github.com/richgo/flo/pkg/redact"
+	"github.com/richgo/flo/pkg/secrets"
+	"github.com/richgo/flo/pkg/tdd"
 	"github.com/richgo/flo/pkg/tools"
+	"github.com/richgo/flo/pkg/workspace"
 )
 
 var mcpCmd = &cobra.Command{
@@ -20,6 +40,120 @@ var mcpServeCmd = &cobra.Command{
 	Long: `Start an MCP server that exposes EAS tools to Claude Code.
 
 The server communicates over stdio using JSON-RPC 2.0.
+
+Every tool call is bounded by a timeout (default 2m, 15m for the test
+tools) and an output size limit (default 256KiB), configurable in
+.flo/config.yaml:
+
+  mcp:
+    timeout: 5m
+    max_output: 131072
+    max_concurrent: 4
+    tools:
+      run_migration: {timeout: 30m, max_concurrent: 1}
+
+A tool that times out returns error code -32001 with data
+{"type": "timeout", "tool": ..., "timeout_ms": ...}. max_concurrent caps
+the calls of a tool running at once, counting timed-out calls whose work
+hasn't stopped yet; a call beyond it is refused with error code -32003 and
+data {"type": "busy", "tool": ..., "max_concurrent": ...}.
+
+Requests are handled concurrently (mcp.concurrency, default 8), so pings
+and quick calls are answered while slow tools run. Responses may arrive
+out of order and are matched to requests by id.
+A client can abort a call with notifications/cancelled: the tool's context
+is cancelled, stopping test runs and custom tool commands, and no response
+is sent.
+
+The workspace is also exposed as MCP resources that clients can pull into
+context: flo://spec (SPEC.md), flo://tasks/<id> (TASK-<id>.md),
+flo://config (.flo/config.yaml) and flo://audit/recent (the last audit log
+entries). Config and audit content is redacted.
+
+The server supports MCP logging: tool calls, to the client that made
+them, and audit events (workspace warnings and errors), to every client,
+are sent as notifications/message at or above the level the client sets
+with logging/setLevel, or mcp.log_level (default warning) until it does.
+
+With --http the server accepts any number of clients at /mcp instead of
+stdio. Each client gets a session (Mcp-Session-Id header) with its own
+log level, task focus (eas_task_focus; claiming a task focuses it) and
+list cursors. Clients receive notifications, including
+notifications/resources/list_changed when another client changes a task,
+on a GET event stream. Every HTTP request must carry
+"Authorization: Bearer <token>" with the workspace token (see 'flo mcp
+token'), which is generated into .flo/.env on first use. --no-auth turns
+this off, and is only allowed on loopback addresses.
+
+The server shuts down when its parent process exits, and optionally after
+a period without requests (mcp.idle_timeout or --idle-timeout; over HTTP
+this also closes unused sessions). With mcp.keepalive set, a quiet stdio
+client is pinged at that interval and dropped if it stops answering, and
+HTTP event streams get keepalive comments.
+
+Tools of external MCP servers (filesystem, GitHub, databases, ...) are
+served alongside flo's own, named <server>_<tool>, so agents get them
+without further setup. Calls are forwarded under the same limits and
+argument checks:
+
+  mcp:
+    servers:
+      github:
+        command: [github-mcp-server, stdio]
+        env: {GITHUB_PERSONAL_ACCESS_TOKEN: "${GITHUB_TOKEN}"}
+      db:
+        url: http://127.0.0.1:9000/mcp
+        token_env: DB_MCP_TOKEN
+        tools: [query]
+
+${VAR} in env is expanded from the environment and .flo/.env. A server
+that can't be reached is skipped with a warning.
+
+Edits to config.yaml are picked up while the server runs: tool timeouts
+and output limits apply from the next call and the tools served are
+updated without dropping clients, and each reload is recorded in the
+audit log (config.reload) with the settings that changed. Other mcp settings and tdd.enforce need a restart.
+
+Tools can be hidden or renamed per workspace. enable, when set, serves
+only the tools it matches and disable hides tools; both take names or
+globs such as github_*. rename serves a tool under another name and
+namespace prefixes every name served, so with the settings below
+eas_task_create is served as flo.task_create. Tools are matched, and
+their limits under tools: set, by their own names:
+
+  mcp:
+    disable: [github_delete_*, run_migration]
+    rename: {eas_task_create: task_create}
+    namespace: flo
+
+read_file, write_file and list_dir read and edit files in the worktree
+of the client's focused task (or the workspace root). Paths can't leave
+it, even through symlinks, nor enter .git, and files matching
+policy.deny_paths are refused.
+
+run_tests runs tdd.test_command in the same worktree, as the TDD gate
+does, and returns JSON with whether it passed, the counts of passed,
+failed and skipped tests with each failure's output (from go test -json,
+jest or pytest output), coverage and the end of the output.
+
+run_command runs build and test commands for agents whose backend has no
+shell of its own. It is served only when commands are allowed, and runs
+commands matching an allow pattern and no deny pattern (nor
+policy.deny_commands), without a shell, in the worktree of the client's
+focused task (or the workspace root). Flags that run another program
+(-exec, -toolexec, -vettool) are always denied, and credential-like
+environment variables (*KEY*, *TOKEN*, *SECRET*, ...) are left out of the
+command's environment:
+
+  mcp:
+    run_command:
+      allow: ['^go (build|test|vet)( |$)', '^make (lint|test)$']
+      deny: ['(^| )-o( |=|$)']   # go build -o can write outside the worktree
+
+Custom tools in .flo/tools/ are reloaded when their files change, and
+connected clients are sent notifications/tools/list_changed so they fetch
+the new list without reconnecting.
+
 Configure in Claude Code with:
 
   {
@@ -38,29 +172,674 @@ Configure in Claude Code with:
 			return err
 		}
 
-		// Create tools with workspace context
-		toolReg := tools.NewEASTools(ws.Tasks, nil)
+		server, stopServer, err := newWorkspaceMCPServer(ws)
+		if err != nil {
+			return err
+		}
+		defer stopServer()
+
+		idleTimeout := ws.Config.MCP.IdleTimeout
+		if cmd.Flags().Changed("idle-timeout") {
+			idleTimeout = mcpServeIdleTimeout
+		}
+		server.SetIdleTimeout(idleTimeout)
+		server.SetKeepalive(ws.Config.MCP.Keepalive)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if mcpServeHTTP != "" {
+			if err := configureHTTPAuth(ws, server, mcpServeHTTP, mcpServeNoAuth); err != nil {
+				return err
+			}
+			mux := http.NewServeMux()
+			mux.Handle("/mcp", server.HTTPHandler())
+			httpServer := &http.Server{Addr: mcpServeHTTP, Handler: mux}
+			go func() {
+				if server.WaitIdle(ctx) {
+					fmt.Fprintln(os.Stderr, "MCP server idle, shutting down")
+				}
+				httpServer.Shutdown(context.Background())
+			}()
+			fmt.Fprintf(os.Stderr, "MCP server listening on http://%s/mcp\n", mcpServeHTTP)
+			if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		}
+
+		ctx, cancel := orphanContext(ctx)
+		defer cancel()
+		err = server.ServeContext(ctx, os.Stdin, os.Stdout)
+		switch {
+		case errors.Is(err, mcp.ErrIdleTimeout), errors.Is(err, mcp.ErrClientGone):
+			fmt.Fprintf(os.Stderr, "%v, shutting down\n", err)
+			return nil
+		case errors.Is(err, context.Canceled):
+			if cause := context.Cause(ctx); cause != context.Canceled {
+				fmt.Fprintf(os.Stderr, "%v, shutting down\n", cause)
+			}
+			return nil
+		}
+		return err
+	},
+}
+
+// newWorkspaceMCPServer builds the MCP server of a workspace with its tools
+// and resources, kept in step with config.yaml and .flo/tools/ until stop
+// is called.
+func newWorkspaceMCPServer(ws *workspace.Workspace) (server *mcp.Server, stop func(), err error) {
+	var stops []func()
+	stop = func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+	defer func() {
+		if err != nil {
+			stop()
+		}
+	}()
+
+	// Create tools with workspace context; completion is gated
+	// on tests passing when TDD enforcement is enabled
+	var testRunner tools.TestRunner
+	if ws.Config.TDD.Enforce {
+		testRunner = ws.TDDGate()
+	}
+	toolReg := tools.NewEASTools(ws.Tasks, testRunner)
+
+	// The workspace policy and review approval can also block
+	// completion
+	if complete, err := toolReg.Get("eas_task_complete"); err == nil {
+		next := complete.ContextHandler
+		complete.ContextHandler = func(ctx context.Context, args tools.Args) (string, error) {
+			if id, ok := args["task_id"].(string); ok {
+				if t, err := ws.Tasks.Get(id); err == nil {
+					if err := ws.CheckPolicy(t, nil); err != nil {
+						return "", fmt.Errorf("cannot complete task %s: %w", id, err)
+					}
+					if err := ws.CheckReview(t); err != nil {
+						return "", fmt.Errorf("cannot complete task %s: %w", id, err)
+					}
+				}
+			}
+			return next(ctx, args)
+		}
+	}
+
+	// Add eas_spec_read tool
+	toolReg.Register(tools.New(
+		"eas_spec_read",
+		"Read the feature specification (SPEC.md)",
+		map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+		func(args tools.Args) (string, error) {
+			return ws.ReadSpec()
+		},
+	))
+
+	// Add file tools confined to the focused task's worktree
+	pol, err := policy.New(ws.Config.Policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, tool := range tools.NewFileTools(worktreeRoot(ws), pol) {
+		toolReg.Register(tool)
+	}
+
+	// Add git tools, whose commits follow the commit message policy
+	// and stay within the focused task's scope
+	for _, tool := range tools.NewGitTools(worktreeRoot(ws), ws.Tasks, pol, ws.Config.MCP.Git.CommitTypes) {
+		toolReg.Register(tool)
+	}
+
+	// Add run_tests when there is a test command, sharing the TDD
+	// gate's runner and result parsing
+	if ws.Config.TDD.TestCommand != "" {
+		toolReg.Register(tools.NewRunTests(func(ctx context.Context, dir string, full bool) (any, error) {
+			gate := tdd.NewGate(ws.Config.TDD, dir)
+			gate.SetFull(full)
+			gate.SetAuditLogger(ws.Audit)
+			result, err := gate.Evaluate(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return result.Brief(runTestsOutput), nil
+		}, worktreeRoot(ws)))
+	}
+
+	// Add run_command when commands are allowed
+	if rc := ws.Config.MCP.RunCommand; len(rc.Allow) > 0 {
+		tool, err := tools.NewRunCommand(tools.CommandRules{
+			Allow: rc.Allow,
+			Deny:  append(append([]string{}, rc.Deny...), ws.Config.Policy.DenyCommands...),
+		}, worktreeRoot(ws))
+		if err != nil {
+			return nil, nil, err
+		}
+		toolReg.Register(tool)
+	}
+
+	// Add team-defined tools from .flo/tools/
+	customTools, err := tools.RegisterCustomTools(toolReg, ws.ToolsDir(), ws.Root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Add the tools of external MCP servers from mcp.servers
+	stops = append(stops, connectExternalServers(ws, toolReg))
+
+	server = mcp.NewServer(toolReg)
+	stops = append(stops, server.Close)
+
+	// Apply configured timeouts, output limits and the tools served,
+	// and keep them in step with config.yaml
+	applyToolLimits(toolReg, ws.Config.MCP)
+	server.SetExposure(toolExposure(ws.Config.MCP))
+	stops = append(stops, config.Watch(ws.ConfigPath(), ws.Config, configReloadInterval, func(cfg *config.Config, changed []string, err error) {
+		reloadMCPConfig(server, toolReg, cfg, changed, err)
+	}))
+	redactor, err := displayRedactor(ws, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	server.AddResources(workspaceResources(ws, redactor))
+	if ws.Config.MCP.LogLevel != "" {
+		level, err := mcp.ParseLogLevel(ws.Config.MCP.LogLevel)
+		if err != nil {
+			return nil, nil, err
+		}
+		server.SetLogLevel(level)
+	}
+	stops = append(stops, audit.Subscribe(forwardAuditEvent(server, redactor)))
+	if ws.Config.MCP.Concurrency > 0 {
+		server.SetConcurrency(ws.Config.MCP.Concurrency)
+	}
+	stops = append(stops, ws.Tasks.Watch(func(string) { server.ResourcesChanged() }))
+
+	// Tell clients when the tools change, such as when .flo/tools/
+	// is edited while the server runs
+	stops = append(stops, toolReg.Watch(server.ToolsChanged))
+	stops = append(stops, tools.WatchCustomTools(toolReg, ws.ToolsDir(), ws.Root, customTools, configReloadInterval, reloadCustomTools))
+
+	return server, stop, nil
+}
+
+var mcpToolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "List custom tools defined in .flo/tools/",
+	Long: `List and validate the custom MCP tools defined in .flo/tools/*.yaml.
+
+Each file describes one tool wrapping a shell command:
+
+  name: run_migration
+  description: Apply database migrations up to a version
+  schema:
+    type: object
+    properties:
+      version: {type: string, description: Target version}
+    required: [version]
+  command: make migrate VERSION={{.version}}
+  timeout: 5m
+
+Arguments are substituted shell-quoted and also exported as FLO_ARG_<NAME>.
+Commands run in the workspace root (or dir:, which must stay inside it).
+timeout and max_output override the limits set under mcp: in
+.flo/config.yaml.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		specs, err := tools.RegisterCustomTools(tools.NewEASTools(ws.Tasks, nil), ws.ToolsDir(), ws.Root)
+		if err != nil {
+			return err
+		}
+		if len(specs) == 0 {
+			fmt.Println("No custom tools defined in .flo/tools/.")
+			return nil
+		}
+		for _, spec := range specs {
+			fmt.Printf("  %-20s %s (%s)\n", spec.Name, spec.Description, filepath.Base(spec.Source))
+		}
+		return nil
+	},
+}
+
+// runTestsOutput is how much of the test output, in bytes, run_tests
+// returns alongside the parsed results.
+const runTestsOutput = 16 * 1024
+
+// worktreeRoot resolves where a tool call runs: the worktree of the
+// session's focused task, or the workspace root when none is focused.
+func worktreeRoot(ws *workspace.Workspace) tools.RootFunc {
+	return func(ctx context.Context) (string, error) {
+		session := tools.SessionFrom(ctx)
+		if session == nil || session.Focus() == "" {
+			return ws.Root, nil
+		}
+		t, err := ws.GetTask(session.Focus())
+		if err != nil {
+			return "", err
+		}
+		return ws.TaskRoot(t)
+	}
+}
+
+// auditResourceLines is how many audit log lines flo://audit/recent shows.
+const auditResourceLines = 100
 
-		// Add eas_spec_read tool
-		toolReg.Register(tools.New(
-			"eas_spec_read",
-			"Read the feature specification (SPEC.md)",
-			map[string]any{
-				"type":       "object",
-				"properties": map[string]any{},
+// workspaceResources lists the spec, task files, config and recent audit
+// entries as MCP resources.
+func workspaceResources(ws *workspace.Workspace, redactor *redact.Redactor) mcp.ResourceLister {
+	readFile := func(path string, redacted bool) func() (string, error) {
+		return func() (string, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			if redacted {
+				return redactor.String(string(data)), nil
+			}
+			return string(data), nil
+		}
+	}
+
+	return func() ([]*mcp.Resource, error) {
+		resources := []*mcp.Resource{
+			{
+				URI:         "flo://spec",
+				Name:        "SPEC.md",
+				Description: "Feature specification",
+				MimeType:    "text/markdown",
+				Read:        readFile(ws.SpecPath(), false),
+			},
+			{
+				URI:         "flo://config",
+				Name:        "config.yaml",
+				Description: "Workspace configuration (redacted)",
+				MimeType:    "application/yaml",
+				Read:        readFile(ws.ConfigPath(), true),
 			},
-			func(args tools.Args) (string, error) {
-				return ws.ReadSpec()
+			{
+				URI:         "flo://audit/recent",
+				Name:        "audit.log",
+				Description: fmt.Sprintf("Last %d audit log entries (redacted)", auditResourceLines),
+				MimeType:    "application/x-ndjson",
+				Read: func() (string, error) {
+					lines, err := audit.Tail(ws.Root, auditResourceLines)
+					if err != nil {
+						return "", err
+					}
+					return redactor.String(strings.Join(lines, "\n")), nil
+				},
 			},
-		))
+		}
+		for _, t := range ws.ListTasks("", "") {
+			resources = append(resources, &mcp.Resource{
+				URI:         "flo://tasks/" + t.ID,
+				Name:        fmt.Sprintf("TASK-%s.md", t.ID),
+				Description: fmt.Sprintf("%s [%s]", t.Title, t.Status),
+				MimeType:    "text/markdown",
+				Read:        readFile(ws.TaskFilePath(t.ID), false),
+			})
+		}
+		return resources, nil
+	}
+}
+
+// forwardAuditEvent returns an audit subscriber sending each event to the
+// MCP client as a redacted log notification.
+func forwardAuditEvent(server *mcp.Server, redactor *redact.Redactor) func(audit.Event) {
+	return func(e audit.Event) {
+		level := mcp.LogInfo
+		switch e.Level {
+		case audit.LevelWarn:
+			level = mcp.LogWarning
+		case audit.LevelError:
+			level = mcp.LogError
+		}
+
+		data := map[string]any{
+			"operation": e.Operation,
+			"message":   redactor.String(e.Message),
+		}
+		if len(e.Details) > 0 {
+			details := make(map[string]any, len(e.Details))
+			for k, v := range e.Details {
+				if str, ok := v.(string); ok {
+					v = redactor.String(str)
+				}
+				details[k] = v
+			}
+			data["details"] = details
+		}
+		server.Log(level, "audit", data)
+	}
+}
+
+// externalConnectTimeout bounds connecting to an external MCP server and
+// listing its tools.
+const externalConnectTimeout = 30 * time.Second
+
+// connectExternalServers connects to the servers in mcp.servers and
+// registers their tools as <name>_<tool>. A server that can't be reached
+// is reported and skipped so the rest of the tools are still served. The
+// returned function closes the connections.
+func connectExternalServers(ws *workspace.Workspace, reg *tools.Registry) func() {
+	servers := ws.Config.MCP.Servers
+	if len(servers) == 0 {
+		return func() {}
+	}
+	manager, err := loadSecretsFor(ws)
+	if err != nil {
+		manager = secrets.NewManager()
+	}
+
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var clients []*client.Client
+	for _, name := range names {
+		c, count, err := connectExternalServer(ws, reg, manager, name, servers[name])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping MCP server %s: %v\n", name, err)
+			audit.Warn("mcp.external", "Failed to connect to external MCP server", map[string]interface{}{
+				"server": name,
+				"error":  err.Error(),
+			})
+			continue
+		}
+		clients = append(clients, c)
+		audit.Info("mcp.external", "Connected to external MCP server", map[string]interface{}{
+			"server": name,
+			"tools":  count,
+		})
+	}
+	return func() {
+		for _, c := range clients {
+			c.Close()
+		}
+	}
+}
+
+// connectExternalServer connects to one external server and registers its
+// tools, returning the client and how many tools were added.
+func connectExternalServer(ws *workspace.Workspace, reg *tools.Registry, manager *secrets.Manager, name string, srv config.ExternalServer) (*client.Client, int, error) {
+	var c *client.Client
+	if srv.URL != "" {
+		token := ""
+		if srv.TokenEnv != "" {
+			token = manager.Get(srv.TokenEnv)
+		}
+		c = client.NewHTTP(srv.URL, token)
+	} else {
+		cmd := exec.Command(srv.Command[0], srv.Command[1:]...)
+		cmd.Dir = ws.Root
+		cmd.Env = os.Environ()
+		for key, value := range srv.Env {
+			cmd.Env = append(cmd.Env, key+"="+os.Expand(value, manager.Get))
+		}
+		var err error
+		if c, err = client.NewStdio(cmd, os.Stderr); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), externalConnectTimeout)
+	defer cancel()
+	if _, err := c.Initialize(ctx); err != nil {
+		c.Close()
+		return nil, 0, err
+	}
+	proxied, err := c.Proxy(ctx, name+"_", srv.Tools)
+	if err != nil {
+		c.Close()
+		return nil, 0, err
+	}
+
+	count := 0
+	for _, tool := range proxied {
+		if _, err := reg.Get(tool.Name); err == nil {
+			fmt.Fprintf(os.Stderr, "Skipping tool %s from MCP server %s: name already in use\n", tool.Name, name)
+			continue
+		}
+		reg.Register(tool)
+		count++
+	}
+	return c, count, nil
+}
+
+// applyToolLimits sets the registry limits from the mcp config section.
+func applyToolLimits(reg *tools.Registry, cfg config.MCPConfig) {
+	reg.ResetLimits()
+	defaults := tools.DefaultLimits
+	if cfg.Timeout > 0 {
+		defaults.Timeout = cfg.Timeout
+	}
+	if cfg.MaxOutput > 0 {
+		defaults.MaxOutput = cfg.MaxOutput
+	}
+	defaults.MaxConcurrent = cfg.MaxConcurrent
+	reg.SetDefaultLimits(defaults)
+	for name, l := range cfg.Tools {
+		reg.SetLimits(name, tools.Limits{Timeout: l.Timeout, MaxOutput: l.MaxOutput, MaxConcurrent: l.MaxConcurrent})
+	}
+}
+
+// toolExposure returns the tools the mcp config section serves and their
+// names.
+func toolExposure(cfg config.MCPConfig) *tools.Exposure {
+	return &tools.Exposure{
+		Enable:    cfg.Enable,
+		Disable:   cfg.Disable,
+		Rename:    cfg.Rename,
+		Namespace: cfg.Namespace,
+	}
+}
 
-		// Start MCP server on stdio
-		server := mcp.NewServer(toolReg)
-		return server.Serve(os.Stdin, os.Stdout)
+// configReloadInterval is how often flo mcp serve checks config.yaml.
+const configReloadInterval = 2 * time.Second
+
+// reloadCustomTools reports the outcome of reloading .flo/tools/.
+func reloadCustomTools(specs []*tools.CustomToolSpec, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring custom tool change: %v\n", err)
+		audit.Warn("mcp.tools.reload", "Ignored invalid custom tool change", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+	audit.Info("mcp.tools.reload", "Reloaded custom tools", map[string]interface{}{
+		"tools": names,
+	})
+}
+
+// reloadMCPConfig applies a changed config.yaml to a running server. Tool
+// limits take effect for the next call and the tools served are updated,
+// telling clients; other mcp settings and TDD enforcement are reported as
+// needing a restart. An invalid config is ignored.
+func reloadMCPConfig(server *mcp.Server, reg *tools.Registry, cfg *config.Config, changed []string, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring config change: %v\n", err)
+		audit.Warn("config.reload", "Ignored invalid config change", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var applied, restart []string
+	var limits, exposure bool
+	for _, field := range changed {
+		switch {
+		case field == "mcp.timeout", field == "mcp.max_output", field == "mcp.max_concurrent", field == "mcp.tools":
+			applied = append(applied, field)
+			limits = true
+		case field == "mcp.enable", field == "mcp.disable", field == "mcp.rename", field == "mcp.namespace":
+			applied = append(applied, field)
+			exposure = true
+		case strings.HasPrefix(field, "mcp."), field == "tdd.enforce":
+			restart = append(restart, field)
+		}
+	}
+	if limits {
+		applyToolLimits(reg, cfg.MCP)
+	}
+	if exposure {
+		server.SetExposure(toolExposure(cfg.MCP))
+	}
+	if len(restart) > 0 {
+		fmt.Fprintf(os.Stderr, "Config changes need a restart: %s\n", strings.Join(restart, ", "))
+	}
+	audit.Info("config.reload", "Reloaded config", map[string]interface{}{
+		"changed":          changed,
+		"applied":          applied,
+		"restart_required": restart,
+	})
+}
+
+var mcpTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Show the bearer token for the HTTP MCP server",
+	Long: `Show the bearer token HTTP clients must send to 'flo mcp serve --http',
+generating one if the workspace has none. The token is stored as
+FLO_MCP_TOKEN in .flo/.env (kept out of git); the FLO_MCP_TOKEN
+environment variable overrides it.
+
+Configure an HTTP client in Claude Code with:
+
+  {
+    "mcpServers": {
+      "flo": {
+        "type": "http",
+        "url": "http://127.0.0.1:7777/mcp",
+        "headers": {"Authorization": "Bearer <token>"}
+      }
+    }
+  }
+
+--rotate replaces the token; restart running servers to apply it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		token, err := mcpAuthToken(ws, mcpTokenRotate)
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+		return nil
 	},
 }
 
+// mcpAuthToken returns the workspace's MCP bearer token, generating and
+// saving a new one when there is none or rotate is set.
+func mcpAuthToken(ws *workspace.Workspace, rotate bool) (string, error) {
+	manager, err := loadSecretsFor(ws)
+	if err != nil {
+		return "", fmt.Errorf("failed to load secrets: %w", err)
+	}
+	if token := manager.Get(secrets.MCPTokenKey); token != "" && !rotate {
+		return token, nil
+	}
+
+	token, err := secrets.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	if err := secrets.SaveEnvValue(filepath.Join(ws.Root, secrets.WorkspaceEnvFile), secrets.MCPTokenKey, token); err != nil {
+		return "", err
+	}
+	audit.Info("mcp.auth", "MCP token generated", map[string]interface{}{
+		"rotated": rotate,
+	})
+	return token, nil
+}
+
+// errParentExited ends a stdio server whose parent process went away.
+var errParentExited = errors.New("parent process exited")
+
+// orphanContext returns ctx cancelled with errParentExited when flo's parent
+// process exits, as when the editor that started a stdio server crashes
+// without closing its stdin.
+func orphanContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ppid := os.Getppid()
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if os.Getppid() != ppid {
+					cancel(errParentExited)
+					return
+				}
+			}
+		}
+	}()
+	return ctx, func() { cancel(nil) }
+}
+
+// configureHTTPAuth sets the server's bearer token, or checks that serving
+// without one stays on a loopback address.
+func configureHTTPAuth(ws *workspace.Workspace, server *mcp.Server, addr string, noAuth bool) error {
+	if noAuth {
+		return checkLoopback(addr)
+	}
+
+	token, err := mcpAuthToken(ws, false)
+	if err != nil {
+		return err
+	}
+	server.SetAuthToken(token)
+	return nil
+}
+
+// checkLoopback checks that --no-auth serves only on a loopback address.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address '%s': %w", addr, err)
+	}
+	ip := net.ParseIP(host)
+	if host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("--no-auth is only allowed on a loopback address such as 127.0.0.1, not '%s'", addr)
+	}
+	return nil
+}
+
+var (
+	mcpServeHTTP        string
+	mcpServeNoAuth      bool
+	mcpServeIdleTimeout time.Duration
+	mcpTokenRotate      bool
+)
+
 func init() {
+	mcpServeCmd.Flags().StringVar(&mcpServeHTTP, "http", "", "Serve MCP over HTTP on this address (e.g. 127.0.0.1:7777) instead of stdio")
+	mcpServeCmd.Flags().DurationVar(&mcpServeIdleTimeout, "idle-timeout", 0, "Shut down after this long without requests (overrides mcp.idle_timeout; 0 disables)")
+	mcpServeCmd.Flags().BoolVar(&mcpServeNoAuth, "no-auth", false, "Serve HTTP without a bearer token (loopback addresses only)")
+	mcpTokenCmd.Flags().BoolVar(&mcpTokenRotate, "rotate", false, "Replace the token with a new one")
+
 	mcpCmd.AddCommand(mcpServeCmd)
+	mcpCmd.AddCommand(mcpToolsCmd)
+	mcpCmd.AddCommand(mcpTokenCmd)
 	rootCmd.AddCommand(mcpCmd)
 }
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/richgo/flo/pkg/output"
+)
+
+// outputFlag is the global --output flag; outputFormat is its parsed value.
+var (
+	outputFlag   string
+	outputFormat = output.Table
+)
+
+// render prints v in the --output format, or calls table to print it for
+// people.
+func render(v interface{}, table func() error) error {
+	if !outputFormat.Machine() {
+		return table()
+	}
+	return output.Write(os.Stdout, outputFormat, v)
+}
+
+// jsonAlias makes a command's --json flag, which predates --output, a
+// shorthand for --output json.
+func jsonAlias(on bool) {
+	if on {
+		outputFormat = output.JSON
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/richgo/flo/pkg/notify"
+	"github.com/richgo/flo/pkg/preferences"
+	"github.com/spf13/cobra"
+)
+
+var preferencesCmd = &cobra.Command{
+	Use:     "preferences",
+	Aliases: []string{"prefs"},
+	Short:   "Manage user preferences",
+	Long: `Manage per-user preferences stored in ~/.flo/preferences.yaml.
+
+Preferences apply to every workspace on this machine and are not committed
+with the feature configuration.`,
+}
+
+var preferencesShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show current preferences",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefs, err := preferences.LoadDefault()
+		if err != nil {
+			return err
+		}
+		for _, key := range prefs.Keys() {
+			value, _ := prefs.Get(key)
+			fmt.Printf("%s: %s\n", key, value)
+		}
+		return nil
+	},
+}
+
+var preferencesSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a preference",
+	Long: `Set a preference, e.g.
+
+  flo preferences set notifications.enabled false
+  flo preferences set notifications.question true`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := preferences.DefaultPath()
+		if err != nil {
+			return err
+		}
+		prefs, err := preferences.Load(path)
+		if err != nil {
+			return err
+		}
+		if err := prefs.Set(args[0], args[1]); err != nil {
+			return err
+		}
+		if err := prefs.Save(path); err != nil {
+			return err
+		}
+		fmt.Printf("✓ %s = %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+var preferencesTestNotifyCmd = &cobra.Command{
+	Use:   "test-notification",
+	Short: "Send a test desktop notification",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return notify.NewDesktop().Notify(notify.Notification{
+			Title:   "flo",
+			Message: "Desktop notifications are working",
+		})
+	},
+}
+
+func init() {
+	preferencesCmd.AddCommand(preferencesShowCmd)
+	preferencesCmd.AddCommand(preferencesSetCmd)
+	preferencesCmd.AddCommand(preferencesTestNotifyCmd)
+	rootCmd.AddCommand(preferencesCmd)
+}
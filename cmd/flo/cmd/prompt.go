@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Prompt template commands",
+	Long: `Commands for working with agent prompt templates.
+
+Templates are Go text/template files in .flo/prompts/<name>.tmpl. A task uses
+the template named by its task type's "prompt" setting, then a template named
+after its task type, then default.tmpl (or the built-in default).
+
+Templates can reference .Task, .Spec, .Sections, .Repo, .TDD and .Feature,
+and {{.Section "Goal"}} for a single spec section.`,
+}
+
+var promptShowCmd = &cobra.Command{
+	Use:   "show <task-id>",
+	Short: "Render the prompt a task would receive",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		t, err := ws.GetTask(args[0])
+		if err != nil {
+			return err
+		}
+
+		rendered, err := ws.RenderPrompt(t)
+		if err != nil {
+			return err
+		}
+		fmt.Println(rendered)
+		return nil
+	},
+}
+
+var promptEjectCmd = &cobra.Command{
+	Use:   "eject",
+	Short: "Write the built-in prompt to .flo/prompts/default.tmpl for editing",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		path, err := ws.Prompts().Eject()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Wrote %s\n", path)
+		return nil
+	},
+}
+
+func init() {
+	promptCmd.AddCommand(promptShowCmd)
+	promptCmd.AddCommand(promptEjectCmd)
+	rootCmd.AddCommand(promptCmd)
+}
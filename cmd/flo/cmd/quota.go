@@ -4,10 +4,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/cost"
 	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -15,72 +21,288 @@ var quotaCmd = &cobra.Command{
 	Use:   "quota",
 	Short: "Show backend usage and quota status",
 	Long: `Display usage statistics for each AI backend including requests,
-tokens consumed, and remaining quota.`,
+tokens consumed, and remaining quota.
+
+Usage is also tracked per model; --by-model breaks it down. Limits are set
+per backend or per model under quota.limits in .flo/config.yaml, or with
+flo quota set-limit:
+
+  quota:
+    limits:
+      claude: 50          # all claude models together
+      claude/opus: 10     # opus on its own
+    windows:
+      claude: daily       # resets at midnight UTC; also weekly, monthly
+      copilot: 5h         # rolling (default 1h)`,
 	RunE: runQuota,
 }
 
+var quotaShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show backend usage and quota status",
+	Args:  cobra.NoArgs,
+	RunE:  runQuota,
+}
+
+var quotaResetAll bool
+
+var quotaResetCmd = &cobra.Command{
+	Use:   "reset [backend[/model]]",
+	Short: "Clear usage and exhaustion for a backend or model",
+	Long: `Clear the usage recorded for a backend (and all its models) or for a
+single model, making it available again before its window or retry time
+is up. --all clears every backend.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if quotaResetAll == (len(args) == 1) {
+			return fmt.Errorf("specify a backend or backend/model, or --all")
+		}
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		tracker := initQuotaTracker(quotaPath(ws), ws)
+
+		target := "all backends"
+		if quotaResetAll {
+			err = tracker.ResetAll()
+		} else {
+			target = args[0]
+			backend, model, _ := strings.Cut(target, "/")
+			err = tracker.ResetModel(backend, model)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reset quota: %w", err)
+		}
+
+		audit.Info("quota.reset", "Quota usage reset", map[string]interface{}{
+			"target": target,
+		})
+		fmt.Printf("✓ Reset quota usage for %s\n", target)
+		return nil
+	},
+}
+
+var quotaSetLimitCmd = &cobra.Command{
+	Use:   "set-limit <backend[/model]> <requests>",
+	Short: "Set the request limit for a backend or model",
+	Long: `Set how many requests a backend, or one of its models, may make per quota
+window, saved under quota.limits in .flo/config.yaml. A limit of 0 removes
+the configured limit.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		key := args[0]
+		requests, err := strconv.Atoi(args[1])
+		if err != nil || requests < 0 {
+			return fmt.Errorf("requests must be a non-negative number, got '%s'", args[1])
+		}
+
+		if requests == 0 {
+			delete(ws.Config.Quota.Limits, key)
+		} else {
+			if ws.Config.Quota.Limits == nil {
+				ws.Config.Quota.Limits = make(map[string]int)
+			}
+			ws.Config.Quota.Limits[key] = requests
+		}
+		if err := ws.Config.Validate(); err != nil {
+			return err
+		}
+		if err := ws.Save(); err != nil {
+			return err
+		}
+
+		audit.Info("quota.set_limit", "Quota limit changed", map[string]interface{}{
+			"key":      key,
+			"requests": requests,
+		})
+		if requests == 0 {
+			fmt.Printf("✓ Removed the request limit for %s\n", key)
+		} else {
+			fmt.Printf("✓ %s is limited to %d requests per window\n", key, requests)
+		}
+		return nil
+	},
+}
+
+var quotaByModel bool
+
 func init() {
+	quotaCmd.PersistentFlags().BoolVar(&quotaByModel, "by-model", false, "Show usage per backend model")
+	quotaResetCmd.Flags().BoolVar(&quotaResetAll, "all", false, "Reset every backend")
+	quotaCmd.AddCommand(quotaShowCmd)
+	quotaCmd.AddCommand(quotaResetCmd)
+	quotaCmd.AddCommand(quotaSetLimitCmd)
 	rootCmd.AddCommand(quotaCmd)
 }
 
+// quotaPath returns the path to the workspace quota store.
+func quotaPath(ws *workspace.Workspace) string {
+	return filepath.Join(ws.Root, ".flo", "quota.json")
+}
+
+// quotaRow is a backend, or a model with --by-model, in `flo quota show`.
+type quotaRow struct {
+	Backend          string  `json:"backend"`
+	Model            string  `json:"model,omitempty"`
+	Requests         int     `json:"requests"`
+	Limit            int     `json:"limit,omitempty"`
+	Tokens           int     `json:"tokens"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens"`
+	Cost             float64 `json:"cost"`
+	Exhausted        bool    `json:"exhausted"`
+	// RetryAfter is when an exhausted backend or model can be used again.
+	RetryAfter  *time.Time `json:"retry_after,omitempty"`
+	LastRequest *time.Time `json:"last_request,omitempty"`
+	// Window is daily, weekly, monthly, or the length of a rolling window.
+	Window   string    `json:"window"`
+	Rolling  bool      `json:"rolling"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
 func runQuota(cmd *cobra.Command, args []string) error {
-	// Get quota file path from .flo directory
-	homeDir, err := os.UserHomeDir()
+	ws, err := loadWorkspace()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
-	
-	quotaPath := filepath.Join(homeDir, ".flo", "quota.json")
-	tracker := quota.New(quotaPath)
-	
-	// Load existing quota data
-	if err := tracker.Load(); err != nil {
-		return fmt.Errorf("failed to load quota data: %w", err)
-	}
-	
-	// Get all usage data
+	rows := quotaRows(initQuotaTracker(quotaPath(ws), ws), quotaByModel)
+	return render(rows, func() error {
+		printQuota(rows, displayLocation(ws))
+		return nil
+	})
+}
+
+// quotaRows returns the usage of each backend, or each model with byModel,
+// by name.
+func quotaRows(tracker *quota.Tracker, byModel bool) []quotaRow {
 	allUsage := tracker.ListUsage()
-	
-	if len(allUsage) == 0 {
+	if byModel {
+		allUsage = tracker.ListModelUsage()
+	}
+	keys := make([]string, 0, len(allUsage))
+	for key := range allUsage {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := make([]quotaRow, 0, len(keys))
+	for _, key := range keys {
+		usage := allUsage[key]
+		row := quotaRow{
+			Backend:          key,
+			Requests:         usage.Requests,
+			Tokens:           usage.Tokens,
+			InputTokens:      usage.InputTokens,
+			OutputTokens:     usage.OutputTokens,
+			CacheReadTokens:  usage.CacheReadTokens,
+			CacheWriteTokens: usage.CacheWriteTokens,
+			Cost:             usage.Cost,
+			Exhausted:        usage.IsExhausted,
+		}
+		if byModel {
+			row.Backend, row.Model = usage.Backend, usage.Model
+		}
+		if limit, ok := tracker.Limit(key); ok {
+			row.Limit = limit
+		}
+		if usage.IsExhausted {
+			retry := usage.RetryAfter
+			row.RetryAfter = &retry
+		}
+		if !usage.LastRequest.IsZero() {
+			last := usage.LastRequest
+			row.LastRequest = &last
+		}
+		window, resets := tracker.Window(key)
+		row.Window, row.Rolling, row.ResetsAt = window.String(), window.Kind == quota.WindowRolling, resets
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func printQuota(rows []quotaRow, loc *time.Location) {
+	if len(rows) == 0 {
 		fmt.Println("No usage data recorded yet.")
-		return nil
+		return
 	}
-	
+
 	// Create table writer
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
 	defer w.Flush()
-	
-	fmt.Fprintln(w, "BACKEND\tREQUESTS\tTOKENS\tSTATUS\tLAST REQUEST\tWINDOW")
-	fmt.Fprintln(w, "-------\t--------\t------\t------\t------------\t------")
-	
-	for backend, usage := range allUsage {
+
+	if quotaByModel {
+		fmt.Fprintln(w, "BACKEND\tMODEL\tREQUESTS\tTOKENS\tIN/OUT/CACHE R/W\tCOST\tSTATUS\tLAST REQUEST\tWINDOW")
+		fmt.Fprintln(w, "-------\t-----\t--------\t------\t----------------\t----\t------\t------------\t------")
+	} else {
+		fmt.Fprintln(w, "BACKEND\tREQUESTS\tTOKENS\tIN/OUT/CACHE R/W\tCOST\tSTATUS\tLAST REQUEST\tWINDOW")
+		fmt.Fprintln(w, "-------\t--------\t------\t----------------\t----\t------\t------------\t------")
+	}
+
+	for _, row := range rows {
 		status := "✓ OK"
-		if usage.IsExhausted {
-			status = fmt.Sprintf("✗ EXHAUSTED (retry after %s)", 
-				formatDuration(time.Until(usage.RetryAfter)))
+		if row.Exhausted {
+			status = fmt.Sprintf("✗ EXHAUSTED (retry after %s, at %s)",
+				formatDuration(time.Until(*row.RetryAfter)),
+				row.RetryAfter.In(loc).Format("15:04 MST"))
 		}
-		
+
 		lastReq := "never"
-		if !usage.LastRequest.IsZero() {
-			lastReq = formatRelativeTime(usage.LastRequest)
+		if row.LastRequest != nil {
+			lastReq = formatRelativeTime(*row.LastRequest)
+		}
+
+		kind := row.Window
+		if row.Rolling {
+			kind = "rolling " + kind
+		}
+		windowInfo := fmt.Sprintf("%s, resets in %s", kind, formatDuration(time.Until(row.ResetsAt)))
+
+		backend := row.Backend
+		if quotaByModel {
+			backend = row.Backend + "\t" + row.Model
+		}
+		usageCost := "-"
+		if row.Cost > 0 {
+			usageCost = cost.Format(row.Cost)
 		}
-		
-		windowAge := formatDuration(time.Since(usage.WindowStart))
-		
-		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n",
+
+		kinds := fmt.Sprintf("%d/%d/%d/%d", row.InputTokens, row.OutputTokens, row.CacheReadTokens, row.CacheWriteTokens)
+		requests := strconv.Itoa(row.Requests)
+		if row.Limit > 0 {
+			requests = fmt.Sprintf("%d/%d", row.Requests, row.Limit)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
 			backend,
-			usage.Requests,
-			usage.Tokens,
+			requests,
+			row.Tokens,
+			kinds,
+			usageCost,
 			status,
 			lastReq,
-			windowAge,
+			windowInfo,
 		)
 	}
-	
+
 	fmt.Fprintln(w)
-	fmt.Fprintln(w, "Use 'flo config' to set backend limits and quotas.")
-	
-	return nil
+	fmt.Fprintln(w, "Set backend and model limits with flo quota set-limit, or under quota.limits in .flo/config.yaml.")
+}
+
+// displayLocation returns the workspace timezone for displaying times,
+// falling back to the system zone when it is unset or invalid.
+func displayLocation(ws *workspace.Workspace) *time.Location {
+	loc, err := ws.Config.Location()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using system timezone\n", err)
+		return time.Local
+	}
+	return loc
 }
 
 func formatRelativeTime(t time.Time) string {
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/repos"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repoAddBranch string
+	repoAddPath   string
+	repoAddClone  bool
+)
+
+var repoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "Manage the repositories of a multi-repo workspace",
+	Long: `Commands for linking repositories to the workspace. A task whose repo field
+names a linked repository runs in that repository's checkout: the agent
+works there, and the TDD gate, verify pipeline and gates run there.`,
+}
+
+var repoAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Link a repository to the workspace",
+	Long: `Link a repository under name, saved to repos in .flo/config.yaml. It is
+checked out at --path (relative to the workspace root) or .flo/repos/<name>;
+--clone clones it there at once, otherwise run 'flo repo clone'.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		name := args[0]
+		if err := ws.AddRepo(name, config.Repo{URL: args[1], Branch: repoAddBranch, Path: repoAddPath}); err != nil {
+			return withExitCode(ExitValidation, err)
+		}
+		fmt.Printf("✓ Linked %s (%s)\n", name, args[1])
+		if repoAddClone {
+			return cloneRepos(ws, []string{name})
+		}
+		if !repos.Cloned(ws.RepoPath(name)) {
+			fmt.Printf("  Run 'flo repo clone %s' to check it out\n", name)
+		}
+		return nil
+	},
+}
+
+// repoEntry is a linked repository as 'flo repo list' reports it.
+type repoEntry struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Branch string `json:"branch,omitempty"`
+	Path   string `json:"path"`
+	Cloned bool   `json:"cloned"`
+	Tasks  int    `json:"tasks"`
+}
+
+var repoListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List linked repositories",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		entries := []repoEntry{}
+		for _, name := range repoNames(ws) {
+			r := ws.Config.Repos[name]
+			entries = append(entries, repoEntry{
+				Name:   name,
+				URL:    r.URL,
+				Branch: r.Branch,
+				Path:   relPath(ws, ws.RepoPath(name)),
+				Cloned: repos.Cloned(ws.RepoPath(name)),
+				Tasks:  len(ws.Tasks.ListByRepo(name)),
+			})
+		}
+		return render(entries, func() error {
+			if len(entries) == 0 {
+				fmt.Println("No repositories linked. Add one with 'flo repo add <name> <url>'.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tURL\tBRANCH\tPATH\tCLONED\tTASKS")
+			for _, e := range entries {
+				cloned := "no"
+				if e.Cloned {
+					cloned = "yes"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", e.Name, e.URL, e.Branch, e.Path, cloned, e.Tasks)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+var repoCloneCmd = &cobra.Command{
+	Use:   "clone [name...]",
+	Short: "Clone linked repositories that aren't checked out yet",
+	Long: `Clone the named linked repositories, or every one, into their paths.
+Repositories already checked out are left as they are.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		names := args
+		if len(names) == 0 {
+			names = repoNames(ws)
+		}
+		for _, name := range names {
+			if _, ok := ws.Config.Repos[name]; !ok {
+				return withExitCode(ExitValidation, fmt.Errorf("unknown repo '%s' (flo repo list)", name))
+			}
+		}
+		if len(names) == 0 {
+			fmt.Println("No repositories linked. Add one with 'flo repo add <name> <url>'.")
+			return nil
+		}
+		return cloneRepos(ws, names)
+	},
+}
+
+// repoStatus is the state of a linked repository's checkout.
+type repoStatus struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Cloned bool   `json:"cloned"`
+	// Expected is the configured branch, when it differs from the one
+	// checked out.
+	Expected string `json:"expected_branch,omitempty"`
+	repos.Status
+	Error string `json:"error,omitempty"`
+}
+
+var repoStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the branch and changes of each linked repository",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		git := repos.New()
+		statuses := []repoStatus{}
+		for _, name := range repoNames(ws) {
+			dir := ws.RepoPath(name)
+			st := repoStatus{Name: name, Path: relPath(ws, dir), Cloned: repos.Cloned(dir)}
+			if st.Cloned {
+				if s, err := git.Status(context.Background(), dir); err != nil {
+					st.Error = err.Error()
+				} else {
+					st.Status = s
+				}
+				if want := ws.Config.Repos[name].Branch; want != "" && want != st.Branch {
+					st.Expected = want
+				}
+			}
+			statuses = append(statuses, st)
+		}
+		return render(statuses, func() error {
+			if len(statuses) == 0 {
+				fmt.Println("No repositories linked. Add one with 'flo repo add <name> <url>'.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tBRANCH\tCOMMIT\tCHANGED\tUPSTREAM\tNOTE")
+			for _, st := range statuses {
+				switch {
+				case !st.Cloned:
+					fmt.Fprintf(w, "%s\t-\t-\t-\t-\tnot cloned (flo repo clone %s)\n", st.Name, st.Name)
+				case st.Error != "":
+					fmt.Fprintf(w, "%s\t-\t-\t-\t-\t%s\n", st.Name, st.Error)
+				default:
+					upstream := "-"
+					if st.Upstream != "" {
+						upstream = fmt.Sprintf("%s +%d -%d", st.Upstream, st.Ahead, st.Behind)
+					}
+					note := ""
+					if st.Expected != "" {
+						note = fmt.Sprintf("expected branch %s", st.Expected)
+					}
+					branch := st.Branch
+					if branch == "" {
+						branch = "(detached)"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", st.Name, branch, st.Commit, st.Changed, upstream, note)
+				}
+			}
+			return w.Flush()
+		})
+	},
+}
+
+func init() {
+	repoAddCmd.Flags().StringVar(&repoAddBranch, "branch", "", "Branch to check out")
+	repoAddCmd.Flags().StringVar(&repoAddPath, "path", "", "Checkout path, relative to the workspace root (default .flo/repos/<name>)")
+	repoAddCmd.Flags().BoolVar(&repoAddClone, "clone", false, "Clone the repository now")
+	repoCmd.AddCommand(repoAddCmd, repoListCmd, repoCloneCmd, repoStatusCmd)
+	rootCmd.AddCommand(repoCmd)
+}
+
+// cloneRepos clones the named repos that aren't checked out yet.
+func cloneRepos(ws *workspace.Workspace, names []string) error {
+	git := repos.New()
+	for _, name := range names {
+		dir := ws.RepoPath(name)
+		if repos.Cloned(dir) {
+			fmt.Printf("  %s already cloned at %s\n", name, relPath(ws, dir))
+			continue
+		}
+		r := ws.Config.Repos[name]
+		fmt.Printf("📥 Cloning %s into %s...\n", name, relPath(ws, dir))
+		if err := git.Clone(context.Background(), r.URL, r.Branch, dir); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Cloned %s\n", name)
+	}
+	return nil
+}
+
+// repoNames returns the linked repos' names in order.
+func repoNames(ws *workspace.Workspace) []string {
+	names := make([]string, 0, len(ws.Config.Repos))
+	for name := range ws.Config.Repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// relPath returns path relative to the workspace root when it is inside it.
+func relPath(ws *workspace.Workspace, path string) string {
+	if rel, err := filepath.Rel(ws.Root, path); err == nil && filepath.IsLocal(rel) {
+		return rel
+	}
+	return path
+}
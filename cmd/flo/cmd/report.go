@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/richgo/flo/pkg/coverage"
+	"github.com/richgo/flo/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportOpenPrint      bool
+	reportBurndownSVG    string
+	reportBurndownWindow int
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Feature reports",
+	Long:  `Commands for reporting on the progress and quality impact of a feature.`,
+}
+
+var reportCoverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Summarize coverage impact of the feature",
+	Long: `Show the coverage change recorded for each task and the overall
+coverage impact of the feature.
+
+Coverage is measured after each agent run when tdd.coverage_profile is set
+in .flo/config.yaml (go coverprofile, lcov, or cobertura format).`,
+	RunE: runReportCoverage,
+}
+
+var reportOpenCmd = &cobra.Command{
+	Use:   "open",
+	Short: "Open the latest run report",
+	Long: `Open the report of the latest 'flo work' run in the default browser.
+
+Every run writes a report to .flo/reports/ as Markdown and HTML: the
+backends tried, durations, retries, tokens and cost, test and gate results,
+and the changes in the worktree. --print prints the Markdown instead, as
+happens when no browser can be opened.`,
+	Args: cobra.NoArgs,
+	RunE: runReportOpen,
+}
+
+var reportBurndownCmd = &cobra.Command{
+	Use:   "burndown",
+	Short: "Show tasks completed per day and projected completion",
+	Long: `Show the feature's burndown: tasks completed each day, tasks remaining at
+the end of each day, the velocity over the last --window days and the date
+the remaining tasks would be done at that velocity.
+
+Days are in the configured timezone. --output json exports the figures, and
+--svg writes them as a chart ("-" for stdout).`,
+	Args: cobra.NoArgs,
+	RunE: runReportBurndown,
+}
+
+func init() {
+	reportBurndownCmd.Flags().StringVar(&reportBurndownSVG, "svg", "", "Write the burndown as an SVG chart to this file (- for stdout)")
+	reportBurndownCmd.Flags().IntVar(&reportBurndownWindow, "window", report.DefaultVelocityWindow, "Days to average velocity over")
+	reportCmd.AddCommand(reportBurndownCmd)
+	reportOpenCmd.Flags().BoolVar(&reportOpenPrint, "print", false, "Print the report's Markdown instead of opening it")
+	reportCmd.AddCommand(reportOpenCmd)
+	reportCmd.AddCommand(reportCoverageCmd)
+	rootCmd.AddCommand(reportCmd)
+}
+
+func runReportCoverage(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	store := coverage.NewStore(coveragePath(ws))
+	if err := store.Load(); err != nil {
+		return err
+	}
+
+	deltas := store.List()
+	if len(deltas) == 0 {
+		fmt.Println("No coverage data recorded yet.")
+		if ws.Config.TDD.CoverageProfile == "" {
+			fmt.Println("Set tdd.coverage_profile in .flo/config.yaml to measure coverage per task.")
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "TASK\tTITLE\tBEFORE\tAFTER\tCHANGE")
+	fmt.Fprintln(w, "----\t-----\t------\t-----\t------")
+	for _, d := range deltas {
+		title := ""
+		if t, err := ws.GetTask(d.TaskID); err == nil {
+			title = t.Title
+		}
+		before := "-"
+		if d.HasBefore {
+			before = fmt.Sprintf("%.1f%%", d.Before)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.1f%%\t%+.1f\n", d.TaskID, title, before, d.After, d.Change())
+	}
+	w.Flush()
+
+	summary := store.Summarize()
+	fmt.Println()
+	fmt.Printf("Feature coverage: %.1f%% → %.1f%% (%+.1f points across %d tasks)\n",
+		summary.Baseline, summary.Current, summary.Change, summary.Tasks)
+
+	return nil
+}
+
+func runReportOpen(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+	path, err := report.Latest(ws.ReportDir())
+	if errors.Is(err, report.ErrNoReports) {
+		fmt.Println("No run reports yet; 'flo work' writes one after each run.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	page := strings.TrimSuffix(path, ".md") + ".html"
+	if !reportOpenPrint && openInBrowser(page) == nil {
+		fmt.Printf("Opened %s\n", page)
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+// openInBrowser opens a file with the desktop's default application.
+func openInBrowser(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", path)
+	case "windows":
+		c = exec.Command("cmd", "/c", "start", "", path)
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return errors.New("no display")
+		}
+		c = exec.Command("xdg-open", path)
+	}
+	return c.Run()
+}
+
+func runReportBurndown(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+	if reportBurndownWindow < 1 {
+		return withExitCode(ExitValidation, fmt.Errorf("--window must be at least 1"))
+	}
+	loc, err := ws.Config.Location()
+	if err != nil {
+		return err
+	}
+	b := report.NewBurndown(ws.ListTasks("", ""), time.Now(), loc, reportBurndownWindow)
+
+	if reportBurndownSVG == "-" {
+		fmt.Print(b.SVG())
+		return nil
+	}
+	if reportBurndownSVG != "" {
+		if err := os.WriteFile(reportBurndownSVG, []byte(b.SVG()), 0644); err != nil {
+			return fmt.Errorf("failed to write chart: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "✓ Wrote %s\n", reportBurndownSVG)
+	}
+
+	return render(b, func() error {
+		if b.Total == 0 {
+			fmt.Println("No tasks yet.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "DATE\tCOMPLETED\tREMAINING")
+		for _, d := range b.Days {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", d.Date, d.Completed, d.Remaining)
+		}
+		w.Flush()
+
+		fmt.Println()
+		fmt.Printf("%d of %d tasks complete, %d remaining\n", b.Completed, b.Total, b.Remaining)
+		fmt.Printf("Velocity: %.1f tasks/day over the last %d day(s)\n", b.Velocity, min(b.Window, len(b.Days)))
+		switch {
+		case b.Remaining == 0:
+			fmt.Println("All tasks are complete.")
+		case b.Projected != nil:
+			fmt.Printf("Projected completion: %s\n", b.Projected.Format("2006-01-02"))
+		default:
+			fmt.Println("Projected completion: unknown (nothing completed recently)")
+		}
+		return nil
+	})
+}
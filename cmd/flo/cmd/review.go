@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/richgo/flo/pkg/approval"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/tui"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewPrint    bool
+	reviewApprove  bool
+	reviewReject   bool
+	reviewReason   string
+	reviewFeedback string
+	reviewNoCommit bool
+	reviewNoRun    bool
+	reviewRejected []string
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review <task-id>",
+	Short: "Review the changes of a task awaiting approval",
+	Long: `Show the diff of a task whose type has approval: required and decide on it.
+
+flo work holds such a task's changes for review instead of completing it.
+In a terminal the diff opens in a viewer:
+
+  j/k, arrows   scroll
+  space, b      page down, up
+  n/p           next, previous file
+  y/x           accept, reject the file
+  a             approve the files not rejected
+  r             reject (optional reason)
+  f             send back with feedback for the agent
+  q             quit
+
+Approving reverts the changes to rejected files, then completes the task
+and commits the rest as "<task id>: <title>" (--no-commit leaves them
+uncommitted). Rejecting fails the task, so 'flo task retry' can run it
+again.
+
+Sending back keeps the changes and reopens the task: flo work runs the
+agent again at once (--no-run leaves that to you), with the feedback and
+any rejected files in its prompt as a follow-up.
+
+--approve, --reject and --feedback decide without the viewer, with
+--reject-file for each rejected file; --print (or running without a
+terminal) prints the diff.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		t, err := ws.GetTask(args[0])
+		if err != nil {
+			return err
+		}
+		req, err := ws.Review(t.ID)
+		if err != nil {
+			return err
+		}
+		if req == nil {
+			return fmt.Errorf("task %s has no changes awaiting review", t.ID)
+		}
+
+		decisions := 0
+		for _, set := range []bool{reviewApprove, reviewReject, reviewFeedback != ""} {
+			if set {
+				decisions++
+			}
+		}
+		switch {
+		case decisions > 1:
+			return withExitCode(ExitValidation, fmt.Errorf("use only one of --approve, --reject and --feedback"))
+		case reviewApprove:
+			if err := decideReview(ws, t, tui.DecisionApprove, "", reviewRejected); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Approved %s; task complete\n", t.ID)
+			return nil
+		case reviewReject:
+			if err := decideReview(ws, t, tui.DecisionReject, reviewReason, nil); err != nil {
+				return err
+			}
+			fmt.Printf("Rejected %s (flo task retry %s)\n", t.ID, t.ID)
+			return nil
+		case reviewFeedback != "":
+			if err := decideReview(ws, t, tui.DecisionChanges, reviewFeedback, reviewRejected); err != nil {
+				return err
+			}
+			fmt.Printf("Sent %s back with feedback\n", t.ID)
+			return continueWork(ws, t.ID)
+		}
+
+		if !reviewPrint && !outputFormat.Machine() && req.Status == approval.StatusPending {
+			term, err := tui.OpenTerminal(os.Stdout)
+			if err == nil {
+				screen := tui.NewReviewScreen(req, func(decision tui.Decision, text string, rejected []string) error {
+					return decideReview(ws, t, decision, text, rejected)
+				})
+				err := tui.RunReviewScreen(term, screen)
+				term.Close()
+				if err != nil || screen.Decision() != tui.DecisionChanges {
+					return err
+				}
+				return continueWork(ws, t.ID)
+			}
+			if !errors.Is(err, tui.ErrNoTTY) {
+				return err
+			}
+		}
+		return render(req, func() error {
+			fmt.Printf("Review %s: %s (%s, %s)\n", t.ID, req.Summary, req.ID, req.Status)
+			for _, g := range req.Gates {
+				mark := "✓"
+				if !g.Passed {
+					mark = "✗"
+				}
+				fmt.Printf("   %s %s\n", mark, g.Name)
+			}
+			if req.Feedback != "" {
+				fmt.Printf("   Feedback: %s\n", req.Feedback)
+			}
+			fmt.Println()
+			if req.Diff == "" {
+				fmt.Println("(no changes)")
+			} else {
+				fmt.Print(req.Diff)
+			}
+			if req.Status == approval.StatusPending {
+				fmt.Printf("\nApprove with 'flo review %s --approve', or --reject / --feedback \"...\"\n", t.ID)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	reviewCmd.Flags().BoolVar(&reviewPrint, "print", false, "Print the diff instead of opening the viewer")
+	reviewCmd.Flags().BoolVar(&reviewApprove, "approve", false, "Approve the changes")
+	reviewCmd.Flags().BoolVar(&reviewReject, "reject", false, "Reject the changes")
+	reviewCmd.Flags().StringVar(&reviewReason, "reason", "", "Reason for rejection")
+	reviewCmd.Flags().StringVar(&reviewFeedback, "feedback", "", "Send the changes back with feedback for the agent")
+	reviewCmd.Flags().StringArrayVar(&reviewRejected, "reject-file", nil, "Reject the changes to a file, relative to the repository (repeatable)")
+	reviewCmd.Flags().BoolVar(&reviewNoCommit, "no-commit", false, "Leave approved changes uncommitted")
+	reviewCmd.Flags().BoolVar(&reviewNoRun, "no-run", false, "Don't run the agent again after sending changes back")
+	rootCmd.AddCommand(reviewCmd)
+}
+
+// decideReview records a reviewer's decision on a task's pending review,
+// committing approved changes unless --no-commit is given.
+func decideReview(ws *workspace.Workspace, t *task.Task, decision tui.Decision, text string, rejected []string) error {
+	switch decision {
+	case tui.DecisionApprove:
+		return ws.ApproveReview(t, reviewerName(), !reviewNoCommit, rejected)
+	case tui.DecisionReject:
+		return ws.RejectReview(t, reviewerName(), text)
+	case tui.DecisionChanges:
+		return ws.RequestReviewChanges(t, reviewerName(), text, rejected)
+	}
+	return fmt.Errorf("unknown decision '%s'", decision)
+}
+
+// continueWork runs the agent again on a task sent back with feedback,
+// as 'flo work' in this terminal, unless --no-run is given.
+func continueWork(ws *workspace.Workspace, taskID string) error {
+	if reviewNoRun {
+		fmt.Printf("Run 'flo work %s' to continue with the feedback\n", taskID)
+		return nil
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the flo executable: %w", err)
+	}
+	c := exec.Command(executable, "work", taskID)
+	c.Dir = ws.Root
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return withExitCode(exitErr.ExitCode(), fmt.Errorf("flo work %s failed", taskID))
+		}
+		return err
+	}
+	return nil
+}
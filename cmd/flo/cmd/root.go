@@ -1,9 +1,20 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/output"
+	"github.com/richgo/flo/pkg/secrets"
+	"github.com/richgo/flo/pkg/telemetry"
+	"github.com/richgo/flo/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
+var offlineMode bool
+
 var rootCmd = &cobra.Command{
 	Use:   "flo",
 	Short: "Flo - Engineer Flow for AI-powered development",
@@ -12,14 +23,87 @@ test-driven development.
 
 Create tasks, define specs, and let AI agents implement them while
 you stay in the zone.`,
+	// Execute prints errors once secrets are scrubbed from them
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if offlineMode {
+			offline.Set(true)
+		}
+		// Credential-like environment variables are scrubbed from audit
+		// events until a command loads its secrets
+		useRedactor(secrets.NewManager().Redactor())
+
+		format, err := output.Parse(outputFlag)
+		if err != nil {
+			return err
+		}
+		outputFormat = format
+		silenceStdout()
+		return nil
+	},
 }
 
-// Execute runs the root command.
+// Execute runs the root command. Secret values are scrubbed from the
+// error it returns; ExitCode gives the exit code for it.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	runExitHooks()
+	closeWorkspaces()
+	restoreStdout()
+	if err == nil {
+		return nil
+	}
+	return withExitCode(ExitCode(err), errors.New(secretRedactor().String(err.Error())))
+}
+
+// openWorkspaces are the workspaces the command has opened, closed on exit.
+var openWorkspaces []*workspace.Workspace
+
+// trackWorkspace closes ws when the command exits, exporting its
+// telemetry.
+func trackWorkspace(ws *workspace.Workspace) *workspace.Workspace {
+	openWorkspaces = append(openWorkspaces, ws)
+	return ws
+}
+
+// exitHooks are run when the command exits, latest first.
+var exitHooks []func()
+
+// atExit runs f when the command exits, before its workspaces are closed.
+func atExit(f func()) {
+	exitHooks = append(exitHooks, f)
+}
+
+// runExitHooks runs the functions registered with atExit.
+func runExitHooks() {
+	for i := len(exitHooks) - 1; i >= 0; i-- {
+		exitHooks[i]()
+	}
+	exitHooks = nil
+}
+
+// closeWorkspaces closes the workspaces the command opened. Failing to
+// export telemetry doesn't fail the command.
+func closeWorkspaces() {
+	for _, ws := range openWorkspaces {
+		if err := ws.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	openWorkspaces = nil
 }
 
 func init() {
+	telemetry.ServiceVersion = version
+	rootCmd.PersistentFlags().BoolVar(&offlineMode, "offline", false,
+		"Fail fast on anything that needs the network (also FLO_OFFLINE=1)")
+	rootCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", "table",
+		"Output format: table, json or yaml")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
+		"Print only errors; check the exit code for the outcome")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return withExitCode(ExitValidation, err)
+	})
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(taskCmd)
 	rootCmd.AddCommand(statusCmd)
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/richgo/flo/pkg/approval"
+	"github.com/richgo/flo/pkg/correlation"
+	"github.com/richgo/flo/pkg/journal"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var runResume string
+
+var runCmd = &cobra.Command{
+	Use:   "run [task-id...]",
+	Short: "Work through the ready tasks one after another",
+	Long: `Run the agent on each ready task in priority order, as flo work does,
+until no ready task is left; with task IDs, only on those tasks. Tasks
+that become ready as others complete are picked up too. A task the run
+leaves failed isn't attempted again; retry it with flo task retry.
+
+The run's progress is saved in .flo/runs/<run-id>.json as it goes. After a
+crash or Ctrl-C, 'flo run --resume <run-id>' picks up where it stopped: it
+skips the tasks the run completed, failed or left awaiting review, and
+claims again the tasks it left in progress.
+
+flo run stops when every backend it could use is out of quota, exiting with
+4; resume it once the quota resets. It exits with 3 when it left a task
+failed.
+
+Examples:
+  flo run
+  flo run t-001 t-004
+  flo run --resume run-1f0c2a9e4b7d3c85`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if runResume != "" && len(args) > 0 {
+			return withExitCode(ExitValidation, fmt.Errorf("--resume takes no task IDs: the run keeps its own"))
+		}
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		var j *journal.Journal
+		if runResume != "" {
+			if j, err = journal.Load(ws.RunsDir(), runResume); err != nil {
+				return err
+			}
+			if j.Finished != nil {
+				return fmt.Errorf("run %s already finished", j.ID)
+			}
+			if err := requeueInterrupted(ws, j); err != nil {
+				return err
+			}
+			fmt.Printf("↻ Resuming run %s\n", j.ID)
+		} else {
+			for _, id := range args {
+				if _, err := ws.GetTask(id); err != nil {
+					return err
+				}
+			}
+			if j, err = journal.Create(ws.RunsDir(), correlation.NewRunID(), args); err != nil {
+				return err
+			}
+			fmt.Printf("▶ Run %s (resume with: flo run --resume %s)\n", j.ID, j.ID)
+		}
+		return runTasks(ws, j)
+	},
+}
+
+// requeueInterrupted returns the tasks a run left in progress to pending,
+// so the resumed run claims them again. Tasks awaiting review are left
+// for their reviewer.
+func requeueInterrupted(ws *workspace.Workspace, j *journal.Journal) error {
+	for _, id := range j.Unsettled() {
+		t, err := ws.GetTask(id)
+		if err != nil || t.Status != task.StatusInProgress {
+			continue
+		}
+		if req, err := ws.Review(id); err == nil && req != nil && req.Status == approval.StatusPending {
+			continue
+		}
+		if _, err := ws.RequeueTask(id); err != nil {
+			return fmt.Errorf("failed to requeue task %s: %w", id, err)
+		}
+		fmt.Printf("↻ Task %s was interrupted; claiming it again\n", id)
+	}
+	return nil
+}
+
+// runTasks works through the run's ready tasks, recording each attempt in
+// its journal. The workspace is loaded once for the whole run, so its
+// webhooks are sent once per event, and reloaded before each task to pick
+// up changes other flo processes made.
+func runTasks(ws *workspace.Workspace, j *journal.Journal) error {
+	attempted := make(map[string]bool)
+	failed := 0
+	for {
+		if err := ws.Reload(); err != nil {
+			return err
+		}
+		t := nextRunTask(ws, j, attempted)
+		if t == nil {
+			break
+		}
+		attempted[t.ID] = true
+
+		if err := j.Start(t.ID); err != nil {
+			return err
+		}
+		err := runWork(ws, t.ID, "")
+		outcome := runOutcome(ws, t.ID, err)
+		if jerr := j.Finish(t.ID, outcome, err); jerr != nil {
+			return jerr
+		}
+		switch {
+		case ExitCode(err) == ExitQuotaExhausted:
+			return withExitCode(ExitQuotaExhausted, fmt.Errorf("run %s stopped: %w; resume it with: flo run --resume %s", j.ID, err, j.ID))
+		case outcome == journal.OutcomeFailed:
+			failed++
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "⚠️  Task %s: %v\n", t.ID, err)
+		}
+	}
+
+	if err := j.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("\n🏁 Run %s finished: %d task(s) attempted\n", j.ID, len(attempted))
+	if failed > 0 {
+		return withExitCode(ExitTasksFailed, fmt.Errorf("run %s left %d task(s) failed", j.ID, failed))
+	}
+	return nil
+}
+
+// nextRunTask returns the run's next ready task, highest priority first,
+// skipping tasks this invocation attempted and those the journal settled.
+func nextRunTask(ws *workspace.Workspace, j *journal.Journal, attempted map[string]bool) *task.Task {
+	for _, t := range ws.GetReadyTasks() {
+		if !j.Includes(t.ID) || attempted[t.ID] {
+			continue
+		}
+		if outcome, ok := j.Outcome(t.ID); ok && outcome.Settled() {
+			continue
+		}
+		return t
+	}
+	return nil
+}
+
+// runOutcome returns how an attempt at a task ended, from the status flo
+// work left it in and the error it returned.
+func runOutcome(ws *workspace.Workspace, taskID string, err error) journal.Outcome {
+	if rerr := ws.Reload(); rerr != nil {
+		return journal.OutcomeInterrupted
+	}
+	t, gerr := ws.GetTask(taskID)
+	if gerr != nil {
+		return journal.OutcomeInterrupted
+	}
+	switch t.Status {
+	case task.StatusComplete:
+		return journal.OutcomeComplete
+	case task.StatusFailed:
+		return journal.OutcomeFailed
+	case task.StatusInProgress:
+		if err == nil {
+			return journal.OutcomeReview
+		}
+		return journal.OutcomeInterrupted
+	default:
+		return journal.OutcomeSkipped
+	}
+}
+
+func init() {
+	runCmd.Flags().StringVar(&runResume, "resume", "", "Resume an interrupted run by its ID")
+	rootCmd.AddCommand(runCmd)
+}
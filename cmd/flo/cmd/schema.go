@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/richgo/flo/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "JSON Schemas for workspace files",
+	Long: `JSON Schemas for the workspace files you edit by hand, so editors can
+offer completion and validation. The schemas are generated from the types
+flo reads the files into and are published with each release.
+
+With the YAML language server, point a file at its schema with a comment:
+
+  # yaml-language-server: $schema=` + schema.ReleaseURL + `config.schema.json`,
+}
+
+var schemaListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available schemas",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tFILES\tDESCRIPTION")
+		for _, d := range schema.Documents() {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", d.Name, strings.Join(d.Files, ", "), d.Description)
+		}
+		return w.Flush()
+	},
+}
+
+var schemaDumpOut string
+
+var schemaDumpCmd = &cobra.Command{
+	Use:   "dump [name]",
+	Short: "Print a schema, or write every schema to a directory",
+	Long: `Print the named schema to stdout, or with --out write schemas to
+<dir>/<name>.schema.json — every schema unless a name is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		docs := schema.Documents()
+		if len(args) == 1 {
+			d, err := schema.Lookup(args[0])
+			if err != nil {
+				return err
+			}
+			docs = []schema.Document{d}
+		}
+
+		if schemaDumpOut == "" {
+			if len(docs) != 1 {
+				return fmt.Errorf("name a schema to print (one of: %s) or use --out", strings.Join(schema.Names(), ", "))
+			}
+			data, err := docs[0].JSON()
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+
+		if err := os.MkdirAll(schemaDumpOut, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", schemaDumpOut, err)
+		}
+		for _, d := range docs {
+			data, err := d.JSON()
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(schemaDumpOut, d.FileName())
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			fmt.Printf("✓ Wrote %s\n", path)
+		}
+		return nil
+	},
+}
+
+func init() {
+	schemaDumpCmd.Flags().StringVar(&schemaDumpOut, "out", "", "Directory to write <name>.schema.json files to")
+
+	schemaCmd.AddCommand(schemaListCmd)
+	schemaCmd.AddCommand(schemaDumpCmd)
+	rootCmd.AddCommand(schemaCmd)
+}
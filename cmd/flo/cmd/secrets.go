@@ -0,0 +1,543 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/authcheck"
+	"github.com/richgo/flo/pkg/redact"
+	"github.com/richgo/flo/pkg/secrets"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretsCheckBackends []string
+	secretsCheckAll      bool
+	secretsEncrypted     bool
+	secretsInitEncrypt   bool
+	secretsAgeRecipients []string
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Store API keys in the OS keyring and check secret providers",
+	Long: `Store secrets such as API keys in the operating system's keyring — the
+macOS Keychain, the Windows Credential Manager, or the Linux secret service
+(through secret-tool) — instead of plaintext .env files.
+
+Stored secrets are loaded by every flo command and exported to the
+backends it starts. The environment and .env files take precedence, so a
+workspace can still override a key.
+
+Workspaces can instead resolve secrets from HashiCorp Vault, AWS Secrets
+Manager or 1Password by listing providers under secrets.providers in
+.flo/config.yaml; see 'flo secrets check'. Workspace credentials can also
+be kept in an encrypted .flo/secrets file; see 'flo secrets init'.`,
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <NAME> [value]",
+	Short: "Store a secret (reads the value from stdin if not given)",
+	Long: `Store a secret in the keyring. Without a value argument the value is read
+from the first line of stdin, which keeps it out of your shell history:
+
+  flo secrets set CLAUDE_API_KEY < key.txt
+
+With --encrypted the secret goes in the workspace's encrypted secrets file
+instead (see 'flo secrets init').`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		var store *secrets.EncryptedStore
+		if secretsEncrypted {
+			var err error
+			if store, err = openEncryptedStore(); err != nil {
+				return err
+			}
+		}
+
+		var value string
+		if len(args) == 2 {
+			value = args[1]
+		} else {
+			if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+				fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+			}
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return fmt.Errorf("failed to read value from stdin: %w", err)
+			}
+			value = strings.TrimRight(line, "\r\n")
+		}
+		if value == "" {
+			return fmt.Errorf("empty value for %s (use flo secrets delete to remove it)", name)
+		}
+
+		if store != nil {
+			if err := store.Set(name, value); err != nil {
+				return err
+			}
+			if err := store.Save(); err != nil {
+				return err
+			}
+			audit.Info("secrets.set", "Secret stored in encrypted file", map[string]interface{}{
+				"name": name,
+				"file": secrets.EncryptedFile,
+			})
+			fmt.Printf("✓ Stored %s in %s\n", name, secrets.EncryptedFile)
+			return nil
+		}
+
+		if err := secrets.StoreSecret(secrets.DefaultKeyring(), name, value); err != nil {
+			return keyringError(name, err)
+		}
+		audit.Info("secrets.set", "Secret stored in keyring", map[string]interface{}{
+			"name": name,
+		})
+		fmt.Printf("✓ Stored %s in the keyring\n", name)
+		if _, err := os.Stat(secrets.WorkspaceEnvFile); err == nil {
+			if m := secrets.NewManager(); m.LoadEnvFile(secrets.WorkspaceEnvFile) == nil && contains(m.List(), name) {
+				fmt.Printf("  %s also sets %s and takes precedence; remove it there to use the keyring\n", secrets.WorkspaceEnvFile, name)
+			}
+		}
+		return nil
+	},
+}
+
+var secretsGetCmd = &cobra.Command{
+	Use:   "get <NAME>",
+	Short: "Print a secret stored in the keyring",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := secrets.ValidateName(args[0]); err != nil {
+			return err
+		}
+		if secretsEncrypted {
+			store, err := openEncryptedStore()
+			if err != nil {
+				return err
+			}
+			value, err := store.Get(args[0])
+			if err != nil {
+				return fmt.Errorf("%s is not stored in %s", args[0], secrets.EncryptedFile)
+			}
+			fmt.Println(value)
+			return nil
+		}
+		value, err := secrets.DefaultKeyring().Get(args[0])
+		if err != nil {
+			return keyringError(args[0], err)
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var secretsDeleteCmd = &cobra.Command{
+	Use:   "delete <NAME>",
+	Short: "Remove a secret from the keyring",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if secretsEncrypted {
+			store, err := openEncryptedStore()
+			if err != nil {
+				return err
+			}
+			if err := store.Delete(args[0]); err != nil {
+				return fmt.Errorf("%s is not stored in %s", args[0], secrets.EncryptedFile)
+			}
+			if err := store.Save(); err != nil {
+				return err
+			}
+			audit.Info("secrets.delete", "Secret removed from encrypted file", map[string]interface{}{
+				"name": args[0],
+				"file": secrets.EncryptedFile,
+			})
+			fmt.Printf("✓ Removed %s from %s\n", args[0], secrets.EncryptedFile)
+			return nil
+		}
+		if err := secrets.DeleteSecret(secrets.DefaultKeyring(), args[0]); err != nil {
+			return keyringError(args[0], err)
+		}
+		audit.Info("secrets.delete", "Secret removed from keyring", map[string]interface{}{
+			"name": args[0],
+		})
+		fmt.Printf("✓ Removed %s from the keyring\n", args[0])
+		return nil
+	},
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the secrets stored in the keyring (names only)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var names []string
+		where := "the keyring"
+		if secretsEncrypted {
+			store, err := openEncryptedStore()
+			if err != nil {
+				return err
+			}
+			names, where = store.Names(), secrets.EncryptedFile
+		} else {
+			var err error
+			if names, err = secrets.KeyringNames(secrets.DefaultKeyring()); err != nil {
+				return keyringError("", err)
+			}
+		}
+		if len(names) == 0 {
+			fmt.Printf("No secrets stored in %s.\n", where)
+			return nil
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	},
+}
+
+var secretsCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Show where each secret is resolved from and verify backend credentials",
+	Long: `Resolve the secrets the workspace's providers declare, and flo's own
+variables, and show where each comes from: the environment, a .env file or
+a provider. Providers are listed under secrets.providers in
+.flo/config.yaml and consulted in order; without any, the OS keyring is
+used. Values are masked.
+
+Then verify the credential of each configured backend with one cheap
+authenticated request to its provider (listing models, which uses no
+tokens), so a wrong, revoked or expired key is found before a long run
+fails halfway through. The workspace's backend is checked by default;
+--backend picks others and --all checks every known backend. The check
+fails if a provider rejects a credential.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var cfgs []secrets.ProviderConfig
+		backends := secretsCheckBackends
+		if ws, err := loadWorkspace(); err == nil {
+			cfgs = ws.Config.Secrets.Providers
+			if len(backends) == 0 && ws.Config.Backend != "" {
+				backends = []string{ws.Config.Backend}
+			}
+		}
+		if secretsCheckAll {
+			backends = authcheck.Backends()
+		}
+		for _, b := range backends {
+			if _, ok := authcheck.Credentials[b]; !ok {
+				return fmt.Errorf("unknown backend '%s' (%s)", b, strings.Join(authcheck.Backends(), ", "))
+			}
+		}
+		providers := []secrets.Provider{secrets.KeyringProvider(secrets.DefaultKeyring())}
+		if len(cfgs) > 0 {
+			var err error
+			if providers, err = secrets.NewProviders(cfgs); err != nil {
+				return err
+			}
+		}
+		if _, err := os.Stat(secrets.EncryptedFile); err == nil {
+			encrypted := secrets.EncryptedProvider(secrets.EncryptedFile, secrets.KeyFromEnv(), nil)
+			providers = append([]secrets.Provider{encrypted}, providers...)
+		}
+
+		m := secrets.NewManager()
+		for _, path := range []string{".env", secrets.WorkspaceEnvFile} {
+			if err := m.LoadEnvFile(path); err != nil {
+				return err
+			}
+		}
+		names := append([]string(nil), secrets.WellKnownKeys...)
+		for _, b := range backends {
+			for _, env := range authcheck.Credentials[b].Env {
+				if !contains(names, env) && (m.Get(env) != "" || env == authcheck.Credentials[b].Env[0]) {
+					names = append(names, env)
+				}
+			}
+		}
+		for _, p := range providers {
+			held, err := p.Names()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ %s: %v\n", p.Name(), err)
+			}
+			for _, name := range held {
+				if !contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+
+		failed := 0
+		ctx := context.Background()
+		resolved := make(map[string]string)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSOURCE\tVALUE")
+		for _, name := range names {
+			if value := m.Get(name); value != "" {
+				resolved[name] = value
+				fmt.Fprintf(w, "%s\t%s\t%s\n", name, m.Source(name), secrets.Mask(value))
+				continue
+			}
+			value, source, err := secrets.Resolve(ctx, providers, name)
+			switch {
+			case errors.Is(err, secrets.ErrNotFound):
+				fmt.Fprintf(w, "%s\t-\t%s\n", name, secrets.Mask(""))
+			case err != nil:
+				failed++
+				fmt.Fprintf(w, "%s\t-\t✗ %v\n", name, err)
+			default:
+				resolved[name] = value
+				fmt.Fprintf(w, "%s\t%s\t%s\n", name, source, secrets.Mask(value))
+			}
+		}
+		w.Flush()
+
+		rejected := 0
+		if len(backends) > 0 {
+			fmt.Println("\nBackend credentials:")
+			checker := authcheck.NewChecker()
+			for _, b := range backends {
+				r := checker.Check(ctx, b, func(name string) string { return resolved[name] })
+				mark := map[string]string{authcheck.StatusOK: "✓", authcheck.StatusInvalid: "✗", authcheck.StatusUnset: "-"}[r.Status]
+				if mark == "" {
+					mark = "⚠"
+				}
+				label := r.Backend
+				if r.Env != "" {
+					label += " (" + r.Env + ")"
+				}
+				fmt.Printf("  %s %s: %s\n", mark, label, r.Message)
+				if r.Status == authcheck.StatusInvalid {
+					rejected++
+					audit.Warn("secrets.check", "Backend credential rejected", map[string]interface{}{
+						"backend": r.Backend,
+						"env":     r.Env,
+					})
+				}
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d secret(s) could not be resolved", failed)
+		}
+		if rejected > 0 {
+			return fmt.Errorf("%d backend credential(s) rejected", rejected)
+		}
+		return nil
+	},
+}
+
+var secretsInitCmd = &cobra.Command{
+	Use:   "init --encrypt",
+	Short: "Create the workspace's encrypted secrets file",
+	Long: `Create .flo/secrets, an encrypted store for workspace credentials that is
+safe to keep alongside committed files. Add secrets to it with
+'flo secrets set --encrypted'.
+
+The file is encrypted with a passphrase (AES-256-GCM with a PBKDF2 key),
+read from FLO_SECRETS_PASSPHRASE or prompted for, or with --age for the
+given age recipients, which needs the age CLI. Every flo command decrypts
+it when FLO_SECRETS_PASSPHRASE, or FLO_AGE_IDENTITY naming an age identity
+file, is set; its secrets come after the environment and .env files and
+before secret providers.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !secretsInitEncrypt {
+			return fmt.Errorf("only encrypted secrets files are supported; pass --encrypt (plaintext secrets belong in %s)", secrets.WorkspaceEnvFile)
+		}
+		key := secrets.KeyFromEnv()
+		if len(secretsAgeRecipients) == 0 && key.Passphrase == "" {
+			pass, err := readPassphrase("New passphrase: ")
+			if err != nil {
+				return err
+			}
+			confirm, err := readPassphrase("Repeat passphrase: ")
+			if err != nil {
+				return err
+			}
+			if pass != confirm {
+				return fmt.Errorf("passphrases don't match")
+			}
+			key.Passphrase = pass
+		}
+
+		store, err := secrets.InitEncrypted(secrets.EncryptedFile, secretsAgeRecipients, key, nil)
+		if err != nil {
+			return err
+		}
+		audit.Info("secrets.init", "Encrypted secrets file created", map[string]interface{}{
+			"file":   secrets.EncryptedFile,
+			"scheme": store.Scheme(),
+		})
+		fmt.Printf("✓ Created %s (%s)\n", secrets.EncryptedFile, store.Scheme())
+		if store.Scheme() == secrets.SchemeAge {
+			fmt.Printf("  Set %s to an age identity file to decrypt it\n", secrets.AgeIdentityEnv)
+		} else {
+			fmt.Printf("  Set %s to decrypt it in flo commands\n", secrets.PassphraseEnv)
+		}
+		return nil
+	},
+}
+
+// openEncryptedStore opens the workspace's encrypted secrets file,
+// prompting for its passphrase when it isn't set.
+func openEncryptedStore() (*secrets.EncryptedStore, error) {
+	scheme, err := secrets.EncryptedScheme(secrets.EncryptedFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no encrypted secrets file; create one with 'flo secrets init --encrypt'")
+	}
+	if err != nil {
+		return nil, err
+	}
+	key := secrets.KeyFromEnv()
+	if scheme == secrets.SchemePassphrase && key.Passphrase == "" {
+		if key.Passphrase, err = readPassphrase("Passphrase for " + secrets.EncryptedFile + ": "); err != nil {
+			return nil, err
+		}
+	}
+	return secrets.OpenEncrypted(secrets.EncryptedFile, key, nil)
+}
+
+// readPassphrase prompts for a passphrase on the terminal, without echoing
+// it where stty is available.
+func readPassphrase(prompt string) (string, error) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return "", fmt.Errorf("%w (set %s)", secrets.ErrLocked, secrets.PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	stty := exec.Command("stty", "-echo")
+	stty.Stdin = os.Stdin
+	if stty.Run() == nil {
+		defer func() {
+			restore := exec.Command("stty", "echo")
+			restore.Stdin = os.Stdin
+			restore.Run()
+		}()
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	fmt.Fprintln(os.Stderr)
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	pass := strings.TrimRight(line, "\r\n")
+	if pass == "" {
+		return "", fmt.Errorf("empty passphrase")
+	}
+	return pass, nil
+}
+
+// loadSecrets loads .env files and the secrets resolved by the current
+// workspace's secret providers.
+func loadSecrets() (*secrets.Manager, error) {
+	ws, _ := loadWorkspace()
+	return loadSecretsFor(ws)
+}
+
+// loadSecretsFor loads .env files and the secrets resolved by ws's secret
+// providers, or the OS keyring when ws is nil or declares none.
+func loadSecretsFor(ws *workspace.Workspace) (*secrets.Manager, error) {
+	var providers []secrets.ProviderConfig
+	if ws != nil {
+		providers = ws.Config.Secrets.Providers
+	}
+	m, err := secrets.Load(context.Background(), providers)
+	if err != nil {
+		return nil, err
+	}
+	useRedactor(m.Redactor())
+	return m, nil
+}
+
+// lazySecrets resolves secrets that run in the background, such as
+// webhook signing secrets and issue tracker tokens, loading them the first
+// time one is needed.
+func lazySecrets(ws *workspace.Workspace) func(name string) string {
+	var once sync.Once
+	var manager *secrets.Manager
+	return func(name string) string {
+		once.Do(func() {
+			m, err := loadSecretsFor(ws)
+			if err != nil {
+				m = secrets.NewManager()
+			}
+			manager = m
+		})
+		return manager.Get(name)
+	}
+}
+
+// activeRedactor scrubs the values of the secrets loaded so far.
+var (
+	activeRedactorMu sync.Mutex
+	activeRedactor   *redact.Redactor
+)
+
+// useRedactor scrubs r's secrets from audit events, transcripts and
+// errors from now on.
+func useRedactor(r *redact.Redactor) {
+	activeRedactorMu.Lock()
+	defer activeRedactorMu.Unlock()
+	activeRedactor = r
+	audit.SetRedactor(r)
+}
+
+// secretRedactor returns the redactor for the secrets loaded so far, or
+// one for credential-like environment variables if none were loaded.
+func secretRedactor() *redact.Redactor {
+	activeRedactorMu.Lock()
+	defer activeRedactorMu.Unlock()
+	if activeRedactor == nil {
+		activeRedactor = secrets.NewManager().Redactor()
+	}
+	return activeRedactor
+}
+
+// keyringError explains keyring errors users can act on.
+func keyringError(name string, err error) error {
+	switch {
+	case errors.Is(err, secrets.ErrNotFound):
+		return fmt.Errorf("%s is not stored in the keyring", name)
+	case errors.Is(err, secrets.ErrKeyringUnavailable):
+		return fmt.Errorf("%w (on Linux, install secret-tool from libsecret-tools; otherwise use %s)", err, secrets.WorkspaceEnvFile)
+	}
+	return err
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	secretsCheckCmd.Flags().StringSliceVar(&secretsCheckBackends, "backend", nil, "Backends whose credentials to verify (default the workspace backend)")
+	secretsCheckCmd.Flags().BoolVar(&secretsCheckAll, "all", false, "Verify the credentials of every known backend")
+	secretsInitCmd.Flags().BoolVar(&secretsInitEncrypt, "encrypt", false, "Create an encrypted secrets file (required)")
+	secretsInitCmd.Flags().StringArrayVar(&secretsAgeRecipients, "age", nil, "Encrypt for an age recipient instead of a passphrase (repeatable)")
+	for _, c := range []*cobra.Command{secretsSetCmd, secretsGetCmd, secretsDeleteCmd, secretsListCmd} {
+		c.Flags().BoolVar(&secretsEncrypted, "encrypted", false, "Use the encrypted .flo/secrets file instead of the keyring")
+	}
+
+	secretsCmd.AddCommand(secretsInitCmd)
+	secretsCmd.AddCommand(secretsSetCmd)
+	secretsCmd.AddCommand(secretsGetCmd)
+	secretsCmd.AddCommand(secretsDeleteCmd)
+	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsCheckCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
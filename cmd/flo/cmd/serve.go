@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/richgo/flo/pkg/api"
+	"github.com/richgo/flo/pkg/approval"
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/dashboard"
+	"github.com/richgo/flo/pkg/repos"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/tui"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr   string
+	serveNoAuth bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the workspace over a local REST API",
+	Long: `Serve workspace state and control operations over a REST/JSON API, so
+dashboards and editor plugins can integrate without shelling out to flo.
+
+Every request must carry "Authorization: Bearer <token>", or an
+access_token query parameter, with the workspace token (see 'flo mcp
+token'). --no-auth turns this off, and is only allowed on loopback
+addresses.
+
+A web dashboard is served at /: a task board, the dependency graph, the
+rendered spec, reviews awaiting a decision and the live event stream, for
+team members who don't use the CLI. Open the URL flo serve prints, which carries the token.
+
+  GET  /v1/status              Workspace status, as 'flo status -o json'
+  GET  /v1/tasks               Tasks (?status=failed&repo=android filters)
+  POST /v1/tasks               Create a task: {"title", "type", "repo",
+                               "deps", "parent", "priority", "spec_ref",
+                               "criteria"}
+  GET  /v1/tasks/{id}          A task, as 'flo task show -o json'
+  GET  /v1/tasks/{id}/review   The task's latest review: its diff, also
+                               split into "files", gates and decision
+  POST /v1/tasks/{id}/review   Decide on a pending review: {"decision":
+                               "approve"|"reject"|"changes", "feedback",
+                               "rejected_files"}; "changes" starts a run
+                               with the feedback
+  GET  /v1/reviews             Reviews awaiting a decision
+  GET  /v1/runs                Runs started by this server
+  POST /v1/runs                Start 'flo work' on a task: {"task_id"}
+  GET  /v1/runs/{id}           A run and the end of its output
+  POST /v1/runs/{id}/cancel    Interrupt a run; its task is left failed
+  GET  /v1/quota               Backend usage (?by_model=true per model)
+  GET  /v1/spec                The spec: {"path", "content"}
+  GET  /v1/events              Server-sent "audit" events as any flo
+                               command or run records them
+
+Responses are JSON. Errors are {"error": "..."} with status 400 for a
+malformed request, 401 without the token, 404 for an unknown task, run or
+review, 409 for a run or review decision that conflicts with the task's
+state and 422 for a task that can't be created.
+
+Changes made by other flo commands are picked up on the next request.
+Runs are cancelled when the server shuts down.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		server := api.NewServer()
+		token := ""
+		if serveNoAuth {
+			if err := checkLoopback(serveAddr); err != nil {
+				return err
+			}
+		} else {
+			token, err = mcpAuthToken(ws, false)
+			if err != nil {
+				return err
+			}
+			server.SetAuthToken(token)
+		}
+		server.SetRefresh(ws.Reload)
+
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to find the flo executable: %w", err)
+		}
+		runs := api.NewRuns(func(taskID string) *exec.Cmd {
+			c := exec.Command(executable, "work", taskID)
+			c.Dir = ws.Root
+			return c
+		})
+		// A cancelled run leaves its task in progress; fail it so it can
+		// be retried
+		runs.OnFinish(func(run api.Run) {
+			if run.Status != api.RunCancelled {
+				return
+			}
+			server.Do(func() error {
+				t, err := ws.GetTask(run.TaskID)
+				if err != nil || t.Status != task.StatusInProgress {
+					return err
+				}
+				return ws.SetTaskStatus(t.ID, string(task.StatusFailed))
+			})
+		})
+		registerRoutes(server, ws, runs)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// Requests share ctx, so event streams end on shutdown
+		httpServer := &http.Server{
+			Addr:        serveAddr,
+			Handler:     server.Handler(),
+			BaseContext: func(net.Listener) context.Context { return ctx },
+		}
+		go func() {
+			<-ctx.Done()
+			runs.CancelAll()
+			httpServer.Shutdown(context.Background())
+		}()
+
+		audit.Info("api.serve", "API server started", map[string]interface{}{
+			"addr": serveAddr,
+			"auth": !serveNoAuth,
+		})
+		fmt.Fprintf(os.Stderr, "flo API listening on http://%s/v1\n", serveAddr)
+		fmt.Fprintf(os.Stderr, "Dashboard: %s\n", dashboardURL(serveAddr, token))
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	},
+}
+
+// createTaskRequest is the body of POST /v1/tasks.
+type createTaskRequest struct {
+	Title    string   `json:"title"`
+	Type     string   `json:"type"`
+	Repo     string   `json:"repo"`
+	Deps     []string `json:"deps"`
+	Parent   string   `json:"parent"`
+	Priority *int     `json:"priority"`
+	SpecRef  string   `json:"spec_ref"`
+	Criteria []string `json:"criteria"`
+}
+
+// reviewDecisionRequest is the body of POST /v1/tasks/{id}/review.
+type reviewDecisionRequest struct {
+	Decision      string   `json:"decision"`
+	Feedback      string   `json:"feedback"`
+	RejectedFiles []string `json:"rejected_files"`
+}
+
+// reviewView is a review as the API returns it, with its diff split
+// into files.
+type reviewView struct {
+	*approval.Request
+	Files []repos.FileDiff `json:"files"`
+}
+
+func newReviewView(req *approval.Request) reviewView {
+	files := repos.SplitDiff(req.Diff)
+	if files == nil {
+		files = []repos.FileDiff{}
+	}
+	return reviewView{Request: req, Files: files}
+}
+
+// reviewDecisionResponse is the response of POST /v1/tasks/{id}/review.
+type reviewDecisionResponse struct {
+	Review reviewView `json:"review"`
+	// Run is the run started on a task sent back with feedback.
+	Run *api.Run `json:"run,omitempty"`
+}
+
+// specDocument is the response of GET /v1/spec.
+type specDocument struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// eventPollInterval is how often /v1/events checks the audit log.
+const eventPollInterval = 500 * time.Millisecond
+
+// dashboardURL returns the address of the dashboard, with the token in the
+// fragment, which browsers don't send to the server.
+func dashboardURL(addr, token string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err == nil && (host == "" || host == "0.0.0.0" || host == "::") {
+		addr = net.JoinHostPort("localhost", port)
+	}
+	url := "http://" + addr + "/"
+	if token != "" {
+		url += "#token=" + token
+	}
+	return url
+}
+
+// startRunRequest is the body of POST /v1/runs.
+type startRunRequest struct {
+	TaskID string `json:"task_id"`
+}
+
+// registerRoutes adds the API's endpoints, documented in 'flo serve --help'.
+func registerRoutes(server *api.Server, ws *workspace.Workspace, runs *api.Runs) {
+	server.Handle("GET /v1/status", func(r *http.Request) (int, interface{}, error) {
+		return http.StatusOK, newStatusReport(ws), nil
+	})
+
+	server.Handle("GET /v1/tasks", func(r *http.Request) (int, interface{}, error) {
+		query := r.URL.Query()
+		tasks := ws.ListTasks(query.Get("status"), query.Get("repo"))
+		if tasks == nil {
+			tasks = []*task.Task{}
+		}
+		return http.StatusOK, tasks, nil
+	})
+
+	server.Handle("POST /v1/tasks", func(r *http.Request) (int, interface{}, error) {
+		var req createTaskRequest
+		if err := api.Decode(r, &req); err != nil {
+			return 0, nil, err
+		}
+		t, err := createTaskFromRequest(ws, req)
+		if err != nil {
+			return 0, nil, api.Errorf(http.StatusUnprocessableEntity, "%v", err)
+		}
+		return http.StatusCreated, t, nil
+	})
+
+	server.Handle("GET /v1/tasks/{id}", func(r *http.Request) (int, interface{}, error) {
+		t, err := ws.GetTask(r.PathValue("id"))
+		if err != nil {
+			return 0, nil, err
+		}
+		view, err := newTaskView(ws, t, 10)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, view, nil
+	})
+
+	server.Handle("GET /v1/reviews", func(r *http.Request) (int, interface{}, error) {
+		pending, err := ws.Approvals().Pending()
+		if err != nil {
+			return 0, nil, err
+		}
+		reviews := []reviewView{}
+		for _, req := range pending {
+			if req.Kind == workspace.ReviewKind {
+				reviews = append(reviews, newReviewView(req))
+			}
+		}
+		return http.StatusOK, reviews, nil
+	})
+
+	server.Handle("GET /v1/tasks/{id}/review", func(r *http.Request) (int, interface{}, error) {
+		t, err := ws.GetTask(r.PathValue("id"))
+		if err != nil {
+			return 0, nil, err
+		}
+		req, err := ws.Review(t.ID)
+		if err != nil {
+			return 0, nil, err
+		}
+		if req == nil {
+			return 0, nil, api.Errorf(http.StatusNotFound, "task %s has no changes awaiting review", t.ID)
+		}
+		return http.StatusOK, newReviewView(req), nil
+	})
+
+	server.Handle("POST /v1/tasks/{id}/review", func(r *http.Request) (int, interface{}, error) {
+		var body reviewDecisionRequest
+		if err := api.Decode(r, &body); err != nil {
+			return 0, nil, err
+		}
+		t, err := ws.GetTask(r.PathValue("id"))
+		if err != nil {
+			return 0, nil, err
+		}
+		decision := tui.Decision(body.Decision)
+		switch decision {
+		case tui.DecisionApprove, tui.DecisionReject:
+		case tui.DecisionChanges:
+			if body.Feedback == "" {
+				return 0, nil, api.Errorf(http.StatusBadRequest, "feedback is required to send changes back")
+			}
+		default:
+			return 0, nil, api.Errorf(http.StatusBadRequest, "decision must be approve, reject or changes")
+		}
+		if err := decideReview(ws, t, decision, body.Feedback, body.RejectedFiles); err != nil {
+			return 0, nil, api.Errorf(http.StatusConflict, "%v", err)
+		}
+		req, err := ws.Review(t.ID)
+		if err != nil {
+			return 0, nil, err
+		}
+		resp := reviewDecisionResponse{Review: newReviewView(req)}
+		// Changes sent back reopen the task: run the agent on it again
+		if decision == tui.DecisionChanges {
+			run, err := runs.Start(t.ID)
+			if err != nil {
+				return 0, nil, err
+			}
+			resp.Run = &run
+		}
+		return http.StatusOK, resp, nil
+	})
+
+	server.Handle("GET /v1/runs", func(r *http.Request) (int, interface{}, error) {
+		return http.StatusOK, runs.List(), nil
+	})
+
+	server.Handle("POST /v1/runs", func(r *http.Request) (int, interface{}, error) {
+		var req startRunRequest
+		if err := api.Decode(r, &req); err != nil {
+			return 0, nil, err
+		}
+		t, err := ws.GetTask(req.TaskID)
+		if err != nil {
+			return 0, nil, err
+		}
+		if t.Status != task.StatusPending {
+			return 0, nil, api.Errorf(http.StatusConflict, "task %s is %s, not pending", t.ID, t.Status)
+		}
+		run, err := runs.Start(t.ID)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusAccepted, run, nil
+	})
+
+	server.Handle("GET /v1/runs/{id}", func(r *http.Request) (int, interface{}, error) {
+		run, err := runs.Get(r.PathValue("id"))
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, run, nil
+	})
+
+	server.Handle("POST /v1/runs/{id}/cancel", func(r *http.Request) (int, interface{}, error) {
+		run, err := runs.Cancel(r.PathValue("id"))
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusAccepted, run, nil
+	})
+
+	server.Handle("GET /v1/spec", func(r *http.Request) (int, interface{}, error) {
+		content, err := ws.ReadSpec()
+		if err != nil {
+			return 0, nil, err
+		}
+		path, err := filepath.Rel(ws.Root, ws.SpecPath())
+		if err != nil {
+			path = ws.SpecPath()
+		}
+		return http.StatusOK, specDocument{Path: path, Content: content}, nil
+	})
+
+	server.HandleStream("GET /v1/events", func(r *http.Request, send api.SendFunc) error {
+		return audit.Follow(r.Context(), ws.Root, eventPollInterval, func(event audit.Event) {
+			send("audit", event)
+		})
+	})
+
+	server.HandlePublic("GET /", dashboard.Handler())
+
+	server.Handle("GET /v1/quota", func(r *http.Request) (int, interface{}, error) {
+		byModel := r.URL.Query().Get("by_model") == "true"
+		return http.StatusOK, quotaRows(initQuotaTracker(quotaPath(ws), ws), byModel), nil
+	})
+}
+
+// createTaskFromRequest creates a task as 'flo task create' does.
+func createTaskFromRequest(ws *workspace.Workspace, req createTaskRequest) (*task.Task, error) {
+	if req.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if err := ws.ValidateSpecRef(req.SpecRef); err != nil {
+		return nil, err
+	}
+	if err := ws.ValidateCriteria(req.Criteria); err != nil {
+		return nil, err
+	}
+
+	priority := ws.DefaultPriority(req.Type, req.Parent)
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+
+	var t *task.Task
+	var err error
+	if req.Parent != "" {
+		t, err = ws.CreateSubtask(req.Parent, req.Title, req.Type, req.Repo, req.Deps, priority)
+	} else {
+		t, err = ws.CreateTaskWithType(req.Title, req.Type, req.Repo, req.Deps, priority)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if req.SpecRef != "" || len(req.Criteria) > 0 {
+		t.SpecRef = req.SpecRef
+		t.Criteria = req.Criteria
+		if err := ws.UpdateTask(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().BoolVar(&serveNoAuth, "no-auth", false, "Serve without a bearer token (loopback addresses only)")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -1,11 +1,20 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/richgo/flo/pkg/agent"
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
 	"github.com/richgo/flo/pkg/spec"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -18,19 +27,556 @@ var specCmd = &cobra.Command{
 var specValidateCmd = &cobra.Command{
 	Use:   "validate [path]",
 	Short: "Validate a SPEC.md file",
-	Long: `Validate that a SPEC.md file contains all required sections (Goal, Context, Success Criteria)
-and follows proper markdown structure.
+	Long: `Validate that a SPEC.md file contains all required sections and follows
+proper markdown structure.
+
+Required sections default to Goal, Context and Success Criteria and can be
+changed with spec.required_sections in .flo/config.yaml.
 
 If no path is provided, validates .flo/SPEC.md in the current directory.`,
-	Args: cobra.MaximumNArgs(1),
-	RunE: runSpecValidate,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runSpecValidate,
+}
+
+// Decompose flags
+var (
+	decomposeBackend string
+	decomposeYes     bool
+	decomposeApply   bool
+)
+
+var specDecomposeCmd = &cobra.Command{
+	Use:   "decompose",
+	Short: "Generate a task breakdown from SPEC.md",
+	Long: `Send SPEC.md to the configured backend and propose a task breakdown
+with dependencies and task types.
+
+The proposal is saved to .flo/proposals/decompose.json and shown for review.
+Nothing is added to the task registry until you confirm, pass --yes, or
+later run 'flo spec decompose --apply' to create the saved proposal.`,
+	RunE: runSpecDecompose,
+}
+
+// New flags
+var (
+	specNewTemplate string
+	specNewForce    bool
+)
+
+var specNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Create SPEC.md from a template",
+	Long: `Replace .flo/SPEC.md with a spec template.
+
+Built-in templates: default, api, bugfix, migration and mobile. User
+templates are *.md files in ~/.config/flo/templates/ (or
+$XDG_CONFIG_HOME/flo/templates/); they may use {{.Feature}} and replace a
+built-in of the same name. An optional first line "<!-- description -->"
+is shown by 'flo spec templates'.
+
+An edited SPEC.md is only overwritten with --force.`,
+	Args: cobra.NoArgs,
+	RunE: runSpecNew,
+}
+
+var specTemplatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List available spec templates",
+	Args:  cobra.NoArgs,
+	RunE:  runSpecTemplates,
+}
+
+var specCriteriaCmd = &cobra.Command{
+	Use:   "criteria",
+	Short: "List acceptance criteria and their completion",
+	Long: `List the checkbox items under Success Criteria / Acceptance Criteria in
+.flo/SPEC.md with their IDs and completion.
+
+Items may carry an explicit ID ("- [ ] AC-3: ..."); others are numbered
+AC-1, AC-2, ... in order. Tasks reference criteria with --criteria. A
+criterion is met when it is checked in SPEC.md or all of its tasks are
+complete.`,
+	Args: cobra.NoArgs,
+	RunE: runSpecCriteria,
+}
+
+// Coverage flags
+var coverageStrict bool
+
+var specCoverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Show which spec sections have tasks",
+	Long: `List the sections of .flo/SPEC.md with the tasks that reference them
+through their spec ref (e.g. SPEC.md#oauth). A section is covered when it or
+one of its subsections is referenced.
+
+With --strict, exits non-zero if any section has no tasks.`,
+	RunE: runSpecCoverage,
+}
+
+// Commit flags
+var specCommitMessage string
+
+var specCommitCmd = &cobra.Command{
+	Use:   "commit",
+	Short: "Snapshot SPEC.md as a new spec version",
+	Long: `Record the current .flo/SPEC.md as a numbered version in
+.flo/spec-history/. Nothing is recorded when SPEC.md is unchanged.
+
+Versions are also recorded by flo init and flo spec new, and when a task
+is given a spec ref, so each task knows which spec it was created against.`,
+	Args: cobra.NoArgs,
+	RunE: runSpecCommit,
+}
+
+var specLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "List spec versions",
+	Args:  cobra.NoArgs,
+	RunE:  runSpecLog,
+}
+
+// Diff flags
+var specDiffSections bool
+
+var specDiffCmd = &cobra.Command{
+	Use:   "diff [from] [to]",
+	Short: "Show changes between spec versions",
+	Long: `Show what changed in SPEC.md between two versions (e.g. v2 v3).
+
+With no arguments, compares the latest version with the working SPEC.md, or
+the previous version with the latest when there are no uncommitted edits.
+With one argument, compares that version with the working SPEC.md.
+
+Changed sections are listed, followed by tasks whose referenced section
+changed after the task was created.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runSpecDiff,
 }
 
 func init() {
+	specCommitCmd.Flags().StringVarP(&specCommitMessage, "message", "m", "", "Describe the change")
+	specDiffCmd.Flags().BoolVar(&specDiffSections, "sections", false, "Only list changed sections and stale tasks")
+	specNewCmd.Flags().StringVarP(&specNewTemplate, "template", "t", "default", "Spec template to use")
+	specNewCmd.Flags().BoolVar(&specNewForce, "force", false, "Overwrite an edited SPEC.md")
+	specCoverageCmd.Flags().BoolVar(&coverageStrict, "strict", false, "Fail if any section has no associated tasks")
+	specDecomposeCmd.Flags().StringVar(&decomposeBackend, "backend", "", "Override backend (claude or copilot)")
+	specDecomposeCmd.Flags().BoolVarP(&decomposeYes, "yes", "y", false, "Create the proposed tasks without asking")
+	specDecomposeCmd.Flags().BoolVar(&decomposeApply, "apply", false, "Create tasks from the saved proposal instead of generating a new one")
+
 	specCmd.AddCommand(specValidateCmd)
+	specCmd.AddCommand(specNewCmd)
+	specCmd.AddCommand(specTemplatesCmd)
+	specCmd.AddCommand(specDecomposeCmd)
+	specCmd.AddCommand(specCoverageCmd)
+	specCmd.AddCommand(specCriteriaCmd)
+	specCmd.AddCommand(specCommitCmd)
+	specCmd.AddCommand(specLogCmd)
+	specCmd.AddCommand(specDiffCmd)
 	rootCmd.AddCommand(specCmd)
 }
 
+func runSpecDecompose(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+	proposalPath := filepath.Join(ws.Root, ".flo", "proposals", "decompose.json")
+
+	if decomposeApply {
+		proposal, err := spec.LoadProposal(proposalPath)
+		if err != nil {
+			return err
+		}
+		return applyProposal(ws, proposal, proposalPath)
+	}
+
+	content, err := ws.ReadSpec()
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	backendName := ws.Backend
+	if decomposeBackend != "" {
+		backendName = decomposeBackend
+	}
+	fmt.Printf("🧩 Decomposing spec with %s...\n", backendName)
+
+	output, err := runOneShot(context.Background(), ws, backendName,
+		spec.DecomposePrompt(content, ws.TaskTypeNames()))
+	if err != nil {
+		return err
+	}
+
+	proposal, err := spec.ParseProposal(output)
+	if err != nil {
+		return err
+	}
+	if err := proposal.Validate(ws.TaskTypeNames()); err != nil {
+		return fmt.Errorf("invalid task breakdown: %w", err)
+	}
+	if err := spec.SaveProposal(proposalPath, proposal); err != nil {
+		return err
+	}
+
+	printProposal(proposal)
+
+	if !decomposeYes {
+		if !stdinIsTerminal() {
+			fmt.Printf("\nProposal saved to %s\n", proposalPath)
+			fmt.Println("Review it, then run 'flo spec decompose --apply' to create the tasks.")
+			return nil
+		}
+		fmt.Printf("\nCreate %d tasks? [y/N] ", len(proposal.Tasks))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Printf("Not created. Proposal saved to %s\n", proposalPath)
+			return nil
+		}
+	}
+
+	return applyProposal(ws, proposal, proposalPath)
+}
+
+// runOneShot runs a single prompt on a backend and returns the final
+// output. With hedge.fallback set, a slow request is also sent to the
+// fallback backend and the first to succeed wins.
+func runOneShot(ctx context.Context, ws *workspace.Workspace, backendName, prompt string) (string, error) {
+	primary := func(ctx context.Context) (*agent.Result, error) {
+		return oneShot(ctx, ws, backendName, "", prompt)
+	}
+
+	hedge := ws.Config.Hedge
+	fallbackBackend, fallbackModel, _ := strings.Cut(hedge.Fallback, "/")
+	if fallbackBackend == "" || hedge.Fallback == backendName {
+		result, err := primary(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !result.Success {
+			return "", fmt.Errorf("agent failed: %s", result.Error)
+		}
+		return result.Output, nil
+	}
+
+	delay := hedge.Delay
+	if delay <= 0 {
+		delay = config.DefaultHedgeDelay
+	}
+	start := time.Now()
+	result, hedged, err := agent.Hedge(ctx, delay, primary, func(ctx context.Context) (*agent.Result, error) {
+		fmt.Printf("⏱️  %s is slow; also asking %s\n", backendName, hedge.Fallback)
+		return oneShot(ctx, ws, fallbackBackend, fallbackModel, prompt)
+	})
+	if err != nil {
+		return "", err
+	}
+	if hedged {
+		audit.Info("agent.hedge", "A hedged request answered first", map[string]interface{}{
+			"backend":    backendName,
+			"fallback":   hedge.Fallback,
+			"delay_ms":   delay.Milliseconds(),
+			"elapsed_ms": time.Since(start).Milliseconds(),
+		})
+		fmt.Printf("   %s answered first\n", hedge.Fallback)
+	}
+	return result.Output, nil
+}
+
+// oneShot runs a single prompt on a backend.
+func oneShot(ctx context.Context, ws *workspace.Workspace, backendName, model, prompt string) (*agent.Result, error) {
+	backend, err := newBackend(ws, backendName, model)
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start backend: %w", err)
+	}
+	defer backend.Stop()
+
+	session, err := backend.CreateSession(ctx, task.New("spec", "Decompose spec"), ws.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Destroy(ctx)
+
+	go func() {
+		for range session.Events() {
+		}
+	}()
+
+	return session.Run(ctx, prompt)
+}
+
+// printProposal shows a proposed breakdown for review.
+func printProposal(p *spec.Proposal) {
+	fmt.Printf("\nProposed tasks (%d):\n\n", len(p.Tasks))
+	for _, t := range p.Tasks {
+		line := fmt.Sprintf("  [%s] %s", t.Key, t.Title)
+		if t.Type != "" {
+			line += fmt.Sprintf(" (%s)", t.Type)
+		}
+		if len(t.Deps) > 0 {
+			line += fmt.Sprintf(" ← %s", strings.Join(t.Deps, ", "))
+		}
+		fmt.Println(line)
+		if t.Description != "" {
+			fmt.Printf("      %s\n", t.Description)
+		}
+	}
+}
+
+// applyProposal creates the proposed tasks and removes the saved proposal.
+func applyProposal(ws *workspace.Workspace, p *spec.Proposal, path string) error {
+	created, err := ws.ApplyProposal(p)
+	for _, t := range created {
+		fmt.Printf("✓ Created %s: %s\n", t.ID, t.Title)
+	}
+	if err != nil {
+		return err
+	}
+	os.Remove(path)
+	return nil
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func runSpecNew(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+	if err := ws.NewSpec(specNewTemplate, specNewForce); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote .flo/SPEC.md from template '%s'\n", specNewTemplate)
+
+	content, err := ws.ReadSpec()
+	if err != nil {
+		return err
+	}
+	if result := ws.SpecValidator().Validate(content); len(result.MissingSections) > 0 {
+		fmt.Printf("  Note: template lacks required sections: %s\n", strings.Join(result.MissingSections, ", "))
+	}
+	return nil
+}
+
+func runSpecTemplates(cmd *cobra.Command, args []string) error {
+	library := spec.DefaultTemplateLibrary()
+	templates, err := library.List()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Spec templates:")
+	for _, t := range templates {
+		source := ""
+		if t.Source != "built-in" {
+			source = fmt.Sprintf(" (%s)", t.Source)
+		}
+		fmt.Printf("  %-10s %s%s\n", t.Name, t.Description, source)
+	}
+	if library.Dir() != "" {
+		fmt.Printf("\nUser templates: %s\n", library.Dir())
+	}
+	return nil
+}
+
+func runSpecCriteria(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	progress, err := ws.CriteriaProgress()
+	if err != nil {
+		return err
+	}
+	if len(progress.Criteria) == 0 {
+		fmt.Println("No acceptance criteria found in SPEC.md.")
+		return nil
+	}
+
+	fmt.Println("Acceptance criteria:")
+	for _, c := range progress.Criteria {
+		mark := "[ ]"
+		if c.Met {
+			mark = "[x]"
+		}
+		tasks := ""
+		if len(c.Tasks) > 0 {
+			tasks = fmt.Sprintf(" (%s)", strings.Join(c.Tasks, ", "))
+		}
+		fmt.Printf("  %s %-6s %s%s\n", mark, c.ID, c.Text, tasks)
+	}
+	fmt.Printf("\n%d/%d met (%d%%)\n", progress.Met, len(progress.Criteria), progress.Percent())
+	return nil
+}
+
+func runSpecCoverage(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	sections, err := ws.SpecCoverage()
+	if err != nil {
+		return err
+	}
+	if len(sections) == 0 {
+		fmt.Println("No sections found in SPEC.md.")
+		return nil
+	}
+
+	uncovered := 0
+	fmt.Println("Spec coverage:")
+	for _, s := range sections {
+		indent := strings.Repeat("  ", s.Anchor.Level-1)
+		if s.Covered() {
+			fmt.Printf("%s✓ %s (#%s): %s\n", indent, s.Anchor.Heading, s.Anchor.Slug, strings.Join(s.Tasks, ", "))
+		} else {
+			uncovered++
+			fmt.Printf("%s✗ %s (#%s): no tasks\n", indent, s.Anchor.Heading, s.Anchor.Slug)
+		}
+	}
+	fmt.Printf("\n%d of %d sections covered\n", len(sections)-uncovered, len(sections))
+
+	if coverageStrict && uncovered > 0 {
+		return fmt.Errorf("%d spec sections have no tasks", uncovered)
+	}
+	return nil
+}
+
+func runSpecCommit(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	v, created, err := ws.CommitSpec(specCommitMessage)
+	if err != nil {
+		return err
+	}
+	if !created {
+		fmt.Printf("No changes since spec %s.\n", v.Label())
+		return nil
+	}
+	fmt.Printf("✓ Recorded spec %s\n", v.Label())
+	return nil
+}
+
+func runSpecLog(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+
+	versions, err := ws.SpecHistory().List()
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Println("No spec versions recorded. Run 'flo spec commit' to record one.")
+		return nil
+	}
+
+	loc := displayLocation(ws)
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		fmt.Printf("%-5s %s  %s\n", v.Label(), v.CreatedAt.In(loc).Format("2006-01-02 15:04"), v.Message)
+	}
+	return nil
+}
+
+func runSpecDiff(cmd *cobra.Command, args []string) error {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return err
+	}
+	history := ws.SpecHistory()
+
+	working, err := ws.ReadSpec()
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	// Resolve the two sides: a version number, or 0 for the working SPEC.md.
+	var from, to int
+	switch len(args) {
+	case 0:
+		latest, err := history.Latest()
+		if err != nil {
+			return err
+		}
+		if latest == nil {
+			fmt.Println("No spec versions recorded. Run 'flo spec commit' to record one.")
+			return nil
+		}
+		from = latest.Number
+		if content, err := history.Read(from); err == nil && content == working && from > 1 {
+			from, to = from-1, from
+		}
+	case 1, 2:
+		if from, err = spec.ParseVersion(args[0]); err != nil {
+			return err
+		}
+		if len(args) == 2 {
+			if to, err = spec.ParseVersion(args[1]); err != nil {
+				return err
+			}
+		}
+	}
+
+	oldContent, err := history.Read(from)
+	if err != nil {
+		return err
+	}
+	newContent, newName := working, "SPEC.md (working)"
+	if to > 0 {
+		if newContent, err = history.Read(to); err != nil {
+			return err
+		}
+		newName = fmt.Sprintf("SPEC.md v%d", to)
+	}
+	oldName := fmt.Sprintf("SPEC.md v%d", from)
+
+	changes := spec.ChangedSections(oldContent, newContent)
+	if oldContent == newContent {
+		fmt.Printf("No changes between %s and %s.\n", oldName, newName)
+	} else {
+		if !specDiffSections {
+			fmt.Print(spec.UnifiedDiff(oldName, newName, oldContent, newContent, 3))
+			fmt.Println()
+		}
+		fmt.Printf("Changed sections (%s → %s):\n", oldName, newName)
+		if len(changes) == 0 {
+			fmt.Println("  (only the title or text outside sections changed)")
+		}
+		for _, c := range changes {
+			fmt.Printf("  %-8s #%s  %s\n", c.Kind, c.Anchor.Slug, c.Anchor.Heading)
+		}
+	}
+
+	stale, err := ws.StaleTasks()
+	if err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		fmt.Println()
+		fmt.Println("Tasks whose spec section changed since they were created:")
+		for _, st := range stale {
+			fmt.Printf("  ⚠ %s [%s] %s → %s (since %s)\n", st.Task.ID, st.Task.Status, st.Task.Title, st.Task.SpecRef, st.Since.Label())
+		}
+		fmt.Println("Review them, then run 'flo task update <id> --spec-ack' to accept the current spec.")
+	}
+	return nil
+}
+
 func runSpecValidate(cmd *cobra.Command, args []string) error {
 	// Determine spec file path
 	specPath := ".flo/SPEC.md"
@@ -49,40 +595,73 @@ func runSpecValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("spec file not found: %s", absPath)
 	}
 
-	// Validate the spec
+	// Validate the spec against the workspace's required sections, if any
 	validator := spec.NewValidator()
+	if ws, err := loadWorkspace(); err == nil {
+		validator = ws.SpecValidator()
+	}
 	result, err := validator.ValidateFile(absPath)
 	if err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
-	// Display results
-	fmt.Printf("Validating: %s\n\n", absPath)
+	report := specValidateReport{
+		Path:            absPath,
+		Valid:           result.Valid,
+		MissingSections: result.MissingSections,
+		Errors:          result.Errors,
+	}
+	if report.MissingSections == nil {
+		report.MissingSections = []string{}
+	}
+	if report.Errors == nil {
+		report.Errors = []string{}
+	}
+	if err := render(report, func() error {
+		printSpecValidation(report)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if !result.Valid {
+		return withExitCode(ExitValidation, fmt.Errorf("spec validation failed"))
+	}
+	return nil
+}
+
+// specValidateReport is what `flo spec validate` reports.
+type specValidateReport struct {
+	Path            string   `json:"path"`
+	Valid           bool     `json:"valid"`
+	MissingSections []string `json:"missing_sections"`
+	Errors          []string `json:"errors"`
+}
 
-	if result.Valid {
+func printSpecValidation(report specValidateReport) {
+	fmt.Printf("Validating: %s\n\n", report.Path)
+
+	if report.Valid {
 		fmt.Println("✓ Spec is valid!")
-		return nil
+		return
 	}
 
 	// Show validation errors
 	fmt.Println("✗ Spec validation failed:")
 	fmt.Println()
 
-	if len(result.MissingSections) > 0 {
+	if len(report.MissingSections) > 0 {
 		fmt.Println("Missing required sections:")
-		for _, section := range result.MissingSections {
+		for _, section := range report.MissingSections {
 			fmt.Printf("  - %s\n", section)
 		}
 		fmt.Println()
 	}
 
-	if len(result.Errors) > 0 {
+	if len(report.Errors) > 0 {
 		fmt.Println("Errors:")
-		for _, err := range result.Errors {
+		for _, err := range report.Errors {
 			fmt.Printf("  - %s\n", err)
 		}
 		fmt.Println()
 	}
-
-	return fmt.Errorf("spec validation failed")
 }
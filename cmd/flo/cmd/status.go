@@ -2,10 +2,64 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
+// statusReport is what `flo status` reports.
+type statusReport struct {
+	Feature string `json:"feature"`
+	Backend string `json:"backend"`
+	// ReadOnly is set when the task manifest is newer than this flo.
+	ReadOnly bool `json:"read_only"`
+	// Frozen explains why changes are frozen now, if they are.
+	Frozen     string          `json:"frozen,omitempty"`
+	Tasks      statusTasks     `json:"tasks"`
+	Criteria   *statusCriteria `json:"criteria,omitempty"`
+	Cost       *statusCost     `json:"cost,omitempty"`
+	StaleTasks int             `json:"stale_tasks"`
+	Ready      []statusTask    `json:"ready"`
+	// Breakers is each backend's circuit breaker state as of its last run.
+	Breakers []statusBreaker `json:"breakers"`
+}
+
+type statusBreaker struct {
+	Backend   string    `json:"backend"`
+	State     string    `json:"state"`
+	AsOf      time.Time `json:"as_of"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+type statusTasks struct {
+	Total      int `json:"total"`
+	Pending    int `json:"pending"`
+	InProgress int `json:"in_progress"`
+	Complete   int `json:"complete"`
+	Failed     int `json:"failed"`
+	Ready      int `json:"ready"`
+}
+
+type statusCriteria struct {
+	Total int `json:"total"`
+	Met   int `json:"met"`
+}
+
+type statusCost struct {
+	Spent  float64 `json:"spent"`
+	Budget float64 `json:"budget,omitempty"`
+	Runs   int     `json:"runs"`
+}
+
+type statusTask struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show workspace status",
@@ -15,28 +69,106 @@ var statusCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		report := newStatusReport(ws)
+		return render(report, func() error {
+			printStatus(ws, report)
+			return nil
+		})
+	},
+}
 
-		status := ws.Status()
+func newStatusReport(ws *workspace.Workspace) statusReport {
+	status := ws.Status()
+	report := statusReport{
+		Feature:  status.Feature,
+		Backend:  status.Backend,
+		ReadOnly: ws.Tasks.ReadOnly(),
+		Tasks: statusTasks{
+			Total:      status.TotalTasks,
+			Pending:    status.PendingTasks,
+			InProgress: status.InProgressTasks,
+			Complete:   status.CompleteTasks,
+			Failed:     status.FailedTasks,
+			Ready:      status.ReadyTasks,
+		},
+		StaleTasks: status.StaleTasks,
+		Ready:      []statusTask{},
+	}
+	if schedule, err := ws.Config.FreezeSchedule(); err == nil {
+		if err := schedule.Check(time.Now()); err != nil {
+			report.Frozen = err.Error()
+		}
+	}
+	if status.CriteriaTotal > 0 {
+		report.Criteria = &statusCriteria{Total: status.CriteriaTotal, Met: status.CriteriaMet}
+	}
+	if summary, err := ws.Costs().Summary(); err == nil && (summary.Runs > 0 || ws.Config.Budget > 0) {
+		report.Cost = &statusCost{Spent: summary.Spent, Budget: ws.Config.Budget, Runs: summary.Runs}
+	}
+	for _, t := range ws.GetReadyTasks() {
+		report.Ready = append(report.Ready, statusTask{ID: t.ID, Title: t.Title})
+	}
+	report.Breakers = []statusBreaker{}
+	if stats, err := ws.Health().Stats(); err == nil {
+		for _, st := range stats {
+			report.Breakers = append(report.Breakers, statusBreaker{
+				Backend:   st.Backend,
+				State:     st.Breaker,
+				AsOf:      st.LastRun,
+				LastError: st.LastError,
+			})
+		}
+	}
+	return report
+}
 
-		fmt.Printf("Feature: %s\n", status.Feature)
-		fmt.Printf("Backend: %s\n", status.Backend)
+func printStatus(ws *workspace.Workspace, report statusReport) {
+	fmt.Printf("Feature: %s\n", report.Feature)
+	fmt.Printf("Backend: %s\n", report.Backend)
+	if report.ReadOnly {
+		fmt.Printf("⚠ Task manifest uses schema %d (this flo supports %d): read-only, upgrade flo to modify it\n",
+			ws.Tasks.Schema(), task.SchemaVersion)
+	}
+	if report.Frozen != "" {
+		fmt.Printf("❄ %s\n", report.Frozen)
+	}
+	fmt.Println()
+	fmt.Printf("Tasks: %d total\n", report.Tasks.Total)
+	fmt.Printf("  📋 Pending:     %d\n", report.Tasks.Pending)
+	fmt.Printf("  🔄 In Progress: %d\n", report.Tasks.InProgress)
+	fmt.Printf("  ✅ Complete:    %d\n", report.Tasks.Complete)
+	fmt.Printf("  ❌ Failed:      %d\n", report.Tasks.Failed)
+	fmt.Println()
+	fmt.Printf("Ready to start: %d\n", report.Tasks.Ready)
+	if c := report.Criteria; c != nil {
+		fmt.Printf("Acceptance criteria: %d/%d met (%d%%)\n", c.Met, c.Total, c.Met*100/c.Total)
+	}
+	if c := report.Cost; c != nil {
+		if c.Budget > 0 {
+			fmt.Printf("Cost: %s of %s budget (%d runs)\n", cost.Format(c.Spent), cost.Format(c.Budget), c.Runs)
+		} else {
+			fmt.Printf("Cost: %s (%d runs)\n", cost.Format(c.Spent), c.Runs)
+		}
+	}
+	for _, b := range report.Breakers {
+		if b.State == "closed" {
+			continue
+		}
+		fmt.Printf("⚡ %s circuit breaker %s as of %s; runs on it may fail fast", b.Backend, strings.ReplaceAll(b.State, "_", "-"), formatRelativeTime(b.AsOf))
+		if b.LastError != "" {
+			fmt.Printf(" (last error: %s)", b.LastError)
+		}
 		fmt.Println()
-		fmt.Printf("Tasks: %d total\n", status.TotalTasks)
-		fmt.Printf("  📋 Pending:     %d\n", status.PendingTasks)
-		fmt.Printf("  🔄 In Progress: %d\n", status.InProgressTasks)
-		fmt.Printf("  ✅ Complete:    %d\n", status.CompleteTasks)
-		fmt.Printf("  ❌ Failed:      %d\n", status.FailedTasks)
+	}
+	if report.StaleTasks > 0 {
+		fmt.Printf("⚠ %d task(s) reference spec sections changed since they were created (flo spec diff)\n", report.StaleTasks)
+	}
+
+	if len(report.Ready) > 0 {
 		fmt.Println()
-		fmt.Printf("Ready to start: %d\n", status.ReadyTasks)
-
-		if status.ReadyTasks > 0 {
-			fmt.Println()
-			fmt.Println("Ready tasks:")
-			for _, t := range ws.GetReadyTasks() {
-				fmt.Printf("  %s: %s\n", t.ID, t.Title)
-			}
+		fmt.Println("Ready tasks:")
+		for _, t := range report.Ready {
+			fmt.Printf("  %s: %s\n", t.ID, t.Title)
 		}
-
-		return nil
-	},
+	}
 }
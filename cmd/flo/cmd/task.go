@@ -1,12 +1,21 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/richgo/flo/pkg/approval"
+	"github.com/richgo/flo/pkg/jsoncompat"
+	"github.com/richgo/flo/pkg/policy"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/transcript"
 	"github.com/richgo/flo/pkg/workspace"
 )
 
@@ -31,32 +40,31 @@ var taskListCmd = &cobra.Command{
 		}
 
 		tasks := ws.ListTasks(listStatus, listRepo)
-
-		if listJSON {
-			data, _ := json.MarshalIndent(tasks, "", "  ")
-			fmt.Println(string(data))
-			return nil
-		}
-
-		if len(tasks) == 0 {
-			fmt.Println("No tasks found.")
-			return nil
+		if tasks == nil {
+			tasks = []*task.Task{}
 		}
 
-		fmt.Printf("Tasks (%d):\n", len(tasks))
-		for _, t := range tasks {
-			deps := ""
-			if len(t.Deps) > 0 {
-				deps = fmt.Sprintf(" [deps: %s]", strings.Join(t.Deps, ", "))
-			}
-			repo := ""
-			if t.Repo != "" {
-				repo = fmt.Sprintf(" (%s)", t.Repo)
+		jsonAlias(listJSON)
+		return render(tasks, func() error {
+			if len(tasks) == 0 {
+				fmt.Println("No tasks found.")
+				return nil
 			}
-			fmt.Printf("  %s [%s] %s%s%s\n", t.ID, t.Status, t.Title, repo, deps)
-		}
 
-		return nil
+			fmt.Printf("Tasks (%d):\n", len(tasks))
+			for _, t := range tasks {
+				deps := ""
+				if len(t.Deps) > 0 {
+					deps = fmt.Sprintf(" [deps: %s]", strings.Join(t.Deps, ", "))
+				}
+				repo := ""
+				if t.Repo != "" {
+					repo = fmt.Sprintf(" (%s)", t.Repo)
+				}
+				fmt.Printf("  %s [%s] %s%s%s\n", t.ID, t.Status, t.Title, repo, deps)
+			}
+			return nil
+		})
 	},
 }
 
@@ -65,6 +73,11 @@ var createRepo string
 var createDeps string
 var createPriority int
 var createType string
+var createSpecRef string
+var createCriteria string
+var createScope string
+var createMutex string
+var createParent string
 
 var taskCreateCmd = &cobra.Command{
 	Use:   "create <title>",
@@ -85,11 +98,42 @@ var taskCreateCmd = &cobra.Command{
 			}
 		}
 
-		task, err := ws.CreateTaskWithType(title, createType, createRepo, deps, createPriority)
+		if err := ws.ValidateSpecRef(createSpecRef); err != nil {
+			return err
+		}
+		criteria := splitCriteria(createCriteria)
+		if err := ws.ValidateCriteria(criteria); err != nil {
+			return err
+		}
+		scope, err := splitScope(createScope)
 		if err != nil {
 			return err
 		}
 
+		priority := createPriority
+		if !cmd.Flags().Changed("priority") {
+			priority = ws.DefaultPriority(createType, createParent)
+		}
+
+		var task *task.Task
+		if createParent != "" {
+			task, err = ws.CreateSubtask(createParent, title, createType, createRepo, deps, priority)
+		} else {
+			task, err = ws.CreateTaskWithType(title, createType, createRepo, deps, priority)
+		}
+		if err != nil {
+			return err
+		}
+		if createSpecRef != "" || len(criteria) > 0 || len(scope) > 0 || createMutex != "" {
+			task.SpecRef = createSpecRef
+			task.Criteria = criteria
+			task.Scope = scope
+			task.Mutex = strings.TrimSpace(createMutex)
+			if err := ws.UpdateTask(task); err != nil {
+				return err
+			}
+		}
+
 		fmt.Printf("✓ Created task: %s\n", task.ID)
 		fmt.Printf("  Title: %s\n", task.Title)
 		if task.Type != "" {
@@ -100,11 +144,113 @@ var taskCreateCmd = &cobra.Command{
 		}
 		if task.Repo != "" {
 			fmt.Printf("  Repo:  %s\n", task.Repo)
+			if _, ok := ws.Config.Repos[task.Repo]; !ok && len(ws.Config.Repos) > 0 {
+				fmt.Printf("  ⚠ Repo '%s' is not linked (flo repo add)\n", task.Repo)
+			}
+		}
+		if task.Parent != "" {
+			fmt.Printf("  Parent: %s\n", task.Parent)
 		}
 		if len(task.Deps) > 0 {
 			fmt.Printf("  Deps:  %s\n", strings.Join(task.Deps, ", "))
 		}
+		if task.SpecRef != "" {
+			fmt.Printf("  Spec:  %s\n", task.SpecRef)
+		}
+		if len(task.Criteria) > 0 {
+			fmt.Printf("  Criteria: %s\n", strings.Join(task.Criteria, ", "))
+		}
+		if len(task.Scope) > 0 {
+			fmt.Printf("  Scope: %s\n", strings.Join(task.Scope, ", "))
+		}
+		if task.Mutex != "" {
+			fmt.Printf("  Mutex: %s\n", task.Mutex)
+		}
+
+		return nil
+	},
+}
+
+// Update flags
+var updateTitle string
+var updateDescription string
+var updatePriority int
+var updateSpecRef string
+var updateCriteria string
+var updateScope string
+var updateMutex string
+var updateSpecAck bool
+
+var taskUpdateCmd = &cobra.Command{
+	Use:   "update <task-id>",
+	Short: "Update task fields",
+	Long: `Update a task's title, description, priority, spec reference,
+acceptance criteria, scope, or mutex.
+
+Spec references point at a section of .flo/SPEC.md by its heading anchor,
+e.g. SPEC.md#oauth, and are checked against the spec. Pass --spec-ref ""
+to clear the reference. Criteria are IDs from 'flo spec criteria'.
+
+The task remembers the spec version its reference was checked against;
+'flo spec diff' flags it when that section changes. --spec-ack accepts the
+current spec as the new baseline.
+
+The scope lists globs of the files the task may change, as policy.deny_paths
+takes them (e.g. pkg/auth/,docs/auth.md); the git_commit MCP tool refuses
+commits touching other files. Pass --scope "" to allow any file.
+
+Tasks with the same mutex, such as db-migrations, never run at once even
+when they don't depend on each other: flo work refuses to start one while
+another is in progress. Pass --mutex "" to clear it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		task, err := ws.GetTask(args[0])
+		if err != nil {
+			return err
+		}
+
+		flags := cmd.Flags()
+		if flags.Changed("title") {
+			task.Title = updateTitle
+		}
+		if flags.Changed("description") {
+			task.Description = updateDescription
+		}
+		if flags.Changed("priority") {
+			task.Priority = updatePriority
+		}
+		if flags.Changed("spec-ref") && updateSpecRef != task.SpecRef {
+			task.SpecRef = updateSpecRef
+			task.SpecVersion = 0 // re-baseline against the current spec
+		}
+		if updateSpecAck {
+			if task.SpecRef == "" {
+				return fmt.Errorf("task %s has no spec ref to acknowledge", task.ID)
+			}
+			task.SpecVersion = 0
+		}
+		if flags.Changed("criteria") {
+			task.Criteria = splitCriteria(updateCriteria)
+		}
+		if flags.Changed("scope") {
+			if task.Scope, err = splitScope(updateScope); err != nil {
+				return err
+			}
+		}
+		if flags.Changed("mutex") {
+			task.Mutex = strings.TrimSpace(updateMutex)
+		}
+
+		if err := ws.UpdateTask(task); err != nil {
+			return err
+		}
 
+		fmt.Printf("✓ Updated task: %s\n", task.ID)
 		return nil
 	},
 }
@@ -124,13 +270,41 @@ var taskGetCmd = &cobra.Command{
 			return err
 		}
 
-		data, _ := json.MarshalIndent(task, "", "  ")
-		fmt.Println(string(data))
-
-		return nil
+		data, err := taskDetails(ws, task)
+		if err != nil {
+			return err
+		}
+		// The details are JSON for people too
+		return render(json.RawMessage(data), func() error {
+			fmt.Println(string(data))
+			return nil
+		})
 	},
 }
 
+// taskDetails encodes a task for 'flo task get', adding the priority it is
+// scheduled with and, when that isn't its own, why.
+func taskDetails(ws *workspace.Workspace, t *task.Task) ([]byte, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	priority, source := ws.EffectivePriority(t)
+	extra := jsoncompat.Fields{"effective_priority": json.RawMessage(strconv.Itoa(priority))}
+	if source != "" {
+		quoted, _ := json.Marshal(source)
+		extra["priority_source"] = quoted
+	}
+	if data, err = jsoncompat.Merge(data, extra); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
 var taskStartCmd = &cobra.Command{
 	Use:   "start <task-id>",
 	Short: "Mark task as in progress",
@@ -150,6 +324,8 @@ var taskStartCmd = &cobra.Command{
 	},
 }
 
+var completeFull bool
+
 var taskCompleteCmd = &cobra.Command{
 	Use:   "complete <task-id>",
 	Short: "Mark task as complete",
@@ -160,6 +336,7 @@ var taskCompleteCmd = &cobra.Command{
 			return err
 		}
 
+		ws.FullTests = completeFull
 		if err := ws.SetTaskStatus(args[0], "complete"); err != nil {
 			return err
 		}
@@ -188,7 +365,179 @@ var taskFailCmd = &cobra.Command{
 	},
 }
 
+// Retry flags
+var retryAllFailed bool
+var retryModel string
+
+var taskRetryCmd = &cobra.Command{
+	Use:   "retry [task-id]",
+	Short: "Send failed tasks back to pending",
+	Long: `Send a failed task, or with --all-failed every failed task, back to
+pending so flo work picks it up again.
+
+A task that has failed escalation.after times (or its task type's
+escalation.after) is moved to escalation.model, e.g. from claude/sonnet to
+claude/opus. --model sets the model to retry on instead. Retries are
+recorded in the task's history.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (len(args) == 1) == retryAllFailed {
+			return withExitCode(ExitValidation, fmt.Errorf("give a task ID or --all-failed"))
+		}
+
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		ids := args
+		if retryAllFailed {
+			ids = nil
+			for _, t := range ws.ListTasks(string(task.StatusFailed), "") {
+				ids = append(ids, t.ID)
+			}
+			sort.Strings(ids)
+			if len(ids) == 0 {
+				fmt.Println("No failed tasks.")
+				return nil
+			}
+		}
+
+		for _, id := range ids {
+			before, err := ws.GetTask(id)
+			if err != nil {
+				return err
+			}
+			oldModel := before.Model
+			t, err := ws.RetryTask(id, retryModel)
+			if err != nil {
+				return err
+			}
+			if t.Model != oldModel {
+				fmt.Printf("✓ Task %s retried on %s (retry %d)\n", t.ID, t.Model, t.Retries)
+			} else {
+				fmt.Printf("✓ Task %s retried (retry %d)\n", t.ID, t.Retries)
+			}
+		}
+		return nil
+	},
+}
+
+var taskContinueCmd = &cobra.Command{
+	Use:   "continue <task-id> <instructions...>",
+	Short: "Run the agent again on a task with further instructions",
+	Long: `Run the agent on a pending or failed task again to refine its earlier
+work, rather than starting over. The agent gets the task's usual prompt,
+the end of its latest run transcript and the instructions, and picks up
+from the changes still in the worktree.
+
+A failed task goes back to pending without counting as a retry. Tasks
+awaiting review are sent back with flo review --feedback instead.
+
+Examples:
+  flo task continue t-003 "also handle expired tokens"
+  flo task continue t-003 rename the helper to parseToken`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		taskID := args[0]
+		instructions := strings.TrimSpace(strings.Join(args[1:], " "))
+		if instructions == "" {
+			return withExitCode(ExitValidation, fmt.Errorf("give instructions to continue with"))
+		}
+
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		t, err := ws.GetTask(taskID)
+		if err != nil {
+			return err
+		}
+
+		switch t.Status {
+		case task.StatusInProgress:
+			if req, err := ws.Review(t.ID); err == nil && req != nil && req.Status == approval.StatusPending {
+				return fmt.Errorf("task %s is awaiting review; send it back with: flo review %s --feedback %q", t.ID, t.ID, instructions)
+			}
+			return fmt.Errorf("task %s is running", t.ID)
+		case task.StatusComplete:
+			return fmt.Errorf("task %s is complete", t.ID)
+		case task.StatusFailed:
+			if err := ws.SetTaskStatus(t.ID, string(task.StatusPending)); err != nil {
+				return err
+			}
+		}
+
+		return runWork(ws, t.ID, instructions)
+	},
+}
+
+// Logs flags
+var logsAll bool
+var logsRaw bool
+
+var taskLogsCmd = &cobra.Command{
+	Use:   "logs <task-id>",
+	Short: "Show agent run transcripts for a task",
+	Long: `Show the transcript of the most recent agent run for a task.
+
+Encrypted transcripts are decrypted transparently when the workspace key
+(.flo/keys/workspace.key or FLO_WORKSPACE_KEY) is available.
+
+Secret values are redacted at display time. Use --raw to disable redaction;
+this requires logs.allow_raw: true in .flo/config.yaml.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		if _, err := ws.GetTask(args[0]); err != nil {
+			return err
+		}
+
+		redactor, err := displayRedactor(ws, logsRaw)
+		if err != nil {
+			return err
+		}
+
+		paths, err := transcript.List(ws.TranscriptDir(), args[0])
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			fmt.Printf("No transcripts for task %s.\n", args[0])
+			return nil
+		}
+		if !logsAll {
+			paths = paths[len(paths)-1:]
+		}
+
+		loc := displayLocation(ws)
+		for _, path := range paths {
+			entries, err := ws.ReadTranscript(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+			}
+			fmt.Printf("=== %s ===\n", filepath.Base(path))
+			for _, e := range entries {
+				fmt.Printf("[%s] %s: %s\n", e.Timestamp.In(loc).Format("15:04:05"), e.Type, redactor.String(e.Content))
+			}
+		}
+
+		return nil
+	},
+}
+
 func init() {
+	// Logs command
+	taskCompleteCmd.Flags().BoolVar(&completeFull, "full", false, "Run every test, not just those affected by the change (with tdd.affected)")
+	taskRetryCmd.Flags().BoolVar(&retryAllFailed, "all-failed", false, "Retry every failed task")
+	taskRetryCmd.Flags().StringVar(&retryModel, "model", "", "Model to retry on, as backend/model (overrides escalation)")
+	taskLogsCmd.Flags().BoolVar(&logsAll, "all", false, "Show all runs, not just the latest")
+	taskLogsCmd.Flags().BoolVar(&logsRaw, "raw", false, "Show transcripts without redaction (requires logs.allow_raw)")
+
 	// List command
 	taskListCmd.Flags().StringVar(&listStatus, "status", "", "Filter by status (pending, in_progress, complete, failed)")
 	taskListCmd.Flags().StringVar(&listRepo, "repo", "", "Filter by repository")
@@ -197,15 +546,59 @@ func init() {
 	// Create command
 	taskCreateCmd.Flags().StringVar(&createRepo, "repo", "", "Target repository")
 	taskCreateCmd.Flags().StringVar(&createDeps, "deps", "", "Comma-separated dependency task IDs")
-	taskCreateCmd.Flags().IntVar(&createPriority, "priority", 0, "Task priority (0 = highest)")
+	taskCreateCmd.Flags().IntVar(&createPriority, "priority", 0, "Task priority (0 = highest; defaults to the task type's or parent's priority)")
+	taskCreateCmd.Flags().StringVar(&createParent, "parent", "", "Parent task ID (makes this a subtask)")
 	taskCreateCmd.Flags().StringVar(&createType, "type", "", "Task type (e.g., build, refactor, test, fix)")
+	taskCreateCmd.Flags().StringVar(&createSpecRef, "spec-ref", "", "Spec section the task implements (e.g., SPEC.md#oauth)")
+	taskCreateCmd.Flags().StringVar(&createCriteria, "criteria", "", "Comma-separated acceptance criteria IDs the task satisfies (e.g., AC-1,AC-3)")
+	taskCreateCmd.Flags().StringVar(&createScope, "scope", "", "Comma-separated globs of the files the task may change (e.g., pkg/auth/,docs/auth.md)")
+	taskCreateCmd.Flags().StringVar(&createMutex, "mutex", "", "Resource the task needs to itself; tasks with the same mutex never run at once (e.g., db-migrations)")
+
+	// Update command
+	taskUpdateCmd.Flags().StringVar(&updateTitle, "title", "", "New title")
+	taskUpdateCmd.Flags().StringVar(&updateDescription, "description", "", "New description")
+	taskUpdateCmd.Flags().IntVar(&updatePriority, "priority", 0, "New priority (0 = highest)")
+	taskUpdateCmd.Flags().StringVar(&updateSpecRef, "spec-ref", "", "Spec section the task implements (e.g., SPEC.md#oauth)")
+	taskUpdateCmd.Flags().BoolVar(&updateSpecAck, "spec-ack", false, "Accept the current spec section as reviewed (clears the stale flag)")
+	taskUpdateCmd.Flags().StringVar(&updateCriteria, "criteria", "", "Comma-separated acceptance criteria IDs the task satisfies (e.g., AC-1,AC-3)")
+	taskUpdateCmd.Flags().StringVar(&updateScope, "scope", "", "Comma-separated globs of the files the task may change (e.g., pkg/auth/,docs/auth.md)")
+	taskUpdateCmd.Flags().StringVar(&updateMutex, "mutex", "", "Resource the task needs to itself; tasks with the same mutex never run at once (e.g., db-migrations)")
 
 	taskCmd.AddCommand(taskListCmd)
 	taskCmd.AddCommand(taskCreateCmd)
 	taskCmd.AddCommand(taskGetCmd)
+	taskCmd.AddCommand(taskUpdateCmd)
 	taskCmd.AddCommand(taskStartCmd)
 	taskCmd.AddCommand(taskCompleteCmd)
 	taskCmd.AddCommand(taskFailCmd)
+	taskCmd.AddCommand(taskRetryCmd)
+	taskCmd.AddCommand(taskContinueCmd)
+	taskCmd.AddCommand(taskLogsCmd)
+}
+
+// splitCriteria parses a comma-separated list of criterion IDs.
+func splitCriteria(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.ToUpper(strings.TrimSpace(id)); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// splitScope parses and checks a comma-separated list of scope globs.
+func splitScope(s string) ([]string, error) {
+	var globs []string
+	for _, glob := range strings.Split(s, ",") {
+		if glob = strings.TrimSpace(glob); glob != "" {
+			globs = append(globs, glob)
+		}
+	}
+	if _, err := policy.NewScope(globs); err != nil {
+		return nil, withExitCode(ExitValidation, err)
+	}
+	return globs, nil
 }
 
 func loadWorkspace() (*workspace.Workspace, error) {
@@ -213,5 +606,12 @@ func loadWorkspace() (*workspace.Workspace, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current directory: %w", err)
 	}
-	return workspace.Load(cwd)
+	ws, err := workspace.Load(cwd)
+	if err != nil {
+		return nil, err
+	}
+	secret := lazySecrets(ws)
+	ws.Webhooks.SetSecretLookup(secret)
+	ws.Issues.SetSecretLookup(secret)
+	return trackWorkspace(ws), nil
 }
@@ -0,0 +1,399 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/spec"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/transcript"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+// showHistory is how many audit events `flo task show` lists.
+var showHistory int
+
+// specExcerptLines is how much of the spec section `flo task show` prints.
+const specExcerptLines = 12
+
+var taskShowCmd = &cobra.Command{
+	Use:   "show <task-id>",
+	Short: "Show a task for people to read",
+	Long: `Show a task with its description, dependency tree, subtasks, the spec
+section it implements, the result of its last agent run, recent history
+from the audit log and the files it produced.
+
+flo task get prints the task itself as JSON; --output json or yaml prints
+everything shown here for scripts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+		t, err := ws.GetTask(args[0])
+		if err != nil {
+			return err
+		}
+		view, err := newTaskView(ws, t, showHistory)
+		if err != nil {
+			return err
+		}
+		return render(view, func() error {
+			printTaskView(view, displayLocation(ws))
+			return nil
+		})
+	},
+}
+
+// taskView is what `flo task show` reports.
+type taskView struct {
+	Task              *task.Task     `json:"task"`
+	EffectivePriority int            `json:"effective_priority"`
+	PrioritySource    string         `json:"priority_source,omitempty"`
+	Deps              []depNode      `json:"deps"`
+	Subtasks          []statusTask   `json:"subtasks"`
+	Spec              *specExcerpt   `json:"spec,omitempty"`
+	LastRun           *lastRun       `json:"last_run,omitempty"`
+	History           []historyEvent `json:"history"`
+	Artifacts         []artifact     `json:"artifacts"`
+}
+
+// depNode is a dependency and, recursively, its own dependencies.
+type depNode struct {
+	ID     string      `json:"id"`
+	Title  string      `json:"title,omitempty"`
+	Status task.Status `json:"status,omitempty"`
+	// Missing is set when the dependency isn't in the manifest.
+	Missing bool      `json:"missing,omitempty"`
+	Deps    []depNode `json:"deps"`
+}
+
+type specExcerpt struct {
+	Ref     string `json:"ref"`
+	Heading string `json:"heading,omitempty"`
+	Content string `json:"content,omitempty"`
+	// Error says why the section couldn't be shown.
+	Error string `json:"error,omitempty"`
+}
+
+type lastRun struct {
+	Backend  string        `json:"backend"`
+	Model    string        `json:"model,omitempty"`
+	At       time.Time     `json:"at"`
+	Duration time.Duration `json:"duration_ns"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Retries  int           `json:"retries,omitempty"`
+	Tokens   *cost.Tokens  `json:"tokens,omitempty"`
+	Cost     float64       `json:"cost,omitempty"`
+}
+
+type historyEvent struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Level     audit.Level `json:"level"`
+	Operation string      `json:"operation"`
+	Message   string      `json:"message"`
+}
+
+type artifact struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+}
+
+// newTaskView gathers what 'flo task show' prints about a task, with its
+// last history audit events (-1 for all).
+func newTaskView(ws *workspace.Workspace, t *task.Task, history int) (*taskView, error) {
+	view := &taskView{
+		Task:      t,
+		Deps:      depTree(ws, t.Deps, map[string]bool{t.ID: true}),
+		Subtasks:  []statusTask{},
+		History:   []historyEvent{},
+		Artifacts: []artifact{},
+	}
+	view.EffectivePriority, view.PrioritySource = ws.EffectivePriority(t)
+	for _, sub := range ws.ListTasks("", "") {
+		if sub.Parent == t.ID {
+			view.Subtasks = append(view.Subtasks, statusTask{ID: sub.ID, Title: sub.Title})
+		}
+	}
+	if t.SpecRef != "" {
+		view.Spec = taskSpecExcerpt(ws, t.SpecRef)
+	}
+
+	run, err := taskLastRun(ws, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	view.LastRun = run
+
+	if view.History, err = taskHistory(ws.Root, t.ID, history); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(ws.TaskFilePath(t.ID)); err == nil {
+		view.Artifacts = append(view.Artifacts, artifact{Kind: "task file", Path: ws.TaskFilePath(t.ID)})
+	}
+	transcripts, err := transcript.List(ws.TranscriptDir(), t.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range transcripts {
+		view.Artifacts = append(view.Artifacts, artifact{Kind: "transcript", Path: path})
+	}
+	return view, nil
+}
+
+// depTree returns the dependency tree below a task. seen holds the tasks
+// on the path from the root, so a cycle in a hand-edited manifest ends.
+func depTree(ws *workspace.Workspace, ids []string, seen map[string]bool) []depNode {
+	nodes := []depNode{}
+	for _, id := range ids {
+		dep, err := ws.GetTask(id)
+		if err != nil {
+			nodes = append(nodes, depNode{ID: id, Missing: true})
+			continue
+		}
+		node := depNode{ID: id, Title: dep.Title, Status: dep.Status}
+		if !seen[id] {
+			seen[id] = true
+			node.Deps = depTree(ws, dep.Deps, seen)
+			delete(seen, id)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func taskSpecExcerpt(ws *workspace.Workspace, ref string) *specExcerpt {
+	excerpt := &specExcerpt{Ref: ref}
+	_, slug, err := spec.ParseRef(ref)
+	if err != nil {
+		excerpt.Error = err.Error()
+		return excerpt
+	}
+	content, err := ws.ReadSpec()
+	if err != nil {
+		excerpt.Error = err.Error()
+		return excerpt
+	}
+	anchor, body, ok := spec.Section(content, slug)
+	if !ok {
+		excerpt.Error = fmt.Sprintf("no section '#%s' in %s", slug, spec.DefaultFile)
+		return excerpt
+	}
+	excerpt.Heading, excerpt.Content = anchor.Heading, strings.TrimSpace(body)
+	return excerpt
+}
+
+// taskLastRun returns the task's most recent agent run, with its tokens
+// and cost when the cost ledger recorded them.
+func taskLastRun(ws *workspace.Workspace, taskID string) (*lastRun, error) {
+	run, ok, err := ws.Health().LastRun(taskID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	last := &lastRun{
+		Backend:  run.Backend,
+		At:       run.At,
+		Duration: run.Duration,
+		Success:  run.Success,
+		Error:    run.Error,
+		Retries:  run.Retries,
+	}
+	entries, err := ws.Costs().Entries()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if e := entries[i]; e.TaskID == taskID && e.Backend == run.Backend {
+			tokens := e.Tokens
+			last.Model, last.Tokens, last.Cost = e.Model, &tokens, e.Cost
+			break
+		}
+	}
+	return last, nil
+}
+
+// taskHistory returns the last n audit events about a task, oldest first.
+// A negative n returns them all.
+func taskHistory(root, taskID string, n int) ([]historyEvent, error) {
+	lines, err := audit.Tail(root, -1)
+	if err != nil {
+		return nil, err
+	}
+	history := []historyEvent{}
+	for _, line := range lines {
+		event, err := audit.ParseEvent([]byte(line))
+		if err != nil || event.Details["task_id"] != taskID {
+			continue
+		}
+		// The workspace's own events describe the same changes
+		if strings.HasPrefix(event.Operation, "task.registry.") {
+			continue
+		}
+		history = append(history, historyEvent{
+			Timestamp: event.Timestamp,
+			Level:     event.Level,
+			Operation: event.Operation,
+			Message:   event.Message,
+		})
+	}
+	if n >= 0 && len(history) > n {
+		history = history[len(history)-n:]
+	}
+	return history, nil
+}
+
+var statusIcons = map[task.Status]string{
+	task.StatusPending:    "📋",
+	task.StatusInProgress: "🔄",
+	task.StatusComplete:   "✅",
+	task.StatusFailed:     "❌",
+}
+
+func printTaskView(view *taskView, loc *time.Location) {
+	t := view.Task
+	fmt.Printf("%s: %s\n", t.ID, t.Title)
+	fmt.Printf("  Status:   %s %s\n", statusIcons[t.Status], t.Status)
+	priority := fmt.Sprintf("%d", view.EffectivePriority)
+	if view.PrioritySource != "" {
+		priority += fmt.Sprintf(" (%s)", view.PrioritySource)
+	}
+	fmt.Printf("  Priority: %s\n", priority)
+	for _, field := range [][2]string{
+		{"Type", t.Type},
+		{"Model", t.Model},
+		{"Fallback", t.Fallback},
+		{"Repo", t.Repo},
+		{"Parent", t.Parent},
+		{"Criteria", strings.Join(t.Criteria, ", ")},
+		{"Scope", strings.Join(t.Scope, ", ")},
+		{"Mutex", t.Mutex},
+	} {
+		if field[1] != "" {
+			fmt.Printf("  %-9s %s\n", field[0]+":", field[1])
+		}
+	}
+	fmt.Printf("  Created:  %s\n", t.CreatedAt.In(loc).Format("2006-01-02 15:04 MST"))
+	fmt.Printf("  Updated:  %s (%s)\n", t.UpdatedAt.In(loc).Format("2006-01-02 15:04 MST"), formatRelativeTime(t.UpdatedAt))
+
+	if t.Description != "" {
+		fmt.Println("\nDescription:")
+		printIndented(t.Description, "  ")
+	}
+
+	if len(view.Deps) > 0 {
+		fmt.Println("\nDependencies:")
+		printDepTree(view.Deps, "  ")
+	}
+
+	if len(view.Subtasks) > 0 {
+		fmt.Println("\nSubtasks:")
+		for _, sub := range view.Subtasks {
+			fmt.Printf("  %s: %s\n", sub.ID, sub.Title)
+		}
+	}
+
+	if s := view.Spec; s != nil {
+		fmt.Printf("\nSpec: %s", s.Ref)
+		if s.Error != "" {
+			fmt.Printf(" (%s)\n", s.Error)
+		} else {
+			fmt.Printf(" — %s\n", s.Heading)
+			lines := strings.Split(s.Content, "\n")
+			if len(lines) > specExcerptLines {
+				lines = append(lines[:specExcerptLines], "…")
+			}
+			printIndented(strings.Join(lines, "\n"), "  │ ")
+		}
+	}
+
+	if r := view.LastRun; r != nil {
+		fmt.Println("\nLast run:")
+		outcome := "✓ succeeded"
+		if !r.Success {
+			outcome = "✗ failed"
+		}
+		backend := r.Backend
+		if r.Model != "" {
+			backend += "/" + r.Model
+		}
+		fmt.Printf("  %s on %s %s, took %s", outcome, backend, formatRelativeTime(r.At), r.Duration.Round(time.Second))
+		if r.Retries > 0 {
+			fmt.Printf(", %d retries", r.Retries)
+		}
+		fmt.Println()
+		if r.Error != "" {
+			fmt.Printf("  Error: %s\n", r.Error)
+		}
+		if r.Tokens != nil {
+			fmt.Printf("  Tokens: %d (%d in, %d out)", r.Tokens.Total(), r.Tokens.Input, r.Tokens.Output)
+			if r.Cost > 0 {
+				fmt.Printf(", cost %s", cost.Format(r.Cost))
+			}
+			fmt.Println()
+		}
+	}
+
+	if len(view.History) > 0 {
+		fmt.Println("\nHistory:")
+		for _, e := range view.History {
+			fmt.Printf("  %s  %s\n", e.Timestamp.In(loc).Format("2006-01-02 15:04"), e.Message)
+		}
+	}
+
+	if len(view.Artifacts) > 0 {
+		fmt.Println("\nArtifacts:")
+		for _, a := range view.Artifacts {
+			fmt.Printf("  %-11s %s\n", a.Kind+":", displayPath(a.Path))
+		}
+		fmt.Printf("\nRead the latest transcript with flo task logs %s.\n", t.ID)
+	}
+}
+
+// printDepTree prints dependencies as a tree with their status.
+func printDepTree(nodes []depNode, indent string) {
+	for i, node := range nodes {
+		branch, next := "├─ ", "│  "
+		if i == len(nodes)-1 {
+			branch, next = "└─ ", "   "
+		}
+		if node.Missing {
+			fmt.Printf("%s%s%s (missing)\n", indent, branch, node.ID)
+			continue
+		}
+		fmt.Printf("%s%s%s %s: %s\n", indent, branch, statusIcons[node.Status], node.ID, node.Title)
+		printDepTree(node.Deps, indent+next)
+	}
+}
+
+func printIndented(text, prefix string) {
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Println(strings.TrimRight(prefix+line, " "))
+	}
+}
+
+// displayPath returns path relative to the working directory when it is
+// below it.
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+func init() {
+	taskShowCmd.Flags().IntVar(&showHistory, "history", 10, "How many audit events to show (-1 for all)")
+	taskCmd.AddCommand(taskShowCmd)
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	usageSince  string
+	usageFormat string
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Usage and cost reporting",
+}
+
+var usageReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report requests, tokens and cost per backend, model and task",
+	Long: `Report the agent runs recorded in .flo/costs.json since a point in time,
+grouped by backend, model and task: requests, tokens of each kind and the
+estimated cost from the configured pricing.
+
+--since takes a duration back from now (12h, 7d, 2w) or a date
+(2026-01-31). --format csv, json and yaml are for spreadsheets and scripts.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		var since time.Time
+		if usageSince != "" {
+			if since, err = cost.ParseSince(usageSince, time.Now().UTC()); err != nil {
+				return err
+			}
+		}
+		entries, err := ws.Costs().Entries()
+		if err != nil {
+			return err
+		}
+		report := cost.NewReport(entries, since)
+
+		format := usageFormat
+		if outputFormat.Machine() {
+			format = string(outputFormat)
+		}
+		switch format {
+		case "table":
+			return printUsageTable(report)
+		case "json", "yaml":
+			return output.Write(os.Stdout, output.Format(format), report)
+		case "csv":
+			return writeUsageCSV(report)
+		}
+		return fmt.Errorf("unknown format '%s' (table, json, yaml or csv)", usageFormat)
+	},
+}
+
+// printUsageTable prints the report as a table with a total row.
+func printUsageTable(report cost.Report) error {
+	if len(report.Rows) == 0 {
+		fmt.Println("No runs recorded in this period.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "BACKEND\tMODEL\tTASK\tREQUESTS\tTOKENS\tIN/OUT/CACHE R/W\tCOST")
+	fmt.Fprintln(w, "-------\t-----\t----\t--------\t------\t----------------\t----")
+	row := func(r cost.UsageRow) {
+		model, taskID := r.Model, r.TaskID
+		if model == "" {
+			model = "-"
+		}
+		if taskID == "" {
+			taskID = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d/%d/%d/%d\t%s\n",
+			r.Backend, model, taskID, r.Requests, r.Tokens.Total(),
+			r.Tokens.Input, r.Tokens.Output, r.Tokens.CacheRead, r.Tokens.CacheWrite,
+			cost.Format(r.Cost))
+	}
+	for _, r := range report.Rows {
+		row(r)
+	}
+	total := report.Total
+	total.Backend, total.Model, total.TaskID = "TOTAL", " ", " "
+	row(total)
+	return w.Flush()
+}
+
+// writeUsageCSV writes one CSV record per row, with a header.
+func writeUsageCSV(report cost.Report) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"backend", "model", "task", "requests", "input_tokens", "output_tokens",
+		"cache_read_tokens", "cache_write_tokens", "total_tokens", "cost_usd"})
+	for _, r := range report.Rows {
+		w.Write([]string{
+			r.Backend, r.Model, r.TaskID,
+			strconv.Itoa(r.Requests),
+			strconv.Itoa(r.Tokens.Input),
+			strconv.Itoa(r.Tokens.Output),
+			strconv.Itoa(r.Tokens.CacheRead),
+			strconv.Itoa(r.Tokens.CacheWrite),
+			strconv.Itoa(r.Tokens.Total()),
+			strconv.FormatFloat(r.Cost, 'f', 4, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func init() {
+	usageReportCmd.Flags().StringVar(&usageSince, "since", "", "Only runs since a duration ago (12h, 7d, 2w) or a date (2026-01-31); default all")
+	usageReportCmd.Flags().StringVar(&usageFormat, "format", "table", "Output format: table, json, yaml or csv")
+
+	usageCmd.AddCommand(usageReportCmd)
+	rootCmd.AddCommand(usageCmd)
+}
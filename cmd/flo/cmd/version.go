@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"sort"
 
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/gate"
+	"github.com/richgo/flo/pkg/release"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -13,17 +20,231 @@ var (
 	date    = "unknown"
 )
 
+var (
+	versionCheck bool
+	versionJSON  bool
+)
+
+// Workspace compatibility with this binary.
+const (
+	compatOK          = "compatible"
+	compatUpgradeFlo  = "binary_older"
+	compatUpgradesWks = "binary_newer"
+)
+
+// versionReport is what `flo version` reports.
+type versionReport struct {
+	Version   string           `json:"version"`
+	Commit    string           `json:"commit"`
+	Built     string           `json:"built"`
+	Workspace *workspaceCompat `json:"workspace,omitempty"`
+	Plugins   []pluginVersion  `json:"plugins,omitempty"`
+	Update    *updateAvailable `json:"update,omitempty"`
+}
+
+// workspaceCompat compares the workspace's formats with this binary's.
+type workspaceCompat struct {
+	Root              string `json:"root"`
+	ConfigVersion     int    `json:"config_version"`
+	ConfigSupported   int    `json:"config_supported"`
+	ManifestSchema    int    `json:"manifest_schema"`
+	ManifestSupported int    `json:"manifest_supported"`
+	Status            string `json:"status"`
+	Message           string `json:"message"`
+}
+
+// pluginVersion is the version a gate plugin reports.
+type pluginVersion struct {
+	Gate    string `json:"gate"`
+	Plugin  string `json:"plugin"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// updateAvailable describes the latest release.
+type updateAvailable struct {
+	Latest    string `json:"latest,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Available bool   `json:"available"`
+	Error     string `json:"error,omitempty"`
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
-	Long:  `Display the version, build commit, and build date of flo.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("flo version %s\n", version)
-		fmt.Printf("  commit: %s\n", commit)
-		fmt.Printf("  built:  %s\n", date)
+	Long: `Display the version, build commit, and build date of flo.
+
+With --check, also report whether this flo can work on the current
+workspace (its config version and task manifest schema against the ones
+this binary supports), the versions of the workspace's gate plugins (as
+printed by '<plugin> --version'), and whether a newer release is
+available. The check fails when the workspace needs a newer flo.`,
+	Args: cobra.NoArgs,
+	// The report above explains a failed check
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report := versionReport{Version: version, Commit: commit, Built: date}
+		if versionCheck {
+			ctx := context.Background()
+			if ws, err := loadWorkspace(); err == nil {
+				report.Workspace = checkWorkspaceCompat(ws)
+				report.Plugins = pluginVersions(ctx, ws)
+			}
+			report.Update = checkForUpdate(ctx)
+		}
+
+		jsonAlias(versionJSON)
+		if err := render(report, func() error {
+			printVersionReport(report)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if report.Workspace != nil && report.Workspace.Status == compatUpgradeFlo {
+			return fmt.Errorf("workspace requires a newer flo")
+		}
+		return nil
 	},
 }
 
+// checkWorkspaceCompat compares the workspace's config version and
+// manifest schema with those this binary supports.
+func checkWorkspaceCompat(ws *workspace.Workspace) *workspaceCompat {
+	c := &workspaceCompat{
+		Root:              ws.Root,
+		ConfigVersion:     ws.Config.Version,
+		ConfigSupported:   config.CurrentVersion,
+		ManifestSchema:    ws.Tasks.Schema(),
+		ManifestSupported: task.SchemaVersion,
+	}
+	// Manifests without a schema predate versioning
+	if c.ManifestSchema == 0 {
+		c.ManifestSchema = 1
+	}
+
+	switch {
+	case c.ConfigVersion > c.ConfigSupported || c.ManifestSchema > c.ManifestSupported:
+		c.Status = compatUpgradeFlo
+		c.Message = "the workspace was written by a newer flo and is read-only with this one; upgrade flo"
+	case c.ConfigVersion < c.ConfigSupported || c.ManifestSchema < c.ManifestSupported:
+		c.Status = compatUpgradesWks
+		c.Message = fmt.Sprintf("the workspace was written by an older flo; the task manifest is upgraded to schema %d on the next save", c.ManifestSupported)
+	default:
+		c.Status = compatOK
+		c.Message = "this flo reads and writes the workspace's formats"
+	}
+	return c
+}
+
+// pluginVersions asks each gate plugin configured in the workspace for its
+// version.
+func pluginVersions(ctx context.Context, ws *workspace.Workspace) []pluginVersion {
+	cfgs := append([]config.GateConfig(nil), ws.Config.Gates...)
+	types := make([]string, 0, len(ws.Config.TaskTypes))
+	for name := range ws.Config.TaskTypes {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	for _, name := range types {
+		cfgs = append(cfgs, ws.Config.TaskTypes[name].Gates...)
+	}
+
+	var versions []pluginVersion
+	seen := make(map[string]bool)
+	for _, cfg := range cfgs {
+		if cfg.Plugin == "" || seen[cfg.Plugin] {
+			continue
+		}
+		seen[cfg.Plugin] = true
+
+		pv := pluginVersion{Gate: cfg.Name, Plugin: cfg.Plugin}
+		g, err := gate.New(cfg, ws.Root)
+		if err != nil {
+			pv.Error = err.Error()
+			versions = append(versions, pv)
+			continue
+		}
+		versioned, ok := g.(interface {
+			Version(ctx context.Context) (string, error)
+		})
+		if !ok {
+			continue
+		}
+		if v, err := versioned.Version(ctx); err != nil {
+			pv.Error = err.Error()
+		} else {
+			pv.Version = v
+		}
+		versions = append(versions, pv)
+	}
+	return versions
+}
+
+// checkForUpdate looks up the latest release and whether it is newer.
+func checkForUpdate(ctx context.Context) *updateAvailable {
+	u := &updateAvailable{}
+	rel, err := release.NewChecker("").Latest(ctx)
+	if err != nil {
+		u.Error = err.Error()
+		return u
+	}
+	u.Latest = rel.Version
+	u.URL = rel.URL
+	cmp, err := release.Compare(version, rel.Version)
+	if err != nil {
+		u.Error = fmt.Sprintf("can't compare build '%s' with releases", version)
+		return u
+	}
+	u.Available = cmp < 0
+	return u
+}
+
+// printVersionReport prints the report for people.
+func printVersionReport(r versionReport) {
+	fmt.Printf("flo version %s\n", r.Version)
+	fmt.Printf("  commit: %s\n", r.Commit)
+	fmt.Printf("  built:  %s\n", r.Built)
+
+	if w := r.Workspace; w != nil {
+		mark := "✓"
+		if w.Status == compatUpgradeFlo {
+			mark = "✗"
+		}
+		fmt.Printf("\nWorkspace: %s\n", w.Root)
+		fmt.Printf("  config version:  %d (supported: %d)\n", w.ConfigVersion, w.ConfigSupported)
+		fmt.Printf("  manifest schema: %d (supported: %d)\n", w.ManifestSchema, w.ManifestSupported)
+		fmt.Printf("  %s %s\n", mark, w.Message)
+	}
+
+	if len(r.Plugins) > 0 {
+		fmt.Println("\nPlugins:")
+		for _, p := range r.Plugins {
+			v := p.Version
+			if p.Error != "" {
+				v = "unknown (" + p.Error + ")"
+			}
+			fmt.Printf("  %s (%s): %s\n", p.Gate, p.Plugin, v)
+		}
+	}
+
+	if u := r.Update; u != nil {
+		fmt.Println()
+		switch {
+		case u.Available:
+			fmt.Printf("⬆ flo %s is available: %s\n", u.Latest, u.URL)
+		case u.Error != "" && u.Latest != "":
+			fmt.Printf("Latest release: %s (%s)\n", u.Latest, u.Error)
+		case u.Error != "":
+			fmt.Printf("Update check failed: %s\n", u.Error)
+		default:
+			fmt.Printf("✓ flo is up to date (latest: %s)\n", u.Latest)
+		}
+	}
+}
+
 func init() {
+	versionCmd.Flags().BoolVar(&versionCheck, "check", false, "Check workspace compatibility, plugin versions and available updates")
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Output as JSON")
 	rootCmd.AddCommand(versionCmd)
 }
@@ -3,20 +3,50 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/richgo/flo/pkg/agent"
+	"github.com/richgo/flo/pkg/approval"
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/correlation"
+	"github.com/richgo/flo/pkg/coverage"
+	"github.com/richgo/flo/pkg/events"
+	"github.com/richgo/flo/pkg/flags"
+	"github.com/richgo/flo/pkg/health"
+	"github.com/richgo/flo/pkg/inflight"
+	"github.com/richgo/flo/pkg/notify"
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/policy"
 	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/report"
+	"github.com/richgo/flo/pkg/sandbox"
+	"github.com/richgo/flo/pkg/secrets"
 	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/tdd"
+	"github.com/richgo/flo/pkg/telemetry"
+	"github.com/richgo/flo/pkg/transcript"
 	"github.com/richgo/flo/pkg/workspace"
 )
 
 var workBackend string
+var workIgnoreFreeze bool
+var workIgnoreBudget bool
+var workFull bool
+
+// estimatedRunTokens is the token usage charged for a run whose backend
+// doesn't report its usage.
+var estimatedRunTokens = cost.Tokens{Input: 10000}
 
 var workCmd = &cobra.Command{
 	Use:   "work <task-id>",
@@ -29,110 +59,277 @@ The agent will:
 3. Run tests (TDD enforcement)
 4. Complete the task when tests pass
 
-Uses the configured backend (claude or copilot) unless overridden.`,
-	Args: cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		taskID := args[0]
+Uses the configured backend (claude or copilot) unless overridden.
+
+Runs are refused during the freeze windows configured under freeze: in
+.flo/config.yaml, leaving the task pending; --ignore-freeze overrides this
+and is recorded in the audit log.
+
+A task with a mutex (flo task create --mutex) doesn't start while another
+task with the same mutex is in progress.
+
+With concurrency: set, a run waits while its repo has concurrency.per_repo
+runs in flight (concurrency.repos overrides it per repo), and each attempt
+waits while its backend has concurrency.backends sessions, counting every
+flo process in the workspace.
+
+When budget: is set, a run is also refused if the cost so far plus an
+estimate of the run (the average run so far, or the model's price under
+pricing: for an estimated run) would exceed it; --ignore-budget overrides
+this.
 
+With tdd.affected set, tests run only for the packages or Bazel targets
+affected by the change; --full runs every test.
+
+Task types with approval: required hold the agent's changes for review
+instead of completing: the task stays in progress until a reviewer
+approves them with 'flo review <task>', which completes the task and
+commits them.
+
+flo work exits with 3 when the agent leaves the task failed, and 4 when
+every backend it could use is out of quota.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		ws, err := loadWorkspace()
 		if err != nil {
 			return err
 		}
+		return runWork(ws, args[0], "")
+	},
+}
 
-		// Get the task
-		t, err := ws.GetTask(taskID)
-		if err != nil {
-			return err
-		}
+// runWork runs the agent on a pending task of ws. followUp, when set,
+// continues earlier work on the task: the agent gets the instructions,
+// with the end of its latest transcript as context.
+func runWork(ws *workspace.Workspace, taskID, followUp string) error {
+	// Refuse before claiming the task so offline runs leave no trace
+	if err := offline.Check("flo work"); err != nil {
+		return err
+	}
 
-		// Check task is ready
-		if t.Status != task.StatusPending {
-			return fmt.Errorf("task %s is not pending (status: %s)", taskID, t.Status)
-		}
+	ws.FullTests = workFull
+	defer notify.Default().Subscribe(events.Default())()
 
-		// Check deps complete
-		ready := ws.GetReadyTasks()
-		isReady := false
-		for _, r := range ready {
-			if r.ID == taskID {
-				isReady = true
-				break
-			}
-		}
-		if !isReady {
-			return fmt.Errorf("task %s has incomplete dependencies", taskID)
-		}
+	// Get the task
+	t, err := ws.GetTask(taskID)
+	if err != nil {
+		return err
+	}
 
-		// Try to read task.md file to get model from frontmatter
-		taskMDPath := filepath.Join(ws.Root, ".flo", "tasks", fmt.Sprintf("TASK-%s.md", taskID))
-		if taskFromFile, err := task.ParseTaskFile(taskMDPath); err == nil && taskFromFile.Model != "" {
-			// Update task with model from frontmatter
-			t.Model = taskFromFile.Model
-			t.Fallback = taskFromFile.Fallback
+	// Check task is ready
+	if t.Status != task.StatusPending {
+		return fmt.Errorf("task %s is not pending (status: %s)", taskID, t.Status)
+	}
+
+	// Tasks sharing a mutex never run at once
+	if err := ws.Tasks.CheckMutex(t); err != nil {
+		return err
+	}
+
+	// Check deps complete
+	ready := ws.GetReadyTasks()
+	isReady := false
+	for _, r := range ready {
+		if r.ID == taskID {
+			isReady = true
+			break
 		}
+	}
+	if !isReady {
+		return fmt.Errorf("task %s has incomplete dependencies", taskID)
+	}
 
-		// Determine backend and model
-		backendName := ws.Backend
-		model := ""
-		
-		if workBackend != "" {
-			backendName = workBackend
-		} else if t.Model != "" {
-			// Parse model format: "backend/model" (e.g., "claude/sonnet", "copilot/gpt-4")
-			parts := strings.Split(t.Model, "/")
-			if len(parts) == 2 {
-				backendName = parts[0]
-				model = parts[1]
-			}
+	// Run in the checkout of the task's repo, which must be cloned
+	if _, err := ws.TaskRoot(t); err != nil {
+		return withExitCode(ExitValidation, err)
+	}
+
+	// Don't start runs during a freeze; the task stays pending
+	if err := checkFreeze(ws, taskID); err != nil {
+		return err
+	}
+
+	// Try to read task.md file to get model from frontmatter
+	taskMDPath := filepath.Join(ws.Root, ".flo", "tasks", fmt.Sprintf("TASK-%s.md", taskID))
+	if taskFromFile, err := task.ParseTaskFile(taskMDPath); err == nil && taskFromFile.Model != "" {
+		// Update task with model from frontmatter
+		t.Model = taskFromFile.Model
+		t.Fallback = taskFromFile.Fallback
+	}
+
+	// Determine backend and model
+	backendName := ws.Backend
+	model := ""
+	
+	if workBackend != "" {
+		backendName = workBackend
+	} else if t.Model != "" {
+		// Parse model format: "backend/model" (e.g., "claude/sonnet", "copilot/gpt-4")
+		parts := strings.Split(t.Model, "/")
+		if len(parts) == 2 {
+			backendName = parts[0]
+			model = parts[1]
 		}
+	}
 
-		fmt.Printf("🚀 Starting work on task: %s\n", taskID)
-		fmt.Printf("   Title: %s\n", t.Title)
-		fmt.Printf("   Backend: %s\n", backendName)
-		if model != "" {
-			fmt.Printf("   Model: %s\n", model)
+	// Pause when the run would take the feature over budget
+	if err := checkBudget(ws, taskID, backendName, quotaModel(ws, backendName, model)); err != nil {
+		return err
+	}
+
+	// Correlate everything the run records under one run ID
+	ctx := correlation.WithRun(context.Background())
+	runIDs := correlation.FromContext(ctx)
+	ws.Audit.SetCorrelation(runIDs)
+
+	fmt.Printf("🚀 Starting work on task: %s\n", taskID)
+	fmt.Printf("   Run: %s\n", runIDs.RunID)
+	fmt.Printf("   Title: %s\n", t.Title)
+	fmt.Printf("   Backend: %s\n", backendName)
+	if model != "" {
+		fmt.Printf("   Model: %s\n", model)
+	}
+	if sb := ws.Config.Sandbox; sb.Enabled() {
+		fmt.Printf("   Sandbox: %s (%s)\n", sb.Runtime, sb.Image)
+	}
+
+	// Wait while the task's repo has as many runs in flight as it allows
+	repoKey, repoName := "repo", "the workspace repo"
+	if t.Repo != "" {
+		repoKey, repoName = "repo-"+t.Repo, "repo "+t.Repo
+	}
+	release, err := acquireSlot(ctx, ws, repoKey, "a run slot in "+repoName, ws.Config.Concurrency.RepoLimit(t.Repo))
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Claim the task. Saving fails with a version conflict if another run
+	// changed the manifest since it was loaded, such as by claiming a task
+	// with the same mutex
+	if err := ws.Tasks.SetStatus(t, task.StatusInProgress); err != nil {
+		return err
+	}
+	ws.Tasks.Update(t)
+	if err := ws.Save(); err != nil {
+		return fmt.Errorf("failed to claim task %s: %w", taskID, err)
+	}
+
+	// Initialize quota tracker
+	quotaTracker := initQuotaTracker(quotaPath(ws), ws)
+
+	// Trace the run, with registry operations from here on as children
+	ctx, span := ws.Telemetry.Start(ctx, "flo.work",
+		telemetry.String("flo.run_id", runIDs.RunID),
+		telemetry.String("task.id", taskID),
+		telemetry.String("task.type", t.Type),
+		telemetry.String("flo.backend", backendName))
+	ws.Telemetry.SetParent(span)
+
+	// Share one retry budget between every backend the run tries, and
+	// report on them all
+	run := &workRun{
+		budget:   agent.NewRetryBudget(ws.Config.RetryBudget.MaxRetries, ws.Config.RetryBudget.MaxTime),
+		report:   &report.Report{RunID: runIDs.RunID, TaskID: taskID, Title: t.Title, Started: time.Now()},
+		followUp: followUp,
+	}
+
+	// Attempt to run with primary backend, fallback if needed
+	result, err := runWithFailover(ctx, ws, t, backendName, model, quotaTracker, run)
+	writeRunReport(ws, ws.RepoRoot(t), run.report, result, err)
+	
+	if err != nil && errors.Is(err, agent.ErrRetryBudgetExhausted) {
+		span.End(err)
+		return pauseOnRetryBudget(ws, t, run.budget, err)
+	}
+	if err != nil {
+		span.End(err)
+		events.PublishTo(ws.Audit, audit.LevelError, "Agent run failed", audit.RunFinished{
+			TaskID:  taskID,
+			Title:   t.Title,
+			Backend: backendName,
+			Model:   model,
+			Error:   err.Error(),
+		})
+		err = fmt.Errorf("agent failed: %w", err)
+		if isQuotaError(err) {
+			return withExitCode(ExitQuotaExhausted, err)
 		}
+		return err
+	}
+	span.SetAttributes(telemetry.Bool("flo.success", result.Success))
+	defer span.End(nil)
 
-		// Claim the task
-		if err := t.SetStatus(task.StatusInProgress); err != nil {
+	finished := audit.RunFinished{
+		TaskID:  taskID,
+		Title:   t.Title,
+		Backend: backendName,
+		Model:   model,
+		Success: result.Success,
+		Error:   result.Error,
+	}
+	if result.Success && ws.Config.ReviewRequired(t.Type) {
+		// The task type needs approval: hold the changes for review,
+		// leaving the task in progress until a reviewer decides
+		finished.Review = true
+		events.PublishTo(ws.Audit, audit.LevelInfo, "Agent run finished", finished)
+		req, err := ws.RequestReview(t, reviewGates(result), run.report.Cost())
+		if err != nil {
 			return err
 		}
+		fmt.Printf("\n⏸ Task %s is awaiting review: flo review %s (%s)\n", taskID, taskID, req.ID)
+		return nil
+	}
+	if result.Success {
+		events.PublishTo(ws.Audit, audit.LevelInfo, "Agent run finished", finished)
+		fmt.Printf("\n✅ Task %s completed successfully\n", taskID)
+	} else {
+		events.PublishTo(ws.Audit, audit.LevelWarn, "Agent run finished", finished)
+		fmt.Printf("\n❌ Task %s failed: %s\n", taskID, result.Error)
+		// Revert status
+		ws.Tasks.SetStatus(t, task.StatusFailed)
 		ws.Tasks.Update(t)
 		ws.Save()
+		return withExitCode(ExitTasksFailed, fmt.Errorf("task %s failed", taskID))
+	}
 
-		// Initialize quota tracker
-		quotaPath := filepath.Join(ws.Root, ".flo", "quota.json")
-		quotaTracker := initQuotaTracker(quotaPath, ws)
+	return nil
+}
 
-		// Attempt to run with primary backend, fallback if needed
-		ctx := context.Background()
-		result, err := runWithFailover(ctx, ws, t, backendName, model, quotaTracker)
-		
-		if err != nil {
-			return fmt.Errorf("agent failed: %w", err)
+// reviewGates summarizes a run's verify steps and gates for its review.
+func reviewGates(result *agent.Result) []approval.GateResult {
+	var gates []approval.GateResult
+	if result.Verification != nil {
+		for _, step := range result.Verification.Steps {
+			if !step.Skipped {
+				gates = append(gates, approval.GateResult{Name: "verify: " + step.Name, Passed: step.Passed, Detail: step.Error})
+			}
 		}
-
-		if result.Success {
-			fmt.Printf("\n✅ Task %s completed successfully\n", taskID)
-		} else {
-			fmt.Printf("\n❌ Task %s failed: %s\n", taskID, result.Error)
-			// Revert status
-			t.SetStatus(task.StatusFailed)
-			ws.Tasks.Update(t)
-			ws.Save()
+	}
+	if result.Gates != nil {
+		for _, g := range result.Gates.Gates {
+			gates = append(gates, approval.GateResult{Name: g.Name, Passed: g.Passed, Detail: g.Error})
 		}
+	}
+	return gates
+}
 
-		return nil
-	},
+// workRun is what the attempts of one flo work run share.
+type workRun struct {
+	budget *agent.RetryBudget
+	report *report.Report
+	// followUp is the instructions of 'flo task continue', if any.
+	followUp string
 }
 
 // runWithFailover attempts to run a task with the primary backend, and falls back to the fallback model if quota is exhausted.
-func runWithFailover(ctx context.Context, ws *workspace.Workspace, t *task.Task, backendName, model string, tracker *quota.Tracker) (*agent.Result, error) {
+func runWithFailover(ctx context.Context, ws *workspace.Workspace, t *task.Task, backendName, model string, tracker *quota.Tracker, run *workRun) (*agent.Result, error) {
 	// Try primary backend
-	result, err := runBackend(ctx, ws, t, backendName, model, tracker)
+	result, err := runBackend(ctx, ws, t, backendName, model, tracker, run)
 	
-	// Check if we hit quota exhaustion
-	if err != nil && isQuotaError(err) && t.Fallback != "" {
+	// Check if we hit quota exhaustion; a spent retry budget stops the run
+	if err != nil && isQuotaError(err) && !errors.Is(err, agent.ErrRetryBudgetExhausted) && t.Fallback != "" {
 		fmt.Printf("\n⚠️  Quota exhausted for %s, failing over to %s\n", backendName, t.Fallback)
 		
 		// Parse fallback model
@@ -142,12 +339,12 @@ func runWithFailover(ctx context.Context, ws *workspace.Workspace, t *task.Task,
 			fallbackModel := parts[1]
 			
 			// Record the failover
-			tracker.RecordError(backendName, time.Hour)
+			recordQuotaError(tracker, backendName, quotaModel(ws, backendName, model), err)
 			
 			fmt.Printf("🔄 Retrying with fallback backend: %s/%s\n", fallbackBackend, fallbackModel)
 			
 			// Try fallback
-			result, err = runBackend(ctx, ws, t, fallbackBackend, fallbackModel, tracker)
+			result, err = runBackend(ctx, ws, t, fallbackBackend, fallbackModel, tracker, run)
 		}
 	}
 	
@@ -155,98 +352,142 @@ func runWithFailover(ctx context.Context, ws *workspace.Workspace, t *task.Task,
 }
 
 // runBackend executes a task with a specific backend.
-func runBackend(ctx context.Context, ws *workspace.Workspace, t *task.Task, backendName, model string, tracker *quota.Tracker) (*agent.Result, error) {
-	// Check if backend is exhausted before starting
-	if tracker.IsExhausted(backendName) {
-		return nil, fmt.Errorf("quota exhausted for backend %s", backendName)
+func runBackend(ctx context.Context, ws *workspace.Workspace, t *task.Task, backendName, model string, tracker *quota.Tracker, run *workRun) (*agent.Result, error) {
+	// Check if backend or model is exhausted before starting
+	usedModel := quotaModel(ws, backendName, model)
+	if err := tracker.CheckModel(backendName, usedModel); err != nil {
+		return nil, err
 	}
 
-	// Create backend
-	var backend agent.Backend
-	switch backendName {
-	case "claude":
-		mcpConfig := filepath.Join(ws.Root, ".eas", "mcp.json")
-		// Generate MCP config
-		if err := generateMCPConfig(mcpConfig, ws.Root); err != nil {
-			return nil, fmt.Errorf("failed to generate MCP config: %w", err)
-		}
-		claudeModel := ws.Config.Claude.Model
-		if model != "" {
-			claudeModel = model
-		}
-		backend = agent.NewClaudeBackend(agent.ClaudeConfig{
-			MCPConfig: mcpConfig,
-			Model:     claudeModel,
-		})
-	case "copilot":
-		copilotModel := ws.Config.Copilot.Model
-		if model != "" {
-			copilotModel = model
-		}
-		backend = agent.NewCopilotBackend(agent.CopilotConfig{
-			Model: copilotModel,
+	backend, err := newBackend(ws, backendName, model)
+	if err != nil {
+		return nil, err
+	}
+	// Record rate limits as they happen, so other runs see them while this
+	// one waits
+	if rb, ok := backend.(*agent.RetryableBackend); ok {
+		rb.OnRateLimit(func(wait time.Duration, err error) {
+			recordQuotaError(tracker, backendName, usedModel, err)
 		})
-	default:
-		return nil, fmt.Errorf("unknown backend: %s", backendName)
+		rb.SetBudget(run.budget)
+	}
+
+	// Wait while the backend has as many sessions as it allows
+	release, err := acquireSlot(ctx, ws, "backend-"+backendName, "a session slot on "+backendName, ws.Config.Concurrency.BackendLimit(backendName))
+	if err != nil {
+		return nil, err
 	}
+	defer release()
+
+	// Each attempt at the task, such as a failover, is its own execution
+	ctx = correlation.WithExecution(ctx)
+	ids := correlation.FromContext(ctx)
+	ws.Audit.SetCorrelation(ids)
+	defer ws.Audit.SetCorrelation(correlation.IDs{RunID: ids.RunID})
+	events.PublishTo(ws.Audit, audit.LevelInfo, "Started a task attempt", audit.SessionStarted{
+		TaskID:  t.ID,
+		Backend: backendName,
+		Model:   usedModel,
+	})
+
+	ctx, span := ws.Telemetry.Start(ctx, "flo.backend.session",
+		telemetry.String("flo.run_id", ids.RunID),
+		telemetry.String("flo.execution_id", ids.ExecutionID),
+		telemetry.String("task.id", t.ID),
+		telemetry.String("flo.backend", backendName),
+		telemetry.String("flo.model", usedModel))
+	start := time.Now()
+	result, err := runAgent(ctx, ws, t, backend, backendName, usedModel, tracker, run.followUp)
+	recordHealth(ws, t, backend, backendName, time.Since(start), result, err)
+	recordRunTelemetry(ws, span, backend, backendName, time.Since(start), result, err)
+	run.report.Attempts = append(run.report.Attempts, reportAttempt(ws, backend, backendName, usedModel, time.Since(start), result, err))
+	return result, err
+}
 
+// runAgent starts the backend and runs the agent on a task, recording
+// quota usage against the backend's model. followUp continues earlier
+// work on the task with further instructions.
+func runAgent(ctx context.Context, ws *workspace.Workspace, t *task.Task, backend agent.Backend, backendName, model string, tracker *quota.Tracker, followUp string) (*agent.Result, error) {
 	if err := backend.Start(ctx); err != nil {
 		// Check if this is a quota error
 		if isQuotaError(err) {
-			tracker.RecordError(backendName, time.Hour)
+			recordQuotaError(tracker, backendName, model, err)
 		}
 		return nil, fmt.Errorf("failed to start backend: %w", err)
 	}
 	defer backend.Stop()
 
-	// Read spec for context
-	spec, _ := ws.ReadSpec()
-
-	// Build prompt
-	prompt := fmt.Sprintf(`You are working on task %s in a TDD workflow.
-
-## Task
-Title: %s
-%s
-
-## Feature Specification
-%s
-
-## Instructions
-1. Implement the required changes for this task
-2. Run tests using eas_run_tests to verify your implementation
-3. When tests pass, call eas_task_complete to finish the task
-
-Available tools:
-- eas_task_get: Get task details
-- eas_run_tests: Run tests for the task
-- eas_task_complete: Mark task complete (requires tests to pass)
-- eas_spec_read: Read the feature specification
+	// Build prompt from the task type's template
+	prompt, err := ws.RenderPrompt(t)
+	if followUp != "" {
+		prompt, err = ws.RenderFollowUpPrompt(t, followUp)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-Begin implementing the task.`, t.ID, t.Title, t.Description, spec)
+	// Measure baseline coverage before the agent changes anything
+	gate := ws.TaskTDDGate(t)
+	var baseline *coverage.Report
+	if ws.Config.TDD.CoverageProfile != "" {
+		fmt.Println("   Measuring baseline coverage...")
+		if baseline, err = gate.MeasureCoverage(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: no coverage baseline, so the task's coverage change won't be recorded: %v\n", err)
+		}
+	}
 
 	// Create session
-	session, err := backend.CreateSession(ctx, t, ws.Root)
+	session, err := backend.CreateSession(ctx, t, ws.RepoRoot(t))
 	if err != nil {
 		if isQuotaError(err) {
-			tracker.RecordError(backendName, time.Hour)
+			recordQuotaError(tracker, backendName, model, err)
 		}
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 	defer session.Destroy(ctx)
 
-	// Stream events
+	// Record the run transcript
+	tw, err := ws.NewTranscript(t.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcript: %w", err)
+	}
+	defer tw.Close()
+	tw.SetRedactor(secretRedactor())
+	tw.SetCorrelation(correlation.FromContext(ctx))
+	tw.Write(transcript.EntryPrompt, prompt)
+
+	// Stream events, checking tool calls against the policy
+	pol, err := policy.New(ws.Config.Policy)
+	if err != nil {
+		return nil, err
+	}
+	var calls policy.Violations
+	streamDone := make(chan struct{})
 	go func() {
+		defer close(streamDone)
 		for event := range session.Events() {
+			tw.Write(event.Type, event.Content)
 			switch event.Type {
 			case "message":
 				fmt.Print(event.Content)
 			case "tool_call":
 				fmt.Printf("\n🔧 %s\n", event.Content)
+				for _, v := range pol.CheckToolCall(ws.RepoRoot(t), event.Input) {
+					fmt.Printf("🚫 policy: %s\n", v)
+					calls = append(calls, v)
+				}
+			case "question":
+				fmt.Printf("\n❓ %s\n", event.Content)
+				events.PublishTo(ws.Audit, audit.LevelInfo, "Agent asked a question", audit.QuestionAsked{
+					TaskID:   t.ID,
+					Question: event.Content,
+				})
 			case "complete":
 				fmt.Println("\n✅ Complete")
 			case "error":
 				fmt.Printf("\n❌ Error: %s\n", event.Content)
+			case "retry":
+				// Already printed by the retry config's printRetry
 			}
 		}
 	}()
@@ -254,47 +495,618 @@ Begin implementing the task.`, t.ID, t.Title, t.Description, spec)
 	// Run the agent
 	result, err := session.Run(ctx, prompt)
 	if err != nil {
+		tw.Write(transcript.EntryError, err.Error())
 		if isQuotaError(err) {
-			tracker.RecordError(backendName, time.Hour)
+			recordQuotaError(tracker, backendName, model, err)
 		}
 		return nil, err
 	}
+	<-streamDone // Sessions close the event stream when Run returns
+	if data, err := json.Marshal(result); err == nil {
+		tw.Write(transcript.EntryResult, string(data))
+	}
 	
-	// Record successful usage (approximate token count)
+	// Record usage: as reported by the backend, which includes failed runs,
+	// or an estimate for successful ones
+	if result.Usage != nil || result.Success {
+		tokens := estimatedRunTokens
+		if result.Usage != nil {
+			tokens = *result.Usage
+		}
+		tracker.RecordUsage(backendName, model, tokens)
+		recordCost(ws, t, backendName, model, tokens)
+		recordTokens(ws, backendName, model, tokens)
+	}
+
 	if result.Success {
-		tracker.Record(backendName, 10000) // Estimate, actual would come from API
+		result.Coverage = recordCoverage(ctx, ws, t, gate, baseline)
+
+		// Run the verify pipeline; failures block completion
+		if pipeline := ws.VerifyPipeline(t); !pipeline.Empty() {
+			result.Verification = pipeline.Run(ctx)
+			for _, step := range result.Verification.Steps {
+				mark := "✓"
+				switch {
+				case step.Skipped:
+					mark = "-"
+				case !step.Passed:
+					mark = "✗"
+				}
+				fmt.Printf("   %s verify: %s\n", mark, step.Name)
+			}
+			if err := result.Verification.Err(); err != nil {
+				result.Success = false
+				result.Error = err.Error()
+			}
+		}
+
+		// Run custom gates; failures block completion
+		suite, err := ws.Gates(t)
+		if err != nil {
+			return nil, err
+		}
+		if !suite.Empty() {
+			result.Gates = suite.Run(ctx, ws.GateContext(t))
+			printGateResults(result.Gates, "   ")
+			if err := result.Gates.Err(); err != nil && result.Success {
+				result.Success = false
+				result.Error = err.Error()
+			}
+		}
+
+		// Check the run against the policy; denied operations fail it, and
+		// ones needing approval hold completion until it is given
+		if err := ws.CheckPolicy(t, calls); err != nil {
+			if errors.Is(err, policy.ErrApprovalRequired) {
+				fmt.Printf("   ⏸ completion held: %s\n", err)
+			} else if result.Success {
+				result.Success = false
+				result.Error = err.Error()
+			}
+		}
 	}
 	
 	return result, nil
 }
 
-// isQuotaError checks if an error is related to quota exhaustion.
-func isQuotaError(err error) bool {
+// newBackend creates the named agent backend configured from the workspace,
+// with model overriding the configured model when set.
+func newBackend(ws *workspace.Workspace, backendName, model string) (agent.Backend, error) {
+	if err := offline.Check(backendName + " backend"); err != nil {
+		return nil, err
+	}
+	// Backends read their API keys from the environment they inherit
+	if _, err := loadSecretsFor(ws); err != nil {
+		return nil, fmt.Errorf("failed to load secrets: %w", err)
+	}
+
+	var backend agent.Backend
+	switch backendName {
+	case "claude":
+		mcpConfig, sb, err := backendMCPConfig(ws)
+		if err != nil {
+			return nil, err
+		}
+		claudeModel := ws.Config.Claude.Model
+		if model != "" {
+			claudeModel = model
+		}
+		backend = agent.NewClaudeBackend(agent.ClaudeConfig{
+			MCPConfig: mcpConfig,
+			Model:     claudeModel,
+			Sandbox:   sb,
+		})
+	case "copilot":
+		copilotModel := ws.Config.Copilot.Model
+		if model != "" {
+			copilotModel = model
+		}
+		backend = agent.NewCopilotBackend(agent.CopilotConfig{
+			Model: copilotModel,
+		})
+	case "codex", "gemini":
+		features, err := ws.Features()
+		if err != nil {
+			return nil, err
+		}
+		if err := features.Require(flags.ExperimentalBackends, "the "+backendName+" backend"); err != nil {
+			return nil, err
+		}
+		mcpConfig, sb, err := backendMCPConfig(ws)
+		if err != nil {
+			return nil, err
+		}
+		if backendName == "codex" {
+			backend = agent.NewCodexBackend(agent.CodexConfig{MCPConfig: mcpConfig, Model: model, Sandbox: sb})
+		} else {
+			backend = agent.NewGeminiBackend(agent.GeminiConfig{MCPConfig: mcpConfig, Model: model, Sandbox: sb})
+		}
+	default:
+		return nil, fmt.Errorf("unknown backend: %s", backendName)
+	}
+
+	if settings, ok := ws.Config.RetryFor(backendName); ok {
+		retryConfig, err := agentRetryConfig(settings)
+		if err != nil {
+			return nil, fmt.Errorf("retry.%s: %w", backendName, err)
+		}
+		retryConfig.OnRetry = printRetry
+		retryConfig.Audit = ws.Audit
+		backend = agent.NewRetryableBackend(backend, retryConfig)
+	}
+	return backend, nil
+}
+
+// backendMCPConfig returns the MCP config a backend CLI is started with,
+// and the container sandbox it runs in, or nil when none is configured.
+//
+// On the host the CLI starts flo's MCP server itself. In a sandbox only
+// the task's worktree is mounted, so the workspace's tools are served from
+// the host over HTTP instead, and the container is given a config pointing
+// at them.
+func backendMCPConfig(ws *workspace.Workspace) (string, *sandbox.Sandbox, error) {
+	if !ws.Config.Sandbox.Enabled() {
+		mcpConfig := filepath.Join(ws.Root, ".eas", "mcp.json")
+		if err := generateMCPConfig(mcpConfig, ws.Root); err != nil {
+			return "", nil, fmt.Errorf("failed to generate MCP config: %w", err)
+		}
+		return mcpConfig, nil, nil
+	}
+
+	sb := sandbox.New(ws.Config.Sandbox)
+	mcpConfig, err := serveSandboxMCP(ws, sb)
+	if err != nil {
+		return "", nil, err
+	}
+	if mcpConfig == "" {
+		return "", sb, nil
+	}
+	return mcpConfig, sandbox.New(ws.Config.Sandbox, mcpConfig), nil
+}
+
+// sandboxMCPConfigs are the MCP configs of the servers serveSandboxMCP
+// started, by workspace root.
+var sandboxMCPConfigs = map[string]string{}
+
+// serveSandboxMCP serves the workspace's MCP tools over HTTP for sandboxed
+// backends until flo exits, and returns the path of an MCP config pointing
+// at them. The server requires a token generated for this process only.
+// The path is empty when the container has no network to reach it.
+func serveSandboxMCP(ws *workspace.Workspace, sb *sandbox.Sandbox) (string, error) {
+	if path, ok := sandboxMCPConfigs[ws.Root]; ok {
+		return path, nil
+	}
+	listen, host, ok := sb.HostAddress()
+	if !ok {
+		fmt.Fprintln(os.Stderr, "⚠️  The sandbox has no network, so the agent can't reach flo's MCP tools")
+		sandboxMCPConfigs[ws.Root] = ""
+		return "", nil
+	}
+
+	server, stopServer, err := newWorkspaceMCPServer(ws)
+	if err != nil {
+		return "", fmt.Errorf("failed to start MCP server: %w", err)
+	}
+	token, err := secrets.GenerateToken()
+	if err != nil {
+		stopServer()
+		return "", err
+	}
+	server.SetAuthToken(token)
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		stopServer()
+		return "", fmt.Errorf("failed to start MCP server: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", server.HTTPHandler())
+	httpServer := &http.Server{Handler: mux}
+	go httpServer.Serve(ln)
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	path, err := writeSandboxMCPConfig("http://"+net.JoinHostPort(host, port)+"/mcp", token)
+	if err != nil {
+		httpServer.Close()
+		stopServer()
+		return "", fmt.Errorf("failed to generate MCP config: %w", err)
+	}
+	atExit(func() {
+		httpServer.Close()
+		stopServer()
+		os.Remove(path)
+	})
+	sandboxMCPConfigs[ws.Root] = path
+	return path, nil
+}
+
+// writeSandboxMCPConfig writes an MCP config for a server at url to a
+// file only the user can read, outside the workspace, since it holds the
+// server's token.
+func writeSandboxMCPConfig(url, token string) (string, error) {
+	config := map[string]any{
+		"mcpServers": map[string]any{
+			"eas": map[string]any{
+				"type":    "http",
+				"url":     url,
+				"headers": map[string]string{"Authorization": "Bearer " + token},
+			},
+		},
+	}
+	data, _ := json.MarshalIndent(config, "", "  ")
+	f, err := os.CreateTemp("", "flo-mcp-*.json")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// checkFreeze refuses to start a run inside a configured freeze window
+// unless --ignore-freeze is given.
+func checkFreeze(ws *workspace.Workspace, taskID string) error {
+	schedule, err := ws.Config.FreezeSchedule()
+	if err != nil {
+		return err
+	}
+	err = schedule.Check(time.Now())
+	if err == nil {
+		return nil
+	}
+	details := map[string]interface{}{
+		"task":  taskID,
+		"error": err.Error(),
+	}
+	if workIgnoreFreeze {
+		audit.Warn("work.freeze_override", "Started an agent run during a freeze", details)
+		fmt.Fprintf(os.Stderr, "⚠️  %v; starting anyway (--ignore-freeze)\n", err)
+		return nil
+	}
+	audit.Info("work.frozen", "Refused an agent run during a freeze", details)
+	return fmt.Errorf("%w; task %s stays pending (--ignore-freeze to run anyway)", err, taskID)
+}
+
+// checkBudget refuses to start a run projected to take the feature over
+// its budget unless --ignore-budget is given, and warns when the pending
+// tasks are projected to.
+func checkBudget(ws *workspace.Workspace, taskID, backendName, model string) error {
+	budget := ws.Config.Budget
+	if budget <= 0 {
+		return nil
+	}
+	summary, err := ws.Costs().Summary()
+	if err != nil {
+		return err
+	}
+	next := summary.PerRun
+	if summary.Runs == 0 {
+		next, _ = ws.Config.Pricing.Cost(backendName, model, estimatedRunTokens)
+	}
+
+	pending := len(ws.ListTasks(string(task.StatusPending), ""))
+	if projected := summary.Spent + next*float64(pending); projected > budget {
+		fmt.Fprintf(os.Stderr, "⚠️  At about %s a run, the %d pending task(s) would bring the cost to %s, over the %s budget\n",
+			cost.Format(next), pending, cost.Format(projected), cost.Format(budget))
+	}
+
+	err = cost.CheckBudget(budget, summary.Spent, next)
 	if err == nil {
-		return false
+		return nil
+	}
+	if workIgnoreBudget {
+		events.PublishTo(ws.Audit, audit.LevelWarn, "Started an agent run over budget", audit.BudgetOverridden{
+			TaskID: taskID,
+			Error:  err.Error(),
+		})
+		fmt.Fprintf(os.Stderr, "⚠️  %v; starting anyway (--ignore-budget)\n", err)
+		return nil
+	}
+	events.PublishTo(ws.Audit, audit.LevelWarn, "Paused agent runs over budget", audit.BudgetExceeded{
+		TaskID: taskID,
+		Error:  err.Error(),
+	})
+	return fmt.Errorf("%w; runs are paused until budget is raised in .flo/config.yaml (--ignore-budget to run anyway)", err)
+}
+
+// recordCost adds a run to the workspace's cost ledger.
+func recordCost(ws *workspace.Workspace, t *task.Task, backendName, model string, tokens cost.Tokens) {
+	c, _ := ws.Config.Pricing.Cost(backendName, model, tokens)
+	entry := cost.Entry{TaskID: t.ID, Backend: backendName, Model: model, Tokens: tokens, Cost: c}
+	if err := ws.Costs().Record(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record run cost: %v\n", err)
+	}
+}
+
+// quotaModel returns the model a run is charged to: model, or the
+// backend's configured model when it is empty.
+func quotaModel(ws *workspace.Workspace, backendName, model string) string {
+	if model != "" {
+		return model
+	}
+	switch backendName {
+	case "claude":
+		if ws.Config.Claude != nil {
+			return ws.Config.Claude.Model
+		}
+	case "copilot":
+		if ws.Config.Copilot != nil {
+			return ws.Config.Copilot.Model
+		}
+	}
+	return ""
+}
+
+// recordHealth adds a run to the workspace's backend health statistics.
+func recordHealth(ws *workspace.Workspace, t *task.Task, backend agent.Backend, backendName string, d time.Duration, result *agent.Result, err error) {
+	run := health.Run{
+		Backend:  backendName,
+		TaskID:   t.ID,
+		Duration: d,
+		Success:  err == nil && result != nil && result.Success,
+	}
+	switch {
+	case err != nil:
+		run.Error = err.Error()
+	case result != nil:
+		run.Error = result.Error
+	}
+	if rb, ok := backend.(*agent.RetryableBackend); ok {
+		run.Retries = rb.Retries()
+		run.BreakerTrips = rb.BreakerTrips()
+		run.Breaker = rb.BreakerState().String()
+	}
+	if err := ws.Health().Record(run); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record backend health: %v\n", err)
+	}
+}
+
+// recordRunTelemetry ends a backend session's span and records the run's
+// duration and retries.
+func recordRunTelemetry(ws *workspace.Workspace, span *telemetry.Span, backend agent.Backend, backendName string, d time.Duration, result *agent.Result, err error) {
+	success := err == nil && result != nil && result.Success
+	retries := 0
+	if rb, ok := backend.(*agent.RetryableBackend); ok {
+		retries = rb.Retries()
+	}
+	span.SetAttributes(telemetry.Bool("flo.success", success), telemetry.Int("flo.retries", retries))
+	if err == nil && result != nil && !result.Success {
+		err = errors.New(result.Error)
+	}
+	span.End(err)
+
+	ws.Telemetry.RecordDuration(telemetry.MetricTaskDuration, d,
+		telemetry.String("flo.backend", backendName), telemetry.Bool("flo.success", success))
+	ws.Telemetry.Add(telemetry.MetricRetries, int64(retries), telemetry.String("flo.backend", backendName))
+}
+
+// recordTokens counts a run's tokens by type.
+func recordTokens(ws *workspace.Workspace, backendName, model string, tokens cost.Tokens) {
+	for typ, n := range map[string]int{
+		"input":       tokens.Input,
+		"output":      tokens.Output,
+		"cache_read":  tokens.CacheRead,
+		"cache_write": tokens.CacheWrite,
+	} {
+		ws.Telemetry.Add(telemetry.MetricTokens, int64(n),
+			telemetry.String("flo.backend", backendName),
+			telemetry.String("flo.model", model),
+			telemetry.String("flo.token_type", typ))
+	}
+}
+
+// printRetry tells the user why a run has paused.
+func printRetry(a agent.RetryAttempt) {
+	fmt.Printf("\n⏳ %s\n", a)
+}
+
+// reportAttempt describes an attempt at a task for the run report.
+func reportAttempt(ws *workspace.Workspace, backend agent.Backend, backendName, model string, d time.Duration, result *agent.Result, err error) report.Attempt {
+	a := report.Attempt{
+		Backend:  backendName,
+		Model:    model,
+		Duration: d,
+		Success:  err == nil && result != nil && result.Success,
+	}
+	switch {
+	case err != nil:
+		a.Error = err.Error()
+	case result != nil:
+		a.Error = result.Error
+	}
+	if rb, ok := backend.(*agent.RetryableBackend); ok {
+		a.Retries = rb.Retries()
+	}
+	// Charged as runAgent records it
+	if result != nil && (result.Usage != nil || result.Success) {
+		a.Tokens = estimatedRunTokens
+		if result.Usage != nil {
+			a.Tokens = *result.Usage
+		}
+		a.Cost, _ = ws.Config.Pricing.Cost(backendName, model, a.Tokens)
+	}
+	return a
+}
+
+// writeRunReport finishes the run report with the run's outcome, test
+// results and the changes in dir, and saves it in .flo/reports.
+func writeRunReport(ws *workspace.Workspace, dir string, r *report.Report, result *agent.Result, err error) {
+	r.Finished = time.Now()
+	switch {
+	case err != nil:
+		r.Error = err.Error()
+	case result != nil:
+		r.Success = result.Success
+		r.Error = result.Error
+		r.Coverage = result.Coverage
+		r.Verification = result.Verification
+		r.Gates = result.Gates
+	}
+	if out, err := exec.Command("git", "-C", dir, "diff", "--stat", "HEAD").Output(); err == nil {
+		r.DiffStat = strings.TrimRight(string(out), "\n")
+	}
+	path, err := report.Write(ws.ReportDir(), r)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write run report: %v\n", err)
+		return
+	}
+	if rel, err := filepath.Rel(ws.Root, path); err == nil {
+		path = rel
+	}
+	fmt.Printf("\n📝 Run report: %s (flo report open)\n", path)
+}
+
+// pauseOnRetryBudget stops a run whose retries used up the run's retry
+// budget. The task goes back to pending, without failing, so the next
+// flo work or a resumed flo run picks it up once the backend recovers.
+func pauseOnRetryBudget(ws *workspace.Workspace, t *task.Task, budget *agent.RetryBudget, err error) error {
+	retries, waited := budget.Used()
+	ws.Audit.Warn("work.retry_budget", "Paused a run that used up its retry budget", map[string]interface{}{
+		"task":      t.ID,
+		"retries":   retries,
+		"waited_ms": waited.Milliseconds(),
+		"error":     err.Error(),
+	})
+	if _, rerr := ws.RequeueTask(t.ID); rerr != nil {
+		return fmt.Errorf("failed to requeue paused task %s: %w (paused after: %v)", t.ID, rerr, err)
 	}
-	errStr := strings.ToLower(err.Error())
-	return strings.Contains(errStr, "429") ||
-		strings.Contains(errStr, "rate limit") ||
-		strings.Contains(errStr, "quota") ||
-		strings.Contains(errStr, "too many requests")
+	fmt.Fprintf(os.Stderr, "\n⏸️  Paused task %s after %d retries (%s waiting): %v\n", t.ID, retries, waited.Round(time.Second), err)
+	fmt.Fprintf(os.Stderr, "   The backend looks unhealthy; the task is pending again, so run it once the backend recovers, or raise retry_budget in .flo/config.yaml.\n")
+	return withExitCode(ExitQuotaExhausted, fmt.Errorf("task %s paused: %w", t.ID, err))
+}
+
+// agentRetryConfig applies configured retry settings over the defaults.
+func agentRetryConfig(settings config.RetryConfig) (agent.RetryConfig, error) {
+	rc := agent.DefaultRetryConfig()
+	if err := agent.ValidateBackoff(settings.Strategy); err != nil {
+		return rc, fmt.Errorf("%w (known: %s)", err, strings.Join(agent.ListBackoffs(), ", "))
+	}
+	rc.Strategy = settings.Strategy
+	if settings.MaxRetries > 0 {
+		rc.MaxRetries = settings.MaxRetries
+	}
+	if settings.InitialBackoff > 0 {
+		rc.InitialBackoff = settings.InitialBackoff
+	}
+	if settings.MaxBackoff > 0 {
+		rc.MaxBackoff = settings.MaxBackoff
+	}
+	if settings.Factor > 0 {
+		rc.BackoffFactor = settings.Factor
+	}
+	if settings.Jitter != nil {
+		rc.Jitter = *settings.Jitter
+	}
+	if settings.MaxRetryAfter > 0 {
+		rc.MaxRetryAfter = settings.MaxRetryAfter
+	}
+	return rc, nil
+}
+
+// recordCoverage re-runs the tests to measure the coverage change produced by
+// the task and stores it in the workspace coverage store. A nil baseline is
+// recorded as a delta without a before figure.
+func recordCoverage(ctx context.Context, ws *workspace.Workspace, t *task.Task, gate *tdd.Gate, baseline *coverage.Report) *coverage.Delta {
+	if ws.Config.TDD.CoverageProfile == "" {
+		return nil
+	}
+	report, err := gate.MeasureCoverage(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to measure coverage: %v\n", err)
+		return nil
+	}
+
+	delta := &coverage.Delta{
+		TaskID:     t.ID,
+		After:      report.Percent(),
+		RecordedAt: time.Now().UTC(),
+	}
+	if baseline != nil {
+		delta.Before = baseline.Percent()
+		delta.HasBefore = true
+	}
+
+	store := coverage.NewStore(coveragePath(ws))
+	if err := store.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record coverage: %v\n", err)
+		return delta
+	}
+	if err := store.Record(delta); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record coverage: %v\n", err)
+	}
+	return delta
+}
+
+// coveragePath returns the path to the workspace coverage store.
+func coveragePath(ws *workspace.Workspace) string {
+	return filepath.Join(ws.Root, ".flo", "coverage.json")
+}
+
+// acquireSlot takes one of limit slots named key, shared by the flo
+// processes of the workspace, telling the user what it waits for when
+// they are all taken. A limit of zero is no cap.
+func acquireSlot(ctx context.Context, ws *workspace.Workspace, key, what string, limit int) (func(), error) {
+	slots := inflight.New(filepath.Join(ws.Root, ".flo", "inflight"))
+	return slots.Acquire(ctx, key, limit, func() {
+		fmt.Printf("⏳ Waiting for %s (%d in flight)\n", what, limit)
+		audit.Info("work.inflight_wait", "Waiting for an in-flight slot", map[string]interface{}{
+			"slot":  key,
+			"limit": limit,
+		})
+	})
+}
+
+// isQuotaError checks if an error is related to quota exhaustion: a
+// backend the tracker has exhausted, or a rate limit, whether typed or
+// only described by the backend's output.
+func isQuotaError(err error) bool {
+	return err != nil && agent.ErrorClass(err) == agent.ErrorClassRateLimit
+}
+
+// recordQuotaError marks a model exhausted after a rate limit error, until
+// the provider said it resets or for an hour if it didn't.
+func recordQuotaError(tracker *quota.Tracker, backendName, model string, err error) {
+	wait, reported := agent.RetryAfter(err)
+	tracker.RecordModelError(backendName, model, wait)
+	audit.Warn("quota.rate_limited", "Backend rate limited", map[string]interface{}{
+		"backend":     backendName,
+		"model":       model,
+		"retry_after": wait.String(),
+		"reported":    reported,
+	})
 }
 
 // initQuotaTracker initializes the quota tracker with limits from config.
 func initQuotaTracker(path string, ws *workspace.Workspace) *quota.Tracker {
 	tracker := quota.New(path)
+	tracker.SetLocation(displayLocation(ws))
+	tracker.SetPricing(ws.Config.Pricing)
 	tracker.Load()
 	
 	// Set limits from config if available
 	// Default limits for common backends
 	tracker.SetLimit("claude", 50)  // 50 requests per hour for premium
 	tracker.SetLimit("copilot", 100) // Higher limit for copilot
+	for key, limit := range ws.Config.Quota.Limits {
+		backend, model, _ := strings.Cut(key, "/")
+		tracker.SetModelLimit(backend, model, limit)
+	}
+	for key, spec := range ws.Config.Quota.Windows {
+		if window, err := quota.ParseWindow(spec); err == nil {
+			tracker.SetBackendWindow(key, window)
+		}
+	}
 	
 	return tracker
 }
 
 func init() {
 	workCmd.Flags().StringVar(&workBackend, "backend", "", "Override backend (claude or copilot)")
+	workCmd.Flags().BoolVar(&workIgnoreFreeze, "ignore-freeze", false, "Start the run even during a freeze window")
+	workCmd.Flags().BoolVar(&workFull, "full", false, "Run every test, not just those affected by the change (with tdd.affected)")
+	workCmd.Flags().BoolVar(&workIgnoreBudget, "ignore-budget", false, "Start the run even if it is projected to exceed the budget")
 	rootCmd.AddCommand(workCmd)
 }
 
@@ -318,5 +1130,8 @@ func generateMCPConfig(path, workspaceRoot string) error {
 	}
 
 	data, _ := json.MarshalIndent(config, "", "  ")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
 	return os.WriteFile(path, data, 0644)
 }
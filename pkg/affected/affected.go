@@ -0,0 +1,289 @@
+// Package affected works out which Go packages or Bazel test targets a
+// change can affect, from the files it touches and the build graph, so the
+// TDD gate can run only those tests instead of the whole suite.
+package affected
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/richgo/flo/pkg/shell"
+)
+
+// Selection kinds, set as tdd.affected.
+const (
+	KindGo    = "go"
+	KindBazel = "bazel"
+)
+
+// Pattern returns the "everything" target pattern of a kind, which is
+// replaced by the selected targets in the test command.
+func Pattern(kind string) (string, bool) {
+	switch kind {
+	case KindGo:
+		return "./...", true
+	case KindBazel:
+		return "//...", true
+	}
+	return "", false
+}
+
+// Selection is the tests a change affects.
+type Selection struct {
+	Kind string `json:"kind"`
+	// Changed is the files changed relative to the base, relative to the
+	// directory the tests run in.
+	Changed []string `json:"changed,omitempty"`
+	// Targets is the Go import paths or Bazel labels to test.
+	Targets []string `json:"targets,omitempty"`
+	// Full is set when the change can't be narrowed down, such as when
+	// go.mod changed, and Reason says why.
+	Full   bool   `json:"full,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Command returns the test command restricted to the selected targets, or
+// unchanged when the selection is full.
+func (s *Selection) Command(testCommand string) string {
+	pattern, ok := Pattern(s.Kind)
+	if s.Full || !ok {
+		return testCommand
+	}
+	return strings.Replace(testCommand, pattern, strings.Join(s.Targets, " "), 1)
+}
+
+// Selector computes the selection for a directory.
+type Selector struct {
+	kind   string
+	dir    string
+	base   string
+	runner shell.Runner
+}
+
+// NewSelector creates a selector of the given kind for the tests in dir,
+// comparing the working tree with the git ref base (HEAD when empty).
+func NewSelector(kind, dir, base string) *Selector {
+	if base == "" {
+		base = "HEAD"
+	}
+	return &Selector{kind: kind, dir: dir, base: base, runner: shell.Exec}
+}
+
+// SetRunner replaces the command runner (for testing).
+func (s *Selector) SetRunner(runner shell.Runner) {
+	s.runner = runner
+}
+
+// Select returns the targets affected by the files changed since the base,
+// including untracked files.
+func (s *Selector) Select(ctx context.Context) (*Selection, error) {
+	if _, ok := Pattern(s.kind); !ok {
+		return nil, fmt.Errorf("unknown affected kind '%s' (want go or bazel)", s.kind)
+	}
+	changed, err := s.changedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sel := &Selection{Kind: s.kind, Changed: changed}
+	if len(changed) == 0 {
+		return sel, nil
+	}
+	if s.kind == KindGo {
+		err = s.selectGo(ctx, sel)
+	} else {
+		err = s.selectBazel(ctx, sel)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sel.Full {
+		sel.Targets = nil
+	}
+	return sel, nil
+}
+
+// changedFiles lists files that differ from the base, plus untracked files.
+func (s *Selector) changedFiles(ctx context.Context) ([]string, error) {
+	diff, err := s.runner(ctx, s.dir, []string{"git", "diff", "--name-only", "--relative", s.base})
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff against %s: %w", s.base, err)
+	}
+	untracked, err := s.runner(ctx, s.dir, []string{"git", "ls-files", "--others", "--exclude-standard"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	seen := make(map[string]bool)
+	var files []string
+	for _, f := range append(strings.Fields(string(diff)), strings.Fields(string(untracked))...) {
+		if !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// goPackage is the part of `go list -json` output used for selection.
+type goPackage struct {
+	ImportPath   string
+	Dir          string
+	Deps         []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// goModuleFiles change the dependencies of every package.
+var goModuleFiles = map[string]bool{"go.mod": true, "go.sum": true, "go.work": true, "go.work.sum": true}
+
+// selectGo selects the packages whose code, dependencies or test
+// dependencies include a changed package.
+func (s *Selector) selectGo(ctx context.Context, sel *Selection) error {
+	out, err := s.runner(ctx, s.dir, []string{"go", "list", "-e", "-json=ImportPath,Dir,Deps,TestImports,XTestImports", "./..."})
+	if err != nil {
+		return fmt.Errorf("failed to list go packages: %w", err)
+	}
+	var pkgs []goPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p goPackage
+		if err := dec.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		pkgs = append(pkgs, p)
+	}
+
+	root, err := filepath.Abs(s.dir)
+	if err != nil {
+		return err
+	}
+	byDir := make(map[string]string)
+	deps := make(map[string][]string)
+	for _, p := range pkgs {
+		byDir[filepath.Clean(p.Dir)] = p.ImportPath
+		deps[p.ImportPath] = p.Deps
+	}
+
+	changed := make(map[string]bool)
+	for _, f := range sel.Changed {
+		if goModuleFiles[path.Base(f)] {
+			sel.Full, sel.Reason = true, f+" changed"
+			return nil
+		}
+		pkg, ok := byDir[filepath.Join(root, filepath.FromSlash(ownerDir(f)))]
+		switch {
+		case ok:
+			changed[pkg] = true
+		case strings.HasSuffix(f, ".go"):
+			// A removed or unlisted package; its importers can't be found.
+			sel.Full, sel.Reason = true, f+" is not in a listed package"
+			return nil
+		}
+	}
+
+	affects := func(importPath string) bool {
+		if changed[importPath] {
+			return true
+		}
+		for _, d := range deps[importPath] {
+			if changed[d] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range pkgs {
+		hit := affects(p.ImportPath)
+		for _, imports := range [][]string{p.TestImports, p.XTestImports} {
+			for _, imp := range imports {
+				hit = hit || affects(imp)
+			}
+		}
+		if hit {
+			sel.Targets = append(sel.Targets, p.ImportPath)
+		}
+	}
+	return nil
+}
+
+// ownerDir returns the directory of the package a file belongs to: its
+// own directory, or the one holding its testdata.
+func ownerDir(file string) string {
+	dir := path.Dir(file)
+	parts := strings.Split(dir, "/")
+	for i, part := range parts {
+		if part == "testdata" {
+			return path.Join(parts[:i]...)
+		}
+	}
+	return dir
+}
+
+// bazelGlobalFiles change how every target builds.
+var bazelGlobalFiles = map[string]bool{
+	"WORKSPACE": true, "WORKSPACE.bazel": true, "MODULE.bazel": true, "MODULE.bazel.lock": true,
+	".bazelrc": true, ".bazelversion": true,
+}
+
+// selectBazel selects the test targets that depend on a changed file, or
+// on any target of a package whose BUILD file changed.
+func (s *Selector) selectBazel(ctx context.Context, sel *Selection) error {
+	var labels []string
+	for _, f := range sel.Changed {
+		base := path.Base(f)
+		if bazelGlobalFiles[base] || strings.HasSuffix(base, ".bzl") {
+			sel.Full, sel.Reason = true, f+" changed"
+			return nil
+		}
+		pkg, ok := s.bazelPackage(path.Dir(f))
+		if !ok {
+			continue // Not part of the build
+		}
+		if base == "BUILD" || base == "BUILD.bazel" {
+			labels = append(labels, "//"+pkg+":all")
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(f, pkg), "/")
+		labels = append(labels, "//"+pkg+":"+name)
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf("tests(rdeps(//..., set(%s)))", strings.Join(labels, " "))
+	out, err := s.runner(ctx, s.dir, []string{"bazel", "query", "--keep_going", "--output=label", query})
+	// With --keep_going, labels that no longer exist (deleted files) are
+	// reported but the rest of the query still answers.
+	if err != nil && len(bytes.TrimSpace(out)) == 0 {
+		return fmt.Errorf("bazel query failed: %w", err)
+	}
+	sel.Targets = strings.Fields(string(out))
+	return nil
+}
+
+// bazelPackage finds the package owning a directory: the nearest one,
+// walking up to the root, with a BUILD or BUILD.bazel file.
+func (s *Selector) bazelPackage(dir string) (string, bool) {
+	for {
+		if dir == "." {
+			dir = ""
+		}
+		for _, build := range []string{"BUILD.bazel", "BUILD"} {
+			if _, err := os.Stat(filepath.Join(s.dir, filepath.FromSlash(dir), build)); err == nil {
+				return dir, true
+			}
+		}
+		if dir == "" {
+			return "", false
+		}
+		dir = path.Dir(dir)
+	}
+}
@@ -0,0 +1,116 @@
+package affected
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/shell"
+)
+
+// fakeRunner answers git with changed files and go/bazel with output.
+func fakeRunner(t *testing.T, changed, untracked, output string) shell.Runner {
+	return func(ctx context.Context, dir string, argv []string) ([]byte, error) {
+		switch strings.Join(argv[:2], " ") {
+		case "git diff":
+			return []byte(changed), nil
+		case "git ls-files":
+			return []byte(untracked), nil
+		case "go list", "bazel query":
+			return []byte(output), nil
+		}
+		t.Fatalf("unexpected command: %v", argv)
+		return nil, nil
+	}
+}
+
+func TestSelectGo(t *testing.T) {
+	root := t.TempDir()
+	list := `{"ImportPath": "ex/a", "Dir": "` + filepath.Join(root, "a") + `"}
+{"ImportPath": "ex/b", "Dir": "` + filepath.Join(root, "b") + `", "Deps": ["ex/a"]}
+{"ImportPath": "ex/c", "Dir": "` + filepath.Join(root, "c") + `", "TestImports": ["ex/b"]}
+{"ImportPath": "ex/d", "Dir": "` + filepath.Join(root, "d") + `"}
+`
+
+	tests := []struct {
+		name      string
+		changed   string
+		untracked string
+		targets   []string
+		full      bool
+	}{
+		{"dependency", "a/a.go\n", "", []string{"ex/a", "ex/b", "ex/c"}, false},
+		{"test dependency", "b/b.go\n", "", []string{"ex/b", "ex/c"}, false},
+		{"untracked test", "", "d/d_test.go\n", []string{"ex/d"}, false},
+		{"testdata", "d/testdata/golden.txt\n", "", []string{"ex/d"}, false},
+		{"docs only", "README.md\ndocs/x.md\n", "", nil, false},
+		{"go.mod", "a/a.go\ngo.mod\n", "", nil, true},
+		{"removed package", "e/e.go\n", "", nil, true},
+	}
+	for _, tt := range tests {
+		s := NewSelector(KindGo, root, "")
+		s.SetRunner(fakeRunner(t, tt.changed, tt.untracked, list))
+		sel, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatalf("%s: Select failed: %v", tt.name, err)
+		}
+		if sel.Full != tt.full || !reflect.DeepEqual(sel.Targets, tt.targets) {
+			t.Errorf("%s: got full=%v targets=%v, want full=%v targets=%v", tt.name, sel.Full, sel.Targets, tt.full, tt.targets)
+		}
+	}
+}
+
+func TestSelectBazel(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "lib", "util"), 0755)
+	os.WriteFile(filepath.Join(root, "lib", "BUILD.bazel"), nil, 0644)
+
+	var query string
+	s := NewSelector(KindBazel, root, "main")
+	s.SetRunner(func(ctx context.Context, dir string, argv []string) ([]byte, error) {
+		switch argv[1] {
+		case "diff":
+			if argv[len(argv)-1] != "main" {
+				t.Errorf("expected diff against main, got %v", argv)
+			}
+			return []byte("lib/util/x.cc\nlib/BUILD.bazel\nREADME.md\n"), nil
+		case "ls-files":
+			return nil, nil
+		}
+		query = argv[len(argv)-1]
+		return []byte("//lib:x_test\n//app:app_test\n"), nil
+	})
+
+	sel, err := s.Select(context.Background())
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if query != "tests(rdeps(//..., set(//lib:util/x.cc //lib:all)))" {
+		t.Errorf("unexpected query: %s", query)
+	}
+	if got := sel.Command("bazel test //..."); got != "bazel test //lib:x_test //app:app_test" {
+		t.Errorf("unexpected command: %s", got)
+	}
+
+	s.SetRunner(fakeRunner(t, "tools/defs.bzl\n", "", ""))
+	if sel, _ := s.Select(context.Background()); !sel.Full || sel.Command("bazel test //...") != "bazel test //..." {
+		t.Errorf("expected a .bzl change to select everything, got %+v", sel)
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	s := NewSelector(KindGo, t.TempDir(), "")
+	s.SetRunner(func(ctx context.Context, dir string, argv []string) ([]byte, error) {
+		return nil, errors.New("not a git repository")
+	})
+	if _, err := s.Select(context.Background()); err == nil {
+		t.Error("expected a git failure to be an error")
+	}
+	if _, err := NewSelector("make", t.TempDir(), "").Select(context.Background()); err == nil {
+		t.Error("expected an unknown kind to be an error")
+	}
+}
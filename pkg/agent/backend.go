@@ -3,8 +3,13 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/coverage"
+	"github.com/richgo/flo/pkg/gate"
 	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/verify"
 )
 
 // Backend is the interface for agent execution backends.
@@ -18,6 +23,9 @@ type Backend interface {
 // Session represents an agent session for executing a task.
 type Session interface {
 	Run(ctx context.Context, prompt string) (*Result, error)
+	// Events returns the stream of the current Run, or of the next one
+	// between runs. Each Run closes its stream when it returns, and a
+	// session may be run again, each Run on a fresh stream.
 	Events() <-chan Event
 	Destroy(ctx context.Context) error
 }
@@ -27,12 +35,25 @@ type Result struct {
 	Success bool   `json:"success"`
 	Output  string `json:"output"`
 	Error   string `json:"error,omitempty"`
+	// Coverage is the coverage change produced by the run, when measured.
+	Coverage *coverage.Delta `json:"coverage,omitempty"`
+	// Verification holds the output of the verify pipeline run after the agent.
+	Verification *verify.Result `json:"verification,omitempty"`
+	// Gates holds the custom gate results for the run.
+	Gates *gate.SuiteResult `json:"gates,omitempty"`
+	// Usage is the tokens the run used, when the backend reports them.
+	Usage *cost.Tokens `json:"usage,omitempty"`
 }
 
 // Event represents a streaming event during agent execution.
 type Event struct {
-	Type    string `json:"type"`    // "message", "tool_call", "complete", "error"
+	Type    string `json:"type"`    // "message", "tool_call", "question", "complete", "error", "retry"
 	Content string `json:"content"`
+	// Retry describes the failed attempt for "retry" events.
+	Retry *RetryAttempt `json:"-"`
+	// Input is the tool's arguments for "tool_call" events, when the
+	// backend reports them.
+	Input json.RawMessage `json:"-"`
 }
 
 // Call records a call to a mock backend for verification.
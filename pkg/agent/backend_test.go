@@ -2,8 +2,13 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/offline"
 	"github.com/richgo/flo/pkg/task"
 )
 
@@ -191,3 +196,78 @@ func TestNewBackendByNameUnknown(t *testing.T) {
 		t.Error("expected nil for unknown backend")
 	}
 }
+
+func TestRemoteBackendsFailOffline(t *testing.T) {
+	offline.Set(true)
+	defer offline.Set(false)
+
+	for _, name := range []string{"claude", "copilot", "codex", "gemini"} {
+		err := NewBackendByName(name, nil).Start(context.Background())
+		if !errors.Is(err, offline.ErrOffline) {
+			t.Errorf("%s: expected offline error, got %v", name, err)
+		}
+	}
+
+	if err := NewMockBackend().Start(context.Background()); err != nil {
+		t.Errorf("mock backend should work offline: %v", err)
+	}
+}
+
+func TestClaudeSessionReportsUsage(t *testing.T) {
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "claude")
+	script := `#!/bin/sh
+echo '{"type":"assistant","message":{"content":[{"type":"text","text":"done"}]}}'
+echo '{"type":"result","usage":{"input_tokens":120,"output_tokens":45,"cache_read_input_tokens":3000,"cache_creation_input_tokens":800}}'
+`
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := NewClaudeBackend(ClaudeConfig{CLIPath: cli})
+	session, _ := backend.CreateSession(context.Background(), task.New("t-001", "Test"), dir)
+	go func() {
+		for range session.Events() {
+		}
+	}()
+	result, err := session.Run(context.Background(), "go")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := cost.Tokens{Input: 120, Output: 45, CacheRead: 3000, CacheWrite: 800}
+	if result.Usage == nil || *result.Usage != want {
+		t.Errorf("expected usage %+v, got %+v", want, result.Usage)
+	}
+}
+
+func TestClaudeSessionRunsAgain(t *testing.T) {
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "claude")
+	script := `#!/bin/sh
+echo '{"type":"assistant","message":{"content":[{"type":"text","text":"run"}]}}'
+echo '{"type":"result"}'
+`
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := NewClaudeBackend(ClaudeConfig{CLIPath: cli})
+	session, _ := backend.CreateSession(context.Background(), task.New("t-001", "Test"), dir)
+	for run := 1; run <= 2; run++ {
+		var events []Event
+		streamDone := make(chan struct{})
+		go func(stream <-chan Event) {
+			defer close(streamDone)
+			for event := range stream {
+				events = append(events, event)
+			}
+		}(session.Events())
+		if _, err := session.Run(context.Background(), "go"); err != nil {
+			t.Fatalf("run %d failed: %v", run, err)
+		}
+		<-streamDone
+		if len(events) != 2 {
+			t.Errorf("run %d: expected its own stream of 2 events, got %+v", run, events)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Built-in backoff strategies.
+const (
+	BackoffExponential       = "exponential"
+	BackoffExponentialJitter = "exponential-jitter"
+	BackoffFibonacci         = "fibonacci"
+	BackoffFixed             = "fixed"
+	BackoffDecorrelated      = "decorrelated"
+)
+
+// BackoffFunc returns the wait before retry n (1 for the first retry),
+// given the previous wait (zero before the first). It reads InitialBackoff,
+// BackoffFactor and Rand from cfg; the caller caps the result at
+// MaxBackoff.
+type BackoffFunc func(cfg RetryConfig, n int, prev time.Duration) time.Duration
+
+var (
+	backoffs   = make(map[string]BackoffFunc)
+	backoffsMu sync.RWMutex
+)
+
+func init() {
+	RegisterBackoff(BackoffExponential, func(cfg RetryConfig, n int, prev time.Duration) time.Duration {
+		return exponential(cfg, n)
+	})
+
+	// Equal jitter: a random wait between half and all of the exponential
+	// one, so clients that failed together don't retry together.
+	RegisterBackoff(BackoffExponentialJitter, func(cfg RetryConfig, n int, prev time.Duration) time.Duration {
+		d := exponential(cfg, n)
+		return d/2 + time.Duration(cfg.random()*float64(d/2))
+	})
+
+	RegisterBackoff(BackoffFibonacci, func(cfg RetryConfig, n int, prev time.Duration) time.Duration {
+		a, b := 1, 2
+		for i := 1; i < n; i++ {
+			a, b = b, a+b
+		}
+		return cfg.InitialBackoff * time.Duration(a)
+	})
+
+	RegisterBackoff(BackoffFixed, func(cfg RetryConfig, n int, prev time.Duration) time.Duration {
+		return cfg.InitialBackoff
+	})
+
+	// Decorrelated jitter: a random wait between the initial backoff and
+	// three times the previous wait.
+	RegisterBackoff(BackoffDecorrelated, func(cfg RetryConfig, n int, prev time.Duration) time.Duration {
+		if prev < cfg.InitialBackoff {
+			prev = cfg.InitialBackoff
+		}
+		upper := 3 * prev
+		return cfg.InitialBackoff + time.Duration(cfg.random()*float64(upper-cfg.InitialBackoff))
+	})
+}
+
+// exponential returns InitialBackoff * BackoffFactor^(n-1).
+func exponential(cfg RetryConfig, n int) time.Duration {
+	factor := cfg.BackoffFactor
+	if factor < 1 {
+		factor = 1
+	}
+	d := float64(cfg.InitialBackoff) * math.Pow(factor, float64(n-1))
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
+
+// RegisterBackoff registers a backoff strategy under name, replacing any
+// strategy of that name.
+func RegisterBackoff(name string, fn BackoffFunc) {
+	backoffsMu.Lock()
+	defer backoffsMu.Unlock()
+	backoffs[name] = fn
+}
+
+// ListBackoffs returns the registered backoff strategy names, sorted.
+func ListBackoffs() []string {
+	backoffsMu.RLock()
+	defer backoffsMu.RUnlock()
+	names := make([]string, 0, len(backoffs))
+	for name := range backoffs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupBackoff returns the named strategy; an empty name is exponential.
+func lookupBackoff(name string) (BackoffFunc, error) {
+	if name == "" {
+		name = BackoffExponential
+	}
+	backoffsMu.RLock()
+	defer backoffsMu.RUnlock()
+	fn, ok := backoffs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown backoff strategy: %s", name)
+	}
+	return fn, nil
+}
+
+// ValidateBackoff checks that name is a registered backoff strategy.
+func ValidateBackoff(name string) error {
+	_, err := lookupBackoff(name)
+	return err
+}
+
+// nextBackoff returns the wait before retry n under cfg's strategy,
+// capped at MaxBackoff and shortened by up to Jitter.
+func nextBackoff(cfg RetryConfig, n int, prev time.Duration) (time.Duration, error) {
+	fn, err := lookupBackoff(cfg.Strategy)
+	if err != nil {
+		return 0, err
+	}
+	d := fn(cfg, n, prev)
+	if cfg.MaxBackoff > 0 && d > cfg.MaxBackoff {
+		d = cfg.MaxBackoff
+	}
+	if cfg.Jitter > 0 {
+		d -= time.Duration(math.Min(cfg.Jitter, 1) * cfg.random() * float64(d))
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d, nil
+}
+
+// random returns a number in [0, 1) from cfg.Rand, or math/rand.
+func (cfg RetryConfig) random() float64 {
+	if cfg.Rand != nil {
+		return cfg.Rand()
+	}
+	return rand.Float64()
+}
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoffStrategies(t *testing.T) {
+	base := RetryConfig{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		BackoffFactor:  2.0,
+		Rand:           func() float64 { return 0.5 },
+	}
+	ms := time.Millisecond
+	tests := []struct {
+		strategy string
+		want     []time.Duration
+	}{
+		{"", []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, time.Second}},
+		{BackoffExponential, []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, time.Second}},
+		{BackoffExponentialJitter, []time.Duration{75 * ms, 150 * ms, 300 * ms, 600 * ms, time.Second}},
+		{BackoffFibonacci, []time.Duration{100 * ms, 200 * ms, 300 * ms, 500 * ms, 800 * ms}},
+		{BackoffFixed, []time.Duration{100 * ms, 100 * ms, 100 * ms, 100 * ms, 100 * ms}},
+		// Halfway between the initial backoff and 3x the previous wait.
+		{BackoffDecorrelated, []time.Duration{200 * ms, 350 * ms, 575 * ms, 912500 * time.Microsecond, time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			cfg := base
+			cfg.Strategy = tt.strategy
+			var prev time.Duration
+			for i, want := range tt.want {
+				got, err := nextBackoff(cfg, i+1, prev)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got != want {
+					t.Errorf("retry %d: got %v, want %v", i+1, got, want)
+				}
+				prev = got
+			}
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	cfg := RetryConfig{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		BackoffFactor:  2.0,
+		Jitter:         0.2,
+		Rand:           func() float64 { return 0.5 },
+	}
+	// Shortened by half of the 20% jitter, after the cap
+	for n, want := range map[int]time.Duration{1: 90 * time.Millisecond, 2: 180 * time.Millisecond, 5: 900 * time.Millisecond} {
+		if got, _ := nextBackoff(cfg, n, 0); got != want {
+			t.Errorf("retry %d: got %v, want %v", n, got, want)
+		}
+	}
+
+	cfg.Rand = nil
+	for i := 0; i < 100; i++ {
+		got, _ := nextBackoff(cfg, 1, 0)
+		if got < 80*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("jittered backoff %v outside [80ms, 100ms]", got)
+		}
+	}
+}
+
+func TestBackoffUnknownStrategy(t *testing.T) {
+	if err := ValidateBackoff("zigzag"); err == nil || !strings.Contains(err.Error(), "zigzag") {
+		t.Errorf("expected unknown strategy error, got %v", err)
+	}
+
+	attempts := 0
+	err := retry(context.Background(), RetryConfig{MaxRetries: 2, Strategy: "zigzag"}, NewCircuitBreaker(100, time.Second), func() error {
+		attempts++
+		return errors.New("fail")
+	})
+	if err == nil || attempts != 0 {
+		t.Errorf("expected retry to refuse an unknown strategy before calling, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestRegisterBackoff(t *testing.T) {
+	RegisterBackoff("test-constant", func(cfg RetryConfig, n int, prev time.Duration) time.Duration {
+		return 42 * time.Millisecond
+	})
+	found := false
+	for _, name := range ListBackoffs() {
+		found = found || name == "test-constant"
+	}
+	if !found {
+		t.Errorf("expected registered strategy to be listed, got %v", ListBackoffs())
+	}
+	if got, _ := nextBackoff(RetryConfig{Strategy: "test-constant"}, 1, 0); got != 42*time.Millisecond {
+		t.Errorf("expected the registered strategy to be used, got %v", got)
+	}
+}
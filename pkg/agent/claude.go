@@ -2,20 +2,26 @@ package agent
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
 
+	"github.com/richgo/flo/pkg/correlation"
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/sandbox"
 	"github.com/richgo/flo/pkg/task"
 )
 
 // ClaudeConfig holds configuration for the Claude backend.
 type ClaudeConfig struct {
-	CLIPath   string   // Path to claude binary
-	Model     string   // Model name
-	MCPConfig string   // Path to MCP config file
-	ExtraArgs []string // Additional CLI arguments
+	CLIPath   string           // Path to claude binary
+	Model     string           // Model name
+	MCPConfig string           // Path to MCP config file
+	ExtraArgs []string         // Additional CLI arguments
+	Sandbox   *sandbox.Sandbox // Runs the CLI in a container when set
 }
 
 // ClaudeBackend executes tasks using Claude Code CLI.
@@ -36,7 +42,10 @@ func (b *ClaudeBackend) Name() string {
 }
 
 func (b *ClaudeBackend) Start(ctx context.Context) error {
-	return nil
+	if err := offline.Check("claude backend"); err != nil {
+		return err
+	}
+	return b.config.Sandbox.Check()
 }
 
 func (b *ClaudeBackend) Stop() error {
@@ -48,7 +57,6 @@ func (b *ClaudeBackend) CreateSession(ctx context.Context, t *task.Task, worktre
 		backend:  b,
 		task:     t,
 		worktree: worktree,
-		events:   make(chan Event, 100),
 	}, nil
 }
 
@@ -81,25 +89,33 @@ type ClaudeSession struct {
 	backend  *ClaudeBackend
 	task     *task.Task
 	worktree string
-	events   chan Event
+	eventStream
 	cmd      *exec.Cmd
 }
 
 func (s *ClaudeSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	events := s.current()
+	defer s.end(events)
+
 	args := s.backend.buildArgs(s.task, s.worktree, prompt)
-	s.cmd = exec.CommandContext(ctx, s.backend.config.CLIPath, args...)
+	argv := append([]string{s.backend.config.CLIPath}, args...)
+	s.cmd = s.backend.config.Sandbox.Command(ctx, s.worktree, argv, correlation.FromContext(ctx).Env())
 
 	stdout, err := s.cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
+	// Rate limit errors are reported on stderr or in the result event
+	var stderr bytes.Buffer
+	s.cmd.Stderr = &stderr
 
 	if err := s.cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start claude: %w", err)
 	}
 
 	// Read and process output
-	var lastMessage string
+	var lastMessage, resultText string
+	var usage *cost.Tokens
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -113,35 +129,41 @@ func (s *ClaudeSession) Run(ctx context.Context, prompt string) (*Result, error)
 		case "assistant":
 			if event.Message != nil && event.Message.Content != nil {
 				for _, block := range event.Message.Content {
-					if block.Type == "text" {
+					switch block.Type {
+					case "text":
 						lastMessage = block.Text
-						s.events <- Event{Type: "message", Content: block.Text}
+						events <- Event{Type: "message", Content: block.Text}
+					case "tool_use":
+						if block.Name == askUserTool {
+							events <- Event{Type: "question", Content: string(block.Input)}
+						} else {
+							events <- Event{Type: "tool_call", Content: block.Name, Input: block.Input}
+						}
 					}
 				}
 			}
 		case "result":
-			s.events <- Event{Type: "complete", Content: "done"}
+			if event.Usage != nil {
+				usage = event.Usage.tokens()
+			}
+			if event.IsError {
+				resultText = event.Result
+			}
+			events <- Event{Type: "complete", Content: "done"}
 		}
 	}
-	close(s.events)
 
 	if err := s.cmd.Wait(); err != nil {
-		return &Result{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+		return cliFailure(err, resultText+"\n"+stderr.String(), &Result{Usage: usage})
 	}
 
 	return &Result{
 		Success: true,
 		Output:  lastMessage,
+		Usage:   usage,
 	}, nil
 }
 
-func (s *ClaudeSession) Events() <-chan Event {
-	return s.events
-}
-
 func (s *ClaudeSession) Destroy(ctx context.Context) error {
 	if s.cmd != nil && s.cmd.Process != nil {
 		s.cmd.Process.Kill()
@@ -153,6 +175,29 @@ func (s *ClaudeSession) Destroy(ctx context.Context) error {
 type streamEvent struct {
 	Type    string        `json:"type"`
 	Message *streamMessage `json:"message,omitempty"`
+	// Usage is the run's token usage, on the final "result" event.
+	Usage *streamUsage `json:"usage,omitempty"`
+	// IsError and Result are set on a final "result" event for a failed
+	// run, Result holding the error message.
+	IsError bool   `json:"is_error,omitempty"`
+	Result  string `json:"result,omitempty"`
+}
+
+// streamUsage is the token usage reported by the Claude CLI.
+type streamUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+}
+
+func (u *streamUsage) tokens() *cost.Tokens {
+	return &cost.Tokens{
+		Input:      u.InputTokens,
+		Output:     u.OutputTokens,
+		CacheRead:  u.CacheReadInputTokens,
+		CacheWrite: u.CacheCreationInputTokens,
+	}
 }
 
 type streamMessage struct {
@@ -160,6 +205,11 @@ type streamMessage struct {
 }
 
 type contentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type  string          `json:"type"`
+	Text  string          `json:"text,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
 }
+
+// askUserTool is the Claude CLI tool used when the agent asks the user a question.
+const askUserTool = "AskUserQuestion"
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/richgo/flo/pkg/offline"
 	"github.com/richgo/flo/pkg/task"
 )
 
@@ -40,6 +41,9 @@ func (b *CopilotBackend) Name() string {
 }
 
 func (b *CopilotBackend) Start(ctx context.Context) error {
+	if err := offline.Check("copilot backend"); err != nil {
+		return err
+	}
 	// TODO: Initialize Copilot SDK client
 	return nil
 }
@@ -54,7 +58,6 @@ func (b *CopilotBackend) CreateSession(ctx context.Context, t *task.Task, worktr
 		backend:  b,
 		task:     t,
 		worktree: worktree,
-		events:   make(chan Event, 100),
 	}, nil
 }
 
@@ -63,23 +66,20 @@ type CopilotSession struct {
 	backend  *CopilotBackend
 	task     *task.Task
 	worktree string
-	events   chan Event
+	eventStream
 }
 
 func (s *CopilotSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	defer s.end(s.current())
+
 	// TODO: Implement using Copilot SDK
 	// For now, return a placeholder
-	close(s.events)
 	return &Result{
 		Success: false,
 		Error:   fmt.Sprintf("Copilot backend not yet implemented - requires SDK dependency"),
 	}, nil
 }
 
-func (s *CopilotSession) Events() <-chan Event {
-	return s.events
-}
-
 func (s *CopilotSession) Destroy(ctx context.Context) error {
 	return nil
 }
@@ -2,20 +2,25 @@ package agent
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
 
+	"github.com/richgo/flo/pkg/correlation"
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/sandbox"
 	"github.com/richgo/flo/pkg/task"
 )
 
 // GeminiConfig holds configuration for the Gemini backend.
 type GeminiConfig struct {
-	CLIPath   string   // Path to gemini binary
-	Model     string   // Model name
-	MCPConfig string   // Path to MCP config file
-	ExtraArgs []string // Additional CLI arguments
+	CLIPath   string           // Path to gemini binary
+	Model     string           // Model name
+	MCPConfig string           // Path to MCP config file
+	ExtraArgs []string         // Additional CLI arguments
+	Sandbox   *sandbox.Sandbox // Runs the CLI in a container when set
 }
 
 // GeminiBackend executes tasks using Gemini CLI.
@@ -36,7 +41,10 @@ func (b *GeminiBackend) Name() string {
 }
 
 func (b *GeminiBackend) Start(ctx context.Context) error {
-	return nil
+	if err := offline.Check("gemini backend"); err != nil {
+		return err
+	}
+	return b.config.Sandbox.Check()
 }
 
 func (b *GeminiBackend) Stop() error {
@@ -48,7 +56,6 @@ func (b *GeminiBackend) CreateSession(ctx context.Context, t *task.Task, worktre
 		backend:  b,
 		task:     t,
 		worktree: worktree,
-		events:   make(chan Event, 100),
 	}, nil
 }
 
@@ -81,25 +88,32 @@ type GeminiSession struct {
 	backend  *GeminiBackend
 	task     *task.Task
 	worktree string
-	events   chan Event
+	eventStream
 	cmd      *exec.Cmd
 }
 
 func (s *GeminiSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	events := s.current()
+	defer s.end(events)
+
 	args := s.backend.buildArgs(s.task, s.worktree, prompt)
-	s.cmd = exec.CommandContext(ctx, s.backend.config.CLIPath, args...)
+	argv := append([]string{s.backend.config.CLIPath}, args...)
+	s.cmd = s.backend.config.Sandbox.Command(ctx, s.worktree, argv, correlation.FromContext(ctx).Env())
 
 	stdout, err := s.cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
+	// Rate limit errors are reported on stderr or in the result event
+	var stderr bytes.Buffer
+	s.cmd.Stderr = &stderr
 
 	if err := s.cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start gemini: %w", err)
 	}
 
 	// Read and process output
-	var lastMessage string
+	var lastMessage, resultText string
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -115,21 +129,20 @@ func (s *GeminiSession) Run(ctx context.Context, prompt string) (*Result, error)
 				for _, block := range event.Message.Content {
 					if block.Type == "text" {
 						lastMessage = block.Text
-						s.events <- Event{Type: "message", Content: block.Text}
+						events <- Event{Type: "message", Content: block.Text}
 					}
 				}
 			}
 		case "result":
-			s.events <- Event{Type: "complete", Content: "done"}
+			if event.IsError {
+				resultText = event.Result
+			}
+			events <- Event{Type: "complete", Content: "done"}
 		}
 	}
-	close(s.events)
 
 	if err := s.cmd.Wait(); err != nil {
-		return &Result{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+		return cliFailure(err, resultText+"\n"+stderr.String(), &Result{})
 	}
 
 	return &Result{
@@ -138,10 +151,6 @@ func (s *GeminiSession) Run(ctx context.Context, prompt string) (*Result, error)
 	}, nil
 }
 
-func (s *GeminiSession) Events() <-chan Event {
-	return s.events
-}
-
 func (s *GeminiSession) Destroy(ctx context.Context) error {
 	if s.cmd != nil && s.cmd.Process != nil {
 		s.cmd.Process.Kill()
@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HedgedCall is one way of running a request, such as on one backend.
+type HedgedCall func(ctx context.Context) (*Result, error)
+
+// Hedge runs primary and, if it hasn't succeeded after delay, hedge
+// alongside it, returning the first success and cancelling the other. A
+// primary that fails before delay starts the hedge at once. hedged
+// reports whether the result came from hedge. When both fail the errors
+// are joined, primary's first.
+func Hedge(ctx context.Context, delay time.Duration, primary, hedge HedgedCall) (result *Result, hedged bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		result *Result
+		hedged bool
+		err    error
+	}
+	// Buffered so the loser can finish after we return
+	done := make(chan outcome, 2)
+	run := func(call HedgedCall, hedged bool) {
+		r, err := call(ctx)
+		if err == nil && (r == nil || !r.Success) {
+			err = errors.New("agent failed")
+			if r != nil && r.Error != "" {
+				err = fmt.Errorf("agent failed: %s", r.Error)
+			}
+		}
+		done <- outcome{r, hedged, err}
+	}
+
+	go run(primary, false)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	running, started := 1, false
+	var errs [2]error
+	start := func() {
+		if !started {
+			started = true
+			running++
+			go run(hedge, true)
+		}
+	}
+	for running > 0 {
+		select {
+		case <-timer.C:
+			start()
+		case o := <-done:
+			running--
+			if o.err == nil {
+				return o.result, o.hedged, nil
+			}
+			if o.hedged {
+				errs[1] = fmt.Errorf("hedged request: %w", o.err)
+			} else {
+				errs[0] = o.err
+				// Don't wait out the delay for a request already lost
+				if ctx.Err() == nil {
+					start()
+				}
+			}
+		}
+	}
+	return nil, false, errors.Join(errs[0], errs[1])
+}
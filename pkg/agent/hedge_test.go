@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// answer returns a call that succeeds with output after delay, or fails
+// early if its context is cancelled.
+func answer(output string, delay time.Duration) HedgedCall {
+	return func(ctx context.Context) (*Result, error) {
+		select {
+		case <-time.After(delay):
+			return &Result{Success: true, Output: output}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func TestHedgeFastPrimary(t *testing.T) {
+	hedgeCalled := false
+	result, hedged, err := Hedge(context.Background(), 50*time.Millisecond, answer("primary", 0),
+		func(ctx context.Context) (*Result, error) {
+			hedgeCalled = true
+			return &Result{Success: true, Output: "hedge"}, nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hedged || result.Output != "primary" {
+		t.Errorf("expected the primary's answer, got %q (hedged %v)", result.Output, hedged)
+	}
+	if hedgeCalled {
+		t.Error("expected no hedged request for a fast primary")
+	}
+}
+
+func TestHedgeSlowPrimary(t *testing.T) {
+	cancelled := make(chan struct{})
+	primary := func(ctx context.Context) (*Result, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}
+	result, hedged, err := Hedge(context.Background(), 10*time.Millisecond, primary, answer("hedge", 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hedged || result.Output != "hedge" {
+		t.Errorf("expected the hedge's answer, got %q (hedged %v)", result.Output, hedged)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the slow primary to be cancelled")
+	}
+}
+
+func TestHedgeFailedPrimary(t *testing.T) {
+	// A failed primary starts the hedge without waiting out the delay
+	primary := func(ctx context.Context) (*Result, error) {
+		return &Result{Success: false, Error: "boom"}, nil
+	}
+	start := time.Now()
+	result, hedged, err := Hedge(context.Background(), time.Minute, primary, answer("hedge", 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hedged || result.Output != "hedge" {
+		t.Errorf("expected the hedge's answer, got %q (hedged %v)", result.Output, hedged)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("expected the hedge to start at once")
+	}
+
+	// Both failing returns both errors
+	fail := errors.New("503 Service Unavailable")
+	_, _, err = Hedge(context.Background(), time.Millisecond, primary, func(ctx context.Context) (*Result, error) {
+		return nil, fail
+	})
+	if !errors.Is(err, fail) {
+		t.Errorf("expected the hedge's error, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the primary's error, got %v", err)
+	}
+}
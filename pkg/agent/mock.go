@@ -39,7 +39,6 @@ func (m *MockBackend) CreateSession(ctx context.Context, t *task.Task, worktree
 		backend:  m,
 		task:     t,
 		worktree: worktree,
-		events:   make(chan Event, 100),
 	}, nil
 }
 
@@ -87,10 +86,13 @@ type MockSession struct {
 	backend  *MockBackend
 	task     *task.Task
 	worktree string
-	events   chan Event
+	eventStream
 }
 
 func (s *MockSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	events := s.current()
+	defer s.end(events)
+
 	// Record the call
 	s.backend.recordCall(Call{
 		TaskID:   s.task.ID,
@@ -100,19 +102,14 @@ func (s *MockSession) Run(ctx context.Context, prompt string) (*Result, error) {
 
 	// Emit events
 	for _, event := range s.backend.getEvents() {
-		s.events <- event
+		events <- event
 	}
-	close(s.events)
 
 	// Return configured response
 	result := s.backend.getResponse()
 	return &result, nil
 }
 
-func (s *MockSession) Events() <-chan Event {
-	return s.events
-}
-
 func (s *MockSession) Destroy(ctx context.Context) error {
 	return nil
 }
@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/quota"
+)
+
+// RateLimitError is returned by a session the provider rate limited.
+type RateLimitError struct {
+	// Message is what the provider or CLI reported.
+	Message string
+	// RetryAfter is how long until the provider accepts requests again,
+	// or zero if it didn't say.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited (retry after %s): %s", e.RetryAfter.Round(time.Second), e.Message)
+	}
+	return "rate limited: " + e.Message
+}
+
+// Is reports whether target is quota.ErrQuotaExhausted: a rate limited
+// backend is out of quota until the provider accepts requests again.
+func (e *RateLimitError) Is(target error) bool {
+	return target == quota.ErrQuotaExhausted
+}
+
+// RetryAfter returns how long the provider asked to wait, if err is or
+// wraps a RateLimitError that said.
+func RetryAfter(err error) (time.Duration, bool) {
+	var rl *RateLimitError
+	if errors.As(err, &rl) && rl.RetryAfter > 0 {
+		return rl.RetryAfter, true
+	}
+	return 0, false
+}
+
+var (
+	// The Claude CLI reports a subscription limit as "Claude AI usage
+	// limit reached|<unix time it resets>".
+	usageLimitPattern = regexp.MustCompile(`(?i)usage limit reached\|(\d{9,})`)
+	// Retry-After headers are either seconds or an HTTP date.
+	retryAfterPattern = regexp.MustCompile(`(?im)retry-after["']?\s*[:=]\s*["']?([^"'\r\n}]+)`)
+	// x-ratelimit-reset-requests: 20s, anthropic-ratelimit-requests-reset:
+	// 2026-01-02T15:04:05Z and the like.
+	resetHeaderPattern = regexp.MustCompile(`(?im)ratelimit-(?:[a-z-]+-)?reset(?:-[a-z-]+)?["']?\s*[:=]\s*["']?([^"'\r\n,}]+)`)
+	// "try again in 20s", "retry in 5 minutes", "resets in 1h30m".
+	retryInPattern = regexp.MustCompile(`(?i)(?:try again|retry|resets?) in (\d+(?:\.\d+)?)\s*(ms|s|m|h|sec(?:ond)?s?|min(?:ute)?s?|hours?)\b`)
+	// Signs that a failure was a rate limit even without a retry time.
+	rateLimitPattern = regexp.MustCompile(`(?i)\b429\b|rate.?limit|too many requests|usage limit|quota exceeded`)
+)
+
+// ParseRateLimit reports whether CLI or API output describes a rate limit,
+// and how long the provider asked to wait: from a Retry-After or
+// rate-limit reset header, the Claude CLI's usage limit message, or a
+// "try again in" hint. now is when the output was produced.
+func ParseRateLimit(text string, now time.Time) (*RateLimitError, bool) {
+	if !rateLimitPattern.MatchString(text) && !retryAfterPattern.MatchString(text) {
+		return nil, false
+	}
+	rl := &RateLimitError{Message: summarize(text)}
+
+	if m := usageLimitPattern.FindStringSubmatch(text); m != nil {
+		if sec, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+			rl.RetryAfter = time.Unix(sec, 0).Sub(now)
+		}
+	}
+	if rl.RetryAfter <= 0 {
+		if m := retryAfterPattern.FindStringSubmatch(text); m != nil {
+			rl.RetryAfter = parseWait(m[1], now)
+		}
+	}
+	if rl.RetryAfter <= 0 {
+		for _, m := range resetHeaderPattern.FindAllStringSubmatch(text, -1) {
+			// The longest reset is when every limit allows requests again
+			if d := parseWait(m[1], now); d > rl.RetryAfter {
+				rl.RetryAfter = d
+			}
+		}
+	}
+	if rl.RetryAfter <= 0 {
+		if m := retryInPattern.FindStringSubmatch(text); m != nil {
+			rl.RetryAfter = parseWait(m[1]+unitSuffix(m[2]), now)
+		}
+	}
+	if rl.RetryAfter < 0 {
+		rl.RetryAfter = 0
+	}
+	return rl, true
+}
+
+// parseWait parses a wait given as seconds, a Go duration, an HTTP date or
+// an RFC 3339 time.
+func parseWait(s string, now time.Time) time.Duration {
+	s = strings.TrimSpace(s)
+	if sec, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(sec * float64(time.Second))
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		return t.Sub(now)
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Sub(now)
+	}
+	// A value followed by other fields, as in "retry-after: 30, ..."
+	if before, _, ok := strings.Cut(s, ","); ok {
+		return parseWait(before, now)
+	}
+	return 0
+}
+
+// unitSuffix converts a spelled-out unit to a Go duration suffix.
+func unitSuffix(unit string) string {
+	switch u := strings.ToLower(unit); {
+	case u == "ms":
+		return "ms"
+	case strings.HasPrefix(u, "h"):
+		return "h"
+	case strings.HasPrefix(u, "m"):
+		return "m"
+	}
+	return "s"
+}
+
+// summarize returns the first non-empty line of text, for error messages.
+func summarize(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if len(line) > 200 {
+				line = line[:200] + "…"
+			}
+			return line
+		}
+	}
+	return "rate limited"
+}
+
+// cliFailure returns the outcome of a CLI run that exited with err: a
+// RateLimitError when its output (the final result text and stderr) says
+// the provider rate limited it, otherwise result marked as failed.
+func cliFailure(err error, output string, result *Result) (*Result, error) {
+	if rl, ok := ParseRateLimit(output, time.Now()); ok {
+		return nil, rl
+	}
+	result.Success = false
+	result.Error = err.Error()
+	return result, nil
+}
@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/quota"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		text    string
+		limited bool
+		want    time.Duration
+	}{
+		{"claude usage limit", fmt.Sprintf("Claude AI usage limit reached|%d", now.Add(3*time.Hour).Unix()), true, 3 * time.Hour},
+		{"retry-after seconds", "API Error: 429 Too Many Requests\nretry-after: 30\n", true, 30 * time.Second},
+		{"retry-after date", "HTTP 429\nRetry-After: Mon, 02 Mar 2026 12:05:00 GMT", true, 5 * time.Minute},
+		{"json header", `{"status":429,"headers":{"retry-after":"12"}}`, true, 12 * time.Second},
+		{"reset headers", "rate limit exceeded\nx-ratelimit-reset-requests: 20s\nx-ratelimit-reset-tokens: 1m30s", true, 90 * time.Second},
+		{"rfc3339 reset", "429\nanthropic-ratelimit-requests-reset: 2026-03-02T12:10:00Z", true, 10 * time.Minute},
+		{"try again hint", "Rate limit reached for requests. Please try again in 20 seconds.", true, 20 * time.Second},
+		{"no wait given", "Error: 429 Too Many Requests", true, 0},
+		{"not a rate limit", "Error: tests failed in ./pkg/foo", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, ok := ParseRateLimit(tt.text, now)
+			if ok != tt.limited {
+				t.Fatalf("limited = %v, want %v", ok, tt.limited)
+			}
+			if ok && rl.RetryAfter != tt.want {
+				t.Errorf("RetryAfter = %s, want %s", rl.RetryAfter, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterThroughWrapping(t *testing.T) {
+	err := fmt.Errorf("max retries exceeded: %w", &RateLimitError{Message: "429", RetryAfter: time.Minute})
+	if d, ok := RetryAfter(err); !ok || d != time.Minute {
+		t.Errorf("RetryAfter = %s %v, want 1m", d, ok)
+	}
+	if ErrorClass(err) != ErrorClassRateLimit {
+		t.Errorf("expected a rate limit class, got %s", ErrorClass(err))
+	}
+	if !errors.Is(err, quota.ErrQuotaExhausted) {
+		t.Error("expected a rate limit to be quota exhaustion")
+	}
+	if _, ok := RetryAfter(errors.New("exit status 1")); ok {
+		t.Error("expected no retry time for other errors")
+	}
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	config := RetryConfig{
+		MaxRetries:       3,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       50 * time.Millisecond,
+		BackoffFactor:    2.0,
+		FailureThreshold: 100,
+		ResetTimeout:     time.Second,
+	}
+
+	var backoffs []time.Duration
+	config.OnRetry = func(a RetryAttempt) { backoffs = append(backoffs, a.Backoff) }
+	attempts := 0
+	err := retry(context.Background(), config, newCircuitBreaker(config), func() error {
+		attempts++
+		if attempts == 1 {
+			return &RateLimitError{Message: "slow down", RetryAfter: 20 * time.Millisecond}
+		}
+		return nil
+	})
+	if err != nil || len(backoffs) != 1 || backoffs[0] != 20*time.Millisecond {
+		t.Errorf("expected one retry after the provider's 20ms, got %v (err %v)", backoffs, err)
+	}
+
+	// A reset beyond the longest backoff isn't worth waiting for
+	attempts = 0
+	err = retry(context.Background(), config, newCircuitBreaker(config), func() error {
+		attempts++
+		return &RateLimitError{Message: "usage limit reached", RetryAfter: time.Hour}
+	})
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+	if d, ok := RetryAfter(err); !ok || d != time.Hour {
+		t.Errorf("expected the rate limit error back, got %v", err)
+	}
+}
+
+func TestRetryAfterReplacesSchedule(t *testing.T) {
+	config := RetryConfig{
+		MaxRetries:       3,
+		InitialBackoff:   40 * time.Millisecond,
+		MaxBackoff:       40 * time.Millisecond,
+		MaxRetryAfter:    100 * time.Millisecond,
+		Jitter:           0.5,
+		FailureThreshold: 100,
+		ResetTimeout:     time.Second,
+	}
+	var attempts []RetryAttempt
+	var limits []time.Duration
+	config.OnRetry = func(a RetryAttempt) { attempts = append(attempts, a) }
+	config.OnRateLimit = func(wait time.Duration, err error) { limits = append(limits, wait) }
+
+	waits := []time.Duration{2 * time.Millisecond, 60 * time.Millisecond}
+	n := 0
+	err := retry(context.Background(), config, newCircuitBreaker(config), func() error {
+		if n < len(waits) {
+			n++
+			return &RateLimitError{Message: "slow down", RetryAfter: waits[n-1]}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Shorter than the schedule, and beyond MaxBackoff but within
+	// MaxRetryAfter: both exactly as asked, without jitter
+	if len(attempts) != 2 || attempts[0].Backoff != waits[0] || attempts[1].Backoff != waits[1] {
+		t.Errorf("expected backoffs of %v, got %+v", waits, attempts)
+	}
+	if attempts[0].RetryAfter != waits[0] || !strings.Contains(attempts[0].String(), "as the provider asked") {
+		t.Errorf("expected the attempt to carry the provider's wait, got %+v", attempts[0])
+	}
+	if len(limits) != 2 || limits[1] != waits[1] {
+		t.Errorf("expected OnRateLimit for each wait, got %v", limits)
+	}
+
+	rb := NewRetryableBackend(NewMockBackend(), RetryConfig{MaxRetries: 1, MaxRetryAfter: time.Millisecond, FailureThreshold: 100})
+	var recorded []time.Duration
+	rb.OnRateLimit(func(wait time.Duration, err error) { recorded = append(recorded, wait) })
+	rb.retryWithBackoff(context.Background(), func() error {
+		return &RateLimitError{Message: "usage limit reached", RetryAfter: time.Hour}
+	})
+	if len(recorded) != 1 || recorded[0] != time.Hour {
+		t.Errorf("expected a rate limit beyond the cap to still be reported, got %v", recorded)
+	}
+}
@@ -3,10 +3,18 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/clock"
+	"github.com/richgo/flo/pkg/events"
+	"github.com/richgo/flo/pkg/quota"
 	"github.com/richgo/flo/pkg/task"
 )
 
@@ -16,9 +24,132 @@ type RetryConfig struct {
 	InitialBackoff time.Duration
 	MaxBackoff     time.Duration
 	BackoffFactor  float64
+	// Strategy names the backoff strategy (default exponential); see
+	// RegisterBackoff.
+	Strategy string
+	// Rand returns numbers in [0, 1) for jittered strategies (default
+	// math/rand).
+	Rand func() float64
+	// Jitter shortens each backoff by a random fraction of up to Jitter
+	// (0 to 1), so clients that failed together don't retry together.
+	Jitter float64
+	// Retryable reports whether a failed attempt is worth retrying
+	// (default IsRetryable).
+	Retryable func(error) bool
 	// Circuit breaker settings
 	FailureThreshold int
 	ResetTimeout     time.Duration
+	// SuccessThreshold is how many probes in a row must succeed before a
+	// half-open circuit closes (default 1).
+	SuccessThreshold int
+	// Clock times backoffs and the circuit reset (default the system clock).
+	Clock clock.Clock
+	// OnRetry, if set, is called before each backoff so callers can show
+	// why a run has paused.
+	OnRetry func(RetryAttempt)
+	// MaxRetryAfter is the longest wait a rate-limiting provider may ask
+	// for and still be retried; longer waits fail the call so callers can
+	// fail over. Zero uses MaxBackoff.
+	MaxRetryAfter time.Duration
+	// OnRateLimit, if set, is called when a provider rate limits an
+	// attempt and says how long to wait, so callers can record it.
+	OnRateLimit func(wait time.Duration, err error)
+	// Budget, if set, caps retries across every backend sharing it, such
+	// as those of one run.
+	Budget *RetryBudget
+	// OnBreakerChange, if set, is called when a backend's circuit breaker
+	// changes state.
+	OnBreakerChange func(from, to CircuitState)
+	// Audit, if set, is the audit log retries and circuit breaker changes
+	// are recorded in, such as the workspace's (default the default log).
+	Audit *audit.Logger
+}
+
+// RetryAttempt describes a failed attempt that is about to be retried.
+type RetryAttempt struct {
+	// Attempt is the number of the attempt that failed, from 1.
+	Attempt int
+	// MaxAttempts is the most attempts that will be made.
+	MaxAttempts int
+	// Class is the kind of error, from ErrorClass.
+	Class string
+	Err   error
+	// Backoff is how long until the next attempt.
+	Backoff time.Duration
+	// RetryAfter is the wait the provider asked for, or zero if it
+	// didn't; the backoff is then exactly that.
+	RetryAfter time.Duration
+}
+
+// String describes the attempt for people watching a run.
+func (a RetryAttempt) String() string {
+	if a.RetryAfter > 0 {
+		return fmt.Sprintf("attempt %d/%d failed (%s): %v; retrying in %s as the provider asked", a.Attempt, a.MaxAttempts, a.Class, a.Err, a.Backoff)
+	}
+	return fmt.Sprintf("attempt %d/%d failed (%s): %v; retrying in %s", a.Attempt, a.MaxAttempts, a.Class, a.Err, a.Backoff)
+}
+
+// ErrCircuitOpen is returned while the circuit breaker refuses calls.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Error classes reported in retry events.
+const (
+	ErrorClassTimeout     = "timeout"
+	ErrorClassRateLimit   = "rate_limit"
+	ErrorClassServer      = "server"
+	ErrorClassCircuitOpen = "circuit_open"
+	ErrorClassAuth        = "auth"
+	ErrorClassInvalid     = "invalid_request"
+	ErrorClassOther       = "error"
+)
+
+var (
+	// 5xx responses and overloaded providers.
+	serverErrorPattern = regexp.MustCompile(`(?i)\b5\d\d\b|internal server error|bad gateway|service unavailable|overloaded`)
+	// Missing or rejected credentials.
+	authErrorPattern = regexp.MustCompile(`(?i)\b40[13]\b|unauthori[sz]ed|forbidden|authentication|invalid (?:x-)?api[ -]?key|api key (?:is )?(?:invalid|missing)|not logged in|please run /login`)
+	// Requests the provider will reject however often they are sent.
+	invalidErrorPattern = regexp.MustCompile(`(?i)\b400\b|bad request|invalid_request|invalid request|prompt is too long|context length|context window`)
+)
+
+// ErrorClass names the kind of err for retry events and the audit log.
+func ErrorClass(err error) string {
+	if errors.Is(err, ErrCircuitOpen) {
+		return ErrorClassCircuitOpen
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	if errors.Is(err, quota.ErrQuotaExhausted) {
+		return ErrorClassRateLimit
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "429"), strings.Contains(msg, "rate limit"),
+		strings.Contains(msg, "too many requests"), strings.Contains(msg, "quota"):
+		return ErrorClassRateLimit
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "timed out"):
+		return ErrorClassTimeout
+	case serverErrorPattern.MatchString(msg):
+		return ErrorClassServer
+	case authErrorPattern.MatchString(msg):
+		return ErrorClassAuth
+	case invalidErrorPattern.MatchString(msg):
+		return ErrorClassInvalid
+	}
+	return ErrorClassOther
+}
+
+// IsRetryable reports whether an attempt that failed with err may succeed
+// if tried again. Auth failures and invalid requests fail the same way
+// every time; rate limits, server errors, timeouts and unrecognised
+// errors are retried.
+func IsRetryable(err error) bool {
+	switch ErrorClass(err) {
+	case ErrorClassAuth, ErrorClassInvalid:
+		return false
+	}
+	return true
 }
 
 // DefaultRetryConfig returns sensible defaults.
@@ -28,8 +159,11 @@ func DefaultRetryConfig() RetryConfig {
 		InitialBackoff:   time.Second,
 		MaxBackoff:       30 * time.Second,
 		BackoffFactor:    2.0,
+		Jitter:           0.2,
+		MaxRetryAfter:    2 * time.Minute,
 		FailureThreshold: 5,
 		ResetTimeout:     60 * time.Second,
+		SuccessThreshold: 2,
 	}
 }
 
@@ -42,14 +176,38 @@ const (
 	CircuitHalfOpen
 )
 
-// CircuitBreaker implements the circuit breaker pattern.
+// String returns the state's name: closed, open or half_open.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker implements the circuit breaker pattern. Once the reset
+// timeout has passed an open circuit goes half-open and lets one probe
+// call through at a time, failing the rest fast; it closes after
+// enough probes in a row succeed and opens again if one fails.
 type CircuitBreaker struct {
 	mu               sync.Mutex
 	state            CircuitState
 	failures         int
+	successes        int
+	probing          bool
 	lastFailureTime  time.Time
+	trips            int
 	failureThreshold int
+	successThreshold int
 	resetTimeout     time.Duration
+	clock            clock.Clock
+	onChange         func(from, to CircuitState)
+	// changes holds transitions not yet passed to onChange, which is
+	// called outside the lock.
+	changes [][2]CircuitState
 }
 
 // NewCircuitBreaker creates a new circuit breaker.
@@ -58,23 +216,79 @@ func NewCircuitBreaker(failureThreshold int, resetTimeout time.Duration) *Circui
 		state:            CircuitClosed,
 		failures:         0,
 		failureThreshold: failureThreshold,
+		successThreshold: 1,
 		resetTimeout:     resetTimeout,
+		clock:            clock.Real,
+	}
+}
+
+// SetClock sets the clock the reset timeout is measured with.
+func (cb *CircuitBreaker) SetClock(c clock.Clock) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.clock = clock.Or(c)
+}
+
+// SetSuccessThreshold sets how many probes in a row must succeed before
+// a half-open circuit closes. Values below 1 are treated as 1.
+func (cb *CircuitBreaker) SetSuccessThreshold(n int) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.successThreshold = max(n, 1)
+}
+
+// OnStateChange calls fn whenever the circuit changes state.
+func (cb *CircuitBreaker) OnStateChange(fn func(from, to CircuitState)) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.onChange = fn
+}
+
+// setState moves the circuit to state; the caller holds the lock.
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	if state != cb.state && cb.onChange != nil {
+		cb.changes = append(cb.changes, [2]CircuitState{cb.state, state})
+	}
+	cb.state = state
+}
+
+// notify passes pending transitions to onChange; the caller doesn't hold
+// the lock.
+func (cb *CircuitBreaker) notify() {
+	cb.mu.Lock()
+	changes, fn := cb.changes, cb.onChange
+	cb.changes = nil
+	cb.mu.Unlock()
+	for _, c := range changes {
+		fn(c[0], c[1])
 	}
 }
 
 // Call executes a function through the circuit breaker.
 func (cb *CircuitBreaker) Call(fn func() error) error {
+	defer cb.notify()
 	cb.mu.Lock()
 
 	// Check if circuit should transition from open to half-open
 	if cb.state == CircuitOpen {
-		if time.Since(cb.lastFailureTime) > cb.resetTimeout {
-			cb.state = CircuitHalfOpen
+		if cb.clock.Since(cb.lastFailureTime) > cb.resetTimeout {
+			cb.setState(CircuitHalfOpen)
 			cb.failures = 0
+			cb.successes = 0
 		} else {
 			cb.mu.Unlock()
-			return fmt.Errorf("circuit breaker is open")
+			return ErrCircuitOpen
+		}
+	}
+
+	// Only one probe at a time while half-open
+	probe := cb.state == CircuitHalfOpen
+	if probe {
+		if cb.probing {
+			cb.mu.Unlock()
+			return ErrCircuitOpen
 		}
+		cb.probing = true
 	}
 
 	cb.mu.Unlock()
@@ -84,20 +298,29 @@ func (cb *CircuitBreaker) Call(fn func() error) error {
 
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
+	if probe {
+		cb.probing = false
+	}
 
 	if err != nil {
 		cb.failures++
-		cb.lastFailureTime = time.Now()
+		cb.lastFailureTime = cb.clock.Now()
 
-		if cb.failures >= cb.failureThreshold {
-			cb.state = CircuitOpen
+		// A failed probe opens the circuit again at once
+		if (probe || cb.failures >= cb.failureThreshold) && cb.state != CircuitOpen {
+			cb.setState(CircuitOpen)
+			cb.trips++
 		}
 		return err
 	}
 
-	// Success - reset circuit
+	// Success - close the circuit once enough probes have succeeded
 	if cb.state == CircuitHalfOpen {
-		cb.state = CircuitClosed
+		cb.successes++
+		if cb.successes >= cb.successThreshold {
+			cb.setState(CircuitClosed)
+			cb.successes = 0
+		}
 	}
 	cb.failures = 0
 	return nil
@@ -110,12 +333,29 @@ func (cb *CircuitBreaker) State() CircuitState {
 	return cb.state
 }
 
+// Trips returns how many times the circuit has opened.
+func (cb *CircuitBreaker) Trips() int {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.trips
+}
+
 // Reset resets the circuit breaker to closed state.
 func (cb *CircuitBreaker) Reset() {
+	defer cb.notify()
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
-	cb.state = CircuitClosed
+	cb.setState(CircuitClosed)
 	cb.failures = 0
+	cb.successes = 0
+}
+
+// newCircuitBreaker creates the circuit breaker for a retry config.
+func newCircuitBreaker(config RetryConfig) *CircuitBreaker {
+	cb := NewCircuitBreaker(config.FailureThreshold, config.ResetTimeout)
+	cb.SetClock(config.Clock)
+	cb.SetSuccessThreshold(config.SuccessThreshold)
+	return cb
 }
 
 // RetryableBackend wraps a Backend with retry logic and circuit breaker.
@@ -123,18 +363,65 @@ type RetryableBackend struct {
 	backend        Backend
 	config         RetryConfig
 	circuitBreaker *CircuitBreaker
+	retries        atomic.Int64
 }
 
 // NewRetryableBackend wraps a backend with retry capabilities.
 func NewRetryableBackend(backend Backend, config RetryConfig) *RetryableBackend {
-	return &RetryableBackend{
-		backend: backend,
-		config:  config,
-		circuitBreaker: NewCircuitBreaker(
-			config.FailureThreshold,
-			config.ResetTimeout,
-		),
+	r := &RetryableBackend{
+		backend:        backend,
+		circuitBreaker: newCircuitBreaker(config),
 	}
+	onRetry := config.OnRetry
+	config.OnRetry = func(a RetryAttempt) {
+		r.retries.Add(1)
+		if onRetry != nil {
+			onRetry(a)
+		}
+	}
+	r.config = config
+	r.circuitBreaker.OnStateChange(func(from, to CircuitState) {
+		level := audit.LevelInfo
+		if to == CircuitOpen {
+			level = audit.LevelWarn
+		}
+		events.PublishTo(r.config.Audit, level, "Circuit breaker changed state", audit.BreakerChanged{
+			Backend: backend.Name(),
+			From:    from.String(),
+			To:      to.String(),
+		})
+		if r.config.OnBreakerChange != nil {
+			r.config.OnBreakerChange(from, to)
+		}
+	})
+	return r
+}
+
+// OnRateLimit calls fn when a provider rate limits the backend and says
+// how long to wait, such as to record it in a quota tracker.
+func (r *RetryableBackend) OnRateLimit(fn func(wait time.Duration, err error)) {
+	r.config.OnRateLimit = fn
+}
+
+// SetBudget draws the backend's retries from budget, which may be shared
+// with other backends.
+func (r *RetryableBackend) SetBudget(budget *RetryBudget) {
+	r.config.Budget = budget
+}
+
+// Retries returns how many retries the backend has made.
+func (r *RetryableBackend) Retries() int {
+	return int(r.retries.Load())
+}
+
+// BreakerTrips returns how many times the backend's circuit has opened.
+func (r *RetryableBackend) BreakerTrips() int {
+	return r.circuitBreaker.Trips()
+}
+
+// BreakerState returns the state of the backend's circuit breaker.
+func (r *RetryableBackend) BreakerState() CircuitState {
+	return r.circuitBreaker.State()
 }
 
 // Name returns the backend name.
@@ -154,7 +441,9 @@ func (r *RetryableBackend) Stop() error {
 	return r.backend.Stop()
 }
 
-// CreateSession creates a session with retry.
+// CreateSession creates a session with retry. Rate limits and most other
+// transient failures only show up when the session runs, so the session's
+// runs are retried too, with the backend's config and circuit breaker.
 func (r *RetryableBackend) CreateSession(ctx context.Context, t *task.Task, worktree string) (Session, error) {
 	var session Session
 	err := r.retryWithBackoff(ctx, func() error {
@@ -162,117 +451,179 @@ func (r *RetryableBackend) CreateSession(ctx context.Context, t *task.Task, work
 		session, err = r.backend.CreateSession(ctx, t, worktree)
 		return err
 	})
-	return session, err
+	if err != nil {
+		return nil, err
+	}
+	return &RetryableSession{
+		session:        session,
+		config:         r.config,
+		circuitBreaker: r.circuitBreaker,
+	}, nil
 }
 
-// retryWithBackoff implements exponential backoff retry logic.
+// retryWithBackoff retries fn with the configured backoff strategy.
 func (r *RetryableBackend) retryWithBackoff(ctx context.Context, fn func() error) error {
-	var lastErr error
-	backoff := r.config.InitialBackoff
-
-	for attempt := 0; attempt <= r.config.MaxRetries; attempt++ {
-		// Check circuit breaker
-		err := r.circuitBreaker.Call(fn)
-		if err == nil {
-			return nil
-		}
-
-		lastErr = err
-
-		// Don't sleep after last attempt
-		if attempt == r.config.MaxRetries {
-			break
-		}
-
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("retry cancelled: %w", ctx.Err())
-		case <-time.After(backoff):
-		}
-
-		// Calculate next backoff
-		backoff = time.Duration(float64(backoff) * r.config.BackoffFactor)
-		if backoff > r.config.MaxBackoff {
-			backoff = r.config.MaxBackoff
-		}
-	}
-
-	return fmt.Errorf("max retries exceeded: %w", lastErr)
+	return retry(ctx, r.config, r.circuitBreaker, fn)
 }
 
-// RetryableSession wraps a Session with retry logic.
+// RetryableSession wraps a Session with retry logic. Its event stream
+// carries the events of each of the wrapped session's runs plus a "retry"
+// event before each backoff.
 type RetryableSession struct {
 	session        Session
 	config         RetryConfig
 	circuitBreaker *CircuitBreaker
+	eventStream
 }
 
 // NewRetryableSession wraps a session with retry capabilities.
 func NewRetryableSession(session Session, config RetryConfig) *RetryableSession {
 	return &RetryableSession{
-		session: session,
-		config:  config,
-		circuitBreaker: NewCircuitBreaker(
-			config.FailureThreshold,
-			config.ResetTimeout,
-		),
+		session:        session,
+		config:         config,
+		circuitBreaker: newCircuitBreaker(config),
 	}
 }
 
-// Run executes the session with retry.
+// Run executes the session with retry. The event stream is closed when
+// Run returns.
 func (r *RetryableSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	events := r.current()
+	defer r.end(events)
+
+	config := r.config
+	onRetry := config.OnRetry
+	config.OnRetry = func(a RetryAttempt) {
+		events <- Event{Type: "retry", Content: a.String(), Retry: &a}
+		if onRetry != nil {
+			onRetry(a)
+		}
+	}
+
 	var result *Result
-	err := r.retryWithBackoff(ctx, func() error {
+	err := retry(ctx, config, r.circuitBreaker, func() error {
+		// Each run of the wrapped session has a stream of its own, closed
+		// when the run returns
+		forwarded := make(chan struct{})
+		go func(stream <-chan Event) {
+			defer close(forwarded)
+			for event := range stream {
+				events <- event
+			}
+		}(r.session.Events())
+
 		var err error
 		result, err = r.session.Run(ctx, prompt)
+		<-forwarded
 		return err
 	})
 	return result, err
 }
 
-// Events returns the event channel.
-func (r *RetryableSession) Events() <-chan Event {
-	return r.session.Events()
-}
-
 // Destroy destroys the session.
 func (r *RetryableSession) Destroy(ctx context.Context) error {
 	return r.session.Destroy(ctx)
 }
 
-// retryWithBackoff implements exponential backoff retry logic.
+// retryWithBackoff retries fn with the configured backoff strategy.
 func (r *RetryableSession) retryWithBackoff(ctx context.Context, fn func() error) error {
+	return retry(ctx, r.config, r.circuitBreaker, fn)
+}
+
+// retry calls fn through cb until it succeeds or MaxRetries retries have
+// failed, waiting between attempts as the config's strategy says.
+func retry(ctx context.Context, config RetryConfig, cb *CircuitBreaker, fn func() error) error {
+	if err := ValidateBackoff(config.Strategy); err != nil {
+		return err
+	}
+
 	var lastErr error
-	backoff := r.config.InitialBackoff
+	var backoff time.Duration
 
-	for attempt := 0; attempt <= r.config.MaxRetries; attempt++ {
+	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
 		// Check circuit breaker
-		err := r.circuitBreaker.Call(fn)
+		err := cb.Call(fn)
 		if err == nil {
 			return nil
 		}
 
 		lastErr = err
 
+		// Auth failures and invalid requests would only fail again
+		if !config.retryable(err) {
+			return fmt.Errorf("not retrying %s error: %w", ErrorClass(err), err)
+		}
+
 		// Don't sleep after last attempt
-		if attempt == r.config.MaxRetries {
+		if attempt == config.MaxRetries {
 			break
 		}
 
+		// Wait exactly as long as a rate-limiting provider asked, rather
+		// than the backoff schedule, unless that's longer than the cap,
+		// when retrying would only fail again
+		wait, limited := RetryAfter(lastErr)
+		if limited {
+			if config.OnRateLimit != nil {
+				config.OnRateLimit(wait, lastErr)
+			}
+			if limit := config.maxRetryAfter(); limit > 0 && wait > limit {
+				return lastErr
+			}
+			backoff = wait
+		} else if backoff, err = nextBackoff(config, attempt+1, backoff); err != nil {
+			return err
+		}
+		if err := config.Budget.Spend(backoff); err != nil {
+			return fmt.Errorf("%w; last error: %w", err, lastErr)
+		}
+		reportRetry(config, RetryAttempt{
+			Attempt:     attempt + 1,
+			MaxAttempts: config.MaxRetries + 1,
+			Class:       ErrorClass(lastErr),
+			Err:         lastErr,
+			Backoff:     backoff,
+			RetryAfter:  wait,
+		})
+
 		// Check context cancellation
 		select {
 		case <-ctx.Done():
 			return fmt.Errorf("retry cancelled: %w", ctx.Err())
-		case <-time.After(backoff):
-		}
-
-		// Calculate next backoff
-		backoff = time.Duration(float64(backoff) * r.config.BackoffFactor)
-		if backoff > r.config.MaxBackoff {
-			backoff = r.config.MaxBackoff
+		case <-clock.Or(config.Clock).After(backoff):
 		}
 	}
 
 	return fmt.Errorf("max retries exceeded: %w", lastErr)
 }
+
+// maxRetryAfter returns the longest provider-requested wait retried.
+func (config RetryConfig) maxRetryAfter() time.Duration {
+	if config.MaxRetryAfter > 0 {
+		return config.MaxRetryAfter
+	}
+	return config.MaxBackoff
+}
+
+// retryable reports whether config retries err.
+func (config RetryConfig) retryable(err error) bool {
+	if config.Retryable != nil {
+		return config.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// reportRetry records a retry in the audit log and passes it to OnRetry.
+func reportRetry(config RetryConfig, a RetryAttempt) {
+	events.PublishTo(config.Audit, audit.LevelWarn, "Retrying after failed attempt", audit.RetryScheduled{
+		Attempt:      a.Attempt,
+		MaxAttempts:  a.MaxAttempts,
+		Class:        a.Class,
+		Error:        a.Err.Error(),
+		BackoffMS:    a.Backoff.Milliseconds(),
+		RetryAfterMS: a.RetryAfter.Milliseconds(),
+	})
+	if config.OnRetry != nil {
+		config.OnRetry(a)
+	}
+}
@@ -3,8 +3,13 @@ package agent
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/richgo/flo/pkg/clock"
+	"github.com/richgo/flo/pkg/task"
 )
 
 func TestCircuitBreaker_Call(t *testing.T) {
@@ -66,6 +71,14 @@ func TestCircuitBreaker_Call(t *testing.T) {
 			if cb.State() != tt.wantState {
 				t.Errorf("circuit state = %v, want %v", cb.State(), tt.wantState)
 			}
+
+			wantTrips := 0
+			if tt.wantState == CircuitOpen {
+				wantTrips = 1
+			}
+			if cb.Trips() != wantTrips {
+				t.Errorf("trips = %d, want %d", cb.Trips(), wantTrips)
+			}
 		})
 	}
 }
@@ -92,6 +105,8 @@ func TestCircuitBreaker_Reset(t *testing.T) {
 func TestCircuitBreaker_HalfOpen(t *testing.T) {
 	resetTimeout := 100 * time.Millisecond
 	cb := NewCircuitBreaker(2, resetTimeout)
+	fake := clock.NewFake(time.Now())
+	cb.SetClock(fake)
 
 	// Open the circuit
 	cb.Call(func() error { return errors.New("fail") })
@@ -101,8 +116,11 @@ func TestCircuitBreaker_HalfOpen(t *testing.T) {
 		t.Fatalf("circuit state = %v, want CircuitOpen", cb.State())
 	}
 
-	// Wait for reset timeout
-	time.Sleep(resetTimeout + 10*time.Millisecond)
+	// Still open until the reset timeout passes
+	if err := cb.Call(func() error { return nil }); err == nil {
+		t.Fatal("expected the open circuit to reject calls")
+	}
+	fake.Advance(resetTimeout + time.Millisecond)
 
 	// Next call should transition to half-open
 	err := cb.Call(func() error { return nil })
@@ -116,6 +134,87 @@ func TestCircuitBreaker_HalfOpen(t *testing.T) {
 	}
 }
 
+func TestCircuitBreaker_HalfOpenProbes(t *testing.T) {
+	resetTimeout := 100 * time.Millisecond
+	cb := NewCircuitBreaker(2, resetTimeout)
+	cb.SetSuccessThreshold(2)
+	fake := clock.NewFake(time.Now())
+	cb.SetClock(fake)
+
+	open := func() {
+		cb.Call(func() error { return errors.New("fail") })
+		cb.Call(func() error { return errors.New("fail") })
+		fake.Advance(resetTimeout + time.Millisecond)
+	}
+	open()
+
+	// While one probe is in flight, other callers fail fast
+	release := make(chan struct{})
+	probed := make(chan error)
+	go func() {
+		probed <- cb.Call(func() error {
+			<-release
+			return nil
+		})
+	}()
+	// The probe goes half-open and claims its slot in one step
+	for cb.State() != CircuitHalfOpen {
+		time.Sleep(time.Millisecond)
+	}
+	called := false
+	if err := cb.Call(func() error { called = true; return nil }); !errors.Is(err, ErrCircuitOpen) || called {
+		t.Fatalf("expected a second caller to fail fast during a probe, got %v", err)
+	}
+	close(release)
+	if err := <-probed; err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+
+	// One success isn't enough to close
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("circuit state after one probe = %v, want CircuitHalfOpen", cb.State())
+	}
+	cb.Call(func() error { return nil })
+	if cb.State() != CircuitClosed {
+		t.Errorf("circuit state after two probes = %v, want CircuitClosed", cb.State())
+	}
+
+	// A failed probe opens the circuit again at once
+	open()
+	cb.Call(func() error { return nil })
+	cb.Call(func() error { return errors.New("fail") })
+	if cb.State() != CircuitOpen {
+		t.Errorf("circuit state after a failed probe = %v, want CircuitOpen", cb.State())
+	}
+	if cb.Trips() != 3 {
+		t.Errorf("trips = %d, want 3", cb.Trips())
+	}
+}
+
+func TestCircuitBreaker_OnStateChange(t *testing.T) {
+	resetTimeout := 100 * time.Millisecond
+	cb := NewCircuitBreaker(1, resetTimeout)
+	fake := clock.NewFake(time.Now())
+	cb.SetClock(fake)
+
+	var changes []string
+	cb.OnStateChange(func(from, to CircuitState) {
+		// Called outside the lock, so reading the breaker doesn't deadlock
+		cb.State()
+		changes = append(changes, from.String()+"->"+to.String())
+	})
+
+	cb.Call(func() error { return errors.New("fail") })
+	cb.Call(func() error { return nil })
+	fake.Advance(resetTimeout + time.Millisecond)
+	cb.Call(func() error { return nil })
+
+	want := []string{"closed->open", "open->half_open", "half_open->closed"}
+	if strings.Join(changes, " ") != strings.Join(want, " ") {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+}
+
 func TestRetryableBackend_RetryLogic(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -222,37 +321,205 @@ func TestRetryableBackend_ContextCancellation(t *testing.T) {
 
 func TestRetryableBackend_ExponentialBackoff(t *testing.T) {
 	mockBackend := NewMockBackend()
+	fake := clock.NewFake(time.Now())
 	config := RetryConfig{
-		MaxRetries:       3,
+		MaxRetries:       4,
 		InitialBackoff:   10 * time.Millisecond,
-		MaxBackoff:       100 * time.Millisecond,
+		MaxBackoff:       30 * time.Millisecond,
 		BackoffFactor:    2.0,
 		FailureThreshold: 100,
 		ResetTimeout:     time.Second,
+		Clock:            fake,
 	}
 
 	rb := NewRetryableBackend(mockBackend, config)
 
-	start := time.Now()
-	attempts := 0
+	start := fake.Now()
+	var attemptTimes []time.Duration
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rb.retryWithBackoff(context.Background(), func() error {
+			attemptTimes = append(attemptTimes, fake.Since(start))
+			return errors.New("simulated failure")
+		})
+	}()
+
+	// Wake each backoff as soon as the retry loop sleeps, one step at a time.
+	for i := 0; i < config.MaxRetries; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(time.Millisecond)
+		for fake.Waiters() > 0 {
+			fake.Advance(time.Millisecond)
+		}
+	}
+	<-done
 
-	rb.retryWithBackoff(context.Background(), func() error {
-		attempts++
-		return errors.New("simulated failure")
+	// Backoffs of 10ms, 20ms, then capped at 30ms.
+	want := []time.Duration{0, 10 * time.Millisecond, 30 * time.Millisecond, 60 * time.Millisecond, 90 * time.Millisecond}
+	if len(attemptTimes) != len(want) {
+		t.Fatalf("attempts at %v, want %v", attemptTimes, want)
+	}
+	for i := range want {
+		if attemptTimes[i] != want[i] {
+			t.Errorf("attempts at %v, want %v", attemptTimes, want)
+			break
+		}
+	}
+}
+
+// flakySession fails its first failures runs with err, then succeeds,
+// emitting a message. Each run closes its stream.
+type flakySession struct {
+	failures int
+	err      error
+	runs     int
+	eventStream
+}
+
+func (s *flakySession) Run(ctx context.Context, prompt string) (*Result, error) {
+	events := s.current()
+	defer s.end(events)
+	s.runs++
+	if s.runs <= s.failures {
+		events <- Event{Type: "error", Content: s.err.Error()}
+		return nil, s.err
+	}
+	events <- Event{Type: "message", Content: "hello"}
+	return &Result{Success: true}, nil
+}
+
+func (s *flakySession) Destroy(context.Context) error { return nil }
+
+func TestRetryableSession_RetryEvents(t *testing.T) {
+	inner := &flakySession{failures: 2, err: errors.New("HTTP 429 Too Many Requests")}
+	var hooked []RetryAttempt
+	rs := NewRetryableSession(inner, RetryConfig{
+		MaxRetries:       3,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       10 * time.Millisecond,
+		BackoffFactor:    2.0,
+		FailureThreshold: 100,
+		ResetTimeout:     time.Second,
+		OnRetry:          func(a RetryAttempt) { hooked = append(hooked, a) },
+	})
+
+	var events []Event
+	streamDone := make(chan struct{})
+	go func() {
+		defer close(streamDone)
+		for event := range rs.Events() {
+			events = append(events, event)
+		}
+	}()
+
+	if _, err := rs.Run(context.Background(), "go"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	<-streamDone
+
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	if strings.Join(types, ",") != "error,retry,error,retry,message" {
+		t.Fatalf("events = %v, want each run's events with a retry after each failure", types)
+	}
+	events = []Event{events[1], events[3]}
+	first := events[0].Retry
+	if first == nil || first.Attempt != 1 || first.MaxAttempts != 4 || first.Class != ErrorClassRateLimit || first.Backoff != time.Millisecond {
+		t.Errorf("unexpected first retry: %+v", first)
+	}
+	if second := events[1].Retry; second == nil || second.Attempt != 2 || second.Backoff != 2*time.Millisecond {
+		t.Errorf("unexpected second retry: %+v", second)
+	}
+	if !strings.Contains(events[0].Content, "attempt 1/4 failed (rate_limit)") || !strings.Contains(events[0].Content, "retrying in 1ms") {
+		t.Errorf("unexpected retry content: %q", events[0].Content)
+	}
+	if len(hooked) != 2 {
+		t.Errorf("OnRetry called %d times, want 2", len(hooked))
+	}
+}
+
+// flakyBackend creates sessions from its flakySession.
+type flakyBackend struct {
+	MockBackend
+	session *flakySession
+}
+
+func (b *flakyBackend) CreateSession(context.Context, *task.Task, string) (Session, error) {
+	return b.session, nil
+}
+
+func TestRetryableBackend_RetriesSessionRuns(t *testing.T) {
+	inner := &flakySession{failures: 1, err: &RateLimitError{Message: "429", RetryAfter: time.Millisecond}}
+	budget := NewRetryBudget(5, time.Minute)
+	rb := NewRetryableBackend(&flakyBackend{session: inner}, RetryConfig{
+		MaxRetries:       2,
+		InitialBackoff:   time.Second,
+		MaxBackoff:       time.Minute,
+		BackoffFactor:    2.0,
+		FailureThreshold: 100,
+		ResetTimeout:     time.Second,
 	})
+	rb.SetBudget(budget)
+	var waits []time.Duration
+	rb.OnRateLimit(func(wait time.Duration, err error) { waits = append(waits, wait) })
 
-	elapsed := time.Since(start)
+	session, err := rb.CreateSession(context.Background(), task.New("t-001", "Test"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	streamDone := make(chan struct{})
+	go func() {
+		defer close(streamDone)
+		for event := range session.Events() {
+			types = append(types, event.Type)
+		}
+	}()
+	result, err := session.Run(context.Background(), "go")
+	if err != nil || !result.Success {
+		t.Fatalf("expected the rate limited run retried to success, got %+v, %v", result, err)
+	}
+	<-streamDone
 
-	// With initial backoff of 10ms and factor 2.0:
-	// Attempt 1: immediate
-	// Attempt 2: wait 10ms
-	// Attempt 3: wait 20ms
-	// Attempt 4: wait 40ms
-	// Total wait time: 10 + 20 + 40 = 70ms
-	expectedMin := 70 * time.Millisecond
+	if inner.runs != 2 || rb.Retries() != 1 {
+		t.Errorf("expected 2 runs and 1 retry, got %d runs, %d retries", inner.runs, rb.Retries())
+	}
+	if len(waits) != 1 || waits[0] != time.Millisecond {
+		t.Errorf("expected the provider's Retry-After honoured, got %v", waits)
+	}
+	if retries, waited := budget.Used(); retries != 1 || waited != time.Millisecond {
+		t.Errorf("expected the retry drawn from the budget, used %d, %s", retries, waited)
+	}
+	if strings.Join(types, ",") != "error,retry,message" {
+		t.Errorf("events = %v, want error,retry,message", types)
+	}
+}
 
-	if elapsed < expectedMin {
-		t.Errorf("elapsed time %v is less than expected minimum %v", elapsed, expectedMin)
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{ErrCircuitOpen, ErrorClassCircuitOpen},
+		{fmt.Errorf("run: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{errors.New("request timed out"), ErrorClassTimeout},
+		{errors.New("429 Too Many Requests"), ErrorClassRateLimit},
+		{errors.New("monthly quota exhausted"), ErrorClassRateLimit},
+		{&RateLimitError{Message: "slow down"}, ErrorClassRateLimit},
+		{errors.New("API Error: 529 overloaded_error"), ErrorClassServer},
+		{errors.New("502 Bad Gateway"), ErrorClassServer},
+		{errors.New("Invalid API key · Please run /login"), ErrorClassAuth},
+		{errors.New("HTTP 401 Unauthorized"), ErrorClassAuth},
+		{errors.New("400 invalid_request_error: prompt is too long"), ErrorClassInvalid},
+		{errors.New("exit status 1"), ErrorClassOther},
+	}
+	for _, tt := range tests {
+		if got := ErrorClass(tt.err); got != tt.want {
+			t.Errorf("ErrorClass(%v) = %s, want %s", tt.err, got, tt.want)
+		}
 	}
 }
 
@@ -278,3 +545,108 @@ func TestDefaultRetryConfig(t *testing.T) {
 		t.Error("ResetTimeout should be > 0")
 	}
 }
+
+func TestRetryNonRetryable(t *testing.T) {
+	tests := []struct {
+		err          error
+		wantAttempts int
+	}{
+		{errors.New("HTTP 401 Unauthorized"), 1},
+		{errors.New("400 Bad Request: context length exceeded"), 1},
+		{errors.New("503 Service Unavailable"), 4},
+		{errors.New("exit status 1"), 4},
+	}
+	for _, tt := range tests {
+		config := RetryConfig{
+			MaxRetries:       3,
+			InitialBackoff:   time.Millisecond,
+			MaxBackoff:       time.Millisecond,
+			FailureThreshold: 100,
+			ResetTimeout:     time.Second,
+		}
+		attempts := 0
+		err := retry(context.Background(), config, newCircuitBreaker(config), func() error {
+			attempts++
+			return tt.err
+		})
+		if attempts != tt.wantAttempts {
+			t.Errorf("%v: %d attempts, want %d", tt.err, attempts, tt.wantAttempts)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%v: expected the attempt's error, got %v", tt.err, err)
+		}
+	}
+
+	// A custom classifier overrides the default
+	config := RetryConfig{MaxRetries: 3, FailureThreshold: 100, Retryable: func(error) bool { return false }}
+	attempts := 0
+	retry(context.Background(), config, newCircuitBreaker(config), func() error {
+		attempts++
+		return errors.New("503 Service Unavailable")
+	})
+	if attempts != 1 {
+		t.Errorf("expected the custom classifier to stop retries, got %d attempts", attempts)
+	}
+}
+
+func TestRetryBudgetSharedAcrossBackends(t *testing.T) {
+	budget := NewRetryBudget(3, 0)
+	config := RetryConfig{
+		MaxRetries:       5,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       time.Millisecond,
+		FailureThreshold: 100,
+		ResetTimeout:     time.Second,
+		Budget:           budget,
+	}
+	flaky := errors.New("503 Service Unavailable")
+
+	// The first backend spends two of the three retries before succeeding
+	attempts := 0
+	err := retry(context.Background(), config, newCircuitBreaker(config), func() error {
+		attempts++
+		if attempts < 3 {
+			return flaky
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The second gets only the one left
+	attempts = 0
+	err = retry(context.Background(), config, newCircuitBreaker(config), func() error {
+		attempts++
+		return flaky
+	})
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+	if !errors.Is(err, ErrRetryBudgetExhausted) || !errors.Is(err, flaky) {
+		t.Errorf("expected a budget error wrapping the last error, got %v", err)
+	}
+	if retries, _ := budget.Used(); retries != 3 {
+		t.Errorf("expected 3 retries used, got %d", retries)
+	}
+}
+
+func TestRetryBudgetMaxTime(t *testing.T) {
+	budget := NewRetryBudget(0, 25*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if err := budget.Spend(10 * time.Millisecond); err != nil {
+			t.Fatalf("retry %d: unexpected error: %v", i+1, err)
+		}
+	}
+	if err := budget.Spend(10 * time.Millisecond); !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Errorf("expected the budget to be exhausted, got %v", err)
+	}
+	if retries, waited := budget.Used(); retries != 2 || waited != 20*time.Millisecond {
+		t.Errorf("expected 2 retries and 20ms used, got %d and %s", retries, waited)
+	}
+
+	var unlimited *RetryBudget
+	if err := unlimited.Spend(time.Hour); err != nil {
+		t.Errorf("expected a nil budget to be unlimited, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned when a retry would exceed the run's
+// retry budget.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget caps the retries of every backend in a run, so a flapping
+// backend can't stretch a run out many times over. It is safe for
+// concurrent use.
+type RetryBudget struct {
+	mu sync.Mutex
+	// maxRetries and maxWait are the caps; zero is unlimited.
+	maxRetries int
+	maxWait    time.Duration
+	retries    int
+	waited     time.Duration
+}
+
+// NewRetryBudget returns a budget of at most maxRetries retries and
+// maxWait spent waiting between them. Zero leaves a cap off.
+func NewRetryBudget(maxRetries int, maxWait time.Duration) *RetryBudget {
+	return &RetryBudget{maxRetries: maxRetries, maxWait: maxWait}
+}
+
+// Spend takes a retry that waits backoff from the budget, or fails with
+// ErrRetryBudgetExhausted if that would exceed it. A nil budget is
+// unlimited.
+func (b *RetryBudget) Spend(backoff time.Duration) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxRetries > 0 && b.retries+1 > b.maxRetries {
+		return fmt.Errorf("%w: %d of %d retries used", ErrRetryBudgetExhausted, b.retries, b.maxRetries)
+	}
+	if b.maxWait > 0 && b.waited+backoff > b.maxWait {
+		return fmt.Errorf("%w: waited %s of %s, next retry in %s",
+			ErrRetryBudgetExhausted, b.waited.Round(time.Millisecond), b.maxWait, backoff.Round(time.Millisecond))
+	}
+	b.retries++
+	b.waited += backoff
+	return nil
+}
+
+// Used returns the retries taken and time waited so far.
+func (b *RetryBudget) Used() (int, time.Duration) {
+	if b == nil {
+		return 0, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retries, b.waited
+}
@@ -0,0 +1,37 @@
+package agent
+
+import "sync"
+
+// eventStream is a session's event channel. Each Run sends on the current
+// channel and closes it when it returns, after putting a fresh one in its
+// place, so a session can run again, as retries do, without sending on a
+// closed channel.
+type eventStream struct {
+	mu sync.Mutex
+	ch chan Event
+}
+
+// Events returns the channel of the session's current Run, or of its next
+// one between runs.
+func (s *eventStream) Events() <-chan Event {
+	return s.current()
+}
+
+// current returns the channel a Run sends on.
+func (s *eventStream) current() chan Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan Event, 100)
+	}
+	return s.ch
+}
+
+// end closes events, the channel of a Run that has returned, once the next
+// Run has a channel of its own.
+func (s *eventStream) end(events chan Event) {
+	s.mu.Lock()
+	s.ch = make(chan Event, 100)
+	s.mu.Unlock()
+	close(events)
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+)
+
+// RunStatus is the state of a run started through the API.
+type RunStatus string
+
+// Run statuses.
+const (
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+	RunCancelled RunStatus = "cancelled"
+)
+
+// maxRunOutput is how much of a run's output, from the end, is kept.
+const maxRunOutput = 64 << 10
+
+// cancelGrace is how long a cancelled run has to exit after being
+// interrupted before it is killed.
+const cancelGrace = 10 * time.Second
+
+// Run is an agent run started through the API.
+type Run struct {
+	ID         string     `json:"id"`
+	TaskID     string     `json:"task_id"`
+	Status     RunStatus  `json:"status"`
+	ExitCode   *int       `json:"exit_code,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Output is the end of what the run printed; it is only set on a
+	// single run.
+	Output string `json:"output,omitempty"`
+}
+
+// Runs starts and tracks runs, each a process such as 'flo work <task>'.
+type Runs struct {
+	mu       sync.Mutex
+	command  func(taskID string) *exec.Cmd
+	runs     map[string]*process
+	next     int
+	onFinish func(Run)
+	grace    time.Duration
+}
+
+// process is a run's process and output.
+type process struct {
+	run       Run
+	cmd       *exec.Cmd
+	output    *tailBuffer
+	cancelled bool
+	done      chan struct{}
+}
+
+// NewRuns creates a run tracker starting the process command returns for a
+// task.
+func NewRuns(command func(taskID string) *exec.Cmd) *Runs {
+	return &Runs{
+		command: command,
+		runs:    make(map[string]*process),
+		next:    1,
+		grace:   cancelGrace,
+	}
+}
+
+// OnFinish sets a function called with each run once its process exits.
+func (r *Runs) OnFinish(fn func(Run)) {
+	r.onFinish = fn
+}
+
+// Start starts a run of a task. A task can only have one run at a time.
+func (r *Runs) Start(taskID string) (Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.runs {
+		if p.run.TaskID == taskID && p.run.Status == RunRunning {
+			return Run{}, Errorf(http.StatusConflict, "task %s already has run %s", taskID, p.run.ID)
+		}
+	}
+
+	p := &process{
+		run: Run{
+			ID:        fmt.Sprintf("r-%03d", r.next),
+			TaskID:    taskID,
+			Status:    RunRunning,
+			StartedAt: time.Now().UTC(),
+		},
+		cmd:    r.command(taskID),
+		output: &tailBuffer{max: maxRunOutput},
+		done:   make(chan struct{}),
+	}
+	p.cmd.Stdout = p.output
+	p.cmd.Stderr = p.output
+	// In its own process group, so cancelling reaches the agent it runs
+	p.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := p.cmd.Start(); err != nil {
+		return Run{}, fmt.Errorf("failed to start run: %w", err)
+	}
+	r.next++
+	r.runs[p.run.ID] = p
+	go r.wait(p)
+
+	audit.Info("api.run_start", "Run started", map[string]interface{}{
+		"run_id":  p.run.ID,
+		"task_id": taskID,
+		"pid":     p.cmd.Process.Pid,
+	})
+	return p.run, nil
+}
+
+// wait records the run's outcome when its process exits.
+func (r *Runs) wait(p *process) {
+	err := p.cmd.Wait()
+
+	r.mu.Lock()
+	code := p.cmd.ProcessState.ExitCode()
+	finished := time.Now().UTC()
+	p.run.ExitCode = &code
+	p.run.FinishedAt = &finished
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		p.output.Write([]byte(err.Error()))
+	}
+	switch {
+	case p.cancelled:
+		p.run.Status = RunCancelled
+	case err == nil:
+		p.run.Status = RunSucceeded
+	default:
+		p.run.Status = RunFailed
+	}
+	run := p.run
+	onFinish := r.onFinish
+	r.mu.Unlock()
+
+	if onFinish != nil {
+		onFinish(run)
+	}
+	close(p.done)
+}
+
+// Get returns a run with its output.
+func (r *Runs) Get(id string) (Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.runs[id]
+	if !ok {
+		return Run{}, Errorf(http.StatusNotFound, "run '%s' not found", id)
+	}
+	run := p.run
+	run.Output = p.output.String()
+	return run, nil
+}
+
+// List returns every run, oldest first, without output.
+func (r *Runs) List() []Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := make([]Run, 0, len(r.runs))
+	for _, p := range r.runs {
+		runs = append(runs, p.run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].StartedAt.Equal(runs[j].StartedAt) {
+			return runs[i].StartedAt.Before(runs[j].StartedAt)
+		}
+		return runs[i].ID < runs[j].ID
+	})
+	return runs
+}
+
+// Cancel interrupts a running run, killing it if it hasn't exited after a
+// grace period.
+func (r *Runs) Cancel(id string) (Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.runs[id]
+	if !ok {
+		return Run{}, Errorf(http.StatusNotFound, "run '%s' not found", id)
+	}
+	if p.run.Status != RunRunning {
+		return Run{}, Errorf(http.StatusConflict, "run %s is %s", id, p.run.Status)
+	}
+	if p.cancelled {
+		return p.run, nil
+	}
+	p.cancelled = true
+	pgid := p.cmd.Process.Pid
+	if err := syscall.Kill(-pgid, syscall.SIGINT); err != nil {
+		syscall.Kill(-pgid, syscall.SIGKILL)
+	}
+	go func() {
+		select {
+		case <-p.done:
+		case <-time.After(r.grace):
+			syscall.Kill(-pgid, syscall.SIGKILL)
+		}
+	}()
+
+	audit.Info("api.run_cancel", "Run cancelled", map[string]interface{}{
+		"run_id":  id,
+		"task_id": p.run.TaskID,
+	})
+	return p.run, nil
+}
+
+// Wait blocks until a run's process has exited.
+func (r *Runs) Wait(id string) {
+	r.mu.Lock()
+	p, ok := r.runs[id]
+	r.mu.Unlock()
+	if ok {
+		<-p.done
+	}
+}
+
+// CancelAll cancels every running run and waits for them to exit, as when
+// the server shuts down.
+func (r *Runs) CancelAll() {
+	for _, run := range r.List() {
+		if run.Status == RunRunning {
+			r.Cancel(run.ID)
+			r.Wait(run.ID)
+		}
+	}
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu   sync.Mutex
+	max  int
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > b.max {
+		b.data = b.data[len(b.data)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
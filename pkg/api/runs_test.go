@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shellRuns runs the shell script scripts maps a task to.
+func shellRuns(scripts map[string]string) *Runs {
+	return NewRuns(func(taskID string) *exec.Cmd {
+		return exec.Command("sh", "-c", scripts[taskID])
+	})
+}
+
+func TestRunsOutcome(t *testing.T) {
+	runs := shellRuns(map[string]string{
+		"t-001": "echo working; echo done",
+		"t-002": "echo broken >&2; exit 3",
+	})
+	var finished []Run
+	runs.OnFinish(func(run Run) { finished = append(finished, run) })
+
+	ok, err := runs.Start("t-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok.Status != RunRunning || ok.ID != "r-001" {
+		t.Errorf("unexpected new run: %+v", ok)
+	}
+	runs.Wait(ok.ID)
+	if got, _ := runs.Get(ok.ID); got.Status != RunSucceeded || *got.ExitCode != 0 || got.Output != "working\ndone\n" {
+		t.Errorf("unexpected finished run: %+v", got)
+	}
+
+	bad, _ := runs.Start("t-002")
+	runs.Wait(bad.ID)
+	if got, _ := runs.Get(bad.ID); got.Status != RunFailed || *got.ExitCode != 3 || got.Output != "broken\n" {
+		t.Errorf("unexpected failed run: %+v", got)
+	}
+
+	list := runs.List()
+	if len(list) != 2 || list[0].ID != "r-001" || list[1].Output != "" {
+		t.Errorf("unexpected run list: %+v", list)
+	}
+	if len(finished) != 2 {
+		t.Errorf("expected OnFinish for both runs, got %d", len(finished))
+	}
+
+	var apiErr *Error
+	if _, err := runs.Get("r-404"); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %v", err)
+	}
+}
+
+func TestRunsCancel(t *testing.T) {
+	runs := shellRuns(map[string]string{"t-001": "echo started; sleep 30"})
+
+	run, err := runs.Start("t-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *Error
+	if _, err := runs.Start("t-001"); !errors.As(err, &apiErr) || apiErr.Status != http.StatusConflict {
+		t.Errorf("expected a second run of the task to conflict, got %v", err)
+	}
+
+	waitForOutput(runs, run.ID)
+	if _, err := runs.Cancel(run.ID); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() { runs.Wait(run.ID); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled run didn't exit")
+	}
+	got, _ := runs.Get(run.ID)
+	if got.Status != RunCancelled || !strings.HasPrefix(got.Output, "started") {
+		t.Errorf("unexpected cancelled run: %+v", got)
+	}
+	if _, err := runs.Cancel(run.ID); !errors.As(err, &apiErr) || apiErr.Status != http.StatusConflict {
+		t.Errorf("expected cancelling a finished run to conflict, got %v", err)
+	}
+
+	// The task can run again
+	again, err := runs.Start("t-001")
+	if err != nil {
+		t.Fatalf("expected a new run after cancelling: %v", err)
+	}
+	waitForOutput(runs, again.ID)
+	runs.CancelAll()
+	for _, r := range runs.List() {
+		if r.Status == RunRunning {
+			t.Errorf("run %s still running after CancelAll", r.ID)
+		}
+	}
+}
+
+// waitForOutput waits for a run to print, so it is under way when cancelled.
+func waitForOutput(runs *Runs, id string) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if got, _ := runs.Get(id); got.Output != "" {
+			return
+		}
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{max: 5}
+	b.Write([]byte("abc"))
+	b.Write([]byte("defg"))
+	if got := b.String(); got != "cdefg" {
+		t.Errorf("got %q, want the last 5 bytes", got)
+	}
+}
@@ -0,0 +1,239 @@
+// Package api serves a workspace over a local REST/JSON API, so dashboards
+// and editor plugins can read tasks and start runs without shelling out to
+// flo. Routes are registered by the caller; the package handles
+// authentication, JSON encoding, errors and the runs started through it.
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// maxRequestBody bounds the size of a request body.
+const maxRequestBody = 1 << 20
+
+// streamKeepalive is how often an idle event stream gets a comment, so
+// proxies don't close it.
+const streamKeepalive = 15 * time.Second
+
+// HandlerFunc handles a request, returning the HTTP status and the value
+// encoded as the JSON response, or an error.
+type HandlerFunc func(r *http.Request) (int, interface{}, error)
+
+// Error is an error with the HTTP status it is reported with.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Errorf returns an Error with the given status.
+func Errorf(status int, format string, args ...interface{}) *Error {
+	return &Error{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// errorBody is the JSON response for an error.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// Server routes API requests to their handlers. Handlers run one at a time,
+// as the workspace they read isn't safe for concurrent use.
+type Server struct {
+	mux     *http.ServeMux
+	mu      sync.Mutex
+	token   string
+	refresh func() error
+	// public holds the patterns served without the auth token.
+	public map[string]bool
+}
+
+// NewServer creates a server without routes.
+func NewServer() *Server {
+	return &Server{mux: http.NewServeMux(), public: make(map[string]bool)}
+}
+
+// SetAuthToken requires clients to send "Authorization: Bearer <token>", or
+// an access_token query parameter where headers can't be set (as for
+// EventSource), on every request. An empty token disables authentication.
+func (s *Server) SetAuthToken(token string) {
+	s.token = token
+}
+
+// SetRefresh sets a function called before each handler, such as reloading
+// the workspace so changes made by other flo processes are seen.
+func (s *Server) SetRefresh(fn func() error) {
+	s.refresh = fn
+}
+
+// Handle registers h for a pattern such as "GET /v1/tasks/{id}".
+func (s *Server) Handle(pattern string, h HandlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		var status int
+		var body interface{}
+		err := s.Do(func() (err error) {
+			status, body, err = h(r)
+			return err
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, status, body)
+	})
+}
+
+// SendFunc sends a server-sent event named event with data encoded as JSON.
+type SendFunc func(event string, data interface{}) error
+
+// StreamFunc streams server-sent events to a client with send until the
+// request's context is done.
+type StreamFunc func(r *http.Request, send SendFunc) error
+
+// HandleStream registers h to stream server-sent events for a pattern.
+// Streams run alongside handlers and each other, and get a keepalive
+// comment when idle.
+func (s *Server) HandleStream(pattern string, h StreamFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, Errorf(http.StatusInternalServerError, "streaming unsupported"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		var mu sync.Mutex
+		write := func(text string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, err := io.WriteString(w, text); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(streamKeepalive)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					write(": keepalive\n\n")
+				}
+			}
+		}()
+
+		h(r, func(event string, data interface{}) error {
+			encoded, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+			return write(fmt.Sprintf("event: %s\ndata: %s\n\n", event, encoded))
+		})
+	})
+}
+
+// HandlePublic registers h for a pattern served without the auth token,
+// such as static assets that hold no workspace data.
+func (s *Server) HandlePublic(pattern string, h http.Handler) {
+	s.public[pattern] = true
+	s.mux.Handle(pattern, h)
+}
+
+// Do runs fn as a handler would: alone, after refreshing. It is for work
+// done outside a request, such as when a run finishes.
+func (s *Server) Do(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refresh != nil {
+		if err := s.refresh(); err != nil {
+			return err
+		}
+	}
+	return fn()
+}
+
+// Handler returns the server's HTTP handler. Requests without the auth
+// token, when one is set, get 401.
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := s.mux.Handler(r); !s.public[pattern] && !s.authorized(r) {
+			audit.Warn("api.auth", "Rejected unauthenticated API request", map[string]interface{}{
+				"remote": r.RemoteAddr,
+				"method": r.Method,
+				"path":   r.URL.Path,
+			})
+			w.Header().Set("WWW-Authenticate", `Bearer realm="flo"`)
+			writeJSON(w, http.StatusUnauthorized, errorBody{Error: "unauthorized"})
+			return
+		}
+		s.mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token, ok = r.URL.Query().Get("access_token"), r.URL.Query().Has("access_token")
+	}
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// Decode reads a request's JSON body into v, rejecting unknown fields.
+func Decode(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return Errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// writeError reports err with its status: an Error's own, 404 for an
+// unknown task, 409 for a status change the task can't make or a manifest
+// saved meanwhile, else 500.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var apiErr *Error
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.Status
+	case errors.Is(err, task.ErrTaskNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, task.ErrInvalidTransition), errors.Is(err, task.ErrVersionConflict):
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if status == http.StatusNoContent {
+		return
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+func newTestServer() *Server {
+	s := NewServer()
+	s.Handle("GET /v1/tasks/{id}", func(r *http.Request) (int, interface{}, error) {
+		if id := r.PathValue("id"); id != "t-001" {
+			return 0, nil, fmt.Errorf("task '%s' %w", id, task.ErrTaskNotFound)
+		}
+		return http.StatusOK, map[string]string{"id": "t-001"}, nil
+	})
+	s.Handle("POST /v1/tasks/{id}/complete", func(r *http.Request) (int, interface{}, error) {
+		return 0, nil, &task.TransitionError{TaskID: r.PathValue("id"), From: task.StatusPending, To: task.StatusComplete}
+	})
+	s.Handle("POST /v1/tasks", func(r *http.Request) (int, interface{}, error) {
+		var req struct {
+			Title string `json:"title"`
+		}
+		if err := Decode(r, &req); err != nil {
+			return 0, nil, err
+		}
+		if req.Title == "" {
+			return 0, nil, Errorf(http.StatusUnprocessableEntity, "title is required")
+		}
+		return http.StatusCreated, req, nil
+	})
+	return s
+}
+
+func do(t *testing.T, h http.Handler, method, path, body, token string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%s %s: content type %q", method, path, ct)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("%s %s: invalid JSON %q", method, path, rec.Body.String())
+	}
+	return rec.Code, got
+}
+
+func TestServerRoutes(t *testing.T) {
+	h := newTestServer().Handler()
+
+	if code, got := do(t, h, "GET", "/v1/tasks/t-001", "", ""); code != http.StatusOK || got["id"] != "t-001" {
+		t.Errorf("GET task: %d %v", code, got)
+	}
+	if code, got := do(t, h, "GET", "/v1/tasks/t-404", "", ""); code != http.StatusNotFound || got["error"] != "task 't-404' not found" {
+		t.Errorf("GET missing task: %d %v", code, got)
+	}
+	if code, got := do(t, h, "POST", "/v1/tasks/t-001/complete", "", ""); code != http.StatusConflict || got["error"] != "invalid status transition: pending -> complete" {
+		t.Errorf("POST invalid transition: %d %v", code, got)
+	}
+	if code, got := do(t, h, "POST", "/v1/tasks", `{"title":"Login"}`, ""); code != http.StatusCreated || got["title"] != "Login" {
+		t.Errorf("POST task: %d %v", code, got)
+	}
+	if code, _ := do(t, h, "POST", "/v1/tasks", `{"title":""}`, ""); code != http.StatusUnprocessableEntity {
+		t.Errorf("POST without title: %d", code)
+	}
+	if code, _ := do(t, h, "POST", "/v1/tasks", `{"name":"x"}`, ""); code != http.StatusBadRequest {
+		t.Errorf("POST with an unknown field: %d", code)
+	}
+}
+
+func TestServerAuth(t *testing.T) {
+	s := newTestServer()
+	s.SetAuthToken("secret")
+	h := s.Handler()
+
+	if code, _ := do(t, h, "GET", "/v1/tasks/t-001", "", ""); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", code)
+	}
+	if code, _ := do(t, h, "GET", "/v1/tasks/t-001", "", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with the wrong token, got %d", code)
+	}
+	if code, _ := do(t, h, "GET", "/v1/tasks/t-001", "", "secret"); code != http.StatusOK {
+		t.Errorf("expected 200 with the token, got %d", code)
+	}
+	if code, _ := do(t, h, "GET", "/v1/tasks/t-001?access_token=secret", "", ""); code != http.StatusOK {
+		t.Errorf("expected 200 with the token as a query parameter, got %d", code)
+	}
+
+	s.HandlePublic("GET /", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"page":"index"}`))
+	}))
+	if code, got := do(t, h, "GET", "/", "", ""); code != http.StatusOK || got["page"] != "index" {
+		t.Errorf("expected a public route without the token, got %d %v", code, got)
+	}
+	if code, _ := do(t, h, "GET", "/v1/tasks/t-001", "", ""); code != http.StatusUnauthorized {
+		t.Errorf("expected other routes to still need the token, got %d", code)
+	}
+}
+
+func TestServerStream(t *testing.T) {
+	s := NewServer()
+	s.HandleStream("GET /v1/events", func(r *http.Request, send SendFunc) error {
+		send("task", map[string]string{"id": "t-001"})
+		send("task", map[string]string{"id": "t-002"})
+		<-r.Context().Done()
+		return nil
+	})
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type %q", ct)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for len(lines) < 6 && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	want := []string{"event: task", `data: {"id":"t-001"}`, "", "event: task", `data: {"id":"t-002"}`, ""}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+}
+
+func TestServerRefresh(t *testing.T) {
+	s := newTestServer()
+	refreshed := 0
+	s.SetRefresh(func() error {
+		refreshed++
+		if refreshed > 1 {
+			return fmt.Errorf("workspace gone")
+		}
+		return nil
+	})
+	h := s.Handler()
+
+	if code, _ := do(t, h, "GET", "/v1/tasks/t-001", "", ""); code != http.StatusOK {
+		t.Errorf("expected 200, got %d", code)
+	}
+	if code, got := do(t, h, "GET", "/v1/tasks/t-001", "", ""); code != http.StatusInternalServerError || got["error"] != "workspace gone" {
+		t.Errorf("expected a failed refresh to be reported, got %d %v", code, got)
+	}
+}
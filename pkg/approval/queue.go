@@ -0,0 +1,270 @@
+// Package approval manages the queue of human approval and review requests.
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/events"
+)
+
+// Status represents the state of an approval request.
+type Status string
+
+const (
+	StatusPending          Status = "pending"
+	StatusApproved         Status = "approved"
+	StatusRejected         Status = "rejected"
+	StatusChangesRequested Status = "changes_requested"
+)
+
+// GateResult summarizes a completion gate shown alongside a request.
+type GateResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Request is a pending decision for a human reviewer.
+type Request struct {
+	ID        string       `json:"id"`
+	TaskID    string       `json:"task_id"`
+	Kind      string       `json:"kind"` // e.g. "review", "completion"
+	Summary   string       `json:"summary"`
+	Diff      string       `json:"diff,omitempty"`
+	Gates     []GateResult `json:"gates,omitempty"`
+	Cost      float64      `json:"cost,omitempty"`
+	Status    Status       `json:"status"`
+	Feedback  string       `json:"feedback,omitempty"`
+	DecidedBy string       `json:"decided_by,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	DecidedAt time.Time    `json:"decided_at,omitempty"`
+}
+
+// queueData is the JSON structure for persistence.
+type queueData struct {
+	NextID   int        `json:"next_id"`
+	Requests []*Request `json:"requests"`
+}
+
+// Queue stores approval requests in a JSON file.
+type Queue struct {
+	mu    sync.Mutex
+	path  string
+	onAdd func(*Request)
+	// log receives the queue's audit events; nil uses the default
+	// logger.
+	log *audit.Logger
+}
+
+// NewQueue creates a queue backed by the given file.
+func NewQueue(path string) *Queue {
+	return &Queue{path: path}
+}
+
+// SetAuditLogger sends the queue's audit events to l, such as the log of
+// the workspace it belongs to.
+func (q *Queue) SetAuditLogger(l *audit.Logger) {
+	q.log = l
+}
+
+// OnAdd registers a callback invoked after a request is enqueued.
+func (q *Queue) OnAdd(fn func(*Request)) {
+	q.onAdd = fn
+}
+
+// Add enqueues a new pending request and assigns its ID.
+func (q *Queue) Add(req *Request) error {
+	err := q.update(func(data *queueData) error {
+		data.NextID++
+		req.ID = fmt.Sprintf("a-%03d", data.NextID)
+		req.Status = StatusPending
+		if req.CreatedAt.IsZero() {
+			req.CreatedAt = time.Now().UTC()
+		}
+		data.Requests = append(data.Requests, req)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Published once saved, so subscribers can read the queue
+	events.PublishTo(q.log, audit.LevelInfo, "Approval requested", audit.ApprovalRequested{
+		ApprovalID: req.ID,
+		TaskID:     req.TaskID,
+		Kind:       req.Kind,
+		Summary:    req.Summary,
+	})
+	if q.onAdd != nil {
+		q.onAdd(req)
+	}
+	return nil
+}
+
+// Get returns a request by ID.
+func (q *Queue) Get(id string) (*Request, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data, err := q.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range data.Requests {
+		if req.ID == id {
+			return req, nil
+		}
+	}
+	return nil, fmt.Errorf("approval '%s' not found", id)
+}
+
+// List returns requests with the given status (all when status is empty),
+// oldest first.
+func (q *Queue) List(status Status) ([]*Request, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data, err := q.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Request
+	for _, req := range data.Requests {
+		if status == "" || req.Status == status {
+			result = append(result, req)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// Pending returns pending requests, oldest first.
+func (q *Queue) Pending() ([]*Request, error) {
+	return q.List(StatusPending)
+}
+
+// LatestForTask returns the most recent request for a task.
+func (q *Queue) LatestForTask(taskID string) (*Request, error) {
+	all, err := q.List("")
+	if err != nil {
+		return nil, err
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i].TaskID == taskID {
+			return all[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no approval requests for task '%s'", taskID)
+}
+
+// Approve marks a pending request as approved.
+func (q *Queue) Approve(id, by string) error {
+	return q.decide(id, StatusApproved, by, "")
+}
+
+// Reject marks a pending request as rejected with an optional reason.
+func (q *Queue) Reject(id, by, reason string) error {
+	return q.decide(id, StatusRejected, by, reason)
+}
+
+// RequestChanges sends a request back with reviewer feedback.
+func (q *Queue) RequestChanges(id, by, feedback string) error {
+	if feedback == "" {
+		return fmt.Errorf("feedback is required when requesting changes")
+	}
+	return q.decide(id, StatusChangesRequested, by, feedback)
+}
+
+func (q *Queue) decide(id string, status Status, by, feedback string) error {
+	var decided audit.ApprovalDecided
+	err := q.update(func(data *queueData) error {
+		for _, req := range data.Requests {
+			if req.ID != id {
+				continue
+			}
+			if req.Status != StatusPending {
+				return fmt.Errorf("approval '%s' is already %s", id, req.Status)
+			}
+			req.Status = status
+			req.Feedback = feedback
+			req.DecidedBy = by
+			req.DecidedAt = time.Now().UTC()
+
+			decided = audit.ApprovalDecided{
+				ApprovalID: id,
+				TaskID:     req.TaskID,
+				Status:     string(status),
+				DecidedBy:  by,
+			}
+			return nil
+		}
+		return fmt.Errorf("approval '%s' not found", id)
+	})
+	if err != nil {
+		return err
+	}
+	events.PublishTo(q.log, audit.LevelInfo, "Approval decided", decided)
+	return nil
+}
+
+// update loads the queue, applies fn, and saves it atomically.
+func (q *Queue) update(fn func(data *queueData) error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data, err := q.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(data); err != nil {
+		return err
+	}
+	return q.save(data)
+}
+
+// load reads the queue file (must be called with lock held).
+func (q *Queue) load() (*queueData, error) {
+	data := &queueData{}
+	raw, err := os.ReadFile(q.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("failed to read approval queue: %w", err)
+	}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, fmt.Errorf("failed to parse approval queue: %w", err)
+	}
+	return data, nil
+}
+
+// save writes the queue file via a temp file and rename (must be called with lock held).
+func (q *Queue) save(data *queueData) error {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize approval queue: %w", err)
+	}
+
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write approval queue: %w", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("failed to replace approval queue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package approval
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestQueue(t *testing.T) *Queue {
+	return NewQueue(filepath.Join(t.TempDir(), "approvals.json"))
+}
+
+func TestQueueAddAndPending(t *testing.T) {
+	q := newTestQueue(t)
+
+	req := &Request{TaskID: "t-001", Kind: "review", Summary: "Add OAuth", Diff: "+code"}
+	if err := q.Add(req); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if req.ID != "a-001" {
+		t.Errorf("expected ID a-001, got %s", req.ID)
+	}
+
+	q.Add(&Request{TaskID: "t-002", Kind: "review", CreatedAt: time.Now().Add(time.Minute)})
+
+	pending, err := q.Pending()
+	if err != nil {
+		t.Fatalf("Pending failed: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending, got %d", len(pending))
+	}
+	if pending[0].TaskID != "t-001" {
+		t.Errorf("expected oldest first, got %s", pending[0].TaskID)
+	}
+}
+
+func TestQueueDecisions(t *testing.T) {
+	q := newTestQueue(t)
+	a := &Request{TaskID: "t-001", Kind: "review"}
+	b := &Request{TaskID: "t-002", Kind: "review"}
+	c := &Request{TaskID: "t-003", Kind: "review"}
+	q.Add(a)
+	q.Add(b)
+	q.Add(c)
+
+	if err := q.Approve(a.ID, "alice"); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if err := q.Reject(b.ID, "alice", "wrong approach"); err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+	if err := q.RequestChanges(c.ID, "alice", ""); err == nil {
+		t.Error("expected error requesting changes without feedback")
+	}
+	if err := q.RequestChanges(c.ID, "alice", "add tests"); err != nil {
+		t.Fatalf("RequestChanges failed: %v", err)
+	}
+
+	got, _ := q.Get(a.ID)
+	if got.Status != StatusApproved || got.DecidedBy != "alice" {
+		t.Errorf("unexpected approved request: %+v", got)
+	}
+	got, _ = q.Get(c.ID)
+	if got.Status != StatusChangesRequested || got.Feedback != "add tests" {
+		t.Errorf("unexpected changes-requested request: %+v", got)
+	}
+
+	if err := q.Approve(a.ID, "bob"); err == nil {
+		t.Error("expected error deciding an already decided request")
+	}
+
+	pending, _ := q.Pending()
+	if len(pending) != 0 {
+		t.Errorf("expected no pending requests, got %d", len(pending))
+	}
+}
+
+func TestQueuePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.json")
+	NewQueue(path).Add(&Request{TaskID: "t-001", Kind: "review"})
+
+	req, err := NewQueue(path).LatestForTask("t-001")
+	if err != nil {
+		t.Fatalf("LatestForTask failed: %v", err)
+	}
+	if req.Status != StatusPending {
+		t.Errorf("expected pending, got %s", req.Status)
+	}
+
+	if _, err := NewQueue(path).Get("a-999"); err == nil {
+		t.Error("expected error for unknown approval")
+	}
+}
+
+func TestQueueOnAdd(t *testing.T) {
+	q := newTestQueue(t)
+	var added []string
+	q.OnAdd(func(req *Request) { added = append(added, req.ID) })
+
+	q.Add(&Request{TaskID: "t-001", Kind: "review"})
+	if len(added) != 1 || added[0] != "a-001" {
+		t.Errorf("expected callback for a-001, got %v", added)
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/richgo/flo/pkg/correlation"
+	"github.com/richgo/flo/pkg/redact"
 )
 
 // Level represents the severity level of an audit event.
@@ -26,86 +30,280 @@ type Event struct {
 	Operation string                 `json:"operation"`
 	Message   string                 `json:"message"`
 	Details   map[string]interface{} `json:"details,omitempty"`
+	// RunID and ExecutionID tie the event to an agent run and a task
+	// attempt within it.
+	RunID       string `json:"run_id,omitempty"`
+	ExecutionID string `json:"execution_id,omitempty"`
+	// Seq, PrevHash and Hash chain the events of a tamper-evident log; Hash
+	// covers the rest of the event, including the previous event's hash.
+	Seq      int64  `json:"seq,omitempty"`
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
 }
 
-// Logger handles audit event logging.
+// Logger writes the audit log of one workspace. A nil Logger discards
+// events.
 type Logger struct {
 	mu       sync.Mutex
 	filePath string
 	file     *os.File
+	ids      correlation.IDs
+
+	// chained logs hash-chain events, with checkpoints signed with
+	// chainKey; pending counts events since the last checkpoint.
+	chained  bool
+	chainKey []byte
+	pending  int
 }
 
 var (
+	// defaultLogger receives events logged with the package-level
+	// functions.
+	defaultMu     sync.RWMutex
 	defaultLogger *Logger
-	once          sync.Once
+	// initLogger is the logger Init opened, which it owns.
+	initLogger *Logger
+
+	redactorMu sync.RWMutex
+	redactor   *redact.Redactor
+
+	subscribersMu  sync.RWMutex
+	subscribers    = make(map[int]func(Event))
+	nextSubscriber int
 )
 
-// Init initializes the global audit logger with the given workspace root.
-// It creates the audit log file at .flo/audit.log.
-func Init(workspaceRoot string) error {
-	var err error
-	once.Do(func() {
-		auditPath := filepath.Join(workspaceRoot, ".flo", "audit.log")
-		
-		// Ensure directory exists
-		dir := filepath.Dir(auditPath)
-		if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
-			err = fmt.Errorf("failed to create audit directory: %w", mkdirErr)
-			return
-		}
-		
-		// Open file in append mode
-		file, openErr := os.OpenFile(auditPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if openErr != nil {
-			err = fmt.Errorf("failed to open audit log: %w", openErr)
-			return
-		}
-		
-		defaultLogger = &Logger{
-			filePath: auditPath,
-			file:     file,
-		}
-	})
-	return err
+// NewLogger opens the audit log of a workspace, .flo/audit.log, for
+// appending.
+func NewLogger(workspaceRoot string) (*Logger, error) {
+	auditPath := Path(workspaceRoot)
+	if err := os.MkdirAll(filepath.Dir(auditPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	file, err := os.OpenFile(auditPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	// A process started by a run, such as the MCP server, logs under its IDs
+	return &Logger{filePath: auditPath, file: file, ids: correlation.FromEnv()}, nil
 }
 
-// Close closes the audit logger.
-func Close() error {
-	if defaultLogger != nil && defaultLogger.file != nil {
-		return defaultLogger.file.Close()
+// SetCorrelation stamps events logged from now on with ids, such as those
+// of the task attempt in progress.
+func (l *Logger) SetCorrelation(ids correlation.IDs) {
+	if l == nil {
+		return
 	}
-	return nil
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ids = ids
 }
 
-// Log writes an audit event to the log file.
-func Log(level Level, operation, message string, details map[string]interface{}) {
-	if defaultLogger == nil {
-		// If not initialized, skip logging silently
+// Correlation returns the IDs events are stamped with.
+func (l *Logger) Correlation() correlation.IDs {
+	if l == nil {
+		return correlation.IDs{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ids
+}
+
+// Path returns the file the logger writes.
+func (l *Logger) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.filePath
+}
+
+// Close closes the log file; later events are discarded.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	l.closeChainLocked()
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Log writes an audit event to the log file and sends it to subscribers.
+func (l *Logger) Log(level Level, operation, message string, details map[string]interface{}) {
+	if l == nil {
 		return
 	}
-	
+
+	redactorMu.RLock()
+	r := redactor
+	redactorMu.RUnlock()
+
+	l.mu.Lock()
+	ids := l.ids
+	l.mu.Unlock()
+
 	event := Event{
-		Timestamp: time.Now(),
-		Level:     level,
-		Operation: operation,
-		Message:   message,
-		Details:   details,
+		Timestamp:   time.Now().UTC(),
+		Level:       level,
+		Operation:   operation,
+		Message:     r.String(message),
+		Details:     redactDetails(r, details),
+		RunID:       ids.RunID,
+		ExecutionID: ids.ExecutionID,
 	}
-	
-	defaultLogger.writeEvent(event)
+
+	l.writeEvent(event)
+	notify(event)
 }
 
 // Info logs an informational audit event.
+func (l *Logger) Info(operation, message string, details map[string]interface{}) {
+	l.Log(LevelInfo, operation, message, details)
+}
+
+// Warn logs a warning audit event.
+func (l *Logger) Warn(operation, message string, details map[string]interface{}) {
+	l.Log(LevelWarn, operation, message, details)
+}
+
+// Error logs an error audit event.
+func (l *Logger) Error(operation, message string, details map[string]interface{}) {
+	l.Log(LevelError, operation, message, details)
+}
+
+// SetDefault makes l the logger of the package-level functions, such as
+// for the workspace a command works on. nil discards their events.
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+// Default returns the logger of the package-level functions, or nil.
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+// Init opens the audit log at .flo/audit.log of the given workspace root
+// and makes it the default, unless the default already writes there. A
+// log previously opened by Init is closed.
+func Init(workspaceRoot string) error {
+	if Default().Path() == Path(workspaceRoot) {
+		return nil
+	}
+	l, err := NewLogger(workspaceRoot)
+	if err != nil {
+		return err
+	}
+	defaultMu.Lock()
+	previous := initLogger
+	defaultLogger, initLogger = l, l
+	defaultMu.Unlock()
+	previous.Close()
+	return nil
+}
+
+// Close closes the default logger.
+func Close() error {
+	defaultMu.Lock()
+	l := defaultLogger
+	defaultLogger = nil
+	defaultMu.Unlock()
+	return l.Close()
+}
+
+// Log writes an audit event with the default logger. Events are discarded
+// when there is none.
+func Log(level Level, operation, message string, details map[string]interface{}) {
+	Default().Log(level, operation, message, details)
+}
+
+// SetRedactor scrubs secrets from the message and details of every event
+// logged from now on, before it is written or sent to subscribers.
+func SetRedactor(r *redact.Redactor) {
+	redactorMu.Lock()
+	defer redactorMu.Unlock()
+	redactor = r
+}
+
+// redactDetails returns a copy of details with secrets scrubbed from its
+// strings, errors and lists of strings, including in nested details.
+func redactDetails(r *redact.Redactor, details map[string]interface{}) map[string]interface{} {
+	if r == nil || details == nil {
+		return details
+	}
+	out := make(map[string]interface{}, len(details))
+	for key, value := range details {
+		switch v := value.(type) {
+		case string:
+			out[key] = r.String(v)
+		case error:
+			out[key] = r.String(v.Error())
+		case []string:
+			list := make([]string, len(v))
+			for i, s := range v {
+				list[i] = r.String(s)
+			}
+			out[key] = list
+		case []interface{}:
+			list := make([]interface{}, len(v))
+			for i, item := range v {
+				if s, ok := item.(string); ok {
+					item = r.String(s)
+				}
+				list[i] = item
+			}
+			out[key] = list
+		case map[string]interface{}:
+			out[key] = redactDetails(r, v)
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// Subscribe calls fn with every event logged from now on, such as to
+// forward them to an MCP client. It returns a function that removes the
+// subscription. fn must not log audit events itself.
+func Subscribe(fn func(Event)) (unsubscribe func()) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	id := nextSubscriber
+	nextSubscriber++
+	subscribers[id] = fn
+	return func() {
+		subscribersMu.Lock()
+		defer subscribersMu.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+func notify(event Event) {
+	subscribersMu.RLock()
+	defer subscribersMu.RUnlock()
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
+
+// Info logs an informational audit event with the default logger.
 func Info(operation, message string, details map[string]interface{}) {
 	Log(LevelInfo, operation, message, details)
 }
 
-// Warn logs a warning audit event.
+// Warn logs a warning audit event with the default logger.
 func Warn(operation, message string, details map[string]interface{}) {
 	Log(LevelWarn, operation, message, details)
 }
 
-// Error logs an error audit event.
+// Error logs an error audit event with the default logger.
 func Error(operation, message string, details map[string]interface{}) {
 	Log(LevelError, operation, message, details)
 }
@@ -117,6 +315,10 @@ func (l *Logger) writeEvent(event Event) {
 	if l.file == nil {
 		return
 	}
+	if l.chained {
+		l.writeChainedLocked(event)
+		return
+	}
 	
 	data, err := json.Marshal(event)
 	if err != nil {
@@ -128,3 +330,40 @@ func (l *Logger) writeEvent(event Event) {
 	l.file.Write(data)
 	l.file.Write([]byte("\n"))
 }
+
+// ParseEvent decodes one line of the audit log.
+func ParseEvent(line []byte) (Event, error) {
+	var event Event
+	if err := json.Unmarshal(line, &event); err != nil {
+		return Event{}, fmt.Errorf("invalid audit event: %w", err)
+	}
+	if event.Operation == "" || event.Timestamp.IsZero() {
+		return Event{}, fmt.Errorf("invalid audit event: missing timestamp or operation")
+	}
+	return event, nil
+}
+
+// Path returns the audit log path for a workspace root.
+func Path(workspaceRoot string) string {
+	return filepath.Join(workspaceRoot, ".flo", "audit.log")
+}
+
+// Tail returns the last n lines of the workspace audit log, oldest first.
+// A missing log yields no lines.
+func Tail(workspaceRoot string, n int) ([]string, error) {
+	data, err := os.ReadFile(Path(workspaceRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+	if n >= 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
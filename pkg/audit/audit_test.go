@@ -2,11 +2,15 @@ package audit
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
-	"sync"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/richgo/flo/pkg/correlation"
+	"github.com/richgo/flo/pkg/redact"
 )
 
 func TestAuditInit(t *testing.T) {
@@ -28,30 +32,26 @@ func TestAuditInit(t *testing.T) {
 func TestAuditLog(t *testing.T) {
 	tmpDir := t.TempDir()
 	
-	// Reset for testing - create new once and clear logger
-	once = sync.Once{}
-	defaultLogger = nil
-	
-	err := Init(tmpDir)
+	l, err := NewLogger(tmpDir)
 	if err != nil {
-		t.Fatalf("Init failed: %v", err)
+		t.Fatalf("NewLogger failed: %v", err)
 	}
-	defer Close()
+	defer l.Close()
 	
 	// Log some events
-	Info("test.operation", "Test info message", map[string]interface{}{
+	l.Info("test.operation", "Test info message", map[string]interface{}{
 		"key1": "value1",
 		"key2": 42,
 	})
 	
-	Warn("test.operation", "Test warning message", nil)
+	l.Warn("test.operation", "Test warning message", nil)
 	
-	Error("test.operation", "Test error message", map[string]interface{}{
+	l.Error("test.operation", "Test error message", map[string]interface{}{
 		"error": "something went wrong",
 	})
 	
 	// Close to flush
-	if err := Close(); err != nil {
+	if err := l.Close(); err != nil {
 		t.Fatalf("Close failed: %v", err)
 	}
 	
@@ -110,35 +110,31 @@ func TestAuditLog(t *testing.T) {
 }
 
 func TestAuditLogWithoutInit(t *testing.T) {
-	// Reset logger
-	once = sync.Once{}
-	defaultLogger = nil
-	
-	// Should not panic when logging without init
-	Info("test", "message", nil)
-	Warn("test", "message", nil)
-	Error("test", "message", nil)
+	// Should not panic when logging without a logger
+	var l *Logger
+	l.Info("test", "message", nil)
+	l.Warn("test", "message", nil)
+	l.Error("test", "message", nil)
+	if err := l.Close(); err != nil {
+		t.Errorf("Close of a nil logger failed: %v", err)
+	}
 }
 
 func TestAuditEventTimestamp(t *testing.T) {
 	tmpDir := t.TempDir()
 	
-	// Reset for testing
-	once = sync.Once{}
-	defaultLogger = nil
-	
-	err := Init(tmpDir)
+	l, err := NewLogger(tmpDir)
 	if err != nil {
-		t.Fatalf("Init failed: %v", err)
+		t.Fatalf("NewLogger failed: %v", err)
 	}
-	defer Close()
+	defer l.Close()
 	
 	beforeTime := time.Now()
-	Info("test", "message", nil)
+	l.Info("test", "message", nil)
 	afterTime := time.Now()
 	
 	// Close to flush
-	Close()
+	l.Close()
 	
 	// Read event
 	auditPath := filepath.Join(tmpDir, ".flo", "audit.log")
@@ -158,3 +154,189 @@ func TestAuditEventTimestamp(t *testing.T) {
 			event.Timestamp, beforeTime, afterTime)
 	}
 }
+
+func TestLoggersPerWorkspace(t *testing.T) {
+	t.Parallel()
+	rootA, rootB := t.TempDir(), t.TempDir()
+	a, err := NewLogger(rootA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewLogger(rootB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	a.Info("test.a", "to a", nil)
+	b.Info("test.b", "to b", nil)
+	b.Info("test.b", "to b again", nil)
+
+	linesA, _ := Tail(rootA, -1)
+	linesB, _ := Tail(rootB, -1)
+	if len(linesA) != 1 || len(linesB) != 2 || !strings.Contains(linesA[0], "test.a") {
+		t.Errorf("expected each workspace to get its own events, got %v and %v", linesA, linesB)
+	}
+}
+
+func TestInitSwitchesWorkspace(t *testing.T) {
+	rootA, rootB := t.TempDir(), t.TempDir()
+	if err := Init(rootA); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(rootB); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+	if Default().Path() != Path(rootB) {
+		t.Errorf("expected the last workspace initialised to be the default, got %s", Default().Path())
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	event, err := ParseEvent([]byte(`{"timestamp":"2026-02-05T22:00:00Z","level":"WARN","operation":"task.add","message":"m","details":{"n":1},"trace_id":"future"}`))
+	if err != nil {
+		t.Fatalf("ParseEvent failed: %v", err)
+	}
+	if event.Level != LevelWarn || event.Operation != "task.add" || event.Details["n"] != float64(1) {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	for _, line := range []string{"", "not json", `{"level":"INFO"}`, `{"timestamp":"2026-02-05T22:00:00Z"}`} {
+		if _, err := ParseEvent([]byte(line)); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
+func FuzzParseEvent(f *testing.F) {
+	f.Add([]byte(`{"timestamp":"2026-02-05T22:00:00Z","level":"INFO","operation":"op","message":"m"}`))
+	f.Add([]byte(`{"timestamp":"2026-02-05T22:00:00+05:30","level":"ERROR","operation":"op","details":{"deps":["t-001"],"nested":{"a":null}}}`))
+	f.Add([]byte(`{"timestamp":"0001-01-01T00:00:00Z","operation":"op"}`))
+
+	f.Fuzz(func(t *testing.T, line []byte) {
+		event, err := ParseEvent(line)
+		if err != nil {
+			return
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("parsed event failed to encode: %v", err)
+		}
+		again, err := ParseEvent(data)
+		if err != nil {
+			t.Fatalf("encoded event failed to parse: %v\n%s", err, data)
+		}
+		if !again.Timestamp.Equal(event.Timestamp) || again.Operation != event.Operation || again.Message != event.Message {
+			t.Fatalf("event changed on round trip: %+v → %+v", event, again)
+		}
+	})
+}
+
+func TestTail(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	lines, err := Tail(tmpDir, 10)
+	if err != nil || lines != nil {
+		t.Fatalf("expected no lines for a missing log, got %v, %v", lines, err)
+	}
+
+	os.MkdirAll(filepath.Join(tmpDir, ".flo"), 0755)
+	os.WriteFile(Path(tmpDir), []byte("one\ntwo\nthree\n"), 0644)
+
+	lines, err = Tail(tmpDir, 2)
+	if err != nil {
+		t.Fatalf("Tail failed: %v", err)
+	}
+	if len(lines) != 2 || lines[0] != "two" || lines[1] != "three" {
+		t.Errorf("expected last two lines, got %v", lines)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	if err := Init(t.TempDir()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	var got []Event
+	unsubscribe := Subscribe(func(e Event) { got = append(got, e) })
+	Warn("test.subscribe", "first", nil)
+	unsubscribe()
+	Warn("test.subscribe", "second", nil)
+
+	if len(got) != 1 || got[0].Message != "first" || got[0].Level != LevelWarn {
+		t.Errorf("expected only the first event, got %+v", got)
+	}
+}
+
+func TestRedactedEvents(t *testing.T) {
+	if err := Init(t.TempDir()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	r := redact.New(func(string) string { return "****" })
+	r.AddValue("hunter2-secret")
+	SetRedactor(r)
+	defer SetRedactor(nil)
+
+	var got []Event
+	unsubscribe := Subscribe(func(e Event) { got = append(got, e) })
+	defer unsubscribe()
+	details := map[string]interface{}{
+		"output": "export KEY=hunter2-secret",
+		"error":  errors.New("auth failed for hunter2-secret"),
+		"args":   []string{"--token", "hunter2-secret"},
+		"nested": map[string]interface{}{"env": "hunter2-secret"},
+		"count":  3,
+	}
+	Error("test.redact", "failed with hunter2-secret", details)
+
+	data, _ := json.Marshal(got)
+	if len(got) != 1 || strings.Contains(string(data), "hunter2-secret") {
+		t.Errorf("expected the secret scrubbed from the event, got %s", data)
+	}
+	if got[0].Details["count"] != 3 || got[0].Details["output"] != "export KEY=****" {
+		t.Errorf("unexpected details: %+v", got[0].Details)
+	}
+	if details["output"] != "export KEY=hunter2-secret" {
+		t.Error("expected the caller's details not to be modified")
+	}
+}
+
+func TestCorrelatedEvents(t *testing.T) {
+	root := t.TempDir()
+	l, err := NewLogger(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.Info("test.before", "before the run", nil)
+	l.SetCorrelation(correlation.IDs{RunID: "run-1", ExecutionID: "exec-1"})
+	l.Info("test.during", "during the attempt", nil)
+
+	lines, _ := Tail(root, -1)
+	before, _ := ParseEvent([]byte(lines[0]))
+	during, _ := ParseEvent([]byte(lines[1]))
+	if before.RunID != "" || strings.Contains(lines[0], "run_id") {
+		t.Errorf("expected no IDs before the run, got %s", lines[0])
+	}
+	if during.RunID != "run-1" || during.ExecutionID != "exec-1" {
+		t.Errorf("expected the attempt's IDs, got %+v", during)
+	}
+
+	// A process started by a run logs under the run's IDs
+	t.Setenv(correlation.RunIDEnv, "run-2")
+	t.Setenv(correlation.ExecutionIDEnv, "exec-2")
+	child, err := NewLogger(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer child.Close()
+	child.Info("test.child", "from the MCP server", nil)
+	lines, _ = Tail(root, 1)
+	event, _ := ParseEvent([]byte(lines[0]))
+	if event.RunID != "run-2" || event.ExecutionID != "exec-2" {
+		t.Errorf("expected the IDs from the environment, got %+v", event)
+	}
+}
@@ -0,0 +1,350 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// OpCheckpoint is the operation of the signed checkpoints written to a
+// chained log.
+const OpCheckpoint = "audit.checkpoint"
+
+// CheckpointInterval is how many chained events are written between
+// checkpoints. A checkpoint is also written when the logger is closed.
+const CheckpointInterval = 100
+
+// hashSuffix matches the hash that ends a chained event's line.
+var hashSuffix = regexp.MustCompile(`,"hash":"([0-9a-f]{64})"}$`)
+
+// Checkpoint is a signed record of the chain's head: the sequence number
+// and hash of the latest event. It is written to the log and to
+// .flo/audit.head, so a log cut short before the head can be detected.
+type Checkpoint struct {
+	Seq       int64     `json:"seq"`
+	Hash      string    `json:"hash"`
+	Timestamp time.Time `json:"timestamp"`
+	// Signature is the hex HMAC-SHA256 of Seq and Hash with the workspace
+	// key.
+	Signature string `json:"signature"`
+}
+
+// HeadPath returns the checkpoint file of a workspace's chained log.
+func HeadPath(workspaceRoot string) string {
+	return filepath.Join(workspaceRoot, ".flo", "audit.head")
+}
+
+// EnableChain makes the logger chain events: each one records its sequence
+// number and the hash of the event before it, so editing, removing or
+// reordering events breaks the chain. Checkpoints of the chain's head are
+// signed with key every CheckpointInterval events. Writers in other
+// processes must chain too, which they do when the workspace enables it.
+func (l *Logger) EnableChain(key []byte) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.chainKey = key
+	l.chained = true
+}
+
+// writeChainedLocked appends event to the chain. The log is locked across
+// processes while the previous event is read and the new one written.
+func (l *Logger) writeChainedLocked(event Event) error {
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+
+	seq, prev, err := chainHead(l.filePath)
+	if err != nil {
+		return err
+	}
+	event.Seq, event.PrevHash = seq+1, prev
+	hash, err := l.appendChained(event)
+	if err != nil {
+		return err
+	}
+	l.pending++
+	if event.Seq%CheckpointInterval == 0 {
+		return l.checkpointLocked(event.Seq, hash)
+	}
+	return nil
+}
+
+// appendChained writes event with its hash and returns the hash.
+func (l *Logger) appendChained(event Event) (string, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	line := append(data[:len(data)-1], fmt.Sprintf(`,"hash":"%s"}`, hash)...)
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// checkpointLocked signs the head at seq and hash, appends it to the chain
+// and writes it to the head file.
+func (l *Logger) checkpointLocked(seq int64, hash string) error {
+	cp := Checkpoint{Seq: seq, Hash: hash, Timestamp: time.Now().UTC()}
+	if l.chainKey != nil {
+		cp.Signature = signCheckpoint(l.chainKey, seq, hash)
+	}
+	event := Event{
+		Timestamp: cp.Timestamp,
+		Level:     LevelInfo,
+		Operation: OpCheckpoint,
+		Message:   "Audit log checkpoint",
+		Details: map[string]interface{}{
+			"seq":       seq,
+			"hash":      hash,
+			"signature": cp.Signature,
+		},
+		Seq:      seq + 1,
+		PrevHash: hash,
+	}
+	if _, err := l.appendChained(event); err != nil {
+		return err
+	}
+	l.pending = 0
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	head := filepath.Join(filepath.Dir(l.filePath), "audit.head")
+	tmp := head + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, head)
+}
+
+// closeChainLocked checkpoints events written since the last checkpoint.
+func (l *Logger) closeChainLocked() {
+	if !l.chained || l.pending == 0 || l.file == nil {
+		return
+	}
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_EX); err != nil {
+		return
+	}
+	defer syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	if seq, hash, err := chainHead(l.filePath); err == nil && seq > 0 {
+		l.checkpointLocked(seq, hash)
+	}
+}
+
+func signCheckpoint(key []byte, seq int64, hash string) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%d:%s", seq, hash)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// chainHead returns the sequence number and hash of the last event in the
+// log at path, or zero values when it holds no chained events.
+func chainHead(path string) (int64, string, error) {
+	line, err := lastLine(path)
+	if err != nil || line == "" {
+		return 0, "", err
+	}
+	m := hashSuffix.FindStringSubmatch(line)
+	if m == nil {
+		// The chain starts after events written without it
+		return 0, "", nil
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		return 0, "", fmt.Errorf("invalid last audit event: %w", err)
+	}
+	return event.Seq, m[1], nil
+}
+
+// lastLine returns the last complete line of the file at path.
+func lastLine(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	const chunk = 4096
+	end := info.Size()
+	var buf []byte
+	for pos := end; pos > 0; {
+		n := int64(chunk)
+		if pos < n {
+			n = pos
+		}
+		pos -= n
+		block := make([]byte, n)
+		if _, err := f.ReadAt(block, pos); err != nil && err != io.EOF {
+			return "", err
+		}
+		buf = append(block, buf...)
+		trimmed := bytes.TrimRight(buf, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return string(trimmed[i+1:]), nil
+		}
+		if pos == 0 {
+			return string(trimmed), nil
+		}
+	}
+	return "", nil
+}
+
+// VerifyReport is the outcome of verifying a chained log.
+type VerifyReport struct {
+	// Events is how many events the log holds; Unchained were written
+	// before chaining was enabled.
+	Events    int `json:"events"`
+	Unchained int `json:"unchained"`
+	// Checkpoints counts checkpoints whose signatures were checked.
+	Checkpoints int `json:"checkpoints"`
+	// Unsigned counts checkpoints that couldn't be checked, for want of a
+	// key.
+	Unsigned int    `json:"unsigned,omitempty"`
+	LastSeq  int64  `json:"last_seq"`
+	LastHash string `json:"last_hash,omitempty"`
+	// Problems describes each sign of tampering found.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// OK reports whether no tampering was found.
+func (r *VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *VerifyReport) problem(line int, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf("line %d: ", line)+fmt.Sprintf(format, args...))
+}
+
+// Verify checks the chained audit log of a workspace: every event's hash,
+// its link to the event before, unbroken sequence numbers, the signatures
+// of checkpoints when key is given, and that the log reaches the head
+// recorded in .flo/audit.head, which catches truncation.
+func Verify(workspaceRoot string, key []byte) (*VerifyReport, error) {
+	data, err := os.ReadFile(Path(workspaceRoot))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	report := &VerifyReport{}
+	hashes := make(map[int64]string)
+
+	text := string(data)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		report.Problems = append(report.Problems, "the log ends with a partial event (truncated or still being written)")
+	}
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		lines = nil
+	}
+
+	chained := false
+	for i, line := range lines {
+		n := i + 1
+		report.Events++
+		m := hashSuffix.FindStringSubmatch(line)
+		if m == nil {
+			if chained {
+				report.problem(n, "event without a hash inside the chain (inserted or edited)")
+			} else {
+				report.Unchained++
+			}
+			continue
+		}
+		body := line[:len(line)-len(m[0])] + "}"
+		sum := sha256.Sum256([]byte(body))
+		if hex.EncodeToString(sum[:]) != m[1] {
+			report.problem(n, "hash mismatch (event edited)")
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			report.problem(n, "invalid event: %v", err)
+			continue
+		}
+		if !chained {
+			chained = true
+			if event.Seq != 1 || event.PrevHash != "" {
+				report.problem(n, "the chain starts at event %d (earlier events removed)", event.Seq)
+			}
+		} else {
+			if event.Seq != report.LastSeq+1 {
+				report.problem(n, "sequence %d follows %d (events removed or reordered)", event.Seq, report.LastSeq)
+			}
+			if event.PrevHash != report.LastHash {
+				report.problem(n, "does not link to the previous event (events removed, inserted or reordered)")
+			}
+		}
+		report.LastSeq, report.LastHash = event.Seq, m[1]
+		hashes[event.Seq] = m[1]
+
+		if event.Operation == OpCheckpoint {
+			checkCheckpoint(report, n, event, hashes, key)
+		}
+	}
+
+	checkHead(report, workspaceRoot, hashes, key)
+	return report, nil
+}
+
+// checkCheckpoint verifies a checkpoint event against the chain.
+func checkCheckpoint(report *VerifyReport, n int, event Event, hashes map[int64]string, key []byte) {
+	seq, _ := event.Details["seq"].(float64)
+	hash, _ := event.Details["hash"].(string)
+	sig, _ := event.Details["signature"].(string)
+	if hashes[int64(seq)] != hash {
+		report.problem(n, "checkpoint does not match event %d", int64(seq))
+	}
+	switch {
+	case key == nil || sig == "":
+		report.Unsigned++
+	case !hmac.Equal([]byte(sig), []byte(signCheckpoint(key, int64(seq), hash))):
+		report.problem(n, "checkpoint signature is invalid (forged or signed with another key)")
+	default:
+		report.Checkpoints++
+	}
+}
+
+// checkHead compares the log with the head file.
+func checkHead(report *VerifyReport, workspaceRoot string, hashes map[int64]string, key []byte) {
+	data, err := os.ReadFile(HeadPath(workspaceRoot))
+	if os.IsNotExist(err) {
+		return
+	}
+	var head Checkpoint
+	if err == nil {
+		err = json.Unmarshal(data, &head)
+	}
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("unreadable %s: %v", filepath.Base(HeadPath(workspaceRoot)), err))
+		return
+	}
+	if key != nil && !hmac.Equal([]byte(head.Signature), []byte(signCheckpoint(key, head.Seq, head.Hash))) {
+		report.Problems = append(report.Problems, "audit.head signature is invalid")
+	}
+	switch got, ok := hashes[head.Seq]; {
+	case !ok:
+		report.Problems = append(report.Problems, fmt.Sprintf("the log stops before event %d recorded at the last checkpoint (truncated)", head.Seq))
+	case got != head.Hash:
+		report.Problems = append(report.Problems, fmt.Sprintf("event %d differs from the last checkpoint", head.Seq))
+	}
+}
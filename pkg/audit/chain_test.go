@@ -0,0 +1,188 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// writeChain logs n chained events to a new log under root.
+func writeChain(t *testing.T, root string, key []byte, n int) {
+	t.Helper()
+	l, err := NewLogger(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.EnableChain(key)
+	for i := 0; i < n; i++ {
+		l.Info("test.event", fmt.Sprintf("event %d", i), map[string]interface{}{"i": i})
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rewrite applies fn to the lines of the log under root.
+func rewrite(t *testing.T, root string, fn func(lines []string) []string) {
+	t.Helper()
+	data, err := os.ReadFile(Path(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := fn(strings.Split(strings.TrimRight(string(data), "\n"), "\n"))
+	if err := os.WriteFile(Path(root), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChainVerifies(t *testing.T) {
+	root := t.TempDir()
+	key := []byte("workspace-key")
+	writeChain(t, root, key, 3)
+	// A second writer continues the chain
+	writeChain(t, root, key, 2)
+
+	report, err := Verify(root, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("expected an intact chain, got %v", report.Problems)
+	}
+	// 5 events and a checkpoint when each logger closed
+	if report.Events != 7 || report.Checkpoints != 2 || report.LastSeq != 7 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	lines, _ := Tail(root, 1)
+	event, err := ParseEvent([]byte(lines[0]))
+	if err != nil || event.Operation != OpCheckpoint || event.Hash == "" {
+		t.Errorf("expected a checkpoint to end the log, got %s", lines[0])
+	}
+}
+
+func TestChainPeriodicCheckpoints(t *testing.T) {
+	root := t.TempDir()
+	writeChain(t, root, []byte("k"), CheckpointInterval+1)
+
+	report, _ := Verify(root, []byte("k"))
+	// One at the interval and one on close
+	if !report.OK() || report.Checkpoints != 2 {
+		t.Errorf("expected two checkpoints, got %+v", report)
+	}
+}
+
+func TestChainAfterUnchainedEvents(t *testing.T) {
+	root := t.TempDir()
+	l, _ := NewLogger(root)
+	l.Info("test.old", "before chaining", nil)
+	l.Close()
+	writeChain(t, root, nil, 2)
+
+	report, _ := Verify(root, nil)
+	if !report.OK() || report.Unchained != 1 || report.Unsigned != 1 {
+		t.Errorf("expected the chain to start after the old event, got %+v", report)
+	}
+}
+
+func TestChainDetectsTampering(t *testing.T) {
+	key := []byte("workspace-key")
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, root string)
+		key    []byte
+		want   string
+	}{
+		{
+			name: "edited event",
+			tamper: func(t *testing.T, root string) {
+				rewrite(t, root, func(lines []string) []string {
+					lines[1] = strings.Replace(lines[1], "event 1", "event X", 1)
+					return lines
+				})
+			},
+			want: "hash mismatch",
+		},
+		{
+			name: "removed event",
+			tamper: func(t *testing.T, root string) {
+				rewrite(t, root, func(lines []string) []string {
+					return append(lines[:1], lines[2:]...)
+				})
+			},
+			want: "events removed",
+		},
+		{
+			name: "removed first event",
+			tamper: func(t *testing.T, root string) {
+				rewrite(t, root, func(lines []string) []string { return lines[1:] })
+			},
+			want: "earlier events removed",
+		},
+		{
+			name: "reordered events",
+			tamper: func(t *testing.T, root string) {
+				rewrite(t, root, func(lines []string) []string {
+					lines[0], lines[1] = lines[1], lines[0]
+					return lines
+				})
+			},
+			want: "sequence",
+		},
+		{
+			name: "inserted event",
+			tamper: func(t *testing.T, root string) {
+				rewrite(t, root, func(lines []string) []string {
+					forged := `{"timestamp":"2026-01-01T00:00:00Z","level":"info","operation":"test.forged","message":"forged"}`
+					return append(lines[:2], append([]string{forged}, lines[2:]...)...)
+				})
+			},
+			want: "without a hash",
+		},
+		{
+			name: "truncated",
+			tamper: func(t *testing.T, root string) {
+				rewrite(t, root, func(lines []string) []string { return lines[:2] })
+			},
+			want: "truncated",
+		},
+		{
+			name: "partial last line",
+			tamper: func(t *testing.T, root string) {
+				data, _ := os.ReadFile(Path(root))
+				os.WriteFile(Path(root), data[:len(data)-10], 0644)
+			},
+			want: "partial event",
+		},
+		{
+			name:   "wrong key",
+			tamper: func(t *testing.T, root string) {},
+			key:    []byte("other-key"),
+			want:   "signature is invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeChain(t, root, key, 4)
+			tt.tamper(t, root)
+
+			verifyKey := key
+			if tt.key != nil {
+				verifyKey = tt.key
+			}
+			report, err := Verify(root, verifyKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.OK() {
+				t.Fatal("expected tampering to be detected")
+			}
+			if !strings.Contains(strings.Join(report.Problems, "\n"), tt.want) {
+				t.Errorf("expected a problem mentioning %q, got %v", tt.want, report.Problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Data is the typed details of an audit event. Each type logs one
+// operation with fixed JSON field names, which tools reading the log can
+// rely on. Operations without a type log free-form details with Log, Info,
+// Warn and Error, or Generic.
+type Data interface {
+	// Operation is the event's operation, such as task.registry.add.
+	Operation() string
+}
+
+// Emit logs a typed event.
+func (l *Logger) Emit(level Level, message string, data Data) {
+	if l == nil {
+		return
+	}
+	l.Log(level, data.Operation(), message, detailsOf(data))
+}
+
+// Emit logs a typed event with the default logger.
+func Emit(level Level, message string, data Data) {
+	Default().Emit(level, message, data)
+}
+
+// Details returns data as the details it is logged with, secrets
+// redacted, such as for other destinations of the event.
+func Details(data Data) map[string]interface{} {
+	redactorMu.RLock()
+	r := redactor
+	redactorMu.RUnlock()
+	return redactDetails(r, detailsOf(data))
+}
+
+// detailsOf returns data as event details, keyed by its JSON field names.
+func detailsOf(data Data) map[string]interface{} {
+	if g, ok := data.(Generic); ok {
+		return g.Details
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return map[string]interface{}{"error": fmt.Sprintf("unserializable details: %v", err)}
+	}
+	var details map[string]interface{}
+	json.Unmarshal(raw, &details)
+	return details
+}
+
+// Decode decodes the event's details into data, which must be of the
+// event's operation.
+func (e Event) Decode(data Data) error {
+	if data.Operation() != e.Operation {
+		return fmt.Errorf("cannot decode %s event as %s", e.Operation, data.Operation())
+	}
+	raw, err := json.Marshal(e.Details)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, data)
+}
+
+// Data returns the event's details as the type of its operation, or as
+// Generic for operations without one.
+func (e Event) Data() (Data, error) {
+	newData, ok := dataTypes[e.Operation]
+	if !ok {
+		return Generic{Op: e.Operation, Details: e.Details}, nil
+	}
+	data := newData()
+	if err := e.Decode(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// dataTypes creates the typed details of each operation.
+var dataTypes = map[string]func() Data{
+	opWorkspaceInit:   func() Data { return &WorkspaceInitialized{} },
+	opWorkspaceLoad:   func() Data { return &WorkspaceLoaded{} },
+	opTaskCreated:     func() Data { return &TaskCreated{} },
+	opStatusChanged:   func() Data { return &StatusChanged{} },
+	opTaskRetried:     func() Data { return &TaskRetried{} },
+	opTaskTransition:  func() Data { return &StatusTransition{} },
+	opRegistryAdd:     func() Data { return &TaskAdded{} },
+	opRegistryUpdate:  func() Data { return &TaskUpdated{} },
+	opRegistryDelete:  func() Data { return &TaskDeleted{} },
+	opSessionStarted:  func() Data { return &SessionStarted{} },
+	opRunFinished:     func() Data { return &RunFinished{} },
+	opRetryScheduled:  func() Data { return &RetryScheduled{} },
+	opBreakerChanged:  func() Data { return &BreakerChanged{} },
+	opApprovalAdded:   func() Data { return &ApprovalRequested{} },
+	opApprovalDecided: func() Data { return &ApprovalDecided{} },
+	opGateChecked:     func() Data { return &GateChecked{} },
+	opVerifyStep:      func() Data { return &VerifyStepFinished{} },
+	opPolicyViolation: func() Data { return &PolicyViolation{} },
+	opOverBudget:      func() Data { return &BudgetExceeded{} },
+	opBudgetOverride:  func() Data { return &BudgetOverridden{} },
+	opQuestionAsked:   func() Data { return &QuestionAsked{} },
+}
+
+// Operations of the typed events.
+const (
+	opWorkspaceInit   = "workspace.init"
+	opWorkspaceLoad   = "workspace.load"
+	opTaskCreated     = "workspace.create_task"
+	opStatusChanged   = "workspace.task_status"
+	opTaskRetried     = "workspace.retry_task"
+	opTaskTransition  = "task.set_status"
+	opRegistryAdd     = "task.registry.add"
+	opRegistryUpdate  = "task.registry.update"
+	opRegistryDelete  = "task.registry.delete"
+	opSessionStarted  = "work.attempt"
+	opRunFinished     = "work.finish"
+	opRetryScheduled  = "agent.retry"
+	opBreakerChanged  = "agent.breaker"
+	opApprovalAdded   = "approval.add"
+	opApprovalDecided = "approval.decide"
+	opGateChecked     = "gate.check"
+	opVerifyStep      = "verify.step"
+	opPolicyViolation = "policy.violation"
+	opOverBudget      = "work.over_budget"
+	opBudgetOverride  = "work.budget_override"
+	opQuestionAsked   = "work.question"
+)
+
+// Generic is an event of any operation with free-form details: the escape
+// hatch for operations without a type.
+type Generic struct {
+	Op      string
+	Details map[string]interface{}
+}
+
+func (g Generic) Operation() string { return g.Op }
+
+// WorkspaceInitialized records flo init.
+type WorkspaceInitialized struct {
+	Feature string `json:"feature"`
+	Backend string `json:"backend"`
+	Root    string `json:"root"`
+}
+
+func (WorkspaceInitialized) Operation() string { return opWorkspaceInit }
+
+// WorkspaceLoaded records a command opening the workspace.
+type WorkspaceLoaded struct {
+	Feature   string `json:"feature"`
+	Backend   string `json:"backend"`
+	TaskCount int    `json:"task_count"`
+}
+
+func (WorkspaceLoaded) Operation() string { return opWorkspaceLoad }
+
+// TaskCreated records a task created in the workspace, or the failure to
+// create it.
+type TaskCreated struct {
+	TaskID   string   `json:"task_id,omitempty"`
+	Title    string   `json:"title,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Model    string   `json:"model,omitempty"`
+	Repo     string   `json:"repo,omitempty"`
+	Deps     []string `json:"deps,omitempty"`
+	Parent   string   `json:"parent,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+func (TaskCreated) Operation() string { return opTaskCreated }
+
+// StatusChanged records a workspace task moving to a new status, or a
+// change blocked by a completion gate.
+type StatusChanged struct {
+	TaskID    string `json:"task_id"`
+	OldStatus string `json:"old_status,omitempty"`
+	NewStatus string `json:"new_status"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (StatusChanged) Operation() string { return opStatusChanged }
+
+// TaskRetried records a failed task sent back to pending, and the model it
+// was moved to, if any.
+type TaskRetried struct {
+	TaskID   string `json:"task_id"`
+	Retries  int    `json:"retries"`
+	OldModel string `json:"old_model,omitempty"`
+	NewModel string `json:"new_model,omitempty"`
+}
+
+func (TaskRetried) Operation() string { return opTaskRetried }
+
+// StatusTransition records a task's status changing, or an invalid
+// transition refused.
+type StatusTransition struct {
+	TaskID string `json:"task_id"`
+	Title  string `json:"task_title,omitempty"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+func (StatusTransition) Operation() string { return opTaskTransition }
+
+// TaskAdded records a task added to the registry, or refused.
+type TaskAdded struct {
+	TaskID string   `json:"task_id"`
+	Title  string   `json:"title,omitempty"`
+	Deps   []string `json:"deps,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+func (TaskAdded) Operation() string { return opRegistryAdd }
+
+// TaskUpdated records a task updated in the registry, or refused.
+type TaskUpdated struct {
+	TaskID string `json:"task_id"`
+	Title  string `json:"title,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (TaskUpdated) Operation() string { return opRegistryUpdate }
+
+// TaskDeleted records a task deleted from the registry, or refused because
+// Dependent depends on it.
+type TaskDeleted struct {
+	TaskID    string `json:"task_id"`
+	Dependent string `json:"dependent,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (TaskDeleted) Operation() string { return opRegistryDelete }
+
+// SessionStarted records an attempt at a task with a backend.
+type SessionStarted struct {
+	TaskID  string `json:"task_id"`
+	Backend string `json:"backend"`
+	Model   string `json:"model,omitempty"`
+}
+
+func (SessionStarted) Operation() string { return opSessionStarted }
+
+// RunFinished records the end of a flo work run, and whether the agent
+// completed the task.
+type RunFinished struct {
+	TaskID  string `json:"task_id"`
+	Title   string `json:"title,omitempty"`
+	Backend string `json:"backend"`
+	Model   string `json:"model,omitempty"`
+	Success bool   `json:"success"`
+	// Review is set when the task's changes were held for review.
+	Review bool   `json:"review,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (RunFinished) Operation() string { return opRunFinished }
+
+// RetryScheduled records a backend call retried after a failed attempt.
+type RetryScheduled struct {
+	Attempt     int    `json:"attempt"`
+	MaxAttempts int    `json:"max_attempts"`
+	Class       string `json:"class"`
+	Error       string `json:"error"`
+	BackoffMS   int64  `json:"backoff_ms"`
+	// RetryAfterMS is the wait the provider asked for, when it said.
+	RetryAfterMS int64 `json:"retry_after_ms,omitempty"`
+}
+
+func (RetryScheduled) Operation() string { return opRetryScheduled }
+
+// BreakerChanged records a backend's circuit breaker changing state:
+// closed, open or half_open.
+type BreakerChanged struct {
+	Backend string `json:"backend"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+func (BreakerChanged) Operation() string { return opBreakerChanged }
+
+// ApprovalRequested records an action queued for a person's approval.
+type ApprovalRequested struct {
+	ApprovalID string `json:"approval_id"`
+	TaskID     string `json:"task_id"`
+	Kind       string `json:"kind"`
+	Summary    string `json:"summary,omitempty"`
+}
+
+func (ApprovalRequested) Operation() string { return opApprovalAdded }
+
+// ApprovalDecided records an approval approved or rejected.
+type ApprovalDecided struct {
+	ApprovalID string `json:"approval_id"`
+	TaskID     string `json:"task_id"`
+	Status     string `json:"status"`
+	DecidedBy  string `json:"decided_by"`
+}
+
+func (ApprovalDecided) Operation() string { return opApprovalDecided }
+
+// GateChecked records a custom gate run on a task.
+type GateChecked struct {
+	Gate     string `json:"gate"`
+	TaskID   string `json:"task_id"`
+	Passed   bool   `json:"passed"`
+	Findings int    `json:"findings"`
+	Duration string `json:"duration"`
+}
+
+func (GateChecked) Operation() string { return opGateChecked }
+
+// VerifyStepFinished records a verify pipeline step.
+type VerifyStepFinished struct {
+	Step     string `json:"step"`
+	Passed   bool   `json:"passed"`
+	Duration string `json:"duration"`
+}
+
+func (VerifyStepFinished) Operation() string { return opVerifyStep }
+
+// PolicyViolation records an agent operation the workspace policy denies
+// or requires approval for.
+type PolicyViolation struct {
+	TaskID string `json:"task_id"`
+	Rule   string `json:"rule"`
+	Action string `json:"action"`
+	Target string `json:"target"`
+}
+
+func (PolicyViolation) Operation() string { return opPolicyViolation }
+
+// BudgetExceeded records agent runs paused because the next would take
+// the feature over its budget.
+type BudgetExceeded struct {
+	TaskID string `json:"task"`
+	Error  string `json:"error"`
+}
+
+func (BudgetExceeded) Operation() string { return opOverBudget }
+
+// BudgetOverridden records an agent run started over budget with
+// --ignore-budget.
+type BudgetOverridden struct {
+	TaskID string `json:"task"`
+	Error  string `json:"error"`
+}
+
+func (BudgetOverridden) Operation() string { return opBudgetOverride }
+
+// QuestionAsked records the agent asking for input during a run.
+type QuestionAsked struct {
+	TaskID   string `json:"task_id"`
+	Question string `json:"question"`
+}
+
+func (QuestionAsked) Operation() string { return opQuestionAsked }
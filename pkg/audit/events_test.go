@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/redact"
+)
+
+func TestEmit(t *testing.T) {
+	root := t.TempDir()
+	l, err := NewLogger(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.Emit(LevelInfo, "Task created", TaskCreated{
+		TaskID:   "t-1",
+		Title:    "Add login",
+		Deps:     []string{"t-0"},
+		Priority: 2,
+	})
+
+	lines, _ := Tail(root, -1)
+	if len(lines) != 1 {
+		t.Fatalf("expected one event, got %v", lines)
+	}
+	if !strings.Contains(lines[0], `"details":{"deps":["t-0"],"priority":2,"task_id":"t-1","title":"Add login"}`) {
+		t.Errorf("expected the typed fields as details, got %s", lines[0])
+	}
+	event, _ := ParseEvent([]byte(lines[0]))
+	if event.Operation != "workspace.create_task" || event.Level != LevelInfo {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	data, err := event.Data()
+	if err != nil {
+		t.Fatalf("Data: %v", err)
+	}
+	want := &TaskCreated{TaskID: "t-1", Title: "Add login", Deps: []string{"t-0"}, Priority: 2}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Data = %+v, want %+v", data, want)
+	}
+}
+
+func TestEmitGeneric(t *testing.T) {
+	root := t.TempDir()
+	l, err := NewLogger(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.Emit(LevelWarn, "Something custom", Generic{Op: "plugin.custom", Details: map[string]interface{}{"n": 1}})
+
+	lines, _ := Tail(root, -1)
+	event, _ := ParseEvent([]byte(lines[0]))
+	data, err := event.Data()
+	if err != nil {
+		t.Fatalf("Data: %v", err)
+	}
+	g, ok := data.(Generic)
+	if !ok || g.Operation() != "plugin.custom" || g.Details["n"] != float64(1) {
+		t.Errorf("expected generic details for an untyped operation, got %#v", data)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	event := Event{
+		Operation: "approval.decide",
+		Details:   map[string]interface{}{"approval_id": "a-1", "task_id": "t-1", "status": "approved", "decided_by": "ana"},
+	}
+	var decided ApprovalDecided
+	if err := event.Decode(&decided); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if decided != (ApprovalDecided{ApprovalID: "a-1", TaskID: "t-1", Status: "approved", DecidedBy: "ana"}) {
+		t.Errorf("unexpected details: %+v", decided)
+	}
+
+	var added TaskAdded
+	if err := event.Decode(&added); err == nil {
+		t.Error("expected decoding as another operation's type to fail")
+	}
+}
+
+func TestEmitRedacted(t *testing.T) {
+	root := t.TempDir()
+	l, err := NewLogger(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	r := redact.New(func(string) string { return "****" })
+	r.AddValue("hunter2-secret")
+	SetRedactor(r)
+	defer SetRedactor(nil)
+
+	l.Emit(LevelError, "Dependency validation failed", TaskAdded{
+		TaskID: "t-1",
+		Deps:   []string{"hunter2-secret"},
+		Error:  "bad dep hunter2-secret",
+	})
+
+	lines, _ := Tail(root, -1)
+	if strings.Contains(lines[0], "hunter2-secret") {
+		t.Errorf("expected the secret scrubbed from typed details, got %s", lines[0])
+	}
+}
+
+func TestTypedOperations(t *testing.T) {
+	// Every typed operation decodes back to its own type
+	for op, newData := range dataTypes {
+		if got := newData().Operation(); got != op {
+			t.Errorf("%s creates details of %s", op, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Follow calls fn with each event appended to a workspace's audit log from
+// now on, by this process or any other, until ctx is done. The log is
+// checked every interval; a log that shrinks is followed from its start.
+// Lines that aren't events are skipped.
+func Follow(ctx context.Context, workspaceRoot string, interval time.Duration, fn func(Event)) error {
+	path := Path(workspaceRoot)
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var partial []byte
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		data, next, err := readFrom(path, offset)
+		if err != nil {
+			return err
+		}
+		if next < offset {
+			partial = nil
+		}
+		offset = next
+
+		data = append(partial, data...)
+		end := bytes.LastIndexByte(data, '\n') + 1
+		partial = append([]byte(nil), data[end:]...)
+		for _, line := range bytes.Split(data[:end], []byte("\n")) {
+			if event, err := ParseEvent(line); err == nil {
+				fn(event)
+			}
+		}
+	}
+}
+
+// readFrom returns what the file at path holds past offset, or all of it
+// when it is now shorter, and the offset of its end.
+func readFrom(path string, offset int64) ([]byte, int64, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return data, offset + int64(len(data)), nil
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFollow(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, ".flo"), 0755)
+	path := Path(root)
+	os.WriteFile(path, []byte(`{"timestamp":"2026-01-01T00:00:00Z","level":"INFO","operation":"old","message":"before"}`+"\n"), 0644)
+
+	var mu sync.Mutex
+	var got []string
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Follow(ctx, root, 5*time.Millisecond, func(e Event) {
+			mu.Lock()
+			got = append(got, e.Operation)
+			mu.Unlock()
+		})
+	}()
+	wait := func(n int) []string {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			mu.Lock()
+			ops := append([]string(nil), got...)
+			mu.Unlock()
+			if len(ops) >= n {
+				return ops
+			}
+		}
+		t.Fatalf("timed out waiting for %d events", n)
+		return nil
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// Events another process appends, including one written in two parts
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"timestamp":"2026-01-01T00:00:01Z","level":"INFO","operation":"first","message":"a"}` + "\nnot an event\n")
+	f.WriteString(`{"timestamp":"2026-01-01T00:00:02Z","level":"INFO",`)
+	time.Sleep(20 * time.Millisecond)
+	f.WriteString(`"operation":"second","message":"b"}` + "\n")
+	f.Close()
+	if ops := wait(2); ops[0] != "first" || ops[1] != "second" {
+		t.Errorf("expected only the new events, got %v", ops)
+	}
+
+	// A log started over is followed from its start
+	os.WriteFile(path, []byte(`{"timestamp":"2026-01-01T00:00:03Z","level":"INFO","operation":"third","message":"c"}`+"\n"), 0644)
+	if ops := wait(3); ops[2] != "third" {
+		t.Errorf("expected the new log's event, got %v", ops)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Follow returned %v", err)
+	}
+}
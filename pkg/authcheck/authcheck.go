@@ -0,0 +1,183 @@
+// Package authcheck verifies that backend credentials are accepted by their
+// providers, for `flo secrets check`. Each check is one cheap
+// authenticated request, such as listing models, that uses no tokens.
+package authcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/offline"
+)
+
+// Result statuses.
+const (
+	// StatusOK means the provider accepted the credential.
+	StatusOK = "ok"
+	// StatusInvalid means the provider rejected it: a wrong, revoked or
+	// expired key, or one without access.
+	StatusInvalid = "invalid"
+	// StatusUnset means no credential is set, so the backend's CLI relies
+	// on its own login.
+	StatusUnset = "unset"
+	// StatusError means the check couldn't reach a verdict.
+	StatusError = "error"
+)
+
+// Credential is how a backend authenticates to its provider's API.
+type Credential struct {
+	Backend string
+	// Env lists the variables holding the credential, in the order the
+	// backend's CLI reads them.
+	Env []string
+	// URL is requested to check the credential.
+	URL string
+	// authorize adds the credential to a request.
+	authorize func(req *http.Request, key string)
+}
+
+func bearer(req *http.Request, key string) {
+	req.Header.Set("Authorization", "Bearer "+key)
+}
+
+// Credentials are the credentials of the backends flo knows, by backend.
+var Credentials = map[string]Credential{
+	"claude": {
+		Backend: "claude",
+		Env:     []string{"ANTHROPIC_API_KEY", "CLAUDE_API_KEY"},
+		URL:     "https://api.anthropic.com/v1/models?limit=1",
+		authorize: func(req *http.Request, key string) {
+			req.Header.Set("x-api-key", key)
+			req.Header.Set("anthropic-version", "2023-06-01")
+		},
+	},
+	"copilot": {
+		Backend:   "copilot",
+		Env:       []string{"COPILOT_TOKEN", "GH_TOKEN", "GITHUB_TOKEN"},
+		URL:       "https://api.github.com/user",
+		authorize: bearer,
+	},
+	"codex": {
+		Backend:   "codex",
+		Env:       []string{"OPENAI_API_KEY", "CODEX_API_KEY"},
+		URL:       "https://api.openai.com/v1/models",
+		authorize: bearer,
+	},
+	"gemini": {
+		Backend: "gemini",
+		Env:     []string{"GEMINI_API_KEY", "GOOGLE_API_KEY"},
+		URL:     "https://generativelanguage.googleapis.com/v1beta/models?pageSize=1",
+		authorize: func(req *http.Request, key string) {
+			req.Header.Set("x-goog-api-key", key)
+		},
+	},
+}
+
+// Backends returns the backends with known credentials, sorted.
+func Backends() []string {
+	names := make([]string, 0, len(Credentials))
+	for name := range Credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Result is the outcome of checking a backend's credential.
+type Result struct {
+	Backend string `json:"backend"`
+	// Env is the variable the credential was read from.
+	Env     string `json:"env,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Checker checks credentials against their providers.
+type Checker struct {
+	client *http.Client
+	urls   map[string]string
+}
+
+// NewChecker returns a checker with a short request timeout.
+func NewChecker() *Checker {
+	return &Checker{client: &http.Client{Timeout: 10 * time.Second}, urls: make(map[string]string)}
+}
+
+// SetClient replaces the HTTP client (for testing).
+func (c *Checker) SetClient(client *http.Client) {
+	c.client = client
+}
+
+// SetURL replaces the URL a backend's credential is checked against (for
+// testing).
+func (c *Checker) SetURL(backend, url string) {
+	c.urls[backend] = url
+}
+
+// Check verifies backend's credential, read with lookup from the first of
+// its variables that is set.
+func (c *Checker) Check(ctx context.Context, backend string, lookup func(string) string) Result {
+	r := Result{Backend: backend}
+	cred, ok := Credentials[backend]
+	if !ok {
+		r.Status, r.Message = StatusError, "no credential check for this backend"
+		return r
+	}
+	var key string
+	for _, env := range cred.Env {
+		if key = lookup(env); key != "" {
+			r.Env = env
+			break
+		}
+	}
+	if key == "" {
+		r.Status = StatusUnset
+		r.Message = fmt.Sprintf("%s not set; the %s CLI uses its own login", strings.Join(cred.Env, " or "), backend)
+		return r
+	}
+	if err := offline.Check(backend + " credential check"); err != nil {
+		r.Status, r.Message = StatusError, err.Error()
+		return r
+	}
+
+	url := cred.URL
+	if u, ok := c.urls[backend]; ok {
+		url = u
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		r.Status, r.Message = StatusError, err.Error()
+		return r
+	}
+	cred.authorize(req, key)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		r.Status, r.Message = StatusError, fmt.Sprintf("request failed: %v", err)
+		return r
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		r.Status, r.Message = StatusOK, "accepted"
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// Rate limits are applied to authenticated callers
+		r.Status, r.Message = StatusOK, "accepted (currently rate limited)"
+	case resp.StatusCode == http.StatusUnauthorized:
+		r.Status, r.Message = StatusInvalid, "rejected: the key is wrong, revoked or expired"
+	case resp.StatusCode == http.StatusForbidden:
+		r.Status, r.Message = StatusInvalid, "forbidden: the key lacks access"
+	case resp.StatusCode == http.StatusBadRequest && backend == "gemini":
+		// Gemini answers an invalid key with 400 API_KEY_INVALID
+		r.Status, r.Message = StatusInvalid, "rejected: the key is invalid"
+	default:
+		r.Status, r.Message = StatusError, fmt.Sprintf("unexpected response: %s", resp.Status)
+	}
+	return r
+}
@@ -0,0 +1,59 @@
+package authcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/richgo/flo/pkg/offline"
+)
+
+func TestCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("x-api-key") == "sk-good" && r.Header.Get("anthropic-version") != "":
+			w.Write([]byte(`{"data": []}`))
+		case r.Header.Get("Authorization") == "Bearer busy":
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		case r.Header.Get("Authorization") == "Bearer broken":
+			http.Error(w, "oops", http.StatusInternalServerError)
+		default:
+			http.Error(w, "invalid x-api-key", http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewChecker()
+	for _, b := range Backends() {
+		c.SetURL(b, srv.URL)
+	}
+	tests := []struct {
+		backend string
+		env     map[string]string
+		status  string
+		from    string
+	}{
+		{"claude", map[string]string{"CLAUDE_API_KEY": "sk-good"}, StatusOK, "CLAUDE_API_KEY"},
+		{"claude", map[string]string{"ANTHROPIC_API_KEY": "sk-old", "CLAUDE_API_KEY": "sk-good"}, StatusInvalid, "ANTHROPIC_API_KEY"},
+		{"codex", map[string]string{"OPENAI_API_KEY": "busy"}, StatusOK, "OPENAI_API_KEY"},
+		{"copilot", map[string]string{"GITHUB_TOKEN": "broken"}, StatusError, "GITHUB_TOKEN"},
+		{"gemini", nil, StatusUnset, ""},
+		{"mock", nil, StatusError, ""},
+	}
+	for _, tt := range tests {
+		r := c.Check(context.Background(), tt.backend, func(name string) string { return tt.env[name] })
+		if r.Status != tt.status || r.Env != tt.from {
+			t.Errorf("%s with %v: got %+v, want %s from %s", tt.backend, tt.env, r, tt.status, tt.from)
+		}
+	}
+}
+
+func TestCheckOffline(t *testing.T) {
+	offline.Set(true)
+	defer offline.Set(false)
+	r := NewChecker().Check(context.Background(), "claude", func(string) string { return "sk" })
+	if r.Status != StatusError {
+		t.Errorf("expected the check to be skipped offline, got %+v", r)
+	}
+}
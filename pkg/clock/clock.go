@@ -0,0 +1,119 @@
+// Package clock abstracts the passage of time so that quota windows,
+// retry backoff, circuit breakers and timestamps can be driven by tests and
+// simulations instead of real sleeps.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// After sends the time on the returned channel once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the system clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Or returns c, or Real when c is nil, so that a zero-value Clock field
+// means the system clock.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// Fake is a Clock that only moves when told to. It is safe for concurrent
+// use.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+	// waiting is signalled whenever After registers a waiter.
+	waiting chan struct{}
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFake returns a fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start, waiting: make(chan struct{}, 1)}
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After returns a channel that receives the fake time once Advance has
+// moved the clock d past now. d <= 0 fires immediately.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, waiter{at: f.now.Add(d), ch: ch})
+	select {
+	case f.waiting <- struct{}{}:
+	default:
+	}
+	return ch
+}
+
+// Advance moves the clock forward by d, firing every After whose time has
+// come, in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+	remaining := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			remaining = append(remaining, w)
+			continue
+		}
+		w.ch <- f.now
+	}
+	f.waiters = remaining
+}
+
+// Waiters returns how many After calls are waiting for the clock to move.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until at least n After calls are waiting, so that a
+// test can advance the clock only once the code under test is asleep.
+func (f *Fake) BlockUntil(n int) {
+	for f.Waiters() < n {
+		<-f.waiting
+	}
+}
@@ -0,0 +1,78 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeAdvance(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	short := c.After(time.Second)
+	long := c.After(time.Minute)
+	if c.Waiters() != 2 {
+		t.Fatalf("expected 2 waiters, got %d", c.Waiters())
+	}
+
+	c.Advance(30 * time.Second)
+	select {
+	case at := <-short:
+		if !at.Equal(start.Add(30 * time.Second)) {
+			t.Errorf("expected to fire at the advanced time, got %v", at)
+		}
+	default:
+		t.Error("expected the 1s timer to fire")
+	}
+	select {
+	case <-long:
+		t.Error("expected the 1m timer to still be waiting")
+	default:
+	}
+
+	c.Advance(30 * time.Second)
+	select {
+	case <-long:
+	default:
+		t.Error("expected the 1m timer to fire")
+	}
+	if got := c.Since(start); got != time.Minute {
+		t.Errorf("expected 1m since start, got %v", got)
+	}
+}
+
+func TestFakeAfterZero(t *testing.T) {
+	c := NewFake(time.Now())
+	select {
+	case <-c.After(0):
+	default:
+		t.Error("expected After(0) to fire immediately")
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	c := NewFake(time.Now())
+	done := make(chan struct{})
+	go func() {
+		<-c.After(time.Hour)
+		close(done)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Hour)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("sleeper was not woken")
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != Real {
+		t.Error("expected nil to mean the real clock")
+	}
+	fake := NewFake(time.Now())
+	if Or(fake) != fake {
+		t.Error("expected a set clock to be kept")
+	}
+}
@@ -3,12 +3,34 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/richgo/flo/pkg/affected"
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/flags"
+	"github.com/richgo/flo/pkg/freeze"
+	"github.com/richgo/flo/pkg/issues"
+	"github.com/richgo/flo/pkg/policy"
+	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/repos"
+	"github.com/richgo/flo/pkg/sandbox"
+	"github.com/richgo/flo/pkg/secrets"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/telemetry"
+	"github.com/richgo/flo/pkg/webhook"
 	"gopkg.in/yaml.v3"
 )
 
+// CurrentVersion is the config format this version of flo reads and writes.
+const CurrentVersion = 1
+
 // Config represents the feature configuration.
 type Config struct {
 	Feature   string                `yaml:"feature"`
@@ -19,6 +41,403 @@ type Config struct {
 	TDD       TDDConfig             `yaml:"tdd"`
 	Repos     map[string]Repo       `yaml:"repos,omitempty"`
 	TaskTypes map[string]TaskType   `yaml:"taskTypes,omitempty"`
+	Transcripts TranscriptConfig    `yaml:"transcripts,omitempty"`
+	Verify    []VerifyStep          `yaml:"verify,omitempty"`
+	Gates     []GateConfig          `yaml:"gates,omitempty"`
+	Logs      LogsConfig            `yaml:"logs,omitempty"`
+	Context   ContextConfig         `yaml:"context,omitempty"`
+	Spec      SpecConfig            `yaml:"spec,omitempty"`
+	MCP       MCPConfig             `yaml:"mcp,omitempty"`
+	// Quota limits how many requests each backend, or model of a backend,
+	// may make per quota window.
+	Quota QuotaConfig `yaml:"quota,omitempty"`
+	// Pricing maps "backend/model", or "backend" for its other models, to
+	// USD per million tokens, to estimate what agent runs cost: one price
+	// for all tokens, or input, output, cache_read and cache_write prices.
+	Pricing cost.Pricing `yaml:"pricing,omitempty"`
+	// Budget is the most the feature's agent runs may cost in USD; flo
+	// work pauses when a run is projected to exceed it. Zero is unlimited.
+	Budget float64 `yaml:"budget,omitempty"`
+	// Priorities sets default task priorities and how subtasks relate to
+	// their parent's.
+	Priorities PriorityConfig `yaml:"priorities,omitempty"`
+	// Retry tunes how agent backends are retried, by backend name, with
+	// "default" applying to backends not listed.
+	Retry map[string]RetryConfig `yaml:"retry,omitempty"`
+	// RetryBudget caps the retries of all backends in one run, so a
+	// flapping backend can't stretch it out many times over.
+	RetryBudget RetryBudgetConfig `yaml:"retry_budget,omitempty"`
+	// Concurrency caps the agent runs in flight at once across flo
+	// processes, per repo and per backend, so parallel runs don't trip
+	// provider concurrency limits or contend for a git checkout.
+	Concurrency ConcurrencyConfig `yaml:"concurrency,omitempty"`
+	// Hedge also sends quick one-shot requests, such as spec
+	// decomposition, to a fallback backend when the first is slow.
+	Hedge HedgeConfig `yaml:"hedge,omitempty"`
+	// Escalation moves a task to a stronger model when 'flo task retry'
+	// sends it back after failing often enough. Task types may override it.
+	Escalation EscalationConfig `yaml:"escalation,omitempty"`
+	// Features turns experimental subsystems on or off for this workspace
+	// (see 'flo flags list'); FLO_FEATURES overrides it.
+	Features map[string]bool `yaml:"features,omitempty"`
+	// Freeze lists windows in which flo work won't start agent runs, such
+	// as weekends or a release freeze. Times are in Timezone.
+	Freeze []FreezeWindow `yaml:"freeze,omitempty"`
+	// Timezone is the IANA zone used for quota windows and displayed times
+	// (e.g. "Europe/London"). Defaults to the system zone; persisted
+	// timestamps are always UTC.
+	Timezone string `yaml:"timezone,omitempty"`
+	// Secrets declares where secrets such as CLAUDE_API_KEY are resolved
+	// from when the environment and .env files don't set them.
+	Secrets SecretsConfig `yaml:"secrets,omitempty"`
+	// Telemetry exports traces and metrics over OTLP; the standard
+	// OTEL_EXPORTER_OTLP_* variables fill in unset fields.
+	Telemetry telemetry.Config `yaml:"telemetry,omitempty"`
+	// Sandbox runs backend CLIs in a Docker or Podman container with the
+	// worktree mounted, under a network policy and resource limits.
+	Sandbox sandbox.Config `yaml:"sandbox,omitempty"`
+	// Policy denies risky agent operations, or holds them for approval.
+	Policy policy.Config `yaml:"policy,omitempty"`
+	// Webhooks are URLs that task, run and budget events are posted to.
+	Webhooks []WebhookConfig `yaml:"webhooks,omitempty"`
+	// Issues connects issue trackers that tasks are imported from and,
+	// with sync set, whose issues follow their tasks' status.
+	Issues IssuesConfig `yaml:"issues,omitempty"`
+}
+
+// IssuesConfig configures issue trackers.
+type IssuesConfig struct {
+	GitHub *GitHubIssues `yaml:"github,omitempty"`
+	Linear *LinearIssues `yaml:"linear,omitempty"`
+}
+
+// GitHubIssues imports the issues of a GitHub repository.
+type GitHubIssues struct {
+	// Repo is the repository, "org/name".
+	Repo string `yaml:"repo"`
+	// Labels selects issues carrying all of them.
+	Labels []string `yaml:"labels,omitempty"`
+	// TokenEnv names the API token, resolved like CLAUDE_API_KEY (default
+	// GITHUB_TOKEN).
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// URL is the API base URL, for GitHub Enterprise (default
+	// https://api.github.com).
+	URL string `yaml:"url,omitempty"`
+	// Sync closes an issue when its task completes.
+	Sync bool `yaml:"sync,omitempty"`
+}
+
+// LinearIssues imports the issues of a Linear team.
+type LinearIssues struct {
+	// Team is the team key, such as ENG.
+	Team string `yaml:"team"`
+	// Project limits the import to a project, by name.
+	Project string `yaml:"project,omitempty"`
+	// Labels selects issues carrying all of them.
+	Labels []string `yaml:"labels,omitempty"`
+	// TokenEnv names the API key, resolved like CLAUDE_API_KEY (default
+	// LINEAR_API_KEY).
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// URL is the GraphQL endpoint (default https://api.linear.app/graphql).
+	URL string `yaml:"url,omitempty"`
+	// Sync moves an issue through the workflow as its task's status
+	// changes.
+	Sync bool `yaml:"sync,omitempty"`
+	// States names the workflow state each task status (pending,
+	// in_progress, complete, failed) moves an issue to. By default pending,
+	// in_progress and complete move it to the team's first unstarted,
+	// started and completed state.
+	States map[string]string `yaml:"states,omitempty"`
+}
+
+// DefaultLinearTokenEnv names the Linear API key when token_env is unset.
+const DefaultLinearTokenEnv = "LINEAR_API_KEY"
+
+// Token returns the name of the Linear API key.
+func (l *LinearIssues) Token() string {
+	if l.TokenEnv != "" {
+		return l.TokenEnv
+	}
+	return DefaultLinearTokenEnv
+}
+
+// DefaultGitHubTokenEnv names the GitHub token when token_env is unset.
+const DefaultGitHubTokenEnv = "GITHUB_TOKEN"
+
+// Token returns the name of the GitHub API token.
+func (g *GitHubIssues) Token() string {
+	if g.TokenEnv != "" {
+		return g.TokenEnv
+	}
+	return DefaultGitHubTokenEnv
+}
+
+// WebhookConfig is a URL that receives workspace events.
+type WebhookConfig struct {
+	URL string `yaml:"url"`
+	// SecretEnv names the secret deliveries are signed with, resolved like
+	// CLAUDE_API_KEY. Unset leaves them unsigned.
+	SecretEnv string `yaml:"secret_env,omitempty"`
+	// Events limits which events are sent (default all): task_complete,
+	// task_failed, run_complete or budget_alert.
+	Events []string `yaml:"events,omitempty"`
+	// MaxAttempts is how often a delivery is tried (default 3).
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+}
+
+// SecretsConfig declares secret providers.
+type SecretsConfig struct {
+	// Providers are consulted in order; the first that holds a secret
+	// wins. The default is the OS keyring alone.
+	Providers []secrets.ProviderConfig `yaml:"providers,omitempty"`
+}
+
+// MCPConfig bounds the tools served by flo mcp serve. Zero values keep
+// the built-in defaults.
+type MCPConfig struct {
+	Timeout   time.Duration `yaml:"timeout,omitempty"`
+	MaxOutput int           `yaml:"max_output,omitempty"`
+	// MaxConcurrent caps the concurrent calls of each tool (default no cap).
+	MaxConcurrent int `yaml:"max_concurrent,omitempty"`
+	// Tools overrides the limits of individual tools by name.
+	Tools map[string]ToolLimits `yaml:"tools,omitempty"`
+	// Concurrency is how many requests are handled at once.
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// LogLevel is the minimum level of log notifications sent to the
+	// client until it sets its own (default warning).
+	LogLevel string `yaml:"log_level,omitempty"`
+	// IdleTimeout stops the server after this long without requests.
+	IdleTimeout time.Duration `yaml:"idle_timeout,omitempty"`
+	// Keepalive is how often a quiet client is pinged; one that doesn't
+	// answer within the interval is dropped.
+	Keepalive time.Duration `yaml:"keepalive,omitempty"`
+	// Servers are external MCP servers whose tools are served alongside
+	// flo's own, keyed by a name that prefixes their tool names.
+	Servers map[string]ExternalServer `yaml:"servers,omitempty"`
+	// Enable, when set, serves only the tools it matches, and Disable
+	// hides the tools it matches. Both take tool names or globs such as
+	// github_*, matched against the tools' own names.
+	Enable  []string `yaml:"enable,omitempty"`
+	Disable []string `yaml:"disable,omitempty"`
+	// Rename serves tools under other names, keyed by their own name, and
+	// Namespace prefixes every served name as <namespace>.<name>.
+	Rename    map[string]string `yaml:"rename,omitempty"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	// RunCommand enables the run_command tool.
+	RunCommand RunCommandConfig `yaml:"run_command,omitempty"`
+	// Git configures the git_status, git_diff and git_commit tools.
+	Git GitToolsConfig `yaml:"git,omitempty"`
+}
+
+// RunCommandConfig gates the commands the run_command tool runs in the
+// task worktree. The tool is served only when Allow is set.
+type RunCommandConfig struct {
+	// Allow are regular expressions of the command lines agents may run,
+	// such as '^go (build|test|vet) '.
+	Allow []string `yaml:"allow,omitempty"`
+	// Deny are regular expressions of command lines refused even when
+	// allowed, on top of policy.deny_commands.
+	Deny []string `yaml:"deny,omitempty"`
+}
+
+// GitToolsConfig configures the git MCP tools.
+type GitToolsConfig struct {
+	// CommitTypes are the conventional commit types git_commit accepts,
+	// by default feat, fix, docs, style, refactor, perf, test, build, ci,
+	// chore and revert.
+	CommitTypes []string `yaml:"commit_types,omitempty"`
+}
+
+// commitTypePattern matches conventional commit types.
+var commitTypePattern = regexp.MustCompile(`^[a-z]+$`)
+
+// toolNamePattern matches the names tools can be served under.
+var toolNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// serverNamePattern matches external server names, which must be usable
+// in tool names.
+var serverNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// ExternalServer is an MCP server that flo connects to, either by starting
+// Command or at URL. ${VAR} in Env values is expanded from the environment
+// and .flo/.env.
+type ExternalServer struct {
+	Command []string          `yaml:"command,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty"`
+	URL     string            `yaml:"url,omitempty"`
+	// TokenEnv names the variable holding the bearer token for URL.
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// Tools limits which of the server's tools are served (default all).
+	Tools []string `yaml:"tools,omitempty"`
+}
+
+// RetryConfig tunes retries of an agent backend's start and session
+// creation. Zero values keep the built-in defaults.
+type RetryConfig struct {
+	// Strategy is the backoff strategy: exponential (default),
+	// exponential-jitter, fibonacci, fixed or decorrelated.
+	Strategy       string        `yaml:"strategy,omitempty"`
+	MaxRetries     int           `yaml:"max_retries,omitempty"`
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty"`
+	MaxBackoff     time.Duration `yaml:"max_backoff,omitempty"`
+	// Factor is the growth of exponential backoffs.
+	Factor float64 `yaml:"factor,omitempty"`
+	// Jitter shortens each backoff by a random fraction of up to this much
+	// (default 0.2); 0 makes backoffs exact.
+	Jitter *float64 `yaml:"jitter,omitempty"`
+	// MaxRetryAfter is the longest wait a rate-limiting provider may ask
+	// for and still be retried (default 2m); longer waits fail over.
+	MaxRetryAfter time.Duration `yaml:"max_retry_after,omitempty"`
+}
+
+// RetryBudgetConfig caps retries across a run. Zero values are unlimited.
+type RetryBudgetConfig struct {
+	// MaxRetries is the most retries a run may make in total.
+	MaxRetries int `yaml:"max_retries,omitempty"`
+	// MaxTime is the most time a run may spend waiting to retry.
+	MaxTime time.Duration `yaml:"max_time,omitempty"`
+}
+
+// ConcurrencyConfig caps the agent runs in flight at once. Zero values
+// are unlimited.
+type ConcurrencyConfig struct {
+	// PerRepo is the most runs at once in each repo, the workspace's own
+	// included.
+	PerRepo int `yaml:"per_repo,omitempty"`
+	// Repos overrides PerRepo for repos by name.
+	Repos map[string]int `yaml:"repos,omitempty"`
+	// Backends is the most sessions at once on each backend, by name;
+	// "default" applies to backends not listed.
+	Backends map[string]int `yaml:"backends,omitempty"`
+}
+
+// RepoLimit returns the most runs at once in a repo, "" for the
+// workspace's own, or zero for no cap.
+func (c ConcurrencyConfig) RepoLimit(repo string) int {
+	if n, ok := c.Repos[repo]; ok && repo != "" {
+		return n
+	}
+	return c.PerRepo
+}
+
+// BackendLimit returns the most sessions at once on a backend, or zero for
+// no cap.
+func (c ConcurrencyConfig) BackendLimit(backend string) int {
+	if n, ok := c.Backends[backend]; ok {
+		return n
+	}
+	return c.Backends["default"]
+}
+
+// DefaultHedgeDelay is how long a request runs before it is hedged.
+const DefaultHedgeDelay = 20 * time.Second
+
+// HedgeConfig sets how quick requests are hedged.
+type HedgeConfig struct {
+	// Fallback is the backend, or backend/model, to hedge to. Empty turns
+	// hedging off.
+	Fallback string `yaml:"fallback,omitempty"`
+	// Delay is how long the first request runs before the fallback is
+	// sent too (default 20s).
+	Delay time.Duration `yaml:"delay,omitempty"`
+}
+
+// RetryFor returns the retry settings for a backend, falling back to the
+// "default" entry, and whether any apply.
+func (c *Config) RetryFor(backend string) (RetryConfig, bool) {
+	if r, ok := c.Retry[backend]; ok {
+		return r, true
+	}
+	r, ok := c.Retry["default"]
+	return r, ok
+}
+
+// EscalationConfig sets when a retried task moves to a stronger model.
+type EscalationConfig struct {
+	// After is how many times a task must have failed before a retry
+	// escalates it. Zero never escalates.
+	After int `yaml:"after,omitempty"`
+	// Model is the model escalated to, as "backend/model".
+	Model string `yaml:"model,omitempty"`
+}
+
+// EscalationFor returns the escalation settings for a task type, falling
+// back to the workspace's, and whether retries escalate at all.
+func (c *Config) EscalationFor(taskType string) (EscalationConfig, bool) {
+	e := c.Escalation
+	if tt, ok := c.TaskTypes[taskType]; ok && tt.Escalation != nil {
+		e = *tt.Escalation
+	}
+	return e, e.After > 0 && e.Model != ""
+}
+
+// ToolLimits bounds a single MCP tool.
+type ToolLimits struct {
+	Timeout       time.Duration `yaml:"timeout,omitempty"`
+	MaxOutput     int           `yaml:"max_output,omitempty"`
+	MaxConcurrent int           `yaml:"max_concurrent,omitempty"`
+}
+
+// SpecConfig controls SPEC.md validation.
+type SpecConfig struct {
+	// RequiredSections lists the headings every spec must contain. Defaults
+	// to Goal, Context and Success Criteria; the SPEC.md template generated
+	// by 'flo init' uses the same list.
+	RequiredSections []string `yaml:"required_sections,omitempty"`
+}
+
+// ContextConfig controls which repository files are packed into agent prompts.
+type ContextConfig struct {
+	// Disable turns off automatic context packing.
+	Disable bool `yaml:"disable,omitempty"`
+	// MaxTokens is the approximate token budget for file contents (default 8000).
+	MaxTokens int `yaml:"max_tokens,omitempty"`
+	// Include lists glob patterns always considered relevant.
+	Include []string `yaml:"include,omitempty"`
+	// Exclude lists glob patterns never packed into the prompt.
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// LogsConfig holds display policy for transcripts and audit logs.
+type LogsConfig struct {
+	// AllowRaw permits --raw output that bypasses secret redaction.
+	AllowRaw bool `yaml:"allow_raw,omitempty"`
+	// TamperEvident hash-chains audit events, with checkpoints signed with
+	// the workspace key, so 'flo audit verify' can detect edits, removals
+	// and truncation.
+	TamperEvident bool `yaml:"tamper_evident,omitempty"`
+}
+
+// VerifyStep is a command run in the worktree after an agent run.
+// Any failing step blocks task completion.
+type VerifyStep struct {
+	Name            string        `yaml:"name"`
+	Command         string        `yaml:"command"`
+	Timeout         time.Duration `yaml:"timeout,omitempty"`
+	ContinueOnError bool          `yaml:"continue_on_error,omitempty"`
+}
+
+// GateConfig configures a custom completion gate, such as a compliance
+// scanner plugin. Gates run in the worktree after the verify pipeline.
+type GateConfig struct {
+	Name string `yaml:"name"`
+	// Kind selects the gate implementation (default "exec").
+	Kind string `yaml:"kind,omitempty"`
+	// Plugin is the executable for exec gates: a path, or a name resolved
+	// as .flo/plugins/<name>, then flo-gate-<name> or <name> on PATH.
+	Plugin string   `yaml:"plugin,omitempty"`
+	Args   []string `yaml:"args,omitempty"`
+	// FailOn is the lowest finding severity that fails the gate
+	// (info, warning or error; default error).
+	FailOn  string            `yaml:"fail_on,omitempty"`
+	Timeout time.Duration     `yaml:"timeout,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// TranscriptConfig holds settings for stored run transcripts.
+type TranscriptConfig struct {
+	// Encrypt seals transcripts and prompts with the workspace key.
+	Encrypt bool `yaml:"encrypt,omitempty"`
 }
 
 // ClaudeConfig holds Claude-specific settings.
@@ -47,26 +466,88 @@ type TDDConfig struct {
 	Enforce           bool   `yaml:"enforce"`
 	TestCommand       string `yaml:"test_command,omitempty"`
 	CoverageThreshold int    `yaml:"coverage_threshold,omitempty"`
+	// CoverageProfile is a coverage report written by TestCommand
+	// (go coverprofile, lcov, or cobertura), relative to the worktree.
+	CoverageProfile string `yaml:"coverage_profile,omitempty"`
+	// Affected ("go" or "bazel") runs only the tests affected by the
+	// change, replacing ./... or //... in TestCommand with the selected
+	// packages or targets.
+	Affected string `yaml:"affected,omitempty"`
+	// AffectedBase is the git ref the working tree is compared with to
+	// find the change (default HEAD).
+	AffectedBase string `yaml:"affected_base,omitempty"`
 }
 
 // Repo represents a linked repository.
 type Repo struct {
 	URL    string `yaml:"url"`
 	Branch string `yaml:"branch,omitempty"`
-	Path   string `yaml:"path,omitempty"`
+	// Path is where the repository is checked out, relative to the
+	// workspace root unless absolute (default .flo/repos/<name>).
+	Path string `yaml:"path,omitempty"`
 }
 
 // TaskType represents configuration for a task type.
 type TaskType struct {
 	Model    string `yaml:"model"`
 	Thinking string `yaml:"thinking,omitempty"`
+	// Prompt names the template in .flo/prompts used for this task type.
+	Prompt string `yaml:"prompt,omitempty"`
+	// Verify overrides the workspace verify pipeline for this task type.
+	Verify []VerifyStep `yaml:"verify,omitempty"`
+	// Gates overrides the workspace custom gates for this task type.
+	Gates []GateConfig `yaml:"gates,omitempty"`
+	// Priority is the default priority of tasks of this type.
+	Priority *int `yaml:"priority,omitempty"`
+	// Escalation overrides the workspace escalation for this task type.
+	Escalation *EscalationConfig `yaml:"escalation,omitempty"`
+	// Approval set to "required" holds the agent's changes for review
+	// after each run; the task completes only once a reviewer approves.
+	Approval string `yaml:"approval,omitempty"`
+}
+
+// ApprovalRequired is the TaskType approval setting that holds changes
+// for review.
+const ApprovalRequired = "required"
+
+// QuotaConfig sets request limits per quota window.
+type QuotaConfig struct {
+	// Limits maps a backend ("claude") or one of its models
+	// ("claude/opus") to the requests it may make per window. A model's
+	// requests also count towards its backend's limit.
+	Limits map[string]int `yaml:"limits,omitempty"`
+	// Windows maps a backend or model to how its window resets: daily,
+	// weekly or monthly at midnight UTC, or a rolling duration such as
+	// "5h". The default is a rolling hour.
+	Windows map[string]string `yaml:"windows,omitempty"`
+}
+
+// FreezeWindow is a period in which agent runs are not started: weekly
+// ("Fri 18:00" to "Mon 06:00") or between dates ("2026-12-20" to
+// "2027-01-02", both included).
+type FreezeWindow struct {
+	Name string `yaml:"name"`
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// PriorityConfig sets default task priorities (0 is the highest).
+type PriorityConfig struct {
+	// Default is the priority of new tasks whose type sets none.
+	Default int `yaml:"default,omitempty"`
+	// Inherit gives new subtasks their parent's priority instead of the
+	// default.
+	Inherit bool `yaml:"inherit,omitempty"`
+	// Cap stops a subtask from being scheduled ahead of its parent: its
+	// effective priority is never higher than the parent's.
+	Cap bool `yaml:"cap,omitempty"`
 }
 
 // New creates a new Config with default values.
 func New(feature string) *Config {
 	return &Config{
 		Feature: feature,
-		Version: 1,
+		Version: CurrentVersion,
 		Backend: "claude",
 		TDD: TDDConfig{
 			Enforce:     true,
@@ -154,9 +635,363 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("backend must be 'claude' or 'copilot', got '%s'", c.Backend)
 	}
 
+	if _, err := c.Location(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, section := range c.Spec.RequiredSections {
+		key := strings.ToLower(strings.TrimSpace(section))
+		if key == "" {
+			return fmt.Errorf("spec.required_sections contains an empty section")
+		}
+		if seen[key] {
+			return fmt.Errorf("spec.required_sections lists '%s' more than once", section)
+		}
+		seen[key] = true
+	}
+
+	if c.MCP.Concurrency < 0 {
+		return fmt.Errorf("mcp.concurrency must not be negative")
+	}
+	if c.MCP.MaxConcurrent < 0 {
+		return fmt.Errorf("mcp.max_concurrent must not be negative")
+	}
+	for name, l := range c.MCP.Tools {
+		if l.Timeout < 0 || l.MaxOutput < 0 || l.MaxConcurrent < 0 {
+			return fmt.Errorf("mcp.tools.%s: limits must not be negative", name)
+		}
+	}
+	if c.MCP.IdleTimeout < 0 || c.MCP.Keepalive < 0 {
+		return fmt.Errorf("mcp.idle_timeout and mcp.keepalive must not be negative")
+	}
+	for name, srv := range c.MCP.Servers {
+		if !serverNamePattern.MatchString(name) {
+			return fmt.Errorf("mcp.servers.%s: name must be lowercase letters, digits, '-' or '_'", name)
+		}
+		if (len(srv.Command) > 0) == (srv.URL != "") {
+			return fmt.Errorf("mcp.servers.%s: set exactly one of command or url", name)
+		}
+		if srv.TokenEnv != "" && srv.URL == "" {
+			return fmt.Errorf("mcp.servers.%s: token_env needs url", name)
+		}
+	}
+	for _, pattern := range append(append([]string{}, c.MCP.Enable...), c.MCP.Disable...) {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("mcp.enable and mcp.disable: invalid tool pattern '%s'", pattern)
+		}
+	}
+	sources := make([]string, 0, len(c.MCP.Rename))
+	for name := range c.MCP.Rename {
+		sources = append(sources, name)
+	}
+	sort.Strings(sources)
+	renamed := make(map[string]string)
+	for _, name := range sources {
+		to := c.MCP.Rename[name]
+		if !toolNamePattern.MatchString(to) {
+			return fmt.Errorf("mcp.rename.%s: name must be letters, digits, '-' or '_'", name)
+		}
+		if other, ok := renamed[to]; ok {
+			return fmt.Errorf("mcp.rename: %s and %s are both renamed to %s", other, name, to)
+		}
+		renamed[to] = name
+	}
+	if c.MCP.Namespace != "" && !toolNamePattern.MatchString(c.MCP.Namespace) {
+		return fmt.Errorf("mcp.namespace must be letters, digits, '-' or '_'")
+	}
+	for field, exprs := range map[string][]string{"allow": c.MCP.RunCommand.Allow, "deny": c.MCP.RunCommand.Deny} {
+		for _, expr := range exprs {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("mcp.run_command.%s: invalid pattern '%s': %w", field, expr, err)
+			}
+		}
+	}
+	for _, typ := range c.MCP.Git.CommitTypes {
+		if !commitTypePattern.MatchString(typ) {
+			return fmt.Errorf("mcp.git.commit_types: '%s' must be lowercase letters", typ)
+		}
+	}
+	switch c.MCP.LogLevel {
+	case "", "debug", "info", "notice", "warning", "error", "critical", "alert", "emergency":
+	default:
+		return fmt.Errorf("mcp.log_level must be one of debug, info, notice, warning, error, critical, alert, emergency")
+	}
+
+	if c.TDD.Affected != "" {
+		pattern, ok := affected.Pattern(c.TDD.Affected)
+		if !ok {
+			return fmt.Errorf("tdd.affected must be go or bazel")
+		}
+		if !strings.Contains(c.TDD.TestCommand, pattern) {
+			return fmt.Errorf("tdd.affected is %s but tdd.test_command has no %s to replace", c.TDD.Affected, pattern)
+		}
+	}
+
+	for key, limit := range c.Quota.Limits {
+		if !validModelKey(key) {
+			return fmt.Errorf("quota.limits.%s: key must be a backend or backend/model", key)
+		}
+		if limit <= 0 {
+			return fmt.Errorf("quota.limits.%s must be positive", key)
+		}
+	}
+	for key, window := range c.Quota.Windows {
+		if !validModelKey(key) {
+			return fmt.Errorf("quota.windows.%s: key must be a backend or backend/model", key)
+		}
+		if _, err := quota.ParseWindow(window); err != nil {
+			return fmt.Errorf("quota.windows.%s: %w", key, err)
+		}
+	}
+	for key, price := range c.Pricing {
+		if !validModelKey(key) {
+			return fmt.Errorf("pricing.%s: key must be a backend or backend/model", key)
+		}
+		if price.Negative() {
+			return fmt.Errorf("pricing.%s must not be negative", key)
+		}
+	}
+	if c.Budget < 0 {
+		return fmt.Errorf("budget must not be negative")
+	}
+	for i, p := range c.Secrets.Providers {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("secrets.providers[%d]: %w", i, err)
+		}
+	}
+	if err := c.Telemetry.Validate(); err != nil {
+		return fmt.Errorf("telemetry: %w", err)
+	}
+	if err := c.Sandbox.Validate(); err != nil {
+		return fmt.Errorf("sandbox: %w", err)
+	}
+	if err := c.Policy.Validate(); err != nil {
+		return fmt.Errorf("policy: %w", err)
+	}
+
+	if _, err := c.FreezeSchedule(); err != nil {
+		return err
+	}
+
+	if c.Priorities.Default < 0 {
+		return fmt.Errorf("priorities.default must not be negative")
+	}
+	for name, tt := range c.TaskTypes {
+		if tt.Priority != nil && *tt.Priority < 0 {
+			return fmt.Errorf("taskTypes.%s.priority must not be negative", name)
+		}
+		if tt.Approval != "" && tt.Approval != ApprovalRequired {
+			return fmt.Errorf("taskTypes.%s.approval must be '%s', got '%s'", name, ApprovalRequired, tt.Approval)
+		}
+	}
+
+	for name, r := range c.Repos {
+		if !repos.ValidName(name) {
+			return fmt.Errorf("repos: invalid repo name '%s'", name)
+		}
+		if r.URL == "" {
+			return fmt.Errorf("repos.%s.url is required", name)
+		}
+	}
+
+	for name, r := range c.Retry {
+		if r.MaxRetries < 0 || r.InitialBackoff < 0 || r.MaxBackoff < 0 || r.Factor < 0 || r.MaxRetryAfter < 0 {
+			return fmt.Errorf("retry.%s: settings must not be negative", name)
+		}
+		if r.Jitter != nil && (*r.Jitter < 0 || *r.Jitter > 1) {
+			return fmt.Errorf("retry.%s.jitter must be between 0 and 1", name)
+		}
+	}
+	if c.RetryBudget.MaxRetries < 0 || c.RetryBudget.MaxTime < 0 {
+		return fmt.Errorf("retry_budget: settings must not be negative")
+	}
+	if c.Concurrency.PerRepo < 0 {
+		return fmt.Errorf("concurrency.per_repo must not be negative")
+	}
+	for name, n := range c.Concurrency.Repos {
+		if n < 0 {
+			return fmt.Errorf("concurrency.repos.%s must not be negative", name)
+		}
+	}
+	for name, n := range c.Concurrency.Backends {
+		if n < 0 {
+			return fmt.Errorf("concurrency.backends.%s must not be negative", name)
+		}
+	}
+	if c.Hedge.Delay < 0 {
+		return fmt.Errorf("hedge.delay must not be negative")
+	}
+	if c.Hedge.Fallback != "" && strings.HasPrefix(c.Hedge.Fallback, "/") {
+		return fmt.Errorf("hedge.fallback must be backend or backend/model, got '%s'", c.Hedge.Fallback)
+	}
+
+	if err := validateEscalation("escalation", c.Escalation); err != nil {
+		return err
+	}
+	for name, tt := range c.TaskTypes {
+		if tt.Escalation == nil {
+			continue
+		}
+		if err := validateEscalation("taskTypes."+name+".escalation", *tt.Escalation); err != nil {
+			return err
+		}
+	}
+
+	for i, w := range c.Webhooks {
+		if err := validateWebhook(w); err != nil {
+			return fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+	}
+
+	if g := c.Issues.GitHub; g != nil {
+		if !issues.ValidRepo(g.Repo) {
+			return fmt.Errorf("issues.github.repo must be org/name, got '%s'", g.Repo)
+		}
+		if g.URL != "" {
+			if u, err := url.Parse(g.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("issues.github.url must be an http or https URL, got '%s'", g.URL)
+			}
+		}
+	}
+
+	if l := c.Issues.Linear; l != nil {
+		if l.Team == "" {
+			return fmt.Errorf("issues.linear.team is required")
+		}
+		if l.URL != "" {
+			if u, err := url.Parse(l.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("issues.linear.url must be an http or https URL, got '%s'", l.URL)
+			}
+		}
+		for status := range l.States {
+			if !task.Status(status).IsValid() {
+				return fmt.Errorf("issues.linear.states: unknown task status '%s'", status)
+			}
+		}
+	}
+
+	features := make([]string, 0, len(c.Features))
+	for name := range c.Features {
+		features = append(features, name)
+	}
+	sort.Strings(features)
+	if err := flags.Validate(features); err != nil {
+		return fmt.Errorf("features: %w", err)
+	}
+
+	if err := validateGates("gates", c.Gates); err != nil {
+		return err
+	}
+	for name, tt := range c.TaskTypes {
+		if err := validateGates("taskTypes."+name+".gates", tt.Gates); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateEscalation checks that an escalation names a "backend/model" to
+// escalate to after a positive number of failures.
+func validateEscalation(field string, e EscalationConfig) error {
+	if e.After < 0 {
+		return fmt.Errorf("%s.after must not be negative", field)
+	}
+	if e.After > 0 && e.Model == "" {
+		return fmt.Errorf("%s.model is required with after", field)
+	}
+	if e.Model != "" && len(strings.Split(e.Model, "/")) != 2 {
+		return fmt.Errorf("%s.model must be backend/model, got '%s'", field, e.Model)
+	}
+	return nil
+}
+
+// validateWebhook checks that a webhook posts to an http(s) URL and names
+// known events.
+func validateWebhook(w WebhookConfig) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https URL, got '%s'", w.URL)
+	}
+	known := make(map[string]bool)
+	for _, event := range webhook.Events {
+		known[event] = true
+	}
+	for _, event := range w.Events {
+		if !known[event] {
+			return fmt.Errorf("unknown event '%s' (want %s)", event, strings.Join(webhook.Events, ", "))
+		}
+	}
+	if w.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts must not be negative")
+	}
+	return nil
+}
+
+// validateGates checks that gate names are set and unique and that the
+// fail_on severity is known. Kinds are checked when the gates are built.
+func validateGates(field string, gates []GateConfig) error {
+	seen := make(map[string]bool)
+	for _, g := range gates {
+		if g.Name == "" {
+			return fmt.Errorf("%s: every gate needs a name", field)
+		}
+		if seen[g.Name] {
+			return fmt.Errorf("%s: gate '%s' is defined more than once", field, g.Name)
+		}
+		seen[g.Name] = true
+		switch strings.ToLower(g.FailOn) {
+		case "", "info", "warning", "error":
+		default:
+			return fmt.Errorf("%s: gate '%s' has unknown fail_on '%s' (want info, warning or error)", field, g.Name, g.FailOn)
+		}
+	}
 	return nil
 }
 
+// Location returns the configured timezone, or the system zone when unset.
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone '%s': %w", c.Timezone, err)
+	}
+	return loc, nil
+}
+
+// validModelKey reports whether key is "backend" or "backend/model".
+func validModelKey(key string) bool {
+	backend, model, hasModel := strings.Cut(key, "/")
+	return backend != "" && (!hasModel || (model != "" && !strings.Contains(model, "/")))
+}
+
+// FreezeSchedule parses the freeze windows in the config's timezone.
+func (c *Config) FreezeSchedule() (freeze.Schedule, error) {
+	if len(c.Freeze) == 0 {
+		return nil, nil
+	}
+	loc, err := c.Location()
+	if err != nil {
+		return nil, err
+	}
+	schedule := make(freeze.Schedule, 0, len(c.Freeze))
+	for i, fw := range c.Freeze {
+		name := fw.Name
+		if name == "" {
+			name = fmt.Sprintf("freeze[%d]", i)
+		}
+		w, err := freeze.Parse(name, fw.From, fw.To, loc)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, w)
+	}
+	return schedule, nil
+}
+
 // Load reads a config from a YAML file.
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -198,7 +1033,7 @@ func (c *Config) Save(path string) error {
 // applyDefaults sets default values for optional fields.
 func (c *Config) applyDefaults() {
 	if c.Version == 0 {
-		c.Version = 1
+		c.Version = CurrentVersion
 	}
 	if c.Backend == "" {
 		c.Backend = "claude"
@@ -227,6 +1062,30 @@ func (c *Config) GetBackendConfig() any {
 	}
 }
 
+// VerifySteps returns the verification pipeline for a task type,
+// falling back to the workspace-wide pipeline.
+func (c *Config) VerifySteps(taskType string) []VerifyStep {
+	if tt, ok := c.TaskTypes[taskType]; ok && len(tt.Verify) > 0 {
+		return tt.Verify
+	}
+	return c.Verify
+}
+
+// GateConfigs returns the custom gates for a task type, falling back to
+// the workspace-wide gates.
+func (c *Config) GateConfigs(taskType string) []GateConfig {
+	if tt, ok := c.TaskTypes[taskType]; ok && len(tt.Gates) > 0 {
+		return tt.Gates
+	}
+	return c.Gates
+}
+
+// ReviewRequired reports whether a task type's changes need a reviewer's
+// approval before the task completes.
+func (c *Config) ReviewRequired(taskType string) bool {
+	return c.TaskTypes[taskType].Approval == ApprovalRequired
+}
+
 // DefaultConfigPath returns the default config path for a directory.
 func DefaultConfigPath(dir string) string {
 	return filepath.Join(dir, ".flo", "config.yaml")
@@ -3,7 +3,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/cost"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -57,6 +61,168 @@ func TestConfigValidation(t *testing.T) {
 			config:  &Config{Feature: "test", Backend: "copilot"},
 			wantErr: false,
 		},
+		{
+			name:    "known feature flag",
+			config:  &Config{Feature: "test", Backend: "claude", Features: map[string]bool{"auto_merge": true}},
+			wantErr: false,
+		},
+		{
+			name:    "unknown feature flag",
+			config:  &Config{Feature: "test", Backend: "claude", Features: map[string]bool{"warp_drive": true}},
+			wantErr: true,
+			errMsg:  "features",
+		},
+		{
+			name: "external MCP servers",
+			config: &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{Servers: map[string]ExternalServer{
+				"github": {Command: []string{"github-mcp"}, Env: map[string]string{"GITHUB_TOKEN": "${GITHUB_TOKEN}"}},
+				"db":     {URL: "http://127.0.0.1:9000/mcp", TokenEnv: "DB_TOKEN"},
+			}}},
+			wantErr: false,
+		},
+		{
+			name: "external MCP server with command and url",
+			config: &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{Servers: map[string]ExternalServer{
+				"both": {Command: []string{"x"}, URL: "http://127.0.0.1/mcp"},
+			}}},
+			wantErr: true,
+			errMsg:  "exactly one of command or url",
+		},
+		{
+			name: "external MCP server with bad name",
+			config: &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{Servers: map[string]ExternalServer{
+				"Git Hub": {Command: []string{"x"}},
+			}}},
+			wantErr: true,
+			errMsg:  "mcp.servers.Git Hub",
+		},
+		{
+			name: "MCP tool exposure",
+			config: &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{
+				Enable:    []string{"eas_*", "github_search"},
+				Disable:   []string{"eas_task_create"},
+				Rename:    map[string]string{"eas_task_list": "task_list"},
+				Namespace: "flo",
+			}},
+			wantErr: false,
+		},
+		{
+			name:    "bad MCP tool pattern",
+			config:  &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{Disable: []string{"eas_[task"}}},
+			wantErr: true,
+			errMsg:  "invalid tool pattern",
+		},
+		{
+			name: "MCP tools renamed to the same name",
+			config: &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{Rename: map[string]string{
+				"eas_task_list": "list",
+				"github_list":   "list",
+			}}},
+			wantErr: true,
+			errMsg:  "both renamed to list",
+		},
+		{
+			name: "run_command rules",
+			config: &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{RunCommand: RunCommandConfig{
+				Allow: []string{`^go (build|test|vet)( |$)`},
+				Deny:  []string{`-exec`},
+			}}},
+			wantErr: false,
+		},
+		{
+			name:    "bad run_command pattern",
+			config:  &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{RunCommand: RunCommandConfig{Allow: []string{"^go (test"}}}},
+			wantErr: true,
+			errMsg:  "mcp.run_command.allow",
+		},
+		{
+			name:    "bad commit type",
+			config:  &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{Git: GitToolsConfig{CommitTypes: []string{"feat", "Hotfix"}}}},
+			wantErr: true,
+			errMsg:  "mcp.git.commit_types",
+		},
+		{
+			name:    "bad MCP namespace",
+			config:  &Config{Feature: "test", Backend: "claude", MCP: MCPConfig{Namespace: "flo.tools"}},
+			wantErr: true,
+			errMsg:  "mcp.namespace",
+		},
+		{
+			name:    "quota limits",
+			config:  &Config{Feature: "test", Backend: "claude", Quota: QuotaConfig{Limits: map[string]int{"claude": 50, "claude/opus": 10}}},
+			wantErr: false,
+		},
+		{
+			name:    "quota limit with bad key",
+			config:  &Config{Feature: "test", Backend: "claude", Quota: QuotaConfig{Limits: map[string]int{"claude/": 10}}},
+			wantErr: true,
+			errMsg:  "quota.limits",
+		},
+		{
+			name:    "non-positive quota limit",
+			config:  &Config{Feature: "test", Backend: "claude", Quota: QuotaConfig{Limits: map[string]int{"claude/opus": 0}}},
+			wantErr: true,
+			errMsg:  "must be positive",
+		},
+		{
+			name:    "quota windows",
+			config:  &Config{Feature: "test", Backend: "claude", Quota: QuotaConfig{Windows: map[string]string{"claude": "daily", "copilot/gpt-4o": "5h"}}},
+			wantErr: false,
+		},
+		{
+			name:    "unknown quota window",
+			config:  &Config{Feature: "test", Backend: "claude", Quota: QuotaConfig{Windows: map[string]string{"claude": "yearly"}}},
+			wantErr: true,
+			errMsg:  "quota.windows.claude",
+		},
+		{
+			name: "freeze windows",
+			config: &Config{Feature: "test", Backend: "claude", Freeze: []FreezeWindow{
+				{Name: "weekend", From: "Fri 18:00", To: "Mon 06:00"},
+				{Name: "release", From: "2026-12-20", To: "2027-01-02"},
+			}},
+			wantErr: false,
+		},
+		{
+			name:    "invalid freeze window",
+			config:  &Config{Feature: "test", Backend: "claude", Freeze: []FreezeWindow{{Name: "weekend", From: "Friday", To: "Mon 06:00"}}},
+			wantErr: true,
+			errMsg:  "weekend",
+		},
+		{
+			name:    "pricing and budget",
+			config:  &Config{Feature: "test", Backend: "claude", Pricing: cost.Pricing{"claude": {Input: 3}, "claude/opus": {Input: 15, Output: 75}}, Budget: 50},
+			wantErr: false,
+		},
+		{
+			name:    "negative price",
+			config:  &Config{Feature: "test", Backend: "claude", Pricing: cost.Pricing{"claude/opus": {Input: 15, Output: -1}}},
+			wantErr: true,
+			errMsg:  "pricing.claude/opus",
+		},
+		{
+			name:    "affected go tests",
+			config:  &Config{Feature: "test", Backend: "claude", TDD: TDDConfig{TestCommand: "go test -cover ./...", Affected: "go"}},
+			wantErr: false,
+		},
+		{
+			name:    "affected bazel tests without a pattern",
+			config:  &Config{Feature: "test", Backend: "claude", TDD: TDDConfig{TestCommand: "bazel test //app:all", Affected: "bazel"}},
+			wantErr: true,
+			errMsg:  "has no //...",
+		},
+		{
+			name:    "unknown affected kind",
+			config:  &Config{Feature: "test", Backend: "claude", TDD: TDDConfig{TestCommand: "make test", Affected: "make"}},
+			wantErr: true,
+			errMsg:  "tdd.affected",
+		},
+		{
+			name:    "negative default priority",
+			config:  &Config{Feature: "test", Backend: "claude", Priorities: PriorityConfig{Default: -1}},
+			wantErr: true,
+			errMsg:  "priorities.default",
+		},
 	}
 
 	for _, tt := range tests {
@@ -299,3 +465,459 @@ func TestConfigTaskTypesPersistence(t *testing.T) {
 		t.Errorf("custom type thinking mismatch: got %q", customType.Thinking)
 	}
 }
+
+func TestConfigVerifySteps(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "config.yaml")
+
+	content := `feature: verify
+backend: claude
+verify:
+  - name: lint
+    command: golangci-lint run
+    timeout: 2m
+  - name: build
+    command: go build ./...
+taskTypes:
+  docs:
+    model: claude/haiku
+    verify:
+      - name: markdown
+        command: markdownlint .
+`
+	os.WriteFile(path, []byte(content), 0644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	steps := cfg.VerifySteps("build")
+	if len(steps) != 2 {
+		t.Fatalf("expected workspace pipeline with 2 steps, got %d", len(steps))
+	}
+	if steps[0].Timeout != 2*time.Minute {
+		t.Errorf("expected 2m timeout, got %v", steps[0].Timeout)
+	}
+
+	docs := cfg.VerifySteps("docs")
+	if len(docs) != 1 || docs[0].Name != "markdown" {
+		t.Errorf("expected task type override, got %+v", docs)
+	}
+}
+
+func TestConfigGates(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "config.yaml")
+
+	content := `feature: gates
+backend: claude
+gates:
+  - name: compliance
+    plugin: acme-scan
+    fail_on: warning
+    timeout: 1m
+    options:
+      policy: strict
+taskTypes:
+  docs:
+    model: claude/haiku
+    gates:
+      - name: spelling
+        plugin: ./tools/spell.sh
+`
+	os.WriteFile(path, []byte(content), 0644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	gates := cfg.GateConfigs("build")
+	if len(gates) != 1 || gates[0].Plugin != "acme-scan" {
+		t.Fatalf("expected workspace gate, got %+v", gates)
+	}
+	if gates[0].Timeout != time.Minute || gates[0].Options["policy"] != "strict" {
+		t.Errorf("gate settings not loaded: %+v", gates[0])
+	}
+	if docs := cfg.GateConfigs("docs"); len(docs) != 1 || docs[0].Name != "spelling" {
+		t.Errorf("expected task type override, got %+v", docs)
+	}
+
+	cfg.Gates = append(cfg.Gates, GateConfig{Name: "compliance", Plugin: "other"})
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected duplicate gate names to be rejected")
+	}
+	cfg.Gates = []GateConfig{{Name: "scan", Plugin: "scan", FailOn: "critical"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected unknown fail_on to be rejected")
+	}
+}
+
+func TestConfigLocation(t *testing.T) {
+	cfg := New("test")
+	if loc, err := cfg.Location(); err != nil || loc != time.Local {
+		t.Errorf("expected system zone by default, got %v (%v)", loc, err)
+	}
+
+	cfg.Timezone = "UTC"
+	if loc, err := cfg.Location(); err != nil || loc.String() != "UTC" {
+		t.Errorf("expected UTC, got %v (%v)", loc, err)
+	}
+
+	cfg.Timezone = "Mars/Olympus_Mons"
+	if _, err := cfg.Location(); err == nil {
+		t.Error("expected error for unknown timezone")
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected Validate to reject unknown timezone")
+	}
+}
+
+func TestConfigSpecRequiredSections(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(path, []byte("feature: test\nbackend: claude\nspec:\n  required_sections: [Overview, User Stories]\n"), 0644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Spec.RequiredSections) != 2 || cfg.Spec.RequiredSections[1] != "User Stories" {
+		t.Errorf("unexpected required sections: %v", cfg.Spec.RequiredSections)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	cfg.Spec.RequiredSections = []string{"Overview", "overview"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected duplicate section to be rejected")
+	}
+	cfg.Spec.RequiredSections = []string{" "}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected empty section to be rejected")
+	}
+}
+
+func TestConfigSecretProviders(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(path, []byte(`feature: test
+backend: claude
+secrets:
+  providers:
+    - type: vault
+      address: https://vault.example.com
+      secrets:
+        CLAUDE_API_KEY: secret/flo#claude
+    - type: keyring
+`), 0644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Secrets.Providers) != 2 || cfg.Secrets.Providers[0].Secrets["CLAUDE_API_KEY"] != "secret/flo#claude" {
+		t.Errorf("unexpected providers: %+v", cfg.Secrets.Providers)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	cfg.Secrets.Providers[1].Type = "lastpass"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unknown provider type to be rejected")
+	}
+}
+
+func TestConfigDiff(t *testing.T) {
+	old := New("feature")
+	updated := New("feature")
+	updated.MCP.Timeout = 5 * time.Minute
+	updated.TaskTypes["build"] = TaskType{Model: "claude/opus"}
+	updated.Timezone = "UTC"
+
+	changed := Diff(old, updated)
+	want := []string{"mcp.timeout", "taskTypes.build", "timezone"}
+	if len(changed) != len(want) {
+		t.Fatalf("expected %v, got %v", want, changed)
+	}
+	for i := range want {
+		if changed[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, changed)
+		}
+	}
+
+	if changed := Diff(old, New("feature")); len(changed) != 0 {
+		t.Errorf("expected no changes, got %v", changed)
+	}
+}
+
+func TestConfigWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := New("feature")
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	type reload struct {
+		cfg     *Config
+		changed []string
+		err     error
+	}
+	reloads := make(chan reload, 4)
+	stop := Watch(path, cfg, 10*time.Millisecond, func(c *Config, changed []string, err error) {
+		reloads <- reload{c, changed, err}
+	})
+	defer stop()
+
+	next := func() reload {
+		select {
+		case r := <-reloads:
+			return r
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for reload")
+			return reload{}
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("feature: feature\nbackend: nope\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if r := next(); r.err == nil {
+		t.Errorf("expected invalid config to be reported, got %v", r.changed)
+	}
+
+	updated := New("feature")
+	updated.MCP.MaxOutput = 1024
+	if err := updated.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	r := next()
+	if r.err != nil {
+		t.Fatalf("unexpected error: %v", r.err)
+	}
+	if len(r.changed) != 1 || r.changed[0] != "mcp.max_output" {
+		t.Errorf("expected [mcp.max_output] against the last good config, got %v", r.changed)
+	}
+	if r.cfg.MCP.MaxOutput != 1024 {
+		t.Errorf("expected reloaded max_output 1024, got %d", r.cfg.MCP.MaxOutput)
+	}
+}
+
+func TestConfigRetryFor(t *testing.T) {
+	cfg := New("feature")
+	if _, ok := cfg.RetryFor("claude"); ok {
+		t.Error("expected no retry settings by default")
+	}
+
+	cfg.Retry = map[string]RetryConfig{
+		"default": {Strategy: "exponential-jitter"},
+		"copilot": {Strategy: "fixed", InitialBackoff: 2 * time.Second},
+	}
+	if r, ok := cfg.RetryFor("copilot"); !ok || r.Strategy != "fixed" {
+		t.Errorf("expected the backend's own settings, got %+v", r)
+	}
+	if r, ok := cfg.RetryFor("claude"); !ok || r.Strategy != "exponential-jitter" {
+		t.Errorf("expected the default settings, got %+v", r)
+	}
+
+	cfg.Retry["claude"] = RetryConfig{MaxRetries: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected negative retry settings to be rejected")
+	}
+}
+
+func TestConfigEscalationFor(t *testing.T) {
+	cfg := New("feature")
+	if _, ok := cfg.EscalationFor("build"); ok {
+		t.Error("expected no escalation by default")
+	}
+
+	cfg.Escalation = EscalationConfig{After: 2, Model: "claude/opus"}
+	cfg.TaskTypes["docs"] = TaskType{Model: "claude/haiku", Escalation: &EscalationConfig{After: 1, Model: "claude/sonnet"}}
+	if e, ok := cfg.EscalationFor("build"); !ok || e.Model != "claude/opus" || e.After != 2 {
+		t.Errorf("expected the workspace escalation, got %+v", e)
+	}
+	if e, ok := cfg.EscalationFor("docs"); !ok || e.Model != "claude/sonnet" || e.After != 1 {
+		t.Errorf("expected the task type's escalation, got %+v", e)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Escalation = EscalationConfig{After: 2}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an escalation without a model to be rejected")
+	}
+	cfg.Escalation = EscalationConfig{After: 2, Model: "opus"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a model without a backend to be rejected")
+	}
+}
+
+func TestConfigWebhooks(t *testing.T) {
+	cfg := New("feature")
+	cfg.Webhooks = []WebhookConfig{{
+		URL:       "https://hooks.example.com/flo",
+		SecretEnv: "FLO_WEBHOOK_SECRET",
+		Events:    []string{"task_failed", "budget_alert"},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []WebhookConfig{
+		{URL: "hooks.example.com/flo"},
+		{URL: "ftp://hooks.example.com/flo"},
+		{URL: "https://hooks.example.com/flo", Events: []string{"task_started"}},
+		{URL: "https://hooks.example.com/flo", MaxAttempts: -1},
+	}
+	for _, w := range tests {
+		cfg.Webhooks = []WebhookConfig{w}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", w)
+		}
+	}
+}
+
+func TestConfigGitHubIssues(t *testing.T) {
+	cfg := New("feature")
+	cfg.Issues.GitHub = &GitHubIssues{Repo: "org/app", Labels: []string{"feature-x"}, Sync: true}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Issues.GitHub.Token(); got != DefaultGitHubTokenEnv {
+		t.Errorf("expected the default token, got %s", got)
+	}
+
+	for _, g := range []GitHubIssues{
+		{Repo: "app"},
+		{Repo: "org/app/extra"},
+		{Repo: "org/app", URL: "api.example.com"},
+	} {
+		cfg.Issues.GitHub = &g
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", g)
+		}
+	}
+}
+
+func TestConfigLinearIssues(t *testing.T) {
+	cfg := New("feature")
+	cfg.Issues.Linear = &LinearIssues{Team: "ENG", States: map[string]string{"complete": "In Review"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Issues.Linear.Token(); got != DefaultLinearTokenEnv {
+		t.Errorf("expected the default token, got %s", got)
+	}
+
+	for _, l := range []LinearIssues{
+		{Project: "Auth"},
+		{Team: "ENG", States: map[string]string{"done": "Done"}},
+		{Team: "ENG", URL: "linear"},
+	} {
+		cfg.Issues.Linear = &l
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", l)
+		}
+	}
+}
+
+func TestConfigRetryJitter(t *testing.T) {
+	cfg := New("feature")
+	for _, jitter := range []float64{0, 0.5, 1} {
+		cfg.Retry = map[string]RetryConfig{"default": {Jitter: &jitter}}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("jitter %v: unexpected error: %v", jitter, err)
+		}
+	}
+	for _, jitter := range []float64{-0.1, 1.5} {
+		cfg.Retry = map[string]RetryConfig{"default": {Jitter: &jitter}}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected jitter %v to be rejected", jitter)
+		}
+	}
+}
+
+func TestConfigRetryBudget(t *testing.T) {
+	cfg := New("feature")
+	cfg.RetryBudget = RetryBudgetConfig{MaxRetries: 10, MaxTime: 5 * time.Minute}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	cfg.RetryBudget = RetryBudgetConfig{MaxRetries: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a negative retry budget to be rejected")
+	}
+}
+
+func TestConfigConcurrency(t *testing.T) {
+	cfg := New("feature")
+	cfg.Concurrency = ConcurrencyConfig{
+		PerRepo:  1,
+		Repos:    map[string]int{"android": 2},
+		Backends: map[string]int{"claude": 3, "default": 1},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if cfg.Concurrency.RepoLimit("") != 1 || cfg.Concurrency.RepoLimit("ios") != 1 || cfg.Concurrency.RepoLimit("android") != 2 {
+		t.Errorf("unexpected repo limits %+v", cfg.Concurrency)
+	}
+	if cfg.Concurrency.BackendLimit("claude") != 3 || cfg.Concurrency.BackendLimit("copilot") != 1 {
+		t.Errorf("unexpected backend limits %+v", cfg.Concurrency)
+	}
+	if (ConcurrencyConfig{}).BackendLimit("claude") != 0 {
+		t.Error("expected no cap by default")
+	}
+	cfg.Concurrency.Backends["claude"] = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "concurrency.backends.claude") {
+		t.Errorf("expected a negative backend limit to be rejected, got %v", err)
+	}
+}
+
+func TestConfigHedge(t *testing.T) {
+	cfg := New("feature")
+	cfg.Hedge = HedgeConfig{Fallback: "copilot/gpt-4", Delay: 10 * time.Second}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, h := range []HedgeConfig{{Fallback: "/gpt-4"}, {Fallback: "copilot", Delay: -time.Second}} {
+		cfg.Hedge = h
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", h)
+		}
+	}
+}
+
+func TestConfigRepos(t *testing.T) {
+	cfg := New("feature")
+	cfg.Repos = map[string]Repo{"api": {URL: "git@github.com:org/api.git"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for name, r := range map[string]Repo{"../api": {URL: "git@github.com:org/api.git"}, "web": {}} {
+		cfg.Repos = map[string]Repo{name: r}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected repo %q %+v to be rejected", name, r)
+		}
+	}
+}
+
+func TestConfigTaskTypeApproval(t *testing.T) {
+	cfg := New("feature")
+	cfg.TaskTypes = map[string]TaskType{"migration": {Approval: ApprovalRequired}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !cfg.ReviewRequired("migration") || cfg.ReviewRequired("docs") || cfg.ReviewRequired("") {
+		t.Error("expected only the migration type to require review")
+	}
+	cfg.TaskTypes["migration"] = TaskType{Approval: "sometimes"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unknown approval value to be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diff returns the settings that differ between two configs as dotted
+// YAML paths, such as "mcp.timeout" or "taskTypes.build", sorted. Settings
+// are compared two levels deep; deeper changes are reported at the second
+// level.
+func Diff(old, new *Config) []string {
+	oldMap, newMap := toMap(old), toMap(new)
+	var changed []string
+	for _, key := range unionKeys(oldMap, newMap) {
+		oldSub, oldIsMap := oldMap[key].(map[string]any)
+		newSub, newIsMap := newMap[key].(map[string]any)
+		// Empty sections are omitted, so a missing side counts as empty.
+		if _, ok := oldMap[key]; !ok && newIsMap {
+			oldIsMap = true
+		}
+		if _, ok := newMap[key]; !ok && oldIsMap {
+			newIsMap = true
+		}
+		if !oldIsMap || !newIsMap {
+			if !reflect.DeepEqual(oldMap[key], newMap[key]) {
+				changed = append(changed, key)
+			}
+			continue
+		}
+		for _, sub := range unionKeys(oldSub, newSub) {
+			if !reflect.DeepEqual(oldSub[sub], newSub[sub]) {
+				changed = append(changed, key+"."+sub)
+			}
+		}
+	}
+	return changed
+}
+
+// toMap converts a config to its generic YAML form so that fields can be
+// compared by their YAML names.
+func toMap(c *Config) map[string]any {
+	m := make(map[string]any)
+	if c == nil {
+		return m
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return m
+	}
+	yaml.Unmarshal(data, &m)
+	return m
+}
+
+func unionKeys(a, b map[string]any) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]any{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Watch polls the config file at path every interval and calls fn when its
+// content changes, with the new config and the settings that changed since
+// current (see Diff). A config that fails to load or validate is passed to
+// fn as err and is not adopted, so a later fix is diffed against the last
+// good config. fn runs on the watcher's goroutine; stop ends the watch.
+func Watch(path string, current *Config, interval time.Duration, fn func(cfg *Config, changed []string, err error)) (stop func()) {
+	last, _ := os.ReadFile(path)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			data, err := os.ReadFile(path)
+			if err != nil || bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+			cfg, err := Load(path)
+			if err == nil {
+				err = cfg.Validate()
+			}
+			if err != nil {
+				fn(nil, nil, err)
+				continue
+			}
+			changed := Diff(current, cfg)
+			current = cfg
+			if len(changed) > 0 {
+				fn(cfg, changed, nil)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,96 @@
+// Package correlation identifies agent runs and task attempts, so the audit
+// events, transcript entries and backend processes of one attempt can be
+// tied together after the fact.
+//
+// A run ID names one `flo work` invocation; an execution ID names one
+// attempt at the task within it, such as the primary backend and then a
+// fallback. IDs travel in a context.Context within flo, and in the
+// environment to processes flo starts, such as agent CLIs and the MCP
+// server they run.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+)
+
+// Environment variables carrying the IDs to child processes.
+const (
+	RunIDEnv       = "FLO_RUN_ID"
+	ExecutionIDEnv = "FLO_EXECUTION_ID"
+)
+
+// IDs correlate records of a run and an attempt within it.
+type IDs struct {
+	RunID       string `json:"run_id,omitempty"`
+	ExecutionID string `json:"execution_id,omitempty"`
+}
+
+// IsZero reports whether no ID is set.
+func (ids IDs) IsZero() bool {
+	return ids.RunID == "" && ids.ExecutionID == ""
+}
+
+// Env returns the IDs as environment variable assignments.
+func (ids IDs) Env() []string {
+	var env []string
+	if ids.RunID != "" {
+		env = append(env, RunIDEnv+"="+ids.RunID)
+	}
+	if ids.ExecutionID != "" {
+		env = append(env, ExecutionIDEnv+"="+ids.ExecutionID)
+	}
+	return env
+}
+
+// NewRunID returns a new run ID, such as run-1f0c2a9e4b7d3c85.
+func NewRunID() string {
+	return "run-" + randomHex()
+}
+
+// NewExecutionID returns a new execution ID, such as exec-5e2b9a0f7c1d4e36.
+func NewExecutionID() string {
+	return "exec-" + randomHex()
+}
+
+func randomHex() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+type idsKey struct{}
+
+// WithIDs returns a context carrying ids.
+func WithIDs(ctx context.Context, ids IDs) context.Context {
+	return context.WithValue(ctx, idsKey{}, ids)
+}
+
+// WithRun returns a context carrying a new run ID.
+func WithRun(ctx context.Context) context.Context {
+	return WithIDs(ctx, IDs{RunID: NewRunID()})
+}
+
+// WithExecution returns a context carrying a new execution ID within the
+// context's run.
+func WithExecution(ctx context.Context) context.Context {
+	ids := FromContext(ctx)
+	ids.ExecutionID = NewExecutionID()
+	return WithIDs(ctx, ids)
+}
+
+// FromContext returns the IDs carried by ctx, falling back to those in the
+// environment, as set for a process started by a run.
+func FromContext(ctx context.Context) IDs {
+	if ids, ok := ctx.Value(idsKey{}).(IDs); ok {
+		return ids
+	}
+	return FromEnv()
+}
+
+// FromEnv returns the IDs set in the environment.
+func FromEnv() IDs {
+	return IDs{RunID: os.Getenv(RunIDEnv), ExecutionID: os.Getenv(ExecutionIDEnv)}
+}
@@ -0,0 +1,48 @@
+package correlation
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestContextIDs(t *testing.T) {
+	t.Setenv(RunIDEnv, "")
+	t.Setenv(ExecutionIDEnv, "")
+
+	ctx := WithRun(context.Background())
+	run := FromContext(ctx)
+	if !strings.HasPrefix(run.RunID, "run-") || run.ExecutionID != "" {
+		t.Fatalf("unexpected run IDs: %+v", run)
+	}
+
+	first := FromContext(WithExecution(ctx))
+	second := FromContext(WithExecution(ctx))
+	if first.RunID != run.RunID || second.RunID != run.RunID {
+		t.Error("expected executions to keep the run ID")
+	}
+	if !strings.HasPrefix(first.ExecutionID, "exec-") || first.ExecutionID == second.ExecutionID {
+		t.Errorf("expected distinct execution IDs, got %s and %s", first.ExecutionID, second.ExecutionID)
+	}
+	if FromContext(WithRun(ctx)).RunID == run.RunID {
+		t.Error("expected a new run ID for each run")
+	}
+}
+
+func TestEnv(t *testing.T) {
+	ids := IDs{RunID: "run-1", ExecutionID: "exec-1"}
+	env := ids.Env()
+	if len(env) != 2 || env[0] != "FLO_RUN_ID=run-1" || env[1] != "FLO_EXECUTION_ID=exec-1" {
+		t.Errorf("unexpected env: %v", env)
+	}
+	if len((IDs{}).Env()) != 0 || !(IDs{}).IsZero() {
+		t.Error("expected no variables for zero IDs")
+	}
+
+	// A child process picks the IDs up when its context has none
+	t.Setenv(RunIDEnv, "run-1")
+	t.Setenv(ExecutionIDEnv, "exec-1")
+	if got := FromContext(context.Background()); got != ids {
+		t.Errorf("expected the IDs from the environment, got %+v", got)
+	}
+}
@@ -0,0 +1,282 @@
+// Package cost converts token usage into dollar cost using configurable
+// per-model prices for input, output and cached tokens, keeps a ledger of
+// what a feature's agent runs have cost, and checks that against the
+// feature's budget.
+package cost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/richgo/flo/pkg/clock"
+)
+
+// Tokens counts the tokens of a run by kind, since providers price and
+// limit them differently.
+type Tokens struct {
+	// Input is prompt tokens, excluding those read from or written to the
+	// prompt cache.
+	Input  int `json:"input,omitempty"`
+	Output int `json:"output,omitempty"`
+	// CacheRead and CacheWrite are prompt tokens served from, and added
+	// to, the provider's prompt cache.
+	CacheRead  int `json:"cache_read,omitempty"`
+	CacheWrite int `json:"cache_write,omitempty"`
+}
+
+// Total returns the tokens of every kind.
+func (t Tokens) Total() int {
+	return t.Input + t.Output + t.CacheRead + t.CacheWrite
+}
+
+// Add returns the sum of t and o.
+func (t Tokens) Add(o Tokens) Tokens {
+	return Tokens{
+		Input:      t.Input + o.Input,
+		Output:     t.Output + o.Output,
+		CacheRead:  t.CacheRead + o.CacheRead,
+		CacheWrite: t.CacheWrite + o.CacheWrite,
+	}
+}
+
+// Price is what a model charges in USD per million tokens of each kind.
+// Kinds left unset cost the same as input.
+type Price struct {
+	Input      float64 `yaml:"input" json:"input"`
+	Output     float64 `yaml:"output,omitempty" json:"output,omitempty"`
+	CacheRead  float64 `yaml:"cache_read,omitempty" json:"cache_read,omitempty"`
+	CacheWrite float64 `yaml:"cache_write,omitempty" json:"cache_write,omitempty"`
+}
+
+// UnmarshalYAML accepts a single number, charged for every kind of token,
+// as well as the full form.
+func (p *Price) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var flat float64
+		if err := value.Decode(&flat); err != nil {
+			return err
+		}
+		*p = Price{Input: flat}
+		return nil
+	}
+	type plain Price
+	return value.Decode((*plain)(p))
+}
+
+// JSONSchema describes both forms UnmarshalYAML accepts.
+func (p Price) JSONSchema() map[string]any {
+	perKind := map[string]any{"type": "number", "minimum": 0}
+	return map[string]any{
+		"oneOf": []any{
+			map[string]any{"type": "number", "minimum": 0},
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"input":       perKind,
+					"output":      perKind,
+					"cache_read":  perKind,
+					"cache_write": perKind,
+				},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+// Negative reports whether any of the prices is negative.
+func (p Price) Negative() bool {
+	return p.Input < 0 || p.Output < 0 || p.CacheRead < 0 || p.CacheWrite < 0
+}
+
+// Cost returns the cost of tokens at this price.
+func (p Price) Cost(t Tokens) float64 {
+	orInput := func(price float64) float64 {
+		if price == 0 {
+			return p.Input
+		}
+		return price
+	}
+	return (float64(t.Input)*p.Input +
+		float64(t.Output)*orInput(p.Output) +
+		float64(t.CacheRead)*orInput(p.CacheRead) +
+		float64(t.CacheWrite)*orInput(p.CacheWrite)) / 1e6
+}
+
+// Pricing maps "backend/model", or "backend" for models not listed, to
+// the model's price.
+type Pricing map[string]Price
+
+// Price returns the price of a backend's model.
+func (p Pricing) Price(backend, model string) (Price, bool) {
+	if model != "" {
+		if price, ok := p[backend+"/"+model]; ok {
+			return price, true
+		}
+	}
+	price, ok := p[backend]
+	return price, ok
+}
+
+// Cost returns the cost of tokens on a backend's model, and false when it
+// has no price.
+func (p Pricing) Cost(backend, model string, tokens Tokens) (float64, bool) {
+	price, ok := p.Price(backend, model)
+	if !ok {
+		return 0, false
+	}
+	return price.Cost(tokens), true
+}
+
+// Entry is the cost of one agent run.
+type Entry struct {
+	TaskID  string    `json:"task_id,omitempty"`
+	Backend string    `json:"backend"`
+	Model   string    `json:"model,omitempty"`
+	Tokens  Tokens    `json:"tokens"`
+	Cost    float64   `json:"cost"`
+	At      time.Time `json:"at"`
+}
+
+// Ledger keeps the cost of every agent run of a feature in a JSON file.
+type Ledger struct {
+	mu    sync.Mutex
+	path  string
+	clock clock.Clock
+}
+
+// NewLedger creates a ledger backed by the given file.
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path, clock: clock.Real}
+}
+
+// SetClock sets the clock entries are stamped with.
+func (l *Ledger) SetClock(c clock.Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = clock.Or(c)
+}
+
+// Record adds an entry, stamping it with the current time if At is zero.
+func (l *Ledger) Record(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries, err := l.load()
+	if err != nil {
+		return err
+	}
+	if e.At.IsZero() {
+		e.At = l.clock.Now().UTC()
+	}
+	return l.save(append(entries, e))
+}
+
+// Entries returns every entry, oldest first.
+func (l *Ledger) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.load()
+}
+
+// Summary totals the ledger.
+type Summary struct {
+	Runs  int     `json:"runs"`
+	Spent float64 `json:"spent"`
+	// PerRun is the average cost of a run, zero when there are none.
+	PerRun float64 `json:"per_run"`
+}
+
+// Summary returns the runs recorded and what they cost.
+func (l *Ledger) Summary() (Summary, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return Summary{}, err
+	}
+	s := Summary{Runs: len(entries)}
+	for _, e := range entries {
+		s.Spent += e.Cost
+	}
+	if s.Runs > 0 {
+		s.PerRun = s.Spent / float64(s.Runs)
+	}
+	return s, nil
+}
+
+// load reads the ledger file (must be called with lock held).
+func (l *Ledger) load() ([]Entry, error) {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cost ledger: %w", err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse cost ledger: %w", err)
+	}
+	return entries, nil
+}
+
+// save writes the ledger file via a temp file and rename (must be called
+// with lock held).
+func (l *Ledger) save(entries []Entry) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize cost ledger: %w", err)
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cost ledger: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("failed to replace cost ledger: %w", err)
+	}
+	return nil
+}
+
+// ErrOverBudget is matched by errors refusing a run that would exceed the
+// budget.
+var ErrOverBudget = errors.New("budget exceeded")
+
+// BudgetError reports a run refused because the projected cost exceeds
+// the budget.
+type BudgetError struct {
+	Budget    float64
+	Spent     float64
+	Projected float64
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("projected cost %s exceeds the %s budget (%s spent)",
+		Format(e.Projected), Format(e.Budget), Format(e.Spent))
+}
+
+// Is reports whether target is ErrOverBudget.
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrOverBudget
+}
+
+// CheckBudget returns a *BudgetError if spending next on top of spent
+// would exceed budget. A budget of zero is unlimited.
+func CheckBudget(budget, spent, next float64) error {
+	if budget <= 0 || spent+next <= budget {
+		return nil
+	}
+	return &BudgetError{Budget: budget, Spent: spent, Projected: spent + next}
+}
+
+// Format formats a cost in dollars.
+func Format(usd float64) string {
+	return fmt.Sprintf("$%.2f", usd)
+}
@@ -0,0 +1,133 @@
+package cost
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/richgo/flo/pkg/clock"
+)
+
+func TestPricingCost(t *testing.T) {
+	pricing := Pricing{
+		"claude":      {Input: 3},
+		"claude/opus": {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	}
+	tokens := Tokens{Input: 10000, Output: 2000, CacheRead: 100000, CacheWrite: 4000}
+	if tokens.Total() != 116000 {
+		t.Errorf("Total = %d, want 116000", tokens.Total())
+	}
+
+	// 0.15 input + 0.15 output + 0.15 cache read + 0.075 cache write
+	if c, ok := pricing.Cost("claude", "opus", tokens); !ok || math.Abs(c-0.525) > 1e-9 {
+		t.Errorf("opus cost = %v, %v; want 0.525", c, ok)
+	}
+	// Unlisted models use the backend price, and unset kinds the input price.
+	if c, ok := pricing.Cost("claude", "sonnet", tokens); !ok || math.Abs(c-0.348) > 1e-9 {
+		t.Errorf("sonnet cost = %v, %v; want 0.348", c, ok)
+	}
+	if _, ok := pricing.Cost("copilot", "", tokens); ok {
+		t.Error("expected no price for copilot")
+	}
+}
+
+func TestPriceYAML(t *testing.T) {
+	var pricing Pricing
+	data := "claude: 3\nclaude/opus: {input: 15, output: 75}\n"
+	if err := yaml.Unmarshal([]byte(data), &pricing); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if pricing["claude"] != (Price{Input: 3}) || pricing["claude/opus"] != (Price{Input: 15, Output: 75}) {
+		t.Errorf("unexpected pricing: %+v", pricing)
+	}
+}
+
+func TestLedgerSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "costs.json")
+	ledger := NewLedger(path)
+	ledger.SetClock(clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)))
+
+	ledger.Record(Entry{TaskID: "t-001", Backend: "claude", Model: "opus", Tokens: Tokens{Input: 10000}, Cost: 0.15})
+	ledger.Record(Entry{TaskID: "t-002", Backend: "claude", Model: "sonnet", Tokens: Tokens{Input: 10000}, Cost: 0.05})
+
+	// Reopen to check the ledger persisted.
+	entries, err := NewLedger(path).Entries()
+	if err != nil || len(entries) != 2 || entries[0].At.IsZero() {
+		t.Fatalf("unexpected entries: %+v, %v", entries, err)
+	}
+	summary, err := NewLedger(path).Summary()
+	if err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	if summary.Runs != 2 || math.Abs(summary.Spent-0.20) > 1e-9 || math.Abs(summary.PerRun-0.10) > 1e-9 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	if err := CheckBudget(0, 100, 100); err != nil {
+		t.Errorf("expected no limit without a budget, got %v", err)
+	}
+	if err := CheckBudget(10, 9, 1); err != nil {
+		t.Errorf("expected a run reaching the budget exactly to be allowed, got %v", err)
+	}
+
+	err := CheckBudget(10, 9.5, 1)
+	var over *BudgetError
+	if !errors.As(err, &over) || !errors.Is(err, ErrOverBudget) {
+		t.Fatalf("expected a BudgetError, got %v", err)
+	}
+	if over.Projected != 10.5 || err.Error() != "projected cost $10.50 exceeds the $10.00 budget ($9.50 spent)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	entries := []Entry{
+		{TaskID: "t-001", Backend: "claude", Model: "opus", Tokens: Tokens{Input: 100}, Cost: 1, At: day(1)},
+		{TaskID: "t-002", Backend: "claude", Model: "sonnet", Tokens: Tokens{Input: 50, Output: 10}, Cost: 0.25, At: day(5)},
+		{TaskID: "t-001", Backend: "claude", Model: "opus", Tokens: Tokens{Input: 200, CacheRead: 30}, Cost: 2, At: day(6)},
+		{TaskID: "t-001", Backend: "claude", Model: "opus", Tokens: Tokens{Input: 100}, Cost: 1, At: day(7)},
+		{TaskID: "t-002", Backend: "copilot", Tokens: Tokens{Input: 10}, At: day(7)},
+	}
+
+	report := NewReport(entries, day(5))
+	if len(report.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got %+v", report.Rows)
+	}
+	opus := report.Rows[0]
+	if opus.Model != "opus" || opus.Requests != 2 || opus.Tokens != (Tokens{Input: 300, CacheRead: 30}) || opus.Cost != 3 {
+		t.Errorf("unexpected opus row: %+v", opus)
+	}
+	if report.Rows[1].Model != "sonnet" || report.Rows[2].Backend != "copilot" {
+		t.Errorf("unexpected order: %+v", report.Rows)
+	}
+	if report.Total.Requests != 4 || math.Abs(report.Total.Cost-3.25) > 1e-9 || report.Total.Tokens.Total() != 400 {
+		t.Errorf("unexpected total: %+v", report.Total)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"7d":         now.AddDate(0, 0, -7),
+		"2w":         now.AddDate(0, 0, -14),
+		"12h":        now.Add(-12 * time.Hour),
+		"2026-03-01": time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for in, want := range tests {
+		if got, err := ParseSince(in, now); err != nil || !got.Equal(want) {
+			t.Errorf("ParseSince(%s) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "soon", "-3d", "0d"} {
+		if _, err := ParseSince(bad, now); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
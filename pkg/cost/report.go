@@ -0,0 +1,97 @@
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UsageRow totals the runs of one task on one backend and model.
+type UsageRow struct {
+	Backend  string  `json:"backend"`
+	Model    string  `json:"model,omitempty"`
+	TaskID   string  `json:"task_id,omitempty"`
+	Requests int     `json:"requests"`
+	Tokens   Tokens  `json:"tokens"`
+	Cost     float64 `json:"cost"`
+}
+
+// add counts an entry in the row.
+func (r *UsageRow) add(e Entry) {
+	r.Requests++
+	r.Tokens = r.Tokens.Add(e.Tokens)
+	r.Cost += e.Cost
+}
+
+// Report is the usage recorded since a point in time.
+type Report struct {
+	// Since is the start of the report, nil when it covers every entry.
+	Since *time.Time `json:"since,omitempty"`
+	Rows  []UsageRow `json:"rows"`
+	Total UsageRow   `json:"total"`
+}
+
+// NewReport groups the entries recorded at or after since by backend,
+// model and task, sorted in that order.
+func NewReport(entries []Entry, since time.Time) Report {
+	type key struct{ backend, model, task string }
+	rows := make(map[key]*UsageRow)
+	var report Report
+	if !since.IsZero() {
+		report.Since = &since
+	}
+	for _, e := range entries {
+		if e.At.Before(since) {
+			continue
+		}
+		k := key{e.Backend, e.Model, e.TaskID}
+		row, ok := rows[k]
+		if !ok {
+			row = &UsageRow{Backend: e.Backend, Model: e.Model, TaskID: e.TaskID}
+			rows[k] = row
+		}
+		row.add(e)
+		report.Total.add(e)
+	}
+
+	report.Rows = make([]UsageRow, 0, len(rows))
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.Backend != b.Backend {
+			return a.Backend < b.Backend
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.TaskID < b.TaskID
+	})
+	return report
+}
+
+// ParseSince parses the start of a report: a duration back from now such
+// as "12h", "7d" or "2w", or a date such as "2026-01-31" (UTC).
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if v, err := strconv.Atoi(n); err == nil && v > 0 {
+				return now.Add(-time.Duration(v) * unit), nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid start '%s': use a duration such as 12h, 7d or 2w, or a date such as 2026-01-31", s)
+}
@@ -0,0 +1,233 @@
+// Package coverage parses test coverage reports and tracks per-task coverage deltas.
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Format identifies a coverage report format.
+type Format string
+
+const (
+	FormatGo        Format = "go"
+	FormatLCOV      Format = "lcov"
+	FormatCobertura Format = "cobertura"
+)
+
+// FileCoverage holds covered and total statement (or line) counts for a file.
+type FileCoverage struct {
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+}
+
+// Report is a parsed coverage report.
+type Report struct {
+	Format Format                   `json:"format"`
+	Files  map[string]*FileCoverage `json:"files"`
+}
+
+func newReport(format Format) *Report {
+	return &Report{
+		Format: format,
+		Files:  make(map[string]*FileCoverage),
+	}
+}
+
+func (r *Report) file(name string) *FileCoverage {
+	fc, ok := r.Files[name]
+	if !ok {
+		fc = &FileCoverage{}
+		r.Files[name] = fc
+	}
+	return fc
+}
+
+// Covered returns the total number of covered statements.
+func (r *Report) Covered() int {
+	total := 0
+	for _, fc := range r.Files {
+		total += fc.Covered
+	}
+	return total
+}
+
+// Total returns the total number of statements.
+func (r *Report) Total() int {
+	total := 0
+	for _, fc := range r.Files {
+		total += fc.Total
+	}
+	return total
+}
+
+// Percent returns overall coverage as a percentage (0-100).
+func (r *Report) Percent() float64 {
+	total := r.Total()
+	if total == 0 {
+		return 0
+	}
+	return float64(r.Covered()) / float64(total) * 100
+}
+
+// ParseFile parses a coverage report, detecting its format from the content.
+func ParseFile(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage report: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses coverage data, detecting its format from the content.
+func Parse(data []byte) (*Report, error) {
+	switch DetectFormat(data) {
+	case FormatGo:
+		return ParseGoProfile(bytes.NewReader(data))
+	case FormatLCOV:
+		return ParseLCOV(bytes.NewReader(data))
+	case FormatCobertura:
+		return ParseCobertura(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unrecognized coverage report format")
+	}
+}
+
+// DetectFormat guesses the report format from its content.
+func DetectFormat(data []byte) Format {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		return FormatGo
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return FormatCobertura
+	case bytes.HasPrefix(trimmed, []byte("TN:")), bytes.HasPrefix(trimmed, []byte("SF:")):
+		return FormatLCOV
+	default:
+		return ""
+	}
+}
+
+// ParseGoProfile parses a `go test -coverprofile` file.
+// Each block line has the form: file.go:startLine.col,endLine.col numStmts count
+func ParseGoProfile(r io.Reader) (*Report, error) {
+	report := newReport(FormatGo)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		colon := strings.LastIndex(line, ":")
+		if colon == -1 {
+			return nil, fmt.Errorf("invalid go coverage line %d: %s", lineNum, line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid go coverage line %d: %s", lineNum, line)
+		}
+
+		stmts, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid statement count at line %d: %w", lineNum, err)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid hit count at line %d: %w", lineNum, err)
+		}
+
+		fc := report.file(line[:colon])
+		fc.Total += stmts
+		if count > 0 {
+			fc.Covered += stmts
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading go coverage profile: %w", err)
+	}
+	return report, nil
+}
+
+// ParseLCOV parses an lcov tracefile, using the LF/LH line summary records.
+func ParseLCOV(r io.Reader) (*Report, error) {
+	report := newReport(FormatLCOV)
+	scanner := bufio.NewScanner(r)
+	var current *FileCoverage
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		key, value, _ := strings.Cut(line, ":")
+
+		switch key {
+		case "SF":
+			current = report.file(value)
+		case "LF", "LH":
+			if current == nil {
+				return nil, fmt.Errorf("lcov record %s outside of SF block at line %d", key, lineNum)
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid lcov %s value at line %d: %w", key, lineNum, err)
+			}
+			if key == "LF" {
+				current.Total += n
+			} else {
+				current.Covered += n
+			}
+		case "end_of_record":
+			current = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading lcov file: %w", err)
+	}
+	return report, nil
+}
+
+// coberturaXML mirrors the subset of the Cobertura schema needed for line counts.
+type coberturaXML struct {
+	Packages []struct {
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Hits int `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// ParseCobertura parses a Cobertura XML coverage report.
+func ParseCobertura(r io.Reader) (*Report, error) {
+	var doc coberturaXML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse cobertura report: %w", err)
+	}
+
+	report := newReport(FormatCobertura)
+	for _, pkg := range doc.Packages {
+		for _, class := range pkg.Classes {
+			fc := report.file(class.Filename)
+			for _, line := range class.Lines {
+				fc.Total++
+				if line.Hits > 0 {
+					fc.Covered++
+				}
+			}
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,129 @@
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const goProfile = `mode: set
+github.com/richgo/flo/pkg/a/a.go:10.2,12.3 3 1
+github.com/richgo/flo/pkg/a/a.go:14.2,15.3 1 0
+github.com/richgo/flo/pkg/b/b.go:5.1,6.2 4 2
+`
+
+const lcovReport = `TN:
+SF:src/app.js
+DA:1,1
+DA:2,0
+LF:10
+LH:7
+end_of_record
+SF:src/util.js
+LF:10
+LH:3
+end_of_record
+`
+
+const coberturaReport = `<?xml version="1.0" ?>
+<coverage line-rate="0.5">
+  <packages>
+    <package name="app">
+      <classes>
+        <class name="main" filename="app/main.py">
+          <lines>
+            <line number="1" hits="1"/>
+            <line number="2" hits="0"/>
+            <line number="3" hits="4"/>
+            <line number="4" hits="0"/>
+          </lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>
+`
+
+func TestParseGoProfile(t *testing.T) {
+	report, err := ParseGoProfile(strings.NewReader(goProfile))
+	if err != nil {
+		t.Fatalf("ParseGoProfile failed: %v", err)
+	}
+	if report.Total() != 8 {
+		t.Errorf("expected 8 statements, got %d", report.Total())
+	}
+	if report.Covered() != 7 {
+		t.Errorf("expected 7 covered, got %d", report.Covered())
+	}
+	if len(report.Files) != 2 {
+		t.Errorf("expected 2 files, got %d", len(report.Files))
+	}
+}
+
+func TestParseGoProfileInvalid(t *testing.T) {
+	if _, err := ParseGoProfile(strings.NewReader("mode: set\nbogus line")); err == nil {
+		t.Error("expected error for invalid profile line")
+	}
+}
+
+func TestParseLCOV(t *testing.T) {
+	report, err := ParseLCOV(strings.NewReader(lcovReport))
+	if err != nil {
+		t.Fatalf("ParseLCOV failed: %v", err)
+	}
+	if report.Percent() != 50 {
+		t.Errorf("expected 50%%, got %.1f", report.Percent())
+	}
+}
+
+func TestParseCobertura(t *testing.T) {
+	report, err := ParseCobertura(strings.NewReader(coberturaReport))
+	if err != nil {
+		t.Fatalf("ParseCobertura failed: %v", err)
+	}
+	fc := report.Files["app/main.py"]
+	if fc == nil || fc.Total != 4 || fc.Covered != 2 {
+		t.Errorf("unexpected file coverage: %+v", fc)
+	}
+}
+
+func TestParseDetectsFormat(t *testing.T) {
+	tests := map[string]Format{
+		goProfile:       FormatGo,
+		lcovReport:      FormatLCOV,
+		coberturaReport: FormatCobertura,
+	}
+	for input, want := range tests {
+		report, err := Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Parse failed for %s: %v", want, err)
+		}
+		if report.Format != want {
+			t.Errorf("expected format %s, got %s", want, report.Format)
+		}
+	}
+
+	if _, err := Parse([]byte("not a report")); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "coverage.out")
+	os.WriteFile(path, []byte(goProfile), 0644)
+
+	report, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if report.Percent() != 87.5 {
+		t.Errorf("expected 87.5%%, got %.2f", report.Percent())
+	}
+}
+
+func TestEmptyReportPercent(t *testing.T) {
+	if pct := newReport(FormatGo).Percent(); pct != 0 {
+		t.Errorf("expected 0 for empty report, got %f", pct)
+	}
+}
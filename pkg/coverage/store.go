@@ -0,0 +1,146 @@
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Delta records how a task changed overall coverage.
+type Delta struct {
+	TaskID     string    `json:"task_id"`
+	Before     float64   `json:"before"`
+	After      float64   `json:"after"`
+	HasBefore  bool      `json:"has_before"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Change returns the coverage change in percentage points.
+func (d *Delta) Change() float64 {
+	if !d.HasBefore {
+		return 0
+	}
+	return d.After - d.Before
+}
+
+// Store persists per-task coverage deltas for a workspace.
+type Store struct {
+	mu     sync.Mutex
+	path   string
+	deltas map[string]*Delta
+}
+
+// NewStore creates a coverage store backed by the given file.
+func NewStore(path string) *Store {
+	return &Store{
+		path:   path,
+		deltas: make(map[string]*Delta),
+	}
+}
+
+// Load reads stored deltas from disk. A missing file is not an error.
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read coverage store: %w", err)
+	}
+
+	deltas := make(map[string]*Delta)
+	if err := json.Unmarshal(data, &deltas); err != nil {
+		return fmt.Errorf("failed to parse coverage store: %w", err)
+	}
+	s.deltas = deltas
+	return nil
+}
+
+// Record stores a delta for a task, replacing any previous one, and saves.
+func (s *Store) Record(delta *Delta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deltas[delta.TaskID] = delta
+	return s.save()
+}
+
+// Get returns the delta recorded for a task.
+func (s *Store) Get(taskID string) (*Delta, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deltas[taskID]
+	if !ok {
+		return nil, false
+	}
+	copy := *d
+	return &copy, true
+}
+
+// List returns all deltas ordered by recording time.
+func (s *Store) List() []*Delta {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deltas := make([]*Delta, 0, len(s.deltas))
+	for _, d := range s.deltas {
+		copy := *d
+		deltas = append(deltas, &copy)
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[i].RecordedAt.Before(deltas[j].RecordedAt)
+	})
+	return deltas
+}
+
+// Summary describes the coverage impact of a whole feature.
+type Summary struct {
+	Tasks    int     `json:"tasks"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+	Change   float64 `json:"change"`
+}
+
+// Summarize computes the feature-level coverage impact from the first
+// recorded baseline to the most recent measurement.
+func (s *Store) Summarize() *Summary {
+	deltas := s.List()
+	summary := &Summary{Tasks: len(deltas)}
+	if len(deltas) == 0 {
+		return summary
+	}
+
+	first := deltas[0]
+	summary.Baseline = first.After
+	if first.HasBefore {
+		summary.Baseline = first.Before
+	}
+	summary.Current = deltas[len(deltas)-1].After
+	summary.Change = summary.Current - summary.Baseline
+	return summary
+}
+
+// save writes deltas to disk (must be called with lock held).
+func (s *Store) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.deltas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize coverage store: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write coverage store: %w", err)
+	}
+	return nil
+}
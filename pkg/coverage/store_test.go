@@ -0,0 +1,65 @@
+package coverage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreRecordAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "coverage.json")
+	store := NewStore(path)
+
+	now := time.Now()
+	store.Record(&Delta{TaskID: "t-001", Before: 60, After: 65, HasBefore: true, RecordedAt: now})
+	store.Record(&Delta{TaskID: "t-002", Before: 65, After: 72, HasBefore: true, RecordedAt: now.Add(time.Minute)})
+
+	loaded := NewStore(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	d, ok := loaded.Get("t-001")
+	if !ok {
+		t.Fatal("expected delta for t-001")
+	}
+	if d.Change() != 5 {
+		t.Errorf("expected change 5, got %.1f", d.Change())
+	}
+
+	list := loaded.List()
+	if len(list) != 2 || list[0].TaskID != "t-001" {
+		t.Errorf("expected deltas ordered by time, got %+v", list)
+	}
+}
+
+func TestStoreSummarize(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "coverage.json"))
+
+	if s := store.Summarize(); s.Tasks != 0 {
+		t.Errorf("expected empty summary, got %+v", s)
+	}
+
+	now := time.Now()
+	store.Record(&Delta{TaskID: "t-001", Before: 50, After: 55, HasBefore: true, RecordedAt: now})
+	store.Record(&Delta{TaskID: "t-002", Before: 55, After: 58, HasBefore: true, RecordedAt: now.Add(time.Hour)})
+
+	s := store.Summarize()
+	if s.Baseline != 50 || s.Current != 58 || s.Change != 8 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+}
+
+func TestDeltaWithoutBaseline(t *testing.T) {
+	d := &Delta{TaskID: "t-001", After: 70}
+	if d.Change() != 0 {
+		t.Errorf("expected no change without baseline, got %.1f", d.Change())
+	}
+}
+
+func TestStoreLoadMissing(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "missing.json"))
+	if err := store.Load(); err != nil {
+		t.Errorf("expected no error for missing store: %v", err)
+	}
+}
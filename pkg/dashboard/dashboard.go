@@ -0,0 +1,31 @@
+// Package dashboard is the web UI served by 'flo serve': a task board, a
+// dependency graph, the live audit event stream and the rendered spec, for
+// people who won't use the CLI. The pages are static and read everything
+// from the REST API, so the assets are embedded in the binary.
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard's page and assets. They hold no workspace
+// data, so need no auth token; the page asks the API for it.
+func Handler() http.Handler {
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	files := http.FileServerFS(assets)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The spec is rendered into the page; allow only the dashboard's
+		// own scripts and styles, and the API
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; img-src 'self' data:")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,46 @@
+package dashboard
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler()
+	for path, want := range map[string]string{
+		"/":          "text/html",
+		"/app.js":    "javascript",
+		"/style.css": "text/css",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: %d", path, rec.Code)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, want) {
+			t.Errorf("GET %s: content type %q, want %s", path, ct, want)
+		}
+		if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "default-src 'self'") {
+			t.Errorf("GET %s: content security policy %q", path, csp)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	page, _ := io.ReadAll(rec.Body)
+	for _, view := range []string{`id="board"`, `id="graph"`, `id="spec"`, `id="reviews"`, `id="events"`, `src="app.js"`} {
+		if !strings.Contains(string(page), view) {
+			t.Errorf("index page is missing %s", view)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/missing.js", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing asset, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,376 @@
+// flo dashboard: reads the workspace from the flo serve REST API.
+"use strict";
+
+const STATUSES = ["pending", "in_progress", "complete", "failed"];
+const MAX_EVENTS = 200;
+
+// The token comes from the URL fragment flo serve prints (#token=...), and
+// is kept for later visits.
+let token = localStorage.getItem("flo-token") || "";
+const fragment = new URLSearchParams(location.hash.slice(1));
+if (fragment.has("token")) {
+  token = fragment.get("token");
+  localStorage.setItem("flo-token", token);
+  history.replaceState(null, "", location.pathname);
+}
+
+let tasks = [];
+let events = null;
+
+async function api(path, payload) {
+  const headers = token ? { Authorization: "Bearer " + token } : {};
+  const options = { headers };
+  if (payload !== undefined) {
+    options.method = "POST";
+    options.body = JSON.stringify(payload);
+    headers["Content-Type"] = "application/json";
+  }
+  const resp = await fetch(path, options);
+  if (resp.status === 401) {
+    showLogin();
+    throw new Error("unauthorized");
+  }
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return body;
+}
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    node.setAttribute(key, value);
+  }
+  for (const child of children) {
+    node.append(child);
+  }
+  return node;
+}
+
+function svg(tag, attrs, ...children) {
+  const node = document.createElementNS("http://www.w3.org/2000/svg", tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    node.setAttribute(key, value);
+  }
+  for (const child of children) {
+    node.append(child);
+  }
+  return node;
+}
+
+function escapeHTML(text) {
+  return text.replace(/[&<>"']/g, (c) => ({ "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;" })[c]);
+}
+
+// Views
+
+function showView(name) {
+  document.querySelectorAll(".view").forEach((v) => (v.hidden = v.id !== name));
+  document.querySelectorAll("nav button").forEach((b) => b.classList.toggle("active", b.dataset.view === name));
+  if (name === "spec") {
+    loadSpec();
+  } else if (name === "reviews") {
+    loadReviews();
+  }
+}
+
+function showLogin() {
+  document.getElementById("login").hidden = false;
+  document.querySelector("main").hidden = true;
+}
+
+async function refresh() {
+  const [status, list] = await Promise.all([api("/v1/status"), api("/v1/tasks")]);
+  tasks = list;
+  document.getElementById("feature").textContent = status.feature;
+  const t = status.tasks;
+  document.getElementById("summary").textContent =
+    `${t.complete}/${t.total} complete · ${t.ready} ready` + (status.frozen ? " · frozen" : "");
+  renderBoard();
+  renderGraph();
+}
+
+function renderBoard() {
+  for (const status of STATUSES) {
+    const column = document.querySelector(`.column[data-status="${status}"]`);
+    const list = column.querySelector("ul");
+    const matching = tasks.filter((t) => t.status === status);
+    list.replaceChildren(...matching.map(card));
+    const heading = column.querySelector("h2");
+    heading.querySelector(".count")?.remove();
+    heading.append(el("span", { class: "count" }, ` ${matching.length}`));
+  }
+}
+
+function card(task) {
+  const meta = [];
+  if (task.type) meta.push(task.type);
+  if (task.model) meta.push(task.model);
+  if (task.deps?.length) meta.push("after " + task.deps.join(", "));
+  if (task.retries) meta.push(`retried ${task.retries}×`);
+  return el("li", { class: `card ${task.status}` },
+    el("span", { class: "id" }, task.id), " ", task.title,
+    el("div", { class: "meta" }, meta.join(" · ")));
+}
+
+// renderGraph lays tasks out in columns by dependency depth, with an edge
+// from each dependency to the tasks that need it.
+function renderGraph() {
+  const container = document.getElementById("graph");
+  if (tasks.length === 0) {
+    container.replaceChildren(el("p", { class: "hint" }, "No tasks yet."));
+    return;
+  }
+  const byID = new Map(tasks.map((t) => [t.id, t]));
+  const depth = new Map();
+  const depthOf = (task, seen = new Set()) => {
+    if (depth.has(task.id)) return depth.get(task.id);
+    if (seen.has(task.id)) return 0;
+    seen.add(task.id);
+    let d = 0;
+    for (const dep of task.deps || []) {
+      if (byID.has(dep)) d = Math.max(d, depthOf(byID.get(dep), seen) + 1);
+    }
+    depth.set(task.id, d);
+    return d;
+  };
+  tasks.forEach((t) => depthOf(t));
+
+  const W = 200, H = 44, GAP_X = 60, GAP_Y = 16, PAD = 16;
+  const rows = new Map();
+  const pos = new Map();
+  for (const task of tasks) {
+    const d = depth.get(task.id);
+    const row = rows.get(d) || 0;
+    rows.set(d, row + 1);
+    pos.set(task.id, { x: PAD + d * (W + GAP_X), y: PAD + row * (H + GAP_Y) });
+  }
+  const width = PAD * 2 + (Math.max(...depth.values()) + 1) * (W + GAP_X) - GAP_X;
+  const height = PAD * 2 + Math.max(...rows.values()) * (H + GAP_Y) - GAP_Y;
+
+  const root = svg("svg", { width, height, viewBox: `0 0 ${width} ${height}` });
+  for (const task of tasks) {
+    for (const dep of task.deps || []) {
+      if (!pos.has(dep)) continue;
+      const from = pos.get(dep), to = pos.get(task.id);
+      const x1 = from.x + W, y1 = from.y + H / 2, x2 = to.x, y2 = to.y + H / 2;
+      const mid = (x1 + x2) / 2;
+      root.append(svg("path", { class: "edge", d: `M${x1},${y1} C${mid},${y1} ${mid},${y2} ${x2},${y2}` }));
+    }
+  }
+  for (const task of tasks) {
+    const { x, y } = pos.get(task.id);
+    const title = task.title.length > 26 ? task.title.slice(0, 25) + "…" : task.title;
+    root.append(svg("g", { class: `node ${task.status}`, transform: `translate(${x},${y})` },
+      svg("title", {}, `${task.id} ${task.title} (${task.status})`),
+      svg("rect", { width: W, height: H, rx: 4 }),
+      svg("text", { class: "id", x: 8, y: 17 }, task.id),
+      svg("text", { x: 8, y: 34 }, title)));
+  }
+  container.replaceChildren(root);
+}
+
+async function loadSpec() {
+  const container = document.getElementById("spec");
+  try {
+    const spec = await api("/v1/spec");
+    container.innerHTML = renderMarkdown(spec.content);
+  } catch (err) {
+    container.replaceChildren(el("p", { class: "hint" }, "Couldn't load the spec: " + err.message));
+  }
+}
+
+// renderMarkdown renders the Markdown SPEC.md uses: headings, lists with
+// checkboxes, fenced code, emphasis, inline code and links. Text is
+// escaped first, so the spec can't inject markup.
+function renderMarkdown(source) {
+  const out = [];
+  let list = null;
+  let code = null;
+  let paragraph = [];
+  const flush = () => {
+    if (paragraph.length) out.push(`<p>${inline(paragraph.join(" "))}</p>`);
+    paragraph = [];
+  };
+  const closeList = () => {
+    if (list) out.push(`</${list}>`);
+    list = null;
+  };
+  for (const line of source.split("\n")) {
+    if (code !== null) {
+      if (line.startsWith("```")) {
+        out.push(`<pre><code>${escapeHTML(code.join("\n"))}</code></pre>`);
+        code = null;
+      } else {
+        code.push(line);
+      }
+      continue;
+    }
+    let m;
+    if (line.startsWith("```")) {
+      flush(); closeList();
+      code = [];
+    } else if ((m = line.match(/^(#{1,6})\s+(.*)$/))) {
+      flush(); closeList();
+      const level = m[1].length;
+      out.push(`<h${level}>${inline(m[2])}</h${level}>`);
+    } else if ((m = line.match(/^\s*([-*]|\d+\.)\s+(.*)$/))) {
+      flush();
+      const kind = /\d/.test(m[1]) ? "ol" : "ul";
+      if (list !== kind) { closeList(); out.push(`<${kind}>`); list = kind; }
+      const box = m[2].match(/^\[( |x|X)\]\s+(.*)$/);
+      if (box) {
+        const checked = box[1] !== " " ? " checked" : "";
+        out.push(`<li class="task"><input type="checkbox" disabled${checked}> ${inline(box[2])}</li>`);
+      } else {
+        out.push(`<li>${inline(m[2])}</li>`);
+      }
+    } else if (line.trim() === "") {
+      flush(); closeList();
+    } else {
+      closeList();
+      paragraph.push(line.trim());
+    }
+  }
+  if (code !== null) out.push(`<pre><code>${escapeHTML(code.join("\n"))}</code></pre>`);
+  flush(); closeList();
+  return out.join("\n");
+}
+
+function inline(text) {
+  return escapeHTML(text)
+    .replace(/`([^`]+)`/g, "<code>$1</code>")
+    .replace(/\*\*([^*]+)\*\*/g, "<strong>$1</strong>")
+    .replace(/\*([^*]+)\*/g, "<em>$1</em>")
+    .replace(/\[([^\]]+)\]\(((?:https?:\/\/|#)[^)\s]*)\)/g, '<a href="$2">$1</a>');
+}
+
+// Reviews
+
+async function loadReviews() {
+  const container = document.getElementById("reviews");
+  try {
+    const reviews = await api("/v1/reviews");
+    if (reviews.length === 0) {
+      container.replaceChildren(el("p", { class: "hint" }, "No changes awaiting review."));
+      return;
+    }
+    container.replaceChildren(...reviews.map(review));
+  } catch (err) {
+    container.replaceChildren(el("p", { class: "hint" }, "Couldn't load reviews: " + err.message));
+  }
+}
+
+function review(req) {
+  const gates = (req.gates || []).map((g) =>
+    el("span", { class: g.passed ? "gate passed" : "gate failed" }, g.name));
+  const meta = el("div", { class: "meta" }, ...gates);
+  if (req.cost) meta.append(` $${req.cost.toFixed(2)}`);
+
+  // Each file can be rejected: approving reverts it, and sending back
+  // lists it for the agent
+  const rejected = new Set();
+  const files = (req.files || []).map((file) => {
+    const box = el("input", { type: "checkbox" });
+    const section = el("div", { class: "file" },
+      el("label", {}, box, " Reject ", el("code", {}, file.path)),
+      diffView(file.patch));
+    box.addEventListener("change", () => {
+      if (box.checked) rejected.add(file.path); else rejected.delete(file.path);
+      section.classList.toggle("rejected", box.checked);
+    });
+    return section;
+  });
+  if (files.length === 0) files.push(el("p", { class: "hint" }, "(no changes)"));
+
+  const feedback = el("textarea", { placeholder: "Feedback for the agent, or a reason for rejecting", rows: 2 });
+  const status = el("span", { class: "hint" });
+  const decide = async (decision) => {
+    try {
+      await api(`/v1/tasks/${encodeURIComponent(req.task_id)}/review`,
+        { decision, feedback: feedback.value.trim(), rejected_files: [...rejected] });
+      await loadReviews();
+      refresh().catch(() => {});
+    } catch (err) {
+      status.textContent = err.message;
+    }
+  };
+  const button = (label, decision) => {
+    const b = el("button", { type: "button" }, label);
+    b.addEventListener("click", () => decide(decision));
+    return b;
+  };
+
+  return el("article", { class: "review" },
+    el("h2", {}, el("span", { class: "id" }, req.task_id), " ", req.summary),
+    meta,
+    ...files,
+    feedback,
+    el("div", { class: "actions" },
+      button("Approve", "approve"), button("Send back", "changes"), button("Reject all", "reject"), status));
+}
+
+// diffView renders a unified diff with added and removed lines marked.
+function diffView(diff) {
+  const lines = diff.replace(/\n$/, "").split("\n").map((line) => {
+    let kind = "";
+    if (line.startsWith("diff --git ") || line.startsWith("+++ ") || line.startsWith("--- ")) kind = "file";
+    else if (line.startsWith("@@")) kind = "hunk";
+    else if (line.startsWith("+")) kind = "add";
+    else if (line.startsWith("-")) kind = "del";
+    return el("span", { class: kind }, line + "\n");
+  });
+  return el("pre", { class: "diff" }, ...lines);
+}
+
+// Events
+
+function connectEvents() {
+  if (events) events.close();
+  const state = document.getElementById("stream-state");
+  const query = token ? "?access_token=" + encodeURIComponent(token) : "";
+  events = new EventSource("/v1/events" + query);
+  events.onopen = () => (state.textContent = "Live.");
+  events.onerror = () => (state.textContent = "Reconnecting…");
+  let pending = null;
+  events.addEventListener("audit", (msg) => {
+    const event = JSON.parse(msg.data);
+    addEvent(event);
+    // Task changes, from this server or any flo command, refresh the board
+    if (event.details?.task_id && !pending) {
+      pending = setTimeout(() => { pending = null; refresh().catch(() => {}); }, 300);
+    }
+  });
+}
+
+function addEvent(event) {
+  const list = document.getElementById("event-list");
+  const time = new Date(event.timestamp).toLocaleTimeString();
+  const task = event.details?.task_id ? ` (${event.details.task_id})` : "";
+  list.prepend(el("li", { class: event.level },
+    el("span", {}, time),
+    el("span", {}, event.level),
+    el("span", { class: "op" }, event.operation),
+    el("span", {}, event.message + task)));
+  while (list.children.length > MAX_EVENTS) list.lastChild.remove();
+}
+
+// Start
+
+document.querySelectorAll("nav button").forEach((b) => b.addEventListener("click", () => showView(b.dataset.view)));
+
+document.getElementById("login").addEventListener("submit", (e) => {
+  e.preventDefault();
+  token = document.getElementById("token").value.trim();
+  localStorage.setItem("flo-token", token);
+  document.getElementById("login").hidden = true;
+  document.querySelector("main").hidden = false;
+  start();
+});
+
+function start() {
+  refresh().then(connectEvents).catch((err) => console.error(err));
+}
+
+start();
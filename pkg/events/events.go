@@ -0,0 +1,170 @@
+// Package events is the in-process bus subsystems publish what happens to:
+// tasks created and changing status, agent runs and their retries,
+// approvals, gates and budget alerts. Publishers don't know who listens;
+// the audit log, notifications, webhooks and the TUI subscribe to the
+// events they care about.
+//
+// Events are typed by their data, the audit package's event types, and
+// delivered synchronously in the publisher's goroutine, in the order
+// subscribers subscribed. Publishers may hold their own locks, so
+// subscribers must not call back into them, and must be quick: slow work,
+// such as network calls, belongs in a goroutine.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+)
+
+// Event is a published event.
+type Event struct {
+	Time time.Time
+	// Level and Message are what the event is audited with.
+	Level   audit.Level
+	Message string
+	// Data is the event's typed details, such as audit.StatusTransition.
+	Data audit.Data
+	// RunID and ExecutionID tie the event to the agent run and task
+	// attempt in progress, if any.
+	RunID       string
+	ExecutionID string
+
+	// log is the audit log the event is recorded in, such as its
+	// workspace's; Publish sets it to the default log when unset.
+	log *audit.Logger
+}
+
+// Kind returns the event's operation, such as task.set_status.
+func (e Event) Kind() string {
+	return e.Data.Operation()
+}
+
+// Log returns the audit log the event is recorded in, so subscribers can
+// tell the events of one workspace from another's.
+func (e Event) Log() *audit.Logger {
+	return e.log
+}
+
+// Bus delivers published events to its subscribers.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []subscriber
+	next        int
+}
+
+type subscriber struct {
+	id int
+	fn func(Event)
+}
+
+// NewBus creates a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls fn with every event published from now on. It returns a
+// function that removes the subscription. fn may publish events itself.
+func (b *Bus) Subscribe(fn func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subscribers = append(b.subscribers, subscriber{id: id, fn: fn})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subscribers {
+			if s.id == id {
+				b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// On calls fn with the data of every event of type T published from now
+// on, such as audit.RunFinished.
+func On[T audit.Data](b *Bus, fn func(Event, T)) (unsubscribe func()) {
+	return b.Subscribe(func(e Event) {
+		if data, ok := e.Data.(T); ok {
+			fn(e, data)
+		}
+	})
+}
+
+// Publish delivers e to the bus's subscribers, stamping its time and the
+// correlation IDs of the audit log it is recorded in when unset.
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.log == nil {
+		e.log = audit.Default()
+	}
+	if e.RunID == "" && e.ExecutionID == "" {
+		ids := e.log.Correlation()
+		e.RunID, e.ExecutionID = ids.RunID, ids.ExecutionID
+	}
+
+	// Subscribers may subscribe or publish in turn
+	b.mu.RLock()
+	subscribers := append([]subscriber(nil), b.subscribers...)
+	b.mu.RUnlock()
+	for _, s := range subscribers {
+		s.fn(e)
+	}
+}
+
+// Record writes events to the audit log they were published for, or to
+// the default log. The default bus subscribes it first.
+func Record(e Event) {
+	log := e.log
+	if log == nil {
+		log = audit.Default()
+	}
+	log.Emit(e.Level, e.Message, e.Data)
+}
+
+// SubscribeLog calls fn with every event recorded in log published on b
+// from now on, such as the events of one workspace when several are open.
+func (b *Bus) SubscribeLog(log *audit.Logger, fn func(Event)) (unsubscribe func()) {
+	return b.Subscribe(func(e Event) {
+		if e.log == log {
+			fn(e)
+		}
+	})
+}
+
+// defaultBus is the bus of the package-level functions.
+var defaultBus = func() *Bus {
+	b := NewBus()
+	b.Subscribe(Record)
+	return b
+}()
+
+// Default returns the bus of the package-level functions, which records
+// every event in the audit log.
+func Default() *Bus {
+	return defaultBus
+}
+
+// Publish publishes an event on the default bus, recorded in the default
+// audit log.
+func Publish(level audit.Level, message string, data audit.Data) {
+	defaultBus.Publish(Event{Level: level, Message: message, Data: data})
+}
+
+// PublishTo publishes an event on the default bus, recorded in log rather
+// than the default audit log, such as the log of the workspace it belongs
+// to.
+func PublishTo(log *audit.Logger, level audit.Level, message string, data audit.Data) {
+	defaultBus.Publish(Event{Level: level, Message: message, Data: data, log: log})
+}
+
+// Subscribe calls fn with every event published on the default bus from
+// now on, until the returned function is called.
+func Subscribe(fn func(Event)) (unsubscribe func()) {
+	return defaultBus.Subscribe(fn)
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/correlation"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var all []string
+	unsubscribe := bus.Subscribe(func(e Event) { all = append(all, e.Kind()) })
+	var finished []audit.RunFinished
+	On(bus, func(e Event, data audit.RunFinished) { finished = append(finished, data) })
+
+	bus.Publish(Event{Level: audit.LevelInfo, Data: audit.TaskAdded{TaskID: "t-001"}})
+	bus.Publish(Event{Level: audit.LevelInfo, Data: audit.RunFinished{TaskID: "t-001", Success: true}})
+	unsubscribe()
+	bus.Publish(Event{Level: audit.LevelWarn, Data: audit.RunFinished{TaskID: "t-002"}})
+
+	if strings.Join(all, ",") != "task.registry.add,work.finish" {
+		t.Errorf("unexpected events %v", all)
+	}
+	if len(finished) != 2 || finished[0].TaskID != "t-001" || finished[1].TaskID != "t-002" {
+		t.Errorf("expected both runs delivered to the typed subscriber, got %+v", finished)
+	}
+}
+
+func TestBusSubscriberPublishes(t *testing.T) {
+	bus := NewBus()
+	var kinds []string
+	bus.Subscribe(func(e Event) {
+		kinds = append(kinds, e.Kind())
+		if _, ok := e.Data.(audit.RunFinished); ok {
+			bus.Publish(Event{Level: audit.LevelInfo, Data: audit.StatusTransition{TaskID: "t-001", To: "complete"}})
+		}
+	})
+	bus.Publish(Event{Level: audit.LevelInfo, Data: audit.RunFinished{TaskID: "t-001"}})
+	if strings.Join(kinds, ",") != "work.finish,task.set_status" {
+		t.Errorf("unexpected events %v", kinds)
+	}
+}
+
+func TestPublishRecordsInAuditLog(t *testing.T) {
+	root := t.TempDir()
+	logger, err := audit.NewLogger(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	logger.SetCorrelation(correlation.IDs{RunID: "run-1"})
+
+	var got Event
+	defer Subscribe(func(e Event) { got = e })()
+	PublishTo(logger, audit.LevelInfo, "Task status changed", audit.StatusTransition{TaskID: "t-001", From: "in_progress", To: "complete"})
+
+	if got.RunID != "run-1" || got.Time.IsZero() {
+		t.Errorf("expected the event stamped with its time and run, got %+v", got)
+	}
+	data, _ := os.ReadFile(logger.Path())
+	event, err := audit.ParseEvent([]byte(strings.TrimSpace(string(data))))
+	if err != nil {
+		t.Fatalf("expected one audit event, got %q: %v", data, err)
+	}
+	var transition audit.StatusTransition
+	if err := event.Decode(&transition); err != nil || transition.To != "complete" || event.Message != "Task status changed" {
+		t.Errorf("unexpected audit event %+v", event)
+	}
+}
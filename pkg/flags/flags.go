@@ -0,0 +1,151 @@
+// Package flags gates experimental subsystems behind feature flags, so
+// they can ship dark and be turned on per workspace (features in
+// .flo/config.yaml) or per process (FLO_FEATURES).
+package flags
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EnvVar lists flags to turn on, or off with a leading "-", separated by
+// commas (e.g. "auto_merge,-experimental_backends"). It overrides the
+// workspace config.
+const EnvVar = "FLO_FEATURES"
+
+// Known feature flags.
+const (
+	// ExperimentalBackends enables the codex and gemini agent backends.
+	ExperimentalBackends = "experimental_backends"
+	// DistributedWorkers lets tasks be claimed by workers on other machines.
+	DistributedWorkers = "distributed_workers"
+	// AutoMerge merges a task's branch once every completion gate passes.
+	AutoMerge = "auto_merge"
+)
+
+// Flag describes a feature flag.
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// Known lists every feature flag, sorted by name.
+var Known = []Flag{
+	{Name: AutoMerge, Description: "Merge a task's branch once every completion gate passes"},
+	{Name: DistributedWorkers, Description: "Let workers on other machines claim tasks"},
+	{Name: ExperimentalBackends, Description: "Enable the codex and gemini agent backends"},
+}
+
+// Lookup returns the known flag with the given name.
+func Lookup(name string) (Flag, bool) {
+	for _, f := range Known {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// Validate checks that every name is a known flag.
+func Validate(names []string) error {
+	for _, name := range names {
+		if _, ok := Lookup(name); !ok {
+			return fmt.Errorf("unknown feature flag '%s' (known: %s)", name, strings.Join(knownNames(), ", "))
+		}
+	}
+	return nil
+}
+
+func knownNames() []string {
+	names := make([]string, len(Known))
+	for i, f := range Known {
+		names[i] = f.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sources of a flag's state.
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config"
+	SourceEnv     = "env"
+)
+
+// State is the resolved value of a flag and where it came from.
+type State struct {
+	Flag
+	Enabled bool
+	Source  string
+}
+
+// Set holds the resolved state of every known flag.
+type Set struct {
+	states map[string]State
+}
+
+// Resolve combines the defaults, the workspace config and the FLO_FEATURES
+// value env, later ones winning. Unknown flag names are an error.
+func Resolve(config map[string]bool, env string) (*Set, error) {
+	s := &Set{states: make(map[string]State)}
+	for _, f := range Known {
+		s.states[f.Name] = State{Flag: f, Enabled: f.Default, Source: SourceDefault}
+	}
+
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if err := Validate(names); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		s.set(name, config[name], SourceConfig)
+	}
+
+	for _, item := range strings.Split(env, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, on := strings.TrimPrefix(item, "-"), !strings.HasPrefix(item, "-")
+		if _, ok := Lookup(name); !ok {
+			return nil, fmt.Errorf("%s: unknown feature flag '%s' (known: %s)", EnvVar, name, strings.Join(knownNames(), ", "))
+		}
+		s.set(name, on, SourceEnv)
+	}
+	return s, nil
+}
+
+func (s *Set) set(name string, on bool, source string) {
+	state := s.states[name]
+	state.Enabled = on
+	state.Source = source
+	s.states[name] = state
+}
+
+// Enabled reports whether the named flag is on. Unknown flags are off.
+func (s *Set) Enabled(name string) bool {
+	return s.states[name].Enabled
+}
+
+// List returns the state of every known flag, sorted by name.
+func (s *Set) List() []State {
+	states := make([]State, 0, len(s.states))
+	for _, f := range Known {
+		states = append(states, s.states[f.Name])
+	}
+	return states
+}
+
+// Require returns an error naming how to turn the flag on if it is off.
+// feature describes what needs it, e.g. "the gemini backend".
+func (s *Set) Require(name, feature string) error {
+	if s.Enabled(name) {
+		return nil
+	}
+	return fmt.Errorf("%s is experimental: enable it with 'features: {%s: true}' in .flo/config.yaml or %s=%s", feature, name, EnvVar, name)
+}
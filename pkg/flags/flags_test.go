@@ -0,0 +1,68 @@
+package flags
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveDefaults(t *testing.T) {
+	s, err := Resolve(nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, state := range s.List() {
+		if state.Enabled || state.Source != SourceDefault {
+			t.Errorf("expected %s off by default, got %+v", state.Name, state)
+		}
+	}
+	if len(s.List()) != len(Known) {
+		t.Errorf("expected every known flag listed, got %d", len(s.List()))
+	}
+}
+
+func TestResolveConfigAndEnv(t *testing.T) {
+	s, err := Resolve(map[string]bool{AutoMerge: true, ExperimentalBackends: true}, " distributed_workers, -experimental_backends")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name    string
+		enabled bool
+		source  string
+	}{
+		{AutoMerge, true, SourceConfig},
+		{DistributedWorkers, true, SourceEnv},
+		{ExperimentalBackends, false, SourceEnv},
+	}
+	for _, tt := range tests {
+		for _, state := range s.List() {
+			if state.Name == tt.name && (state.Enabled != tt.enabled || state.Source != tt.source) {
+				t.Errorf("%s: expected enabled=%v from %s, got %+v", tt.name, tt.enabled, tt.source, state)
+			}
+		}
+		if s.Enabled(tt.name) != tt.enabled {
+			t.Errorf("%s: expected Enabled %v", tt.name, tt.enabled)
+		}
+	}
+}
+
+func TestResolveUnknownFlag(t *testing.T) {
+	if _, err := Resolve(map[string]bool{"warp_drive": true}, ""); err == nil || !strings.Contains(err.Error(), "warp_drive") {
+		t.Errorf("expected unknown config flag error, got %v", err)
+	}
+	if _, err := Resolve(nil, "-warp_drive"); err == nil || !strings.Contains(err.Error(), EnvVar) {
+		t.Errorf("expected unknown env flag error, got %v", err)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	s, _ := Resolve(nil, "")
+	err := s.Require(ExperimentalBackends, "the gemini backend")
+	if err == nil || !strings.Contains(err.Error(), "FLO_FEATURES=experimental_backends") {
+		t.Errorf("expected an error explaining how to enable the flag, got %v", err)
+	}
+	s, _ = Resolve(nil, ExperimentalBackends)
+	if err := s.Require(ExperimentalBackends, "the gemini backend"); err != nil {
+		t.Errorf("expected no error once enabled, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+// Package freeze decides whether autonomous agent runs are paused by a
+// configured freeze window, such as every weekend or a release freeze,
+// so that no unattended agent commits land while nobody is watching.
+package freeze
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrFrozen is matched by errors refusing a run during a freeze.
+var ErrFrozen = errors.New("agent runs are frozen")
+
+// FrozenError reports the window a run was refused in and when runs may
+// start again.
+type FrozenError struct {
+	Window string
+	Until  time.Time
+}
+
+func (e *FrozenError) Error() string {
+	return fmt.Sprintf("agent runs are frozen (%s) until %s", e.Window, e.Until.Format("Mon 2006-01-02 15:04 MST"))
+}
+
+// Is reports whether target is ErrFrozen.
+func (e *FrozenError) Is(target error) bool {
+	return target == ErrFrozen
+}
+
+const (
+	weekLayout    = "Mon 15:04"
+	dateLayout    = "2006-01-02"
+	timeLayout    = "2006-01-02 15:04"
+	minutesPerDay = 24 * 60
+)
+
+// Window is a recurring weekly freeze ("Fri 18:00" to "Mon 06:00") or a
+// one-off range of dates ("2026-12-20" to "2027-01-02", both included).
+type Window struct {
+	Name string
+
+	weekly bool
+	loc    *time.Location
+	// fromDay/fromMin and toDay/toMin bound a weekly window.
+	fromDay, toDay time.Weekday
+	fromMin, toMin int
+	// start and end bound a date window, end exclusive.
+	start, end time.Time
+}
+
+// Parse parses a window running from from to to, interpreted in loc. Both
+// ends are either a weekday and time ("Fri 18:00") or a date, optionally
+// with a time ("2026-12-20", "2026-12-20 18:00"); a date without a time
+// as the end includes that whole day.
+func Parse(name, from, to string, loc *time.Location) (*Window, error) {
+	w := &Window{Name: name, loc: loc}
+	fromDay, fromMin, fromWeekly := parseWeekly(from)
+	toDay, toMin, toWeekly := parseWeekly(to)
+	switch {
+	case fromWeekly && toWeekly:
+		if fromDay == toDay && fromMin == toMin {
+			return nil, fmt.Errorf("freeze window '%s' is empty", name)
+		}
+		w.weekly = true
+		w.fromDay, w.fromMin = fromDay, fromMin
+		w.toDay, w.toMin = toDay, toMin
+		return w, nil
+	case fromWeekly || toWeekly:
+		return nil, fmt.Errorf("freeze window '%s' mixes a weekday with a date", name)
+	}
+
+	start, _, err := parseDate(from, loc)
+	if err != nil {
+		return nil, fmt.Errorf("freeze window '%s': %w", name, err)
+	}
+	end, dateOnly, err := parseDate(to, loc)
+	if err != nil {
+		return nil, fmt.Errorf("freeze window '%s': %w", name, err)
+	}
+	if dateOnly {
+		end = end.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("freeze window '%s' ends before it starts", name)
+	}
+	w.start, w.end = start, end
+	return w, nil
+}
+
+// parseWeekly parses "Fri 18:00" into a weekday and minute of the day.
+func parseWeekly(s string) (time.Weekday, int, bool) {
+	t, err := time.Parse(weekLayout, strings.TrimSpace(s))
+	if err != nil {
+		return 0, 0, false
+	}
+	// time.Parse checks the day name but doesn't keep it.
+	day, ok := weekdays[strings.ToLower(strings.Fields(s)[0])]
+	if !ok {
+		return 0, 0, false
+	}
+	return day, t.Hour()*60 + t.Minute(), true
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseDate parses a date with an optional time, reporting whether the
+// time was omitted.
+func parseDate(s string, loc *time.Location) (time.Time, bool, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation(timeLayout, s, loc); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, s, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("'%s' is not a weekday and time (Fri 18:00) or a date (2026-12-20)", s)
+	}
+	return t, true, nil
+}
+
+// End returns when the window, if it contains t, ends.
+func (w *Window) End(t time.Time) (time.Time, bool) {
+	if !w.weekly {
+		if t.Before(w.start) || !t.Before(w.end) {
+			return time.Time{}, false
+		}
+		return w.end, true
+	}
+
+	local := t.In(w.loc)
+	now := int(local.Weekday())*minutesPerDay + local.Hour()*60 + local.Minute()
+	from := int(w.fromDay)*minutesPerDay + w.fromMin
+	to := int(w.toDay)*minutesPerDay + w.toMin
+	inside := from <= now && now < to
+	if from > to {
+		inside = now >= from || now < to
+	}
+	if !inside {
+		return time.Time{}, false
+	}
+
+	days := (int(w.toDay) - int(local.Weekday()) + 7) % 7
+	if days == 0 && w.toMin <= local.Hour()*60+local.Minute() {
+		days = 7
+	}
+	end := time.Date(local.Year(), local.Month(), local.Day()+days, w.toMin/60, w.toMin%60, 0, 0, w.loc)
+	return end, true
+}
+
+// Schedule is a set of freeze windows.
+type Schedule []*Window
+
+// Active returns the window t falls in and when runs may start again,
+// following on through windows that overlap or adjoin it.
+func (s Schedule) Active(t time.Time) (*Window, time.Time, bool) {
+	var first *Window
+	until := t
+	// Bound the passes so windows covering the whole week can't loop.
+	for range len(s) + 1 {
+		moved := false
+		for _, w := range s {
+			end, ok := w.End(until)
+			if !ok || !end.After(until) {
+				continue
+			}
+			if first == nil {
+				first = w
+			}
+			until, moved = end, true
+		}
+		if !moved {
+			break
+		}
+	}
+	if first == nil {
+		return nil, time.Time{}, false
+	}
+	return first, until, true
+}
+
+// Check returns a *FrozenError if t falls in a freeze window.
+func (s Schedule) Check(t time.Time) error {
+	w, until, frozen := s.Active(t)
+	if !frozen {
+		return nil
+	}
+	return &FrozenError{Window: w.Name, Until: until}
+}
@@ -0,0 +1,108 @@
+package freeze
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, name, from, to string) *Window {
+	t.Helper()
+	w, err := Parse(name, from, to, time.UTC)
+	if err != nil {
+		t.Fatalf("Parse(%s, %s) failed: %v", from, to, err)
+	}
+	return w
+}
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestWeeklyWindow(t *testing.T) {
+	// 2026-10-16 is a Friday.
+	weekend := mustParse(t, "weekend", "Fri 18:00", "Mon 06:00")
+	tests := []struct {
+		at     string
+		frozen bool
+	}{
+		{"2026-10-16 17:59", false},
+		{"2026-10-16 18:00", true},
+		{"2026-10-18 12:00", true},
+		{"2026-10-19 05:59", true},
+		{"2026-10-19 06:00", false},
+		{"2026-10-21 12:00", false},
+	}
+	for _, tt := range tests {
+		end, frozen := weekend.End(at(tt.at))
+		if frozen != tt.frozen {
+			t.Errorf("%s: frozen = %v, want %v", tt.at, frozen, tt.frozen)
+		}
+		if frozen && !end.Equal(at("2026-10-19 06:00")) {
+			t.Errorf("%s: ends %s, want Monday 06:00", tt.at, end)
+		}
+	}
+
+	// A window within one day doesn't wrap around the week.
+	lunch := mustParse(t, "lunch", "Wed 12:00", "Wed 13:00")
+	if _, frozen := lunch.End(at("2026-10-21 12:30")); !frozen {
+		t.Error("expected Wednesday 12:30 to be frozen")
+	}
+	if _, frozen := lunch.End(at("2026-10-22 12:30")); frozen {
+		t.Error("expected Thursday 12:30 not to be frozen")
+	}
+}
+
+func TestDateWindow(t *testing.T) {
+	release := mustParse(t, "release", "2026-12-20", "2027-01-02")
+	if _, frozen := release.End(at("2026-12-19 23:59")); frozen {
+		t.Error("expected the day before to be open")
+	}
+	end, frozen := release.End(at("2027-01-02 23:00"))
+	if !frozen || !end.Equal(at("2027-01-03 00:00")) {
+		t.Errorf("expected the last day to be included, got %v %s", frozen, end)
+	}
+
+	timed := mustParse(t, "deploy", "2026-11-01 09:00", "2026-11-01 17:00")
+	if _, frozen := timed.End(at("2026-11-01 17:00")); frozen {
+		t.Error("expected the end time to be exclusive")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range [][2]string{
+		{"Fri 18:00", "2026-12-20"},
+		{"Funday 18:00", "Mon 06:00"},
+		{"2026-12-20", "2026-12-19"},
+		{"Mon 06:00", "Mon 06:00"},
+	} {
+		if _, err := Parse("bad", tt[0], tt[1], time.UTC); err == nil {
+			t.Errorf("expected error for %s to %s", tt[0], tt[1])
+		}
+	}
+}
+
+func TestScheduleCheck(t *testing.T) {
+	schedule := Schedule{
+		mustParse(t, "weekend", "Fri 18:00", "Mon 06:00"),
+		mustParse(t, "release", "2026-10-19", "2026-10-20"),
+	}
+
+	if err := schedule.Check(at("2026-10-16 12:00")); err != nil {
+		t.Errorf("expected Friday noon to be open, got %v", err)
+	}
+
+	// The weekend runs into the release freeze, so runs resume after it.
+	err := schedule.Check(at("2026-10-17 12:00"))
+	var frozen *FrozenError
+	if !errors.As(err, &frozen) || !errors.Is(err, ErrFrozen) {
+		t.Fatalf("expected a FrozenError, got %v", err)
+	}
+	if frozen.Window != "weekend" || !frozen.Until.Equal(at("2026-10-21 00:00")) {
+		t.Errorf("unexpected freeze: %s until %s", frozen.Window, frozen.Until)
+	}
+}
@@ -0,0 +1,215 @@
+package gate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/config"
+)
+
+func init() {
+	Register(DefaultKind, NewExecGate)
+}
+
+// pluginDir is where workspace-local gate plugins live, relative to the root.
+const pluginDir = ".flo/plugins"
+
+// pluginPrefix is prepended to plugin names looked up on PATH.
+const pluginPrefix = "flo-gate-"
+
+// versionTimeout bounds a plugin's --version run.
+const versionTimeout = 5 * time.Second
+
+// Request is the JSON document written to an exec gate's stdin.
+type Request struct {
+	Gate     string            `json:"gate"`
+	Worktree string            `json:"worktree"`
+	Task     RequestTask       `json:"task"`
+	Options  map[string]string `json:"options,omitempty"`
+}
+
+// RequestTask identifies the task being checked.
+type RequestTask struct {
+	ID    string `json:"id"`
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// Response is the JSON document an exec gate prints on stdout. When Passed
+// is omitted the gate passes unless a finding reaches the fail_on severity.
+type Response struct {
+	Passed   *bool     `json:"passed,omitempty"`
+	Findings []Finding `json:"findings"`
+}
+
+// Runner executes a plugin in dir with the given stdin.
+type Runner func(ctx context.Context, dir string, argv []string, stdin []byte) (stdout, stderr []byte, err error)
+
+// ExecGate runs a plugin executable that speaks the JSON gate protocol:
+// a Request on stdin and a Response on stdout. A non-zero exit fails the
+// gate; stderr is kept as the gate output.
+type ExecGate struct {
+	cfg    config.GateConfig
+	root   string
+	failOn Severity
+	runner Runner
+}
+
+// NewExecGate creates an exec gate. It is registered as the "exec" kind.
+func NewExecGate(cfg config.GateConfig, root string) (Gate, error) {
+	if cfg.Plugin == "" {
+		return nil, fmt.Errorf("gate '%s': plugin is required", cfg.Name)
+	}
+	failOn, err := ParseSeverity(cfg.FailOn)
+	if err != nil {
+		return nil, fmt.Errorf("gate '%s': %w", cfg.Name, err)
+	}
+	return &ExecGate{cfg: cfg, root: root, failOn: failOn, runner: runCommand}, nil
+}
+
+// SetRunner replaces the plugin runner (for testing).
+func (g *ExecGate) SetRunner(runner Runner) {
+	g.runner = runner
+}
+
+// Name returns the configured gate name, defaulting to the plugin.
+func (g *ExecGate) Name() string {
+	if g.cfg.Name != "" {
+		return g.cfg.Name
+	}
+	return g.cfg.Plugin
+}
+
+// Check runs the plugin in the worktree and evaluates its findings.
+func (g *ExecGate) Check(ctx context.Context, gctx Context) (*Result, error) {
+	path, err := g.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := json.Marshal(Request{
+		Gate:     g.Name(),
+		Worktree: gctx.Dir,
+		Task:     RequestTask{ID: gctx.TaskID, Type: gctx.TaskType, Title: gctx.Title},
+		Options:  g.cfg.Options,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode gate request: %w", err)
+	}
+
+	if g.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.cfg.Timeout)
+		defer cancel()
+	}
+
+	argv := append([]string{path}, g.cfg.Args...)
+	stdout, stderr, runErr := g.runner(ctx, gctx.Dir, argv, req)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("gate '%s' timed out after %s", g.Name(), g.cfg.Timeout)
+	}
+
+	result := &Result{Name: g.Name(), Output: strings.TrimSpace(string(stderr))}
+	var resp Response
+	if trimmed := bytes.TrimSpace(stdout); len(trimmed) > 0 {
+		if err := json.Unmarshal(trimmed, &resp); err != nil {
+			if runErr != nil {
+				return nil, fmt.Errorf("gate '%s' failed: %w", g.Name(), runErr)
+			}
+			return nil, fmt.Errorf("gate '%s' returned invalid output: %w", g.Name(), err)
+		}
+	} else if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return nil, fmt.Errorf("gate '%s' failed to run: %w", g.Name(), runErr)
+		}
+	}
+
+	result.Findings = resp.Findings
+	for i := range result.Findings {
+		if result.Findings[i].Severity == "" {
+			result.Findings[i].Severity = SeverityError
+		}
+	}
+
+	switch {
+	case runErr != nil:
+		result.Passed = false
+	case resp.Passed != nil:
+		result.Passed = *resp.Passed
+	default:
+		result.Passed = true
+		for _, f := range result.Findings {
+			if f.Severity.AtLeast(g.failOn) {
+				result.Passed = false
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// Version runs the plugin with --version and returns the first line it
+// prints. Plugins that don't support the flag report an error.
+func (g *ExecGate) Version(ctx context.Context) (string, error) {
+	path, err := g.resolve()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+	stdout, _, err := g.runner(ctx, g.root, []string{path, "--version"}, nil)
+	if err != nil {
+		return "", fmt.Errorf("plugin '%s' --version failed: %w", g.cfg.Plugin, err)
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(stdout)), "\n")
+	if line == "" {
+		return "", fmt.Errorf("plugin '%s' printed no version", g.cfg.Plugin)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// resolve finds the plugin executable: a path (relative to the workspace
+// root), then .flo/plugins/<name>, then flo-gate-<name> or <name> on PATH.
+func (g *ExecGate) resolve() (string, error) {
+	plugin := g.cfg.Plugin
+	if strings.ContainsRune(plugin, filepath.Separator) || strings.Contains(plugin, "/") {
+		if !filepath.IsAbs(plugin) {
+			plugin = filepath.Join(g.root, plugin)
+		}
+		return plugin, nil
+	}
+
+	local := filepath.Join(g.root, pluginDir, plugin)
+	if info, err := os.Stat(local); err == nil && !info.IsDir() {
+		return local, nil
+	}
+	for _, name := range []string{pluginPrefix + plugin, plugin} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("gate '%s': plugin '%s' not found in %s or on PATH (as %s%s)", g.Name(), g.cfg.Plugin, pluginDir, pluginPrefix, g.cfg.Plugin)
+}
+
+// runCommand runs argv in dir, feeding stdin and capturing stdout and stderr.
+func runCommand(ctx context.Context, dir string, argv []string, stdin []byte) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
@@ -0,0 +1,266 @@
+// Package gate runs custom completion gates, such as compliance scanners
+// provided by plugins, in a task worktree. Gates report structured findings
+// and are configured per task type alongside the built-in TDD and verify
+// gates.
+package gate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/events"
+)
+
+// Severity ranks a finding. Gates fail on findings at or above their
+// configured fail_on severity.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// rank orders severities; unknown severities are treated as errors so a
+// misbehaving plugin cannot pass by inventing a level.
+func (s Severity) rank() int {
+	switch Severity(strings.ToLower(string(s))) {
+	case SeverityInfo:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// AtLeast reports whether s is as severe as min.
+func (s Severity) AtLeast(min Severity) bool {
+	return s.rank() >= min.rank()
+}
+
+// ParseSeverity validates a configured severity. Empty means error.
+func ParseSeverity(s string) (Severity, error) {
+	switch Severity(strings.ToLower(s)) {
+	case "", SeverityError:
+		return SeverityError, nil
+	case SeverityWarning:
+		return SeverityWarning, nil
+	case SeverityInfo:
+		return SeverityInfo, nil
+	}
+	return "", fmt.Errorf("unknown severity '%s' (want info, warning or error)", s)
+}
+
+// Finding is a single issue reported by a gate.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule,omitempty"`
+	Message  string   `json:"message"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+}
+
+// String formats the finding as "file:line: [severity] rule: message".
+func (f Finding) String() string {
+	var b strings.Builder
+	if f.File != "" {
+		b.WriteString(f.File)
+		if f.Line > 0 {
+			fmt.Fprintf(&b, ":%d", f.Line)
+		}
+		b.WriteString(": ")
+	}
+	fmt.Fprintf(&b, "[%s] ", f.Severity)
+	if f.Rule != "" {
+		b.WriteString(f.Rule + ": ")
+	}
+	b.WriteString(f.Message)
+	return b.String()
+}
+
+// Context describes the task a gate is checking.
+type Context struct {
+	// Dir is the worktree the gate runs in.
+	Dir      string
+	TaskID   string
+	TaskType string
+	Title    string
+}
+
+// Result is the outcome of a single gate.
+type Result struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Findings []Finding     `json:"findings,omitempty"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Gate is a completion check. Check returns an error only when the gate
+// itself could not run; a gate that ran and found blocking issues returns
+// a Result with Passed false.
+type Gate interface {
+	Name() string
+	Check(ctx context.Context, gctx Context) (*Result, error)
+}
+
+// Factory creates a gate from its configuration. root is the workspace root.
+type Factory func(cfg config.GateConfig, root string) (Gate, error)
+
+// DefaultKind is the gate kind used when a gate config sets none.
+const DefaultKind = "exec"
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register makes a gate kind available to configs. It panics if the kind is
+// registered twice.
+func Register(kind string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		panic("gate: Register factory is nil")
+	}
+	if _, dup := factories[kind]; dup {
+		panic("gate: Register called twice for kind " + kind)
+	}
+	factories[kind] = factory
+}
+
+// Kinds returns the registered gate kinds in sorted order.
+func Kinds() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	var kinds []string
+	for k := range factories {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// New creates the gate described by cfg.
+func New(cfg config.GateConfig, root string) (Gate, error) {
+	kind := cfg.Kind
+	if kind == "" {
+		kind = DefaultKind
+	}
+	factoriesMu.RLock()
+	factory, ok := factories[kind]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("gate '%s': unknown kind '%s' (available: %s)", cfg.Name, kind, strings.Join(Kinds(), ", "))
+	}
+	return factory(cfg, root)
+}
+
+// SuiteResult is the outcome of running every configured gate.
+type SuiteResult struct {
+	Passed bool     `json:"passed"`
+	Gates  []Result `json:"gates"`
+}
+
+// Failed returns the gates that did not pass.
+func (r *SuiteResult) Failed() []Result {
+	var failed []Result
+	for _, g := range r.Gates {
+		if !g.Passed {
+			failed = append(failed, g)
+		}
+	}
+	return failed
+}
+
+// Err returns an error naming the failed gates, or nil if all passed.
+func (r *SuiteResult) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	names := make([]string, len(failed))
+	for i, g := range failed {
+		names[i] = g.Name
+	}
+	return fmt.Errorf("gates failed: %s", strings.Join(names, ", "))
+}
+
+// Suite runs a list of gates in order.
+type Suite struct {
+	gates []Gate
+	// log receives the suite's audit events; nil uses the default
+	// logger.
+	log *audit.Logger
+}
+
+// NewSuite creates the gates for the given configs.
+func NewSuite(cfgs []config.GateConfig, root string) (*Suite, error) {
+	s := &Suite{}
+	for _, cfg := range cfgs {
+		g, err := New(cfg, root)
+		if err != nil {
+			return nil, err
+		}
+		s.gates = append(s.gates, g)
+	}
+	return s, nil
+}
+
+// NewSuiteFromGates creates a suite from already constructed gates.
+func NewSuiteFromGates(gates ...Gate) *Suite {
+	return &Suite{gates: gates}
+}
+
+// SetAuditLogger sends the suite's audit events to l, such as the log of
+// the workspace it belongs to.
+func (s *Suite) SetAuditLogger(l *audit.Logger) {
+	s.log = l
+}
+
+// Empty reports whether the suite has no gates.
+func (s *Suite) Empty() bool {
+	return len(s.gates) == 0
+}
+
+// Run executes every gate. Unlike the verify pipeline, all gates run so
+// the reviewer sees every finding at once.
+func (s *Suite) Run(ctx context.Context, gctx Context) *SuiteResult {
+	result := &SuiteResult{Passed: true}
+	for _, g := range s.gates {
+		start := time.Now()
+		r, err := g.Check(ctx, gctx)
+		if r == nil {
+			r = &Result{}
+		}
+		r.Name = g.Name()
+		if err != nil {
+			r.Passed = false
+			r.Error = err.Error()
+		}
+		if r.Duration == 0 {
+			r.Duration = time.Since(start)
+		}
+		if !r.Passed {
+			result.Passed = false
+		}
+		result.Gates = append(result.Gates, *r)
+
+		events.PublishTo(s.log, audit.LevelInfo, "Gate finished", audit.GateChecked{
+			Gate:     r.Name,
+			TaskID:   gctx.TaskID,
+			Passed:   r.Passed,
+			Findings: len(r.Findings),
+			Duration: r.Duration.String(),
+		})
+	}
+	return result
+}
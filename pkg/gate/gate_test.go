@@ -0,0 +1,230 @@
+package gate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/config"
+)
+
+// fakeGate is a gate with a fixed outcome.
+type fakeGate struct {
+	name   string
+	result *Result
+	err    error
+}
+
+func (g *fakeGate) Name() string { return g.name }
+
+func (g *fakeGate) Check(ctx context.Context, gctx Context) (*Result, error) {
+	return g.result, g.err
+}
+
+func newExec(t *testing.T, cfg config.GateConfig, root string) *ExecGate {
+	t.Helper()
+	g, err := New(cfg, root)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return g.(*ExecGate)
+}
+
+// localPlugin creates an empty executable in .flo/plugins so resolution succeeds.
+func localPlugin(t *testing.T, root, name string) {
+	t.Helper()
+	dir := filepath.Join(root, pluginDir)
+	os.MkdirAll(dir, 0755)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	if !SeverityError.AtLeast(SeverityWarning) || SeverityInfo.AtLeast(SeverityWarning) {
+		t.Error("unexpected severity ordering")
+	}
+	if !Severity("critical").AtLeast(SeverityError) {
+		t.Error("unknown severities should rank as errors")
+	}
+	if s, err := ParseSeverity(""); err != nil || s != SeverityError {
+		t.Errorf("expected empty fail_on to default to error, got %q %v", s, err)
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("expected unknown severity to be rejected")
+	}
+}
+
+func TestNewUnknownKind(t *testing.T) {
+	_, err := New(config.GateConfig{Name: "x", Kind: "wasm"}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "exec") {
+		t.Errorf("expected unknown kind error listing available kinds, got %v", err)
+	}
+	if _, err := New(config.GateConfig{Name: "x"}, t.TempDir()); err == nil {
+		t.Error("expected exec gate without plugin to be rejected")
+	}
+}
+
+func TestExecGateProtocol(t *testing.T) {
+	root := t.TempDir()
+	localPlugin(t, root, "scan")
+	g := newExec(t, config.GateConfig{
+		Name:    "compliance",
+		Plugin:  "scan",
+		Args:    []string{"--fast"},
+		Options: map[string]string{"policy": "strict"},
+	}, root)
+
+	var gotReq Request
+	var gotArgv []string
+	g.SetRunner(func(ctx context.Context, dir string, argv []string, stdin []byte) ([]byte, []byte, error) {
+		gotArgv = argv
+		json.Unmarshal(stdin, &gotReq)
+		return []byte(`{"findings":[{"severity":"warning","rule":"LIC-1","message":"missing header","file":"a.go","line":3}]}`), []byte("scanned 1 file"), nil
+	})
+
+	result, err := g.Check(context.Background(), Context{Dir: root, TaskID: "t-1", TaskType: "build", Title: "Add a"})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !result.Passed {
+		t.Error("expected warning to pass with default fail_on error")
+	}
+	if len(result.Findings) != 1 || result.Findings[0].Line != 3 || result.Output != "scanned 1 file" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if got := result.Findings[0].String(); got != "a.go:3: [warning] LIC-1: missing header" {
+		t.Errorf("unexpected finding format: %q", got)
+	}
+	if gotArgv[0] != filepath.Join(root, pluginDir, "scan") || gotArgv[1] != "--fast" {
+		t.Errorf("unexpected argv: %v", gotArgv)
+	}
+	if gotReq.Task.ID != "t-1" || gotReq.Worktree != root || gotReq.Options["policy"] != "strict" {
+		t.Errorf("unexpected request: %+v", gotReq)
+	}
+}
+
+func TestExecGateFailOn(t *testing.T) {
+	root := t.TempDir()
+	localPlugin(t, root, "scan")
+	g := newExec(t, config.GateConfig{Name: "scan", Plugin: "scan", FailOn: "warning"}, root)
+	g.SetRunner(func(ctx context.Context, dir string, argv []string, stdin []byte) ([]byte, []byte, error) {
+		return []byte(`{"findings":[{"severity":"warning","message":"todo left"}]}`), nil, nil
+	})
+
+	result, err := g.Check(context.Background(), Context{Dir: root})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if result.Passed {
+		t.Error("expected warning to fail with fail_on warning")
+	}
+}
+
+func TestExecGateExplicitVerdictAndExit(t *testing.T) {
+	root := t.TempDir()
+	localPlugin(t, root, "scan")
+	g := newExec(t, config.GateConfig{Name: "scan", Plugin: "scan"}, root)
+
+	g.SetRunner(func(ctx context.Context, dir string, argv []string, stdin []byte) ([]byte, []byte, error) {
+		return []byte(`{"passed":false,"findings":[]}`), nil, nil
+	})
+	if result, _ := g.Check(context.Background(), Context{Dir: root}); result.Passed {
+		t.Error("expected explicit passed:false to fail the gate")
+	}
+
+	g.SetRunner(func(ctx context.Context, dir string, argv []string, stdin []byte) ([]byte, []byte, error) {
+		return []byte(`not json`), nil, nil
+	})
+	if _, err := g.Check(context.Background(), Context{Dir: root}); err == nil {
+		t.Error("expected invalid output to be an error")
+	}
+
+	g.SetRunner(func(ctx context.Context, dir string, argv []string, stdin []byte) ([]byte, []byte, error) {
+		return nil, nil, errors.New("exec format error")
+	})
+	if _, err := g.Check(context.Background(), Context{Dir: root}); err == nil {
+		t.Error("expected a plugin that cannot start to be an error")
+	}
+}
+
+func TestExecGateRealPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell plugin")
+	}
+	root := t.TempDir()
+	dir := filepath.Join(root, pluginDir)
+	os.MkdirAll(dir, 0755)
+	script := "#!/bin/sh\ncat > /dev/null\necho 'blocked' >&2\necho '{\"findings\":[{\"message\":\"secret committed\"}]}'\nexit 1\n"
+	os.WriteFile(filepath.Join(dir, "secrets"), []byte(script), 0755)
+
+	g := newExec(t, config.GateConfig{Name: "secrets", Plugin: "secrets"}, root)
+	result, err := g.Check(context.Background(), Context{Dir: root, TaskID: "t-1"})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if result.Passed || result.Output != "blocked" {
+		t.Errorf("expected failing plugin with stderr output, got %+v", result)
+	}
+	if len(result.Findings) != 1 || result.Findings[0].Severity != SeverityError {
+		t.Errorf("expected finding defaulting to error severity, got %+v", result.Findings)
+	}
+}
+
+func TestExecGatePluginNotFound(t *testing.T) {
+	g := newExec(t, config.GateConfig{Name: "scan", Plugin: "no-such-plugin-xyz"}, t.TempDir())
+	_, err := g.Check(context.Background(), Context{})
+	if err == nil || !strings.Contains(err.Error(), "flo-gate-no-such-plugin-xyz") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestExecGateVersion(t *testing.T) {
+	root := t.TempDir()
+	localPlugin(t, root, "lint")
+	g := newExec(t, config.GateConfig{Name: "lint", Plugin: "lint"}, root)
+
+	g.SetRunner(func(ctx context.Context, dir string, argv []string, stdin []byte) ([]byte, []byte, error) {
+		if argv[len(argv)-1] != "--version" {
+			t.Errorf("unexpected argv: %v", argv)
+		}
+		return []byte("lint-gate 0.3.1\nprotocol 1\n"), nil, nil
+	})
+	if v, err := g.Version(context.Background()); err != nil || v != "lint-gate 0.3.1" {
+		t.Errorf("Version = %q, %v", v, err)
+	}
+
+	g.SetRunner(func(ctx context.Context, dir string, argv []string, stdin []byte) ([]byte, []byte, error) {
+		return nil, nil, nil
+	})
+	if _, err := g.Version(context.Background()); err == nil {
+		t.Error("expected an error when the plugin prints no version")
+	}
+}
+
+func TestSuiteRunsAllGates(t *testing.T) {
+	suite := NewSuiteFromGates(
+		&fakeGate{name: "a", err: errors.New("crashed")},
+		&fakeGate{name: "b", result: &Result{Passed: true}},
+		&fakeGate{name: "c", result: &Result{Passed: false, Findings: []Finding{{Severity: SeverityError, Message: "x"}}}},
+	)
+
+	result := suite.Run(context.Background(), Context{TaskID: "t-1"})
+	if result.Passed {
+		t.Fatal("expected suite to fail")
+	}
+	if len(result.Gates) != 3 {
+		t.Fatalf("expected every gate to run, got %d", len(result.Gates))
+	}
+	if result.Gates[0].Error != "crashed" || result.Gates[1].Name != "b" {
+		t.Errorf("unexpected gate results: %+v", result.Gates)
+	}
+	if err := result.Err(); err == nil || err.Error() != "gates failed: a, c" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
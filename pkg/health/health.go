@@ -0,0 +1,277 @@
+// Package health keeps rolling statistics of agent backend runs — success
+// rate, latency, retries and circuit breaker trips — so that failover and
+// model selection can be based on how each backend has actually behaved.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/clock"
+)
+
+// Defaults for the rolling window.
+const (
+	DefaultMaxRuns = 100
+	DefaultMaxAge  = 7 * 24 * time.Hour
+)
+
+// Run is the outcome of one agent run on a backend.
+type Run struct {
+	Backend  string        `json:"backend"`
+	TaskID   string        `json:"task_id,omitempty"`
+	At       time.Time     `json:"at"`
+	Duration time.Duration `json:"duration_ns"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	// Retries is how many times the run's backend calls were retried.
+	Retries int `json:"retries,omitempty"`
+	// BreakerTrips is how many times the circuit breaker opened.
+	BreakerTrips int `json:"breaker_trips,omitempty"`
+	// Breaker is the circuit breaker's state when the run ended: closed,
+	// open or half_open.
+	Breaker string `json:"breaker,omitempty"`
+}
+
+// Stats summarizes a backend's recent runs.
+type Stats struct {
+	Backend string `json:"backend"`
+	Runs    int    `json:"runs"`
+	// SuccessRate is the fraction of runs that succeeded.
+	SuccessRate   float64       `json:"success_rate"`
+	MedianLatency time.Duration `json:"median_latency_ns"`
+	// RetryRate is the fraction of runs that needed at least one retry.
+	RetryRate    float64   `json:"retry_rate"`
+	Retries      int       `json:"retries"`
+	BreakerTrips int       `json:"breaker_trips"`
+	LastRun      time.Time `json:"last_run"`
+	// LastError is the error of the most recent failed run.
+	LastError string `json:"last_error,omitempty"`
+	// Breaker is the circuit breaker's state at the end of the most
+	// recent run, "closed" if it didn't say.
+	Breaker string `json:"breaker"`
+}
+
+// Store keeps recent runs per backend in a JSON file. Each backend keeps
+// at most MaxRuns runs, none older than MaxAge.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	clock   clock.Clock
+	maxRuns int
+	maxAge  time.Duration
+}
+
+// NewStore creates a store backed by the given file.
+func NewStore(path string) *Store {
+	return &Store{path: path, clock: clock.Real, maxRuns: DefaultMaxRuns, maxAge: DefaultMaxAge}
+}
+
+// SetClock sets the clock run times and ages are measured with.
+func (s *Store) SetClock(c clock.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock.Or(c)
+}
+
+// SetWindow sets how many runs per backend are kept, and for how long.
+// Zero keeps the default.
+func (s *Store) SetWindow(maxRuns int, maxAge time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maxRuns > 0 {
+		s.maxRuns = maxRuns
+	}
+	if maxAge > 0 {
+		s.maxAge = maxAge
+	}
+}
+
+// Record adds a run, stamping it with the current time if At is zero, and
+// drops runs that have left the window.
+func (s *Store) Record(run Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.load()
+	if err != nil {
+		return err
+	}
+	if run.At.IsZero() {
+		run.At = s.clock.Now().UTC()
+	}
+	runs[run.Backend] = append(runs[run.Backend], run)
+	s.trim(runs)
+	return s.save(runs)
+}
+
+// Runs returns the backend's runs in the window, oldest first.
+func (s *Store) Runs(backend string) ([]Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.trim(runs)
+	return runs[backend], nil
+}
+
+// LastRun returns the most recent run of a task in the window, on any
+// backend, and whether there was one.
+func (s *Store) LastRun(taskID string) (Run, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.load()
+	if err != nil {
+		return Run{}, false, err
+	}
+	s.trim(runs)
+	var last Run
+	found := false
+	for _, backendRuns := range runs {
+		for _, run := range backendRuns {
+			if run.TaskID == taskID && (!found || run.At.After(last.At)) {
+				last, found = run, true
+			}
+		}
+	}
+	return last, found, nil
+}
+
+// Stats returns the statistics of every backend with runs in the window,
+// sorted by backend name.
+func (s *Store) Stats() ([]Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.trim(runs)
+
+	var stats []Stats
+	for backend, backendRuns := range runs {
+		stats = append(stats, Summarize(backend, backendRuns))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Backend < stats[j].Backend })
+	return stats, nil
+}
+
+// StatsFor returns the statistics of one backend; Runs is zero when it
+// has none in the window.
+func (s *Store) StatsFor(backend string) (Stats, error) {
+	runs, err := s.Runs(backend)
+	if err != nil {
+		return Stats{}, err
+	}
+	return Summarize(backend, runs), nil
+}
+
+// Summarize computes the statistics of runs, which must be oldest first.
+func Summarize(backend string, runs []Run) Stats {
+	st := Stats{Backend: backend, Runs: len(runs)}
+	if len(runs) == 0 {
+		return st
+	}
+
+	var succeeded, retried int
+	latencies := make([]time.Duration, len(runs))
+	for i, run := range runs {
+		if run.Success {
+			succeeded++
+		} else if run.Error != "" {
+			st.LastError = run.Error
+		}
+		if run.Retries > 0 {
+			retried++
+		}
+		st.Retries += run.Retries
+		st.BreakerTrips += run.BreakerTrips
+		latencies[i] = run.Duration
+	}
+	st.SuccessRate = float64(succeeded) / float64(len(runs))
+	st.RetryRate = float64(retried) / float64(len(runs))
+	st.LastRun = runs[len(runs)-1].At
+	st.Breaker = runs[len(runs)-1].Breaker
+	if st.Breaker == "" {
+		st.Breaker = "closed"
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	mid := len(latencies) / 2
+	if len(latencies)%2 == 1 {
+		st.MedianLatency = latencies[mid]
+	} else {
+		st.MedianLatency = (latencies[mid-1] + latencies[mid]) / 2
+	}
+	return st
+}
+
+// trim drops runs beyond the window (must be called with lock held).
+func (s *Store) trim(runs map[string][]Run) {
+	cutoff := s.clock.Now().Add(-s.maxAge)
+	for backend, backendRuns := range runs {
+		kept := backendRuns[:0]
+		for _, run := range backendRuns {
+			if run.At.After(cutoff) {
+				kept = append(kept, run)
+			}
+		}
+		if len(kept) > s.maxRuns {
+			kept = kept[len(kept)-s.maxRuns:]
+		}
+		if len(kept) == 0 {
+			delete(runs, backend)
+			continue
+		}
+		runs[backend] = kept
+	}
+}
+
+// load reads the stats file (must be called with lock held).
+func (s *Store) load() (map[string][]Run, error) {
+	runs := make(map[string][]Run)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return runs, nil
+		}
+		return nil, fmt.Errorf("failed to read backend health: %w", err)
+	}
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse backend health: %w", err)
+	}
+	if runs == nil {
+		runs = make(map[string][]Run)
+	}
+	return runs, nil
+}
+
+// save writes the stats file via a temp file and rename (must be called
+// with lock held).
+func (s *Store) save(runs map[string][]Run) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize backend health: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write backend health: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace backend health: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/clock"
+)
+
+func TestStoreStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.json")
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	store := NewStore(path)
+	store.SetClock(fake)
+
+	runs := []Run{
+		{Backend: "claude", Duration: 10 * time.Second, Success: true},
+		{Backend: "claude", Duration: 30 * time.Second, Success: false, Error: "exit status 1", Retries: 2},
+		{Backend: "claude", Duration: 20 * time.Second, Success: true, Retries: 1, BreakerTrips: 1, Breaker: "half_open"},
+		{Backend: "copilot", Duration: 5 * time.Second, Success: true},
+		{Backend: "copilot", Duration: 7 * time.Second, Success: true},
+	}
+	for _, run := range runs {
+		fake.Advance(time.Minute)
+		if err := store.Record(run); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	// A second store reads the same file.
+	reopened := NewStore(path)
+	reopened.SetClock(fake)
+	stats, err := reopened.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Backend != "claude" || stats[1].Backend != "copilot" {
+		t.Fatalf("expected claude and copilot stats, got %+v", stats)
+	}
+
+	claude := stats[0]
+	if claude.Runs != 3 || claude.SuccessRate != 2.0/3 || claude.RetryRate != 2.0/3 {
+		t.Errorf("unexpected claude rates: %+v", claude)
+	}
+	if claude.MedianLatency != 20*time.Second {
+		t.Errorf("median = %s, want 20s", claude.MedianLatency)
+	}
+	if claude.Retries != 3 || claude.BreakerTrips != 1 || claude.LastError != "exit status 1" {
+		t.Errorf("unexpected claude counts: %+v", claude)
+	}
+	if claude.Breaker != "half_open" {
+		t.Errorf("breaker = %q, want half_open", claude.Breaker)
+	}
+	if !claude.LastRun.Equal(fake.Now().Add(-2 * time.Minute)) {
+		t.Errorf("last run = %s", claude.LastRun)
+	}
+
+	copilot := stats[1]
+	if copilot.SuccessRate != 1 || copilot.MedianLatency != 6*time.Second || copilot.Breaker != "closed" {
+		t.Errorf("unexpected copilot stats: %+v", copilot)
+	}
+}
+
+func TestStoreWindow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.json")
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	store := NewStore(path)
+	store.SetClock(fake)
+	store.SetWindow(3, 24*time.Hour)
+
+	for i := 0; i < 5; i++ {
+		store.Record(Run{Backend: "claude", Duration: time.Duration(i) * time.Second, Success: true})
+	}
+	runs, err := store.Runs("claude")
+	if err != nil || len(runs) != 3 || runs[0].Duration != 2*time.Second {
+		t.Fatalf("expected the last 3 runs, got %+v, %v", runs, err)
+	}
+
+	// Runs older than the window are dropped.
+	fake.Advance(25 * time.Hour)
+	if st, err := store.StatsFor("claude"); err != nil || st.Runs != 0 {
+		t.Errorf("expected no runs after the window, got %+v, %v", st, err)
+	}
+	if stats, _ := store.Stats(); len(stats) != 0 {
+		t.Errorf("expected no backends, got %+v", stats)
+	}
+}
+
+func TestStoreCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.json")
+	os.WriteFile(path, []byte("{not json"), 0644)
+	if _, err := NewStore(path).Stats(); err == nil {
+		t.Error("expected error for corrupt file")
+	}
+}
+
+func TestStoreLastRun(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	store := NewStore(filepath.Join(t.TempDir(), "health.json"))
+	store.SetClock(fake)
+
+	for _, run := range []Run{
+		{Backend: "claude", TaskID: "t-1", Success: false, Error: "quota"},
+		{Backend: "copilot", TaskID: "t-1", Success: true},
+		{Backend: "claude", TaskID: "t-2", Success: false},
+	} {
+		fake.Advance(time.Minute)
+		store.Record(run)
+	}
+
+	run, ok, err := store.LastRun("t-1")
+	if err != nil || !ok || run.Backend != "copilot" || !run.Success {
+		t.Errorf("expected the copilot run, got %+v, %v, %v", run, ok, err)
+	}
+	if _, ok, _ := store.LastRun("t-3"); ok {
+		t.Error("expected no run for a task never run")
+	}
+}
@@ -0,0 +1,256 @@
+// Package hook installs a git pre-commit hook that checks staged workspace
+// files — the spec, the task manifest and the config — and scans them for
+// secrets, so broken or leaking .flo state never reaches the shared repo.
+package hook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/redact"
+	"github.com/richgo/flo/pkg/shell"
+	"github.com/richgo/flo/pkg/spec"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// marker identifies hooks written by Install.
+const marker = "# Installed by flo hook install"
+
+// script is the pre-commit hook Install writes.
+const script = "#!/bin/sh\n" + marker + "; checks staged .flo changes.\nexec flo hook pre-commit\n"
+
+// Repo is the git repository a workspace lives in.
+type Repo struct {
+	dir    string
+	runner shell.Runner
+}
+
+// NewRepo returns the repository containing dir.
+func NewRepo(dir string) *Repo {
+	return &Repo{dir: dir, runner: shell.Exec}
+}
+
+// SetRunner replaces the git runner (for testing).
+func (r *Repo) SetRunner(runner shell.Runner) {
+	r.runner = runner
+}
+
+// git runs a git subcommand in the repository.
+func (r *Repo) git(ctx context.Context, args ...string) ([]byte, error) {
+	return r.runner(ctx, r.dir, append([]string{"git"}, args...))
+}
+
+// hookPath returns where git looks for the pre-commit hook, honouring
+// core.hooksPath and worktrees.
+func (r *Repo) hookPath(ctx context.Context) (string, error) {
+	out, err := r.git(ctx, "rev-parse", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	p := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(r.dir, p)
+	}
+	return p, nil
+}
+
+// Install writes the pre-commit hook and returns its path. An existing hook
+// not written by flo is only replaced with force.
+func (r *Repo) Install(ctx context.Context, force bool) (string, error) {
+	p, err := r.hookPath(ctx)
+	if err != nil {
+		return "", err
+	}
+	if existing, err := os.ReadFile(p); err == nil && !bytes.Contains(existing, []byte(marker)) && !force {
+		return "", fmt.Errorf("%s already exists and was not installed by flo (use --force to replace it)", p)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(p, []byte(script), 0755); err != nil {
+		return "", fmt.Errorf("failed to write hook: %w", err)
+	}
+	return p, nil
+}
+
+// Uninstall removes the pre-commit hook if flo installed it, and returns
+// its path.
+func (r *Repo) Uninstall(ctx context.Context) (string, error) {
+	p, err := r.hookPath(ctx)
+	if err != nil {
+		return "", err
+	}
+	existing, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read hook: %w", err)
+	}
+	if !bytes.Contains(existing, []byte(marker)) {
+		return "", fmt.Errorf("%s was not installed by flo; leaving it alone", p)
+	}
+	if err := os.Remove(p); err != nil {
+		return "", fmt.Errorf("failed to remove hook: %w", err)
+	}
+	return p, nil
+}
+
+// Finding is a problem in a staged file.
+type Finding struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the finding as "file:line: message".
+func (f Finding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message)
+	}
+	return fmt.Sprintf("%s: %s", f.File, f.Message)
+}
+
+// Options configures Check.
+type Options struct {
+	// SpecSections are the sections a staged SPEC.md must have, used when
+	// the config isn't staged too.
+	SpecSections []string
+	// Redactor finds secrets; staged lines it would redact are reported.
+	Redactor *redact.Redactor
+}
+
+// Staged lists the staged (added, copied, modified or renamed) files in a
+// .flo directory, relative to the repository root.
+func (r *Repo) Staged(ctx context.Context) ([]string, error) {
+	out, err := r.git(ctx, "diff", "--cached", "--name-only", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged files: %w", err)
+	}
+	var files []string
+	for _, f := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if f == ".flo" || strings.HasPrefix(f, ".flo/") || strings.Contains(f, "/.flo/") {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// Check validates the staged content of .flo files: only files that are
+// staged are checked, as they will be committed rather than as they are
+// in the working tree.
+func (r *Repo) Check(ctx context.Context, opts Options) ([]Finding, error) {
+	files, err := r.Staged(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	staged := make(map[string][]byte, len(files))
+	for _, f := range files {
+		data, err := r.git(ctx, "show", ":"+f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read staged %s: %w", f, err)
+		}
+		staged[f] = data
+	}
+
+	var findings []Finding
+	for _, f := range files {
+		data := staged[f]
+		dir, name := path.Split(f)
+		switch {
+		case name == ".env":
+			findings = append(findings, Finding{File: f, Message: "secrets file must not be committed"})
+			continue
+		case name == "config.yaml" && strings.HasSuffix(dir, ".flo/"):
+			if err := checkConfig(data); err != nil {
+				findings = append(findings, Finding{File: f, Message: err.Error()})
+			}
+		case name == "SPEC.md" && strings.HasSuffix(dir, ".flo/"):
+			sections := opts.SpecSections
+			if cfgData, ok := staged[dir+"config.yaml"]; ok {
+				if cfg, err := parseConfig(cfgData); err == nil {
+					sections = cfg.Spec.RequiredSections
+				}
+			}
+			result := spec.NewValidatorWithSections(sections).Validate(string(data))
+			for _, section := range result.MissingSections {
+				findings = append(findings, Finding{File: f, Message: "missing required section: " + section})
+			}
+			for _, msg := range result.Errors {
+				findings = append(findings, Finding{File: f, Message: msg})
+			}
+		case name == "manifest.json" && strings.HasSuffix(dir, ".flo/tasks/"):
+			if err := checkManifest(data); err != nil {
+				findings = append(findings, Finding{File: f, Message: err.Error()})
+			}
+		}
+		findings = append(findings, scanSecrets(f, data, opts.Redactor)...)
+	}
+	return findings, nil
+}
+
+// parseConfig parses staged config content, applying defaults.
+func parseConfig(data []byte) (*config.Config, error) {
+	var cfg *config.Config
+	err := withTempFile(data, func(p string) error {
+		var err error
+		cfg, err = config.Load(p)
+		return err
+	})
+	return cfg, err
+}
+
+// checkConfig parses and validates staged config content.
+func checkConfig(data []byte) error {
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+// checkManifest loads staged manifest content the way flo does, which
+// checks every task and that dependencies exist and don't form a cycle.
+func checkManifest(data []byte) error {
+	return withTempFile(data, func(p string) error {
+		return task.NewRegistry().Load(p)
+	})
+}
+
+// withTempFile writes data to a temporary file and calls fn with its path.
+func withTempFile(data []byte, fn func(path string) error) error {
+	f, err := os.CreateTemp("", "flo-hook-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	return fn(f.Name())
+}
+
+// scanSecrets reports the lines of a file that contain a secret.
+func scanSecrets(file string, data []byte, r *redact.Redactor) []Finding {
+	if r == nil {
+		r = redact.New(nil)
+	}
+	var findings []Finding
+	for i, line := range strings.Split(string(data), "\n") {
+		if r.String(line) != line {
+			findings = append(findings, Finding{File: file, Line: i + 1, Message: "possible secret"})
+		}
+	}
+	return findings
+}
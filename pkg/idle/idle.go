@@ -0,0 +1,95 @@
+// Package idle detects long-running servers that nobody is using, so they
+// can shut themselves down.
+package idle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Monitor tracks activity. A monitor is idle once nothing is in progress
+// and no activity has been recorded for its timeout.
+type Monitor struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	last   time.Time
+	active int
+	wake   chan struct{}
+}
+
+// New creates a monitor that is idle after timeout without activity.
+func New(timeout time.Duration) *Monitor {
+	return &Monitor{
+		timeout: timeout,
+		last:    time.Now(),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Touch records activity.
+func (m *Monitor) Touch() {
+	m.mu.Lock()
+	m.last = time.Now()
+	m.mu.Unlock()
+}
+
+// Begin records the start of work, such as a request; the monitor is not
+// idle until the returned function is called.
+func (m *Monitor) Begin() (end func()) {
+	m.mu.Lock()
+	m.active++
+	m.last = time.Now()
+	m.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			m.active--
+			m.last = time.Now()
+			m.mu.Unlock()
+			select {
+			case m.wake <- struct{}{}:
+			default:
+			}
+		})
+	}
+}
+
+// Idle reports how long the monitor has been idle, or 0 while work is in
+// progress.
+func (m *Monitor) Idle() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active > 0 {
+		return 0
+	}
+	return time.Since(m.last)
+}
+
+// Wait blocks until the monitor has been idle for its timeout, returning
+// true, or until ctx is done, returning false.
+func (m *Monitor) Wait(ctx context.Context) bool {
+	for {
+		idle := m.Idle()
+		if idle >= m.timeout {
+			return true
+		}
+		wait := m.timeout - idle
+		if idle == 0 {
+			// Busy: check again when the work ends or after a timeout.
+			wait = m.timeout
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-m.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package idle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMonitorWaitIdle(t *testing.T) {
+	m := New(30 * time.Millisecond)
+	start := time.Now()
+
+	time.Sleep(15 * time.Millisecond)
+	m.Touch()
+
+	if !m.Wait(context.Background()) {
+		t.Fatal("expected the monitor to become idle")
+	}
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Errorf("expected activity to postpone idleness, idle after %s", elapsed)
+	}
+}
+
+func TestMonitorBusyIsNotIdle(t *testing.T) {
+	m := New(20 * time.Millisecond)
+	end := m.Begin()
+
+	done := make(chan bool, 1)
+	go func() { done <- m.Wait(context.Background()) }()
+
+	select {
+	case <-done:
+		t.Fatal("expected no idleness while work is in progress")
+	case <-time.After(60 * time.Millisecond):
+	}
+	if m.Idle() != 0 {
+		t.Errorf("expected no idle time while busy, got %s", m.Idle())
+	}
+
+	end()
+	end() // ending twice is harmless
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected idleness after the work ended")
+	}
+}
+
+func TestMonitorWaitCancelled(t *testing.T) {
+	m := New(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if m.Wait(ctx) {
+		t.Error("expected Wait to return false when cancelled")
+	}
+}
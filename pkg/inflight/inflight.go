@@ -0,0 +1,94 @@
+// Package inflight caps how many flo processes do something at once, such
+// as running agent sessions on one backend. Each of the allowed number of
+// slots is a lock file in a shared directory; a process holds a slot while
+// it holds the file's lock, so a process that exits, however it exits,
+// frees its slots.
+package inflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"syscall"
+	"time"
+)
+
+// DefaultPoll is how often Acquire checks for a free slot while waiting.
+const DefaultPoll = time.Second
+
+// unsafeKey matches the characters of a key not used in slot file names.
+var unsafeKey = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// Slots hands out the slots under a directory.
+type Slots struct {
+	dir  string
+	poll time.Duration
+}
+
+// New creates the slots kept under dir, which is created when a slot is
+// first taken.
+func New(dir string) *Slots {
+	return &Slots{dir: dir, poll: DefaultPoll}
+}
+
+// SetPoll sets how often Acquire checks for a free slot while waiting.
+func (s *Slots) SetPoll(d time.Duration) {
+	s.poll = d
+}
+
+// TryAcquire takes one of max slots named key without waiting. ok is false
+// when every slot is taken. A max of zero or less is no cap.
+func (s *Slots) TryAcquire(key string, max int) (release func(), ok bool, err error) {
+	if max <= 0 {
+		return func() {}, true, nil
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, false, fmt.Errorf("failed to create slot directory: %w", err)
+	}
+	name := unsafeKey.ReplaceAllString(key, "_")
+	for i := 0; i < max; i++ {
+		file, err := os.OpenFile(filepath.Join(s.dir, fmt.Sprintf("%s.%d.lock", name, i)), os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to open slot: %w", err)
+		}
+		// Locks belong to the open file, so a process taking two slots
+		// of the same key locks two files
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return func() {
+				syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+				file.Close()
+			}, true, nil
+		}
+		file.Close()
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, fmt.Errorf("failed to lock slot: %w", err)
+		}
+	}
+	return nil, false, nil
+}
+
+// Acquire takes one of max slots named key, waiting until one is free or
+// ctx is done. onWait, if set, is called once when every slot is taken.
+// A max of zero or less is no cap.
+func (s *Slots) Acquire(ctx context.Context, key string, max int, onWait func()) (release func(), err error) {
+	waiting := false
+	for {
+		release, ok, err := s.TryAcquire(key, max)
+		if err != nil || ok {
+			return release, err
+		}
+		if !waiting && onWait != nil {
+			onWait()
+		}
+		waiting = true
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.poll):
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package inflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTryAcquire(t *testing.T) {
+	slots := New(t.TempDir())
+
+	release1, ok, err := slots.TryAcquire("backend-claude", 2)
+	if err != nil || !ok {
+		t.Fatalf("expected the first slot, got %v, %v", ok, err)
+	}
+	release2, ok, _ := slots.TryAcquire("backend-claude", 2)
+	if !ok {
+		t.Fatal("expected the second slot")
+	}
+	if _, ok, _ := slots.TryAcquire("backend-claude", 2); ok {
+		t.Error("expected no third slot")
+	}
+	if _, ok, _ := slots.TryAcquire("backend-copilot", 2); !ok {
+		t.Error("expected other keys to have their own slots")
+	}
+	if _, ok, _ := slots.TryAcquire("backend-claude", 0); !ok {
+		t.Error("expected no cap for a max of zero")
+	}
+
+	release1()
+	release3, ok, _ := slots.TryAcquire("backend-claude", 2)
+	if !ok {
+		t.Error("expected a released slot to be free again")
+	}
+	release2()
+	release3()
+}
+
+func TestAcquireWaits(t *testing.T) {
+	slots := New(t.TempDir())
+	slots.SetPoll(10 * time.Millisecond)
+
+	release, err := slots.Acquire(context.Background(), "repo/android", 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, release)
+
+	waited := 0
+	release, err = slots.Acquire(context.Background(), "repo/android", 1, func() { waited++ })
+	if err != nil || waited != 1 {
+		t.Fatalf("expected to wait once for the slot, waited %d: %v", waited, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := slots.Acquire(ctx, "repo/android", 1, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to end with the context, got %v", err)
+	}
+	release()
+}
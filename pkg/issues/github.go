@@ -0,0 +1,318 @@
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// DefaultGitHubURL is the GitHub REST API.
+const DefaultGitHubURL = "https://api.github.com"
+
+// GitHub imports the issues of a GitHub repository.
+type GitHub struct {
+	repo    string
+	labels  []string
+	token   func() string
+	baseURL string
+	client  *http.Client
+}
+
+// NewGitHub returns a tracker for the issues of repo ("org/name") carrying
+// all of labels. token returns the API token; nil or empty makes
+// unauthenticated requests, which can't close issues.
+func NewGitHub(repo string, labels []string, token func() string) *GitHub {
+	return &GitHub{
+		repo:    repo,
+		labels:  labels,
+		token:   token,
+		baseURL: DefaultGitHubURL,
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// SetBaseURL points the tracker at another API, such as GitHub Enterprise
+// or a test server.
+func (g *GitHub) SetBaseURL(base string) {
+	g.baseURL = strings.TrimSuffix(base, "/")
+}
+
+// SetClient replaces the HTTP client (for testing).
+func (g *GitHub) SetClient(client *http.Client) {
+	g.client = client
+}
+
+// GitHubRef returns the ref of issue number in repo.
+func GitHubRef(repo string, number int) string {
+	return fmt.Sprintf("github:%s#%d", repo, number)
+}
+
+// ValidRepo reports whether repo has the form "org/name".
+func ValidRepo(repo string) bool {
+	org, name, ok := strings.Cut(repo, "/")
+	return ok && org != "" && name != "" && !strings.Contains(name, "/")
+}
+
+type githubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	Labels  []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Assignees []struct {
+		Login string `json:"login"`
+	} `json:"assignees"`
+	PullRequest json.RawMessage `json:"pull_request"`
+}
+
+// Issues returns the repository's open issues with the tracker's labels,
+// leaving out pull requests.
+func (g *GitHub) Issues(ctx context.Context) ([]Issue, error) {
+	if err := offline.Check("GitHub import"); err != nil {
+		return nil, err
+	}
+	query := url.Values{"state": {"open"}, "per_page": {"100"}}
+	if len(g.labels) > 0 {
+		query.Set("labels", strings.Join(g.labels, ","))
+	}
+	next := fmt.Sprintf("%s/repos/%s/issues?%s", g.baseURL, g.repo, query.Encode())
+
+	var issues []Issue
+	for next != "" {
+		var page []githubIssue
+		resp, err := g.do(ctx, http.MethodGet, next, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, gi := range page {
+			if len(gi.PullRequest) > 0 {
+				continue
+			}
+			issues = append(issues, g.issue(gi))
+		}
+		next = nextPage(resp.Header.Get("Link"))
+	}
+	return issues, nil
+}
+
+// issue converts a GitHub issue.
+func (g *GitHub) issue(gi githubIssue) Issue {
+	is := Issue{
+		Ref:   GitHubRef(g.repo, gi.Number),
+		URL:   gi.HTMLURL,
+		Title: gi.Title,
+		Body:  gi.Body,
+		Links: githubLinks(g.repo, gi.Body),
+	}
+	for _, l := range gi.Labels {
+		is.Labels = append(is.Labels, l.Name)
+	}
+	for _, a := range gi.Assignees {
+		is.Assignees = append(is.Assignees, a.Login)
+	}
+	return is
+}
+
+// SetStatus closes the issue when its task completes.
+func (g *GitHub) SetStatus(ctx context.Context, ref string, status task.Status) error {
+	if status != task.StatusComplete {
+		return nil
+	}
+	repo, number, err := parseGitHubRef(ref)
+	if err != nil {
+		return err
+	}
+	target := fmt.Sprintf("%s/repos/%s/issues/%s", g.baseURL, repo, number)
+	body := map[string]string{"state": "closed", "state_reason": "completed"}
+	_, err = g.do(ctx, http.MethodPatch, target, body, nil)
+	return err
+}
+
+// ErrProtectedBranch is returned by CheckMerge for a branch flo mustn't
+// push to.
+var ErrProtectedBranch = errors.New("branch is protected")
+
+// Protection is how a branch is protected on GitHub.
+type Protection struct {
+	Branch    string
+	Protected bool
+	// RequiredChecks are the status checks that must pass before a pull
+	// request can merge into the branch.
+	RequiredChecks []string
+}
+
+type githubBranch struct {
+	Name       string `json:"name"`
+	Protected  bool   `json:"protected"`
+	Protection struct {
+		RequiredStatusChecks struct {
+			Contexts []string `json:"contexts"`
+			Checks   []struct {
+				Context string `json:"context"`
+			} `json:"checks"`
+		} `json:"required_status_checks"`
+	} `json:"protection"`
+}
+
+// BranchProtection returns the protection of branch, or of the
+// repository's default branch when branch is empty.
+func (g *GitHub) BranchProtection(ctx context.Context, branch string) (*Protection, error) {
+	if err := offline.Check("GitHub branch protection check"); err != nil {
+		return nil, err
+	}
+	if branch == "" {
+		var repo struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if _, err := g.do(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s", g.baseURL, g.repo), nil, &repo); err != nil {
+			return nil, fmt.Errorf("%w (check issues.github.repo and its token)", err)
+		}
+		branch = repo.DefaultBranch
+	}
+
+	var gb githubBranch
+	target := fmt.Sprintf("%s/repos/%s/branches/%s", g.baseURL, g.repo, url.PathEscape(branch))
+	if _, err := g.do(ctx, http.MethodGet, target, nil, &gb); err != nil {
+		return nil, fmt.Errorf("%w (check that %s has a branch %s)", err, g.repo, branch)
+	}
+	p := &Protection{Branch: branch, Protected: gb.Protected}
+	seen := make(map[string]bool)
+	checks := gb.Protection.RequiredStatusChecks
+	for _, name := range checks.Contexts {
+		if !seen[name] {
+			seen[name] = true
+			p.RequiredChecks = append(p.RequiredChecks, name)
+		}
+	}
+	for _, c := range checks.Checks {
+		if !seen[c.Context] {
+			seen[c.Context] = true
+			p.RequiredChecks = append(p.RequiredChecks, c.Context)
+		}
+	}
+	return p, nil
+}
+
+// CheckMerge checks that flo may merge task branches into branch (the
+// default branch when empty) by pushing to it, failing with
+// ErrProtectedBranch and what to do instead when the branch is protected,
+// rather than leaving the push to fail with GitHub's error. It is for the
+// auto_merge merge step to call before pushing; nothing merges yet.
+func (g *GitHub) CheckMerge(ctx context.Context, branch string) error {
+	p, err := g.BranchProtection(ctx, branch)
+	if err != nil {
+		return err
+	}
+	if !p.Protected {
+		return nil
+	}
+	checks := ""
+	if len(p.RequiredChecks) > 0 {
+		checks = fmt.Sprintf(", requiring %s to pass", strings.Join(p.RequiredChecks, ", "))
+	}
+	return fmt.Errorf("%w: %s of %s is protected%s, so auto_merge can't push to it; "+
+		"turn auto_merge off (FLO_FEATURES=-auto_merge) and merge task branches through pull requests",
+		ErrProtectedBranch, p.Branch, g.repo, checks)
+}
+
+// do makes an API request, encoding body and decoding the response into
+// out when they are set.
+func (g *GitHub) do(ctx context.Context, method, target string, body, out interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.token != nil {
+		if token := g.token(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, apiError(strings.ToLower(method)+" "+req.URL.Path, resp.Status)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("failed to decode GitHub response: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+var linkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPage returns the next page URL from a Link header, if any.
+func nextPage(link string) string {
+	if m := linkNext.FindStringSubmatch(link); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// githubDependency matches "depends on #12", "blocked by org/name#12" and
+// "depends on https://github.com/org/name/issues/12".
+var githubDependency = regexp.MustCompile(
+	`(?i)(?:depends on|blocked by)\s+(?:https://github\.com/([\w.-]+/[\w.-]+)/issues/|([\w.-]+/[\w.-]+)?#)(\d+)`)
+
+// githubLinks returns the refs of the issues body says its issue in repo
+// depends on.
+func githubLinks(repo, body string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, m := range githubDependency.FindAllStringSubmatch(body, -1) {
+		target := repo
+		if m[1] != "" {
+			target = m[1]
+		} else if m[2] != "" {
+			target = m[2]
+		}
+		ref := "github:" + target + "#" + m[3]
+		if !seen[ref] {
+			seen[ref] = true
+			links = append(links, ref)
+		}
+	}
+	return links
+}
+
+// parseGitHubRef splits "github:org/name#12" into its repo and number.
+func parseGitHubRef(ref string) (repo, number string, err error) {
+	rest, ok := strings.CutPrefix(ref, "github:")
+	if ok {
+		repo, number, ok = strings.Cut(rest, "#")
+	}
+	if !ok || !ValidRepo(repo) || number == "" {
+		return "", "", fmt.Errorf("'%s' is not a GitHub issue ref", ref)
+	}
+	return repo, number, nil
+}
@@ -0,0 +1,184 @@
+// Package issues imports tasks from issue trackers and pushes task status
+// back to the issues they came from.
+package issues
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// requestTimeout bounds each tracker API request.
+const requestTimeout = 15 * time.Second
+
+// Issue is an issue to import as a task.
+type Issue struct {
+	// Ref identifies the issue across trackers, e.g. "github:org/name#12".
+	Ref   string
+	URL   string
+	Title string
+	Body  string
+	// Labels are the issue's label names.
+	Labels []string
+	// Assignees are the logins of the people the issue is assigned to.
+	Assignees []string
+	// Links are the refs of issues this one depends on.
+	Links []string
+	// Priority is the tracker's priority, 1 most urgent, or 0 when it has
+	// none; a priority label takes precedence.
+	Priority int
+}
+
+// Tracker is an issue tracker tasks are imported from.
+type Tracker interface {
+	// Issues returns the open issues matching the tracker's filters.
+	Issues(ctx context.Context) ([]Issue, error)
+	// SetStatus updates the issue ref to reflect a task's new status.
+	// Statuses the tracker has no equivalent for are ignored.
+	SetStatus(ctx context.Context, ref string, status task.Status) error
+}
+
+// TrackerOf returns the tracker name of a ref, "github" for
+// "github:org/name#12".
+func TrackerOf(ref string) string {
+	name, _, _ := strings.Cut(ref, ":")
+	return name
+}
+
+// Mapping is how an issue's labels translate into task fields.
+type Mapping struct {
+	// Type is the first label naming a task type.
+	Type string
+	// Priority comes from a "priority:N" or "pN" label; HasPriority is
+	// false when there is none.
+	Priority    int
+	HasPriority bool
+}
+
+var priorityLabel = regexp.MustCompile(`(?i)^(?:priority[:/ -]?|p)(\d+)$`)
+
+// MapLabels maps labels onto the task types in types and a priority.
+func MapLabels(labels, types []string) Mapping {
+	known := make(map[string]bool)
+	for _, t := range types {
+		known[t] = true
+	}
+	var m Mapping
+	for _, label := range labels {
+		if m.Type == "" && known[label] {
+			m.Type = label
+		}
+		if match := priorityLabel.FindStringSubmatch(label); match != nil && !m.HasPriority {
+			m.Priority, _ = strconv.Atoi(match[1])
+			m.HasPriority = true
+		}
+	}
+	return m
+}
+
+// Syncer pushes task status changes to the issues tasks were imported
+// from, in the background. Failures are audited, never returned.
+type Syncer struct {
+	mu       sync.Mutex
+	trackers map[string]Tracker
+	secret   func(name string) string
+	pending  sync.WaitGroup
+}
+
+// NewSyncer creates a syncer with no trackers. Secrets are read from the
+// environment until SetSecretLookup says otherwise.
+func NewSyncer() *Syncer {
+	return &Syncer{trackers: make(map[string]Tracker), secret: os.Getenv}
+}
+
+// SetTracker syncs the issues of the tracker name with t; nil stops
+// syncing them.
+func (s *Syncer) SetTracker(name string, t Tracker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t == nil {
+		delete(s.trackers, name)
+		return
+	}
+	s.trackers[name] = t
+}
+
+// SetSecretLookup sets how tracker tokens are resolved.
+func (s *Syncer) SetSecretLookup(lookup func(name string) string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secret = lookup
+}
+
+// Secret resolves a tracker token by name.
+func (s *Syncer) Secret(name string) string {
+	s.mu.Lock()
+	lookup := s.secret
+	s.mu.Unlock()
+	return lookup(name)
+}
+
+// Push updates the issue ref to status when its tracker is synced.
+func (s *Syncer) Push(ref string, status task.Status) {
+	if s == nil || ref == "" || offline.Enabled() {
+		return
+	}
+	s.mu.Lock()
+	t, ok := s.trackers[TrackerOf(ref)]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+		if err := t.SetStatus(ctx, ref, status); err != nil {
+			audit.Warn("issues.sync", "Failed to sync issue status", map[string]interface{}{
+				"issue":  ref,
+				"status": string(status),
+				"error":  err.Error(),
+			})
+			return
+		}
+		audit.Info("issues.sync", "Issue status synced", map[string]interface{}{
+			"issue":  ref,
+			"status": string(status),
+		})
+	}()
+}
+
+// Wait waits up to timeout for status pushes in progress, and reports
+// whether they all finished.
+func (s *Syncer) Wait(timeout time.Duration) bool {
+	if s == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// apiError describes a failed tracker API response.
+func apiError(action, status string) error {
+	return fmt.Errorf("failed to %s: %s", action, status)
+}
@@ -0,0 +1,307 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestGitHubIssues(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("page") == "" {
+			if got := r.URL.Query().Get("labels"); got != "feature-x,ready" {
+				t.Errorf("unexpected labels filter %q", got)
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/app/issues?page=2>; rel="next"`, srv.URL))
+			fmt.Fprint(w, `[
+				{"number": 1, "title": "Login", "body": "Add login", "html_url": "https://github.com/org/app/issues/1",
+				 "labels": [{"name": "feature-x"}, {"name": "p1"}], "assignees": [{"login": "ana"}]},
+				{"number": 2, "title": "A pull request", "pull_request": {"url": "x"}}
+			]`)
+			return
+		}
+		fmt.Fprint(w, `[{"number": 3, "title": "Logout", "body": "Depends on #1 and blocked by other/lib#7"}]`)
+	}))
+	defer srv.Close()
+
+	g := NewGitHub("org/app", []string{"feature-x", "ready"}, func() string { return "tok" })
+	g.SetBaseURL(srv.URL)
+	got, err := g.Issues(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Issue{
+		{
+			Ref: "github:org/app#1", URL: "https://github.com/org/app/issues/1", Title: "Login", Body: "Add login",
+			Labels: []string{"feature-x", "p1"}, Assignees: []string{"ana"},
+		},
+		{
+			Ref: "github:org/app#3", Title: "Logout", Body: "Depends on #1 and blocked by other/lib#7",
+			Links: []string{"github:org/app#1", "github:other/lib#7"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestGitHubSetStatus(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var fields map[string]string
+		json.Unmarshal(body, &fields)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+fields["state"])
+		mu.Unlock()
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	g := NewGitHub("org/app", nil, nil)
+	g.SetBaseURL(srv.URL)
+	ctx := context.Background()
+	if err := g.SetStatus(ctx, "github:org/app#4", task.StatusInProgress); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetStatus(ctx, "github:org/app#4", task.StatusComplete); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetStatus(ctx, "linear:ENG-1", task.StatusComplete); err == nil {
+		t.Error("expected a non-GitHub ref to be rejected")
+	}
+	if want := []string{"PATCH /repos/org/app/issues/4 closed"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests %q, want %q", requests, want)
+	}
+}
+
+func TestGitHubOffline(t *testing.T) {
+	offline.Set(true)
+	defer offline.Set(false)
+	if _, err := NewGitHub("org/app", nil, nil).Issues(context.Background()); err == nil {
+		t.Error("expected the import to fail offline")
+	}
+}
+
+func TestGitHubCheckMerge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/app":
+			fmt.Fprint(w, `{"default_branch": "main"}`)
+		case "/repos/org/app/branches/main":
+			fmt.Fprint(w, `{"name": "main", "protected": true, "protection": {"required_status_checks": {
+				"contexts": ["ci/test", "lint"], "checks": [{"context": "ci/test"}, {"context": "build"}]}}}`)
+		case "/repos/org/app/branches/dev":
+			fmt.Fprint(w, `{"name": "dev", "protected": false}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	g := NewGitHub("org/app", nil, nil)
+	g.SetBaseURL(srv.URL)
+	ctx := context.Background()
+	p, err := g.BranchProtection(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Protection{Branch: "main", Protected: true, RequiredChecks: []string{"ci/test", "lint", "build"}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+
+	err = g.CheckMerge(ctx, "")
+	if !errors.Is(err, ErrProtectedBranch) || !strings.Contains(err.Error(), "ci/test, lint, build") {
+		t.Errorf("expected the default branch refused with its required checks, got %v", err)
+	}
+	if err := g.CheckMerge(ctx, "dev"); err != nil {
+		t.Errorf("expected an unprotected branch allowed, got %v", err)
+	}
+	if err := g.CheckMerge(ctx, "gone"); err == nil || !strings.Contains(err.Error(), "has a branch gone") {
+		t.Errorf("expected a missing branch to fail with guidance, got %v", err)
+	}
+}
+
+func TestMapLabels(t *testing.T) {
+	types := []string{"bugfix", "feature"}
+	tests := []struct {
+		labels []string
+		want   Mapping
+	}{
+		{nil, Mapping{}},
+		{[]string{"feature-x", "bugfix", "feature"}, Mapping{Type: "bugfix"}},
+		{[]string{"priority:2"}, Mapping{Priority: 2, HasPriority: true}},
+		{[]string{"P0", "feature", "p3"}, Mapping{Type: "feature", Priority: 0, HasPriority: true}},
+		{[]string{"pending"}, Mapping{}},
+	}
+	for _, tt := range tests {
+		if got := MapLabels(tt.labels, types); got != tt.want {
+			t.Errorf("MapLabels(%q) = %+v, want %+v", tt.labels, got, tt.want)
+		}
+	}
+}
+
+// fakeTracker records status updates.
+type fakeTracker struct {
+	mu      sync.Mutex
+	updates []string
+}
+
+func (f *fakeTracker) Issues(ctx context.Context) ([]Issue, error) { return nil, nil }
+
+func (f *fakeTracker) SetStatus(ctx context.Context, ref string, status task.Status) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updates = append(f.updates, ref+" "+string(status))
+	return nil
+}
+
+func TestSyncerPush(t *testing.T) {
+	tracker := &fakeTracker{}
+	s := NewSyncer()
+	s.SetTracker("github", tracker)
+
+	s.Push("github:org/app#1", task.StatusComplete)
+	s.Push("linear:ENG-1", task.StatusComplete)
+	s.Push("", task.StatusComplete)
+	if !s.Wait(time.Second) {
+		t.Fatal("pushes didn't finish")
+	}
+	if want := []string{"github:org/app#1 complete"}; !reflect.DeepEqual(tracker.updates, want) {
+		t.Errorf("got %q, want %q", tracker.updates, want)
+	}
+
+	s.SetTracker("github", nil)
+	s.Push("github:org/app#2", task.StatusComplete)
+	s.Wait(time.Second)
+	if len(tracker.updates) != 1 {
+		t.Errorf("expected no push after the tracker was removed, got %q", tracker.updates)
+	}
+}
+
+// graphQL answers Linear queries by the operation name, recording the
+// variables of each.
+type graphQL struct {
+	mu        sync.Mutex
+	responses map[string][]string
+	calls     []map[string]interface{}
+}
+
+func (g *graphQL) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls = append(g.calls, req.Variables)
+	for op, responses := range g.responses {
+		if len(responses) > 0 && strings.HasPrefix(req.Query, op) {
+			g.responses[op] = responses[1:]
+			fmt.Fprint(w, responses[0])
+			return
+		}
+	}
+	fmt.Fprint(w, `{"errors": [{"message": "unexpected query"}]}`)
+}
+
+func TestLinearIssues(t *testing.T) {
+	gql := &graphQL{responses: map[string][]string{"query Issues": {
+		`{"data": {"issues": {"nodes": [
+			{"identifier": "ENG-1", "title": "Login", "description": "Add login", "url": "https://linear.app/x/issue/ENG-1",
+			 "priority": 2, "labels": {"nodes": [{"name": "feature-x"}]}, "assignee": {"displayName": "Ana"},
+			 "inverseRelations": {"nodes": []}}
+		], "pageInfo": {"hasNextPage": true, "endCursor": "c1"}}}}`,
+		`{"data": {"issues": {"nodes": [
+			{"identifier": "ENG-2", "title": "Logout", "labels": {"nodes": []},
+			 "inverseRelations": {"nodes": [{"type": "blocks", "issue": {"identifier": "ENG-1"}}, {"type": "related", "issue": {"identifier": "ENG-9"}}]}}
+		], "pageInfo": {"hasNextPage": false}}}}`,
+	}}}
+	srv := httptest.NewServer(gql)
+	defer srv.Close()
+
+	l := NewLinear(LinearFilter{Team: "ENG", Project: "Auth", Labels: []string{"feature-x"}}, nil)
+	l.SetURL(srv.URL)
+	got, err := l.Issues(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Issue{
+		{
+			Ref: "linear:ENG-1", URL: "https://linear.app/x/issue/ENG-1", Title: "Login", Body: "Add login",
+			Labels: []string{"feature-x"}, Assignees: []string{"Ana"}, Priority: 2,
+		},
+		{Ref: "linear:ENG-2", Title: "Logout", Links: []string{"linear:ENG-1"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	filter, _ := json.Marshal(gql.calls[0]["filter"])
+	for _, part := range []string{`"team":{"key":{"eq":"ENG"}}`, `"project":{"name":{"eq":"Auth"}}`, `"name":{"eq":"feature-x"}`} {
+		if !strings.Contains(string(filter), part) {
+			t.Errorf("expected the filter to contain %s, got %s", part, filter)
+		}
+	}
+	if gql.calls[1]["after"] != "c1" {
+		t.Errorf("expected the second page after c1, got %v", gql.calls[1]["after"])
+	}
+}
+
+func TestLinearSetStatus(t *testing.T) {
+	states := `{"data": {"issue": {"team": {"states": {"nodes": [
+		{"id": "s-todo", "name": "Todo", "type": "unstarted", "position": 1},
+		{"id": "s-review", "name": "In Review", "type": "started", "position": 3},
+		{"id": "s-doing", "name": "In Progress", "type": "started", "position": 2},
+		{"id": "s-done", "name": "Done", "type": "completed", "position": 4}
+	]}}}}}`
+	gql := &graphQL{responses: map[string][]string{
+		"query States":    {states, states},
+		"mutation Update": {`{"data": {"issueUpdate": {"success": true}}}`, `{"data": {"issueUpdate": {"success": true}}}`},
+	}}
+	srv := httptest.NewServer(gql)
+	defer srv.Close()
+
+	l := NewLinear(LinearFilter{Team: "ENG"}, nil)
+	l.SetURL(srv.URL)
+	l.SetStates(map[string]string{"complete": "in review"})
+	ctx := context.Background()
+	if err := l.SetStatus(ctx, "linear:ENG-1", task.StatusInProgress); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetStatus(ctx, "linear:ENG-1", task.StatusComplete); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetStatus(ctx, "linear:ENG-1", task.StatusFailed); err != nil {
+		t.Fatal(err)
+	}
+
+	var moved []interface{}
+	for _, vars := range gql.calls {
+		if id, ok := vars["stateId"]; ok {
+			moved = append(moved, id)
+		}
+	}
+	if want := []interface{}{"s-doing", "s-review"}; !reflect.DeepEqual(moved, want) {
+		t.Errorf("got state moves %v, want %v", moved, want)
+	}
+}
@@ -0,0 +1,312 @@
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// DefaultLinearURL is the Linear GraphQL API.
+const DefaultLinearURL = "https://api.linear.app/graphql"
+
+// LinearFilter selects the Linear issues to import.
+type LinearFilter struct {
+	// Team is the team key, such as "ENG".
+	Team string
+	// Project is a project name; empty imports from every project.
+	Project string
+	// Labels selects issues carrying all of them.
+	Labels []string
+}
+
+// linearStateTypes are the workflow state types task statuses move issues
+// to by default. Failed tasks leave their issue as it is.
+var linearStateTypes = map[task.Status]string{
+	task.StatusPending:    "unstarted",
+	task.StatusInProgress: "started",
+	task.StatusComplete:   "completed",
+}
+
+// Linear imports the issues of a Linear team.
+type Linear struct {
+	filter LinearFilter
+	states map[string]string
+	token  func() string
+	url    string
+	client *http.Client
+}
+
+// NewLinear returns a tracker for the Linear issues filter selects. token
+// returns the API key.
+func NewLinear(filter LinearFilter, token func() string) *Linear {
+	return &Linear{
+		filter: filter,
+		token:  token,
+		url:    DefaultLinearURL,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// SetStates names the workflow state each task status (pending,
+// in_progress, complete, failed) moves an issue to, overriding the
+// team's first state of the matching type.
+func (l *Linear) SetStates(states map[string]string) {
+	l.states = states
+}
+
+// SetURL points the tracker at another API endpoint, such as a test
+// server.
+func (l *Linear) SetURL(url string) {
+	l.url = url
+}
+
+// SetClient replaces the HTTP client (for testing).
+func (l *Linear) SetClient(client *http.Client) {
+	l.client = client
+}
+
+// LinearRef returns the ref of the issue with identifier, such as
+// "ENG-123".
+func LinearRef(identifier string) string {
+	return "linear:" + identifier
+}
+
+const linearIssuesQuery = `query Issues($filter: IssueFilter, $after: String) {
+  issues(filter: $filter, first: 100, after: $after) {
+    nodes {
+      identifier
+      title
+      description
+      url
+      priority
+      labels { nodes { name } }
+      assignee { displayName }
+      inverseRelations { nodes { type issue { identifier } } }
+    }
+    pageInfo { hasNextPage endCursor }
+  }
+}`
+
+type linearIssue struct {
+	Identifier  string `json:"identifier"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	Priority    int    `json:"priority"`
+	Labels      struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Assignee *struct {
+		DisplayName string `json:"displayName"`
+	} `json:"assignee"`
+	InverseRelations struct {
+		Nodes []struct {
+			Type  string `json:"type"`
+			Issue struct {
+				Identifier string `json:"identifier"`
+			} `json:"issue"`
+		} `json:"nodes"`
+	} `json:"inverseRelations"`
+}
+
+// Issues returns the open issues matching the tracker's filter: those not
+// completed or canceled.
+func (l *Linear) Issues(ctx context.Context) ([]Issue, error) {
+	if err := offline.Check("Linear import"); err != nil {
+		return nil, err
+	}
+	filter := map[string]interface{}{
+		"team":  map[string]interface{}{"key": map[string]string{"eq": l.filter.Team}},
+		"state": map[string]interface{}{"type": map[string][]string{"nin": {"completed", "canceled"}}},
+	}
+	if l.filter.Project != "" {
+		filter["project"] = map[string]interface{}{"name": map[string]string{"eq": l.filter.Project}}
+	}
+	if len(l.filter.Labels) > 0 {
+		var all []interface{}
+		for _, label := range l.filter.Labels {
+			all = append(all, map[string]interface{}{
+				"labels": map[string]interface{}{"some": map[string]interface{}{"name": map[string]string{"eq": label}}},
+			})
+		}
+		filter["and"] = all
+	}
+
+	var issues []Issue
+	var after *string
+	for {
+		var data struct {
+			Issues struct {
+				Nodes    []linearIssue `json:"nodes"`
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+			} `json:"issues"`
+		}
+		vars := map[string]interface{}{"filter": filter, "after": after}
+		if err := l.query(ctx, linearIssuesQuery, vars, &data); err != nil {
+			return nil, err
+		}
+		for _, li := range data.Issues.Nodes {
+			issues = append(issues, linearToIssue(li))
+		}
+		if !data.Issues.PageInfo.HasNextPage {
+			return issues, nil
+		}
+		cursor := data.Issues.PageInfo.EndCursor
+		after = &cursor
+	}
+}
+
+// linearToIssue converts a Linear issue. Issues that block it become its
+// links.
+func linearToIssue(li linearIssue) Issue {
+	is := Issue{
+		Ref:      LinearRef(li.Identifier),
+		URL:      li.URL,
+		Title:    li.Title,
+		Body:     li.Description,
+		Priority: li.Priority,
+	}
+	for _, l := range li.Labels.Nodes {
+		is.Labels = append(is.Labels, l.Name)
+	}
+	if li.Assignee != nil {
+		is.Assignees = []string{li.Assignee.DisplayName}
+	}
+	for _, r := range li.InverseRelations.Nodes {
+		if r.Type == "blocks" {
+			is.Links = append(is.Links, LinearRef(r.Issue.Identifier))
+		}
+	}
+	return is
+}
+
+const linearStatesQuery = `query States($id: String!) {
+  issue(id: $id) { team { states { nodes { id name type position } } } }
+}`
+
+const linearUpdateMutation = `mutation Update($id: String!, $stateId: String!) {
+  issueUpdate(id: $id, input: { stateId: $stateId }) { success }
+}`
+
+type linearState struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Position float64 `json:"position"`
+}
+
+// SetStatus moves the issue to the workflow state for status.
+func (l *Linear) SetStatus(ctx context.Context, ref string, status task.Status) error {
+	identifier, ok := strings.CutPrefix(ref, "linear:")
+	if !ok || identifier == "" {
+		return fmt.Errorf("'%s' is not a Linear issue ref", ref)
+	}
+	name, stateType := l.states[string(status)], linearStateTypes[status]
+	if name == "" && stateType == "" {
+		return nil
+	}
+
+	var data struct {
+		Issue struct {
+			Team struct {
+				States struct {
+					Nodes []linearState `json:"nodes"`
+				} `json:"states"`
+			} `json:"team"`
+		} `json:"issue"`
+	}
+	if err := l.query(ctx, linearStatesQuery, map[string]interface{}{"id": identifier}, &data); err != nil {
+		return err
+	}
+	state := pickLinearState(data.Issue.Team.States.Nodes, name, stateType)
+	if state == nil {
+		if name != "" {
+			return fmt.Errorf("Linear team of %s has no state '%s'", identifier, name)
+		}
+		return fmt.Errorf("Linear team of %s has no %s state", identifier, stateType)
+	}
+
+	var updated struct {
+		IssueUpdate struct {
+			Success bool `json:"success"`
+		} `json:"issueUpdate"`
+	}
+	vars := map[string]interface{}{"id": identifier, "stateId": state.ID}
+	if err := l.query(ctx, linearUpdateMutation, vars, &updated); err != nil {
+		return err
+	}
+	if !updated.IssueUpdate.Success {
+		return fmt.Errorf("Linear didn't update %s", identifier)
+	}
+	return nil
+}
+
+// pickLinearState returns the state called name, or without a name the
+// first state of stateType in the team's workflow order.
+func pickLinearState(states []linearState, name, stateType string) *linearState {
+	var found *linearState
+	for i := range states {
+		s := &states[i]
+		if name != "" {
+			if strings.EqualFold(s.Name, name) {
+				return s
+			}
+			continue
+		}
+		if s.Type == stateType && (found == nil || s.Position < found.Position) {
+			found = s
+		}
+	}
+	return found
+}
+
+// query runs a GraphQL request, decoding its data into out.
+func (l *Linear) query(ctx context.Context, query string, vars map[string]interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.token != nil {
+		if token := l.token(); token != "" {
+			req.Header.Set("Authorization", token)
+		}
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Linear request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError("query Linear", resp.Status)
+	}
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode Linear response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("Linear: %s", result.Errors[0].Message)
+	}
+	return json.Unmarshal(result.Data, out)
+}
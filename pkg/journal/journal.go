@@ -0,0 +1,207 @@
+// Package journal records the progress of a flo run, which works through
+// several tasks, as it goes: which tasks it attempted and how each attempt
+// ended. The journal is saved after every change, so a run that crashes or
+// is interrupted can be resumed where it stopped.
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNotFound is returned by Load for a run without a journal.
+var ErrNotFound = errors.New("run not found")
+
+// Outcome is how an attempt at a task ended.
+type Outcome string
+
+// Attempt outcomes.
+const (
+	// OutcomeRunning is an attempt still in progress, or one whose run
+	// stopped before it ended.
+	OutcomeRunning Outcome = "running"
+	// OutcomeComplete and OutcomeFailed are attempts that left the task
+	// complete or failed.
+	OutcomeComplete Outcome = "complete"
+	OutcomeFailed   Outcome = "failed"
+	// OutcomeReview is an attempt whose changes are awaiting review.
+	OutcomeReview Outcome = "review"
+	// OutcomeInterrupted is an attempt that stopped with an error, such as
+	// exhausted quota, leaving the task in progress.
+	OutcomeInterrupted Outcome = "interrupted"
+	// OutcomeSkipped is an attempt refused before the task was claimed,
+	// such as during a freeze.
+	OutcomeSkipped Outcome = "skipped"
+)
+
+// Settled reports whether the outcome is final, so a resumed run doesn't
+// attempt the task again.
+func (o Outcome) Settled() bool {
+	return o == OutcomeComplete || o == OutcomeFailed || o == OutcomeReview
+}
+
+// Attempt is one try at a task within a run.
+type Attempt struct {
+	TaskID   string     `json:"task_id"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Outcome  Outcome    `json:"outcome"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// Journal is the state of a run, saved as .flo/runs/<id>.json.
+type Journal struct {
+	ID      string    `json:"id"`
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"`
+	// Finished is set once the run has no tasks left to attempt.
+	Finished *time.Time `json:"finished,omitempty"`
+	// Tasks limits the run to these tasks; empty runs every ready task.
+	Tasks    []string  `json:"tasks,omitempty"`
+	Attempts []Attempt `json:"attempts"`
+
+	path string
+}
+
+// Create starts the journal of a new run in dir, limited to tasks when set.
+func Create(dir, id string, tasks []string) (*Journal, error) {
+	now := time.Now().UTC()
+	j := &Journal{
+		ID:       id,
+		Started:  now,
+		Updated:  now,
+		Tasks:    tasks,
+		Attempts: []Attempt{},
+		path:     filepath.Join(dir, id+".json"),
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	return j, j.save()
+}
+
+// Load reads the journal of run id from dir.
+func Load(dir, id string) (*Journal, error) {
+	path := filepath.Join(dir, filepath.Base(id)+".json")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run journal: %w", err)
+	}
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("failed to parse run journal %s: %w", path, err)
+	}
+	j.path = path
+	return &j, nil
+}
+
+// Start records an attempt at a task beginning.
+func (j *Journal) Start(taskID string) error {
+	j.Attempts = append(j.Attempts, Attempt{
+		TaskID:  taskID,
+		Started: time.Now().UTC(),
+		Outcome: OutcomeRunning,
+	})
+	return j.save()
+}
+
+// Finish records how the latest attempt at a task ended, with the error it
+// stopped with, if any.
+func (j *Journal) Finish(taskID string, outcome Outcome, err error) error {
+	a := j.last(taskID)
+	if a == nil {
+		return fmt.Errorf("run %s has no attempt at task %s", j.ID, taskID)
+	}
+	now := time.Now().UTC()
+	a.Finished = &now
+	a.Outcome = outcome
+	if err != nil {
+		a.Error = err.Error()
+	}
+	return j.save()
+}
+
+// Close records the run finished.
+func (j *Journal) Close() error {
+	now := time.Now().UTC()
+	j.Finished = &now
+	return j.save()
+}
+
+// Outcome returns how the latest attempt at a task ended, and whether the
+// run attempted it at all.
+func (j *Journal) Outcome(taskID string) (Outcome, bool) {
+	if a := j.last(taskID); a != nil {
+		return a.Outcome, true
+	}
+	return "", false
+}
+
+// Unsettled returns the tasks whose latest attempt didn't settle them, such
+// as one interrupted by a crash, in the order they were first attempted.
+func (j *Journal) Unsettled() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, a := range j.Attempts {
+		if seen[a.TaskID] {
+			continue
+		}
+		seen[a.TaskID] = true
+		if outcome, _ := j.Outcome(a.TaskID); !outcome.Settled() {
+			ids = append(ids, a.TaskID)
+		}
+	}
+	return ids
+}
+
+// Includes reports whether the run covers a task.
+func (j *Journal) Includes(taskID string) bool {
+	if len(j.Tasks) == 0 {
+		return true
+	}
+	for _, id := range j.Tasks {
+		if id == taskID {
+			return true
+		}
+	}
+	return false
+}
+
+// Path returns where the journal is saved.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+func (j *Journal) last(taskID string) *Attempt {
+	for i := len(j.Attempts) - 1; i >= 0; i-- {
+		if j.Attempts[i].TaskID == taskID {
+			return &j.Attempts[i]
+		}
+	}
+	return nil
+}
+
+// save writes the journal via a temp file and rename, so a crash never
+// leaves it partly written.
+func (j *Journal) save() error {
+	j.Updated = time.Now().UTC()
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run journal: %w", err)
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run journal: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("failed to write run journal: %w", err)
+	}
+	return nil
+}
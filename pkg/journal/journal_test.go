@@ -0,0 +1,67 @@
+package journal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Create(dir, "run-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Start("t-001")
+	j.Finish("t-001", OutcomeComplete, nil)
+	j.Start("t-002")
+	j.Finish("t-002", OutcomeSkipped, errors.New("frozen"))
+	j.Start("t-003")
+	j.Finish("t-003", OutcomeReview, nil)
+	j.Start("t-004")
+
+	// A resumed run sees what the crashed one saved
+	loaded, err := Load(dir, "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Unsettled(); !reflect.DeepEqual(got, []string{"t-002", "t-004"}) {
+		t.Errorf("expected the skipped and interrupted tasks unsettled, got %v", got)
+	}
+	if outcome, ok := loaded.Outcome("t-004"); !ok || outcome != OutcomeRunning {
+		t.Errorf("expected t-004 still running, got %s %v", outcome, ok)
+	}
+	if _, ok := loaded.Outcome("t-005"); ok {
+		t.Error("expected no outcome for a task the run didn't attempt")
+	}
+	if loaded.Attempts[1].Error != "frozen" || loaded.Finished != nil {
+		t.Errorf("unexpected journal %+v", loaded)
+	}
+
+	loaded.Start("t-004")
+	loaded.Finish("t-004", OutcomeFailed, nil)
+	loaded.Close()
+	loaded, _ = Load(dir, "run-1")
+	if len(loaded.Attempts) != 5 || loaded.Finished == nil {
+		t.Errorf("expected the retried attempt and the run finished, got %+v", loaded)
+	}
+	if got := loaded.Unsettled(); !reflect.DeepEqual(got, []string{"t-002"}) {
+		t.Errorf("expected only the skipped task unsettled, got %v", got)
+	}
+}
+
+func TestJournalTasks(t *testing.T) {
+	j, err := Create(t.TempDir(), "run-2", []string{"t-001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !j.Includes("t-001") || j.Includes("t-002") {
+		t.Errorf("expected the run limited to t-001, got %v", j.Tasks)
+	}
+	if err := j.Finish("t-001", OutcomeComplete, nil); err == nil {
+		t.Error("expected finishing an attempt that never started to fail")
+	}
+	if _, err := Load(t.TempDir(), "run-3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
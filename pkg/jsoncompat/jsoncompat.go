@@ -0,0 +1,92 @@
+// Package jsoncompat keeps persisted JSON forward compatible: fields written
+// by a newer flo are carried through a load/save cycle instead of dropped.
+package jsoncompat
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Fields holds raw JSON object members keyed by name.
+type Fields map[string]json.RawMessage
+
+// Unknown returns the members of the JSON object data that don't correspond
+// to an exported field of v, which must be a struct or pointer to one.
+// Matching is case-insensitive, as in encoding/json. Data that is not a JSON
+// object has no unknown fields.
+func Unknown(data []byte, v interface{}) (Fields, error) {
+	if !isObject(data) {
+		return nil, nil
+	}
+	var all Fields
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	known := fieldNames(reflect.TypeOf(v))
+	var unknown Fields
+	for name, raw := range all {
+		if known[strings.ToLower(name)] {
+			continue
+		}
+		if unknown == nil {
+			unknown = make(Fields)
+		}
+		unknown[name] = raw
+	}
+	return unknown, nil
+}
+
+// Merge adds extra members to the JSON object data. Members already present
+// in data win, so current fields are never overwritten by stale copies.
+func Merge(data []byte, extra Fields) ([]byte, error) {
+	if len(extra) == 0 || !isObject(data) {
+		return data, nil
+	}
+	var all Fields
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for name, raw := range extra {
+		if _, ok := all[name]; !ok {
+			all[name] = raw
+		}
+	}
+	return json.Marshal(all)
+}
+
+// fieldNames returns the lowercased JSON names of t's exported fields.
+func fieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	names := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		names[strings.ToLower(name)] = true
+	}
+	return names
+}
+
+func isObject(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
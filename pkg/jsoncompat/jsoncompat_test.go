@@ -0,0 +1,48 @@
+package jsoncompat
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type record struct {
+	ID     string `json:"id"`
+	Title  string `json:"title,omitempty"`
+	Hidden string `json:"-"`
+	Plain  int
+	secret string
+}
+
+func TestUnknown(t *testing.T) {
+	data := []byte(`{"id": "1", "TITLE": "x", "plain": 2, "Hidden": "h", "labels": ["a"], "owner": {"name": "sam"}}`)
+
+	unknown, err := Unknown(data, &record{})
+	if err != nil {
+		t.Fatalf("Unknown failed: %v", err)
+	}
+	if len(unknown) != 3 || string(unknown["labels"]) != `["a"]` || unknown["Hidden"] == nil {
+		t.Errorf("unexpected unknown fields: %v", unknown)
+	}
+
+	if unknown, err := Unknown([]byte(`null`), record{}); err != nil || unknown != nil {
+		t.Errorf("expected no unknown fields for non-object, got %v (%v)", unknown, err)
+	}
+	if _, err := Unknown([]byte(`{"id": `), record{}); err == nil {
+		t.Error("expected error for malformed object")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	data := []byte(`{"id":"1"}`)
+	merged, err := Merge(data, Fields{"id": json.RawMessage(`"stale"`), "labels": json.RawMessage(`["a"]`)})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if string(merged) != `{"id":"1","labels":["a"]}` {
+		t.Errorf("unexpected merge result: %s", merged)
+	}
+
+	if out, _ := Merge(data, nil); string(out) != string(data) {
+		t.Errorf("expected data unchanged without extra fields, got %s", out)
+	}
+}
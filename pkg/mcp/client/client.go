@@ -0,0 +1,369 @@
+// Package client implements an MCP (Model Context Protocol) client for
+// talking to MCP servers over stdio or HTTP.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const protocolVersion = "2024-11-05"
+
+// message is a JSON-RPC 2.0 message in either direction.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// incoming is a message received from the server.
+type incoming struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC error returned by the server.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// transport carries messages to a server. Messages from the server are
+// passed to the receive function given to the transport's constructor.
+type transport interface {
+	send(ctx context.Context, data []byte) error
+	close() error
+}
+
+// Client is a connection to an MCP server. It is safe for concurrent use.
+type Client struct {
+	transport transport
+
+	mu       sync.Mutex
+	nextID   int
+	pending  map[string]chan *incoming
+	trace    func(outgoing bool, data []byte)
+	notify   func(method string, params json.RawMessage)
+	closed   bool
+	closeErr error
+}
+
+func newClient() *Client {
+	return &Client{pending: make(map[string]chan *incoming)}
+}
+
+// SetTrace calls fn with every raw message sent (outgoing) and received.
+func (c *Client) SetTrace(fn func(outgoing bool, data []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trace = fn
+}
+
+// OnNotification calls fn with every notification from the server, such
+// as notifications/message log entries.
+func (c *Client) OnNotification(fn func(method string, params json.RawMessage)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notify = fn
+}
+
+// receive handles one raw message from the server.
+func (c *Client) receive(data []byte) {
+	c.mu.Lock()
+	trace, notify := c.trace, c.notify
+	c.mu.Unlock()
+	if trace != nil {
+		trace(false, data)
+	}
+
+	var msg incoming
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+	if msg.Method != "" {
+		if len(msg.ID) > 0 {
+			c.answer(&msg)
+		} else if notify != nil {
+			notify(msg.Method, msg.Params)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	ch, ok := c.pending[string(msg.ID)]
+	delete(c.pending, string(msg.ID))
+	c.mu.Unlock()
+	if ok {
+		ch <- &msg
+	}
+}
+
+// answer responds to a request from the server. Only ping is supported,
+// which servers use to check the client is still there.
+func (c *Client) answer(req *incoming) {
+	resp := &message{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage("{}")
+	} else {
+		resp.Error = &RPCError{Code: -32601, Message: "Method not found: " + req.Method}
+	}
+	c.write(context.Background(), resp)
+}
+
+// fail ends every pending call with err, as when the server exits.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.closeErr = err
+	for id, ch := range c.pending {
+		ch <- &incoming{Error: &RPCError{Code: -32603, Message: err.Error()}}
+		delete(c.pending, id)
+	}
+}
+
+// Call sends a request and returns its raw result.
+func (c *Client) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	c.mu.Lock()
+	if c.closed {
+		err := c.closeErr
+		c.mu.Unlock()
+		return nil, fmt.Errorf("connection closed: %w", err)
+	}
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	ch := make(chan *incoming, 1)
+	c.pending[string(id)] = ch
+	c.mu.Unlock()
+
+	if err := c.write(ctx, &message{JSONRPC: "2.0", ID: id, Method: method, Params: params}); err != nil {
+		c.mu.Lock()
+		delete(c.pending, string(id))
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, string(id))
+		c.mu.Unlock()
+		// Tell the server to stop working on it; ctx is already done.
+		cancelCtx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+		defer cancel()
+		c.Notify(cancelCtx, "notifications/cancelled", map[string]any{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		})
+		return nil, ctx.Err()
+	}
+}
+
+// cancelTimeout bounds sending notifications/cancelled for an abandoned call.
+const cancelTimeout = 5 * time.Second
+
+// Notify sends a notification, which gets no response.
+func (c *Client) Notify(ctx context.Context, method string, params any) error {
+	return c.write(ctx, &message{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *Client) write(ctx context.Context, msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", msg.Method, err)
+	}
+	c.mu.Lock()
+	trace := c.trace
+	c.mu.Unlock()
+	if trace != nil {
+		trace(true, data)
+	}
+	return c.transport.send(ctx, data)
+}
+
+// Close ends the connection.
+func (c *Client) Close() error {
+	c.fail(fmt.Errorf("client closed"))
+	return c.transport.close()
+}
+
+// ServerInfo describes the server, from the initialize result.
+type ServerInfo struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"serverInfo"`
+}
+
+// Initialize performs the MCP handshake. It must be called first.
+func (c *Client) Initialize(ctx context.Context) (*ServerInfo, error) {
+	raw, err := c.Call(ctx, "initialize", map[string]any{
+		"protocolVersion": protocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo": map[string]any{
+			"name":    "flo",
+			"version": "0.1.0",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initialize failed: %w", err)
+	}
+	info := &ServerInfo{}
+	if err := json.Unmarshal(raw, info); err != nil {
+		return nil, fmt.Errorf("invalid initialize result: %w", err)
+	}
+	if err := c.Notify(ctx, "notifications/initialized", nil); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Ping checks that the server is responding.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Call(ctx, "ping", nil)
+	return err
+}
+
+// Tool is a tool offered by the server.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema,omitempty"`
+}
+
+// Resource is a resource offered by the server.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// Content is one item of a tool result or resource.
+type Content struct {
+	Type     string `json:"type,omitempty"`
+	URI      string `json:"uri,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+// ToolResult is the result of a tool call.
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Text joins the text content of the result.
+func (r *ToolResult) Text() string {
+	var text string
+	for _, c := range r.Content {
+		text += c.Text
+	}
+	return text
+}
+
+// ListTools returns every tool, following pagination cursors.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var all []Tool
+	err := c.list(ctx, "tools/list", func(raw json.RawMessage) (string, error) {
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		err := json.Unmarshal(raw, &page)
+		all = append(all, page.Tools...)
+		return page.NextCursor, err
+	})
+	return all, err
+}
+
+// ListResources returns every resource, following pagination cursors.
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	var all []Resource
+	err := c.list(ctx, "resources/list", func(raw json.RawMessage) (string, error) {
+		var page struct {
+			Resources  []Resource `json:"resources"`
+			NextCursor string     `json:"nextCursor"`
+		}
+		err := json.Unmarshal(raw, &page)
+		all = append(all, page.Resources...)
+		return page.NextCursor, err
+	})
+	return all, err
+}
+
+// list calls a paginated list method until there is no next cursor.
+func (c *Client) list(ctx context.Context, method string, add func(json.RawMessage) (string, error)) error {
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		raw, err := c.Call(ctx, method, params)
+		if err != nil {
+			return err
+		}
+		next, err := add(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s result: %w", method, err)
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// CallTool calls a tool with the given arguments.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (*ToolResult, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	raw, err := c.Call(ctx, "tools/call", map[string]any{"name": name, "arguments": args})
+	if err != nil {
+		return nil, err
+	}
+	result := &ToolResult{}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return nil, fmt.Errorf("invalid tools/call result: %w", err)
+	}
+	return result, nil
+}
+
+// ReadResource returns the contents of a resource.
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]Content, error) {
+	raw, err := c.Call(ctx, "resources/read", map[string]any{"uri": uri})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Contents []Content `json:"contents"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid resources/read result: %w", err)
+	}
+	return result.Contents, nil
+}
@@ -0,0 +1,265 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/mcp"
+	"github.com/richgo/flo/pkg/tools"
+)
+
+func testServer() *mcp.Server {
+	reg := tools.NewRegistry()
+	reg.Register(tools.New("echo", "Echo the message", map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{"type": "string"},
+		},
+		"required": []any{"message"},
+	}, func(args tools.Args) (string, error) {
+		return args["message"].(string), nil
+	}))
+	reg.Register(tools.New("fail", "Always fails", nil, func(args tools.Args) (string, error) {
+		return "", errors.New("boom")
+	}))
+
+	server := mcp.NewServer(reg)
+	server.SetPageSize(1)
+	server.AddResources(func() ([]*mcp.Resource, error) {
+		return []*mcp.Resource{{
+			URI:      "flo://spec",
+			Name:     "SPEC.md",
+			MimeType: "text/markdown",
+			Read:     func() (string, error) { return "# Spec", nil },
+		}}, nil
+	})
+	return server
+}
+
+// pipeClient connects a client to server.Serve over in-memory pipes.
+func pipeClient(t *testing.T, server *mcp.Server) *Client {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go func() {
+		server.Serve(serverR, serverW)
+		serverW.Close()
+	}()
+	c := NewStream(clientR, clientW)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func exercise(t *testing.T, c *Client) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info, err := c.Initialize(ctx)
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if info.ServerInfo.Name == "" || info.Capabilities["tools"] == nil {
+		t.Errorf("unexpected server info: %+v", info)
+	}
+
+	// Page size 1 forces the client to follow cursors.
+	toolList, err := c.ListTools(ctx)
+	if err != nil || len(toolList) != 2 || toolList[0].Name != "echo" || toolList[1].Name != "fail" {
+		t.Fatalf("expected both tools, got %+v, %v", toolList, err)
+	}
+
+	result, err := c.CallTool(ctx, "echo", map[string]any{"message": "hi"})
+	if err != nil || result.Text() != "hi" {
+		t.Errorf("expected echo, got %+v, %v", result, err)
+	}
+
+	_, err = c.CallTool(ctx, "fail", nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || !strings.Contains(rpcErr.Message, "boom") {
+		t.Errorf("expected RPC error, got %v", err)
+	}
+
+	resources, err := c.ListResources(ctx)
+	if err != nil || len(resources) != 1 || resources[0].URI != "flo://spec" {
+		t.Fatalf("unexpected resources: %+v, %v", resources, err)
+	}
+	contents, err := c.ReadResource(ctx, "flo://spec")
+	if err != nil || len(contents) != 1 || contents[0].Text != "# Spec" {
+		t.Errorf("unexpected contents: %+v, %v", contents, err)
+	}
+}
+
+func TestClientStream(t *testing.T) {
+	c := pipeClient(t, testServer())
+
+	var mu sync.Mutex
+	var sent, received int
+	c.SetTrace(func(outgoing bool, data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !json.Valid(data) {
+			t.Errorf("traced invalid JSON: %s", data)
+		}
+		if outgoing {
+			sent++
+		} else {
+			received++
+		}
+	})
+
+	exercise(t, c)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if sent == 0 || received == 0 {
+		t.Errorf("expected traffic to be traced, got %d sent and %d received", sent, received)
+	}
+}
+
+func TestClientStreamNotifications(t *testing.T) {
+	server := testServer()
+	c := pipeClient(t, server)
+
+	got := make(chan string, 10)
+	c.OnNotification(func(method string, params json.RawMessage) {
+		got <- method + " " + string(params)
+	})
+
+	ctx := context.Background()
+	if _, err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if _, err := c.Call(ctx, "logging/setLevel", map[string]any{"level": "info"}); err != nil {
+		t.Fatalf("setLevel failed: %v", err)
+	}
+	c.CallTool(ctx, "echo", map[string]any{"message": "hi"})
+
+	select {
+	case n := <-got:
+		if !strings.HasPrefix(n, "notifications/message") || !strings.Contains(n, "echo") {
+			t.Errorf("unexpected notification: %s", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a notification")
+	}
+}
+
+func TestClientStreamClosed(t *testing.T) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go io.Copy(io.Discard, serverR)
+	c := NewStream(clientR, clientW)
+	serverW.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := c.Call(ctx, "ping", nil); err == nil || ctx.Err() != nil {
+		t.Errorf("expected a connection error, got %v", err)
+	}
+}
+
+func TestClientHTTP(t *testing.T) {
+	server := testServer()
+	server.SetAuthToken("s3cret")
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	c := NewHTTP(ts.URL, "s3cret")
+	defer c.Close()
+	exercise(t, c)
+
+	bad := NewHTTP(ts.URL, "wrong")
+	defer bad.Close()
+	if _, err := bad.Initialize(context.Background()); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+}
+
+func TestClientAnswersKeepalivePings(t *testing.T) {
+	server := testServer()
+	server.SetKeepalive(20 * time.Millisecond)
+	c := pipeClient(t, server)
+
+	ctx := context.Background()
+	if _, err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	// Several keepalive intervals pass without client requests.
+	time.Sleep(150 * time.Millisecond)
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("expected the server to keep the client, got %v", err)
+	}
+}
+
+func TestClientCancelsAbandonedCalls(t *testing.T) {
+	stopped := make(chan error, 1)
+	reg := tools.NewRegistry()
+	reg.Register(tools.NewWithContext("hang", "Runs until cancelled", nil, func(ctx context.Context, args tools.Args) (string, error) {
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return "", ctx.Err()
+	}))
+	c := pipeClient(t, mcp.NewServer(reg))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.CallTool(ctx, "hang", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the call to time out, got %v", err)
+	}
+
+	// The client told the server, which cancelled the tool.
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the tool to be cancelled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("server kept running the abandoned call")
+	}
+}
+
+func TestClientProxy(t *testing.T) {
+	c := pipeClient(t, testServer())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	proxied, err := c.Proxy(ctx, "ext_", nil)
+	if err != nil || len(proxied) != 2 {
+		t.Fatalf("expected 2 proxied tools, got %d, %v", len(proxied), err)
+	}
+	reg := tools.NewRegistry()
+	for _, tool := range proxied {
+		reg.Register(tool)
+	}
+
+	out, err := reg.ExecuteContext(ctx, "ext_echo", tools.Args{"message": "hi"})
+	if err != nil || out != "hi" {
+		t.Errorf("expected forwarded echo, got %q, %v", out, err)
+	}
+	// The remote schema is enforced before the call leaves flo.
+	if _, err := reg.ExecuteContext(ctx, "ext_echo", tools.Args{}); !errors.Is(err, tools.ErrInvalidArgs) {
+		t.Errorf("expected invalid arguments, got %v", err)
+	}
+	if _, err := reg.ExecuteContext(ctx, "ext_fail", nil); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected remote error, got %v", err)
+	}
+
+	only, err := c.Proxy(ctx, "ext_", []string{"echo"})
+	if err != nil || len(only) != 1 || only[0].Name != "ext_echo" {
+		t.Errorf("expected only ext_echo, got %v, %v", only, err)
+	}
+	if _, err := c.Proxy(ctx, "ext_", []string{"missing"}); err == nil {
+		t.Error("expected error for unknown allowed tool")
+	}
+}
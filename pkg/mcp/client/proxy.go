@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/richgo/flo/pkg/tools"
+)
+
+// Proxy returns a tool for each of the server's tools that forwards calls
+// to it, named prefix followed by the server's tool name. When allow is not
+// empty only the tools it names are returned, and naming one the server
+// doesn't have is an error.
+func (c *Client) Proxy(ctx context.Context, prefix string, allow []string) ([]*tools.Tool, error) {
+	remote, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(allow))
+	for _, name := range allow {
+		wanted[name] = true
+	}
+
+	var proxied []*tools.Tool
+	for _, t := range remote {
+		if len(allow) > 0 && !wanted[t.Name] {
+			continue
+		}
+		delete(wanted, t.Name)
+		name := t.Name
+		proxied = append(proxied, tools.NewWithContext(prefix+name, t.Description, t.InputSchema,
+			func(ctx context.Context, args tools.Args) (string, error) {
+				result, err := c.CallTool(ctx, name, args)
+				if err != nil {
+					return "", err
+				}
+				if result.IsError {
+					return "", errors.New(result.Text())
+				}
+				return result.Text(), nil
+			}))
+	}
+	for _, name := range allow {
+		if wanted[name] {
+			return nil, fmt.Errorf("server has no tool '%s'", name)
+		}
+	}
+	return proxied, nil
+}
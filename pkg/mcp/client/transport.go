@@ -0,0 +1,234 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMessage bounds a single line read from a stdio server.
+const maxMessage = 16 << 20
+
+// streamTransport exchanges newline-delimited messages over a pipe.
+type streamTransport struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+func (t *streamTransport) send(ctx context.Context, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to server: %w", err)
+	}
+	return nil
+}
+
+func (t *streamTransport) close() error {
+	return t.w.Close()
+}
+
+// NewStream creates a client exchanging newline-delimited JSON-RPC
+// messages over r and w, such as the pipes of a server process.
+func NewStream(r io.Reader, w io.WriteCloser) *Client {
+	c := newClient()
+	c.transport = &streamTransport{w: w}
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxMessage)
+		for scanner.Scan() {
+			if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+				c.receive(append([]byte(nil), line...))
+			}
+		}
+		err := scanner.Err()
+		if err == nil {
+			err = io.EOF
+		}
+		c.fail(fmt.Errorf("server closed the connection: %w", err))
+	}()
+	return c
+}
+
+// processTransport is a stream to a server subprocess.
+type processTransport struct {
+	*streamTransport
+	cmd *exec.Cmd
+}
+
+func (t *processTransport) close() error {
+	t.streamTransport.close()
+	done := make(chan error, 1)
+	go func() { done <- t.cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.cmd.Process.Kill()
+		<-done
+	}
+	return nil
+}
+
+// NewStdio starts a server process and connects to its stdin and stdout.
+// The server's stderr is passed to stderr when non-nil.
+func NewStdio(cmd *exec.Cmd, stderr io.Writer) (*Client, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start MCP server: %w", err)
+	}
+
+	c := NewStream(stdout, stdin)
+	c.transport = &processTransport{streamTransport: c.transport.(*streamTransport), cmd: cmd}
+	return c, nil
+}
+
+// sessionHeader carries the server-assigned session ID.
+const sessionHeader = "Mcp-Session-Id"
+
+// httpTransport posts each message and receives the response in the reply.
+// Server notifications arrive on an event stream opened after initialize.
+type httpTransport struct {
+	url    string
+	token  string
+	client *http.Client
+	recv   func([]byte)
+
+	mu      sync.Mutex
+	session string
+	stream  io.Closer
+}
+
+// NewHTTP creates a client for a server at url, sending token (if any)
+// as a bearer token.
+func NewHTTP(url, token string) *Client {
+	c := newClient()
+	c.transport = &httpTransport{
+		url:    url,
+		token:  token,
+		client: &http.Client{},
+		recv:   c.receive,
+	}
+	return c
+}
+
+func (t *httpTransport) request(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, body)
+	if err != nil {
+		return nil, err
+	}
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	t.mu.Lock()
+	if t.session != "" {
+		req.Header.Set(sessionHeader, t.session)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
+
+func (t *httpTransport) send(ctx context.Context, data []byte) error {
+	req, err := t.request(ctx, http.MethodPost, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach MCP server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read MCP response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusAccepted:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("MCP server rejected the request: unauthorized (check the token)")
+	case resp.StatusCode != http.StatusOK && !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json"):
+		return fmt.Errorf("MCP server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.mu.Lock()
+		first := t.session == ""
+		t.session = id
+		t.mu.Unlock()
+		if first {
+			t.openStream()
+		}
+	}
+	t.recv(bytes.TrimSpace(body))
+	return nil
+}
+
+// openStream reads the session's event stream in the background. Servers
+// without one are fine; the client just misses notifications.
+func (t *httpTransport) openStream() {
+	req, err := t.request(context.Background(), http.MethodGet, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	go func() {
+		resp, err := t.client.Do(req)
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return
+		}
+		t.mu.Lock()
+		t.stream = resp.Body
+		t.mu.Unlock()
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), maxMessage)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				t.recv([]byte(data))
+			}
+		}
+	}()
+}
+
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	stream, session := t.stream, t.session
+	t.mu.Unlock()
+	if stream != nil {
+		stream.Close()
+	}
+	if session == "" {
+		return nil
+	}
+	req, err := t.request(context.Background(), http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+	return nil
+}
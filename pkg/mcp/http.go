@@ -0,0 +1,240 @@
+package mcp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+)
+
+// SessionHeader carries the session ID assigned at initialize on every
+// later HTTP request.
+const SessionHeader = "Mcp-Session-Id"
+
+// maxRequestBody bounds the size of a request posted over HTTP.
+const maxRequestBody = 4 << 20
+
+// sessionQueue is how many notifications an HTTP session buffers for its
+// event stream before dropping them.
+const sessionQueue = 64
+
+// SetAuthToken requires HTTP clients to send "Authorization: Bearer
+// <token>" on every request. An empty token disables authentication.
+func (s *Server) SetAuthToken(token string) {
+	s.authToken = token
+}
+
+// HTTPHandler serves MCP over HTTP for any number of clients, each in its
+// own session:
+//
+//   - POST sends one JSON-RPC request and returns its response. An
+//     initialize request starts a session whose ID is returned in the
+//     Mcp-Session-Id header; later requests must send it back.
+//   - GET opens a server-sent event stream of the session's notifications.
+//   - DELETE ends the session.
+//
+// Requests without the auth token, when one is set, get 401. With an idle
+// timeout, sessions without requests for that long are closed until the
+// server is closed.
+func (s *Server) HTTPHandler() http.Handler {
+	slots := make(chan struct{}, s.concurrency)
+	if s.idleTimeout > 0 {
+		go s.reapSessions(s.idleTimeout)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			audit.Warn("mcp.auth", "Rejected unauthenticated MCP request", map[string]interface{}{
+				"remote": r.RemoteAddr,
+				"method": r.Method,
+			})
+			w.Header().Set("WWW-Authenticate", `Bearer realm="flo"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if s.activity != nil && r.Method != http.MethodGet {
+			defer s.activity.Begin()()
+		}
+
+		switch r.Method {
+		case http.MethodPost:
+			s.servePost(w, r, slots)
+		case http.MethodGet:
+			s.serveEvents(w, r)
+		case http.MethodDelete:
+			if !s.CloseSession(r.Header.Get(SessionHeader)) {
+				http.Error(w, "unknown session", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// reapSessions closes the sessions unused for timeout until the server is
+// closed.
+func (s *Server) reapSessions(timeout time.Duration) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.closeUnusedSessions(timeout)
+		}
+	}
+}
+
+// authorized reports whether r carries the auth token, if one is set.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.authToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.authToken)) == 1
+}
+
+func (s *Server) servePost(w http.ResponseWriter, r *http.Request, slots chan struct{}) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &Response{
+			JSONRPC: "2.0",
+			Error: &ErrorResp{
+				Code:    -32700,
+				Message: "Parse error: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	var sess *Session
+	if req.Method == "initialize" {
+		sess = s.NewSession()
+	} else {
+		id := r.Header.Get(SessionHeader)
+		if id == "" {
+			http.Error(w, "missing "+SessionHeader+" header", http.StatusBadRequest)
+			return
+		}
+		var ok bool
+		if sess, ok = s.Session(id); !ok {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+	}
+	sess.touch()
+	w.Header().Set(SessionHeader, sess.ID())
+
+	if req.ID == nil {
+		s.handle(r.Context(), sess, req)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	ctx, finish, ok := sess.startRequest(r.Context(), req.ID)
+	if !ok {
+		writeJSON(w, http.StatusOK, duplicateResp(req.ID))
+		return
+	}
+
+	var resp *Response
+	select {
+	case slots <- struct{}{}:
+		resp = s.handle(ctx, sess, req)
+		<-slots
+	case <-ctx.Done():
+	}
+	if cancelled := finish(); cancelled || resp == nil {
+		// The client cancelled the request and expects no response.
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// serveEvents streams the session's notifications until the client
+// disconnects. A session has at most one stream.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.Session(r.Header.Get(SessionHeader))
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	queue := make(chan any, sessionQueue)
+	attached := sess.attach(func(msg any) {
+		select {
+		case queue <- msg:
+		default:
+			// The client isn't keeping up; drop rather than block the
+			// tool call or broadcast that sent it.
+		}
+	})
+	if !attached {
+		http.Error(w, "session already has an event stream", http.StatusConflict)
+		return
+	}
+	defer sess.setSender(nil)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Comment lines keep proxies from timing out a quiet stream and let
+	// both ends notice a dead connection.
+	var keepalive <-chan time.Time
+	if s.keepalive > 0 {
+		ticker := time.NewTicker(s.keepalive)
+		defer ticker.Stop()
+		keepalive = ticker.C
+	}
+
+	for {
+		select {
+		case <-keepalive:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-sess.closed:
+			return
+		case msg := <-queue:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
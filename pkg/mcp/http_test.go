@@ -0,0 +1,259 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/tools"
+)
+
+// post sends a JSON-RPC request in a session ("" for none) and returns the
+// HTTP response and decoded body.
+func post(t *testing.T, url, session, body string) (*http.Response, map[string]any) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if session != "" {
+		req.Header.Set(SessionHeader, session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var decoded map[string]any
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp, decoded
+}
+
+func initSession(t *testing.T, url string) string {
+	t.Helper()
+	resp, _ := post(t, url, "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	id := resp.Header.Get(SessionHeader)
+	if resp.StatusCode != http.StatusOK || id == "" {
+		t.Fatalf("initialize failed: %d, session %q", resp.StatusCode, id)
+	}
+	return id
+}
+
+func TestMCPHTTPSessions(t *testing.T) {
+	taskReg := task.NewRegistry()
+	taskReg.Add(task.New("a-1", "First"))
+	taskReg.Add(task.New("a-2", "Second"))
+	server := NewServer(tools.NewEASTools(taskReg, nil))
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	a, b := initSession(t, ts.URL), initSession(t, ts.URL)
+	if a == b {
+		t.Fatal("expected distinct session IDs")
+	}
+
+	if resp, _ := post(t, ts.URL, "", `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 without a session, got %d", resp.StatusCode)
+	}
+	if resp, _ := post(t, ts.URL, "nope", `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown session, got %d", resp.StatusCode)
+	}
+
+	// Claiming focuses the task in the claiming session only.
+	post(t, ts.URL, a, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"eas_task_claim","arguments":{"task_id":"a-1"}}}`)
+	sessA, _ := server.Session(a)
+	sessB, _ := server.Session(b)
+	if sessA.Focus() != "a-1" || sessB.Focus() != "" {
+		t.Errorf("expected focus only in session a, got a=%q b=%q", sessA.Focus(), sessB.Focus())
+	}
+
+	// Log levels are per session.
+	post(t, ts.URL, b, `{"jsonrpc":"2.0","id":3,"method":"logging/setLevel","params":{"level":"debug"}}`)
+	if sessA.LogLevel() != DefaultLogLevel || sessB.LogLevel() != LogDebug {
+		t.Errorf("unexpected levels a=%s b=%s", sessA.LogLevel(), sessB.LogLevel())
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL, nil)
+	req.Header.Set(SessionHeader, a)
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	if resp, _ := post(t, ts.URL, a, `{"jsonrpc":"2.0","id":4,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 after the session ended, got %d", resp.StatusCode)
+	}
+	if resp, _ := post(t, ts.URL, a, `{"jsonrpc":"2.0","method":"notifications/initialized"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 after the session ended, got %d", resp.StatusCode)
+	}
+}
+
+func TestMCPHTTPPaginationCursorsPerSession(t *testing.T) {
+	reg := tools.NewRegistry()
+	for _, name := range []string{"c", "a", "b"} {
+		reg.Register(tools.New(name, "Tool "+name, nil, nil))
+	}
+	server := NewServer(reg)
+	server.SetPageSize(2)
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	a, b := initSession(t, ts.URL), initSession(t, ts.URL)
+
+	_, body := post(t, ts.URL, a, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	result := body["result"].(map[string]any)
+	if got := len(result["tools"].([]any)); got != 2 {
+		t.Fatalf("expected a page of 2 tools, got %d", got)
+	}
+	cursor, _ := result["nextCursor"].(string)
+	if cursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	_, body = post(t, ts.URL, a, `{"jsonrpc":"2.0","id":3,"method":"tools/list","params":{"cursor":"`+cursor+`"}}`)
+	result = body["result"].(map[string]any)
+	page := result["tools"].([]any)
+	if len(page) != 1 || page[0].(map[string]any)["name"] != "c" || result["nextCursor"] != nil {
+		t.Errorf("unexpected last page: %v", result)
+	}
+
+	_, body = post(t, ts.URL, b, `{"jsonrpc":"2.0","id":4,"method":"tools/list","params":{"cursor":"`+cursor+`"}}`)
+	if errResp, _ := body["error"].(map[string]any); errResp == nil || errResp["code"] != float64(-32602) {
+		t.Errorf("expected another session's cursor to be rejected, got %v", body)
+	}
+}
+
+func TestMCPHTTPNotificationsBroadcast(t *testing.T) {
+	server := NewServer(tools.NewRegistry())
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	sess := initSession(t, ts.URL)
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set(SessionHeader, sess)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	// A second stream for the same session is refused.
+	dup, _ := http.DefaultClient.Do(req)
+	if dup.StatusCode != http.StatusConflict {
+		t.Errorf("expected 409 for a second stream, got %d", dup.StatusCode)
+	}
+	dup.Body.Close()
+
+	server.ResourcesChanged()
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	for {
+		select {
+		case line := <-lines:
+			if strings.HasPrefix(line, "data: ") {
+				if !strings.Contains(line, "notifications/resources/list_changed") {
+					t.Errorf("unexpected event: %s", line)
+				}
+				return
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for the notification")
+		}
+	}
+}
+
+func TestMCPHTTPToolLogsStayInSession(t *testing.T) {
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("echo", "Echo tool", nil, func(args tools.Args) (string, error) {
+		return "hi", nil
+	}))
+	server := NewServer(toolReg)
+	server.SetLogLevel(LogInfo)
+	ts := httptest.NewServer(server.HTTPHandler())
+	t.Cleanup(ts.Close) // after the streams below close
+
+	// stream opens a session's event stream and returns its data lines.
+	stream := func(sess string) <-chan string {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		req.Header.Set(SessionHeader, sess)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		lines := make(chan string, 10)
+		go func() {
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if line, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+					lines <- line
+				}
+			}
+		}()
+		return lines
+	}
+	next := func(lines <-chan string) string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for an event")
+			return ""
+		}
+	}
+
+	a, b := initSession(t, ts.URL), initSession(t, ts.URL)
+	aEvents, bEvents := stream(a), stream(b)
+	post(t, ts.URL, a, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}`)
+	server.ResourcesChanged()
+
+	if line := next(aEvents); !strings.Contains(line, `"tool":"echo"`) {
+		t.Errorf("expected the caller to get its tool log, got %s", line)
+	}
+	if line := next(bEvents); !strings.Contains(line, "notifications/resources/list_changed") {
+		t.Errorf("expected another session not to see the tool log, got %s", line)
+	}
+}
+
+func TestMCPHTTPAuthToken(t *testing.T) {
+	server := NewServer(tools.NewRegistry())
+	server.SetAuthToken("s3cret")
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	for _, auth := range []string{"", "Bearer wrong", "s3cret", "Basic s3cret"} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: expected 401 with a challenge, got %d", auth, resp.StatusCode)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(SessionHeader) == "" {
+		t.Errorf("expected an authenticated initialize to succeed, got %d", resp.StatusCode)
+	}
+}
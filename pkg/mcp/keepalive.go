@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/richgo/flo/pkg/idle"
+)
+
+// ErrIdleTimeout is returned by ServeContext when no request arrived
+// within the idle timeout.
+var ErrIdleTimeout = errors.New("MCP server idle timeout")
+
+// ErrClientGone is returned by ServeContext when the client stopped
+// answering keepalive pings.
+var ErrClientGone = errors.New("MCP client stopped responding to pings")
+
+// SetIdleTimeout makes the server stop after d without requests; in-flight
+// requests keep it alive. Over HTTP, sessions unused for d are closed. Zero
+// disables the timeout.
+func (s *Server) SetIdleTimeout(d time.Duration) {
+	s.idleTimeout = d
+	s.activity = nil
+	if d > 0 {
+		s.activity = idle.New(d)
+	}
+}
+
+// SetKeepalive makes the server ping a quiet stdio client every d and give
+// up on it when a ping goes unanswered for d; HTTP event streams get a
+// comment line every d so dead connections are noticed. Zero disables
+// keepalives.
+func (s *Server) SetKeepalive(d time.Duration) {
+	s.keepalive = d
+}
+
+// WaitIdle blocks until the idle timeout passes without requests, returning
+// true, or until ctx is done. Without an idle timeout it waits for ctx.
+func (s *Server) WaitIdle(ctx context.Context) bool {
+	if s.activity == nil {
+		<-ctx.Done()
+		return false
+	}
+	return s.activity.Wait(ctx)
+}
+
+// keepalive pings a stdio client that has gone quiet. It is used only by
+// the ServeContext loop; a nil keepalive is disabled.
+type keepalive struct {
+	interval    time.Duration
+	ticker      *time.Ticker
+	lastSeen    time.Time
+	pingSent    time.Time
+	outstanding bool
+	pings       int
+}
+
+func newKeepalive(interval time.Duration) *keepalive {
+	if interval <= 0 {
+		return nil
+	}
+	return &keepalive{
+		interval: interval,
+		ticker:   time.NewTicker(interval),
+		lastSeen: time.Now(),
+	}
+}
+
+// tick returns the keepalive ticker channel, or nil (never ready) when
+// disabled.
+func (k *keepalive) tick() <-chan time.Time {
+	if k == nil {
+		return nil
+	}
+	return k.ticker.C
+}
+
+// seen records a message from the client.
+func (k *keepalive) seen() {
+	if k != nil {
+		k.lastSeen = time.Now()
+	}
+}
+
+// check reports whether the client is alive: it has sent something since
+// the last ping, if one is outstanding.
+func (k *keepalive) check() bool {
+	if k == nil || !k.outstanding {
+		return true
+	}
+	if k.lastSeen.Before(k.pingSent) {
+		return false
+	}
+	k.outstanding = false
+	return true
+}
+
+// ping returns a ping request when the client has been quiet for a full
+// interval, or nil.
+func (k *keepalive) ping() *Request {
+	if k == nil || k.outstanding || time.Since(k.lastSeen) < k.interval {
+		return nil
+	}
+	k.pings++
+	k.pingSent = time.Now()
+	k.outstanding = true
+	return &Request{
+		JSONRPC: "2.0",
+		ID:      fmt.Sprintf("keepalive-%d", k.pings),
+		Method:  "ping",
+	}
+}
+
+func (k *keepalive) stop() {
+	if k != nil {
+		k.ticker.Stop()
+	}
+}
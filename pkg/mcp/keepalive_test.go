@@ -0,0 +1,157 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/tools"
+)
+
+// serveAsync runs ServeContext on pipes and returns the client's ends and
+// a channel with Serve's result.
+func serveAsync(ctx context.Context, server *Server) (*io.PipeWriter, *bufio.Scanner, <-chan error) {
+	serverR, clientW := io.Pipe()
+	clientR, serverW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := server.ServeContext(ctx, serverR, serverW)
+		serverW.Close()
+		done <- err
+	}()
+	return clientW, bufio.NewScanner(clientR), done
+}
+
+func waitServe(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for Serve to return")
+		return nil
+	}
+}
+
+func TestMCPServeIdleTimeout(t *testing.T) {
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("slow", "Slow tool", nil, func(args tools.Args) (string, error) {
+		time.Sleep(100 * time.Millisecond)
+		return "finished", nil
+	}))
+	server := NewServer(toolReg)
+	server.SetIdleTimeout(30 * time.Millisecond)
+
+	in, out, done := serveAsync(context.Background(), server)
+	defer in.Close()
+	go fmt.Fprintln(in, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`)
+
+	// The in-flight call outlasts the idle timeout but still completes.
+	if !out.Scan() || !strings.Contains(out.Text(), "finished") {
+		t.Fatalf("expected the slow call to finish, got %q", out.Text())
+	}
+	go func() {
+		for out.Scan() {
+		}
+	}()
+	if err := waitServe(t, done); !errors.Is(err, ErrIdleTimeout) {
+		t.Errorf("expected ErrIdleTimeout, got %v", err)
+	}
+}
+
+func TestMCPServeContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in, _, done := serveAsync(ctx, NewServer(tools.NewRegistry()))
+	defer in.Close()
+
+	cancel()
+	if err := waitServe(t, done); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestMCPServeKeepalive(t *testing.T) {
+	server := NewServer(tools.NewRegistry())
+	server.SetKeepalive(20 * time.Millisecond)
+
+	// A client that answers pings stays connected.
+	in, out, done := serveAsync(context.Background(), server)
+	answered := 0
+	for answered < 3 && out.Scan() {
+		line := out.Text()
+		if !strings.Contains(line, `"method":"ping"`) {
+			t.Fatalf("expected a ping request, got %s", line)
+		}
+		id := strings.Split(strings.Split(line, `"id":"`)[1], `"`)[0]
+		fmt.Fprintf(in, `{"jsonrpc":"2.0","id":"%s","result":{}}`+"\n", id)
+		answered++
+	}
+	in.Close()
+	if err := waitServe(t, done); err != nil {
+		t.Errorf("expected a clean EOF, got %v", err)
+	}
+
+	// A client that ignores them is dropped.
+	in, out, done = serveAsync(context.Background(), server)
+	defer in.Close()
+	go func() {
+		for out.Scan() {
+		}
+	}()
+	if err := waitServe(t, done); !errors.Is(err, ErrClientGone) {
+		t.Errorf("expected ErrClientGone, got %v", err)
+	}
+}
+
+func TestMCPHTTPUnusedSessionsExpire(t *testing.T) {
+	server := NewServer(tools.NewRegistry())
+	server.SetIdleTimeout(40 * time.Millisecond)
+	defer server.Close()
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	sess := initSession(t, ts.URL)
+	if resp, _ := post(t, ts.URL, sess, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected ping to succeed, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if !server.WaitIdle(ctx) {
+		t.Fatal("expected the server to become idle")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if resp, _ := post(t, ts.URL, sess, `{"jsonrpc":"2.0","id":3,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the unused session to expire, got %d", resp.StatusCode)
+	}
+}
+
+func TestMCPHTTPCloseStopsSessionReaper(t *testing.T) {
+	before := runtime.NumGoroutine()
+	server := NewServer(tools.NewRegistry())
+	server.SetIdleTimeout(time.Hour)
+	for i := 0; i < 3; i++ {
+		server.HTTPHandler()
+	}
+	if n := runtime.NumGoroutine(); n < before+3 {
+		t.Fatalf("expected a reaper per handler, %d goroutines from %d", n, before)
+	}
+
+	server.Close()
+	server.Close() // closing twice is harmless
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the reapers to stop, %d goroutines from %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LogLevel is an MCP log severity (the syslog levels of RFC 5424).
+type LogLevel string
+
+const (
+	LogDebug     LogLevel = "debug"
+	LogInfo      LogLevel = "info"
+	LogNotice    LogLevel = "notice"
+	LogWarning   LogLevel = "warning"
+	LogError     LogLevel = "error"
+	LogCritical  LogLevel = "critical"
+	LogAlert     LogLevel = "alert"
+	LogEmergency LogLevel = "emergency"
+)
+
+// DefaultLogLevel is the minimum level sent until the client sets one.
+const DefaultLogLevel = LogWarning
+
+var logLevelRank = map[LogLevel]int{
+	LogDebug:     0,
+	LogInfo:      1,
+	LogNotice:    2,
+	LogWarning:   3,
+	LogError:     4,
+	LogCritical:  5,
+	LogAlert:     6,
+	LogEmergency: 7,
+}
+
+// ParseLogLevel parses a level name such as "warning".
+func ParseLogLevel(s string) (LogLevel, error) {
+	level := LogLevel(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := logLevelRank[level]; !ok {
+		return "", fmt.Errorf("invalid log level '%s' (want debug, info, notice, warning, error, critical, alert or emergency)", s)
+	}
+	return level, nil
+}
+
+// AtLeast reports whether l is as severe as min.
+func (l LogLevel) AtLeast(min LogLevel) bool {
+	return logLevelRank[l] >= logLevelRank[min]
+}
+
+// Notification is a JSON-RPC 2.0 notification sent by the server.
+type Notification struct {
+	JSONRPC string         `json:"jsonrpc"`
+	Method  string         `json:"method"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+// SetLogLevel sets the minimum level of log notifications for the stdio
+// client and for sessions started later, until they choose their own with
+// logging/setLevel.
+func (s *Server) SetLogLevel(level LogLevel) {
+	s.mu.Lock()
+	s.logLevel = level
+	s.mu.Unlock()
+	s.stdio.SetLogLevel(level)
+}
+
+// LogLevel returns the minimum level of log notifications to the stdio
+// client.
+func (s *Server) LogLevel() LogLevel {
+	return s.stdio.LogLevel()
+}
+
+// Log sends a notifications/message to each connected client whose level
+// is at or below level. logger names the source (e.g. "tools") and data is
+// any JSON value. Clients without a message stream miss the message.
+func (s *Server) Log(level LogLevel, logger string, data any) {
+	msg := logMessage(level, logger, data)
+	for _, sess := range s.allSessions() {
+		sess.log(level, msg)
+	}
+}
+
+// logTo sends a notifications/message to sess alone, for messages about
+// its own requests, such as the tools it calls.
+func (s *Server) logTo(sess *Session, level LogLevel, logger string, data any) {
+	sess.log(level, logMessage(level, logger, data))
+}
+
+// logMessage builds a notifications/message.
+func logMessage(level LogLevel, logger string, data any) *Notification {
+	params := map[string]any{
+		"level": level,
+		"data":  data,
+	}
+	if logger != "" {
+		params["logger"] = logger
+	}
+	return &Notification{
+		JSONRPC: "2.0",
+		Method:  "notifications/message",
+		Params:  params,
+	}
+}
+
+func (s *Server) handleSetLevel(sess *Session, params map[string]any) (map[string]any, *ErrorResp) {
+	name, _ := params["level"].(string)
+	level, err := ParseLogLevel(name)
+	if err != nil {
+		return nil, &ErrorResp{Code: -32602, Message: "Invalid params: " + err.Error()}
+	}
+	sess.SetLogLevel(level)
+	return map[string]any{}, nil
+}
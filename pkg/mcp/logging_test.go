@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/tools"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	level, err := ParseLogLevel("Warning")
+	if err != nil || level != LogWarning {
+		t.Errorf("expected warning, got %q, %v", level, err)
+	}
+	if _, err := ParseLogLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if !LogError.AtLeast(LogWarning) || LogInfo.AtLeast(LogWarning) {
+		t.Error("unexpected level ordering")
+	}
+}
+
+func TestMCPLoggingSetLevel(t *testing.T) {
+	server := NewServer(tools.NewRegistry())
+
+	resp, _ := server.HandleRequest(Request{JSONRPC: "2.0", ID: 1, Method: "initialize"})
+	caps := resp.Result.(map[string]any)["capabilities"].(map[string]any)
+	if _, ok := caps["logging"]; !ok {
+		t.Error("expected logging capability")
+	}
+
+	if server.LogLevel() != DefaultLogLevel {
+		t.Errorf("expected default level %s, got %s", DefaultLogLevel, server.LogLevel())
+	}
+	resp, _ = server.HandleRequest(Request{
+		JSONRPC: "2.0",
+		ID:      2,
+		Method:  "logging/setLevel",
+		Params:  map[string]any{"level": "debug"},
+	})
+	if resp.Error != nil || server.LogLevel() != LogDebug {
+		t.Errorf("expected level debug, got %s (%+v)", server.LogLevel(), resp.Error)
+	}
+
+	resp, _ = server.HandleRequest(Request{
+		JSONRPC: "2.0",
+		ID:      3,
+		Method:  "logging/setLevel",
+		Params:  map[string]any{"level": "loud"},
+	})
+	if resp.Error == nil || resp.Error.Code != -32602 {
+		t.Errorf("expected invalid params, got %+v", resp.Error)
+	}
+}
+
+func TestMCPLogNotifications(t *testing.T) {
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("echo", "Echo tool", nil, func(args tools.Args) (string, error) {
+		return "hi", nil
+	}))
+	server := NewServer(toolReg)
+
+	// Dropped: not serving.
+	server.Log(LogError, "test", "nobody listening")
+
+	server.SetLogLevel(LogInfo)
+	input := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo"}}
+`)
+	var output bytes.Buffer
+	if err := server.Serve(input, &output); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	var notifications []Notification
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var n Notification
+		json.Unmarshal([]byte(line), &n)
+		if n.Method == "notifications/message" {
+			notifications = append(notifications, n)
+		}
+	}
+	// The debug "call" trace is below the level; only "done" is sent.
+	if len(notifications) != 1 {
+		t.Fatalf("expected 1 notification, got %d: %s", len(notifications), output.String())
+	}
+	params := notifications[0].Params
+	data, _ := params["data"].(map[string]any)
+	if params["level"] != "info" || params["logger"] != "tools" || data["tool"] != "echo" || data["event"] != "done" {
+		t.Errorf("unexpected notification: %v", params)
+	}
+}
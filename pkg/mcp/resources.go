@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// codeResourceNotFound is returned by resources/read for unknown URIs.
+const codeResourceNotFound = -32002
+
+// Resource is a readable document exposed to MCP clients, such as the spec
+// or a task file.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	// Read returns the current content.
+	Read func() (string, error) `json:"-"`
+}
+
+// ResourceLister returns the resources currently available. It is called
+// on every list and read, so resources follow changes to the workspace.
+type ResourceLister func() ([]*Resource, error)
+
+// AddResources registers a source of resources and enables the resources
+// capability.
+func (s *Server) AddResources(lister ResourceLister) {
+	s.resources = append(s.resources, lister)
+}
+
+// listResources collects the resources of every lister, sorted by URI.
+func (s *Server) listResources() ([]*Resource, error) {
+	var all []*Resource
+	for _, lister := range s.resources {
+		resources, err := lister()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, resources...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].URI < all[j].URI })
+	return all, nil
+}
+
+func (s *Server) handleResourcesList(sess *Session, params map[string]any) (map[string]any, *ErrorResp) {
+	resources, err := s.listResources()
+	if err != nil {
+		return nil, &ErrorResp{Code: codeToolError, Message: err.Error()}
+	}
+	start, end, next, errResp := s.page(sess, params, len(resources))
+	if errResp != nil {
+		return nil, errResp
+	}
+	list := map[string]any{
+		"resources": append([]*Resource{}, resources[start:end]...),
+	}
+	if next != "" {
+		list["nextCursor"] = next
+	}
+	return list, nil
+}
+
+func (s *Server) handleResourcesRead(params map[string]any) (map[string]any, *ErrorResp) {
+	uri, ok := params["uri"].(string)
+	if !ok || uri == "" {
+		return nil, &ErrorResp{Code: -32602, Message: "Invalid params: missing resource uri"}
+	}
+
+	resources, err := s.listResources()
+	if err != nil {
+		return nil, &ErrorResp{Code: codeToolError, Message: err.Error()}
+	}
+	for _, r := range resources {
+		if r.URI != uri {
+			continue
+		}
+		text, err := r.Read()
+		if err != nil {
+			return nil, &ErrorResp{Code: codeToolError, Message: fmt.Sprintf("failed to read %s: %v", uri, err)}
+		}
+		content := map[string]any{
+			"uri":  r.URI,
+			"text": text,
+		}
+		if r.MimeType != "" {
+			content["mimeType"] = r.MimeType
+		}
+		return map[string]any{
+			"contents": []map[string]any{content},
+		}, nil
+	}
+	return nil, &ErrorResp{
+		Code:    codeResourceNotFound,
+		Message: "Resource not found",
+		Data:    map[string]any{"uri": uri},
+	}
+}
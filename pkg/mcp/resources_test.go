@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/richgo/flo/pkg/tools"
+)
+
+func newResourceServer() *Server {
+	server := NewServer(tools.NewRegistry())
+	server.AddResources(func() ([]*Resource, error) {
+		return []*Resource{
+			{
+				URI:      "flo://tasks/auth",
+				Name:     "TASK-auth.md",
+				MimeType: "text/markdown",
+				Read:     func() (string, error) { return "# Auth", nil },
+			},
+			{
+				URI:      "flo://spec",
+				Name:     "SPEC.md",
+				MimeType: "text/markdown",
+				Read:     func() (string, error) { return "# Spec", nil },
+			},
+			{
+				URI:  "flo://broken",
+				Name: "broken",
+				Read: func() (string, error) { return "", fmt.Errorf("disk on fire") },
+			},
+		}, nil
+	})
+	return server
+}
+
+func TestMCPResourcesCapability(t *testing.T) {
+	resp, _ := NewServer(tools.NewRegistry()).HandleRequest(Request{JSONRPC: "2.0", ID: 1, Method: "initialize"})
+	caps := resp.Result.(map[string]any)["capabilities"].(map[string]any)
+	if _, ok := caps["resources"]; ok {
+		t.Error("expected no resources capability without resources")
+	}
+
+	resp, _ = newResourceServer().HandleRequest(Request{JSONRPC: "2.0", ID: 1, Method: "initialize"})
+	caps = resp.Result.(map[string]any)["capabilities"].(map[string]any)
+	if _, ok := caps["resources"]; !ok {
+		t.Error("expected resources capability")
+	}
+}
+
+func TestMCPResourcesList(t *testing.T) {
+	resp, err := newResourceServer().HandleRequest(Request{JSONRPC: "2.0", ID: 1, Method: "resources/list"})
+	if err != nil || resp.Error != nil {
+		t.Fatalf("resources/list failed: %v %+v", err, resp.Error)
+	}
+
+	data, _ := json.Marshal(resp.Result)
+	var result struct {
+		Resources []map[string]any `json:"resources"`
+	}
+	json.Unmarshal(data, &result)
+	if len(result.Resources) != 3 {
+		t.Fatalf("expected 3 resources, got %d", len(result.Resources))
+	}
+	if result.Resources[1]["uri"] != "flo://spec" || result.Resources[1]["mimeType"] != "text/markdown" {
+		t.Errorf("unexpected resource: %v", result.Resources[1])
+	}
+}
+
+func TestMCPResourcesRead(t *testing.T) {
+	server := newResourceServer()
+
+	resp, _ := server.HandleRequest(Request{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "resources/read",
+		Params:  map[string]any{"uri": "flo://spec"},
+	})
+	if resp.Error != nil {
+		t.Fatalf("resources/read failed: %+v", resp.Error)
+	}
+	contents := resp.Result.(map[string]any)["contents"].([]map[string]any)
+	if len(contents) != 1 || contents[0]["text"] != "# Spec" || contents[0]["uri"] != "flo://spec" {
+		t.Errorf("unexpected contents: %v", contents)
+	}
+
+	resp, _ = server.HandleRequest(Request{
+		JSONRPC: "2.0",
+		ID:      2,
+		Method:  "resources/read",
+		Params:  map[string]any{"uri": "flo://missing"},
+	})
+	if resp.Error == nil || resp.Error.Code != codeResourceNotFound {
+		t.Errorf("expected resource not found, got %+v", resp.Error)
+	}
+
+	resp, _ = server.HandleRequest(Request{
+		JSONRPC: "2.0",
+		ID:      3,
+		Method:  "resources/read",
+		Params:  map[string]any{"uri": "flo://broken"},
+	})
+	if resp.Error == nil || resp.Error.Code != codeToolError {
+		t.Errorf("expected read error, got %+v", resp.Error)
+	}
+
+	resp, _ = server.HandleRequest(Request{JSONRPC: "2.0", ID: 4, Method: "resources/read"})
+	if resp.Error == nil || resp.Error.Code != -32602 {
+		t.Errorf("expected invalid params, got %+v", resp.Error)
+	}
+}
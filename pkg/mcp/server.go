@@ -3,10 +3,17 @@ package mcp
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/idle"
 	"github.com/richgo/flo/pkg/tools"
 )
 
@@ -16,6 +23,13 @@ const (
 	serverVersion   = "0.1.0"
 )
 
+// Server-defined JSON-RPC error codes.
+const (
+	codeToolError   = -32000
+	codeToolTimeout = -32001
+	codeToolBusy    = -32003
+)
+
 // Request represents a JSON-RPC 2.0 request.
 type Request struct {
 	JSONRPC string         `json:"jsonrpc"`
@@ -39,25 +53,97 @@ type ErrorResp struct {
 	Data    any    `json:"data,omitempty"`
 }
 
-// Server is an MCP server that exposes tools.
+// DefaultConcurrency is the number of requests Serve handles at once.
+const DefaultConcurrency = 8
+
+// DefaultPageSize is the number of tools or resources per list response.
+const DefaultPageSize = 100
+
+// Server is an MCP server that exposes tools and, optionally, resources.
 type Server struct {
-	tools *tools.Registry
+	tools       *tools.Registry
+	exposure    atomic.Pointer[tools.Exposure]
+	resources   []ResourceLister
+	concurrency int
+
+	pageSize    int
+	authToken   string
+	idleTimeout time.Duration
+	keepalive   time.Duration
+	activity    *idle.Monitor
+
+	// stdio is the session of the client served by Serve and HandleRequest.
+	stdio *Session
+
+	// mu guards the HTTP sessions and the log level they start with.
+	mu       sync.RWMutex
+	sessions map[string]*Session
+	logLevel LogLevel
+
+	// done is closed by Close to stop the server's background work.
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // NewServer creates a new MCP server with the given tools.
 func NewServer(toolReg *tools.Registry) *Server {
 	return &Server{
-		tools: toolReg,
+		tools:       toolReg,
+		concurrency: DefaultConcurrency,
+		pageSize:    DefaultPageSize,
+		stdio:       newSession("stdio", DefaultLogLevel),
+		sessions:    make(map[string]*Session),
+		logLevel:    DefaultLogLevel,
+		done:        make(chan struct{}),
+	}
+}
+
+// Close stops the server's background work, such as closing idle HTTP
+// sessions. Handlers already returned by HTTPHandler keep serving.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// SetConcurrency sets how many requests Serve handles at once (minimum 1).
+func (s *Server) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.concurrency = n
+}
+
+// SetExposure sets which tools are served and under what names (nil
+// serves all of them as registered) and tells clients the list changed.
+func (s *Server) SetExposure(e *tools.Exposure) {
+	s.exposure.Store(e)
+	s.ToolsChanged()
+}
+
+// SetPageSize sets how many tools or resources a list response holds
+// before it returns a cursor to the next page (minimum 1).
+func (s *Server) SetPageSize(n int) {
+	if n < 1 {
+		n = 1
 	}
+	s.pageSize = n
 }
 
-// HandleRequest processes a single MCP request and returns a response.
+// HandleRequest processes a single MCP request from the stdio client and
+// returns a response.
 // Returns nil response for notifications (requests without ID).
 func (s *Server) HandleRequest(req Request) (*Response, error) {
+	return s.handle(context.Background(), s.stdio, req), nil
+}
+
+// handle processes a request in a client's session. Tool calls run with
+// ctx, which carries the session.
+func (s *Server) handle(ctx context.Context, sess *Session, req Request) *Response {
 	// Notifications don't get responses
 	if req.ID == nil {
-		// Handle known notifications silently
-		return nil, nil
+		if req.Method == "notifications/cancelled" {
+			s.handleCancelled(sess, req.Params)
+		}
+		return nil
 	}
 
 	resp := &Response{
@@ -68,15 +154,36 @@ func (s *Server) HandleRequest(req Request) (*Response, error) {
 	switch req.Method {
 	case "initialize":
 		resp.Result = s.handleInitialize(req.Params)
+	case "ping":
+		resp.Result = map[string]any{}
 	case "tools/list":
-		resp.Result = s.handleToolsList()
+		if result, errResp := s.handleToolsList(sess, req.Params); errResp != nil {
+			resp.Error = errResp
+		} else {
+			resp.Result = result
+		}
+	case "resources/list":
+		if result, errResp := s.handleResourcesList(sess, req.Params); errResp != nil {
+			resp.Error = errResp
+		} else {
+			resp.Result = result
+		}
+	case "resources/read":
+		if result, errResp := s.handleResourcesRead(req.Params); errResp != nil {
+			resp.Error = errResp
+		} else {
+			resp.Result = result
+		}
+	case "logging/setLevel":
+		if result, errResp := s.handleSetLevel(sess, req.Params); errResp != nil {
+			resp.Error = errResp
+		} else {
+			resp.Result = result
+		}
 	case "tools/call":
-		result, err := s.handleToolsCall(req.Params)
+		result, err := s.handleToolsCall(tools.WithSession(ctx, sess), sess, req.Params)
 		if err != nil {
-			resp.Error = &ErrorResp{
-				Code:    -32000,
-				Message: err.Error(),
-			}
+			resp.Error = toolErrorResp(err)
 		} else {
 			resp.Result = result
 		}
@@ -87,27 +194,110 @@ func (s *Server) HandleRequest(req Request) (*Response, error) {
 		}
 	}
 
-	return resp, nil
+	return resp
+}
+
+// handleCancelled aborts the request named by a notifications/cancelled
+// from the client. Requests that already finished, or were never seen, are
+// ignored as the protocol requires.
+func (s *Server) handleCancelled(sess *Session, params map[string]any) {
+	id := params["requestId"]
+	if id == nil || !sess.cancelRequest(id) {
+		return
+	}
+	reason, _ := params["reason"].(string)
+	audit.Info("mcp.cancel", "Request cancelled by client", map[string]interface{}{
+		"session":    sess.ID(),
+		"request_id": requestKey(id),
+		"reason":     reason,
+	})
+}
+
+// duplicateResp rejects a request whose ID is already in flight.
+func duplicateResp(id any) *Response {
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: &ErrorResp{
+			Code:    -32600,
+			Message: fmt.Sprintf("Invalid request: id %s is already in flight", requestKey(id)),
+		},
+	}
+}
+
+// toolErrorResp converts a tool error to a JSON-RPC error. Invalid
+// arguments, timeouts and busy tools carry structured data so clients can
+// tell them apart from tool failures.
+func toolErrorResp(err error) *ErrorResp {
+	var invalid *tools.ValidationError
+	if errors.As(err, &invalid) {
+		return &ErrorResp{
+			Code:    -32602,
+			Message: "Invalid params: " + err.Error(),
+			Data: map[string]any{
+				"type":   "invalid_arguments",
+				"tool":   invalid.Tool,
+				"errors": invalid.Problems,
+			},
+		}
+	}
+	var busy *tools.BusyError
+	if errors.As(err, &busy) {
+		return &ErrorResp{
+			Code:    codeToolBusy,
+			Message: err.Error(),
+			Data: map[string]any{
+				"type":           "busy",
+				"tool":           busy.Tool,
+				"max_concurrent": busy.MaxConcurrent,
+			},
+		}
+	}
+	var timeout *tools.TimeoutError
+	if errors.As(err, &timeout) {
+		return &ErrorResp{
+			Code:    codeToolTimeout,
+			Message: err.Error(),
+			Data: map[string]any{
+				"type":       "timeout",
+				"tool":       timeout.Tool,
+				"timeout_ms": timeout.Timeout.Milliseconds(),
+			},
+		}
+	}
+	return &ErrorResp{
+		Code:    codeToolError,
+		Message: err.Error(),
+	}
 }
 
 func (s *Server) handleInitialize(params map[string]any) map[string]any {
+	capabilities := map[string]any{
+		"tools":   map[string]any{"listChanged": true},
+		"logging": map[string]any{},
+	}
+	if len(s.resources) > 0 {
+		capabilities["resources"] = map[string]any{"listChanged": true}
+	}
 	return map[string]any{
 		"protocolVersion": protocolVersion,
 		"serverInfo": map[string]any{
 			"name":    serverName,
 			"version": serverVersion,
 		},
-		"capabilities": map[string]any{
-			"tools": map[string]any{},
-		},
+		"capabilities": capabilities,
 	}
 }
 
-func (s *Server) handleToolsList() map[string]any {
-	toolsList := s.tools.List()
-	result := make([]map[string]any, 0, len(toolsList))
+func (s *Server) handleToolsList(sess *Session, params map[string]any) (map[string]any, *ErrorResp) {
+	toolsList := s.exposure.Load().Expose(s.tools.List())
+	start, end, next, errResp := s.page(sess, params, len(toolsList))
+	if errResp != nil {
+		return nil, errResp
+	}
+	result := make([]map[string]any, 0, end-start)
 
-	for _, tool := range toolsList {
+	for _, tool := range toolsList[start:end] {
 		toolInfo := map[string]any{
 			"name":        tool.Name,
 			"description": tool.Description,
@@ -123,12 +313,35 @@ func (s *Server) handleToolsList() map[string]any {
 		result = append(result, toolInfo)
 	}
 
-	return map[string]any{
+	list := map[string]any{
 		"tools": result,
 	}
+	if next != "" {
+		list["nextCursor"] = next
+	}
+	return list, nil
+}
+
+// page returns the bounds of the list page requested by params["cursor"]
+// and the cursor of the following page, if any.
+func (s *Server) page(sess *Session, params map[string]any, total int) (start, end int, next string, errResp *ErrorResp) {
+	if cursor, ok := params["cursor"].(string); ok && cursor != "" {
+		offset, ok := sess.cursorOffset(cursor)
+		if !ok {
+			return 0, 0, "", &ErrorResp{Code: -32602, Message: "Invalid params: unknown cursor"}
+		}
+		start = min(offset, total)
+	}
+	end = min(start+s.pageSize, total)
+	if end < total {
+		next = sess.newCursor(end)
+	}
+	return start, end, next, nil
 }
 
-func (s *Server) handleToolsCall(params map[string]any) (map[string]any, error) {
+// handleToolsCall runs a tool for sess, whose client alone is sent the
+// call's log messages.
+func (s *Server) handleToolsCall(ctx context.Context, sess *Session, params map[string]any) (map[string]any, error) {
 	name, ok := params["name"].(string)
 	if !ok {
 		return nil, fmt.Errorf("missing tool name")
@@ -139,10 +352,39 @@ func (s *Server) handleToolsCall(params map[string]any) (map[string]any, error)
 		args = make(map[string]any)
 	}
 
-	result, err := s.tools.Execute(name, tools.Args(args))
+	// Tools are called by the name they are served under
+	own, ok := s.exposure.Load().Lookup(s.tools.List(), name)
+	if !ok {
+		return nil, fmt.Errorf("tool '%s' not found", name)
+	}
+
+	s.logTo(sess, LogDebug, "tools", map[string]any{"event": "call", "tool": name})
+	start := time.Now()
+	result, err := s.tools.ExecuteContext(ctx, own, tools.Args(args))
+	duration := time.Since(start)
+	if err != nil && context.Cause(ctx) == errRequestCancelled {
+		s.logTo(sess, LogInfo, "tools", map[string]any{
+			"event":       "cancelled",
+			"tool":        name,
+			"duration_ms": duration.Milliseconds(),
+		})
+		return nil, err
+	}
 	if err != nil {
+		s.logTo(sess, LogError, "tools", map[string]any{
+			"event":       "failed",
+			"tool":        name,
+			"duration_ms": duration.Milliseconds(),
+			"error":       err.Error(),
+		})
 		return nil, err
 	}
+	s.logTo(sess, LogInfo, "tools", map[string]any{
+		"event":        "done",
+		"tool":         name,
+		"duration_ms":  duration.Milliseconds(),
+		"output_bytes": len(result),
+	})
 
 	return map[string]any{
 		"content": []map[string]any{
@@ -194,7 +436,7 @@ func (s *Server) ProcessRequest(input io.Reader, output io.Writer) error {
 	return nil
 }
 
-func (s *Server) writeResponse(output io.Writer, resp *Response) error {
+func (s *Server) writeResponse(output io.Writer, resp any) error {
 	data, err := json.Marshal(resp)
 	if err != nil {
 		return err
@@ -203,37 +445,162 @@ func (s *Server) writeResponse(output io.Writer, resp *Response) error {
 	return err
 }
 
-// Serve runs the MCP server on stdio until EOF.
+// Serve runs the MCP server on stdio until EOF. See ServeContext.
 func (s *Server) Serve(input io.Reader, output io.Writer) error {
-	scanner := bufio.NewScanner(input)
-	for scanner.Scan() {
-		line := scanner.Bytes()
+	return s.ServeContext(context.Background(), input, output)
+}
+
+// ServeContext runs the MCP server on stdio until EOF, ctx is done, the
+// idle timeout passes or the client stops answering keepalive pings.
+// Requests are handled concurrently, up to the configured limit, so a slow
+// tool call doesn't block pings or other calls. Responses are written
+// whole, one per line, as each request finishes; clients match them to
+// requests by ID. A request reusing the ID of one still in flight is
+// rejected, and one the client cancels with notifications/cancelled has
+// its context cancelled and gets no response. Log notifications are interleaved with responses. ServeContext
+// returns after in-flight requests have been answered; it returns
+// ErrIdleTimeout or ErrClientGone when it gave up on the client.
+func (s *Server) ServeContext(ctx context.Context, input io.Reader, output io.Writer) error {
+	responses := make(chan any)
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for resp := range responses {
+			s.writeResponse(output, resp)
+		}
+	}()
+
+	s.stdio.setSender(func(msg any) { responses <- msg })
+
+	// Read on a separate goroutine so idleness, keepalive failures and ctx
+	// can end the loop while a read is blocked.
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(input)
+		for scanner.Scan() {
+			lines <- append([]byte(nil), scanner.Bytes()...)
+		}
+		readErr <- scanner.Err()
+		close(lines)
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		slots    = make(chan struct{}, s.concurrency)
+		activity = s.activity
+		alive    = newKeepalive(s.keepalive)
+		err      error
+	)
+
+	idle := make(chan struct{})
+	idleCtx, stopIdle := context.WithCancel(ctx)
+	defer stopIdle()
+	if activity != nil {
+		go func() {
+			if activity.Wait(idleCtx) {
+				close(idle)
+			}
+		}()
+	}
+
+loop:
+	for {
+		var line []byte
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				err = <-readErr
+				break loop
+			}
+			line = l
+		case <-alive.tick():
+			if !alive.check() {
+				err = ErrClientGone
+				break loop
+			}
+			if ping := alive.ping(); ping != nil {
+				responses <- ping
+			}
+			continue
+		case <-idle:
+			err = ErrIdleTimeout
+			break loop
+		case <-ctx.Done():
+			err = ctx.Err()
+			break loop
+		}
+
 		if len(line) == 0 {
 			continue
 		}
+		alive.seen()
 
 		var req Request
 		if err := json.Unmarshal(line, &req); err != nil {
-			resp := Response{
+			responses <- &Response{
 				JSONRPC: "2.0",
 				Error: &ErrorResp{
 					Code:    -32700,
 					Message: "Parse error: " + err.Error(),
 				},
 			}
-			s.writeResponse(output, &resp)
+			continue
+		}
+		if req.Method == "" {
+			// A response to one of our keepalive pings.
 			continue
 		}
 
-		resp, err := s.HandleRequest(req)
-		if err != nil {
+		// Notifications are handled in order and without waiting for a
+		// slot, so a cancellation reaches a call that holds the last one.
+		if req.ID == nil {
+			s.handle(ctx, s.stdio, req)
+			continue
+		}
+		reqCtx, finish, ok := s.stdio.startRequest(ctx, req.ID)
+		if !ok {
+			responses <- duplicateResp(req.ID)
 			continue
 		}
 
-		if resp != nil {
-			s.writeResponse(output, resp)
+		end := func() {}
+		if activity != nil {
+			end = activity.Begin()
 		}
+		wg.Add(1)
+		go func(req Request) {
+			defer wg.Done()
+			defer end()
+			var resp *Response
+			select {
+			case slots <- struct{}{}:
+				resp = s.handle(reqCtx, s.stdio, req)
+				<-slots
+			case <-reqCtx.Done():
+			}
+			if cancelled := finish(); !cancelled && resp != nil {
+				responses <- resp
+			}
+		}(req)
 	}
 
-	return scanner.Err()
+	alive.stop()
+	wg.Wait()
+	s.stdio.setSender(nil)
+	close(responses)
+	<-writerDone
+	if err != nil {
+		audit.Info("mcp.serve", "MCP server stopped", map[string]interface{}{
+			"reason": err.Error(),
+		})
+	}
+	return err
+}
+
+// requestKey identifies a request ID across its JSON types (1 and "1"
+// are different IDs).
+func requestKey(id any) string {
+	data, _ := json.Marshal(id)
+	return string(data)
 }
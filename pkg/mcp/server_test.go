@@ -1,9 +1,17 @@
 package mcp
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/richgo/flo/pkg/tools"
 )
@@ -152,6 +160,304 @@ func TestMCPToolsCallNotFound(t *testing.T) {
 	}
 }
 
+func TestMCPToolExposure(t *testing.T) {
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("echo", "Echo tool", nil, func(args tools.Args) (string, error) {
+		return "echoed", nil
+	}))
+	toolReg.Register(tools.New("shell", "Run anything", nil, func(args tools.Args) (string, error) {
+		return "ran", nil
+	}))
+
+	server := NewServer(toolReg)
+	server.SetExposure(&tools.Exposure{Disable: []string{"shell"}, Namespace: "flo"})
+
+	resp, _ := server.HandleRequest(Request{JSONRPC: "2.0", ID: 1, Method: "tools/list"})
+	list := resp.Result.(map[string]any)["tools"].([]map[string]any)
+	if len(list) != 1 || list[0]["name"] != "flo.echo" {
+		t.Errorf("expected only flo.echo to be listed, got %v", list)
+	}
+
+	call := func(name string) *Response {
+		resp, _ := server.HandleRequest(Request{JSONRPC: "2.0", ID: 2, Method: "tools/call", Params: map[string]any{"name": name}})
+		return resp
+	}
+	if resp := call("flo.echo"); resp.Error != nil {
+		t.Errorf("expected flo.echo to be callable, got %v", resp.Error)
+	}
+	for _, name := range []string{"echo", "shell", "flo.shell"} {
+		if resp := call(name); resp.Error == nil || !strings.Contains(resp.Error.Message, "not found") {
+			t.Errorf("expected %s to be refused as not found, got %+v", name, resp)
+		}
+	}
+}
+
+func TestMCPToolsCallTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("hang", "Never returns", nil, func(args tools.Args) (string, error) {
+		<-block
+		return "", nil
+	}))
+	toolReg.SetLimits("hang", tools.Limits{Timeout: 20 * time.Millisecond})
+	toolReg.Register(tools.New("echo", "Echo tool", nil, func(args tools.Args) (string, error) {
+		return "still serving", nil
+	}))
+	server := NewServer(toolReg)
+
+	input := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hang"}}
+{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}
+`)
+	var output bytes.Buffer
+	if err := server.Serve(input, &output); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	byID := responsesByID(t, lines)
+	if len(byID) != 2 {
+		t.Fatalf("expected 2 responses, got %d: %s", len(byID), output.String())
+	}
+
+	var resp struct {
+		Error *struct {
+			Code int            `json:"code"`
+			Data map[string]any `json:"data"`
+		} `json:"error"`
+	}
+	json.Unmarshal([]byte(byID[1]), &resp)
+	if resp.Error == nil || resp.Error.Code != codeToolTimeout {
+		t.Fatalf("expected timeout error code, got %s", byID[1])
+	}
+	if resp.Error.Data["type"] != "timeout" || resp.Error.Data["tool"] != "hang" || resp.Error.Data["timeout_ms"] != float64(20) {
+		t.Errorf("unexpected timeout data: %v", resp.Error.Data)
+	}
+	if !strings.Contains(byID[2], "still serving") {
+		t.Errorf("expected the server to keep serving, got %s", byID[2])
+	}
+}
+
+func TestMCPToolsCallBusy(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("hang", "Never returns", nil, func(args tools.Args) (string, error) {
+		<-block
+		return "", nil
+	}))
+	toolReg.SetLimits("hang", tools.Limits{Timeout: 20 * time.Millisecond, MaxConcurrent: 1})
+	server := NewServer(toolReg)
+
+	// The first call times out but keeps its slot while the handler runs.
+	server.HandleRequest(Request{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: map[string]any{"name": "hang"}})
+	resp, _ := server.HandleRequest(Request{JSONRPC: "2.0", ID: 2, Method: "tools/call", Params: map[string]any{"name": "hang"}})
+
+	if resp.Error == nil || resp.Error.Code != codeToolBusy {
+		t.Fatalf("expected busy error code, got %+v", resp)
+	}
+	data, _ := resp.Error.Data.(map[string]any)
+	if data["type"] != "busy" || data["tool"] != "hang" || data["max_concurrent"] != 1 {
+		t.Errorf("unexpected busy data: %v", resp.Error.Data)
+	}
+}
+
+func TestMCPToolsCallInvalidArguments(t *testing.T) {
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("greet", "Greets", map[string]any{
+		"type":     "object",
+		"required": []any{"name"},
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string"},
+			"times": map[string]any{"type": "integer"},
+		},
+	}, func(args tools.Args) (string, error) {
+		t.Error("handler ran despite invalid arguments")
+		return "", nil
+	}))
+	server := NewServer(toolReg)
+
+	resp, _ := server.HandleRequest(Request{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: map[string]any{
+		"name":      "greet",
+		"arguments": map[string]any{"times": "twice"},
+	}})
+	if resp.Error == nil || resp.Error.Code != -32602 {
+		t.Fatalf("expected invalid params error, got %+v", resp)
+	}
+	if !strings.HasPrefix(resp.Error.Message, "Invalid params: ") {
+		t.Errorf("unexpected message: %s", resp.Error.Message)
+	}
+
+	// Round-trip through JSON as a client would see it.
+	raw, _ := json.Marshal(resp.Error.Data)
+	var data struct {
+		Type   string `json:"type"`
+		Tool   string `json:"tool"`
+		Errors []struct {
+			Path    string `json:"path"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	json.Unmarshal(raw, &data)
+	if data.Type != "invalid_arguments" || data.Tool != "greet" || len(data.Errors) != 2 {
+		t.Fatalf("unexpected data: %s", raw)
+	}
+	if data.Errors[0].Path != "name" || data.Errors[0].Message != "is required" {
+		t.Errorf("unexpected first error: %+v", data.Errors[0])
+	}
+	if data.Errors[1].Path != "times" || data.Errors[1].Message != "must be an integer" {
+		t.Errorf("unexpected second error: %+v", data.Errors[1])
+	}
+}
+
+// responsesByID indexes response lines by their numeric ID, skipping
+// notifications.
+func responsesByID(t *testing.T, lines []string) map[int]string {
+	t.Helper()
+	byID := make(map[int]string)
+	for _, line := range lines {
+		var resp struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid response line %q: %v", line, err)
+		}
+		if resp.Method != "" {
+			continue
+		}
+		byID[resp.ID] = line
+	}
+	return byID
+}
+
+func TestMCPServeConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("slow", "Waits to be released", nil, func(args tools.Args) (string, error) {
+		<-release
+		return "slow done", nil
+	}))
+	server := NewServer(toolReg)
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(inR, outW)
+		outW.Close()
+	}()
+	lines := bufio.NewScanner(outR)
+
+	io.WriteString(inW, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`+"\n")
+	io.WriteString(inW, `{"jsonrpc":"2.0","id":2,"method":"ping"}`+"\n")
+
+	// The ping is answered while the slow call is still running.
+	if !lines.Scan() || !strings.Contains(lines.Text(), `"id":2`) {
+		t.Fatalf("expected ping response first, got %q", lines.Text())
+	}
+
+	// Reusing an in-flight ID is rejected.
+	io.WriteString(inW, `{"jsonrpc":"2.0","id":1,"method":"ping"}`+"\n")
+	if !lines.Scan() || !strings.Contains(lines.Text(), "already in flight") {
+		t.Fatalf("expected duplicate ID error, got %q", lines.Text())
+	}
+
+	close(release)
+	if !lines.Scan() || !strings.Contains(lines.Text(), "slow done") {
+		t.Fatalf("expected slow response, got %q", lines.Text())
+	}
+
+	inW.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Serve failed: %v", err)
+	}
+}
+
+func TestMCPServeConcurrencyLimit(t *testing.T) {
+	var running, peak int32
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("work", "Tracks concurrency", nil, func(args tools.Args) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return "ok", nil
+	}))
+	server := NewServer(toolReg)
+	server.SetConcurrency(2)
+
+	var input strings.Builder
+	for i := 1; i <= 6; i++ {
+		fmt.Fprintf(&input, `{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"work"}}`+"\n", i)
+	}
+	var output bytes.Buffer
+	if err := server.Serve(strings.NewReader(input.String()), &output); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	if got := len(responsesByID(t, strings.Split(strings.TrimSpace(output.String()), "\n"))); got != 6 {
+		t.Errorf("expected 6 responses, got %d", got)
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent calls, saw %d", peak)
+	}
+}
+
+func TestMCPServeCancel(t *testing.T) {
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.NewWithContext("hang", "Runs until cancelled", nil, func(ctx context.Context, args tools.Args) (string, error) {
+		close(started)
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return "", ctx.Err()
+	}))
+	server := NewServer(toolReg)
+	// The cancellation must get through while the call holds the only slot.
+	server.SetConcurrency(1)
+
+	in, out, done := serveAsync(context.Background(), server)
+	fmt.Fprintln(in, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hang"}}`)
+	<-started
+	fmt.Fprintln(in, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1,"reason":"user gave up"}}`)
+
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the tool context to be cancelled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("tool was not cancelled")
+	}
+
+	// The cancelled call gets no response; the next request is answered.
+	fmt.Fprintln(in, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if !out.Scan() {
+		t.Fatal("expected a response")
+	}
+	var resp Response
+	json.Unmarshal(out.Bytes(), &resp)
+	if resp.ID != float64(2) {
+		t.Errorf("expected only the ping response, got %s", out.Text())
+	}
+
+	in.Close()
+	go func() {
+		for out.Scan() {
+		}
+	}()
+	waitServe(t, done)
+}
+
 func TestMCPUnknownMethod(t *testing.T) {
 	server := NewServer(tools.NewRegistry())
 
@@ -276,3 +582,31 @@ func TestMCPToolsListWithSchema(t *testing.T) {
 		t.Errorf("expected type 'string', got '%v'", nameProp["type"])
 	}
 }
+
+func TestMCPToolsListChanged(t *testing.T) {
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.New("install", "Add another tool", nil, func(args tools.Args) (string, error) {
+		toolReg.Register(tools.New("added", "Added at runtime", nil, func(args tools.Args) (string, error) {
+			return "", nil
+		}))
+		return "ok", nil
+	}))
+	server := NewServer(toolReg)
+	defer toolReg.Watch(server.ToolsChanged)()
+
+	resp, _ := server.HandleRequest(Request{JSONRPC: "2.0", ID: 1, Method: "initialize"})
+	caps := resp.Result.(map[string]any)["capabilities"].(map[string]any)
+	if toolCaps, _ := caps["tools"].(map[string]any); toolCaps["listChanged"] != true {
+		t.Errorf("expected tools.listChanged capability, got %v", caps["tools"])
+	}
+
+	input := strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"install"}}
+`)
+	var output bytes.Buffer
+	if err := server.Serve(input, &output); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+	if !strings.Contains(output.String(), `"method":"notifications/tools/list_changed"`) {
+		t.Errorf("expected a tools/list_changed notification, got %s", output.String())
+	}
+}
@@ -0,0 +1,275 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+)
+
+// Session is the state the server keeps for one client: its log level,
+// focused task, pagination cursors and in-flight requests. Stdio serves a single session; each
+// HTTP client gets its own.
+type Session struct {
+	id string
+
+	mu       sync.RWMutex
+	logLevel LogLevel
+	focus    string
+	cursors  map[string]int
+	lastUsed time.Time
+	// inFlight cancels the requests being handled, by request key.
+	inFlight map[string]context.CancelCauseFunc
+	// send delivers a message to the client while it is connected.
+	send func(msg any)
+	// closed is closed when the session ends.
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newSession(id string, level LogLevel) *Session {
+	return &Session{
+		id:       id,
+		logLevel: level,
+		cursors:  make(map[string]int),
+		lastUsed: time.Now(),
+		inFlight: make(map[string]context.CancelCauseFunc),
+		closed:   make(chan struct{}),
+	}
+}
+
+// ID returns the session ID.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Focus returns the ID of the task the client is working on, if any.
+func (s *Session) Focus() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.focus
+}
+
+// SetFocus sets the task the client is working on.
+func (s *Session) SetFocus(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.focus = taskID
+}
+
+// LogLevel returns the minimum level of log notifications sent to the client.
+func (s *Session) LogLevel() LogLevel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.logLevel
+}
+
+// SetLogLevel sets the minimum level of log notifications.
+func (s *Session) SetLogLevel(level LogLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logLevel = level
+}
+
+// errRequestCancelled is the cause of requests the client cancelled with
+// notifications/cancelled.
+var errRequestCancelled = errors.New("request cancelled by client")
+
+// startRequest registers a request as in flight so the client can cancel
+// it, returning a context that is cancelled when it does. It returns false
+// if a request with the same ID is already in flight. finish unregisters
+// the request and reports whether the client cancelled it, in which case
+// no response should be sent.
+func (s *Session) startRequest(ctx context.Context, id any) (reqCtx context.Context, finish func() (cancelled bool), ok bool) {
+	key := requestKey(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, busy := s.inFlight[key]; busy {
+		return nil, nil, false
+	}
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	s.inFlight[key] = cancel
+	return reqCtx, func() bool {
+		s.mu.Lock()
+		delete(s.inFlight, key)
+		s.mu.Unlock()
+		cancelled := context.Cause(reqCtx) == errRequestCancelled
+		cancel(nil)
+		return cancelled
+	}, true
+}
+
+// cancelRequest cancels the in-flight request with the given ID and
+// reports whether there was one.
+func (s *Session) cancelRequest(id any) bool {
+	s.mu.RLock()
+	cancel, ok := s.inFlight[requestKey(id)]
+	s.mu.RUnlock()
+	if ok {
+		cancel(errRequestCancelled)
+	}
+	return ok
+}
+
+// touch records a request in the session.
+func (s *Session) touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastUsed = time.Now()
+}
+
+// unusedFor returns how long ago the session's last request was.
+func (s *Session) unusedFor() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Since(s.lastUsed)
+}
+
+// setSender connects (or, with nil, disconnects) the client's message stream.
+func (s *Session) setSender(send func(msg any)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.send = send
+}
+
+// attach connects a message stream unless one is already connected.
+func (s *Session) attach(send func(msg any)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.send != nil {
+		return false
+	}
+	s.send = send
+	return true
+}
+
+// notify sends msg if the client is connected. It returns whether it was sent.
+func (s *Session) notify(msg any) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.send == nil {
+		return false
+	}
+	s.send(msg)
+	return true
+}
+
+// log sends a log notification if level is at or above the session's level.
+func (s *Session) log(level LogLevel, msg *Notification) {
+	if level.AtLeast(s.LogLevel()) {
+		s.notify(msg)
+	}
+}
+
+// newCursor returns an opaque cursor for the list position offset. Cursors
+// are only valid in the session that issued them.
+func (s *Session) newCursor(offset int) string {
+	cursor := randomID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[cursor] = offset
+	return cursor
+}
+
+// cursorOffset returns the list position of a cursor issued by newCursor.
+func (s *Session) cursorOffset(cursor string) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	offset, ok := s.cursors[cursor]
+	return offset, ok
+}
+
+// NewSession starts a session for a new client.
+func (s *Server) NewSession() *Session {
+	s.mu.Lock()
+	sess := newSession(randomID(), s.logLevel)
+	s.sessions[sess.id] = sess
+	s.mu.Unlock()
+	audit.Info("mcp.session", "MCP session started", map[string]interface{}{
+		"session": sess.id,
+	})
+	return sess
+}
+
+// Session returns an open session by ID.
+func (s *Server) Session(id string) (*Session, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sess, ok := s.sessions[id]
+	return sess, ok
+}
+
+// CloseSession ends a session. It returns false if there is no such session.
+func (s *Server) CloseSession(id string) bool {
+	s.mu.Lock()
+	sess, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	sess.setSender(nil)
+	sess.closeOnce.Do(func() { close(sess.closed) })
+	audit.Info("mcp.session", "MCP session closed", map[string]interface{}{
+		"session": id,
+	})
+	return true
+}
+
+// closeUnusedSessions ends HTTP sessions without a request for longer
+// than d, such as those of clients that went away without a DELETE.
+func (s *Server) closeUnusedSessions(d time.Duration) {
+	s.mu.RLock()
+	var expired []string
+	for id, sess := range s.sessions {
+		if sess.unusedFor() > d {
+			expired = append(expired, id)
+		}
+	}
+	s.mu.RUnlock()
+	for _, id := range expired {
+		s.CloseSession(id)
+	}
+}
+
+// allSessions returns the stdio session and every HTTP session.
+func (s *Server) allSessions() []*Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	all := make([]*Session, 0, len(s.sessions)+1)
+	all = append(all, s.stdio)
+	for _, sess := range s.sessions {
+		all = append(all, sess)
+	}
+	return all
+}
+
+// Notify sends a notification to every connected client.
+func (s *Server) Notify(method string, params map[string]any) {
+	msg := &Notification{JSONRPC: "2.0", Method: method, Params: params}
+	for _, sess := range s.allSessions() {
+		sess.notify(msg)
+	}
+}
+
+// ResourcesChanged tells every client that the resource list changed, such
+// as after a task was added or updated by another client.
+func (s *Server) ResourcesChanged() {
+	s.Notify("notifications/resources/list_changed", nil)
+}
+
+// ToolsChanged tells every client that the tool list changed, such as
+// after tools were reloaded or an external server's tools were added.
+func (s *Server) ToolsChanged() {
+	s.Notify("notifications/tools/list_changed", nil)
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,260 @@
+// Package notify delivers notifications about runs to the user.
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/events"
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/preferences"
+)
+
+// Event identifies what a notification is about.
+type Event string
+
+const (
+	EventRunComplete Event = "run_complete"
+	EventApproval    Event = "approval"
+	EventQuestion    Event = "question"
+)
+
+// Notification is a message shown to the user.
+type Notification struct {
+	Event   Event  `json:"event"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// Notifier delivers notifications.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// CommandRunner runs an external command.
+type CommandRunner func(name string, args ...string) error
+
+// Desktop shows native desktop notifications using the platform's tooling:
+// osascript on macOS, notify-send on Linux, and PowerShell on Windows.
+type Desktop struct {
+	goos   string
+	runner CommandRunner
+}
+
+// NewDesktop creates a desktop notifier for the current platform.
+func NewDesktop() *Desktop {
+	return &Desktop{
+		goos: runtime.GOOS,
+		runner: func(name string, args ...string) error {
+			return exec.Command(name, args...).Run()
+		},
+	}
+}
+
+// SetRunner overrides the command runner (for testing).
+func (d *Desktop) SetRunner(goos string, runner CommandRunner) {
+	d.goos = goos
+	d.runner = runner
+}
+
+// Notify shows a desktop notification.
+func (d *Desktop) Notify(n Notification) error {
+	name, args, err := d.command(n)
+	if err != nil {
+		return err
+	}
+	if err := d.runner(name, args...); err != nil {
+		return fmt.Errorf("failed to show notification via %s: %w", name, err)
+	}
+	return nil
+}
+
+// command builds the platform command for a notification.
+func (d *Desktop) command(n Notification) (string, []string, error) {
+	switch d.goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s",
+			appleScriptQuote(n.Message), appleScriptQuote(n.Title))
+		return "osascript", []string{"-e", script}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return "notify-send", []string{"--app-name=flo", n.Title, n.Message}, nil
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, %s, %s, [System.Windows.Forms.ToolTipIcon]::Info)
+Start-Sleep -Seconds 5
+$n.Dispose()`, powerShellQuote(n.Title), powerShellQuote(n.Message))
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", script}, nil
+	default:
+		return "", nil, fmt.Errorf("desktop notifications are not supported on %s", d.goos)
+	}
+}
+
+// appleScriptQuote returns s as an AppleScript string literal.
+func appleScriptQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// powerShellQuote returns s as a single-quoted PowerShell string literal.
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Dispatcher sends notifications that the user's preferences allow.
+// Delivery is best-effort: failures are audited, never returned.
+//
+// Local notifiers (the desktop) are always used. Remote notifiers are skipped
+// in offline mode; their notifications are buffered in the outbox and
+// delivered by the next send made while online.
+type Dispatcher struct {
+	prefs    preferences.Notifications
+	notifier Notifier
+	remote   []Notifier
+	outbox   *Outbox
+}
+
+// NewDispatcher creates a dispatcher for the given preferences.
+func NewDispatcher(prefs preferences.Notifications, notifier Notifier) *Dispatcher {
+	return &Dispatcher{prefs: prefs, notifier: notifier}
+}
+
+// Default creates a desktop dispatcher from the user's saved preferences.
+func Default() *Dispatcher {
+	prefs, err := preferences.LoadDefault()
+	if err != nil {
+		audit.Warn("notify.prefs", "Failed to load preferences", map[string]interface{}{
+			"error": err.Error(),
+		})
+		prefs = preferences.Default()
+	}
+	d := NewDispatcher(prefs.Notifications, NewDesktop())
+	if path, err := DefaultOutboxPath(); err == nil {
+		d.SetOutbox(NewOutbox(path))
+	}
+	return d
+}
+
+// AddRemote registers a notifier that needs the network.
+func (d *Dispatcher) AddRemote(n Notifier) {
+	d.remote = append(d.remote, n)
+}
+
+// SetOutbox sets where remote notifications are buffered while offline.
+func (d *Dispatcher) SetOutbox(o *Outbox) {
+	d.outbox = o
+}
+
+// Enabled reports whether notifications for event are turned on.
+func (d *Dispatcher) Enabled(event Event) bool {
+	if d == nil || !d.prefs.Enabled {
+		return false
+	}
+	switch event {
+	case EventRunComplete:
+		return d.prefs.RunComplete
+	case EventApproval:
+		return d.prefs.Approval
+	case EventQuestion:
+		return d.prefs.Question
+	}
+	return true
+}
+
+// Send delivers a notification if its event is enabled.
+func (d *Dispatcher) Send(event Event, title, message string) {
+	if !d.Enabled(event) {
+		return
+	}
+	n := Notification{Event: event, Title: title, Message: message}
+	if d.notifier != nil {
+		d.deliver(d.notifier, n)
+	}
+	if len(d.remote) == 0 {
+		return
+	}
+
+	if offline.Enabled() {
+		if d.outbox == nil {
+			return
+		}
+		if err := d.outbox.Add(n); err != nil {
+			audit.Warn("notify.outbox", "Failed to buffer notification", map[string]interface{}{
+				"event": string(event),
+				"error": err.Error(),
+			})
+		}
+		return
+	}
+
+	d.Flush()
+	for _, r := range d.remote {
+		d.deliver(r, n)
+	}
+}
+
+// Flush delivers notifications buffered while offline to remote notifiers.
+// It does nothing in offline mode.
+func (d *Dispatcher) Flush() {
+	if d == nil || d.outbox == nil || len(d.remote) == 0 || offline.Enabled() {
+		return
+	}
+	pending, err := d.outbox.Drain()
+	if err != nil {
+		audit.Warn("notify.outbox", "Failed to read outbox", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	for _, n := range pending {
+		for _, r := range d.remote {
+			d.deliver(r, n)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(notifier Notifier, n Notification) {
+	if err := notifier.Notify(n); err != nil {
+		audit.Warn("notify.send", "Failed to deliver notification", map[string]interface{}{
+			"event": string(n.Event),
+			"error": err.Error(),
+		})
+	}
+}
+
+// Subscribe notifies of the events published on bus that people wait on:
+// agent runs finishing, approvals requested and agent questions. It stops
+// when the returned function is called.
+func (d *Dispatcher) Subscribe(bus *events.Bus) (unsubscribe func()) {
+	return bus.Subscribe(func(e events.Event) {
+		if n, ok := notificationFor(e); ok {
+			d.Send(n.Event, n.Title, n.Message)
+		}
+	})
+}
+
+// notificationFor returns the notification of an event, if it has one.
+func notificationFor(e events.Event) (Notification, bool) {
+	switch data := e.Data.(type) {
+	case audit.RunFinished:
+		switch {
+		case data.Review:
+			// The approval requested for the review notifies
+			return Notification{}, false
+		case data.Success:
+			return Notification{Event: EventRunComplete, Title: "flo: run finished", Message: fmt.Sprintf("Task %s completed: %s", data.TaskID, data.Title)}, true
+		default:
+			return Notification{Event: EventRunComplete, Title: "flo: run failed", Message: fmt.Sprintf("Task %s failed: %s", data.TaskID, data.Error)}, true
+		}
+	case audit.ApprovalRequested:
+		return Notification{Event: EventApproval, Title: "flo: approval needed", Message: fmt.Sprintf("%s (%s) is waiting for review", data.TaskID, data.Summary)}, true
+	case audit.QuestionAsked:
+		return Notification{Event: EventQuestion, Title: "flo: agent has a question", Message: fmt.Sprintf("Task %s needs your input", data.TaskID)}, true
+	}
+	return Notification{}, false
+}
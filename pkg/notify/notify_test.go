@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/events"
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/preferences"
+)
+
+type recorder struct {
+	sent []Notification
+	err  error
+}
+
+func (r *recorder) Notify(n Notification) error {
+	r.sent = append(r.sent, n)
+	return r.err
+}
+
+func TestDesktopCommands(t *testing.T) {
+	tests := []struct {
+		goos string
+		name string
+		want string
+	}{
+		{"darwin", "osascript", `display notification "Task \"t-001\" done" with title "flo"`},
+		{"linux", "notify-send", "Task \"t-001\" done"},
+		{"windows", "powershell", "ShowBalloonTip(10000, 'flo', 'Task \"t-001\" done'"},
+	}
+
+	for _, tt := range tests {
+		var gotName string
+		var gotArgs []string
+		d := NewDesktop()
+		d.SetRunner(tt.goos, func(name string, args ...string) error {
+			gotName, gotArgs = name, args
+			return nil
+		})
+
+		if err := d.Notify(Notification{Title: "flo", Message: `Task "t-001" done`}); err != nil {
+			t.Fatalf("%s: Notify failed: %v", tt.goos, err)
+		}
+		if gotName != tt.name {
+			t.Errorf("%s: expected %s, got %s", tt.goos, tt.name, gotName)
+		}
+		if !strings.Contains(strings.Join(gotArgs, " "), tt.want) {
+			t.Errorf("%s: expected args to contain %q, got %q", tt.goos, tt.want, gotArgs)
+		}
+	}
+}
+
+func TestDesktopUnsupported(t *testing.T) {
+	d := NewDesktop()
+	d.SetRunner("plan9", func(string, ...string) error { return nil })
+	if err := d.Notify(Notification{Title: "flo"}); err == nil {
+		t.Error("expected error on unsupported platform")
+	}
+}
+
+func TestDispatcherRespectsPreferences(t *testing.T) {
+	rec := &recorder{}
+	prefs := preferences.Default().Notifications
+	prefs.Question = false
+
+	d := NewDispatcher(prefs, rec)
+	d.Send(EventRunComplete, "flo", "done")
+	d.Send(EventQuestion, "flo", "which database?")
+	if len(rec.sent) != 1 || rec.sent[0].Event != EventRunComplete {
+		t.Errorf("expected only run_complete notification, got %+v", rec.sent)
+	}
+
+	prefs.Enabled = false
+	d = NewDispatcher(prefs, rec)
+	d.Send(EventApproval, "flo", "review t-001")
+	if len(rec.sent) != 1 {
+		t.Error("expected no notifications when disabled")
+	}
+}
+
+func TestDispatcherSubscribe(t *testing.T) {
+	rec := &recorder{}
+	bus := events.NewBus()
+	unsubscribe := NewDispatcher(preferences.Default().Notifications, rec).Subscribe(bus)
+
+	bus.Publish(events.Event{Level: audit.LevelInfo, Data: audit.RunFinished{TaskID: "t-001", Title: "Add OAuth", Success: true}})
+	bus.Publish(events.Event{Level: audit.LevelInfo, Data: audit.RunFinished{TaskID: "t-002", Success: true, Review: true}})
+	bus.Publish(events.Event{Level: audit.LevelInfo, Data: audit.ApprovalRequested{TaskID: "t-002", Summary: "Add logout"}})
+	bus.Publish(events.Event{Level: audit.LevelWarn, Data: audit.RunFinished{TaskID: "t-003", Error: "tests failed"}})
+	bus.Publish(events.Event{Level: audit.LevelInfo, Data: audit.TaskAdded{TaskID: "t-004"}})
+	unsubscribe()
+	bus.Publish(events.Event{Level: audit.LevelInfo, Data: audit.QuestionAsked{TaskID: "t-005"}})
+
+	var got []string
+	for _, n := range rec.sent {
+		got = append(got, string(n.Event)+": "+n.Message)
+	}
+	want := []string{
+		"run_complete: Task t-001 completed: Add OAuth",
+		"approval: t-002 (Add logout) is waiting for review",
+		"run_complete: Task t-003 failed: tests failed",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected notifications:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestDispatcherSwallowsErrors(t *testing.T) {
+	rec := &recorder{err: errors.New("no display")}
+	d := NewDispatcher(preferences.Default().Notifications, rec)
+	d.Send(EventApproval, "flo", "review t-001") // must not panic
+	if len(rec.sent) != 1 {
+		t.Error("expected delivery attempt")
+	}
+
+	var nilDispatcher *Dispatcher
+	nilDispatcher.Send(EventApproval, "flo", "ignored")
+}
+
+func TestDispatcherBuffersRemoteWhileOffline(t *testing.T) {
+	local, remote := &recorder{}, &recorder{}
+	d := NewDispatcher(preferences.Default().Notifications, local)
+	d.AddRemote(remote)
+	outbox := NewOutbox(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	d.SetOutbox(outbox)
+
+	offline.Set(true)
+	d.Send(EventRunComplete, "flo", "t-001 done")
+	d.Send(EventApproval, "flo", "review t-002")
+	offline.Set(false)
+
+	if len(local.sent) != 2 {
+		t.Errorf("expected local notifications while offline, got %d", len(local.sent))
+	}
+	if len(remote.sent) != 0 {
+		t.Fatalf("expected no remote delivery while offline, got %d", len(remote.sent))
+	}
+	if pending, _ := outbox.Pending(); len(pending) != 2 {
+		t.Fatalf("expected 2 buffered notifications, got %d", len(pending))
+	}
+
+	d.Send(EventQuestion, "flo", "t-003 has a question")
+	if len(remote.sent) != 3 {
+		t.Fatalf("expected buffered plus new remote deliveries, got %d", len(remote.sent))
+	}
+	if remote.sent[0].Message != "t-001 done" || remote.sent[2].Message != "t-003 has a question" {
+		t.Errorf("unexpected delivery order: %+v", remote.sent)
+	}
+	if pending, _ := outbox.Pending(); len(pending) != 0 {
+		t.Errorf("expected outbox drained, got %d", len(pending))
+	}
+}
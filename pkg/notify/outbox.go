@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Outbox buffers notifications for remote notifiers while offline, one JSON
+// object per line.
+type Outbox struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewOutbox creates an outbox backed by the given file.
+func NewOutbox(path string) *Outbox {
+	return &Outbox{path: path}
+}
+
+// DefaultOutboxPath returns ~/.flo/outbox.jsonl.
+func DefaultOutboxPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".flo", "outbox.jsonl"), nil
+}
+
+// Add appends a notification to the outbox.
+func (o *Outbox) Add(n Notification) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(o.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.OpenFile(o.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open outbox: %w", err)
+	}
+	defer file.Close()
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to serialize notification: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	return nil
+}
+
+// Pending returns buffered notifications without removing them.
+func (o *Outbox) Pending() ([]Notification, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.read()
+}
+
+// Drain removes and returns all buffered notifications.
+func (o *Outbox) Drain() ([]Notification, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	pending, err := o.read()
+	if err != nil || len(pending) == 0 {
+		return pending, err
+	}
+	if err := os.Remove(o.path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to clear outbox: %w", err)
+	}
+	return pending, nil
+}
+
+// read parses the outbox file (must be called with lock held).
+func (o *Outbox) read() ([]Notification, error) {
+	file, err := os.Open(o.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open outbox: %w", err)
+	}
+	defer file.Close()
+
+	var pending []Notification
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var n Notification
+		if err := json.Unmarshal(scanner.Bytes(), &n); err != nil {
+			continue // Skip corrupt lines
+		}
+		pending = append(pending, n)
+	}
+	return pending, scanner.Err()
+}
@@ -0,0 +1,60 @@
+// Package offline implements offline mode, in which operations that need the
+// network fail fast with a distinct error instead of partially succeeding.
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/richgo/flo/pkg/audit"
+)
+
+// EnvVar enables offline mode when set to a true value.
+const EnvVar = "FLO_OFFLINE"
+
+// ErrOffline is matched (via errors.Is) by every offline-mode failure.
+var ErrOffline = errors.New("offline mode")
+
+var enabled atomic.Bool
+
+// Error reports an operation refused because offline mode is on.
+type Error struct {
+	Operation string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("offline mode: %s requires network access (drop --offline or unset %s)", e.Operation, EnvVar)
+}
+
+// Is makes errors.Is(err, ErrOffline) true for offline errors.
+func (e *Error) Is(target error) bool {
+	return target == ErrOffline
+}
+
+// Set turns offline mode on or off for this process.
+func Set(on bool) {
+	enabled.Store(on)
+}
+
+// Enabled reports whether offline mode is on, via Set or FLO_OFFLINE.
+func Enabled() bool {
+	if enabled.Load() {
+		return true
+	}
+	on, _ := strconv.ParseBool(os.Getenv(EnvVar))
+	return on
+}
+
+// Check returns an offline error for operation when offline mode is on.
+func Check(operation string) error {
+	if !Enabled() {
+		return nil
+	}
+	audit.Warn("offline.blocked", "Operation blocked in offline mode", map[string]interface{}{
+		"operation": operation,
+	})
+	return &Error{Operation: operation}
+}
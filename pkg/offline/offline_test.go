@@ -0,0 +1,44 @@
+package offline
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	t.Setenv(EnvVar, "")
+	Set(false)
+
+	if err := Check("claude backend"); err != nil {
+		t.Fatalf("expected no error when online, got %v", err)
+	}
+
+	Set(true)
+	defer Set(false)
+
+	err := Check("claude backend")
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected ErrOffline, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "claude backend") {
+		t.Errorf("expected operation in error, got %q", err.Error())
+	}
+
+	wrapped := errors.Join(errors.New("failed to start backend"), err)
+	if !errors.Is(wrapped, ErrOffline) {
+		t.Error("expected wrapped error to match ErrOffline")
+	}
+}
+
+func TestEnabledFromEnv(t *testing.T) {
+	Set(false)
+	t.Setenv(EnvVar, "1")
+	if !Enabled() {
+		t.Error("expected offline mode from environment")
+	}
+	t.Setenv(EnvVar, "false")
+	if Enabled() {
+		t.Error("expected online with FLO_OFFLINE=false")
+	}
+}
@@ -0,0 +1,89 @@
+// Package output encodes command results for scripts. Commands print
+// tables for people by default; --output json or yaml prints the same
+// result with stable field names instead, so flo can be scripted in CI
+// without scraping text.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is how a command prints its result.
+type Format string
+
+// Output formats.
+const (
+	Table Format = "table"
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+)
+
+// Parse returns the format named s; an empty s is Table.
+func Parse(s string) (Format, error) {
+	switch f := Format(s); f {
+	case "":
+		return Table, nil
+	case Table, JSON, YAML:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format '%s' (table, json or yaml)", s)
+}
+
+// Machine reports whether f is for scripts rather than people.
+func (f Format) Machine() bool {
+	return f == JSON || f == YAML
+}
+
+// Write encodes v to w as JSON or YAML. YAML has the same field names, in
+// the same order, as JSON: those of v's json tags.
+func Write(w io.Writer, f Format, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	switch f {
+	case JSON:
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case YAML:
+		data, err = jsonToYAML(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	return fmt.Errorf("cannot write %s output", f)
+}
+
+// jsonToYAML re-encodes JSON, which is YAML in flow style, in block style.
+func jsonToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+	return yaml.Marshal(&node)
+}
+
+// blockStyle clears the flow and quoting styles from node and its children,
+// leaving the encoder to quote strings only where YAML needs it.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		// Keep strings that would otherwise read as numbers, booleans or null
+		var v interface{}
+		if yaml.Unmarshal([]byte(node.Value), &v) != nil {
+			node.Style = yaml.DoubleQuotedStyle
+		} else if _, ok := v.(string); !ok {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+type result struct {
+	Name    string            `json:"name"`
+	Count   int               `json:"count"`
+	Passed  bool              `json:"passed"`
+	Tags    []string          `json:"tags,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Version string            `json:"version"`
+	Note    string            `json:"note,omitempty"`
+}
+
+func TestParse(t *testing.T) {
+	for s, want := range map[string]Format{"": Table, "table": Table, "json": JSON, "yaml": YAML} {
+		got, err := Parse(s)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", s, got, err, want)
+		}
+	}
+	if _, err := Parse("xml"); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+	if Table.Machine() || !JSON.Machine() || !YAML.Machine() {
+		t.Error("expected only json and yaml to be machine formats")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	v := result{Name: "auth", Count: 2, Passed: true, Version: "1.0"}
+	if err := Write(&buf, JSON, v); err != nil {
+		t.Fatal(err)
+	}
+	var got result
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("got %+v, want %+v", got, v)
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	var buf bytes.Buffer
+	v := result{
+		Name:    "auth",
+		Count:   2,
+		Passed:  true,
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]string{"team": "core"},
+		Version: "1.0",
+		Note:    "true",
+	}
+	if err := Write(&buf, YAML, v); err != nil {
+		t.Fatal(err)
+	}
+	want := `name: auth
+count: 2
+passed: true
+tags:
+    - a
+    - b
+labels:
+    team: core
+version: "1.0"
+note: "true"
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	// Strings that look like other types read back as strings
+	var got map[string]interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["version"] != "1.0" || got["note"] != "true" || got["count"] != 2 {
+		t.Errorf("unexpected round trip: %v", got)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, Table, result{}); err == nil {
+		t.Error("expected table output to be left to the command")
+	}
+}
@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// FileChange is a file a change adds, modifies or deletes, with its line
+// counts (zero for binary files).
+type FileChange struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+}
+
+// Changes returns the uncommitted changes in the git checkout dir,
+// untracked files included.
+func Changes(ctx context.Context, dir string) ([]FileChange, error) {
+	out, err := git(ctx, dir, "diff", "--numstat", "--no-renames", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	changes := ParseNumstat(out)
+	untracked, err := git(ctx, dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range strings.Split(string(untracked), "\n") {
+		if file != "" {
+			changes = append(changes, FileChange{Path: file})
+		}
+	}
+	return changes, nil
+}
+
+// ParseNumstat reads git diff --numstat output.
+func ParseNumstat(out []byte) []FileChange {
+	var changes []FileChange
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files count "-" lines
+		added, _ := strconv.Atoi(fields[0])
+		deleted, _ := strconv.Atoi(fields[1])
+		changes = append(changes, FileChange{Path: fields[2], Added: added, Deleted: deleted})
+	}
+	return changes
+}
+
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,337 @@
+// Package policy evaluates the agent's tool calls and changes against the
+// workspace's rules for risky operations: files it may not touch, shell
+// commands it may not run, and changes a person must approve, such as
+// dependency updates or large deletions.
+//
+// A nil *Policy is valid and allows everything.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Rule names, as violations report them.
+const (
+	RuleDenyPath     = "deny_path"
+	RuleDenyCommand  = "deny_command"
+	RuleDependencies = "dependencies"
+	RuleDeletedLines = "deleted_lines"
+	RuleScope        = "scope"
+)
+
+// Action is what a violation requires.
+type Action string
+
+const (
+	// ActionDeny blocks completion.
+	ActionDeny Action = "deny"
+	// ActionApprove blocks completion until a person approves.
+	ActionApprove Action = "approve"
+)
+
+var (
+	// ErrDenied is returned when the policy denies an operation.
+	ErrDenied = errors.New("denied by policy")
+	// ErrApprovalRequired is returned when an operation awaits approval.
+	ErrApprovalRequired = errors.New("policy requires approval")
+)
+
+// DependencyFiles are the manifests and lock files whose changes are
+// dependency changes.
+var DependencyFiles = []string{
+	"go.mod", "go.sum",
+	"package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml",
+	"requirements.txt", "Pipfile", "Pipfile.lock", "pyproject.toml", "poetry.lock",
+	"Cargo.toml", "Cargo.lock",
+	"Gemfile", "Gemfile.lock",
+	"pom.xml", "build.gradle", "build.gradle.kts",
+	"Podfile", "Podfile.lock", "Package.swift", "Package.resolved",
+	"MODULE.bazel",
+}
+
+// Config configures the policy, under policy in .flo/config.yaml.
+type Config struct {
+	// DenyPaths are globs of files agents may not touch, such as
+	// .github/workflows/** or *.pem. A glob without a slash matches the
+	// file's name in any directory; ** matches any number of directories.
+	DenyPaths []string `yaml:"deny_paths,omitempty"`
+	// DenyCommands are regular expressions of shell commands agents may
+	// not run, such as 'rm -rf /' or 'curl .*\| *sh'.
+	DenyCommands []string `yaml:"deny_commands,omitempty"`
+	// RequireApproval lists changes a person must approve before the
+	// task can complete.
+	RequireApproval ApprovalRules `yaml:"require_approval,omitempty"`
+}
+
+// ApprovalRules are changes that need approval.
+type ApprovalRules struct {
+	// Dependencies requires approval for changes to dependency manifests
+	// and lock files.
+	Dependencies bool `yaml:"dependencies,omitempty"`
+	// DeletedLines requires approval when a change deletes more than this
+	// many lines in total (0 never does).
+	DeletedLines int `yaml:"deleted_lines,omitempty"`
+}
+
+// Empty reports whether the config has no rules.
+func (c Config) Empty() bool {
+	return len(c.DenyPaths) == 0 && len(c.DenyCommands) == 0 &&
+		!c.RequireApproval.Dependencies && c.RequireApproval.DeletedLines == 0
+}
+
+// Validate reports invalid globs and regular expressions.
+func (c Config) Validate() error {
+	_, err := New(c)
+	return err
+}
+
+// Violation is an operation a rule denies or requires approval for.
+type Violation struct {
+	Rule   string `json:"rule"`
+	Action Action `json:"action"`
+	// Target is the file, command or change that broke the rule.
+	Target string `json:"target"`
+	// Reason explains the violation.
+	Reason string `json:"reason"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Target, v.Reason)
+}
+
+// Violations are the violations of a set of operations.
+type Violations []Violation
+
+// Denied returns the violations that block completion.
+func (vs Violations) Denied() Violations {
+	return vs.with(ActionDeny)
+}
+
+// NeedApproval returns the violations that need approval.
+func (vs Violations) NeedApproval() Violations {
+	return vs.with(ActionApprove)
+}
+
+func (vs Violations) with(action Action) Violations {
+	var out Violations
+	for _, v := range vs {
+		if v.Action == action {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Summary lists the violations one per line.
+func (vs Violations) Summary() string {
+	lines := make([]string, len(vs))
+	for i, v := range vs {
+		lines[i] = v.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Err returns ErrDenied when any violation is denied, otherwise
+// ErrApprovalRequired when any needs approval, listing them; nil when
+// there are none.
+func (vs Violations) Err() error {
+	if denied := vs.Denied(); len(denied) > 0 {
+		return fmt.Errorf("%w:\n%s", ErrDenied, denied.Summary())
+	}
+	if pending := vs.NeedApproval(); len(pending) > 0 {
+		return fmt.Errorf("%w:\n%s", ErrApprovalRequired, pending.Summary())
+	}
+	return nil
+}
+
+// Policy evaluates operations against a config.
+type Policy struct {
+	denyPaths    []pathRule
+	denyCommands []*regexp.Regexp
+	approval     ApprovalRules
+}
+
+type pathRule struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+// matches reports whether the slash-separated file matches the rule. A
+// glob without a slash also matches the file's name.
+func (r pathRule) matches(file string) bool {
+	return r.re.MatchString(file) || (!strings.Contains(r.glob, "/") && r.re.MatchString(path.Base(file)))
+}
+
+// New compiles a config, returning nil when it has no rules.
+func New(config Config) (*Policy, error) {
+	if config.Empty() {
+		return nil, nil
+	}
+	if config.RequireApproval.DeletedLines < 0 {
+		return nil, fmt.Errorf("require_approval.deleted_lines must not be negative")
+	}
+	p := &Policy{approval: config.RequireApproval}
+	for _, glob := range config.DenyPaths {
+		re, err := compileGlob(glob)
+		if err != nil {
+			return nil, fmt.Errorf("deny_paths: invalid glob '%s': %w", glob, err)
+		}
+		p.denyPaths = append(p.denyPaths, pathRule{glob: glob, re: re})
+	}
+	for _, expr := range config.DenyCommands {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("deny_commands: invalid pattern '%s': %w", expr, err)
+		}
+		p.denyCommands = append(p.denyCommands, re)
+	}
+	return p, nil
+}
+
+// CheckPath checks a file the agent touches, relative to the worktree.
+func (p *Policy) CheckPath(file string) Violations {
+	if p == nil {
+		return nil
+	}
+	file = path.Clean(filepath.ToSlash(file))
+	var vs Violations
+	for _, rule := range p.denyPaths {
+		if rule.matches(file) {
+			vs = append(vs, Violation{
+				Rule:   RuleDenyPath,
+				Action: ActionDeny,
+				Target: file,
+				Reason: fmt.Sprintf("matches denied path %s", rule.glob),
+			})
+			break
+		}
+	}
+	return vs
+}
+
+// CheckCommand checks a shell command the agent runs.
+func (p *Policy) CheckCommand(command string) Violations {
+	if p == nil {
+		return nil
+	}
+	for _, re := range p.denyCommands {
+		if re.MatchString(command) {
+			return Violations{{
+				Rule:   RuleDenyCommand,
+				Action: ActionDeny,
+				Target: command,
+				Reason: fmt.Sprintf("matches denied command %s", re),
+			}}
+		}
+	}
+	return nil
+}
+
+// CheckToolCall checks the input of a tool call from the agent: the
+// command of a shell tool and the file of a file tool, relative to root
+// when inside it.
+func (p *Policy) CheckToolCall(root string, input json.RawMessage) Violations {
+	if p == nil || len(input) == 0 {
+		return nil
+	}
+	var args map[string]any
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil
+	}
+	var vs Violations
+	for _, key := range []string{"command", "cmd"} {
+		if command, ok := args[key].(string); ok {
+			vs = append(vs, p.CheckCommand(command)...)
+		}
+	}
+	for _, key := range []string{"file_path", "path", "notebook_path"} {
+		file, ok := args[key].(string)
+		if !ok || file == "" {
+			continue
+		}
+		if filepath.IsAbs(file) && root != "" {
+			if rel, err := filepath.Rel(root, file); err == nil && filepath.IsLocal(rel) {
+				file = rel
+			}
+		}
+		vs = append(vs, p.CheckPath(file)...)
+	}
+	return vs
+}
+
+// CheckChanges checks the files a change adds, modifies or deletes.
+func (p *Policy) CheckChanges(changes []FileChange) Violations {
+	if p == nil {
+		return nil
+	}
+	var vs Violations
+	deleted := 0
+	for _, c := range changes {
+		vs = append(vs, p.CheckPath(c.Path)...)
+		deleted += c.Deleted
+		if p.approval.Dependencies && isDependencyFile(c.Path) {
+			vs = append(vs, Violation{
+				Rule:   RuleDependencies,
+				Action: ActionApprove,
+				Target: c.Path,
+				Reason: "changes dependencies",
+			})
+		}
+	}
+	if limit := p.approval.DeletedLines; limit > 0 && deleted > limit {
+		vs = append(vs, Violation{
+			Rule:   RuleDeletedLines,
+			Action: ActionApprove,
+			Target: fmt.Sprintf("%d files", len(changes)),
+			Reason: fmt.Sprintf("deletes %d lines, more than %d", deleted, limit),
+		})
+	}
+	return vs
+}
+
+func isDependencyFile(file string) bool {
+	base := path.Base(filepath.ToSlash(file))
+	for _, name := range DependencyFiles {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// compileGlob turns a glob into a regular expression matching whole
+// slash-separated paths. A trailing slash matches everything below.
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	if glob == "" {
+		return nil, fmt.Errorf("empty glob")
+	}
+	if strings.HasSuffix(glob, "/") {
+		glob += "**"
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,60 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+)
+
+// Scope is the files a task may change, as globs in the syntax DenyPaths
+// takes. A nil *Scope allows every file.
+type Scope struct {
+	rules []pathRule
+}
+
+// NewScope compiles a task's scope globs, returning nil when there are
+// none.
+func NewScope(globs []string) (*Scope, error) {
+	if len(globs) == 0 {
+		return nil, nil
+	}
+	s := &Scope{}
+	for _, glob := range globs {
+		re, err := compileGlob(glob)
+		if err != nil {
+			return nil, fmt.Errorf("scope: invalid glob '%s': %w", glob, err)
+		}
+		s.rules = append(s.rules, pathRule{glob: glob, re: re})
+	}
+	return s, nil
+}
+
+// Contains reports whether file, relative to the worktree, is in scope.
+func (s *Scope) Contains(file string) bool {
+	if s == nil {
+		return true
+	}
+	file = path.Clean(filepath.ToSlash(file))
+	for _, rule := range s.rules {
+		if rule.matches(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check denies the files of a change that are out of scope.
+func (s *Scope) Check(changes []FileChange) Violations {
+	var vs Violations
+	for _, c := range changes {
+		if !s.Contains(c.Path) {
+			vs = append(vs, Violation{
+				Rule:   RuleScope,
+				Action: ActionDeny,
+				Target: c.Path,
+				Reason: "outside the task's scope",
+			})
+		}
+	}
+	return vs
+}
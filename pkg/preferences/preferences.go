@@ -0,0 +1,133 @@
+// Package preferences manages per-user settings stored outside the workspace.
+package preferences
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the preferences file within the user's ~/.flo directory.
+const FileName = "preferences.yaml"
+
+// Preferences holds per-user settings.
+type Preferences struct {
+	Notifications Notifications `yaml:"notifications"`
+}
+
+// Notifications controls desktop notifications for local runs.
+type Notifications struct {
+	Enabled     bool `yaml:"enabled"`
+	RunComplete bool `yaml:"run_complete"`
+	Approval    bool `yaml:"approval"`
+	Question    bool `yaml:"question"`
+}
+
+// Default returns preferences with all notifications enabled.
+func Default() *Preferences {
+	return &Preferences{
+		Notifications: Notifications{
+			Enabled:     true,
+			RunComplete: true,
+			Approval:    true,
+			Question:    true,
+		},
+	}
+}
+
+// DefaultPath returns ~/.flo/preferences.yaml.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".flo", FileName), nil
+}
+
+// Load reads preferences from path. Missing files and keys keep their defaults.
+func Load(path string) (*Preferences, error) {
+	p := Default()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return nil, fmt.Errorf("failed to read preferences: %w", err)
+	}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse preferences: %w", err)
+	}
+	return p, nil
+}
+
+// LoadDefault reads preferences from the default path, falling back to
+// defaults when the home directory is unavailable.
+func LoadDefault() (*Preferences, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return Default(), nil
+	}
+	return Load(path)
+}
+
+// Save writes preferences to path.
+func (p *Preferences) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to serialize preferences: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write preferences: %w", err)
+	}
+	return nil
+}
+
+// toggles maps dotted keys to boolean settings.
+func (p *Preferences) toggles() map[string]*bool {
+	return map[string]*bool{
+		"notifications.enabled":      &p.Notifications.Enabled,
+		"notifications.run_complete": &p.Notifications.RunComplete,
+		"notifications.approval":     &p.Notifications.Approval,
+		"notifications.question":     &p.Notifications.Question,
+	}
+}
+
+// Keys returns the settable preference keys in sorted order.
+func (p *Preferences) Keys() []string {
+	var keys []string
+	for k := range p.toggles() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Get returns the value of a dotted key as a string.
+func (p *Preferences) Get(key string) (string, error) {
+	v, ok := p.toggles()[key]
+	if !ok {
+		return "", fmt.Errorf("unknown preference '%s'", key)
+	}
+	return strconv.FormatBool(*v), nil
+}
+
+// Set updates a dotted key from a string value.
+func (p *Preferences) Set(key, value string) error {
+	v, ok := p.toggles()[key]
+	if !ok {
+		return fmt.Errorf("unknown preference '%s'", key)
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %q (expected true or false)", key, value)
+	}
+	*v = b
+	return nil
+}
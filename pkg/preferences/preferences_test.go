@@ -0,0 +1,59 @@
+package preferences
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMissingUsesDefaults(t *testing.T) {
+	p, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !p.Notifications.Enabled || !p.Notifications.Question {
+		t.Errorf("expected notifications enabled by default: %+v", p.Notifications)
+	}
+}
+
+func TestLoadPartialKeepsDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	os.WriteFile(path, []byte("notifications:\n  question: false\n"), 0644)
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if p.Notifications.Question {
+		t.Error("expected question notifications disabled")
+	}
+	if !p.Notifications.Enabled || !p.Notifications.RunComplete {
+		t.Error("expected unspecified keys to keep defaults")
+	}
+}
+
+func TestSetGetSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+	p := Default()
+
+	if err := p.Set("notifications.enabled", "false"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set("notifications.enabled", "maybe"); err == nil {
+		t.Error("expected error for non-boolean value")
+	}
+	if err := p.Set("theme", "dark"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+	if err := p.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, _ := Load(path)
+	if v, _ := loaded.Get("notifications.enabled"); v != "false" {
+		t.Errorf("expected persisted false, got %s", v)
+	}
+	if len(loaded.Keys()) != 4 {
+		t.Errorf("expected 4 keys, got %v", loaded.Keys())
+	}
+}
@@ -0,0 +1,273 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// DefaultContextTokens is the token budget used when none is configured.
+const DefaultContextTokens = 8000
+
+// maxFileSize skips files too large to be useful in a prompt.
+const maxFileSize = 256 * 1024
+
+// defaultExclude lists paths never packed into the prompt.
+var defaultExclude = []string{
+	".git/**", ".flo/**", ".eas/**", "vendor/**", "node_modules/**",
+	"go.sum", "*.lock", "package-lock.json", "*.min.js",
+}
+
+// stopwords are ignored when extracting keywords from a task.
+var stopwords = map[string]bool{
+	"add": true, "the": true, "and": true, "for": true, "with": true,
+	"from": true, "into": true, "that": true, "this": true, "when": true,
+	"should": true, "make": true, "use": true, "new": true, "update": true,
+	"implement": true, "support": true, "fix": true,
+}
+
+// File is a repository file selected for the prompt.
+type File struct {
+	Path    string
+	Content string
+	Reason  string
+	Score   int
+	Tokens  int
+}
+
+// ContextBuilder selects relevant repository files for a task within a
+// token budget.
+type ContextBuilder struct {
+	root   string
+	config config.ContextConfig
+
+	// listFiles and changedFiles are overridable for testing.
+	listFiles    func(root string) ([]string, error)
+	changedFiles func(root string) []string
+}
+
+// NewContextBuilder creates a builder over the repository at root.
+func NewContextBuilder(root string, cfg config.ContextConfig) *ContextBuilder {
+	return &ContextBuilder{
+		root:         root,
+		config:       cfg,
+		listFiles:    listRepoFiles,
+		changedFiles: gitChangedFiles,
+	}
+}
+
+// Budget returns the token budget for packed files.
+func (b *ContextBuilder) Budget() int {
+	if b.config.MaxTokens > 0 {
+		return b.config.MaxTokens
+	}
+	return DefaultContextTokens
+}
+
+// Build ranks repository files by relevance to the task and returns the
+// highest scoring ones that fit in the budget.
+func (b *ContextBuilder) Build(t *task.Task) ([]File, error) {
+	if b.config.Disable {
+		return nil, nil
+	}
+
+	paths, err := b.listFiles(b.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository files: %w", err)
+	}
+
+	changed := make(map[string]bool)
+	for _, p := range b.changedFiles(b.root) {
+		changed[p] = true
+	}
+	keywords := Keywords(t)
+	exclude := append(append([]string{}, defaultExclude...), b.config.Exclude...)
+
+	var candidates []File
+	for _, p := range paths {
+		if matchAny(exclude, p) {
+			continue
+		}
+		score, reason := 0, ""
+		if changed[p] {
+			score, reason = score+100, "changed"
+		}
+		if matchAny(b.config.Include, p) {
+			score += 50
+			if reason == "" {
+				reason = "included"
+			}
+		}
+		if hits := pathHits(p, keywords); len(hits) > 0 {
+			score += 10 * len(hits)
+			if reason == "" {
+				reason = "matches " + strings.Join(hits, ", ")
+			}
+		}
+		if score > 0 {
+			candidates = append(candidates, File{Path: p, Score: score, Reason: reason})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Path < candidates[j].Path
+	})
+
+	remaining := b.Budget()
+	var selected []File
+	for _, f := range candidates {
+		content, ok := b.readText(f.Path)
+		if !ok {
+			continue
+		}
+		tokens := EstimateTokens(content)
+		if tokens > remaining {
+			continue
+		}
+		f.Content = strings.TrimRight(content, "\n")
+		f.Tokens = tokens
+		remaining -= tokens
+		selected = append(selected, f)
+	}
+	return selected, nil
+}
+
+// readText reads a file, rejecting large and binary files.
+func (b *ContextBuilder) readText(rel string) (string, bool) {
+	path := filepath.Join(b.root, rel)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() > maxFileSize {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data, 0) >= 0 {
+		return "", false
+	}
+	return string(data), true
+}
+
+// EstimateTokens approximates the token count of s (about 4 bytes per token).
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// Keywords extracts lowercase search terms from a task's title, description
+// and type.
+func Keywords(t *task.Task) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, word := range splitWords(t.Title + " " + t.Description + " " + t.Type) {
+		if len(word) < 3 || stopwords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+	return keywords
+}
+
+// pathHits returns the keywords that appear as (prefixes of) path segments.
+func pathHits(path string, keywords []string) []string {
+	tokens := splitWords(path)
+	var hits []string
+	for _, kw := range keywords {
+		for _, tok := range tokens {
+			if tok == kw || (len(kw) >= 4 && strings.HasPrefix(tok, kw)) {
+				hits = append(hits, kw)
+				break
+			}
+		}
+	}
+	return hits
+}
+
+// splitWords lowercases s and splits it on non-alphanumeric characters.
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchAny reports whether path matches any glob pattern. A pattern matches
+// the full path or the base name; "dir/**" matches everything under dir.
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// listRepoFiles lists tracked and untracked (non-ignored) files, falling back
+// to walking the directory outside a git repository.
+func listRepoFiles(root string) ([]string, error) {
+	cmd := exec.Command("git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = root
+	if out, err := cmd.Output(); err == nil {
+		return splitNUL(out), nil
+	}
+
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err == nil {
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// gitChangedFiles lists files with uncommitted changes.
+func gitChangedFiles(root string) []string {
+	cmd := exec.Command("git", "diff", "-z", "--name-only", "--relative", "HEAD")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return splitNUL(out)
+}
+
+// splitNUL splits the -z output of a git command into its paths, which
+// are neither quoted nor split at spaces.
+func splitNUL(out []byte) []string {
+	var paths []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
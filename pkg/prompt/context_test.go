@@ -0,0 +1,153 @@
+package prompt
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// writeRepo creates files under a temp dir and returns the root.
+func writeRepo(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		os.MkdirAll(filepath.Dir(full), 0755)
+		os.WriteFile(full, []byte(content), 0644)
+	}
+	return root
+}
+
+func TestKeywords(t *testing.T) {
+	tk := task.New("t-001", "Add OAuth login for the API")
+	tk.Type = "feature"
+
+	got := strings.Join(Keywords(tk), ",")
+	if got != "oauth,login,api,feature" {
+		t.Errorf("unexpected keywords: %s", got)
+	}
+}
+
+func TestContextBuilderRanking(t *testing.T) {
+	root := writeRepo(t, map[string]string{
+		"pkg/auth/login.go":       "package auth\n",
+		"pkg/auth/oauth_login.go": "package auth\n",
+		"pkg/billing/invoice.go":  "package billing\n",
+		"docs/ARCHITECTURE.md":    "# Architecture\n",
+		"pkg/db/migrate.go":       "package db\n",
+		".flo/SPEC.md":            "login login login\n",
+	})
+
+	b := NewContextBuilder(root, config.ContextConfig{Include: []string{"docs/**"}})
+	b.changedFiles = func(string) []string { return []string{"pkg/db/migrate.go"} }
+
+	files, err := b.Build(task.New("t-001", "Add OAuth login"))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	want := "pkg/db/migrate.go,docs/ARCHITECTURE.md,pkg/auth/oauth_login.go,pkg/auth/login.go"
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("unexpected ranking:\n got %s\nwant %s", got, want)
+	}
+	if files[0].Reason != "changed" {
+		t.Errorf("expected changed reason, got %q", files[0].Reason)
+	}
+}
+
+func TestContextBuilderBudget(t *testing.T) {
+	root := writeRepo(t, map[string]string{
+		"login_big.go":   strings.Repeat("x", 400),
+		"login_small.go": strings.Repeat("y", 40),
+		"login.bin":      "bin\x00ary",
+	})
+
+	b := NewContextBuilder(root, config.ContextConfig{MaxTokens: 50})
+	b.changedFiles = func(string) []string { return nil }
+
+	files, err := b.Build(task.New("t-001", "Fix login"))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "login_small.go" {
+		t.Fatalf("expected only the file that fits the budget, got %+v", files)
+	}
+	if files[0].Tokens != 10 {
+		t.Errorf("expected 10 tokens, got %d", files[0].Tokens)
+	}
+}
+
+func TestContextBuilderDisabledAndExcluded(t *testing.T) {
+	root := writeRepo(t, map[string]string{
+		"login.go":      "package main\n",
+		"login_test.go": "package main\n",
+	})
+
+	b := NewContextBuilder(root, config.ContextConfig{Disable: true})
+	if files, _ := b.Build(task.New("t-001", "login")); len(files) != 0 {
+		t.Errorf("expected no files when disabled, got %d", len(files))
+	}
+
+	b = NewContextBuilder(root, config.ContextConfig{Exclude: []string{"*_test.go"}})
+	b.changedFiles = func(string) []string { return nil }
+	files, _ := b.Build(task.New("t-001", "login"))
+	if len(files) != 1 || files[0].Path != "login.go" {
+		t.Errorf("expected excluded test file to be skipped, got %+v", files)
+	}
+}
+
+func TestRenderIncludesFiles(t *testing.T) {
+	data := testData()
+	data.Files = []File{{Path: "pkg/auth/login.go", Reason: "changed", Content: "package auth"}}
+
+	out, err := NewLibrary(t.TempDir()).Render(DefaultName, data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(out, "### pkg/auth/login.go (changed)\n````\npackage auth\n````") {
+		t.Errorf("expected packed file in prompt:\n%s", out)
+	}
+}
+
+func TestGitFilesWithSpaces(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := writeRepo(t, map[string]string{
+		"main.go":          "package main\n",
+		"docs/my notes.md": "notes\n",
+		"café/menu.go":     "package menu\n",
+	})
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-qm", "init")
+
+	files, err := listRepoFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"café/menu.go", "docs/my notes.md", "main.go"}
+	if strings.Join(files, "|") != strings.Join(want, "|") {
+		t.Errorf("listRepoFiles = %q, want %q", files, want)
+	}
+
+	os.WriteFile(filepath.Join(root, "docs", "my notes.md"), []byte("changed\n"), 0644)
+	if got := gitChangedFiles(root); len(got) != 1 || got[0] != "docs/my notes.md" {
+		t.Errorf("gitChangedFiles = %q, want the changed file with its space", got)
+	}
+}
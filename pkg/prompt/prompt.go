@@ -0,0 +1,265 @@
+// Package prompt renders agent prompts from Go templates.
+//
+// Templates live in .flo/prompts/<name>.tmpl and are selected per task type.
+// When no workspace template exists, the built-in default is used.
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// DefaultName is the name of the fallback template.
+const DefaultName = "default"
+
+// Ext is the file extension for prompt templates.
+const Ext = ".tmpl"
+
+// fence delimits file contents in the default template.
+const fence = "````"
+
+// DefaultTemplate is the built-in prompt used when no template file exists.
+const DefaultTemplate = `You are working on task {{.Task.ID}} in a TDD workflow.
+
+## Task
+Title: {{.Task.Title}}
+{{with .Task.Description}}{{.}}
+{{end}}
+## Feature Specification
+{{.Spec}}
+{{- with .Repo.Branch}}
+
+## Repository
+Branch: {{.}}
+{{- end}}
+{{- if .TDD.Enforce}}
+
+## TDD Rules
+- Write failing tests before implementation
+- All tests must pass before completion{{with .TDD.TestCommand}} (` + "`{{.}}`" + `){{end}}
+{{- if .TDD.CoverageThreshold}}
+- Coverage must be at least {{.TDD.CoverageThreshold}}%
+{{- end}}
+{{- end}}
+{{- with .Feedback}}
+
+## Review Feedback
+A reviewer sent your previous changes for this task back; they are still in
+the worktree. Address this feedback:
+
+{{.}}
+{{- end}}
+{{- with .Previous}}
+
+## Previous Run
+You worked on this task before; your changes are still in the worktree.
+The end of that run:
+` + fence + `
+{{.}}
+` + fence + `
+{{- end}}
+{{- with .FollowUp}}
+
+## Follow-up Instructions
+{{.}}
+{{- end}}
+{{- with .Files}}
+
+## Relevant Files
+{{- range .}}
+
+### {{.Path}} ({{.Reason}})
+` + fence + `
+{{.Content}}
+` + fence + `
+{{- end}}
+{{- end}}
+
+## Instructions
+1. Implement the required changes for this task
+2. Run tests using eas_run_tests to verify your implementation
+{{- if .Review}}
+3. When tests pass, stop and leave your changes uncommitted: a reviewer
+   approves them before the task completes
+{{- else}}
+3. When tests pass, call eas_task_complete to finish the task
+{{- end}}
+
+Available tools:
+- eas_task_get: Get task details
+- eas_run_tests: Run tests for the task
+{{- if not .Review}}
+- eas_task_complete: Mark task complete (requires tests to pass)
+{{- end}}
+- eas_spec_read: Read the feature specification
+
+Begin implementing the task.`
+
+// Repo describes the repository the agent works in.
+type Repo struct {
+	Root   string
+	Branch string
+}
+
+// Data is the value templates are executed against.
+type Data struct {
+	Feature  string
+	Task     *task.Task
+	Spec     string
+	Sections map[string]string
+	Repo     Repo
+	TDD      config.TDDConfig
+	// Review is set when a reviewer must approve the task's changes
+	// before it completes.
+	Review bool
+	// Feedback is a reviewer's feedback on the task's previous changes,
+	// which are still in the worktree, when they were sent back.
+	Feedback string
+	// Previous is the end of the task's latest run transcript, and
+	// FollowUp further instructions, when continuing earlier work.
+	Previous string
+	FollowUp string
+	// Files are repository files selected by the context builder.
+	Files []File
+}
+
+// Section returns the body of a spec section by heading (case-insensitive),
+// e.g. {{.Section "Goal"}}.
+func (d *Data) Section(name string) string {
+	for heading, body := range d.Sections {
+		if strings.EqualFold(heading, name) {
+			return body
+		}
+	}
+	return ""
+}
+
+// ParseSections splits a markdown document into its "## " sections.
+func ParseSections(content string) map[string]string {
+	sections := make(map[string]string)
+	var heading string
+	var body []string
+
+	flush := func() {
+		if heading != "" {
+			sections[heading] = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			flush()
+			heading = strings.TrimSpace(strings.TrimPrefix(line, "## "))
+			body = nil
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections
+}
+
+// RepoContext gathers repository details for the prompt. Missing git
+// information is left empty.
+func RepoContext(root string) Repo {
+	repo := Repo{Root: root}
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = root
+	if out, err := cmd.Output(); err == nil {
+		repo.Branch = strings.TrimSpace(string(out))
+	}
+	return repo
+}
+
+// Library loads prompt templates from a directory.
+type Library struct {
+	dir string
+}
+
+// NewLibrary creates a library reading templates from dir.
+func NewLibrary(dir string) *Library {
+	return &Library{dir: dir}
+}
+
+// Dir returns the template directory.
+func (l *Library) Dir() string {
+	return l.dir
+}
+
+// Path returns the file path for a named template.
+func (l *Library) Path(name string) string {
+	return filepath.Join(l.dir, name+Ext)
+}
+
+// Resolve picks the template name for a task: the task type's configured
+// prompt, then a template named after the task type, then the default.
+func (l *Library) Resolve(cfg *config.Config, taskType string) string {
+	if taskType != "" {
+		if tt, ok := cfg.TaskTypes[taskType]; ok && tt.Prompt != "" {
+			return tt.Prompt
+		}
+		if _, err := os.Stat(l.Path(taskType)); err == nil {
+			return taskType
+		}
+	}
+	return DefaultName
+}
+
+// Load parses a named template. The default template falls back to the
+// built-in prompt when no file exists; other names must exist.
+func (l *Library) Load(name string) (*template.Template, error) {
+	data, err := os.ReadFile(l.Path(name))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		if name != DefaultName {
+			return nil, fmt.Errorf("prompt template '%s' not found in %s", name, l.dir)
+		}
+		data = []byte(DefaultTemplate)
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template '%s': %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// Render executes a named template against data.
+func (l *Library) Render(name string, data *Data) (string, error) {
+	tmpl, err := l.Load(name)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template '%s': %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// Eject writes the built-in default template to the library so it can be
+// customized. It refuses to overwrite an existing file.
+func (l *Library) Eject() (string, error) {
+	path := l.Path(DefaultName)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create prompts directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(DefaultTemplate+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write prompt template: %w", err)
+	}
+	return path, nil
+}
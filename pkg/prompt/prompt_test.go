@@ -0,0 +1,182 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/task"
+)
+
+func testData() *Data {
+	spec := "# Feature: auth\n\n## Goal\n\nLet users log in.\n\n## Context\n\nOAuth only.\n"
+	tk := task.New("t-001", "Add login")
+	tk.Description = "Implement the login endpoint"
+	return &Data{
+		Feature:  "auth",
+		Task:     tk,
+		Spec:     spec,
+		Sections: ParseSections(spec),
+		Repo:     Repo{Root: "/repo", Branch: "feature/auth"},
+		TDD:      config.TDDConfig{Enforce: true, TestCommand: "go test ./...", CoverageThreshold: 80},
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	sections := ParseSections(testData().Spec)
+	if sections["Goal"] != "Let users log in." {
+		t.Errorf("unexpected Goal section: %q", sections["Goal"])
+	}
+	if sections["Context"] != "OAuth only." {
+		t.Errorf("unexpected Context section: %q", sections["Context"])
+	}
+	if (&Data{Sections: sections}).Section("goal") != "Let users log in." {
+		t.Error("expected case-insensitive section lookup")
+	}
+}
+
+func TestRenderDefault(t *testing.T) {
+	lib := NewLibrary(t.TempDir())
+
+	out, err := lib.Render(DefaultName, testData())
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for _, want := range []string{
+		"task t-001",
+		"Title: Add login",
+		"Implement the login endpoint",
+		"Let users log in.",
+		"Branch: feature/auth",
+		"`go test ./...`",
+		"at least 80%",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected prompt to contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderDefaultWithoutTDD(t *testing.T) {
+	data := testData()
+	data.TDD = config.TDDConfig{}
+
+	out, err := NewLibrary(t.TempDir()).Render(DefaultName, data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if strings.Contains(out, "TDD Rules") {
+		t.Errorf("expected no TDD rules when not enforced:\n%s", out)
+	}
+}
+
+func TestRenderDefaultWithReview(t *testing.T) {
+	data := testData()
+	data.Review = true
+
+	out, err := NewLibrary(t.TempDir()).Render(DefaultName, data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if strings.Contains(out, "eas_task_complete") {
+		t.Errorf("expected no eas_task_complete when review is required:\n%s", out)
+	}
+	if !strings.Contains(out, "leave your changes uncommitted") {
+		t.Errorf("expected review instructions:\n%s", out)
+	}
+	if strings.Contains(out, "Review Feedback") {
+		t.Errorf("expected no feedback section before a review:\n%s", out)
+	}
+
+	data.Feedback = "Keep the old column"
+	out, err = NewLibrary(t.TempDir()).Render(DefaultName, data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(out, "## Review Feedback") || !strings.Contains(out, "Keep the old column") {
+		t.Errorf("expected the reviewer's feedback:\n%s", out)
+	}
+}
+
+func TestResolveAndRenderCustom(t *testing.T) {
+	dir := t.TempDir()
+	lib := NewLibrary(dir)
+	os.WriteFile(filepath.Join(dir, "bugfix.tmpl"), []byte(`Fix {{.Task.ID}}: {{.Section "Goal"}}`), 0644)
+	os.WriteFile(filepath.Join(dir, "terse.tmpl"), []byte(`Do {{.Task.Title}}`), 0644)
+
+	cfg := config.New("auth")
+	cfg.TaskTypes["docs"] = config.TaskType{Model: "claude/haiku", Prompt: "terse"}
+
+	if name := lib.Resolve(cfg, "docs"); name != "terse" {
+		t.Errorf("expected configured prompt 'terse', got %q", name)
+	}
+	if name := lib.Resolve(cfg, "bugfix"); name != "bugfix" {
+		t.Errorf("expected template named after task type, got %q", name)
+	}
+	if name := lib.Resolve(cfg, "research"); name != DefaultName {
+		t.Errorf("expected default, got %q", name)
+	}
+
+	out, err := lib.Render("bugfix", testData())
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if out != "Fix t-001: Let users log in." {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	lib := NewLibrary(dir)
+
+	if _, err := lib.Load("missing"); err == nil {
+		t.Error("expected error for missing named template")
+	}
+
+	os.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte(`{{.Task.ID`), 0644)
+	if _, err := lib.Load("broken"); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestEject(t *testing.T) {
+	lib := NewLibrary(filepath.Join(t.TempDir(), "prompts"))
+
+	path, err := lib.Eject()
+	if err != nil {
+		t.Fatalf("Eject failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "{{.Task.ID}}") {
+		t.Error("expected ejected file to contain the default template")
+	}
+	if _, err := lib.Eject(); err == nil {
+		t.Error("expected error when template already exists")
+	}
+}
+
+func TestRenderDefaultWithFollowUp(t *testing.T) {
+	data := testData()
+	out, err := NewLibrary(t.TempDir()).Render(DefaultName, data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if strings.Contains(out, "## Previous Run") || strings.Contains(out, "## Follow-up Instructions") {
+		t.Errorf("expected no follow-up sections on a first run:\n%s", out)
+	}
+
+	data.Previous = "Added the handler"
+	data.FollowUp = "Also handle expired tokens"
+	out, err = NewLibrary(t.TempDir()).Render(DefaultName, data)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for _, want := range []string{"## Previous Run", "Added the handler", "## Follow-up Instructions\nAlso handle expired tokens"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected render to contain %q:\n%s", want, out)
+		}
+	}
+}
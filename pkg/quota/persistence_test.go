@@ -0,0 +1,109 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestTrackerPreservesUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	now := time.Now().UTC().Format(time.RFC3339)
+	os.WriteFile(path, []byte(fmt.Sprintf(`{
+		"claude": {"backend": "claude", "requests": 2, "tokens": 10, "cost_usd": 0.42,
+			"last_request": %q, "window_start": %q, "is_exhausted": false},
+		"gemini": {"backend": "gemini", "requests": 1, "window_start": %q}
+	}`, now, now, now)), 0644)
+
+	tracker := New(path)
+	if err := tracker.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := tracker.Record("claude", 5); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	var saved map[string]map[string]interface{}
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("invalid quota file: %v", err)
+	}
+	if saved["claude"]["cost_usd"] != 0.42 {
+		t.Errorf("expected 'cost_usd' preserved, got %v", saved["claude"]["cost_usd"])
+	}
+	if saved["claude"]["tokens"] != float64(15) {
+		t.Errorf("expected updated tokens, got %v", saved["claude"]["tokens"])
+	}
+	if saved["gemini"] == nil {
+		t.Error("expected untouched backend preserved")
+	}
+}
+
+func TestTrackerSaveLoadProperty(t *testing.T) {
+	dir := t.TempDir()
+	backends := []string{"claude", "copilot", "codex", "gemini"}
+
+	property := func(ops []uint16) bool {
+		path := filepath.Join(dir, "quota.json")
+		os.Remove(path)
+
+		tracker := New(path)
+		for _, op := range ops {
+			backend := backends[int(op)%len(backends)]
+			if err := tracker.Record(backend, int(op>>2)); err != nil {
+				return false
+			}
+		}
+
+		loaded := New(path)
+		if err := loaded.Load(); err != nil {
+			return false
+		}
+		want, got := tracker.ListUsage(), loaded.ListUsage()
+		if len(want) != len(got) {
+			return false
+		}
+		for backend, w := range want {
+			g, ok := got[backend]
+			if !ok || g.Requests != w.Requests || g.Tokens != w.Tokens || !g.WindowStart.Equal(w.WindowStart) {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzTrackerLoad(f *testing.F) {
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"claude": {"backend": "claude", "requests": 3, "window_start": "2026-02-05T22:00:00Z", "is_exhausted": true, "retry_after": "2026-02-05T23:00:00+01:00"}}`))
+	f.Add([]byte(`{"claude": null}`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "quota.json")
+		os.WriteFile(path, data, 0644)
+
+		tracker := New(path)
+		tracker.SetLimit("claude", 2)
+		if err := tracker.Load(); err != nil {
+			return
+		}
+		for backend := range tracker.ListUsage() {
+			tracker.IsExhausted(backend)
+			tracker.GetUsage(backend)
+		}
+		if err := tracker.Record("claude", 1); err != nil {
+			t.Fatalf("Record after load failed: %v", err)
+		}
+		if err := New(path).Load(); err != nil {
+			t.Fatalf("saved quota file failed to load: %v", err)
+		}
+	})
+}
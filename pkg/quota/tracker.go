@@ -3,22 +3,104 @@ package quota
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/richgo/flo/pkg/clock"
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/jsoncompat"
 )
 
-// Usage tracks usage metrics for a backend.
+// ErrQuotaExhausted is matched by errors from a backend or model out of
+// quota: an *ExhaustedError, or a provider's rate limit error.
+var ErrQuotaExhausted = errors.New("quota exhausted")
+
+// ExhaustedError reports a run refused because the tracker has the backend
+// or model exhausted. Key is the one exhausted, as returned by Key.
+type ExhaustedError struct {
+	Key string
+}
+
+func (e *ExhaustedError) Error() string {
+	return "quota exhausted for " + e.Key
+}
+
+// Is reports whether target is ErrQuotaExhausted.
+func (e *ExhaustedError) Is(target error) bool {
+	return target == ErrQuotaExhausted
+}
+
+// Usage tracks usage metrics for a backend, or for one of its models when
+// Model is set.
 type Usage struct {
-	Backend      string    `json:"backend"`
-	Requests     int       `json:"requests"`
-	Tokens       int       `json:"tokens"`
-	LastRequest  time.Time `json:"last_request"`
-	WindowStart  time.Time `json:"window_start"`
-	IsExhausted  bool      `json:"is_exhausted"`
-	RetryAfter   time.Time `json:"retry_after,omitempty"`
+	Backend  string `json:"backend"`
+	Model    string `json:"model,omitempty"`
+	Requests int    `json:"requests"`
+	Tokens   int    `json:"tokens"`
+	// InputTokens, OutputTokens, CacheReadTokens and CacheWriteTokens
+	// break Tokens down by kind.
+	InputTokens      int `json:"input_tokens,omitempty"`
+	OutputTokens     int `json:"output_tokens,omitempty"`
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	// Cost is the dollar cost of Tokens, when the model has a price.
+	Cost        float64   `json:"cost,omitempty"`
+	LastRequest time.Time `json:"last_request"`
+	WindowStart time.Time `json:"window_start"`
+	IsExhausted bool      `json:"is_exhausted"`
+	RetryAfter  time.Time `json:"retry_after,omitempty"`
+
+	// windowStarted and retryAt hold monotonic clock readings for windows
+	// opened by this process, so wall-clock changes can't stretch or cut them.
+	windowStarted time.Time
+	retryAt       time.Time
+
+	// extra holds JSON fields written by newer versions, kept on save.
+	extra jsoncompat.Fields
+}
+
+// resetTokens zeroes the requests, tokens and cost of a new window.
+func (u *Usage) resetTokens() {
+	u.Requests = 0
+	u.Tokens = 0
+	u.InputTokens = 0
+	u.OutputTokens = 0
+	u.CacheReadTokens = 0
+	u.CacheWriteTokens = 0
+	u.Cost = 0
+}
+
+// usageJSON has Usage's fields without its JSON methods.
+type usageJSON Usage
+
+// UnmarshalJSON decodes usage, keeping fields this version doesn't know.
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	var decoded usageJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	extra, err := jsoncompat.Unknown(data, decoded)
+	if err != nil {
+		return err
+	}
+	*u = Usage(decoded)
+	u.extra = extra
+	return nil
+}
+
+// MarshalJSON encodes usage, including any preserved fields.
+func (u Usage) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(usageJSON(u))
+	if err != nil {
+		return nil, err
+	}
+	return jsoncompat.Merge(data, u.extra)
 }
 
 // Tracker manages quota tracking for multiple backends.
@@ -26,20 +108,123 @@ type Tracker struct {
 	mu      sync.RWMutex
 	usage   map[string]*Usage
 	path    string
-	limits  map[string]int // Backend -> requests per window
-	window  time.Duration  // Time window for limits
+	limits  map[string]int    // Backend -> requests per window
+	window  time.Duration     // Time window for limits
+	windows map[string]Window // Backend or model -> its own window
+	loc     *time.Location    // Timezone for aligning day-length windows
+	clock   clock.Clock
+	pricing cost.Pricing
 }
 
 // New creates a new quota tracker.
 func New(dataPath string) *Tracker {
 	return &Tracker{
-		usage:  make(map[string]*Usage),
-		path:   dataPath,
-		limits: make(map[string]int),
-		window: time.Hour, // Default 1 hour window
+		usage:   make(map[string]*Usage),
+		path:    dataPath,
+		limits:  make(map[string]int),
+		window:  time.Hour, // Default 1 hour window
+		windows: make(map[string]Window),
+		loc:     time.Local,
+		clock:   clock.Real,
 	}
 }
 
+// SetClock sets the clock windows and retry deadlines are measured with.
+func (t *Tracker) SetClock(c clock.Clock) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clock = clock.Or(c)
+}
+
+// SetPricing sets the prices usage is converted to dollar cost with.
+func (t *Tracker) SetPricing(p cost.Pricing) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pricing = p
+}
+
+// SetLocation sets the timezone used to align day-length windows to midnight.
+func (t *Tracker) SetLocation(loc *time.Location) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.loc = loc
+}
+
+// windowFor returns the window of a backend or model: its own, its
+// backend's, or the default rolling window (must be called with lock held).
+func (t *Tracker) windowFor(usage *Usage) Window {
+	if w, ok := t.windows[Key(usage.Backend, usage.Model)]; ok {
+		return w
+	}
+	if w, ok := t.windows[usage.Backend]; ok {
+		return w
+	}
+	return Rolling(t.window)
+}
+
+// startWindow opens a new window at now. Calendar windows start at the
+// beginning of the current period; rolling windows that are a whole number
+// of days start at midnight in the tracker's timezone.
+func (t *Tracker) startWindow(usage *Usage, now time.Time) {
+	start := now
+	if w := t.windowFor(usage); w.calendar() {
+		start = now.Add(-now.Sub(w.periodStart(now))) // keeps the monotonic reading
+	} else if w.Duration >= 24*time.Hour && w.Duration%(24*time.Hour) == 0 {
+		local := now.In(t.loc)
+		midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, t.loc)
+		start = now.Add(-now.Sub(midnight)) // keeps the monotonic reading
+	}
+	usage.windowStarted = start
+	usage.WindowStart = start.UTC()
+}
+
+// windowElapsed returns how long the usage window has been open.
+func (t *Tracker) windowElapsed(usage *Usage, now time.Time) time.Duration {
+	if !usage.windowStarted.IsZero() {
+		return now.Sub(usage.windowStarted)
+	}
+	elapsed := now.Sub(usage.WindowStart)
+	if elapsed < 0 {
+		// The wall clock moved backwards since the window was persisted;
+		// restart the window from now rather than waiting for it to catch up.
+		t.startWindow(usage, now)
+		return 0
+	}
+	return elapsed
+}
+
+// windowLength returns how long the current usage window lasts.
+func (t *Tracker) windowLength(usage *Usage) time.Duration {
+	w := t.windowFor(usage)
+	if w.calendar() {
+		return w.periodEnd(usage.WindowStart).Sub(usage.WindowStart)
+	}
+	return w.Duration
+}
+
+// setRetry marks the backend unavailable until now+d.
+func setRetry(usage *Usage, now time.Time, d time.Duration) {
+	usage.retryAt = now.Add(d)
+	usage.RetryAfter = usage.retryAt.UTC()
+}
+
+// retryPassed reports whether the retry deadline has passed.
+func retryPassed(usage *Usage, now time.Time) bool {
+	if !usage.retryAt.IsZero() {
+		return !now.Before(usage.retryAt)
+	}
+	return now.After(usage.RetryAfter)
+}
+
+// Key returns the key usage of a backend's model is tracked under:
+// "backend/model", or just the backend when model is empty.
+func Key(backend, model string) string {
+	if model == "" {
+		return backend
+	}
+	return backend + "/" + model
+}
+
 // SetLimit sets the request limit for a backend.
 func (t *Tracker) SetLimit(backend string, requests int) {
 	t.mu.Lock()
@@ -47,6 +232,23 @@ func (t *Tracker) SetLimit(backend string, requests int) {
 	t.limits[backend] = requests
 }
 
+// SetModelLimit sets the request limit for one model of a backend. The
+// backend's own limit still applies to all its models together.
+func (t *Tracker) SetModelLimit(backend, model string, requests int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits[Key(backend, model)] = requests
+}
+
+// Limit returns the request limit of a backend, or of a model as named
+// by Key.
+func (t *Tracker) Limit(key string) (int, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	limit, ok := t.limits[key]
+	return limit, ok
+}
+
 // SetWindow sets the time window for quota tracking.
 func (t *Tracker) SetWindow(d time.Duration) {
 	t.mu.Lock()
@@ -54,69 +256,125 @@ func (t *Tracker) SetWindow(d time.Duration) {
 	t.window = d
 }
 
-// Record records a request and token usage for a backend.
-func (t *Tracker) Record(backend string, tokens int) error {
+// SetBackendWindow sets the window of a backend, or of one model as named
+// by Key, in place of the default rolling window. A model without its own
+// window uses its backend's.
+func (t *Tracker) SetBackendWindow(key string, w Window) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.windows[key] = w
+}
 
-	now := time.Now()
-	
-	usage, ok := t.usage[backend]
+// Window returns the window of a backend or model, and when its current
+// window resets (zero when it has no usage yet).
+func (t *Tracker) Window(key string) (Window, time.Time) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	backend, model, _ := strings.Cut(key, "/")
+	usage, ok := t.usage[key]
 	if !ok {
-		usage = &Usage{
-			Backend:     backend,
-			WindowStart: now,
-		}
-		t.usage[backend] = usage
+		return t.windowFor(&Usage{Backend: backend, Model: model}), time.Time{}
 	}
+	return t.windowFor(usage), usage.WindowStart.Add(t.windowLength(usage))
+}
+
+// Record records a request and token usage for a backend. Tokens of
+// unknown kind are counted as input tokens.
+func (t *Tracker) Record(backend string, tokens int) error {
+	return t.RecordModel(backend, "", tokens)
+}
+
+// RecordModel records a request and token usage for a backend's model,
+// with tokens counted as input tokens (see RecordUsage).
+func (t *Tracker) RecordModel(backend, model string, tokens int) error {
+	return t.RecordUsage(backend, model, cost.Tokens{Input: tokens})
+}
+
+// RecordUsage records a request and its tokens by kind for a backend's
+// model, counting it towards both the model and the backend. An empty
+// model counts towards the backend only.
+func (t *Tracker) RecordUsage(backend, model string, tokens cost.Tokens) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.update(func() {
+		now := t.clock.Now()
+		price, _ := t.pricing.Cost(backend, model, tokens)
+		t.record(backend, "", tokens, price, now)
+		if model != "" {
+			t.record(backend, model, tokens, price, now)
+		}
+	})
+}
+
+// record adds a request costing price to the usage of a backend or model
+// (must be called with lock held).
+func (t *Tracker) record(backend, model string, tokens cost.Tokens, price float64, now time.Time) {
+	key := Key(backend, model)
+	usage := t.usageFor(backend, model, now)
 
 	// Reset window if expired
-	if now.Sub(usage.WindowStart) > t.window {
-		usage.Requests = 0
-		usage.Tokens = 0
-		usage.WindowStart = now
+	if t.windowElapsed(usage, now) > t.windowLength(usage) {
+		usage.resetTokens()
+		t.startWindow(usage, now)
 		usage.IsExhausted = false
 	}
 
 	usage.Requests++
-	usage.Tokens += tokens
-	usage.LastRequest = now
+	usage.Tokens += tokens.Total()
+	usage.InputTokens += tokens.Input
+	usage.OutputTokens += tokens.Output
+	usage.CacheReadTokens += tokens.CacheRead
+	usage.CacheWriteTokens += tokens.CacheWrite
+	usage.Cost += price
+	usage.LastRequest = now.UTC()
 
 	// Check if exhausted
-	if limit, ok := t.limits[backend]; ok {
+	if limit, ok := t.limits[key]; ok {
 		if usage.Requests >= limit {
 			usage.IsExhausted = true
-			usage.RetryAfter = usage.WindowStart.Add(t.window)
+			setRetry(usage, now, t.windowLength(usage)-t.windowElapsed(usage, now))
 		}
 	}
+}
 
-	return t.save()
+// usageFor returns the usage of a backend or model, starting it if needed
+// (must be called with lock held).
+func (t *Tracker) usageFor(backend, model string, now time.Time) *Usage {
+	key := Key(backend, model)
+	usage, ok := t.usage[key]
+	if !ok {
+		usage = &Usage{Backend: backend, Model: model}
+		t.startWindow(usage, now)
+		t.usage[key] = usage
+	}
+	return usage
 }
 
 // RecordError records a rate limit error for a backend.
 func (t *Tracker) RecordError(backend string, retryAfter time.Duration) error {
+	return t.RecordModelError(backend, "", retryAfter)
+}
+
+// RecordModelError records a rate limit error for a backend's model,
+// leaving its other models available. An empty model marks the whole
+// backend.
+func (t *Tracker) RecordModelError(backend, model string, retryAfter time.Duration) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	now := time.Now()
-	
-	usage, ok := t.usage[backend]
-	if !ok {
-		usage = &Usage{
-			Backend:     backend,
-			WindowStart: now,
-		}
-		t.usage[backend] = usage
-	}
-
-	usage.IsExhausted = true
-	if retryAfter > 0 {
-		usage.RetryAfter = now.Add(retryAfter)
-	} else {
-		usage.RetryAfter = now.Add(time.Hour) // Default 1 hour
-	}
+	return t.update(func() {
+		now := t.clock.Now()
+		usage := t.usageFor(backend, model, now)
 
-	return t.save()
+		usage.IsExhausted = true
+		if retryAfter > 0 {
+			setRetry(usage, now, retryAfter)
+		} else {
+			setRetry(usage, now, time.Hour) // Default 1 hour
+		}
+	})
 }
 
 // GetUsage returns the usage for a backend.
@@ -134,26 +392,52 @@ func (t *Tracker) GetUsage(backend string) (*Usage, bool) {
 	return &copy, true
 }
 
-// IsExhausted returns true if the backend has exhausted its quota.
-func (t *Tracker) IsExhausted(backend string) bool {
+// IsModelExhausted returns true if the backend, or the given model of it,
+// has exhausted its quota.
+func (t *Tracker) IsModelExhausted(backend, model string) bool {
+	if t.IsExhausted(backend) {
+		return true
+	}
+	return model != "" && t.IsExhausted(Key(backend, model))
+}
+
+// CheckModel returns an *ExhaustedError if the backend, or the given model
+// of it, has exhausted its quota.
+func (t *Tracker) CheckModel(backend, model string) error {
+	if t.IsExhausted(backend) {
+		return &ExhaustedError{Key: backend}
+	}
+	if model != "" && t.IsExhausted(Key(backend, model)) {
+		return &ExhaustedError{Key: Key(backend, model)}
+	}
+	return nil
+}
+
+// IsExhausted returns true if the backend has exhausted its quota. key may
+// also name a model, as returned by Key.
+func (t *Tracker) IsExhausted(key string) bool {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	usage, ok := t.usage[backend]
+	usage, ok := t.usage[key]
 	if !ok {
 		return false
 	}
 
 	// Check if exhausted and retry time has passed
-	if usage.IsExhausted && time.Now().After(usage.RetryAfter) {
+	now := t.clock.Now()
+	if usage.IsExhausted && retryPassed(usage, now) {
 		// Reset exhausted state
 		t.mu.RUnlock()
 		t.mu.Lock()
-		usage.IsExhausted = false
-		usage.Requests = 0
-		usage.Tokens = 0
-		usage.WindowStart = time.Now()
-		t.save()
+		t.update(func() {
+			// Another process may have reset or renewed it meanwhile
+			if usage, ok := t.usage[key]; ok && usage.IsExhausted && retryPassed(usage, now) {
+				usage.IsExhausted = false
+				usage.resetTokens()
+				t.startWindow(usage, now)
+			}
+		})
 		t.mu.Unlock()
 		t.mu.RLock()
 		return false
@@ -162,29 +446,59 @@ func (t *Tracker) IsExhausted(backend string) bool {
 	return usage.IsExhausted
 }
 
-// ListUsage returns usage for all backends.
+// ListUsage returns usage for all backends, keyed by backend name.
 func (t *Tracker) ListUsage() map[string]*Usage {
+	return t.list(false)
+}
+
+// ListModelUsage returns usage for every model used, keyed by Key.
+func (t *Tracker) ListModelUsage() map[string]*Usage {
+	return t.list(true)
+}
+
+// list copies the backend or model usage entries.
+func (t *Tracker) list(models bool) map[string]*Usage {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
 	result := make(map[string]*Usage)
 	for k, v := range t.usage {
+		if (v.Model != "") != models {
+			continue
+		}
 		copy := *v
 		result[k] = &copy
 	}
 	return result
 }
 
-// Reset clears usage for a backend.
+// Reset clears usage for a backend and its models.
 func (t *Tracker) Reset(backend string) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if _, ok := t.usage[backend]; ok {
-		delete(t.usage, backend)
-		return t.save()
+	return t.update(func() {
+		for key, usage := range t.usage {
+			if usage.Backend == backend || key == backend {
+				delete(t.usage, key)
+			}
+		}
+	})
+}
+
+// ResetModel clears usage for one model of a backend, leaving the
+// backend's own usage and its other models alone. An empty model resets
+// the whole backend (see Reset).
+func (t *Tracker) ResetModel(backend, model string) error {
+	if model == "" {
+		return t.Reset(backend)
 	}
-	return nil
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.update(func() {
+		delete(t.usage, Key(backend, model))
+	})
 }
 
 // ResetAll clears all usage data.
@@ -192,15 +506,22 @@ func (t *Tracker) ResetAll() error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	t.usage = make(map[string]*Usage)
-	return t.save()
+	return t.update(func() {
+		t.usage = make(map[string]*Usage)
+	})
 }
 
 // Load loads usage data from disk.
 func (t *Tracker) Load() error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	return t.load()
+}
 
+// load replaces the usage with what is on disk, keeping the monotonic
+// clock readings of windows and retry deadlines that haven't changed
+// (must be called with lock held).
+func (t *Tracker) load() error {
 	data, err := os.ReadFile(t.path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -214,11 +535,64 @@ func (t *Tracker) Load() error {
 		return fmt.Errorf("failed to parse quota file: %w", err)
 	}
 
-	t.usage = usage
+	loaded := make(map[string]*Usage, len(usage))
+	for backend, u := range usage {
+		if u == nil {
+			continue // "backend": null carries no usage
+		}
+		if old, ok := t.usage[backend]; ok {
+			if old.WindowStart.Equal(u.WindowStart) {
+				u.windowStarted = old.windowStarted
+			}
+			if old.RetryAfter.Equal(u.RetryAfter) {
+				u.retryAt = old.retryAt
+			}
+		}
+		loaded[backend] = u
+	}
+	t.usage = loaded
 	return nil
 }
 
-// save persists usage data to disk (must be called with lock held).
+// update applies fn to the latest usage on disk and saves the result,
+// holding the quota file lock throughout so concurrent flo processes
+// don't overwrite each other's counts (must be called with lock held).
+func (t *Tracker) update(fn func()) error {
+	unlock, err := lockFile(t.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := t.load(); err != nil {
+		return err
+	}
+	fn()
+	return t.save()
+}
+
+// lockFile takes an exclusive lock on path, creating it if needed, and
+// returns a function that releases it.
+func lockFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open quota lock: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock quota file: %w", err)
+	}
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+// save writes usage data to disk via a temp file and rename, so readers
+// never see a partial file (must be called with lock held).
 func (t *Tracker) save() error {
 	// Create directory if needed
 	dir := filepath.Dir(t.path)
@@ -231,9 +605,13 @@ func (t *Tracker) save() error {
 		return fmt.Errorf("failed to serialize usage: %w", err)
 	}
 
-	if err := os.WriteFile(t.path, data, 0644); err != nil {
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
 		return fmt.Errorf("failed to write quota file: %w", err)
 	}
+	if err := os.Rename(tmp, t.path); err != nil {
+		return fmt.Errorf("failed to replace quota file: %w", err)
+	}
 
 	return nil
 }
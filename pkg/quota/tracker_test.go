@@ -1,10 +1,17 @@
 package quota
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/richgo/flo/pkg/clock"
+	"github.com/richgo/flo/pkg/cost"
 )
 
 func TestNewTracker(t *testing.T) {
@@ -167,6 +174,32 @@ func TestReset(t *testing.T) {
 	}
 }
 
+func TestResetModel(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.SetModelLimit("claude", "opus", 1)
+	tracker.RecordModel("claude", "opus", 100)
+	tracker.RecordModel("claude", "sonnet", 100)
+	if !tracker.IsModelExhausted("claude", "opus") {
+		t.Fatal("expected opus to be exhausted")
+	}
+	if limit, ok := tracker.Limit("claude/opus"); !ok || limit != 1 {
+		t.Errorf("expected opus limit 1, got %d %v", limit, ok)
+	}
+
+	if err := tracker.ResetModel("claude", "opus"); err != nil {
+		t.Fatalf("ResetModel failed: %v", err)
+	}
+	if tracker.IsModelExhausted("claude", "opus") {
+		t.Error("expected opus to be available after reset")
+	}
+	if _, ok := tracker.GetUsage("claude/sonnet"); !ok {
+		t.Error("expected sonnet usage to be kept")
+	}
+	if usage, _ := tracker.GetUsage("claude"); usage.Requests != 2 {
+		t.Errorf("expected backend usage to be kept, got %d requests", usage.Requests)
+	}
+}
+
 func TestResetAll(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "quota.json")
@@ -224,6 +257,8 @@ func TestWindowReset(t *testing.T) {
 	path := filepath.Join(tmpDir, "quota.json")
 	
 	tracker := New(path)
+	fake := clock.NewFake(time.Now())
+	tracker.SetClock(fake)
 	tracker.SetWindow(100 * time.Millisecond)
 	tracker.SetLimit("claude", 2)
 	
@@ -236,8 +271,8 @@ func TestWindowReset(t *testing.T) {
 		t.Error("Should be exhausted at limit")
 	}
 	
-	// Wait for window to expire
-	time.Sleep(150 * time.Millisecond)
+	// Let the window expire
+	fake.Advance(150 * time.Millisecond)
 	
 	// Record another request - should reset window
 	tracker.Record("claude", 100)
@@ -282,3 +317,283 @@ func TestLoadInvalidJSON(t *testing.T) {
 		t.Error("Load should fail for invalid JSON")
 	}
 }
+
+func TestTimestampsPersistedInUTC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	tracker := New(path)
+	tracker.Record("claude", 100)
+	tracker.RecordError("claude", time.Minute)
+
+	loaded := New(path)
+	loaded.Load()
+	usage, _ := loaded.GetUsage("claude")
+	for name, ts := range map[string]time.Time{
+		"window_start": usage.WindowStart,
+		"last_request": usage.LastRequest,
+		"retry_after":  usage.RetryAfter,
+	} {
+		if ts.Location() != time.UTC {
+			t.Errorf("expected %s in UTC, got %s", name, ts.Location())
+		}
+	}
+}
+
+func TestDayWindowAlignsToMidnight(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.SetWindow(24 * time.Hour)
+	tracker.SetLocation(loc)
+
+	tracker.Record("claude", 100)
+	usage, _ := tracker.GetUsage("claude")
+
+	start := usage.WindowStart.In(loc)
+	if start.Hour() != 0 || start.Minute() != 0 || start.Second() != 0 {
+		t.Errorf("expected window to start at local midnight, got %s", start)
+	}
+}
+
+func TestWindowSurvivesClockMovingBackwards(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	future := time.Now().Add(2 * time.Hour).UTC()
+	data := fmt.Sprintf(`{"claude": {"backend": "claude", "requests": 3, "window_start": %q}}`,
+		future.Format(time.RFC3339))
+	os.WriteFile(path, []byte(data), 0644)
+
+	tracker := New(path)
+	tracker.Load()
+	tracker.Record("claude", 100)
+
+	usage, _ := tracker.GetUsage("claude")
+	if usage.Requests != 4 {
+		t.Errorf("expected counts kept when the clock moves back, got %d", usage.Requests)
+	}
+	if usage.WindowStart.After(time.Now()) {
+		t.Errorf("expected window restarted from now, got %s", usage.WindowStart)
+	}
+}
+
+func TestModelUsage(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.SetLimit("claude", 10)
+	tracker.SetModelLimit("claude", "opus", 2)
+
+	tracker.RecordModel("claude", "opus", 100)
+	tracker.RecordModel("claude", "sonnet", 200)
+	tracker.RecordModel("claude", "opus", 300)
+
+	backend, ok := tracker.GetUsage("claude")
+	if !ok || backend.Requests != 3 || backend.Tokens != 600 || backend.IsExhausted {
+		t.Errorf("unexpected backend usage: %+v", backend)
+	}
+	opus, ok := tracker.GetUsage(Key("claude", "opus"))
+	if !ok || opus.Requests != 2 || opus.Model != "opus" || !opus.IsExhausted {
+		t.Errorf("unexpected opus usage: %+v", opus)
+	}
+	if !tracker.IsModelExhausted("claude", "opus") {
+		t.Error("expected opus to be exhausted")
+	}
+	if tracker.IsModelExhausted("claude", "sonnet") || tracker.IsExhausted("claude") {
+		t.Error("expected sonnet and the backend to be available")
+	}
+
+	if backends := tracker.ListUsage(); len(backends) != 1 || backends["claude"] == nil {
+		t.Errorf("expected only the backend in ListUsage, got %v", backends)
+	}
+	if models := tracker.ListModelUsage(); len(models) != 2 || models["claude/sonnet"].Tokens != 200 {
+		t.Errorf("unexpected model usage: %v", models)
+	}
+
+	if err := tracker.Reset("claude"); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if len(tracker.ListModelUsage()) != 0 {
+		t.Error("expected Reset to clear the backend's models")
+	}
+}
+
+func TestRecordModelError(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+
+	tracker.RecordModelError("claude", "opus", time.Hour)
+	if !tracker.IsModelExhausted("claude", "opus") {
+		t.Error("expected opus to be exhausted")
+	}
+	if tracker.IsModelExhausted("claude", "sonnet") {
+		t.Error("expected other models to stay available")
+	}
+
+	tracker.RecordError("claude", time.Hour)
+	if !tracker.IsModelExhausted("claude", "sonnet") {
+		t.Error("expected an exhausted backend to exhaust all its models")
+	}
+}
+
+func TestCheckModel(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	if err := tracker.CheckModel("claude", "opus"); err != nil {
+		t.Errorf("expected a fresh model to be available, got %v", err)
+	}
+
+	tracker.RecordModelError("claude", "opus", time.Hour)
+	var exhausted *ExhaustedError
+	err := tracker.CheckModel("claude", "opus")
+	if !errors.Is(err, ErrQuotaExhausted) || !errors.As(err, &exhausted) || exhausted.Key != Key("claude", "opus") {
+		t.Errorf("expected opus exhausted, got %v", err)
+	}
+
+	tracker.RecordError("claude", time.Hour)
+	if err := tracker.CheckModel("claude", "sonnet"); !errors.As(err, &exhausted) || exhausted.Key != "claude" {
+		t.Errorf("expected the backend exhausted, got %v", err)
+	}
+}
+
+func TestUsageCost(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.SetPricing(cost.Pricing{"claude": {Input: 3}, "claude/opus": {Input: 15}})
+
+	tracker.RecordModel("claude", "opus", 1000000)
+	tracker.RecordModel("claude", "sonnet", 1000000)
+	tracker.RecordModel("copilot", "", 1000000)
+
+	backend, _ := tracker.GetUsage("claude")
+	if backend.Cost != 18 {
+		t.Errorf("expected claude to cost 18, got %v", backend.Cost)
+	}
+	opus, _ := tracker.GetUsage(Key("claude", "opus"))
+	if opus.Cost != 15 {
+		t.Errorf("expected opus to cost 15, got %v", opus.Cost)
+	}
+	copilot, _ := tracker.GetUsage("copilot")
+	if copilot.Cost != 0 {
+		t.Errorf("expected unpriced usage to cost nothing, got %v", copilot.Cost)
+	}
+}
+
+func TestRecordUsageByKind(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.SetPricing(cost.Pricing{"claude/opus": {Input: 15, Output: 75, CacheRead: 1.5}})
+
+	tracker.RecordUsage("claude", "opus", cost.Tokens{Input: 1000000, Output: 100000, CacheRead: 2000000})
+	tracker.RecordUsage("claude", "opus", cost.Tokens{Input: 1000000, CacheWrite: 500})
+
+	opus, _ := tracker.GetUsage(Key("claude", "opus"))
+	if opus.Tokens != 4100500 || opus.InputTokens != 2000000 || opus.OutputTokens != 100000 ||
+		opus.CacheReadTokens != 2000000 || opus.CacheWriteTokens != 500 {
+		t.Errorf("unexpected token breakdown: %+v", opus)
+	}
+	// 30 input + 7.5 output + 3 cache read + 0.0075 cache write at the input price
+	if opus.Cost < 40.507 || opus.Cost > 40.508 {
+		t.Errorf("expected cost 40.5075, got %v", opus.Cost)
+	}
+	backend, _ := tracker.GetUsage("claude")
+	if backend.OutputTokens != 100000 {
+		t.Errorf("expected the backend to count output tokens, got %+v", backend)
+	}
+}
+
+func TestCalendarWindows(t *testing.T) {
+	// 2026-10-14 is a Wednesday.
+	fake := clock.NewFake(time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC))
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.SetClock(fake)
+	tracker.SetBackendWindow("claude", Window{Kind: WindowDaily})
+	tracker.SetBackendWindow("copilot", Window{Kind: WindowWeekly})
+	tracker.SetBackendWindow("gemini/pro", Window{Kind: WindowMonthly})
+	tracker.SetLimit("claude", 1)
+
+	tracker.Record("claude", 100)
+	tracker.Record("copilot", 100)
+	tracker.RecordModel("gemini", "pro", 100)
+
+	usage, _ := tracker.GetUsage("claude")
+	midnight := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	if !usage.IsExhausted || !usage.RetryAfter.Equal(midnight) {
+		t.Errorf("expected claude exhausted until midnight UTC, got %v until %s", usage.IsExhausted, usage.RetryAfter)
+	}
+	for key, want := range map[string][2]time.Time{
+		"claude":     {time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), midnight},
+		"copilot":    {time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		"gemini/pro": {time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		// The backend keeps the default rolling window.
+		"gemini": {fake.Now(), fake.Now().Add(time.Hour)},
+	} {
+		usage, _ := tracker.GetUsage(key)
+		_, resets := tracker.Window(key)
+		if !usage.WindowStart.Equal(want[0]) || !resets.Equal(want[1]) {
+			t.Errorf("%s: window %s to %s, want %s to %s", key, usage.WindowStart, resets, want[0], want[1])
+		}
+	}
+
+	fake.Advance(9*time.Hour + time.Minute)
+	if tracker.IsExhausted("claude") {
+		t.Error("expected claude available after midnight UTC")
+	}
+	tracker.Record("copilot", 100)
+	if usage, _ := tracker.GetUsage("copilot"); usage.Requests != 2 {
+		t.Errorf("expected the weekly window to carry on, got %d requests", usage.Requests)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	for s, want := range map[string]Window{
+		"daily":  {Kind: WindowDaily},
+		"Weekly": {Kind: WindowWeekly},
+		"5h":     Rolling(5 * time.Hour),
+	} {
+		got, err := ParseWindow(s)
+		if err != nil || got != want || got.String() != strings.ToLower(s) {
+			t.Errorf("ParseWindow(%q) = %+v (%s), %v", s, got, got, err)
+		}
+	}
+	for _, s := range []string{"yearly", "-1h", "0s"} {
+		if _, err := ParseWindow(s); err == nil {
+			t.Errorf("expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestConcurrentTrackers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+
+	// Separate trackers stand in for separate flo processes.
+	const trackers, requests = 4, 25
+	var wg sync.WaitGroup
+	for i := 0; i < trackers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker := New(path)
+			tracker.Load()
+			for j := 0; j < requests; j++ {
+				if err := tracker.RecordModel("claude", "opus", 10); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	loaded := New(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	for _, key := range []string{"claude", "claude/opus"} {
+		usage, _ := loaded.GetUsage(key)
+		if usage == nil || usage.Requests != trackers*requests {
+			t.Errorf("%s: expected %d requests, got %+v", key, trackers*requests, usage)
+		}
+	}
+
+	// A stale tracker's reset applies to the latest usage, not its own.
+	stale := New(path)
+	stale.Load()
+	loaded.RecordModel("claude", "sonnet", 10)
+	if err := stale.ResetModel("claude", "opus"); err != nil {
+		t.Fatalf("ResetModel failed: %v", err)
+	}
+	loaded.Load()
+	if usage, _ := loaded.GetUsage("claude/sonnet"); usage == nil {
+		t.Error("expected a reset by a stale tracker to keep newer usage")
+	}
+}
@@ -0,0 +1,94 @@
+package quota
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WindowKind is how a usage window resets.
+type WindowKind string
+
+const (
+	// WindowRolling opens a window of a fixed duration at the first
+	// request after the previous one ended.
+	WindowRolling WindowKind = "rolling"
+	// WindowDaily, WindowWeekly and WindowMonthly reset at midnight UTC
+	// each day, each Monday and on the 1st of each month, the way most
+	// providers reset their quotas.
+	WindowDaily   WindowKind = "daily"
+	WindowWeekly  WindowKind = "weekly"
+	WindowMonthly WindowKind = "monthly"
+)
+
+// Window is the period a backend's requests are counted over.
+type Window struct {
+	Kind WindowKind
+	// Duration is the length of a rolling window.
+	Duration time.Duration
+}
+
+// Rolling returns a rolling window of duration d.
+func Rolling(d time.Duration) Window {
+	return Window{Kind: WindowRolling, Duration: d}
+}
+
+// ParseWindow parses "daily", "weekly", "monthly", or a duration such as
+// "5h" for a rolling window.
+func ParseWindow(s string) (Window, error) {
+	switch kind := WindowKind(strings.ToLower(strings.TrimSpace(s))); kind {
+	case WindowDaily, WindowWeekly, WindowMonthly:
+		return Window{Kind: kind}, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil || d <= 0 {
+		return Window{}, fmt.Errorf("window '%s' must be daily, weekly, monthly or a positive duration", s)
+	}
+	return Rolling(d), nil
+}
+
+// String returns the window as ParseWindow accepts it.
+func (w Window) String() string {
+	if w.Kind == WindowRolling {
+		// "1h" rather than "1h0m0s"
+		d := w.Duration.String()
+		if strings.HasSuffix(d, "m0s") {
+			d = strings.TrimSuffix(d, "0s")
+		}
+		if strings.HasSuffix(d, "h0m") {
+			d = strings.TrimSuffix(d, "0m")
+		}
+		return d
+	}
+	return string(w.Kind)
+}
+
+// calendar reports whether the window resets on calendar boundaries.
+func (w Window) calendar() bool {
+	return w.Kind != WindowRolling
+}
+
+// periodStart returns the start of the calendar period containing t.
+func (w Window) periodStart(t time.Time) time.Time {
+	u := t.UTC()
+	day := time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.UTC)
+	switch w.Kind {
+	case WindowWeekly:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case WindowMonthly:
+		return time.Date(u.Year(), u.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// periodEnd returns the end of the calendar period containing t.
+func (w Window) periodEnd(t time.Time) time.Time {
+	start := w.periodStart(t)
+	switch w.Kind {
+	case WindowWeekly:
+		return start.AddDate(0, 0, 7)
+	case WindowMonthly:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
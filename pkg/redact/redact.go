@@ -0,0 +1,97 @@
+// Package redact scrubs secret values and credential-shaped strings from text.
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces text matched by a pattern.
+const Placeholder = "[REDACTED]"
+
+// minValueLength is the shortest literal value that will be redacted; shorter
+// values (e.g. "1", "true") would mangle unrelated text.
+const minValueLength = 6
+
+// DefaultPatterns match common credential formats regardless of origin.
+var DefaultPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]{10,}`),
+	regexp.MustCompile(`sk-[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`github_pat_[A-Za-z0-9_]{20,}`),
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._\-]{16,}`),
+}
+
+// Redactor replaces known secret values and credential patterns in text.
+type Redactor struct {
+	mu       sync.RWMutex
+	values   map[string]struct{}
+	sorted   []string
+	patterns []*regexp.Regexp
+	mask     func(string) string
+}
+
+// New creates a redactor with the default credential patterns.
+// Literal values are replaced with the output of mask.
+func New(mask func(string) string) *Redactor {
+	if mask == nil {
+		mask = func(string) string { return Placeholder }
+	}
+	return &Redactor{
+		values:   make(map[string]struct{}),
+		patterns: append([]*regexp.Regexp{}, DefaultPatterns...),
+		mask:     mask,
+	}
+}
+
+// AddValue registers a literal secret value to be redacted.
+func (r *Redactor) AddValue(value string) {
+	value = strings.TrimSpace(value)
+	if len(value) < minValueLength {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.values[value]; exists {
+		return
+	}
+	r.values[value] = struct{}{}
+
+	// Longest first so a secret containing another is fully replaced
+	r.sorted = append(r.sorted, value)
+	sort.Slice(r.sorted, func(i, j int) bool {
+		return len(r.sorted[i]) > len(r.sorted[j])
+	})
+}
+
+// AddPattern registers an additional pattern to be redacted.
+func (r *Redactor) AddPattern(pattern *regexp.Regexp) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patterns = append(r.patterns, pattern)
+}
+
+// String returns s with all secrets redacted.
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, value := range r.sorted {
+		if strings.Contains(s, value) {
+			s = strings.ReplaceAll(s, value, r.mask(value))
+		}
+	}
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllString(s, Placeholder)
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+// Package release compares flo versions and looks up the latest release,
+// for `flo version --check`.
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/offline"
+)
+
+// DefaultURL is the GitHub API endpoint for the latest flo release.
+const DefaultURL = "https://api.github.com/repos/richgo/flo/releases/latest"
+
+// Checker looks up the latest release.
+type Checker struct {
+	url    string
+	client *http.Client
+}
+
+// NewChecker returns a checker for the release API at url (DefaultURL if
+// empty).
+func NewChecker(url string) *Checker {
+	if url == "" {
+		url = DefaultURL
+	}
+	return &Checker{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// SetClient replaces the HTTP client (for testing).
+func (c *Checker) SetClient(client *http.Client) {
+	c.client = client
+}
+
+// Release is a published release.
+type Release struct {
+	Version string `json:"tag_name"`
+	URL     string `json:"html_url"`
+}
+
+// Latest returns the latest published release.
+func (c *Checker) Latest(ctx context.Context) (*Release, error) {
+	if err := offline.Check("update check"); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to check for updates: %s", resp.Status)
+	}
+
+	var rel Release
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+	if rel.Version == "" {
+		return nil, fmt.Errorf("release has no version")
+	}
+	return &rel, nil
+}
+
+// Compare compares two versions such as "v1.2.3" or "1.2.3-rc.1",
+// returning -1, 0 or 1. A pre-release sorts before its release. Versions
+// that aren't semantic versions, such as "dev" builds, are an error.
+func Compare(a, b string) (int, error) {
+	va, err := parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parse(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range va.nums {
+		if va.nums[i] != vb.nums[i] {
+			return sign(va.nums[i] - vb.nums[i]), nil
+		}
+	}
+	switch {
+	case va.pre == vb.pre:
+		return 0, nil
+	case va.pre == "":
+		return 1, nil
+	case vb.pre == "":
+		return -1, nil
+	case va.pre < vb.pre:
+		return -1, nil
+	}
+	return 1, nil
+}
+
+// version is a parsed semantic version.
+type version struct {
+	nums [3]int
+	pre  string
+}
+
+// parse parses a semantic version, ignoring a leading "v" and build
+// metadata.
+func parse(s string) (version, error) {
+	var v version
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	rest, _, _ = strings.Cut(rest, "+")
+	rest, v.pre, _ = strings.Cut(rest, "-")
+	parts := strings.Split(rest, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, fmt.Errorf("'%s' is not a semantic version", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("'%s' is not a semantic version", s)
+		}
+		v.nums[i] = n
+	}
+	return v, nil
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/richgo/flo/pkg/offline"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2.3", "v1.10.0", -1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.2", "v1.2.0", 0},
+		{"v1.3.0-rc.1", "v1.3.0", -1},
+		{"v1.3.0-rc.2", "v1.3.0-rc.1", 1},
+		{"v1.3.0+build.5", "v1.3.0", 0},
+	}
+	for _, tt := range tests {
+		got, err := Compare(tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+	if _, err := Compare("dev", "v1.0.0"); err == nil {
+		t.Error("expected dev builds not to compare")
+	}
+}
+
+func TestLatest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v1.4.0", "html_url": "https://example.com/v1.4.0"}`))
+	}))
+	defer srv.Close()
+
+	rel, err := NewChecker(srv.URL).Latest(context.Background())
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if rel.Version != "v1.4.0" || rel.URL != "https://example.com/v1.4.0" {
+		t.Errorf("unexpected release: %+v", rel)
+	}
+
+	offline.Set(true)
+	defer offline.Set(false)
+	if _, err := NewChecker(srv.URL).Latest(context.Background()); !errors.Is(err, offline.ErrOffline) {
+		t.Errorf("expected the check to be blocked offline, got %v", err)
+	}
+}
+
+func TestLatestServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	if _, err := NewChecker(srv.URL).Latest(context.Background()); err == nil {
+		t.Error("expected an error for a failed request")
+	}
+}
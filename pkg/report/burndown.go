@@ -0,0 +1,167 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// DefaultVelocityWindow is how many recent days velocity is averaged over.
+const DefaultVelocityWindow = 7
+
+// BurndownDay is the state of a feature at the end of a day.
+type BurndownDay struct {
+	// Date is the day, as YYYY-MM-DD.
+	Date string `json:"date"`
+	// Completed is the tasks completed that day.
+	Completed int `json:"completed"`
+	// Remaining is the tasks created by the end of the day and not yet
+	// complete.
+	Remaining int `json:"remaining"`
+}
+
+// Burndown is a feature's progress over time.
+type Burndown struct {
+	Total     int           `json:"total"`
+	Completed int           `json:"completed"`
+	Remaining int           `json:"remaining"`
+	Days      []BurndownDay `json:"days"`
+	// Velocity is the tasks completed per day, averaged over the last
+	// Window days (or every day, if there are fewer).
+	Velocity float64 `json:"velocity"`
+	Window   int     `json:"window"`
+	// Projected is when the remaining tasks would be done at the current
+	// velocity; nil when they are done or velocity is zero.
+	Projected *time.Time `json:"projected,omitempty"`
+}
+
+// CompletedAt returns when a complete task completed: its CompletedAt, or
+// for tasks completed before that was recorded, when it was last updated.
+func CompletedAt(t *task.Task) (time.Time, bool) {
+	if t.Status != task.StatusComplete {
+		return time.Time{}, false
+	}
+	if t.CompletedAt != nil {
+		return *t.CompletedAt, true
+	}
+	return t.UpdatedAt, true
+}
+
+// NewBurndown computes the burndown of tasks, day by day in loc from the
+// first task's creation to now, with velocity averaged over window days.
+func NewBurndown(tasks []*task.Task, now time.Time, loc *time.Location, window int) Burndown {
+	if window <= 0 {
+		window = DefaultVelocityWindow
+	}
+	b := Burndown{Total: len(tasks), Days: []BurndownDay{}, Window: window}
+	if len(tasks) == 0 {
+		return b
+	}
+
+	day := func(t time.Time) time.Time {
+		y, m, d := t.In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	first := day(now)
+	for _, t := range tasks {
+		if created := day(t.CreatedAt); created.Before(first) {
+			first = created
+		}
+		if at, ok := CompletedAt(t); ok {
+			b.Completed++
+			if done := day(at); done.Before(first) {
+				first = done
+			}
+		}
+	}
+	b.Remaining = b.Total - b.Completed
+
+	today := day(now)
+	for d := first; !d.After(today); d = d.AddDate(0, 0, 1) {
+		end := d.AddDate(0, 0, 1)
+		entry := BurndownDay{Date: d.Format("2006-01-02")}
+		for _, t := range tasks {
+			at, done := CompletedAt(t)
+			if done && !at.Before(d) && at.Before(end) {
+				entry.Completed++
+			}
+			if t.CreatedAt.Before(end) && !(done && at.Before(end)) {
+				entry.Remaining++
+			}
+		}
+		b.Days = append(b.Days, entry)
+	}
+
+	recent := b.Days
+	if len(recent) > window {
+		recent = recent[len(recent)-window:]
+	}
+	completed := 0
+	for _, d := range recent {
+		completed += d.Completed
+	}
+	b.Velocity = float64(completed) / float64(len(recent))
+
+	if b.Remaining > 0 && b.Velocity > 0 {
+		projected := today.AddDate(0, 0, int(math.Ceil(float64(b.Remaining)/b.Velocity)))
+		b.Projected = &projected
+	}
+	return b
+}
+
+// SVG draws the burndown as a chart of remaining tasks by day, with the
+// tasks completed each day as bars.
+func (b Burndown) SVG() string {
+	const (
+		width, height = 640, 320
+		left, right   = 48, 16
+		top, bottom   = 24, 40
+	)
+	plotW, plotH := float64(width-left-right), float64(height-top-bottom)
+
+	var s strings.Builder
+	fmt.Fprintf(&s, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n", width, height, width, height)
+	fmt.Fprintf(&s, `<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+	fmt.Fprintf(&s, `<text x="%d" y="16" font-size="13">Burndown: %d of %d tasks remaining</text>`+"\n", left, b.Remaining, b.Total)
+
+	maxY := 1
+	for _, d := range b.Days {
+		maxY = max(maxY, d.Remaining, d.Completed)
+	}
+	y := func(v int) float64 { return float64(top) + plotH - plotH*float64(v)/float64(maxY) }
+
+	// Axes, with the top and bottom of the scale
+	fmt.Fprintf(&s, `<line x1="%d" y1="%d" x2="%d" y2="%.1f" stroke="#888"/>`+"\n", left, top, left, float64(top)+plotH)
+	fmt.Fprintf(&s, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#888"/>`+"\n", left, float64(top)+plotH, float64(left)+plotW, float64(top)+plotH)
+	fmt.Fprintf(&s, `<text x="%d" y="%d" text-anchor="end">%d</text>`+"\n", left-6, top+4, maxY)
+	fmt.Fprintf(&s, `<text x="%d" y="%.1f" text-anchor="end">0</text>`+"\n", left-6, float64(top)+plotH+4)
+
+	if n := len(b.Days); n > 0 {
+		step := plotW / float64(n)
+		x := func(i int) float64 { return float64(left) + step*(float64(i)+0.5) }
+		var points []string
+		for i, d := range b.Days {
+			if d.Completed > 0 {
+				fmt.Fprintf(&s, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#9be9a8"><title>%s: %d completed</title></rect>`+"\n",
+					x(i)-step*0.35, y(d.Completed), step*0.7, float64(top)+plotH-y(d.Completed), d.Date, d.Completed)
+			}
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(d.Remaining)))
+		}
+		fmt.Fprintf(&s, `<polyline points="%s" fill="none" stroke="#0969da" stroke-width="2"/>`+"\n", strings.Join(points, " "))
+		fmt.Fprintf(&s, `<text x="%.1f" y="%d">%s</text>`+"\n", x(0), height-bottom+16, b.Days[0].Date)
+		if n > 1 {
+			fmt.Fprintf(&s, `<text x="%.1f" y="%d" text-anchor="end">%s</text>`+"\n", x(n-1), height-bottom+16, b.Days[n-1].Date)
+		}
+	}
+
+	legend := fmt.Sprintf("velocity %.1f/day", b.Velocity)
+	if b.Projected != nil {
+		legend += ", projected done " + b.Projected.Format("2006-01-02")
+	}
+	fmt.Fprintf(&s, `<text x="%d" y="%d">%s</text>`+"\n", left, height-8, legend)
+	s.WriteString("</svg>\n")
+	return s.String()
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+func burndownTask(id string, created time.Time, completed *time.Time) *task.Task {
+	t := task.New(id, "Task "+id)
+	t.CreatedAt = created
+	t.UpdatedAt = created
+	if completed != nil {
+		t.Status = task.StatusComplete
+		t.CompletedAt = completed
+	}
+	return t
+}
+
+func TestBurndown(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, time.UTC) }
+	at := func(d, h int) *time.Time { v := day(d, h); return &v }
+	tasks := []*task.Task{
+		burndownTask("t-001", day(1, 9), at(2, 10)),
+		burndownTask("t-002", day(1, 9), at(3, 10)),
+		burndownTask("t-003", day(1, 9), at(3, 15)),
+		burndownTask("t-004", day(2, 9), nil),
+		burndownTask("t-005", day(3, 9), nil),
+		burndownTask("t-006", day(3, 9), nil),
+	}
+	// Completed before CompletedAt was recorded
+	legacy := burndownTask("t-007", day(1, 9), nil)
+	legacy.Status = task.StatusComplete
+	legacy.UpdatedAt = day(4, 12)
+	tasks = append(tasks, legacy)
+
+	b := NewBurndown(tasks, day(4, 18), time.UTC, 7)
+	if b.Total != 7 || b.Completed != 4 || b.Remaining != 3 {
+		t.Fatalf("unexpected totals: %+v", b)
+	}
+	want := []BurndownDay{
+		{Date: "2026-03-01", Completed: 0, Remaining: 4},
+		{Date: "2026-03-02", Completed: 1, Remaining: 4},
+		{Date: "2026-03-03", Completed: 2, Remaining: 4},
+		{Date: "2026-03-04", Completed: 1, Remaining: 3},
+	}
+	if len(b.Days) != len(want) {
+		t.Fatalf("days = %+v, want %+v", b.Days, want)
+	}
+	for i := range want {
+		if b.Days[i] != want[i] {
+			t.Errorf("day %d = %+v, want %+v", i, b.Days[i], want[i])
+		}
+	}
+	if b.Velocity != 1 {
+		t.Errorf("velocity = %v, want 1", b.Velocity)
+	}
+	if b.Projected == nil || b.Projected.Format("2006-01-02") != "2026-03-07" {
+		t.Errorf("projected = %v, want 2026-03-07", b.Projected)
+	}
+
+	// A short window only counts recent days
+	b = NewBurndown(tasks, day(4, 18), time.UTC, 1)
+	if b.Velocity != 1 || b.Projected.Format("2006-01-02") != "2026-03-07" {
+		t.Errorf("unexpected 1-day window: velocity %v, projected %v", b.Velocity, b.Projected)
+	}
+	b = NewBurndown(tasks, day(6, 18), time.UTC, 2)
+	if b.Velocity != 0 || b.Projected != nil {
+		t.Errorf("expected no projection without recent completions, got %v, %v", b.Velocity, b.Projected)
+	}
+
+	svg := b.SVG()
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, "<polyline") || !strings.Contains(svg, "3 of 7 tasks remaining") {
+		t.Errorf("unexpected chart:\n%s", svg)
+	}
+}
+
+func TestBurndownEmpty(t *testing.T) {
+	b := NewBurndown(nil, time.Now(), time.UTC, 0)
+	if b.Total != 0 || len(b.Days) != 0 || b.Projected != nil || b.Window != DefaultVelocityWindow {
+		t.Errorf("unexpected empty burndown: %+v", b)
+	}
+	if !strings.Contains(b.SVG(), "</svg>") {
+		t.Error("expected an empty chart")
+	}
+}
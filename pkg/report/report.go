@@ -0,0 +1,291 @@
+// Package report writes a summary of each agent run — what was attempted
+// on which backends, how long it took, retries, tokens and cost, test and
+// gate results, and the changes produced — as Markdown and HTML, so a run
+// can be reviewed without reading its transcript. It also computes a
+// feature's burndown from its task history.
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/coverage"
+	"github.com/richgo/flo/pkg/gate"
+	"github.com/richgo/flo/pkg/verify"
+)
+
+// ErrNoReports is returned by Latest when no run has been reported.
+var ErrNoReports = errors.New("no run reports yet")
+
+// Attempt is one try at a task on a backend; failovers add more.
+type Attempt struct {
+	Backend  string        `json:"backend"`
+	Model    string        `json:"model,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Retries  int           `json:"retries,omitempty"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Tokens   cost.Tokens   `json:"tokens"`
+	Cost     float64       `json:"cost,omitempty"`
+}
+
+// Report summarizes one run of a task.
+type Report struct {
+	RunID    string    `json:"run_id"`
+	TaskID   string    `json:"task_id"`
+	Title    string    `json:"title"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Success  bool      `json:"success"`
+	Error    string    `json:"error,omitempty"`
+	Attempts []Attempt `json:"attempts"`
+	// Coverage, Verification and Gates are the test results of the final
+	// attempt, when measured.
+	Coverage     *coverage.Delta   `json:"coverage,omitempty"`
+	Verification *verify.Result    `json:"verification,omitempty"`
+	Gates        *gate.SuiteResult `json:"gates,omitempty"`
+	// DiffStat summarizes the changes in the worktree after the run, as
+	// git diff --stat prints them.
+	DiffStat string `json:"diff_stat,omitempty"`
+}
+
+// Duration returns how long the run took.
+func (r *Report) Duration() time.Duration {
+	return r.Finished.Sub(r.Started)
+}
+
+// Retries returns the retries of every attempt.
+func (r *Report) Retries() int {
+	n := 0
+	for _, a := range r.Attempts {
+		n += a.Retries
+	}
+	return n
+}
+
+// Tokens returns the tokens of every attempt.
+func (r *Report) Tokens() cost.Tokens {
+	var t cost.Tokens
+	for _, a := range r.Attempts {
+		t = t.Add(a.Tokens)
+	}
+	return t
+}
+
+// Cost returns the cost of every attempt.
+func (r *Report) Cost() float64 {
+	c := 0.0
+	for _, a := range r.Attempts {
+		c += a.Cost
+	}
+	return c
+}
+
+// Outcome is "succeeded" or "failed".
+func (r *Report) Outcome() string {
+	if r.Success {
+		return "succeeded"
+	}
+	return "failed"
+}
+
+// Markdown renders the report as Markdown.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Run report: %s %s\n\n", r.TaskID, r.Title)
+	fmt.Fprintf(&b, "- Run: `%s`\n", r.RunID)
+	fmt.Fprintf(&b, "- Outcome: %s\n", r.Outcome())
+	if r.Error != "" {
+		fmt.Fprintf(&b, "- Error: %s\n", r.Error)
+	}
+	fmt.Fprintf(&b, "- Started: %s\n", r.Started.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Duration: %s\n", r.Duration().Round(time.Second))
+	fmt.Fprintf(&b, "- Retries: %d\n", r.Retries())
+	fmt.Fprintf(&b, "- Tokens: %d\n", r.Tokens().Total())
+	fmt.Fprintf(&b, "- Cost: %s\n", cost.Format(r.Cost()))
+
+	b.WriteString("\n## Attempts\n\n")
+	if len(r.Attempts) == 0 {
+		b.WriteString("No backend was started.\n")
+	} else {
+		b.WriteString("| Backend | Model | Duration | Retries | Tokens | Cost | Result |\n")
+		b.WriteString("|---|---|---|---|---|---|---|\n")
+		for _, a := range r.Attempts {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %d | %s | %s |\n",
+				a.Backend, a.Model, a.Duration.Round(time.Second), a.Retries,
+				a.Tokens.Total(), cost.Format(a.Cost), attemptResult(a))
+		}
+	}
+
+	if r.Coverage != nil || r.Verification != nil || r.Gates != nil {
+		b.WriteString("\n## Tests\n\n")
+		if d := r.Coverage; d != nil {
+			if d.HasBefore {
+				fmt.Fprintf(&b, "- Coverage: %.1f%% → %.1f%% (%+.1f)\n", d.Before, d.After, d.Change())
+			} else {
+				fmt.Fprintf(&b, "- Coverage: %.1f%%\n", d.After)
+			}
+		}
+		if v := r.Verification; v != nil {
+			for _, step := range v.Steps {
+				fmt.Fprintf(&b, "- Verify %s: %s\n", step.Name, stepResult(step.Passed, step.Skipped, step.Error))
+			}
+		}
+		if g := r.Gates; g != nil {
+			for _, res := range g.Gates {
+				fmt.Fprintf(&b, "- Gate %s: %s\n", res.Name, stepResult(res.Passed, false, res.Error))
+			}
+		}
+	}
+
+	b.WriteString("\n## Changes\n\n")
+	if r.DiffStat == "" {
+		b.WriteString("No changes in the worktree.\n")
+	} else {
+		fmt.Fprintf(&b, "```\n%s\n```\n", strings.TrimRight(r.DiffStat, "\n"))
+	}
+	return b.String()
+}
+
+func attemptResult(a Attempt) string {
+	if a.Success {
+		return "succeeded"
+	}
+	if a.Error != "" {
+		return "failed: " + strings.ReplaceAll(a.Error, "|", `\|`)
+	}
+	return "failed"
+}
+
+func stepResult(passed, skipped bool, errText string) string {
+	switch {
+	case skipped:
+		return "skipped"
+	case passed:
+		return "passed"
+	case errText != "":
+		return "failed: " + errText
+	default:
+		return "failed"
+	}
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"cost":    cost.Format,
+	"seconds": func(d time.Duration) time.Duration { return d.Round(time.Second) },
+	"attempt": attemptResult,
+	"step":    stepResult,
+	"rfc3339": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Run report: {{.TaskID}} {{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em auto; max-width: 60em; color: #222; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+.succeeded { color: #1a7f37; }
+.failed { color: #cf222e; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>Run report: {{.TaskID}} {{.Title}}</h1>
+<ul>
+<li>Run: <code>{{.RunID}}</code></li>
+<li>Outcome: <span class="{{.Outcome}}">{{.Outcome}}</span></li>
+{{- if .Error}}
+<li>Error: {{.Error}}</li>
+{{- end}}
+<li>Started: {{rfc3339 .Started}}</li>
+<li>Duration: {{seconds .Duration}}</li>
+<li>Retries: {{.Retries}}</li>
+<li>Tokens: {{.Tokens.Total}}</li>
+<li>Cost: {{cost .Cost}}</li>
+</ul>
+<h2>Attempts</h2>
+{{- if .Attempts}}
+<table>
+<tr><th>Backend</th><th>Model</th><th>Duration</th><th>Retries</th><th>Tokens</th><th>Cost</th><th>Result</th></tr>
+{{- range .Attempts}}
+<tr><td>{{.Backend}}</td><td>{{.Model}}</td><td>{{seconds .Duration}}</td><td>{{.Retries}}</td><td>{{.Tokens.Total}}</td><td>{{cost .Cost}}</td><td>{{attempt .}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No backend was started.</p>
+{{- end}}
+{{- if or .Coverage .Verification .Gates}}
+<h2>Tests</h2>
+<ul>
+{{- with .Coverage}}
+<li>Coverage: {{if .HasBefore}}{{printf "%.1f%% → %.1f%% (%+.1f)" .Before .After .Change}}{{else}}{{printf "%.1f%%" .After}}{{end}}</li>
+{{- end}}
+{{- with .Verification}}{{range .Steps}}
+<li>Verify {{.Name}}: {{step .Passed .Skipped .Error}}</li>
+{{- end}}{{end}}
+{{- with .Gates}}{{range .Gates}}
+<li>Gate {{.Name}}: {{step .Passed false .Error}}</li>
+{{- end}}{{end}}
+</ul>
+{{- end}}
+<h2>Changes</h2>
+{{- if .DiffStat}}
+<pre>{{.DiffStat}}</pre>
+{{- else}}
+<p>No changes in the worktree.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// HTML renders the report as a standalone HTML page.
+func (r *Report) HTML() (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, r); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Write saves the report in dir as Markdown and HTML, named for when the
+// run started and its task, and returns the Markdown file's path.
+func Write(dir string, r *Report) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+	page, err := r.HTML()
+	if err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	base := filepath.Join(dir, fmt.Sprintf("%s-%s", r.Started.UTC().Format("20060102-150405"), r.TaskID))
+	if err := os.WriteFile(base+".html", []byte(page), 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	if err := os.WriteFile(base+".md", []byte(r.Markdown()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return base + ".md", nil
+}
+
+// Latest returns the path of the newest Markdown report in dir.
+func Latest(dir string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", ErrNoReports
+	}
+	// Names start with the run's start time, so they sort by it
+	sort.Strings(paths)
+	return paths[len(paths)-1], nil
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/coverage"
+	"github.com/richgo/flo/pkg/verify"
+)
+
+func sampleReport(started time.Time) *Report {
+	return &Report{
+		RunID:    "run-1",
+		TaskID:   "t-001",
+		Title:    "Add <login>",
+		Started:  started,
+		Finished: started.Add(90 * time.Second),
+		Success:  true,
+		Attempts: []Attempt{
+			{Backend: "claude", Model: "opus", Duration: 30 * time.Second, Retries: 2, Error: "429 rate limit", Tokens: cost.Tokens{Input: 100}, Cost: 0.5},
+			{Backend: "copilot", Duration: 60 * time.Second, Retries: 1, Success: true, Tokens: cost.Tokens{Input: 200, Output: 50}, Cost: 0.25},
+		},
+		Coverage:     &coverage.Delta{TaskID: "t-001", Before: 70, After: 75, HasBefore: true},
+		Verification: &verify.Result{Passed: true, Steps: []verify.StepResult{{Name: "lint", Passed: true}}},
+		DiffStat:     " main.go | 4 ++--\n 1 file changed",
+	}
+}
+
+func TestReportTotals(t *testing.T) {
+	r := sampleReport(time.Now())
+	if r.Duration() != 90*time.Second {
+		t.Errorf("duration = %s, want 1m30s", r.Duration())
+	}
+	if r.Retries() != 3 || r.Tokens().Total() != 350 || r.Cost() != 0.75 {
+		t.Errorf("unexpected totals: %d retries, %d tokens, %v cost", r.Retries(), r.Tokens().Total(), r.Cost())
+	}
+}
+
+func TestReportRender(t *testing.T) {
+	r := sampleReport(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	md := r.Markdown()
+	for _, want := range []string{"# Run report: t-001", "Outcome: succeeded", "Retries: 3", "| claude | opus | 30s | 2 |", "Coverage: 70.0% → 75.0% (+5.0)", "Verify lint: passed", "main.go | 4"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, md)
+		}
+	}
+
+	page, err := r.HTML()
+	if err != nil {
+		t.Fatalf("HTML failed: %v", err)
+	}
+	if !strings.Contains(page, "Add &lt;login&gt;") {
+		t.Error("expected the title to be escaped")
+	}
+	if !strings.Contains(page, `<span class="succeeded">succeeded</span>`) {
+		t.Error("expected the outcome")
+	}
+}
+
+func TestWriteAndLatest(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	if _, err := Latest(dir); !errors.Is(err, ErrNoReports) {
+		t.Fatalf("expected ErrNoReports, got %v", err)
+	}
+
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	first, err := Write(dir, sampleReport(started))
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	second, err := Write(dir, sampleReport(started.Add(time.Hour)))
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if first == second {
+		t.Fatal("expected separate reports")
+	}
+	if _, err := os.Stat(strings.TrimSuffix(second, ".md") + ".html"); err != nil {
+		t.Errorf("expected an HTML report: %v", err)
+	}
+
+	latest, err := Latest(dir)
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if latest != second {
+		t.Errorf("latest = %s, want %s", latest, second)
+	}
+}
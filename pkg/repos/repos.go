@@ -0,0 +1,266 @@
+// Package repos clones, inspects and commits to the repositories tasks
+// run in: the workspace's own and those linked to a multi-repo workspace,
+// so each task can run in a checkout of the repository it targets.
+package repos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/richgo/flo/pkg/offline"
+	"github.com/richgo/flo/pkg/shell"
+)
+
+// validName matches repo names: they become directory names and task
+// fields, so keep them simple.
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidName reports whether name can name a repo.
+func ValidName(name string) bool {
+	return validName.MatchString(name)
+}
+
+// Status is the state of a checkout.
+type Status struct {
+	// Branch is the checked out branch, empty when HEAD is detached.
+	Branch string `json:"branch,omitempty"`
+	// Commit is the abbreviated HEAD commit.
+	Commit string `json:"commit,omitempty"`
+	// Upstream is the branch's upstream, when it has one.
+	Upstream string `json:"upstream,omitempty"`
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
+	// Changed counts modified, staged and untracked files.
+	Changed int `json:"changed"`
+}
+
+// Git runs git for repos.
+type Git struct {
+	runner shell.Runner
+}
+
+// New returns a Git that runs the git binary.
+func New() *Git {
+	return &Git{runner: shell.Exec}
+}
+
+// SetRunner replaces how git is run (for testing).
+func (g *Git) SetRunner(r shell.Runner) {
+	g.runner = r
+}
+
+// Cloned reports whether dir holds a git checkout.
+func Cloned(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// Clone clones url into dir, checking out branch when given.
+func (g *Git) Clone(ctx context.Context, url, branch, dir string) error {
+	if err := offline.Check("git clone"); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(dir), err)
+	}
+	argv := []string{"git", "clone"}
+	if branch != "" {
+		argv = append(argv, "--branch", branch)
+	}
+	argv = append(argv, "--", url, dir)
+	if _, err := g.runner(ctx, filepath.Dir(dir), argv); err != nil {
+		return fmt.Errorf("git clone %s failed: %w", url, err)
+	}
+	return nil
+}
+
+// Status reports the state of the checkout in dir.
+func (g *Git) Status(ctx context.Context, dir string) (Status, error) {
+	out, err := g.runner(ctx, dir, []string{"git", "status", "--porcelain=v2", "--branch"})
+	if err != nil {
+		return Status{}, fmt.Errorf("git status failed in %s: %w", dir, err)
+	}
+	return parseStatus(out), nil
+}
+
+// Diff returns the uncommitted changes in dir as a patch against HEAD,
+// with untracked files shown as added. Paths in exclude, relative to dir,
+// are left out.
+func (g *Git) Diff(ctx context.Context, dir string, exclude ...string) (string, error) {
+	pathspec := []string{"--", "."}
+	for _, path := range exclude {
+		pathspec = append(pathspec, ":(exclude)"+filepath.ToSlash(path))
+	}
+	out, err := g.runner(ctx, dir, append([]string{"git", "diff", "HEAD"}, pathspec...))
+	if err != nil {
+		return "", fmt.Errorf("git diff failed in %s: %w", dir, err)
+	}
+	untracked, err := g.runner(ctx, dir, append([]string{"git", "ls-files", "--others", "--exclude-standard"}, pathspec...))
+	if err != nil {
+		return "", fmt.Errorf("git ls-files failed in %s: %w", dir, err)
+	}
+	var b strings.Builder
+	b.Write(out)
+	for _, file := range strings.Split(string(untracked), "\n") {
+		if file == "" {
+			continue
+		}
+		// Exits 1 when the files differ, which they always do
+		patch, _ := g.runner(ctx, dir, []string{"git", "diff", "--no-index", "--", os.DevNull, file})
+		b.Write(patch)
+	}
+	return b.String(), nil
+}
+
+// Commit stages every change in dir and commits it with message. It does
+// nothing when there is nothing to commit.
+func (g *Git) Commit(ctx context.Context, dir, message string) error {
+	st, err := g.Status(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if st.Changed == 0 {
+		return nil
+	}
+	if _, err := g.runner(ctx, dir, []string{"git", "add", "-A"}); err != nil {
+		return fmt.Errorf("git add failed in %s: %w", dir, err)
+	}
+	if _, err := g.runner(ctx, dir, []string{"git", "commit", "-q", "-m", message}); err != nil {
+		return fmt.Errorf("git commit failed in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// Revert discards the uncommitted changes to files in dir, given relative
+// to it: files in HEAD are restored and new files are removed.
+func (g *Git) Revert(ctx context.Context, dir string, files []string) error {
+	for _, file := range files {
+		if !filepath.IsLocal(file) {
+			return fmt.Errorf("cannot revert %s: not inside %s", file, dir)
+		}
+		if _, err := g.runner(ctx, dir, []string{"git", "cat-file", "-e", "HEAD:" + filepath.ToSlash(file)}); err == nil {
+			if _, err := g.runner(ctx, dir, []string{"git", "checkout", "-q", "HEAD", "--", file}); err != nil {
+				return fmt.Errorf("git checkout failed in %s: %w", dir, err)
+			}
+			continue
+		}
+		if _, err := g.runner(ctx, dir, []string{"git", "rm", "-q", "--cached", "--ignore-unmatch", "--", file}); err != nil {
+			return fmt.Errorf("git rm failed in %s: %w", dir, err)
+		}
+		if err := os.Remove(filepath.Join(dir, file)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// FileDiff is the part of a patch that changes one file.
+type FileDiff struct {
+	Path  string `json:"path"`
+	Patch string `json:"patch"`
+}
+
+// SplitDiff splits a patch such as Diff returns into its files, in order.
+func SplitDiff(diff string) []FileDiff {
+	var files []FileDiff
+	var lines []string
+	flush := func() {
+		if len(lines) > 0 {
+			files = append(files, FileDiff{Path: diffPath(lines), Patch: strings.Join(lines, "\n") + "\n"})
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+		}
+		if line != "" || len(lines) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return files
+}
+
+// diffPath returns the file a file's patch changes: its new name, or its
+// old one when it is deleted.
+func diffPath(lines []string) string {
+	var oldPath, newPath string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+		if p, ok := strings.CutPrefix(line, "+++ "); ok && p != "/dev/null" {
+			newPath = strings.TrimPrefix(unquote(p), "b/")
+		}
+		if p, ok := strings.CutPrefix(line, "--- "); ok && p != "/dev/null" {
+			oldPath = strings.TrimPrefix(unquote(p), "a/")
+		}
+	}
+	switch {
+	case newPath != "":
+		return newPath
+	case oldPath != "":
+		return oldPath
+	}
+	// Binary and mode-only changes have no ---/+++ lines
+	header := strings.TrimPrefix(lines[0], "diff --git ")
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return unquote(header[i+3:])
+	}
+	return header
+}
+
+func unquote(path string) string {
+	if s, err := strconv.Unquote(path); err == nil {
+		return s
+	}
+	return path
+}
+
+// parseStatus reads git status --porcelain=v2 --branch output.
+func parseStatus(out []byte) Status {
+	var st Status
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			continue
+		}
+		header, ok := strings.CutPrefix(line, "# ")
+		if !ok {
+			st.Changed++
+			continue
+		}
+		key, value, _ := strings.Cut(header, " ")
+		switch key {
+		case "branch.oid":
+			if value != "(initial)" && len(value) >= 7 {
+				st.Commit = value[:7]
+			}
+		case "branch.head":
+			if value != "(detached)" {
+				st.Branch = value
+			}
+		case "branch.upstream":
+			st.Upstream = value
+		case "branch.ab":
+			// "+1 -2"
+			for _, f := range strings.Fields(value) {
+				n, err := strconv.Atoi(f[1:])
+				if err != nil {
+					continue
+				}
+				if f[0] == '+' {
+					st.Ahead = n
+				} else {
+					st.Behind = n
+				}
+			}
+		}
+	}
+	return st
+}
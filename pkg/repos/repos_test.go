@@ -0,0 +1,166 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	out := `# branch.oid 1a2b3c4d5e6f7a8b9c0d
+# branch.head main
+# branch.upstream origin/main
+# branch.ab +2 -1
+1 .M N... 100644 100644 100644 abc abc main.go
+? notes.txt
+`
+	want := Status{Branch: "main", Commit: "1a2b3c4", Upstream: "origin/main", Ahead: 2, Behind: 1, Changed: 2}
+	if got := parseStatus([]byte(out)); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	detached := parseStatus([]byte("# branch.oid (initial)\n# branch.head (detached)\n"))
+	if detached != (Status{}) {
+		t.Errorf("expected an empty status for a detached initial HEAD, got %+v", detached)
+	}
+}
+
+func TestClone(t *testing.T) {
+	var gotDir string
+	var gotArgv []string
+	g := New()
+	g.SetRunner(func(ctx context.Context, dir string, argv []string) ([]byte, error) {
+		gotDir, gotArgv = dir, argv
+		return nil, nil
+	})
+
+	dest := filepath.Join(t.TempDir(), "repos", "api")
+	if err := g.Clone(context.Background(), "git@example.com:org/api.git", "develop", dest); err != nil {
+		t.Fatal(err)
+	}
+	if gotDir != filepath.Dir(dest) {
+		t.Errorf("expected clone to run in %s, got %s", filepath.Dir(dest), gotDir)
+	}
+	want := []string{"git", "clone", "--branch", "develop", "--", "git@example.com:org/api.git", dest}
+	if !reflect.DeepEqual(gotArgv, want) {
+		t.Errorf("got %q, want %q", gotArgv, want)
+	}
+	if _, err := os.Stat(filepath.Dir(dest)); err != nil {
+		t.Errorf("expected the parent directory to be created: %v", err)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	var calls [][]string
+	status := "# branch.head main\n"
+	g := New()
+	g.SetRunner(func(ctx context.Context, dir string, argv []string) ([]byte, error) {
+		calls = append(calls, argv)
+		if argv[1] == "status" {
+			return []byte(status), nil
+		}
+		return nil, nil
+	})
+
+	if err := g.Commit(context.Background(), "/repo", "t-001: Add login"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected a clean tree not to be committed, got %q", calls)
+	}
+
+	calls = nil
+	status += "? login.go\n"
+	if err := g.Commit(context.Background(), "/repo", "t-001: Add login"); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"git", "status", "--porcelain=v2", "--branch"},
+		{"git", "add", "-A"},
+		{"git", "commit", "-q", "-m", "t-001: Add login"},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got %q, want %q", calls, want)
+	}
+}
+
+func TestSplitDiff(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-package old
++package main
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package main
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+	files := SplitDiff(diff)
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if want := []string{"main.go", "old.go", "logo.png"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("got %q, want %q", paths, want)
+	}
+	if files[0].Patch != "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-package old\n+package main\n" {
+		t.Errorf("unexpected patch %q", files[0].Patch)
+	}
+	if SplitDiff("") != nil {
+		t.Error("expected no files for an empty diff")
+	}
+}
+
+func TestRevert(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0644)
+	var calls [][]string
+	g := New()
+	g.SetRunner(func(ctx context.Context, dir string, argv []string) ([]byte, error) {
+		calls = append(calls, argv)
+		if argv[1] == "cat-file" && argv[3] != "HEAD:main.go" {
+			return nil, fmt.Errorf("exit status 128")
+		}
+		return nil, nil
+	})
+
+	if err := g.Revert(context.Background(), dir, []string{"main.go", "new.go"}); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"git", "cat-file", "-e", "HEAD:main.go"},
+		{"git", "checkout", "-q", "HEAD", "--", "main.go"},
+		{"git", "cat-file", "-e", "HEAD:new.go"},
+		{"git", "rm", "-q", "--cached", "--ignore-unmatch", "--", "new.go"},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got %q, want %q", calls, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.go")); !os.IsNotExist(err) {
+		t.Errorf("expected the new file to be removed, got %v", err)
+	}
+	if err := g.Revert(context.Background(), dir, []string{"../outside.go"}); err == nil {
+		t.Error("expected a path outside the repository to be refused")
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for _, name := range []string{"api", "web-app", "ios_2", "org.tools"} {
+		if !ValidName(name) {
+			t.Errorf("expected %q to be valid", name)
+		}
+	}
+	for _, name := range []string{"", "../x", "a/b", "-x", "a b"} {
+		if ValidName(name) {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Package sandbox runs agent backend CLIs inside a Docker or Podman
+// container, so commands the agent runs can only touch the worktree it was
+// given, within the configured network policy and CPU and memory limits.
+//
+// The sandbox is off unless a container runtime is configured. A nil
+// *Sandbox is valid and runs commands directly on the host.
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Supported container runtimes.
+const (
+	Docker = "docker"
+	Podman = "podman"
+)
+
+// DefaultEnv are the host environment variables passed into the
+// container when set: the backends' API keys.
+var DefaultEnv = []string{
+	"ANTHROPIC_API_KEY",
+	"CLAUDE_API_KEY",
+	"OPENAI_API_KEY",
+	"CODEX_API_KEY",
+	"GEMINI_API_KEY",
+	"GOOGLE_API_KEY",
+	"COPILOT_TOKEN",
+	"GITHUB_TOKEN",
+}
+
+// memoryLimit matches the sizes docker and podman accept, such as 512m
+// or 4g.
+var memoryLimit = regexp.MustCompile(`^[0-9]+[bkmgBKMG]?$`)
+
+// Config configures the sandbox, under sandbox in .flo/config.yaml.
+type Config struct {
+	// Runtime is docker or podman. Empty runs backends on the host.
+	Runtime string `yaml:"runtime,omitempty"`
+	// Image is the container image; it must provide the backend CLIs.
+	Image string `yaml:"image,omitempty"`
+	// Network is the container's network: none, bridge, host, or the
+	// name of a network such as one behind an egress proxy. Empty uses the
+	// runtime's default. Backends call their APIs from inside the
+	// container, so none only suits images that reach them another way.
+	Network string `yaml:"network,omitempty"`
+	// CPUs limits the CPUs the container may use, such as 2 or 1.5.
+	CPUs float64 `yaml:"cpus,omitempty"`
+	// Memory limits the container's memory, such as 4g.
+	Memory string `yaml:"memory,omitempty"`
+	// PidsLimit limits how many processes the container may run.
+	PidsLimit int `yaml:"pids_limit,omitempty"`
+	// Env names further host environment variables to pass in, beyond
+	// the API keys in DefaultEnv.
+	Env []string `yaml:"env,omitempty"`
+	// Mounts are further host paths mounted read-only at the same path,
+	// such as a shared package cache.
+	Mounts []string `yaml:"mounts,omitempty"`
+}
+
+// Enabled reports whether backends run in a container.
+func (c Config) Enabled() bool {
+	return c.Runtime != ""
+}
+
+// Validate reports settings the runtime would reject.
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Runtime != Docker && c.Runtime != Podman {
+		return fmt.Errorf("runtime must be docker or podman, got '%s'", c.Runtime)
+	}
+	if c.Image == "" {
+		return fmt.Errorf("image is required")
+	}
+	if c.CPUs < 0 || c.PidsLimit < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	if c.Memory != "" && !memoryLimit.MatchString(c.Memory) {
+		return fmt.Errorf("memory must be a size such as 512m or 4g, got '%s'", c.Memory)
+	}
+	for _, m := range c.Mounts {
+		if !filepath.IsAbs(m) {
+			return fmt.Errorf("mounts must be absolute paths, got '%s'", m)
+		}
+	}
+	return nil
+}
+
+// Hidden are the directories of the working directory the container
+// can't see: git's (a hook written there runs on the host) and flo's own,
+// whose config, keys and tokens would let the agent leave the sandbox.
+var Hidden = []string{".git", ".flo", ".eas"}
+
+// Sandbox builds container commands.
+type Sandbox struct {
+	config Config
+	// files are host paths mounted read-only besides the configured
+	// mounts, such as the MCP config the backend CLI reads.
+	files []string
+}
+
+// New returns a sandbox for config, or nil when it is disabled. files are
+// host paths mounted read-only into every container. Only the working
+// directory is writable: anything else the agent updates, such as the
+// workspace's tasks, goes through flo's MCP server on the host.
+func New(config Config, files ...string) *Sandbox {
+	if !config.Enabled() {
+		return nil
+	}
+	return &Sandbox{config: config, files: files}
+}
+
+// Runtime returns the container runtime.
+func (s *Sandbox) Runtime() string {
+	return s.config.Runtime
+}
+
+// Image returns the container image.
+func (s *Sandbox) Image() string {
+	return s.config.Image
+}
+
+// HostAddress returns the address a server on the host listens on for the
+// container to reach it, and the host name the container reaches it by.
+// ok is false when the container has no network.
+func (s *Sandbox) HostAddress() (listen, host string, ok bool) {
+	switch {
+	case s.config.Network == "none":
+		return "", "", false
+	case s.config.Network == "host":
+		return "127.0.0.1:0", "127.0.0.1", true
+	case s.config.Runtime == Podman:
+		return "0.0.0.0:0", "host.containers.internal", true
+	default:
+		return "0.0.0.0:0", "host.docker.internal", true
+	}
+}
+
+// Check fails when the container runtime isn't installed.
+func (s *Sandbox) Check() error {
+	if s == nil {
+		return nil
+	}
+	if _, err := exec.LookPath(s.config.Runtime); err != nil {
+		return fmt.Errorf("sandbox runtime %s not found: %w", s.config.Runtime, err)
+	}
+	return nil
+}
+
+// Command returns a command that runs argv in dir: in a fresh container
+// with dir mounted at the same path when the sandbox is enabled, otherwise
+// on the host. env are extra KEY=VALUE variables for the command; they are
+// passed into the container by name, so values stay out of the process
+// list.
+func (s *Sandbox) Command(ctx context.Context, dir string, argv []string, env []string) *exec.Cmd {
+	if s == nil {
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		cmd.Dir = dir
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
+		return cmd
+	}
+	args := s.Args(dir, argv, env)
+	cmd := exec.CommandContext(ctx, s.config.Runtime, args...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd
+}
+
+// Args returns the runtime arguments that run argv in a container.
+func (s *Sandbox) Args(dir string, argv []string, env []string) []string {
+	args := []string{"run", "--rm", "-i",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+	}
+	// Files the agent writes should belong to the user, not root
+	if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 {
+		if s.config.Runtime == Podman {
+			args = append(args, "--userns", "keep-id")
+		} else {
+			args = append(args, "--user", fmt.Sprintf("%d:%d", uid, gid))
+		}
+	}
+	if s.config.Network != "" {
+		args = append(args, "--network", s.config.Network)
+	}
+	if _, host, ok := s.HostAddress(); ok && host == "host.docker.internal" {
+		args = append(args, "--add-host", host+":host-gateway")
+	}
+	if s.config.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(s.config.CPUs, 'f', -1, 64))
+	}
+	if s.config.Memory != "" {
+		args = append(args, "--memory", s.config.Memory)
+	}
+	if s.config.PidsLimit > 0 {
+		args = append(args, "--pids-limit", strconv.Itoa(s.config.PidsLimit))
+	}
+
+	mounted := map[string]bool{}
+	mount := func(path, mode string) {
+		if path == "" || mounted[path] {
+			return
+		}
+		mounted[path] = true
+		args = append(args, "-v", path+":"+path+":"+mode)
+	}
+	if dir != "" {
+		mount(dir, "rw")
+		args = append(args, "-w", dir)
+		args = append(args, hide(dir)...)
+	}
+	for _, path := range s.files {
+		mount(path, "ro")
+	}
+	for _, path := range s.config.Mounts {
+		mount(path, "ro")
+		args = append(args, hide(path)...)
+	}
+
+	passed := map[string]bool{}
+	pass := func(name string) {
+		if name == "" || passed[name] {
+			return
+		}
+		passed[name] = true
+		args = append(args, "-e", name)
+	}
+	for _, name := range slices.Concat(DefaultEnv, s.config.Env) {
+		if _, ok := os.LookupEnv(name); ok {
+			pass(name)
+		}
+	}
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		pass(name)
+	}
+
+	args = append(args, s.config.Image)
+	return append(args, argv...)
+}
+
+// hide returns the arguments that cover the Hidden directories of dir: an
+// empty read-only tmpfs over a directory, and /dev/null over a file, such
+// as the .git file of a linked worktree.
+func hide(dir string) []string {
+	var args []string
+	for _, name := range Hidden {
+		path := filepath.Join(dir, name)
+		info, err := os.Lstat(path)
+		switch {
+		case err != nil:
+			continue
+		case info.IsDir():
+			args = append(args, "--tmpfs", path+":ro")
+		default:
+			args = append(args, "-v", os.DevNull+":"+path+":ro")
+		}
+	}
+	return args
+}
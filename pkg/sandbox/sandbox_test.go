@@ -0,0 +1,141 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestArgs(t *testing.T) {
+	t.Setenv("CLAUDE_API_KEY", "sk-test")
+	s := New(Config{
+		Runtime:   Docker,
+		Image:     "ghcr.io/org/agent:latest",
+		Network:   "none",
+		CPUs:      1.5,
+		Memory:    "4g",
+		PidsLimit: 256,
+		Mounts:    []string{"/opt/cache"},
+	}, "/tmp/flo-mcp-1.json")
+
+	args := s.Args("/work/ws/repo", []string{"claude", "--print", "hi"}, []string{"FLO_RUN_ID=run-1"})
+	got := strings.Join(args, " ")
+	for _, want := range []string{
+		"run --rm -i --cap-drop ALL --security-opt no-new-privileges",
+		"--network none --cpus 1.5 --memory 4g --pids-limit 256",
+		"-v /work/ws/repo:/work/ws/repo:rw -w /work/ws/repo",
+		"-v /tmp/flo-mcp-1.json:/tmp/flo-mcp-1.json:ro",
+		"-v /opt/cache:/opt/cache:ro",
+		"-e CLAUDE_API_KEY",
+		"-e FLO_RUN_ID ghcr.io/org/agent:latest claude --print hi",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+	if strings.Contains(got, "sk-test") || strings.Contains(got, "run-1") {
+		t.Errorf("expected environment values to stay off the command line: %q", got)
+	}
+	if uid := os.Getuid(); uid >= 0 && !slices.Contains(args, fmt.Sprintf("%d:%d", uid, os.Getgid())) {
+		t.Errorf("expected the container to run as the user: %q", got)
+	}
+
+	cmd := s.Command(context.Background(), "/work/ws/repo", []string{"claude"}, []string{"FLO_RUN_ID=run-1"})
+	if cmd.Args[0] != Docker || !slices.Contains(cmd.Env, "FLO_RUN_ID=run-1") {
+		t.Errorf("expected docker with the run ID in its environment, got %q", cmd.Args)
+	}
+}
+
+func TestArgsNeverMountsRepoRoot(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".git/hooks", ".flo/repos/app/.git", ".eas"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := filepath.Join(root, ".flo", "repos", "app")
+	s := New(Config{Runtime: Docker, Image: "agent"}, "/tmp/flo-mcp-1.json")
+
+	// A task in a linked repo sees only its checkout
+	args := s.Args(app, []string{"claude"}, nil)
+	for i, arg := range args {
+		if arg == "-v" && strings.HasPrefix(args[i+1], root+":") {
+			t.Errorf("expected the repository root never mounted, got %q", args)
+		}
+	}
+	if got := strings.Join(args, " "); !strings.Contains(got, "--tmpfs "+filepath.Join(app, ".git")+":ro") {
+		t.Errorf("expected the checkout's .git hidden, got %q", got)
+	}
+
+	// A task in the workspace itself can't reach git's or flo's files
+	got := strings.Join(s.Args(root, []string{"claude"}, nil), " ")
+	for _, name := range Hidden {
+		if !strings.Contains(got, "--tmpfs "+filepath.Join(root, name)+":ro") {
+			t.Errorf("expected %s hidden, got %q", name, got)
+		}
+	}
+	if strings.Count(got, ":rw") != 1 {
+		t.Errorf("expected only the working directory writable, got %q", got)
+	}
+}
+
+func TestHostAddress(t *testing.T) {
+	for _, tc := range []struct {
+		config Config
+		host   string
+		ok     bool
+	}{
+		{Config{Runtime: Docker, Image: "agent"}, "host.docker.internal", true},
+		{Config{Runtime: Podman, Image: "agent"}, "host.containers.internal", true},
+		{Config{Runtime: Docker, Image: "agent", Network: "host"}, "127.0.0.1", true},
+		{Config{Runtime: Docker, Image: "agent", Network: "none"}, "", false},
+	} {
+		if _, host, ok := New(tc.config).HostAddress(); host != tc.host || ok != tc.ok {
+			t.Errorf("%+v: expected %s %v, got %s %v", tc.config, tc.host, tc.ok, host, ok)
+		}
+	}
+}
+
+func TestPodmanKeepsUserNamespace(t *testing.T) {
+	s := New(Config{Runtime: Podman, Image: "agent"})
+	args := strings.Join(s.Args("/src", []string{"codex"}, nil), " ")
+	if os.Getuid() >= 0 && !strings.Contains(args, "--userns keep-id") {
+		t.Errorf("expected podman to keep the user's ID, got %q", args)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	s := New(Config{})
+	if s != nil {
+		t.Fatal("expected no sandbox without a runtime")
+	}
+	if err := s.Check(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	cmd := s.Command(context.Background(), "/src", []string{"claude", "--print"}, nil)
+	if cmd.Args[0] != "claude" || cmd.Dir != "/src" || cmd.Env != nil {
+		t.Errorf("expected claude to run on the host, got %q in %s", cmd.Args, cmd.Dir)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Config{Runtime: Docker, Image: "agent", CPUs: 2, Memory: "512m"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, c := range []Config{
+		{Runtime: "lxc", Image: "agent"},
+		{Runtime: Docker},
+		{Runtime: Docker, Image: "agent", CPUs: -1},
+		{Runtime: Docker, Image: "agent", Memory: "lots"},
+		{Runtime: Podman, Image: "agent", Mounts: []string{"cache"}},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", c)
+		}
+	}
+}
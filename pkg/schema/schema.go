@@ -0,0 +1,257 @@
+// Package schema generates JSON Schemas for the files users edit by hand in
+// a workspace, so editors can offer completion and validation. Schemas are
+// derived from the Go types flo decodes the files into, so they can't drift
+// from what flo actually accepts.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/jsoncompat"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/tools"
+)
+
+// Draft is the JSON Schema dialect generated schemas declare.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// ReleaseURL is where released schemas are published; a schema's $id is
+// its file name under it.
+const ReleaseURL = "https://github.com/richgo/flo/releases/latest/download/"
+
+// Schemer is implemented by types whose encoding differs from their Go
+// structure, such as a value that accepts either a number or an object.
+type Schemer interface {
+	JSONSchema() map[string]any
+}
+
+// Document is a kind of workspace file with a schema.
+type Document struct {
+	// Name identifies the schema, as in "flo schema dump config".
+	Name string
+	// Title and Description are shown by editors.
+	Title       string
+	Description string
+	// Files are the workspace paths the schema applies to.
+	Files []string
+	// Tag is the struct tag field names are read from: "yaml" or "json".
+	Tag string
+	// Type is the Go type the file decodes into.
+	Type reflect.Type
+}
+
+// FileName returns the file the schema is written to.
+func (d Document) FileName() string {
+	return d.Name + ".schema.json"
+}
+
+// manifest mirrors the task registry's on-disk format.
+type manifest struct {
+	Schema  int          `json:"schema,omitempty"`
+	Version int          `json:"version"`
+	Tasks   []*task.Task `json:"tasks"`
+
+	extra jsoncompat.Fields
+}
+
+// Documents lists every workspace file with a schema.
+func Documents() []Document {
+	return []Document{
+		{
+			Name:        "config",
+			Title:       "flo workspace config",
+			Description: "Workspace configuration in .flo/config.yaml.",
+			Files:       []string{".flo/config.yaml"},
+			Tag:         "yaml",
+			Type:        reflect.TypeOf(config.Config{}),
+		},
+		{
+			Name:        "manifest",
+			Title:       "flo task manifest",
+			Description: "The task registry in .flo/tasks/manifest.json.",
+			Files:       []string{".flo/tasks/manifest.json"},
+			Tag:         "json",
+			Type:        reflect.TypeOf(manifest{}),
+		},
+		{
+			Name:        "task",
+			Title:       "flo task frontmatter",
+			Description: "YAML frontmatter of a task.md file; the title and description may come from the body instead.",
+			Files:       []string{"task.md"},
+			Tag:         "yaml",
+			Type:        reflect.TypeOf(task.Task{}),
+		},
+		{
+			Name:        "tool",
+			Title:       "flo custom tool",
+			Description: "A custom tool that wraps a shell command, in .flo/tools/*.yaml.",
+			Files:       []string{".flo/tools/*.yaml"},
+			Tag:         "yaml",
+			Type:        reflect.TypeOf(tools.CustomToolSpec{}),
+		},
+	}
+}
+
+// Lookup returns the document named name.
+func Lookup(name string) (Document, error) {
+	for _, d := range Documents() {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return Document{}, fmt.Errorf("unknown schema '%s' (one of: %s)", name, strings.Join(Names(), ", "))
+}
+
+// Generate returns the document's schema.
+func (d Document) Generate() map[string]any {
+	g := &generator{tag: d.Tag, defs: map[string]any{}, names: map[reflect.Type]string{}}
+	root := g.object(d.Type)
+	root["$schema"] = Draft
+	root["$id"] = ReleaseURL + d.FileName()
+	root["title"] = d.Title
+	root["description"] = d.Description
+	if len(g.defs) > 0 {
+		root["$defs"] = g.defs
+	}
+	return root
+}
+
+// JSON returns the document's schema, indented, with a trailing newline.
+func (d Document) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(d.Generate(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s schema: %w", d.Name, err)
+	}
+	return append(data, '\n'), nil
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	schemerType  = reflect.TypeOf((*Schemer)(nil)).Elem()
+	extraType    = reflect.TypeOf(jsoncompat.Fields{})
+)
+
+// generator builds a schema from Go types, putting nested structs in $defs.
+type generator struct {
+	tag   string
+	defs  map[string]any
+	names map[reflect.Type]string
+}
+
+// schema returns the schema for a value of type t.
+func (g *generator) schema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Ptr {
+		return g.schema(t.Elem())
+	}
+	if t.Implements(schemerType) {
+		return reflect.Zero(t).Interface().(Schemer).JSONSchema()
+	}
+	switch t {
+	case durationType:
+		return map[string]any{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+			"description": "A duration such as 30s, 5m or 1h30m."}
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]any{"type": "object"}
+		}
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		return map[string]any{"$ref": "#/$defs/" + g.define(t)}
+	}
+	// Interfaces and anything else accept any value
+	return map[string]any{}
+}
+
+// define adds a struct type to $defs and returns its name there.
+func (g *generator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for _, other := range g.names {
+		if other == name {
+			name = t.String()
+			break
+		}
+	}
+	// Registered before generating so recursive types terminate
+	g.names[t] = name
+	g.defs[name] = g.object(t)
+	return name
+}
+
+// object returns the schema for a struct type.
+func (g *generator) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	open := false
+	g.fields(t, props, &open)
+
+	obj := map[string]any{"type": "object", "properties": props}
+	if !open {
+		obj["additionalProperties"] = false
+	}
+	return obj
+}
+
+// fields adds the properties of struct t to props. A struct that keeps
+// fields it doesn't know (for forward compatibility) sets open.
+func (g *generator) fields(t reflect.Type, props map[string]any, open *bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type == extraType {
+			*open = true
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get(g.tag), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") || (f.Anonymous && name == "") {
+			g.fields(f.Type, props, open)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+			if g.tag == "yaml" {
+				name = strings.ToLower(name)
+			}
+		}
+		props[name] = g.schema(f.Type)
+	}
+}
+
+// Names returns the names of all documents, sorted.
+func Names() []string {
+	var names []string
+	for _, d := range Documents() {
+		names = append(names, d.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// def resolves a $ref in a generated schema.
+func def(t *testing.T, root map[string]any, s any) map[string]any {
+	t.Helper()
+	m := s.(map[string]any)
+	ref, ok := m["$ref"].(string)
+	if !ok {
+		return m
+	}
+	defs := root["$defs"].(map[string]any)
+	name := ref[len("#/$defs/"):]
+	d, ok := defs[name].(map[string]any)
+	if !ok {
+		t.Fatalf("missing definition %s", ref)
+	}
+	return d
+}
+
+func TestConfigSchema(t *testing.T) {
+	d, err := Lookup("config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := d.Generate()
+	if root["$schema"] != Draft || root["$id"] != ReleaseURL+"config.schema.json" {
+		t.Errorf("unexpected header: %v %v", root["$schema"], root["$id"])
+	}
+	if root["additionalProperties"] != false {
+		t.Error("expected unknown config keys to be rejected")
+	}
+	props := root["properties"].(map[string]any)
+	for _, key := range []string{"feature", "backend", "tdd", "taskTypes", "pricing", "quota"} {
+		if _, ok := props[key]; !ok {
+			t.Errorf("missing property %s", key)
+		}
+	}
+
+	tdd := def(t, root, props["tdd"])
+	if got := tdd["properties"].(map[string]any)["coverage_threshold"].(map[string]any)["type"]; got != "integer" {
+		t.Errorf("expected integer coverage_threshold, got %v", got)
+	}
+	mcp := def(t, root, props["mcp"])
+	if got := mcp["properties"].(map[string]any)["timeout"].(map[string]any)["type"]; got != "string" {
+		t.Errorf("expected durations as strings, got %v", got)
+	}
+	// Prices are a number or an object, as cost.Price decodes them
+	price := props["pricing"].(map[string]any)["additionalProperties"].(map[string]any)
+	if _, ok := price["oneOf"]; !ok {
+		t.Errorf("expected the price schema from cost.Price, got %v", price)
+	}
+}
+
+func TestManifestSchemaKeepsUnknownFields(t *testing.T) {
+	d, err := Lookup("manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := d.Generate()
+	if _, ok := root["additionalProperties"]; ok {
+		t.Error("expected the manifest to allow fields from newer versions")
+	}
+	items := root["properties"].(map[string]any)["tasks"].(map[string]any)["items"]
+	task := def(t, root, items)
+	props := task["properties"].(map[string]any)
+	if _, ok := props["spec_ref"]; !ok {
+		t.Error("expected task fields under their JSON names")
+	}
+	if got := props["status"].(map[string]any)["enum"]; got == nil {
+		t.Error("expected the status to be an enum")
+	}
+	if got := props["created_at"].(map[string]any)["format"]; got != "date-time" {
+		t.Errorf("expected timestamps as date-time, got %v", got)
+	}
+}
+
+func TestEveryDocumentEncodes(t *testing.T) {
+	for _, d := range Documents() {
+		data, err := d.JSON()
+		if err != nil {
+			t.Fatalf("%s: %v", d.Name, err)
+		}
+		var decoded map[string]any
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Errorf("%s: invalid JSON: %v", d.Name, err)
+		}
+	}
+	if _, err := Lookup("policy"); err == nil {
+		t.Error("expected an unknown schema to be an error")
+	}
+}
@@ -0,0 +1,158 @@
+// Package seal provides authenticated encryption of workspace data at rest.
+//
+// Data is sealed with AES-256-GCM using a per-workspace key. The key lives in
+// a file under .flo/ (which should not be committed) or can be supplied via
+// the FLO_WORKSPACE_KEY environment variable as base64.
+package seal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeySize is the size of a workspace key in bytes.
+const KeySize = 32
+
+// KeyEnv is the environment variable that can supply the workspace key.
+const KeyEnv = "FLO_WORKSPACE_KEY"
+
+// ErrNoKey is returned when sealed data is read without a key available.
+var ErrNoKey = errors.New("workspace key not available")
+
+// Key is a symmetric workspace key.
+type Key []byte
+
+// GenerateKey creates a new random key.
+func GenerateKey() (Key, error) {
+	key := make(Key, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+// String returns the base64 encoding of the key.
+func (k Key) String() string {
+	return base64.StdEncoding.EncodeToString(k)
+}
+
+// ParseKey decodes a base64-encoded key.
+func ParseKey(s string) (Key, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key length: expected %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// LoadKey reads the workspace key from the environment or the key file.
+// Returns ErrNoKey if neither is present.
+func LoadKey(path string) (Key, error) {
+	if env := os.Getenv(KeyEnv); env != "" {
+		return ParseKey(env)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoKey
+		}
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	return ParseKey(string(data))
+}
+
+// LoadOrCreateKey loads the workspace key, generating and saving a new one
+// with owner-only permissions if none exists.
+func LoadOrCreateKey(path string) (Key, error) {
+	key, err := LoadKey(path)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, ErrNoKey) {
+		return nil, err
+	}
+
+	key, err = GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(key.String()+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext with the key. The nonce is prepended to the output.
+func Seal(key Key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts data produced by Seal.
+func Open(key Key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed data too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// SealString encrypts plaintext and returns it base64-encoded.
+func SealString(key Key, plaintext []byte) (string, error) {
+	sealed, err := Seal(key, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenString decodes and decrypts a string produced by SealString.
+func OpenString(key Key, s string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sealed data encoding: %w", err)
+	}
+	return Open(key, sealed)
+}
+
+func newGCM(key Key) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key length: expected %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,100 @@
+package seal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	sealed, err := SealString(key, []byte("proprietary code"))
+	if err != nil {
+		t.Fatalf("SealString failed: %v", err)
+	}
+
+	plain, err := OpenString(key, sealed)
+	if err != nil {
+		t.Fatalf("OpenString failed: %v", err)
+	}
+	if string(plain) != "proprietary code" {
+		t.Errorf("expected round trip, got %q", plain)
+	}
+}
+
+func TestOpenWithWrongKey(t *testing.T) {
+	key1, _ := GenerateKey()
+	key2, _ := GenerateKey()
+
+	sealed, _ := Seal(key1, []byte("secret"))
+	if _, err := Open(key2, sealed); err == nil {
+		t.Error("expected decryption with wrong key to fail")
+	}
+}
+
+func TestOpenTamperedData(t *testing.T) {
+	key, _ := GenerateKey()
+	sealed, _ := Seal(key, []byte("secret"))
+	sealed[len(sealed)-1] ^= 0xff
+
+	if _, err := Open(key, sealed); err == nil {
+		t.Error("expected tampered data to fail authentication")
+	}
+}
+
+func TestLoadOrCreateKey(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	path := filepath.Join(t.TempDir(), "keys", "workspace.key")
+
+	if _, err := LoadKey(path); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("expected ErrNoKey, got %v", err)
+	}
+
+	key, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatalf("LoadOrCreateKey failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("key file not created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	again, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatalf("second LoadOrCreateKey failed: %v", err)
+	}
+	if again.String() != key.String() {
+		t.Error("expected existing key to be reused")
+	}
+}
+
+func TestLoadKeyFromEnv(t *testing.T) {
+	key, _ := GenerateKey()
+	t.Setenv(KeyEnv, key.String())
+
+	loaded, err := LoadKey(filepath.Join(t.TempDir(), "missing.key"))
+	if err != nil {
+		t.Fatalf("LoadKey failed: %v", err)
+	}
+	if loaded.String() != key.String() {
+		t.Error("expected key from environment")
+	}
+}
+
+func TestParseKeyInvalid(t *testing.T) {
+	if _, err := ParseKey("not base64!"); err == nil {
+		t.Error("expected error for invalid encoding")
+	}
+	if _, err := ParseKey("c2hvcnQ="); err == nil {
+		t.Error("expected error for short key")
+	}
+}
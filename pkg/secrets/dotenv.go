@@ -0,0 +1,213 @@
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// envVar is a variable defined by a .env file.
+type envVar struct {
+	key, value string
+}
+
+// parseEnv parses a .env file with the usual dotenv semantics:
+//
+//   - KEY=VALUE lines, optionally prefixed with "export "
+//   - # comments, on their own line or after an unquoted value
+//   - 'single-quoted' values, taken literally
+//   - "double-quoted" values, with \n, \r, \t, \", \\ and \$ escapes
+//   - quoted values spanning several lines
+//   - $VAR, ${VAR}, ${VAR:-default} and ${VAR-default} in unquoted and
+//     double-quoted values, expanded from lookup or earlier lines of the
+//     file; ":-" uses the default when the variable is unset or empty,
+//     "-" only when it is unset
+//
+// A line with just a name defines nothing, leaving the variable to the
+// environment.
+func parseEnv(src string, lookup func(string) (string, bool)) ([]envVar, error) {
+	defined := make(map[string]string)
+	resolve := func(name string) (string, bool) {
+		v, set := lookup(name)
+		if v != "" {
+			return v, true
+		}
+		if d, ok := defined[name]; ok {
+			return d, true
+		}
+		return "", set
+	}
+
+	var vars []envVar
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line, _ = cutExport(line)
+
+		key, rest, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !secretNamePattern.MatchString(key) {
+			if !ok {
+				return nil, fmt.Errorf("invalid format at line %d: expected KEY=VALUE", lineNum)
+			}
+			return nil, fmt.Errorf("invalid variable name '%s' at line %d", key, lineNum)
+		}
+		if !ok {
+			continue
+		}
+
+		rest = strings.TrimLeft(rest, " \t")
+		var value string
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			quote := rest[0]
+			body := rest[1:]
+			for {
+				if end := closingQuote(body, quote); end >= 0 {
+					if tail := strings.TrimSpace(body[end+1:]); tail != "" && !strings.HasPrefix(tail, "#") {
+						return nil, fmt.Errorf("unexpected text after the quoted value of %s at line %d", key, lineNum)
+					}
+					body = body[:end]
+					break
+				}
+				i++
+				if i >= len(lines) {
+					return nil, fmt.Errorf("unterminated quoted value for %s at line %d", key, lineNum)
+				}
+				body += "\n" + lines[i]
+			}
+			if quote == '\'' {
+				value = body
+			} else {
+				value = expandEnv(body, true, resolve)
+			}
+		} else {
+			if j := inlineComment(rest); j >= 0 {
+				rest = rest[:j]
+			}
+			value = expandEnv(strings.TrimSpace(rest), false, resolve)
+		}
+
+		defined[key] = value
+		vars = append(vars, envVar{key, value})
+	}
+	return vars, nil
+}
+
+// closingQuote returns the index of the quote ending a value in s, skipping
+// escaped double quotes, or -1.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// inlineComment returns where a # comment starts in an unquoted value, or
+// -1. The # must follow whitespace, so values like "a#b" are kept.
+func inlineComment(s string) int {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '#' && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return i
+		}
+	}
+	return -1
+}
+
+// expandEnv expands variable references in s, resolving each to its value
+// and whether it is set. Escapes other than \$ are
+// only interpreted in double-quoted values.
+func expandEnv(s string, quoted bool, resolve func(string) (string, bool)) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			next := s[i+1]
+			if next == '$' {
+				b.WriteByte('$')
+				i++
+				continue
+			}
+			if quoted {
+				if r, ok := map[byte]string{'n': "\n", 'r': "\r", 't': "\t", '"': `"`, '\\': `\`}[next]; ok {
+					b.WriteString(r)
+					i++
+					continue
+				}
+			}
+		}
+		if c == '$' {
+			if value, end, ok := expandRef(s, i, quoted, resolve); ok {
+				b.WriteString(value)
+				i = end
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// expandRef expands the reference starting with the $ at s[i], returning
+// its value and the index of its last byte.
+func expandRef(s string, i int, quoted bool, resolve func(string) (string, bool)) (string, int, bool) {
+	if i+1 >= len(s) {
+		return "", 0, false
+	}
+	if s[i+1] == '{' {
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			return "", 0, false
+		}
+		end += i + 2
+		inner := s[i+2 : end]
+		// ${VAR:-default} defaults when VAR is unset or empty,
+		// ${VAR-default} only when it is unset
+		name, def, hasDefault, orEmpty := inner, "", false, false
+		if n, d, ok := strings.Cut(inner, ":-"); ok {
+			name, def, hasDefault, orEmpty = n, d, true, true
+		} else if n, d, ok := strings.Cut(inner, "-"); ok {
+			name, def, hasDefault = n, d, true
+		}
+		if !secretNamePattern.MatchString(name) {
+			return "", 0, false
+		}
+		value, set := resolve(name)
+		if hasDefault && (!set || orEmpty && value == "") {
+			value = expandEnv(def, quoted, resolve)
+		}
+		return value, end, true
+	}
+
+	end := i + 1
+	for end < len(s) && (s[end] == '_' || isAlnum(s[end])) {
+		end++
+	}
+	name := s[i+1 : end]
+	if !secretNamePattern.MatchString(name) {
+		return "", 0, false
+	}
+	value, _ := resolve(name)
+	return value, end - 1, true
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// cutExport returns line, trimmed of spaces, without an "export " prefix,
+// and whether it had one.
+func cutExport(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if rest, ok := strings.CutPrefix(line, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+		return strings.TrimSpace(rest), true
+	}
+	return line, false
+}
@@ -0,0 +1,353 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// EncryptedFile is the workspace's encrypted secrets store. Unlike .env it
+// is safe to keep next to committed files: only holders of the passphrase
+// or an age identity can read it.
+var EncryptedFile = filepath.Join(".flo", "secrets")
+
+// Environment variables holding the key to the encrypted secrets file.
+const (
+	PassphraseEnv  = "FLO_SECRETS_PASSPHRASE"
+	AgeIdentityEnv = "FLO_AGE_IDENTITY"
+)
+
+// Encryption schemes of the secrets file.
+const (
+	SchemePassphrase = "passphrase"
+	SchemeAge        = "age"
+)
+
+const (
+	encryptedVersion = 1
+	// pbkdf2Iterations follows OWASP's recommendation for PBKDF2-SHA256.
+	pbkdf2Iterations = 600000
+)
+
+// ErrLocked is returned when the encrypted secrets file exists but no key
+// to open it is available.
+var ErrLocked = errors.New("encrypted secrets file is locked")
+
+// Key unlocks the encrypted secrets file: a passphrase, or the path of an
+// age identity file.
+type Key struct {
+	Passphrase string
+	Identity   string
+}
+
+// KeyFromEnv returns the key set in the environment.
+func KeyFromEnv() Key {
+	return Key{Passphrase: os.Getenv(PassphraseEnv), Identity: os.Getenv(AgeIdentityEnv)}
+}
+
+// encryptedHeader is the on-disk form of the secrets file. Data is the
+// encrypted JSON object of secrets.
+type encryptedHeader struct {
+	Version    int      `json:"version"`
+	Scheme     string   `json:"scheme"`
+	Recipients []string `json:"recipients,omitempty"`
+	Iterations int      `json:"iterations,omitempty"`
+	Salt       []byte   `json:"salt,omitempty"`
+	Nonce      []byte   `json:"nonce,omitempty"`
+	Data       []byte   `json:"data"`
+}
+
+// EncryptedStore is an opened encrypted secrets file.
+type EncryptedStore struct {
+	path   string
+	header encryptedHeader
+	key    Key
+	runner Runner
+	values map[string]string
+}
+
+// InitEncrypted creates an empty secrets file at path, encrypted with the
+// passphrase in key, or for the age recipients when any are given. runner
+// runs the age CLI; nil runs the installed one.
+func InitEncrypted(path string, recipients []string, key Key, runner Runner) (*EncryptedStore, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%s already exists", path)
+	}
+	if runner == nil {
+		runner = runCommand
+	}
+	s := &EncryptedStore{
+		path:   path,
+		header: encryptedHeader{Version: encryptedVersion, Scheme: SchemePassphrase},
+		key:    key,
+		runner: runner,
+		values: make(map[string]string),
+	}
+	if len(recipients) > 0 {
+		s.header.Scheme = SchemeAge
+		s.header.Recipients = recipients
+	} else if key.Passphrase == "" {
+		return nil, fmt.Errorf("a passphrase is required")
+	}
+	return s, s.Save()
+}
+
+// readHeader reads the secrets file at path without decrypting it.
+func readHeader(path string) (encryptedHeader, error) {
+	var h encryptedHeader
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return h, fmt.Errorf("invalid secrets file %s: %w", path, err)
+	}
+	if h.Version > encryptedVersion {
+		return h, fmt.Errorf("secrets file %s has version %d; upgrade flo", path, h.Version)
+	}
+	return h, nil
+}
+
+// EncryptedScheme returns how the secrets file at path is encrypted.
+func EncryptedScheme(path string) (string, error) {
+	h, err := readHeader(path)
+	return h.Scheme, err
+}
+
+// OpenEncrypted decrypts the secrets file at path. It returns ErrLocked
+// when key can't open the file's scheme. runner runs the age CLI; nil runs
+// the installed one.
+func OpenEncrypted(path string, key Key, runner Runner) (*EncryptedStore, error) {
+	h, err := readHeader(path)
+	if err != nil {
+		return nil, err
+	}
+	if runner == nil {
+		runner = runCommand
+	}
+	s := &EncryptedStore{path: path, header: h, key: key, runner: runner}
+
+	var plain []byte
+	switch s.header.Scheme {
+	case SchemePassphrase:
+		if key.Passphrase == "" {
+			return nil, fmt.Errorf("%w (set %s)", ErrLocked, PassphraseEnv)
+		}
+		aead, err := passphraseAEAD(key.Passphrase, s.header.Salt, s.header.Iterations)
+		if err != nil {
+			return nil, err
+		}
+		if plain, err = aead.Open(nil, s.header.Nonce, s.header.Data, []byte(SchemePassphrase)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: wrong passphrase or corrupted file", path)
+		}
+	case SchemeAge:
+		if key.Identity == "" {
+			return nil, fmt.Errorf("%w (set %s to an age identity file)", ErrLocked, AgeIdentityEnv)
+		}
+		if plain, err = s.age([]string{"age", "--decrypt", "--identity", key.Identity}, s.header.Data); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("secrets file %s has unknown scheme '%s'", path, s.header.Scheme)
+	}
+
+	if err := json.Unmarshal(plain, &s.values); err != nil {
+		return nil, fmt.Errorf("invalid secrets in %s: %w", path, err)
+	}
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	return s, nil
+}
+
+// Scheme returns how the file is encrypted.
+func (s *EncryptedStore) Scheme() string { return s.header.Scheme }
+
+// Names returns the names of the stored secrets, sorted.
+func (s *EncryptedStore) Names() []string {
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns a stored secret, or ErrNotFound.
+func (s *EncryptedStore) Get(name string) (string, error) {
+	value, ok := s.values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Set stores a secret; call Save to write it.
+func (s *EncryptedStore) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	s.values[name] = value
+	return nil
+}
+
+// Delete removes a secret, or returns ErrNotFound; call Save to write it.
+func (s *EncryptedStore) Delete(name string) error {
+	if _, ok := s.values[name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.values, name)
+	return nil
+}
+
+// Save re-encrypts the secrets, with a fresh salt and nonce, and writes
+// the file atomically.
+func (s *EncryptedStore) Save() error {
+	plain, err := json.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+
+	h := &s.header
+	switch h.Scheme {
+	case SchemePassphrase:
+		h.Iterations = pbkdf2Iterations
+		h.Salt = make([]byte, 16)
+		if _, err := rand.Read(h.Salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+		aead, err := passphraseAEAD(s.key.Passphrase, h.Salt, h.Iterations)
+		if err != nil {
+			return err
+		}
+		h.Nonce = make([]byte, aead.NonceSize())
+		if _, err := rand.Read(h.Nonce); err != nil {
+			return fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		h.Data = aead.Seal(nil, h.Nonce, plain, []byte(SchemePassphrase))
+	case SchemeAge:
+		argv := []string{"age", "--encrypt"}
+		for _, r := range h.Recipients {
+			argv = append(argv, "--recipient", r)
+		}
+		if h.Data, err = s.age(argv, plain); err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", s.path, err)
+		}
+	}
+
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return nil
+}
+
+// age runs the age CLI with input on stdin.
+func (s *EncryptedStore) age(argv []string, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyringTimeout)
+	defer cancel()
+	out, code, err := s.runner(ctx, argv, string(input))
+	if errors.Is(err, ErrKeyringUnavailable) {
+		return nil, fmt.Errorf("age is not installed")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("age exited %d", code)
+	}
+	return out, nil
+}
+
+// passphraseAEAD derives an AES-256-GCM cipher from a passphrase.
+func passphraseAEAD(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if len(salt) == 0 || iterations <= 0 {
+		return nil, fmt.Errorf("secrets file is missing its key derivation parameters")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptedProvider resolves secrets from the encrypted file at path,
+// decrypting it the first time it's needed. A missing file holds no
+// secrets; a file key can't open reports ErrLocked.
+func EncryptedProvider(path string, key Key, runner Runner) Provider {
+	return &encryptedProvider{path: path, key: key, runner: runner}
+}
+
+type encryptedProvider struct {
+	path   string
+	key    Key
+	runner Runner
+	store  *EncryptedStore
+}
+
+func (p *encryptedProvider) Name() string { return "encrypted file" }
+
+func (p *encryptedProvider) open() (*EncryptedStore, error) {
+	if p.store != nil {
+		return p.store, nil
+	}
+	if _, err := os.Stat(p.path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	store, err := OpenEncrypted(p.path, p.key, p.runner)
+	if err != nil {
+		return nil, err
+	}
+	p.store = store
+	return store, nil
+}
+
+func (p *encryptedProvider) Names() ([]string, error) {
+	store, err := p.open()
+	if store == nil {
+		return nil, err
+	}
+	return store.Names(), nil
+}
+
+func (p *encryptedProvider) Get(ctx context.Context, name string) (string, error) {
+	store, err := p.open()
+	if err != nil {
+		return "", err
+	}
+	if store == nil {
+		return "", ErrNotFound
+	}
+	return store.Get(name)
+}
+
+// IsLocked reports whether err means a secrets store couldn't be opened
+// for want of a key: a missing keyring or an encrypted file without its
+// passphrase or identity.
+func IsLocked(err error) bool {
+	return errors.Is(err, ErrKeyringUnavailable) || errors.Is(err, ErrLocked)
+}
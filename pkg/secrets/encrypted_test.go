@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets")
+	key := Key{Passphrase: "correct horse"}
+	store, err := InitEncrypted(path, nil, key, nil)
+	if err != nil {
+		t.Fatalf("InitEncrypted failed: %v", err)
+	}
+	store.Set("CLAUDE_API_KEY", "sk-ant-secret")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "sk-ant-secret") {
+		t.Error("expected the secret to be encrypted on disk")
+	}
+	if _, err := InitEncrypted(path, nil, key, nil); err == nil {
+		t.Error("expected init to refuse an existing file")
+	}
+
+	opened, err := OpenEncrypted(path, key, nil)
+	if err != nil {
+		t.Fatalf("OpenEncrypted failed: %v", err)
+	}
+	if v, err := opened.Get("CLAUDE_API_KEY"); err != nil || v != "sk-ant-secret" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	if _, err := OpenEncrypted(path, Key{Passphrase: "wrong"}, nil); err == nil || errors.Is(err, ErrLocked) {
+		t.Errorf("expected a wrong passphrase to fail, got %v", err)
+	}
+	if _, err := OpenEncrypted(path, Key{}, nil); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked without a passphrase, got %v", err)
+	}
+}
+
+func TestEncryptedAge(t *testing.T) {
+	var calls []string
+	// A reversible stand-in for age
+	fake := func(ctx context.Context, argv []string, stdin string) ([]byte, int, error) {
+		calls = append(calls, strings.Join(argv, " "))
+		if argv[1] == "--encrypt" {
+			return []byte("AGE:" + stdin), 0, nil
+		}
+		return []byte(strings.TrimPrefix(stdin, "AGE:")), 0, nil
+	}
+
+	path := filepath.Join(t.TempDir(), "secrets")
+	store, err := InitEncrypted(path, []string{"age1abc"}, Key{}, fake)
+	if err != nil {
+		t.Fatalf("InitEncrypted failed: %v", err)
+	}
+	store.Set("COPILOT_TOKEN", "ghp-1")
+	store.Save()
+	if calls[0] != "age --encrypt --recipient age1abc" {
+		t.Errorf("unexpected age call: %s", calls[0])
+	}
+
+	if _, err := OpenEncrypted(path, Key{Passphrase: "x"}, fake); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked without an identity, got %v", err)
+	}
+	opened, err := OpenEncrypted(path, Key{Identity: "/keys/age.txt"}, fake)
+	if err != nil {
+		t.Fatalf("OpenEncrypted failed: %v", err)
+	}
+	if v, _ := opened.Get("COPILOT_TOKEN"); v != "ghp-1" {
+		t.Errorf("Get = %q", v)
+	}
+	if scheme, _ := EncryptedScheme(path); scheme != SchemeAge {
+		t.Errorf("EncryptedScheme = %q", scheme)
+	}
+}
+
+func TestManagerLoadsEncryptedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets")
+	store, _ := InitEncrypted(path, nil, Key{Passphrase: "pw"}, nil)
+	store.Set("FLO_TEST_ENCRYPTED_KEY", "from-encrypted-file")
+	store.Save()
+	defer os.Unsetenv("FLO_TEST_ENCRYPTED_KEY")
+
+	m := NewManager()
+	if err := m.LoadProviders(context.Background(), []Provider{EncryptedProvider(path, Key{}, nil)}); err != nil {
+		t.Fatalf("expected a locked file to be skipped, got %v", err)
+	}
+	if m.Get("FLO_TEST_ENCRYPTED_KEY") != "" {
+		t.Error("expected nothing loaded without a key")
+	}
+	if err := m.LoadProviders(context.Background(), []Provider{EncryptedProvider(path, Key{Passphrase: "pw"}, nil)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Get("FLO_TEST_ENCRYPTED_KEY"); got != "from-encrypted-file" {
+		t.Errorf("Get = %q, want the decrypted value", got)
+	}
+}
@@ -0,0 +1,249 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeyringService is the service name secrets are stored under in the OS
+// keyring.
+const KeyringService = "flo"
+
+// indexAccount is the keyring entry listing the names flo has stored, as
+// keyrings can't be enumerated portably. It can't collide with a secret
+// name, which must be a valid environment variable name.
+const indexAccount = ".index"
+
+// keyringTimeout bounds a keyring call, which may wait on an unlock prompt.
+const keyringTimeout = 30 * time.Second
+
+var (
+	// ErrNotFound is returned for a secret the keyring doesn't hold.
+	ErrNotFound = errors.New("secret not found in keyring")
+	// ErrKeyringUnavailable is returned when the platform has no keyring
+	// flo can use, such as Linux without secret-tool.
+	ErrKeyringUnavailable = errors.New("OS keyring unavailable")
+)
+
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateName checks that name can be used as a secret: secrets are
+// exported as environment variables, so names must be valid ones.
+func ValidateName(name string) error {
+	if !secretNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name '%s' (letters, digits and '_', not starting with a digit)", name)
+	}
+	return nil
+}
+
+// Keyring stores secrets in the operating system's credential store: the
+// macOS Keychain, the Windows Credential Manager or the Linux secret
+// service.
+type Keyring interface {
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+}
+
+// Runner executes argv with stdin and returns its stdout and exit code.
+// err is only set when the command couldn't be run.
+type Runner func(ctx context.Context, argv []string, stdin string) (stdout []byte, code int, err error)
+
+// runCommand runs argv, reporting a missing binary as ErrKeyringUnavailable.
+func runCommand(ctx context.Context, argv []string, stdin string) ([]byte, int, error) {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		return stdout.Bytes(), exitErr.ExitCode(), nil
+	case errors.Is(err, exec.ErrNotFound):
+		return nil, 0, fmt.Errorf("%w: %s not found", ErrKeyringUnavailable, argv[0])
+	case err != nil:
+		return nil, 0, err
+	}
+	return stdout.Bytes(), 0, nil
+}
+
+// commandKeyring drives a keyring through a command-line tool.
+type commandKeyring struct {
+	runner Runner
+	// get, set and del return the argv (and stdin) for each operation.
+	get, del func(name string) []string
+	set      func(name, value string) ([]string, string)
+	// notFound reports whether a failed get or delete means the secret
+	// doesn't exist.
+	notFound func(code int, stdout []byte) bool
+}
+
+func (k *commandKeyring) run(argv []string, stdin string) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyringTimeout)
+	defer cancel()
+	return k.runner(ctx, argv, stdin)
+}
+
+func (k *commandKeyring) Get(name string) (string, error) {
+	out, code, err := k.run(k.get(name), "")
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		if k.notFound(code, out) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read %s from keyring (exit %d)", name, code)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+func (k *commandKeyring) Set(name, value string) error {
+	argv, stdin := k.set(name, value)
+	_, code, err := k.run(argv, stdin)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("failed to store %s in keyring (exit %d)", name, code)
+	}
+	return nil
+}
+
+func (k *commandKeyring) Delete(name string) error {
+	out, code, err := k.run(k.del(name), "")
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		if k.notFound(code, out) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete %s from keyring (exit %d)", name, code)
+	}
+	return nil
+}
+
+// NewKeychain returns a keyring backed by the macOS Keychain, through the
+// security tool.
+func NewKeychain(runner Runner) Keyring {
+	return &commandKeyring{
+		runner: runner,
+		get: func(name string) []string {
+			return []string{"security", "find-generic-password", "-s", KeyringService, "-a", name, "-w"}
+		},
+		// security only takes the password as an argument
+		set: func(name, value string) ([]string, string) {
+			return []string{"security", "add-generic-password", "-U", "-s", KeyringService, "-a", name, "-w", value}, ""
+		},
+		del: func(name string) []string {
+			return []string{"security", "delete-generic-password", "-s", KeyringService, "-a", name}
+		},
+		// errSecItemNotFound
+		notFound: func(code int, stdout []byte) bool { return code == 44 },
+	}
+}
+
+// NewSecretService returns a keyring backed by the freedesktop secret
+// service (GNOME Keyring, KWallet), through secret-tool.
+func NewSecretService(runner Runner) Keyring {
+	return &commandKeyring{
+		runner: runner,
+		get: func(name string) []string {
+			return []string{"secret-tool", "lookup", "service", KeyringService, "account", name}
+		},
+		set: func(name, value string) ([]string, string) {
+			return []string{"secret-tool", "store", "--label", KeyringService + " " + name, "service", KeyringService, "account", name}, value
+		},
+		del: func(name string) []string {
+			return []string{"secret-tool", "clear", "service", KeyringService, "account", name}
+		},
+		// secret-tool exits 1 without output for a missing secret
+		notFound: func(code int, stdout []byte) bool { return code == 1 && len(bytes.TrimSpace(stdout)) == 0 },
+	}
+}
+
+// unavailableKeyring is used on platforms without a supported keyring.
+type unavailableKeyring struct{}
+
+func (unavailableKeyring) Get(name string) (string, error) { return "", ErrKeyringUnavailable }
+func (unavailableKeyring) Set(name, value string) error    { return ErrKeyringUnavailable }
+func (unavailableKeyring) Delete(name string) error        { return ErrKeyringUnavailable }
+
+// KeyringNames returns the names of the secrets flo has stored in k.
+func KeyringNames(k Keyring) ([]string, error) {
+	index, err := k.Get(indexAccount)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(index, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// StoreSecret stores a secret in k and records its name.
+func StoreSecret(k Keyring, name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if err := k.Set(name, value); err != nil {
+		return err
+	}
+	return updateIndex(k, func(names map[string]bool) { names[name] = true })
+}
+
+// DeleteSecret removes a secret from k and its name from the index.
+func DeleteSecret(k Keyring, name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	err := k.Delete(name)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if ierr := updateIndex(k, func(names map[string]bool) { delete(names, name) }); ierr != nil {
+		return ierr
+	}
+	return err
+}
+
+// updateIndex rewrites the list of stored names.
+func updateIndex(k Keyring, fn func(names map[string]bool)) error {
+	current, err := KeyringNames(k)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(current))
+	for _, name := range current {
+		names[name] = true
+	}
+	fn(names)
+
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	if len(list) == 0 {
+		if err := k.Delete(indexAccount); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+	return k.Set(indexAccount, strings.Join(list, ","))
+}
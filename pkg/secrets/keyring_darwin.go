@@ -0,0 +1,6 @@
+package secrets
+
+// DefaultKeyring returns the macOS Keychain.
+func DefaultKeyring() Keyring {
+	return NewKeychain(runCommand)
+}
@@ -0,0 +1,6 @@
+package secrets
+
+// DefaultKeyring returns the secret service, through secret-tool.
+func DefaultKeyring() Keyring {
+	return NewSecretService(runCommand)
+}
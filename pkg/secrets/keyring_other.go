@@ -0,0 +1,9 @@
+//go:build !darwin && !linux && !windows
+
+package secrets
+
+// DefaultKeyring returns a keyring that reports ErrKeyringUnavailable, as
+// flo has no keyring support for this platform.
+func DefaultKeyring() Keyring {
+	return unavailableKeyring{}
+}
@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// memoryKeyring is an in-memory Keyring.
+type memoryKeyring map[string]string
+
+func (k memoryKeyring) Get(name string) (string, error) {
+	v, ok := k[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (k memoryKeyring) Set(name, value string) error {
+	k[name] = value
+	return nil
+}
+
+func (k memoryKeyring) Delete(name string) error {
+	if _, ok := k[name]; !ok {
+		return ErrNotFound
+	}
+	delete(k, name)
+	return nil
+}
+
+func TestStoreAndDeleteSecret(t *testing.T) {
+	k := memoryKeyring{}
+	if err := StoreSecret(k, "CLAUDE_API_KEY", "sk-ant-1"); err != nil {
+		t.Fatal(err)
+	}
+	StoreSecret(k, "COPILOT_TOKEN", "ghp-2")
+	if names, _ := KeyringNames(k); strings.Join(names, ",") != "CLAUDE_API_KEY,COPILOT_TOKEN" {
+		t.Errorf("unexpected names: %v", names)
+	}
+	if err := StoreSecret(k, "not a name", "x"); err == nil {
+		t.Error("expected an invalid name to be rejected")
+	}
+
+	if err := DeleteSecret(k, "CLAUDE_API_KEY"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteSecret(k, "CLAUDE_API_KEY"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+	DeleteSecret(k, "COPILOT_TOKEN")
+	if len(k) != 0 {
+		t.Errorf("expected the keyring to be empty, got %v", k)
+	}
+}
+
+func TestManagerLoadKeyring(t *testing.T) {
+	k := memoryKeyring{}
+	StoreSecret(k, "FLO_TEST_KEYRING_KEY", "from-keyring-value")
+	StoreSecret(k, "FLO_TEST_KEYRING_ENV", "shadowed")
+	t.Setenv("FLO_TEST_KEYRING_ENV", "from-env")
+	defer os.Unsetenv("FLO_TEST_KEYRING_KEY")
+
+	m := NewManager()
+	if err := m.LoadKeyring(k); err != nil {
+		t.Fatalf("LoadKeyring failed: %v", err)
+	}
+	if got := m.Get("FLO_TEST_KEYRING_KEY"); got != "from-keyring-value" {
+		t.Errorf("Get = %q, want the keyring value", got)
+	}
+	if got := os.Getenv("FLO_TEST_KEYRING_KEY"); got != "from-keyring-value" {
+		t.Errorf("expected keyring secrets exported to the environment, got %q", got)
+	}
+	if got := m.Get("FLO_TEST_KEYRING_ENV"); got != "from-env" {
+		t.Errorf("expected the environment to win, got %q", got)
+	}
+	if got := m.Redactor().String("key=from-keyring-value"); strings.Contains(got, "from-keyring-value") {
+		t.Errorf("expected keyring values to be redacted, got %q", got)
+	}
+}
+
+func TestCommandKeyrings(t *testing.T) {
+	var calls []string
+	store := map[string]string{}
+	fake := func(ctx context.Context, argv []string, stdin string) ([]byte, int, error) {
+		calls = append(calls, strings.Join(argv, " "))
+		account := argv[len(argv)-1]
+		switch argv[1] {
+		case "store":
+			store[account] = stdin
+		case "lookup":
+			if v, ok := store[account]; ok {
+				return []byte(v), 0, nil
+			}
+			return nil, 1, nil
+		case "clear":
+			delete(store, account)
+		}
+		return nil, 0, nil
+	}
+
+	k := NewSecretService(fake)
+	if err := k.Set("CLAUDE_API_KEY", "sk-ant-1"); err != nil {
+		t.Fatal(err)
+	}
+	if calls[0] != "secret-tool store --label flo CLAUDE_API_KEY service flo account CLAUDE_API_KEY" {
+		t.Errorf("unexpected store call: %s", calls[0])
+	}
+	if v, err := k.Get("CLAUDE_API_KEY"); err != nil || v != "sk-ant-1" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	k.Delete("CLAUDE_API_KEY")
+	if _, err := k.Get("CLAUDE_API_KEY"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	keychain := NewKeychain(func(ctx context.Context, argv []string, stdin string) ([]byte, int, error) {
+		if argv[1] == "find-generic-password" {
+			return nil, 44, nil
+		}
+		return nil, 0, nil
+	})
+	if _, err := keychain.Get("CLAUDE_API_KEY"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected exit 44 to be ErrNotFound, got %v", err)
+	}
+}
+
+func TestMissingKeyringTool(t *testing.T) {
+	_, _, err := runCommand(context.Background(), []string{"flo-no-such-keyring-tool"}, "")
+	if !errors.Is(err, ErrKeyringUnavailable) {
+		t.Errorf("expected ErrKeyringUnavailable, got %v", err)
+	}
+}
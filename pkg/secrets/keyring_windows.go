@@ -0,0 +1,113 @@
+//go:build windows
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32        = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW   = advapi32.NewProc("CredReadW")
+	procCredWriteW  = advapi32.NewProc("CredWriteW")
+	procCredDeleteW = advapi32.NewProc("CredDeleteW")
+	procCredFree    = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+// credential is the Win32 CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credentialManager stores secrets as generic credentials in the Windows
+// Credential Manager, targeted "flo:<name>".
+type credentialManager struct{}
+
+// DefaultKeyring returns the Windows Credential Manager.
+func DefaultKeyring() Keyring {
+	return credentialManager{}
+}
+
+func credentialTarget(name string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(KeyringService + ":" + name)
+}
+
+func (credentialManager) Get(name string) (string, error) {
+	target, err := credentialTarget(name)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	r, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		if errors.Is(err, errorNotFound) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read %s from Credential Manager: %w", name, err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func (credentialManager) Set(name, value string) error {
+	target, err := credentialTarget(name)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	blob := []byte(value)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		UserName:           user,
+		Persist:            credPersistLocalMachine,
+		CredentialBlobSize: uint32(len(blob)),
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	if r, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); r == 0 {
+		return fmt.Errorf("failed to store %s in Credential Manager: %w", name, err)
+	}
+	return nil
+}
+
+func (credentialManager) Delete(name string) error {
+	target, err := credentialTarget(name)
+	if err != nil {
+		return err
+	}
+	if r, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0); r == 0 {
+		if errors.Is(err, errorNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete %s from Credential Manager: %w", name, err)
+	}
+	return nil
+}
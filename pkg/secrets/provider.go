@@ -0,0 +1,230 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Provider types.
+const (
+	ProviderKeyring   = "keyring"
+	ProviderVault     = "vault"
+	ProviderAWS       = "aws"
+	Provider1Password = "1password"
+)
+
+// ProviderConfig declares a secret provider in the workspace config.
+type ProviderConfig struct {
+	// Type is keyring, vault, aws or 1password.
+	Type string `yaml:"type"`
+	// Secrets maps secret names to where the provider holds them: a Vault
+	// "path#field", an AWS Secrets Manager ID (with "#key" to pick a key
+	// of a JSON secret), or a 1Password "op://vault/item/field" reference.
+	// The keyring holds the secrets stored with 'flo secrets set'.
+	Secrets map[string]string `yaml:"secrets,omitempty"`
+	// Address and Namespace select the Vault server (default VAULT_ADDR
+	// and VAULT_NAMESPACE).
+	Address   string `yaml:"address,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+	// Region and Profile configure the AWS CLI.
+	Region  string `yaml:"region,omitempty"`
+	Profile string `yaml:"profile,omitempty"`
+	// Account selects the 1Password account.
+	Account string `yaml:"account,omitempty"`
+}
+
+// Validate checks the provider's type and secret references.
+func (c ProviderConfig) Validate() error {
+	switch c.Type {
+	case ProviderKeyring:
+		if len(c.Secrets) > 0 {
+			return fmt.Errorf("keyring provider takes no secrets (store them with 'flo secrets set')")
+		}
+		return nil
+	case ProviderVault, ProviderAWS, Provider1Password:
+	default:
+		return fmt.Errorf("unknown provider type '%s' (keyring, vault, aws or 1password)", c.Type)
+	}
+	if len(c.Secrets) == 0 {
+		return fmt.Errorf("%s provider lists no secrets", c.Type)
+	}
+	for name, ref := range c.Secrets {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+		switch {
+		case strings.TrimSpace(ref) == "":
+			return fmt.Errorf("secrets.%s: empty reference", name)
+		case c.Type == ProviderVault && !strings.Contains(ref, "#"):
+			return fmt.Errorf("secrets.%s: vault reference must be path#field", name)
+		case c.Type == Provider1Password && !strings.HasPrefix(ref, "op://"):
+			return fmt.Errorf("secrets.%s: 1password reference must start with op://", name)
+		}
+	}
+	return nil
+}
+
+// Provider resolves secrets from an external store.
+type Provider interface {
+	// Name identifies the provider in messages.
+	Name() string
+	// Names returns the secrets the provider can resolve.
+	Names() ([]string, error)
+	// Get resolves a secret, returning ErrNotFound if the provider doesn't
+	// hold it.
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// NewProvider returns the provider cfg declares, running its command-line
+// tool with runner.
+func NewProvider(cfg ProviderConfig, runner Runner) (Provider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Type == ProviderKeyring {
+		return KeyringProvider(defaultKeyring()), nil
+	}
+	return &commandProvider{cfg: cfg, runner: runner}, nil
+}
+
+// NewProviders returns the providers cfgs declare, in order.
+func NewProviders(cfgs []ProviderConfig) ([]Provider, error) {
+	providers := make([]Provider, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		p, err := NewProvider(cfg, runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("secrets.providers[%d]: %w", i, err)
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// KeyringProvider resolves the secrets stored in k.
+func KeyringProvider(k Keyring) Provider {
+	return keyringProvider{k}
+}
+
+type keyringProvider struct{ k Keyring }
+
+func (p keyringProvider) Name() string             { return ProviderKeyring }
+func (p keyringProvider) Names() ([]string, error) { return KeyringNames(p.k) }
+
+func (p keyringProvider) Get(ctx context.Context, name string) (string, error) {
+	return p.k.Get(name)
+}
+
+// commandProvider resolves secrets through a secret manager's CLI: vault,
+// aws or op.
+type commandProvider struct {
+	cfg    ProviderConfig
+	runner Runner
+}
+
+func (p *commandProvider) Name() string { return p.cfg.Type }
+
+func (p *commandProvider) Names() ([]string, error) {
+	names := make([]string, 0, len(p.cfg.Secrets))
+	for name := range p.cfg.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (p *commandProvider) Get(ctx context.Context, name string) (string, error) {
+	ref, ok := p.cfg.Secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	argv, key := p.command(ref)
+
+	ctx, cancel := context.WithTimeout(ctx, keyringTimeout)
+	defer cancel()
+	out, code, err := p.runner(ctx, argv, "")
+	if errors.Is(err, ErrKeyringUnavailable) {
+		return "", fmt.Errorf("%s is not installed", argv[0])
+	}
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return "", fmt.Errorf("%s exited %d reading %s", argv[0], code, name)
+	}
+	value := strings.TrimRight(string(out), "\r\n")
+	if key != "" {
+		return jsonKey(value, key)
+	}
+	return value, nil
+}
+
+// command returns the argv that reads ref and the key to pick from a
+// JSON value, if any.
+func (p *commandProvider) command(ref string) ([]string, string) {
+	switch p.cfg.Type {
+	case ProviderVault:
+		path, field, _ := strings.Cut(ref, "#")
+		argv := []string{"vault", "kv", "get", "-field=" + field}
+		if p.cfg.Address != "" {
+			argv = append(argv, "-address="+p.cfg.Address)
+		}
+		if p.cfg.Namespace != "" {
+			argv = append(argv, "-namespace="+p.cfg.Namespace)
+		}
+		return append(argv, path), ""
+	case ProviderAWS:
+		id, key, _ := strings.Cut(ref, "#")
+		argv := []string{"aws", "secretsmanager", "get-secret-value", "--secret-id", id,
+			"--query", "SecretString", "--output", "text"}
+		if p.cfg.Region != "" {
+			argv = append(argv, "--region", p.cfg.Region)
+		}
+		if p.cfg.Profile != "" {
+			argv = append(argv, "--profile", p.cfg.Profile)
+		}
+		return argv, key
+	}
+	argv := []string{"op", "read", "--no-newline", ref}
+	if p.cfg.Account != "" {
+		argv = append(argv, "--account", p.cfg.Account)
+	}
+	return argv, ""
+}
+
+// jsonKey returns a string field of a JSON object secret.
+func jsonKey(value, key string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, can't read key '%s'", key)
+	}
+	v, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string key '%s'", key)
+	}
+	return v, nil
+}
+
+// Resolve looks a secret up in each provider in turn and returns the first
+// value found and the provider it came from. A provider that fails is
+// skipped, and its error returned if no provider has the secret; a locked
+// store is skipped silently.
+func Resolve(ctx context.Context, providers []Provider, name string) (string, string, error) {
+	var errs []error
+	for _, p := range providers {
+		value, err := p.Get(ctx, name)
+		if err == nil {
+			return value, p.Name(), nil
+		}
+		if !errors.Is(err, ErrNotFound) && !IsLocked(err) {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return "", "", fmt.Errorf("failed to resolve %s: %w", name, errors.Join(errs...))
+	}
+	return "", "", ErrNotFound
+}
@@ -0,0 +1,98 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeCLI answers provider commands from values keyed by argv.
+func fakeCLI(values map[string]string, calls *[]string) Runner {
+	return func(ctx context.Context, argv []string, stdin string) ([]byte, int, error) {
+		cmd := strings.Join(argv, " ")
+		*calls = append(*calls, cmd)
+		if v, ok := values[cmd]; ok {
+			return []byte(v + "\n"), 0, nil
+		}
+		return nil, 2, nil
+	}
+}
+
+func TestCommandProviders(t *testing.T) {
+	var calls []string
+	run := fakeCLI(map[string]string{
+		"vault kv get -field=key -address=https://vault.example.com secret/flo":                                          "sk-vault",
+		"aws secretsmanager get-secret-value --secret-id flo/keys --query SecretString --output text --region eu-west-1": `{"claude": "sk-aws"}`,
+		"op read --no-newline op://Eng/flo/credential":                                                                   "sk-op",
+	}, &calls)
+
+	tests := []struct {
+		cfg  ProviderConfig
+		want string
+	}{
+		{ProviderConfig{Type: ProviderVault, Address: "https://vault.example.com", Secrets: map[string]string{"CLAUDE_API_KEY": "secret/flo#key"}}, "sk-vault"},
+		{ProviderConfig{Type: ProviderAWS, Region: "eu-west-1", Secrets: map[string]string{"CLAUDE_API_KEY": "flo/keys#claude"}}, "sk-aws"},
+		{ProviderConfig{Type: Provider1Password, Secrets: map[string]string{"CLAUDE_API_KEY": "op://Eng/flo/credential"}}, "sk-op"},
+	}
+	for _, tt := range tests {
+		p, err := NewProvider(tt.cfg, run)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.cfg.Type, err)
+		}
+		if got, err := p.Get(context.Background(), "CLAUDE_API_KEY"); err != nil || got != tt.want {
+			t.Errorf("%s: Get = %q, %v; want %q (calls %v)", tt.cfg.Type, got, err, tt.want, calls)
+		}
+		if _, err := p.Get(context.Background(), "COPILOT_TOKEN"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound for an unlisted secret, got %v", tt.cfg.Type, err)
+		}
+	}
+}
+
+func TestProviderConfigValidate(t *testing.T) {
+	bad := []ProviderConfig{
+		{Type: "lastpass", Secrets: map[string]string{"A": "b"}},
+		{Type: ProviderVault},
+		{Type: ProviderVault, Secrets: map[string]string{"A": "secret/flo"}},
+		{Type: Provider1Password, Secrets: map[string]string{"A": "Eng/flo/credential"}},
+		{Type: ProviderAWS, Secrets: map[string]string{"not a name": "id"}},
+		{Type: ProviderKeyring, Secrets: map[string]string{"A": "b"}},
+	}
+	for _, cfg := range bad {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+	if err := (ProviderConfig{Type: ProviderKeyring}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	var calls []string
+	failing, _ := NewProvider(ProviderConfig{Type: ProviderVault, Secrets: map[string]string{"FLO_TEST_PROVIDER_KEY": "secret/flo#key"}}, fakeCLI(nil, &calls))
+	k := memoryKeyring{}
+	StoreSecret(k, "FLO_TEST_PROVIDER_KEY", "from-keyring")
+	providers := []Provider{failing, KeyringProvider(k)}
+
+	value, source, err := Resolve(context.Background(), providers, "FLO_TEST_PROVIDER_KEY")
+	if err != nil || value != "from-keyring" || source != ProviderKeyring {
+		t.Errorf("Resolve = %q, %q, %v; want the keyring value after vault failed", value, source, err)
+	}
+	if _, _, err := Resolve(context.Background(), providers[:1], "FLO_TEST_PROVIDER_KEY"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the vault failure to be reported, got %v", err)
+	}
+	if _, _, err := Resolve(context.Background(), providers, "FLO_TEST_OTHER"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	defer os.Unsetenv("FLO_TEST_PROVIDER_KEY")
+	m := NewManager()
+	if err := m.LoadProviders(context.Background(), providers); err != nil {
+		t.Fatalf("LoadProviders failed: %v", err)
+	}
+	if m.Get("FLO_TEST_PROVIDER_KEY") != "from-keyring" || m.Source("FLO_TEST_PROVIDER_KEY") != ProviderKeyring {
+		t.Errorf("unexpected secret %q from %q", m.Get("FLO_TEST_PROVIDER_KEY"), m.Source("FLO_TEST_PROVIDER_KEY"))
+	}
+}
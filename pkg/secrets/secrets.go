@@ -2,76 +2,131 @@
 package secrets
 
 import (
-	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/richgo/flo/pkg/redact"
 )
 
 // Manager manages secrets and environment variables.
 type Manager struct {
 	envVars map[string]string
+	// providerVars are the secrets resolved by providers such as the OS
+	// keyring, and sources the provider each came from.
+	providerVars map[string]string
+	sources      map[string]string
 }
 
 // NewManager creates a new secrets manager.
 func NewManager() *Manager {
 	return &Manager{
-		envVars: make(map[string]string),
+		envVars:      make(map[string]string),
+		providerVars: make(map[string]string),
+		sources:      make(map[string]string),
 	}
 }
 
-// LoadEnvFile loads environment variables from a .env file.
-func (m *Manager) LoadEnvFile(path string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // .env file is optional
+// LoadKeyring loads the secrets flo stored in the keyring that aren't
+// already set by the environment or a .env file.
+func (m *Manager) LoadKeyring(k Keyring) error {
+	return m.LoadProviders(context.Background(), []Provider{KeyringProvider(k)})
+}
+
+// LoadProviders resolves the secrets the providers hold that aren't
+// already set by the environment or a .env file, taking each from the
+// first provider that has it, and sets them in the environment so
+// backends started by flo see them too. Secrets that can't be resolved
+// are skipped and their errors returned together.
+func (m *Manager) LoadProviders(ctx context.Context, providers []Provider) error {
+	var errs []error
+	var names []string
+	seen := make(map[string]bool)
+	for _, p := range providers {
+		held, err := p.Names()
+		if IsLocked(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		for _, name := range held {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
 		}
-		return fmt.Errorf("failed to open .env file: %w", err)
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
 
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
+	for _, name := range names {
+		if os.Getenv(name) != "" || m.envVars[name] != "" || m.providerVars[name] != "" {
 			continue
 		}
-
-		// Parse KEY=VALUE
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid format at line %d: %s", lineNum, line)
+		value, source, err := Resolve(ctx, providers, name)
+		if errors.Is(err, ErrNotFound) {
+			continue
 		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.providerVars[name] = value
+		m.sources[name] = source
+		os.Setenv(name, value)
+	}
+	return errors.Join(errs...)
+}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Remove quotes if present
-		value = strings.Trim(value, `"'`)
-
-		m.envVars[key] = value
+// Source returns where a secret was loaded from: "environment", ".env",
+// the provider that resolved it, or "" if it isn't set.
+func (m *Manager) Source(key string) string {
+	if source, ok := m.sources[key]; ok {
+		return source
+	}
+	if _, ok := m.envVars[key]; ok && os.Getenv(key) == m.envVars[key] {
+		return ".env"
+	}
+	if os.Getenv(key) != "" {
+		return "environment"
+	}
+	return ""
+}
 
-		// Also set in environment if not already set
-		if os.Getenv(key) == "" {
-			os.Setenv(key, value)
+// LoadEnvFile loads environment variables from a .env file (see parseEnv
+// for the syntax). Variables already set in the environment keep their
+// value there.
+func (m *Manager) LoadEnvFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // .env file is optional
 		}
+		return fmt.Errorf("failed to open .env file: %w", err)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading .env file: %w", err)
+	vars, err := parseEnv(string(data), os.LookupEnv)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
 	}
+	for _, v := range vars {
+		m.envVars[v.key] = v.value
 
+		// Also set in environment if not already set
+		if os.Getenv(v.key) == "" {
+			os.Setenv(v.key, v.value)
+		}
+	}
 	return nil
 }
 
-// Get retrieves a secret by key, checking environment first, then loaded .env.
+// Get retrieves a secret by key, checking environment first, then loaded
+// .env files, then secret providers.
 func (m *Manager) Get(key string) string {
 	// Check environment first
 	if value := os.Getenv(key); value != "" {
@@ -79,7 +134,10 @@ func (m *Manager) Get(key string) string {
 	}
 
 	// Fall back to loaded .env
-	return m.envVars[key]
+	if value, ok := m.envVars[key]; ok {
+		return value
+	}
+	return m.providerVars[key]
 }
 
 // GetRequired retrieves a required secret, returning an error if not found.
@@ -98,13 +156,54 @@ func (m *Manager) Set(key, value string) {
 
 // List returns all loaded secret keys (not values).
 func (m *Manager) List() []string {
-	keys := make([]string, 0, len(m.envVars))
+	keys := make([]string, 0, len(m.envVars)+len(m.providerVars))
 	for key := range m.envVars {
 		keys = append(keys, key)
 	}
+	for key := range m.providerVars {
+		if _, ok := m.envVars[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
 	return keys
 }
 
+// sensitiveNameParts identify environment variables that hold credentials.
+var sensitiveNameParts = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "CREDENTIAL"}
+
+// IsSensitiveName reports whether an environment variable name looks like it
+// holds a credential.
+func IsSensitiveName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(upper, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redactor returns a redactor for every secret value known to the manager:
+// values loaded from .env files and secret providers, and credential-like
+// environment variables.
+// Matches are replaced with their Mask output.
+func (m *Manager) Redactor() *redact.Redactor {
+	r := redact.New(Mask)
+	for _, value := range m.envVars {
+		r.AddValue(value)
+	}
+	for _, value := range m.providerVars {
+		r.AddValue(value)
+	}
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok && IsSensitiveName(name) {
+			r.AddValue(value)
+		}
+	}
+	return r
+}
+
 // Mask returns a masked version of a secret value for display.
 func Mask(value string) string {
 	if value == "" {
@@ -116,8 +215,73 @@ func Mask(value string) string {
 	return value[:4] + "****" + value[len(value)-4:]
 }
 
-// LoadDefault loads .env from the current directory or specified locations.
+// WorkspaceEnvFile is the workspace-local .env file, kept out of version
+// control by .flo/.gitignore.
+var WorkspaceEnvFile = filepath.Join(".flo", ".env")
+
+// SaveEnvValue sets key in the .env file at path, replacing an existing
+// line for the key, exported or not, or appending one. The file is
+// created readable only by the owner.
+func SaveEnvValue(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read .env file: %w", err)
+	}
+
+	line := key + "=" + value
+	var lines []string
+	replaced := false
+	if len(data) > 0 {
+		for _, l := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			kv, exported := cutExport(l)
+			name, _, ok := strings.Cut(kv, "=")
+			if ok && strings.TrimSpace(name) == key {
+				if !replaced {
+					if exported {
+						lines = append(lines, "export "+line)
+					} else {
+						lines = append(lines, line)
+					}
+				}
+				replaced = true
+				continue
+			}
+			lines = append(lines, l)
+		}
+	}
+	if !replaced {
+		lines = append(lines, line)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create .env directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write .env file: %w", err)
+	}
+	return nil
+}
+
+// GenerateToken returns a random 256-bit token, hex encoded.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// LoadDefault loads .env from the current directory and the workspace, then
+// the secrets stored in the OS keyring.
 func LoadDefault() (*Manager, error) {
+	return Load(context.Background(), nil)
+}
+
+// Load loads .env from the current directory and the workspace, the
+// encrypted secrets file if its key is set, then the secrets resolved by
+// the configured providers, in order. Without providers, the OS keyring is
+// used.
+func Load(ctx context.Context, cfgs []ProviderConfig) (*Manager, error) {
 	m := NewManager()
 
 	// Try to load from current directory
@@ -126,18 +290,43 @@ func LoadDefault() (*Manager, error) {
 	}
 
 	// Also try .flo/.env
-	floEnv := filepath.Join(".flo", ".env")
-	if err := m.LoadEnvFile(floEnv); err != nil {
+	if err := m.LoadEnvFile(WorkspaceEnvFile); err != nil {
 		return nil, err
 	}
 
+	encrypted := EncryptedProvider(EncryptedFile, KeyFromEnv(), runCommand)
+	if err := m.LoadProviders(ctx, []Provider{encrypted}); err != nil {
+		return nil, err
+	}
+
+	if len(cfgs) == 0 {
+		// A missing or locked keyring doesn't stop flo, and
+		// 'flo secrets get' reports why
+		m.LoadKeyring(defaultKeyring())
+		return m, nil
+	}
+	providers, err := NewProviders(cfgs)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.LoadProviders(ctx, providers); err != nil {
+		return nil, err
+	}
 	return m, nil
 }
 
+// defaultKeyring is the keyring LoadDefault reads (replaced in tests).
+var defaultKeyring = DefaultKeyring
+
 // WellKnownKeys are the environment variables used by Flo.
 var WellKnownKeys = []string{
 	"CLAUDE_API_KEY",
 	"COPILOT_TOKEN",
 	"FLO_BACKEND",
 	"FLO_MODEL",
+	MCPTokenKey,
 }
+
+// MCPTokenKey holds the bearer token clients must send to flo mcp serve
+// over HTTP.
+const MCPTokenKey = "FLO_MCP_TOKEN"
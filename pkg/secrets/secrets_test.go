@@ -3,6 +3,8 @@ package secrets
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -61,11 +63,79 @@ COPILOT_TOKEN=ghp-test-456
 			},
 			wantError: false,
 		},
+		{
+			name:      "bare name defines nothing",
+			content:   `NAME_WITHOUT_VALUE`,
+			wantVars:  map[string]string{"NAME_WITHOUT_VALUE": ""},
+			wantError: false,
+		},
 		{
 			name:      "invalid format",
-			content:   `INVALID_LINE_WITHOUT_EQUALS`,
+			content:   `not a valid line`,
+			wantError: true,
+		},
+		{
+			name:      "invalid name",
+			content:   `1KEY=value`,
+			wantError: true,
+		},
+		{
+			name:      "unterminated quote",
+			content:   "KEY=\"value\nOTHER=1",
 			wantError: true,
 		},
+		{
+			name: "export prefix and inline comments",
+			content: `export CLAUDE_API_KEY=sk-test-123 # from the console
+export	FLO_TEST_URL=http://host/a#b`,
+			wantVars: map[string]string{
+				"CLAUDE_API_KEY": "sk-test-123",
+				"FLO_TEST_URL":   "http://host/a#b",
+			},
+		},
+		{
+			name: "variable expansion",
+			content: `FLO_TEST_HOST=db.internal
+FLO_TEST_DSN=postgres://${FLO_TEST_HOST}:5432/$FLO_TEST_DB
+FLO_TEST_PORT=${FLO_TEST_UNSET:-8080}
+FLO_TEST_QUOTED="$FLO_TEST_HOST\$HOME"
+FLO_TEST_LITERAL='${FLO_TEST_HOST}'`,
+			wantVars: map[string]string{
+				"FLO_TEST_HOST":    "db.internal",
+				"FLO_TEST_DSN":     "postgres://db.internal:5432/",
+				"FLO_TEST_PORT":    "8080",
+				"FLO_TEST_QUOTED":  "db.internal$HOME",
+				"FLO_TEST_LITERAL": "${FLO_TEST_HOST}",
+			},
+		},
+		{
+			name: "defaults for unset and empty variables",
+			content: `FLO_TEST_EMPTY=
+FLO_TEST_A=${FLO_TEST_EMPTY:-a}
+FLO_TEST_B=${FLO_TEST_EMPTY-b}
+FLO_TEST_C=${FLO_TEST_NEVER_SET:-c}
+FLO_TEST_D=${FLO_TEST_NEVER_SET-d}`,
+			wantVars: map[string]string{
+				"FLO_TEST_A": "a",
+				"FLO_TEST_B": "",
+				"FLO_TEST_C": "c",
+				"FLO_TEST_D": "d",
+			},
+		},
+		{
+			name: "multi-line values",
+			content: `FLO_TEST_PEM="-----BEGIN KEY-----
+abc
+-----END KEY-----"
+FLO_TEST_ESCAPED="line1\nline2\t\"q\""
+FLO_TEST_SINGLE='a
+b' # comment`,
+			wantVars: map[string]string{
+				"FLO_TEST_PEM":     "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+				"FLO_TEST_ESCAPED": "line1\nline2\t\"q\"",
+				"FLO_TEST_SINGLE":  "a\nb",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -220,6 +290,10 @@ func TestMask(t *testing.T) {
 }
 
 func TestLoadDefault(t *testing.T) {
+	// Keep the user's real keyring out of the test
+	defer func(k func() Keyring) { defaultKeyring = k }(defaultKeyring)
+	defaultKeyring = func() Keyring { return unavailableKeyring{} }
+
 	// Create temporary directory
 	tmpDir := t.TempDir()
 	originalDir, _ := os.Getwd()
@@ -259,6 +333,7 @@ func TestWellKnownKeys(t *testing.T) {
 		"COPILOT_TOKEN",
 		"FLO_BACKEND",
 		"FLO_MODEL",
+		"FLO_MCP_TOKEN",
 	}
 
 	if len(WellKnownKeys) != len(expectedKeys) {
@@ -276,3 +351,109 @@ func TestWellKnownKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestManagerRedactor(t *testing.T) {
+	t.Setenv("MY_SERVICE_TOKEN", "tok-1234567890abcdef")
+	t.Setenv("FLO_MODEL", "claude-sonnet-model")
+
+	m := NewManager()
+	m.Set("DATABASE_URL", "postgres://user:hunter2hunter2@db")
+
+	r := m.Redactor()
+	out := r.String("connect postgres://user:hunter2hunter2@db with tok-1234567890abcdef using claude-sonnet-model")
+
+	if strings.Contains(out, "hunter2hunter2") {
+		t.Errorf("loaded .env value not redacted: %q", out)
+	}
+	if strings.Contains(out, "tok-1234567890abcdef") {
+		t.Errorf("sensitive env value not redacted: %q", out)
+	}
+	if !strings.Contains(out, "claude-sonnet-model") {
+		t.Errorf("non-sensitive env value should be kept: %q", out)
+	}
+}
+
+func TestIsSensitiveName(t *testing.T) {
+	for _, name := range []string{"CLAUDE_API_KEY", "COPILOT_TOKEN", "db_password", "AWS_SECRET_ACCESS_KEY"} {
+		if !IsSensitiveName(name) {
+			t.Errorf("expected %s to be sensitive", name)
+		}
+	}
+	if IsSensitiveName("FLO_BACKEND") {
+		t.Error("expected FLO_BACKEND not to be sensitive")
+	}
+}
+
+func TestSaveEnvValue(t *testing.T) {
+	t.Setenv("FLO_MCP_TOKEN", "") // restored after LoadEnvFile exports it
+	path := filepath.Join(t.TempDir(), ".flo", ".env")
+
+	if err := SaveEnvValue(path, "FLO_MCP_TOKEN", "first"); err != nil {
+		t.Fatalf("SaveEnvValue failed: %v", err)
+	}
+	os.WriteFile(path, []byte("# keys\nCLAUDE_API_KEY=sk-1\nFLO_MCP_TOKEN=first\n"), 0600)
+	if err := SaveEnvValue(path, "FLO_MCP_TOKEN", "second"); err != nil {
+		t.Fatalf("SaveEnvValue failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "# keys\nCLAUDE_API_KEY=sk-1\nFLO_MCP_TOKEN=second\n" {
+		t.Errorf("unexpected .env content:\n%s", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	m := NewManager()
+	m.LoadEnvFile(path)
+	if m.envVars["FLO_MCP_TOKEN"] != "second" {
+		t.Errorf("expected saved value to load, got %q", m.envVars["FLO_MCP_TOKEN"])
+	}
+}
+
+func TestSaveEnvValueExported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(path, []byte("export CLAUDE_API_KEY=sk-1\nexport FLO_MCP_TOKEN=first\n"), 0600)
+
+	if err := SaveEnvValue(path, "FLO_MCP_TOKEN", "second"); err != nil {
+		t.Fatalf("SaveEnvValue failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "export CLAUDE_API_KEY=sk-1\nexport FLO_MCP_TOKEN=second\n" {
+		t.Errorf("expected the exported line replaced, got:\n%s", data)
+	}
+}
+
+func TestGenerateToken(t *testing.T) {
+	a, err := GenerateToken()
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	b, _ := GenerateToken()
+	if len(a) != 64 || a == b {
+		t.Errorf("expected distinct 64-char tokens, got %q and %q", a, b)
+	}
+}
+
+func TestParseEnvDefaults(t *testing.T) {
+	env := map[string]string{"FLO_TEST_SET": "x", "FLO_TEST_EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	vars, err := parseEnv(`A=${FLO_TEST_SET:-d}
+B=${FLO_TEST_SET-d}
+C=${FLO_TEST_EMPTY:-d}
+D=${FLO_TEST_EMPTY-d}
+E=${FLO_TEST_UNSET:-d}
+F=${FLO_TEST_UNSET-d}`, lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []envVar{{"A", "x"}, {"B", "x"}, {"C", "d"}, {"D", ""}, {"E", "d"}, {"F", "d"}}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("got %v, want %v", vars, want)
+	}
+}
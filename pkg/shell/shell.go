@@ -0,0 +1,45 @@
+// Package shell runs configured commands, such as the TDD gate's test
+// command and the verify pipeline's steps, through the system shell, and
+// the tools flo drives, such as git and go list, directly.
+package shell
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Runner executes argv in dir and returns its stdout.
+type Runner func(ctx context.Context, dir string, argv []string) ([]byte, error)
+
+// Run runs command through sh in dir and returns its combined stdout and
+// stderr, which are kept even when the command fails.
+func Run(ctx context.Context, dir, command string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// Exec runs argv in dir without a shell and returns its stdout, with
+// stderr in the error when it fails. It is the default Runner.
+func Exec(ctx context.Context, dir string, argv []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, err
+}
@@ -0,0 +1,42 @@
+package shell
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "marker"), nil, 0644)
+
+	out, err := Run(context.Background(), dir, "ls && echo oops >&2")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !strings.Contains(out, "marker") || !strings.Contains(out, "oops") {
+		t.Errorf("expected stdout and stderr from dir, got %q", out)
+	}
+
+	out, err = Run(context.Background(), dir, "echo failing; exit 3")
+	if err == nil || !strings.Contains(out, "failing") {
+		t.Errorf("expected the failure with its output, got %q, %v", out, err)
+	}
+}
+
+func TestExec(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "marker"), nil, 0644)
+
+	out, err := Exec(context.Background(), dir, []string{"ls"})
+	if err != nil || !strings.Contains(string(out), "marker") {
+		t.Fatalf("expected stdout from dir, got %q, %v", out, err)
+	}
+
+	_, err = Exec(context.Background(), dir, []string{"sh", "-c", "echo oops >&2; exit 3"})
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expected stderr in the error, got %v", err)
+	}
+}
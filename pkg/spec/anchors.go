@@ -0,0 +1,155 @@
+package spec
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultFile is the spec file task refs point at when no file is given.
+const DefaultFile = "SPEC.md"
+
+// Anchor is a linkable heading in a spec.
+type Anchor struct {
+	Slug    string
+	Heading string
+	Level   int
+	Line    int
+}
+
+// Slugify converts a heading to a GitHub-style anchor: lowercase, spaces to
+// hyphens, punctuation dropped.
+func Slugify(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// ExtractAnchors returns the anchors for all headings in a markdown document.
+// Headings inside code fences are ignored; repeated slugs get -1, -2, ...
+// suffixes as on GitHub.
+func ExtractAnchors(content string) []Anchor {
+	var anchors []Anchor
+	seen := make(map[string]int)
+	inFence := false
+
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		level, heading, ok := parseHeading(trimmed)
+		if !ok {
+			continue
+		}
+
+		slug := Slugify(heading)
+		if n := seen[slug]; n > 0 {
+			seen[slug]++
+			slug = fmt.Sprintf("%s-%d", slug, n)
+		} else {
+			seen[slug] = 1
+		}
+		anchors = append(anchors, Anchor{Slug: slug, Heading: heading, Level: level, Line: i + 1})
+	}
+	return anchors
+}
+
+// ParseRef splits a spec ref such as "SPEC.md#oauth" or "#oauth" into its
+// file and anchor. The file defaults to SPEC.md.
+func ParseRef(ref string) (file, anchor string, err error) {
+	file, anchor, found := strings.Cut(strings.TrimSpace(ref), "#")
+	if !found || anchor == "" {
+		return "", "", fmt.Errorf("spec ref '%s' must include an anchor, e.g. SPEC.md#overview", ref)
+	}
+	if file == "" {
+		file = DefaultFile
+	}
+	return file, anchor, nil
+}
+
+// ValidateRef checks that ref points at a heading in the given spec content.
+func ValidateRef(ref, content string) error {
+	file, anchor, err := ParseRef(ref)
+	if err != nil {
+		return err
+	}
+	if filepath.Base(file) != DefaultFile {
+		return fmt.Errorf("spec ref '%s' must point into %s", ref, DefaultFile)
+	}
+	for _, a := range ExtractAnchors(content) {
+		if a.Slug == anchor {
+			return nil
+		}
+	}
+	return fmt.Errorf("spec ref '%s': no section '#%s' in %s", ref, anchor, DefaultFile)
+}
+
+// SectionCoverage lists the tasks referencing a spec section.
+type SectionCoverage struct {
+	Anchor Anchor
+	Tasks  []string
+}
+
+// Covered reports whether any task references the section.
+func (s SectionCoverage) Covered() bool {
+	return len(s.Tasks) > 0
+}
+
+// Coverage maps task refs (task ID → spec ref) onto the spec's sections.
+// The document title (level 1) is skipped. A section counts as covered
+// when it or one of its subsections is referenced.
+func Coverage(content string, refs map[string]string) []SectionCoverage {
+	anchors := ExtractAnchors(content)
+
+	bySlug := make(map[string][]string)
+	for taskID, ref := range refs {
+		if _, anchor, err := ParseRef(ref); err == nil {
+			bySlug[anchor] = append(bySlug[anchor], taskID)
+		}
+	}
+
+	var result []SectionCoverage
+	for i, a := range anchors {
+		if a.Level == 1 {
+			continue
+		}
+		var tasks []string
+		tasks = append(tasks, bySlug[a.Slug]...)
+		for _, child := range anchors[i+1:] {
+			if child.Level <= a.Level {
+				break
+			}
+			tasks = append(tasks, bySlug[child.Slug]...)
+		}
+		result = append(result, SectionCoverage{Anchor: a, Tasks: dedupeSorted(tasks)})
+	}
+	return result
+}
+
+// dedupeSorted returns the unique values of s in sorted order.
+func dedupeSorted(s []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,111 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+const anchorSpec = `# Feature: Login
+
+## Goal
+Let users sign in.
+
+## OAuth Providers
+### Google & GitHub
+### Token Refresh
+
+` + "```" + `
+## Not a heading
+` + "```" + `
+
+## Goal
+Duplicate heading.
+
+## Success Criteria
+`
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"OAuth Providers":    "oauth-providers",
+		"Google & GitHub":    "google--github",
+		"Step 1: Setup_Env":  "step-1-setup_env",
+		"  Trailing Space  ": "trailing-space",
+	}
+	for heading, want := range tests {
+		if got := Slugify(heading); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", heading, got, want)
+		}
+	}
+}
+
+func TestExtractAnchors(t *testing.T) {
+	anchors := ExtractAnchors(anchorSpec)
+
+	var slugs []string
+	for _, a := range anchors {
+		slugs = append(slugs, a.Slug)
+	}
+	want := "feature-login,goal,oauth-providers,google--github,token-refresh,goal-1,success-criteria"
+	if got := strings.Join(slugs, ","); got != want {
+		t.Errorf("unexpected anchors:\n got %s\nwant %s", got, want)
+	}
+	if anchors[3].Level != 3 || anchors[3].Heading != "Google & GitHub" {
+		t.Errorf("unexpected anchor: %+v", anchors[3])
+	}
+}
+
+func TestValidateRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		wantErr string
+	}{
+		{"SPEC.md#oauth-providers", ""},
+		{"#token-refresh", ""},
+		{".flo/SPEC.md#goal-1", ""},
+		{"SPEC.md#billing", "no section"},
+		{"SPEC.md", "must include an anchor"},
+		{"README.md#goal", "must point into"},
+	}
+	for _, tt := range tests {
+		err := ValidateRef(tt.ref, anchorSpec)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.ref, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.ref, tt.wantErr, err)
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	refs := map[string]string{
+		"t-002": "SPEC.md#token-refresh",
+		"t-001": "SPEC.md#goal",
+		"t-003": "#token-refresh",
+	}
+	sections := Coverage(anchorSpec, refs)
+
+	got := make(map[string]string)
+	for _, s := range sections {
+		got[s.Anchor.Slug] = strings.Join(s.Tasks, ",")
+	}
+	if _, ok := got["feature-login"]; ok {
+		t.Error("expected document title to be skipped")
+	}
+	want := map[string]string{
+		"goal":             "t-001",
+		"oauth-providers":  "t-002,t-003",
+		"google--github":   "",
+		"token-refresh":    "t-002,t-003",
+		"goal-1":           "",
+		"success-criteria": "",
+	}
+	for slug, tasks := range want {
+		if got[slug] != tasks {
+			t.Errorf("%s: expected tasks %q, got %q", slug, tasks, got[slug])
+		}
+	}
+}
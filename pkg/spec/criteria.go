@@ -0,0 +1,183 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// criteriaHeadings are the sections whose checkbox items are acceptance
+// criteria (matched case-insensitively, including their subsections).
+var criteriaHeadings = []string{"success criteria", "acceptance criteria"}
+
+var (
+	checkboxPattern = regexp.MustCompile(`^[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	// criterionIDPattern matches an explicit ID prefix such as "AC-3:".
+	criterionIDPattern = regexp.MustCompile(`^\**([A-Za-z][A-Za-z0-9]*-\d+)\**[:.)]?\s+`)
+)
+
+// Criterion is a checkbox item from a spec's criteria sections.
+type Criterion struct {
+	// ID is the explicit prefix (e.g. "AC-3: ...") or AC-<n> by position.
+	ID      string
+	Text    string
+	Checked bool
+	Section string
+	Line    int
+}
+
+// ExtractCriteria returns the checkbox items under Success Criteria or
+// Acceptance Criteria headings. Items without an explicit ID are numbered
+// AC-1, AC-2, ... in document order, skipping IDs used explicitly.
+func ExtractCriteria(content string) []Criterion {
+	var criteria []Criterion
+	explicit := make(map[string]bool)
+
+	inFence := false
+	sectionLevel := 0 // level of the enclosing criteria heading, 0 if none
+	section := ""
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if level, heading, ok := parseHeading(trimmed); ok {
+			if isCriteriaHeading(heading) {
+				sectionLevel, section = level, heading
+			} else if sectionLevel > 0 && level <= sectionLevel {
+				sectionLevel, section = 0, ""
+			}
+			continue
+		}
+		if sectionLevel == 0 {
+			continue
+		}
+
+		m := checkboxPattern.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		c := Criterion{
+			Text:    strings.TrimSpace(m[2]),
+			Checked: m[1] != " ",
+			Section: section,
+			Line:    i + 1,
+		}
+		if id := criterionIDPattern.FindStringSubmatch(c.Text); id != nil {
+			c.ID = strings.ToUpper(id[1])
+			c.Text = strings.TrimSpace(c.Text[len(id[0]):])
+			explicit[c.ID] = true
+		}
+		criteria = append(criteria, c)
+	}
+
+	next := 1
+	for i := range criteria {
+		if criteria[i].ID != "" {
+			continue
+		}
+		for explicit[fmt.Sprintf("AC-%d", next)] {
+			next++
+		}
+		criteria[i].ID = fmt.Sprintf("AC-%d", next)
+		next++
+	}
+	return criteria
+}
+
+// ValidateCriteria checks that every ID names a criterion in the spec.
+func ValidateCriteria(ids []string, content string) error {
+	known := make(map[string]bool)
+	for _, c := range ExtractCriteria(content) {
+		known[c.ID] = true
+	}
+	for _, id := range ids {
+		if !known[strings.ToUpper(id)] {
+			return fmt.Errorf("unknown acceptance criterion '%s'", id)
+		}
+	}
+	return nil
+}
+
+// CriterionProgress is a criterion with the tasks referencing it.
+type CriterionProgress struct {
+	Criterion
+	Tasks []string
+	// Met is true when the criterion is checked in the spec or every task
+	// referencing it is complete.
+	Met bool
+}
+
+// Progress summarizes acceptance criteria completion.
+type Progress struct {
+	Criteria []CriterionProgress
+	Met      int
+}
+
+// Percent returns the share of criteria met, from 0 to 100.
+func (p *Progress) Percent() int {
+	if len(p.Criteria) == 0 {
+		return 0
+	}
+	return p.Met * 100 / len(p.Criteria)
+}
+
+// CriteriaProgress computes completion from task criteria references
+// (task ID → criterion IDs) and which tasks are complete.
+func CriteriaProgress(content string, refs map[string][]string, complete map[string]bool) *Progress {
+	byCriterion := make(map[string][]string)
+	for taskID, ids := range refs {
+		for _, id := range ids {
+			id = strings.ToUpper(id)
+			byCriterion[id] = append(byCriterion[id], taskID)
+		}
+	}
+
+	progress := &Progress{}
+	for _, c := range ExtractCriteria(content) {
+		cp := CriterionProgress{Criterion: c, Tasks: dedupeSorted(byCriterion[c.ID])}
+		cp.Met = c.Checked
+		if !cp.Met && len(cp.Tasks) > 0 {
+			cp.Met = true
+			for _, id := range cp.Tasks {
+				if !complete[id] {
+					cp.Met = false
+					break
+				}
+			}
+		}
+		if cp.Met {
+			progress.Met++
+		}
+		progress.Criteria = append(progress.Criteria, cp)
+	}
+	return progress
+}
+
+// parseHeading returns the level and text of a markdown ATX heading.
+func parseHeading(line string) (int, string, bool) {
+	if !strings.HasPrefix(line, "#") {
+		return 0, "", false
+	}
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level > 6 || !strings.HasPrefix(line[level:], " ") {
+		return 0, "", false
+	}
+	heading := strings.TrimSpace(line[level:])
+	return level, heading, heading != ""
+}
+
+func isCriteriaHeading(heading string) bool {
+	lower := strings.ToLower(heading)
+	for _, h := range criteriaHeadings {
+		if strings.HasPrefix(lower, h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+const criteriaSpec = `# Feature: Login
+
+## Goal
+- [ ] Not a criterion
+
+## Success Criteria
+- [ ] Users can sign in
+- [x] AC-2: Passwords are hashed
+* [X] **AC-7** Sessions expire
+
+### Security
+- [ ] Lockout after 5 attempts
+
+` + "```" + `
+- [ ] fenced example
+` + "```" + `
+
+## Technical Notes
+- [ ] Not a criterion either
+
+## Acceptance Criteria (mobile)
+- [ ] Works offline
+`
+
+func TestExtractCriteria(t *testing.T) {
+	criteria := ExtractCriteria(criteriaSpec)
+
+	var got []string
+	for _, c := range criteria {
+		got = append(got, c.ID+"="+c.Text)
+	}
+	want := "AC-1=Users can sign in|AC-2=Passwords are hashed|AC-7=Sessions expire|AC-3=Lockout after 5 attempts|AC-4=Works offline"
+	if strings.Join(got, "|") != want {
+		t.Errorf("unexpected criteria:\n got %s\nwant %s", strings.Join(got, "|"), want)
+	}
+	if criteria[0].Checked || !criteria[1].Checked || !criteria[2].Checked {
+		t.Errorf("unexpected checked state: %+v", criteria)
+	}
+	if criteria[3].Section != "Success Criteria" || criteria[4].Section != "Acceptance Criteria (mobile)" {
+		t.Errorf("unexpected sections: %q, %q", criteria[3].Section, criteria[4].Section)
+	}
+}
+
+func TestValidateCriteria(t *testing.T) {
+	if err := ValidateCriteria([]string{"AC-1", "ac-7"}, criteriaSpec); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateCriteria([]string{"AC-9"}, criteriaSpec); err == nil {
+		t.Error("expected unknown criterion to be rejected")
+	}
+}
+
+func TestCriteriaProgress(t *testing.T) {
+	refs := map[string][]string{
+		"t-001": {"AC-1"},
+		"t-002": {"AC-1", "AC-3"},
+		"t-003": {"AC-4"},
+	}
+	complete := map[string]bool{"t-001": true, "t-002": false, "t-003": true}
+
+	progress := CriteriaProgress(criteriaSpec, refs, complete)
+	met := make(map[string]bool)
+	for _, c := range progress.Criteria {
+		met[c.ID] = c.Met
+	}
+
+	// AC-2 and AC-7 are checked; AC-4's only task is complete; AC-1 still
+	// waits on t-002 and AC-3 has an incomplete task.
+	if !met["AC-2"] || !met["AC-7"] || !met["AC-4"] || met["AC-1"] || met["AC-3"] {
+		t.Errorf("unexpected progress: %v", met)
+	}
+	if progress.Met != 3 || progress.Percent() != 60 {
+		t.Errorf("expected 3 met (60%%), got %d (%d%%)", progress.Met, progress.Percent())
+	}
+	if got := strings.Join(progress.Criteria[0].Tasks, ","); got != "t-001,t-002" {
+		t.Errorf("unexpected tasks for AC-1: %s", got)
+	}
+}
@@ -0,0 +1,163 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProposedTask is one task in a proposed breakdown. Deps refer to other
+// proposed tasks by Key, since registry IDs are assigned on apply.
+type ProposedTask struct {
+	Key         string   `json:"key"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Deps        []string `json:"deps,omitempty"`
+	Priority    int      `json:"priority,omitempty"`
+}
+
+// Proposal is a task breakdown generated from a spec, awaiting review.
+type Proposal struct {
+	Tasks []ProposedTask `json:"tasks"`
+}
+
+// DecomposePrompt builds the prompt asking an agent to break a spec into tasks.
+func DecomposePrompt(specContent string, taskTypes []string) string {
+	sorted := append([]string{}, taskTypes...)
+	sort.Strings(sorted)
+
+	return fmt.Sprintf(`Break the following feature specification into implementation tasks.
+
+## Feature Specification
+%s
+
+## Output
+Respond with only a JSON object of this form:
+
+{"tasks": [
+  {"key": "1", "title": "...", "description": "...", "type": "...", "deps": [], "priority": 0}
+]}
+
+Rules:
+- key is a short unique identifier; deps lists the keys of tasks that must finish first
+- type is one of: %s
+- Keep tasks small enough for a single agent session, each independently testable
+- Order tasks so dependencies come first
+- Do not modify any files`, specContent, strings.Join(sorted, ", "))
+}
+
+// ParseProposal extracts a proposal from agent output, tolerating prose or a
+// markdown code fence around the JSON object.
+func ParseProposal(output string) (*Proposal, error) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON task breakdown found in agent output")
+	}
+
+	var p Proposal
+	if err := json.Unmarshal([]byte(output[start:end+1]), &p); err != nil {
+		return nil, fmt.Errorf("failed to parse task breakdown: %w", err)
+	}
+	if len(p.Tasks) == 0 {
+		return nil, fmt.Errorf("task breakdown contains no tasks")
+	}
+	return &p, nil
+}
+
+// Validate checks that keys are unique, deps resolve, types are known (when
+// taskTypes is non-empty), and the dependency graph has no cycles.
+func (p *Proposal) Validate(taskTypes []string) error {
+	known := make(map[string]bool)
+	for _, tt := range taskTypes {
+		known[tt] = true
+	}
+
+	keys := make(map[string]bool)
+	for i, t := range p.Tasks {
+		if t.Key == "" {
+			return fmt.Errorf("task %d has no key", i+1)
+		}
+		if strings.TrimSpace(t.Title) == "" {
+			return fmt.Errorf("task %s has no title", t.Key)
+		}
+		if keys[t.Key] {
+			return fmt.Errorf("duplicate task key '%s'", t.Key)
+		}
+		keys[t.Key] = true
+		if t.Type != "" && len(known) > 0 && !known[t.Type] {
+			return fmt.Errorf("task %s has unknown type '%s'", t.Key, t.Type)
+		}
+	}
+	for _, t := range p.Tasks {
+		for _, dep := range t.Deps {
+			if !keys[dep] {
+				return fmt.Errorf("task %s depends on unknown task '%s'", t.Key, dep)
+			}
+		}
+	}
+
+	_, err := p.Ordered()
+	return err
+}
+
+// Ordered returns the tasks sorted so every task follows its dependencies,
+// keeping the proposal order where possible.
+func (p *Proposal) Ordered() ([]ProposedTask, error) {
+	done := make(map[string]bool)
+	var ordered []ProposedTask
+
+	for len(ordered) < len(p.Tasks) {
+		progressed := false
+		for _, t := range p.Tasks {
+			if done[t.Key] {
+				continue
+			}
+			ready := true
+			for _, dep := range t.Deps {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[t.Key] = true
+				ordered = append(ordered, t)
+				progressed = true
+			}
+		}
+		if !progressed {
+			return nil, fmt.Errorf("task breakdown has a dependency cycle")
+		}
+	}
+	return ordered, nil
+}
+
+// SaveProposal writes a proposal to path for later review.
+func SaveProposal(path string, p *Proposal) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize proposal: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadProposal reads a saved proposal.
+func LoadProposal(path string) (*Proposal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proposal: %w", err)
+	}
+	var p Proposal
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse proposal: %w", err)
+	}
+	return &p, nil
+}
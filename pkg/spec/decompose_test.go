@@ -0,0 +1,98 @@
+package spec
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseProposal(t *testing.T) {
+	output := "Here is the breakdown:\n```json\n" +
+		`{"tasks": [{"key": "1", "title": "Schema", "type": "feature"}, {"key": "2", "title": "API", "deps": ["1"]}]}` +
+		"\n```\nLet me know if you want changes."
+
+	p, err := ParseProposal(output)
+	if err != nil {
+		t.Fatalf("ParseProposal failed: %v", err)
+	}
+	if len(p.Tasks) != 2 || p.Tasks[1].Deps[0] != "1" {
+		t.Errorf("unexpected proposal: %+v", p)
+	}
+
+	if _, err := ParseProposal("I could not do it"); err == nil {
+		t.Error("expected error for output without JSON")
+	}
+	if _, err := ParseProposal(`{"tasks": []}`); err == nil {
+		t.Error("expected error for empty breakdown")
+	}
+}
+
+func TestProposalValidate(t *testing.T) {
+	types := []string{"feature", "test"}
+	tests := []struct {
+		name    string
+		tasks   []ProposedTask
+		wantErr string
+	}{
+		{"valid", []ProposedTask{{Key: "1", Title: "A", Type: "feature"}, {Key: "2", Title: "B", Deps: []string{"1"}}}, ""},
+		{"duplicate key", []ProposedTask{{Key: "1", Title: "A"}, {Key: "1", Title: "B"}}, "duplicate"},
+		{"unknown dep", []ProposedTask{{Key: "1", Title: "A", Deps: []string{"9"}}}, "unknown task"},
+		{"unknown type", []ProposedTask{{Key: "1", Title: "A", Type: "magic"}}, "unknown type"},
+		{"missing title", []ProposedTask{{Key: "1"}}, "no title"},
+		{"cycle", []ProposedTask{{Key: "1", Title: "A", Deps: []string{"2"}}, {Key: "2", Title: "B", Deps: []string{"1"}}}, "cycle"},
+	}
+
+	for _, tt := range tests {
+		err := (&Proposal{Tasks: tt.tasks}).Validate(types)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestProposalOrdered(t *testing.T) {
+	p := &Proposal{Tasks: []ProposedTask{
+		{Key: "api", Title: "API", Deps: []string{"db"}},
+		{Key: "db", Title: "DB"},
+		{Key: "docs", Title: "Docs"},
+	}}
+
+	ordered, err := p.Ordered()
+	if err != nil {
+		t.Fatalf("Ordered failed: %v", err)
+	}
+	var keys []string
+	for _, task := range ordered {
+		keys = append(keys, task.Key)
+	}
+	if got := strings.Join(keys, ","); got != "db,docs,api" {
+		t.Errorf("unexpected order: %s", got)
+	}
+}
+
+func TestSaveLoadProposal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proposals", "decompose.json")
+	if err := SaveProposal(path, &Proposal{Tasks: []ProposedTask{{Key: "1", Title: "A"}}}); err != nil {
+		t.Fatalf("SaveProposal failed: %v", err)
+	}
+	p, err := LoadProposal(path)
+	if err != nil {
+		t.Fatalf("LoadProposal failed: %v", err)
+	}
+	if p.Tasks[0].Title != "A" {
+		t.Errorf("unexpected loaded proposal: %+v", p)
+	}
+}
+
+func TestDecomposePrompt(t *testing.T) {
+	prompt := DecomposePrompt("# Feature: auth", []string{"test", "feature"})
+	if !strings.Contains(prompt, "# Feature: auth") || !strings.Contains(prompt, "feature, test") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+}
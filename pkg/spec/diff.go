@@ -0,0 +1,218 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffOp is the kind of a diff line.
+type DiffOp byte
+
+const (
+	DiffEqual  DiffOp = ' '
+	DiffDelete DiffOp = '-'
+	DiffInsert DiffOp = '+'
+)
+
+// DiffLine is one line of a line-based diff.
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+	// OldLine and NewLine are 1-based positions in each side (0 if absent).
+	OldLine int
+	NewLine int
+}
+
+// Diff returns a line diff turning a into b, using the longest common
+// subsequence of lines.
+func Diff(a, b string) []DiffLine {
+	x, y := splitLines(a), splitLines(b)
+
+	// Strip the common prefix and suffix so the LCS table stays small for
+	// the typical small edit.
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+	mx, my := x[prefix:len(x)-suffix], y[prefix:len(y)-suffix]
+
+	// lcs[i][j] is the LCS length of mx[i:] and my[j:].
+	lcs := make([][]int, len(mx)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(my)+1)
+	}
+	for i := len(mx) - 1; i >= 0; i-- {
+		for j := len(my) - 1; j >= 0; j-- {
+			if mx[i] == my[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: x[i], OldLine: i + 1, NewLine: i + 1})
+	}
+	i, j := 0, 0
+	for i < len(mx) || j < len(my) {
+		switch {
+		case i < len(mx) && j < len(my) && mx[i] == my[j]:
+			lines = append(lines, DiffLine{Op: DiffEqual, Text: mx[i], OldLine: prefix + i + 1, NewLine: prefix + j + 1})
+			i++
+			j++
+		case i < len(mx) && (j == len(my) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: mx[i], OldLine: prefix + i + 1})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: my[j], NewLine: prefix + j + 1})
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		oi, nj := len(x)-suffix+k, len(y)-suffix+k
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: x[oi], OldLine: oi + 1, NewLine: nj + 1})
+	}
+	return lines
+}
+
+// UnifiedDiff formats the diff of a and b in unified format with the given
+// number of context lines. It returns "" when the contents are equal.
+func UnifiedDiff(oldName, newName, a, b string, context int) string {
+	lines := Diff(a, b)
+
+	// Collect hunks: ranges of lines around changes, merging close ones.
+	type hunk struct{ start, end int }
+	var hunks []hunk
+	for idx, l := range lines {
+		if l.Op == DiffEqual {
+			continue
+		}
+		start, end := max(idx-context, 0), min(idx+context+1, len(lines))
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+		} else {
+			hunks = append(hunks, hunk{start, end})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks {
+		oldStart, newStart, oldCount, newCount := 0, 0, 0, 0
+		for _, l := range lines[h.start:h.end] {
+			if l.Op != DiffInsert {
+				if oldCount == 0 {
+					oldStart = l.OldLine
+				}
+				oldCount++
+			}
+			if l.Op != DiffDelete {
+				if newCount == 0 {
+					newStart = l.NewLine
+				}
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, l := range lines[h.start:h.end] {
+			fmt.Fprintf(&out, "%c%s\n", l.Op, l.Text)
+		}
+	}
+	return out.String()
+}
+
+// SectionChange describes a spec section that differs between versions.
+type SectionChange struct {
+	Anchor Anchor
+	// Kind is "added", "removed" or "modified".
+	Kind string
+}
+
+// ChangedSections compares the sections of two spec versions. A section's
+// content includes its subsections; the document title (level 1) is
+// skipped. Results follow the new document's order, then removed sections.
+func ChangedSections(oldContent, newContent string) []SectionChange {
+	oldAnchors, oldBodies := sectionBodies(oldContent)
+	newAnchors, newBodies := sectionBodies(newContent)
+
+	var changes []SectionChange
+	for _, a := range newAnchors {
+		old, ok := oldBodies[a.Slug]
+		switch {
+		case !ok:
+			changes = append(changes, SectionChange{Anchor: a, Kind: "added"})
+		case old != newBodies[a.Slug]:
+			changes = append(changes, SectionChange{Anchor: a, Kind: "modified"})
+		}
+	}
+	for _, a := range oldAnchors {
+		if _, ok := newBodies[a.Slug]; !ok {
+			changes = append(changes, SectionChange{Anchor: a, Kind: "removed"})
+		}
+	}
+	return changes
+}
+
+// SectionChanged reports whether the section with the given anchor slug
+// differs between two spec versions, including being removed.
+func SectionChanged(oldContent, newContent, slug string) bool {
+	_, oldBodies := sectionBodies(oldContent)
+	_, newBodies := sectionBodies(newContent)
+	old, inOld := oldBodies[slug]
+	cur, inNew := newBodies[slug]
+	return inOld != inNew || old != cur
+}
+
+// Section returns the anchor and content of the section with the given
+// slug, including its subsections, and whether the spec has it.
+func Section(content, slug string) (Anchor, string, bool) {
+	anchors, bodies := sectionBodies(content)
+	for _, a := range anchors {
+		if a.Slug == slug {
+			return a, bodies[slug], true
+		}
+	}
+	return Anchor{}, "", false
+}
+
+// sectionBodies returns the non-title anchors of a spec and the content of
+// each section (heading line excluded, trailing blank lines trimmed).
+func sectionBodies(content string) ([]Anchor, map[string]string) {
+	lines := strings.Split(content, "\n")
+	anchors := ExtractAnchors(content)
+
+	var kept []Anchor
+	bodies := make(map[string]string)
+	for i, a := range anchors {
+		if a.Level == 1 {
+			continue
+		}
+		end := len(lines)
+		for _, next := range anchors[i+1:] {
+			if next.Level <= a.Level {
+				end = next.Line - 1
+				break
+			}
+		}
+		bodies[a.Slug] = strings.TrimRight(strings.Join(lines[a.Line:end], "\n"), "\n \t")
+		kept = append(kept, a)
+	}
+	return kept, bodies
+}
+
+// splitLines splits content into lines, ignoring a final newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,121 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+const diffOld = `# Feature: Login
+
+## Goal
+
+Users sign in with a password.
+
+## OAuth
+
+Support GitHub.
+
+### Scopes
+
+read:user
+
+## Rollout
+
+Everyone at once.
+`
+
+const diffNew = `# Feature: Login
+
+## Goal
+
+Users sign in with a password.
+
+## OAuth
+
+Support GitHub.
+
+### Scopes
+
+read:user, user:email
+
+## Metrics
+
+Track sign-in success rate.
+`
+
+func TestDiff(t *testing.T) {
+	lines := Diff("a\nb\nc\n", "a\nx\nc\nd\n")
+	var got []string
+	for _, l := range lines {
+		got = append(got, string(l.Op)+l.Text)
+	}
+	want := []string{" a", "-b", "+x", " c", "+d"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Diff = %v, want %v", got, want)
+	}
+
+	if d := Diff("same\n", "same\n"); len(d) != 1 || d[0].Op != DiffEqual {
+		t.Errorf("expected only equal lines, got %+v", d)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if out := UnifiedDiff("a", "b", diffOld, diffOld, 3); out != "" {
+		t.Errorf("expected no diff for equal content, got %q", out)
+	}
+
+	out := UnifiedDiff("SPEC.md v1", "SPEC.md v2", diffOld, diffNew, 1)
+	for _, want := range []string{
+		"--- SPEC.md v1\n+++ SPEC.md v2\n",
+		"-read:user\n+read:user, user:email\n",
+		"-## Rollout\n",
+		"+## Metrics\n",
+		"@@ -12,6 +12,6 @@\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in diff:\n%s", want, out)
+		}
+	}
+}
+
+func TestChangedSections(t *testing.T) {
+	changes := ChangedSections(diffOld, diffNew)
+	got := make(map[string]string)
+	for _, c := range changes {
+		got[c.Anchor.Slug] = c.Kind
+	}
+	want := map[string]string{
+		"oauth":   "modified", // via its Scopes subsection
+		"scopes":  "modified",
+		"metrics": "added",
+		"rollout": "removed",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ChangedSections = %v, want %v", got, want)
+	}
+	for slug, kind := range want {
+		if got[slug] != kind {
+			t.Errorf("section %s: got %q, want %q", slug, got[slug], kind)
+		}
+	}
+
+	if SectionChanged(diffOld, diffNew, "goal") {
+		t.Error("expected goal to be unchanged")
+	}
+	if !SectionChanged(diffOld, diffNew, "rollout") {
+		t.Error("expected removed section to count as changed")
+	}
+}
+
+func TestSection(t *testing.T) {
+	anchor, body, ok := Section(diffNew, "oauth")
+	if !ok || anchor.Heading != "OAuth" {
+		t.Fatalf("expected the OAuth section, got %+v, %v", anchor, ok)
+	}
+	if body != "\nSupport GitHub.\n\n### Scopes\n\nread:user, user:email" {
+		t.Errorf("expected the section with its subsections, got %q", body)
+	}
+	if _, _, ok := Section(diffNew, "rollout"); ok {
+		t.Error("expected no section for a missing anchor")
+	}
+}
@@ -0,0 +1,176 @@
+package spec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyIndex is the index file inside a history directory.
+const historyIndex = "index.json"
+
+// Version is a snapshot of the spec.
+type Version struct {
+	Number    int       `json:"version"`
+	Hash      string    `json:"hash"`
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Label returns the version as "v<n>".
+func (v Version) Label() string {
+	return fmt.Sprintf("v%d", v.Number)
+}
+
+// historyData is the JSON structure of the history index.
+type historyData struct {
+	Versions []Version `json:"versions"`
+}
+
+// History stores numbered snapshots of the spec in a directory: an index
+// plus one file per version.
+type History struct {
+	dir string
+}
+
+// NewHistory creates a history stored in dir.
+func NewHistory(dir string) *History {
+	return &History{dir: dir}
+}
+
+// ParseVersion parses "3" or "v3".
+func ParseVersion(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(s), "v"))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid spec version '%s' (want e.g. v3)", s)
+	}
+	return n, nil
+}
+
+// List returns all versions, oldest first.
+func (h *History) List() ([]Version, error) {
+	data, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+	return data.Versions, nil
+}
+
+// Latest returns the newest version, or nil if there are none.
+func (h *History) Latest() (*Version, error) {
+	versions, err := h.List()
+	if err != nil || len(versions) == 0 {
+		return nil, err
+	}
+	return &versions[len(versions)-1], nil
+}
+
+// At returns the newest version created at or before t, or nil.
+func (h *History) At(t time.Time) (*Version, error) {
+	versions, err := h.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !versions[i].CreatedAt.After(t) {
+			return &versions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Read returns the content of a version.
+func (h *History) Read(number int) (string, error) {
+	data, err := os.ReadFile(h.versionPath(number))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("spec version v%d not found", number)
+		}
+		return "", fmt.Errorf("failed to read spec version: %w", err)
+	}
+	return string(data), nil
+}
+
+// Commit snapshots content as a new version. When content matches the
+// latest version nothing is written and the latest version is returned
+// with created false.
+func (h *History) Commit(content, message string) (v *Version, created bool, err error) {
+	data, err := h.load()
+	if err != nil {
+		return nil, false, err
+	}
+
+	hash := contentHash(content)
+	if n := len(data.Versions); n > 0 && data.Versions[n-1].Hash == hash {
+		return &data.Versions[n-1], false, nil
+	}
+
+	version := Version{
+		Number:    len(data.Versions) + 1,
+		Hash:      hash,
+		Message:   message,
+		CreatedAt: time.Now().UTC(),
+	}
+	if n := len(data.Versions); n > 0 {
+		version.Number = data.Versions[n-1].Number + 1
+	}
+
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		return nil, false, fmt.Errorf("failed to create spec history: %w", err)
+	}
+	if err := os.WriteFile(h.versionPath(version.Number), []byte(content), 0644); err != nil {
+		return nil, false, fmt.Errorf("failed to write spec version: %w", err)
+	}
+	data.Versions = append(data.Versions, version)
+	if err := h.save(data); err != nil {
+		return nil, false, err
+	}
+	return &version, true, nil
+}
+
+func (h *History) versionPath(number int) string {
+	return filepath.Join(h.dir, fmt.Sprintf("v%04d.md", number))
+}
+
+func (h *History) load() (*historyData, error) {
+	data := &historyData{}
+	raw, err := os.ReadFile(filepath.Join(h.dir, historyIndex))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("failed to read spec history: %w", err)
+	}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, fmt.Errorf("failed to parse spec history: %w", err)
+	}
+	return data, nil
+}
+
+// save writes the index via a temp file and rename.
+func (h *History) save(data *historyData) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize spec history: %w", err)
+	}
+	path := filepath.Join(h.dir, historyIndex)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write spec history: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace spec history: %w", err)
+	}
+	return nil
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
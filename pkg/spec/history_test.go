@@ -0,0 +1,62 @@
+package spec
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryCommit(t *testing.T) {
+	h := NewHistory(t.TempDir())
+
+	if latest, err := h.Latest(); err != nil || latest != nil {
+		t.Fatalf("expected empty history, got %v %v", latest, err)
+	}
+
+	v1, created, err := h.Commit("# Feature: x\n", "first")
+	if err != nil || !created || v1.Number != 1 {
+		t.Fatalf("expected v1 to be created, got %+v %v %v", v1, created, err)
+	}
+
+	same, created, err := h.Commit("# Feature: x\n", "again")
+	if err != nil || created || same.Number != 1 {
+		t.Errorf("expected unchanged spec to return v1, got %+v %v %v", same, created, err)
+	}
+
+	v2, created, _ := h.Commit("# Feature: y\n", "")
+	if !created || v2.Number != 2 || v2.Label() != "v2" {
+		t.Errorf("expected v2, got %+v", v2)
+	}
+
+	content, err := h.Read(1)
+	if err != nil || content != "# Feature: x\n" {
+		t.Errorf("unexpected v1 content %q: %v", content, err)
+	}
+	if _, err := h.Read(9); err == nil {
+		t.Error("expected missing version to fail")
+	}
+
+	versions, _ := h.List()
+	if len(versions) != 2 || versions[0].Message != "first" {
+		t.Errorf("unexpected versions: %+v", versions)
+	}
+
+	if v, _ := h.At(v1.CreatedAt.Add(-time.Second)); v != nil {
+		t.Errorf("expected no version before v1, got %+v", v)
+	}
+	if v, _ := h.At(time.Now().Add(time.Hour)); v == nil || v.Number != 2 {
+		t.Errorf("expected v2 as the latest version, got %+v", v)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	for in, want := range map[string]int{"3": 3, "v3": 3, "V12": 12} {
+		if got, err := ParseVersion(in); err != nil || got != want {
+			t.Errorf("ParseVersion(%q) = %d, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"", "v0", "head", "-1"} {
+		if _, err := ParseVersion(in); err == nil {
+			t.Errorf("expected ParseVersion(%q) to fail", in)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sectionPlaceholders holds the starter text for well-known sections, keyed
+// by lowercase heading.
+var sectionPlaceholders = map[string]string{
+	"goal":                "_Describe what the feature should achieve._",
+	"context":             "_Why is this needed? Background, users and constraints._",
+	"success criteria":    "- [ ] Criterion 1\n- [ ] Criterion 2",
+	"overview":            "_Describe the feature here._",
+	"user stories":        "1. As a user, I can...",
+	"acceptance criteria": "- [ ] Criterion 1\n- [ ] Criterion 2",
+	"technical notes":     "_Add technical details here._",
+}
+
+// Template returns a starter SPEC.md with a heading for each required
+// section, so a fresh spec passes validation once filled in. An empty list
+// uses RequiredSections. A Technical Notes section is always included.
+func Template(feature string, sections []string) string {
+	if len(sections) == 0 {
+		sections = RequiredSections
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Feature: %s\n", feature)
+
+	hasNotes := false
+	for _, section := range sections {
+		if strings.EqualFold(section, "Technical Notes") {
+			hasNotes = true
+		}
+		writeSection(&b, section)
+	}
+	if !hasNotes {
+		writeSection(&b, "Technical Notes")
+	}
+	return b.String()
+}
+
+func writeSection(b *strings.Builder, section string) {
+	placeholder, ok := sectionPlaceholders[strings.ToLower(section)]
+	if !ok {
+		placeholder = fmt.Sprintf("_Describe %s here._", strings.ToLower(section))
+	}
+	fmt.Fprintf(b, "\n## %s\n\n%s\n", section, placeholder)
+}
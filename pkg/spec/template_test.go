@@ -0,0 +1,40 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateMatchesValidator(t *testing.T) {
+	tests := [][]string{
+		nil,
+		{"Overview", "User Stories", "Acceptance Criteria"},
+		{"Problem", "Technical Notes"},
+	}
+
+	for _, sections := range tests {
+		content := Template("login", sections)
+		if !strings.HasPrefix(content, "# Feature: login\n") {
+			t.Errorf("%v: unexpected title:\n%s", sections, content)
+		}
+		if strings.Count(content, "## Technical Notes") != 1 {
+			t.Errorf("%v: expected one Technical Notes section:\n%s", sections, content)
+		}
+		result := NewValidatorWithSections(sections).Validate(content)
+		if !result.Valid {
+			t.Errorf("%v: template fails validation: missing %v, errors %v", sections, result.MissingSections, result.Errors)
+		}
+	}
+}
+
+func TestValidatorWithSections(t *testing.T) {
+	v := NewValidatorWithSections([]string{"Overview", "User Stories"})
+	result := v.Validate("# Feature\n\n## Overview\n\n## Goal\n")
+	if result.Valid || len(result.MissingSections) != 1 || result.MissingSections[0] != "User Stories" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if got := NewValidatorWithSections(nil).RequiredSections(); len(got) != len(RequiredSections) {
+		t.Errorf("expected default sections, got %v", got)
+	}
+}
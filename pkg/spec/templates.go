@@ -0,0 +1,352 @@
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplateName is the template used when none is requested.
+const DefaultTemplateName = "default"
+
+// templateExt is the file extension of user spec templates.
+const templateExt = ".md"
+
+// SpecTemplate is a named starting point for a SPEC.md.
+type SpecTemplate struct {
+	Name        string
+	Description string
+	// Source is "built-in" or the path of a user template file.
+	Source  string
+	Content string
+}
+
+// TemplateData is the data available to spec templates.
+type TemplateData struct {
+	Feature string
+	// Sections are the workspace's required spec sections.
+	Sections []string
+}
+
+// builtinSource marks templates shipped with flo.
+const builtinSource = "built-in"
+
+// builtinTemplates are the templates shipped with flo. The default template
+// is generated from the required sections (see Template).
+var builtinTemplates = []SpecTemplate{
+	{
+		Name:        DefaultTemplateName,
+		Description: "General feature with the required sections",
+		Source:      builtinSource,
+	},
+	{
+		Name:        "api",
+		Description: "HTTP/RPC API feature: endpoints, payloads, errors and auth",
+		Source:      builtinSource,
+		Content: `# Feature: {{.Feature}}
+
+## Goal
+
+_What the API lets clients do._
+
+## Context
+
+_Who calls this API and why. Existing endpoints it relates to._
+
+## Endpoints
+
+| Method | Path | Description |
+|--------|------|-------------|
+| GET    | /... | ...         |
+
+## Request & Response
+
+_Payload schemas and examples._
+
+## Errors
+
+_Error codes, status mapping and retry semantics._
+
+## Authentication & Authorization
+
+_Who may call each endpoint._
+
+## Success Criteria
+
+- [ ] Endpoints implemented with contract tests
+- [ ] Errors documented and covered by tests
+- [ ] API documentation updated
+
+## Technical Notes
+
+_Versioning, pagination, rate limits._
+`,
+	},
+	{
+		Name:        "bugfix",
+		Description: "Bug fix: reproduction, root cause and regression test",
+		Source:      builtinSource,
+		Content: `# Bug: {{.Feature}}
+
+## Goal
+
+_Describe the correct behaviour once fixed._
+
+## Context
+
+**Observed:** _What happens._
+
+**Expected:** _What should happen._
+
+**Steps to reproduce:**
+
+1. ...
+
+## Root Cause
+
+_Fill in once investigated._
+
+## Fix
+
+_The smallest change that corrects the behaviour._
+
+## Success Criteria
+
+- [ ] Failing regression test reproduces the bug
+- [ ] Test passes with the fix
+- [ ] No related regressions
+
+## Technical Notes
+
+_Affected versions, workarounds._
+`,
+	},
+	{
+		Name:        "migration",
+		Description: "Data or system migration with rollout and rollback plans",
+		Source:      builtinSource,
+		Content: `# Migration: {{.Feature}}
+
+## Goal
+
+_What the system looks like after the migration._
+
+## Context
+
+_Why migrate now; constraints such as downtime and data volume._
+
+## Current State
+
+_Schemas, services or versions in use today._
+
+## Target State
+
+_Schemas, services or versions after the migration._
+
+## Migration Plan
+
+1. ...
+
+## Rollback Plan
+
+_How to undo each step safely._
+
+## Success Criteria
+
+- [ ] Migration verified on a copy of production data
+- [ ] Rollback tested
+- [ ] No data loss (row counts / checksums match)
+
+## Technical Notes
+
+_Backfills, dual writes, feature flags._
+`,
+	},
+	{
+		Name:        "mobile",
+		Description: "Mobile app feature: platforms, screens and device concerns",
+		Source:      builtinSource,
+		Content: `# Feature: {{.Feature}}
+
+## Goal
+
+_What users can do in the app once shipped._
+
+## Context
+
+_Target users and the problem being solved._
+
+## Platforms
+
+- [ ] iOS (minimum version: ...)
+- [ ] Android (minimum API level: ...)
+
+## Screens & Flows
+
+1. ...
+
+## Offline, Permissions & Notifications
+
+_Behaviour without connectivity; permissions requested; push notifications._
+
+## Success Criteria
+
+- [ ] UI tests for the main flows on each platform
+- [ ] Accessibility labels and dynamic type supported
+- [ ] Analytics events recorded
+
+## Technical Notes
+
+_APIs used, release and feature-flag plan._
+`,
+	},
+}
+
+// TemplateLibrary resolves spec templates from the built-ins and a user
+// template directory. A user template named like a built-in replaces it.
+type TemplateLibrary struct {
+	userDir string
+}
+
+// NewTemplateLibrary creates a library reading user templates from userDir.
+// An empty userDir disables user templates.
+func NewTemplateLibrary(userDir string) *TemplateLibrary {
+	return &TemplateLibrary{userDir: userDir}
+}
+
+// DefaultTemplateDir returns the user template directory,
+// $XDG_CONFIG_HOME/flo/templates or ~/.config/flo/templates.
+func DefaultTemplateDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "flo", "templates"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "flo", "templates"), nil
+}
+
+// DefaultTemplateLibrary returns a library using the default user directory.
+func DefaultTemplateLibrary() *TemplateLibrary {
+	dir, _ := DefaultTemplateDir()
+	return NewTemplateLibrary(dir)
+}
+
+// Dir returns the user template directory.
+func (l *TemplateLibrary) Dir() string {
+	return l.userDir
+}
+
+// List returns all available templates sorted by name.
+func (l *TemplateLibrary) List() ([]SpecTemplate, error) {
+	byName := make(map[string]SpecTemplate)
+	for _, t := range builtinTemplates {
+		byName[t.Name] = t
+	}
+
+	user, err := l.userTemplates()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range user {
+		byName[t.Name] = t
+	}
+
+	var templates []SpecTemplate
+	for _, t := range byName {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// Get returns the template with the given name.
+func (l *TemplateLibrary) Get(name string) (*SpecTemplate, error) {
+	if name == "" {
+		name = DefaultTemplateName
+	}
+	templates, err := l.List()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, t := range templates {
+		if t.Name == name {
+			return &t, nil
+		}
+		names = append(names, t.Name)
+	}
+	return nil, fmt.Errorf("unknown spec template '%s' (available: %s)", name, strings.Join(names, ", "))
+}
+
+// Render renders the named template.
+func (l *TemplateLibrary) Render(name string, data TemplateData) (string, error) {
+	t, err := l.Get(name)
+	if err != nil {
+		return "", err
+	}
+	if t.Name == DefaultTemplateName && t.Source == builtinSource {
+		return Template(data.Feature, data.Sections), nil
+	}
+
+	tmpl, err := template.New(t.Name).Option("missingkey=error").Parse(t.Content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse spec template '%s': %w", t.Name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render spec template '%s': %w", t.Name, err)
+	}
+	return buf.String(), nil
+}
+
+// userTemplates reads *.md templates from the user directory.
+func (l *TemplateLibrary) userTemplates() ([]SpecTemplate, error) {
+	if l.userDir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(l.userDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read spec templates: %w", err)
+	}
+
+	var templates []SpecTemplate
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != templateExt {
+			continue
+		}
+		path := filepath.Join(l.userDir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spec template: %w", err)
+		}
+		description, content := splitDescription(string(data))
+		templates = append(templates, SpecTemplate{
+			Name:        strings.TrimSuffix(e.Name(), templateExt),
+			Description: description,
+			Source:      path,
+			Content:     content,
+		})
+	}
+	return templates, nil
+}
+
+// splitDescription strips an optional leading "<!-- description -->" line
+// from a user template and returns it as the description.
+func splitDescription(content string) (string, string) {
+	first, rest, _ := strings.Cut(content, "\n")
+	first = strings.TrimSpace(first)
+	if strings.HasPrefix(first, "<!--") && strings.HasSuffix(first, "-->") {
+		description := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(first, "<!--"), "-->"))
+		return description, strings.TrimLeft(rest, "\n")
+	}
+	return "User template", content
+}
@@ -0,0 +1,78 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinTemplatesPassValidation(t *testing.T) {
+	library := NewTemplateLibrary("")
+	templates, err := library.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(templates) != 5 {
+		t.Errorf("expected 5 built-in templates, got %d", len(templates))
+	}
+
+	for _, tmpl := range templates {
+		content, err := library.Render(tmpl.Name, TemplateData{Feature: "checkout"})
+		if err != nil {
+			t.Fatalf("%s: Render failed: %v", tmpl.Name, err)
+		}
+		if !strings.Contains(content, "checkout") {
+			t.Errorf("%s: expected feature name in spec", tmpl.Name)
+		}
+		if result := NewValidator().Validate(content); !result.Valid {
+			t.Errorf("%s: template fails validation: missing %v", tmpl.Name, result.MissingSections)
+		}
+	}
+}
+
+func TestUserTemplates(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "rfc.md"), []byte("<!-- Team RFC -->\n# RFC: {{.Feature}}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "bugfix.md"), []byte("# Incident: {{.Feature}}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644)
+
+	library := NewTemplateLibrary(dir)
+	rfc, err := library.Get("rfc")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if rfc.Description != "Team RFC" || rfc.Source != filepath.Join(dir, "rfc.md") {
+		t.Errorf("unexpected user template: %+v", rfc)
+	}
+
+	content, err := library.Render("rfc", TemplateData{Feature: "search"})
+	if err != nil || content != "# RFC: search\n" {
+		t.Errorf("unexpected render: %q (%v)", content, err)
+	}
+	if content, _ := library.Render("bugfix", TemplateData{Feature: "crash"}); content != "# Incident: crash\n" {
+		t.Errorf("expected user template to replace built-in, got %q", content)
+	}
+
+	if _, err := library.Get("notes"); err == nil || !strings.Contains(err.Error(), "rfc") {
+		t.Errorf("expected unknown template error listing templates, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "broken.md"), []byte("# {{.Nope}}"), 0644)
+	if _, err := library.Render("broken", TemplateData{}); err == nil {
+		t.Error("expected error for unknown template field")
+	}
+}
+
+func TestDefaultTemplateDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if dir, _ := DefaultTemplateDir(); dir != filepath.Join("/xdg", "flo", "templates") {
+		t.Errorf("unexpected XDG template dir: %s", dir)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/home/sam")
+	if dir, _ := DefaultTemplateDir(); dir != filepath.Join("/home/sam", ".config", "flo", "templates") {
+		t.Errorf("unexpected template dir: %s", dir)
+	}
+}
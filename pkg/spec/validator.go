@@ -9,6 +9,7 @@ import (
 )
 
 // RequiredSections lists the sections that must be present in a valid SPEC.md
+// when the workspace config does not override them.
 var RequiredSections = []string{"Goal", "Context", "Success Criteria"}
 
 // ValidationResult represents the outcome of spec validation.
@@ -19,11 +20,27 @@ type ValidationResult struct {
 }
 
 // Validator validates SPEC.md files.
-type Validator struct{}
+type Validator struct {
+	required []string
+}
 
-// NewValidator creates a new spec validator.
+// NewValidator creates a new spec validator requiring the default sections.
 func NewValidator() *Validator {
-	return &Validator{}
+	return &Validator{required: RequiredSections}
+}
+
+// NewValidatorWithSections creates a validator requiring the given sections.
+// An empty list falls back to RequiredSections.
+func NewValidatorWithSections(sections []string) *Validator {
+	if len(sections) == 0 {
+		return NewValidator()
+	}
+	return &Validator{required: sections}
+}
+
+// RequiredSections returns the sections this validator requires.
+func (v *Validator) RequiredSections() []string {
+	return v.required
 }
 
 // ValidateFile validates a SPEC.md file at the given path.
@@ -48,7 +65,7 @@ func (v *Validator) Validate(content string) *ValidationResult {
 	sections := v.extractSections(content)
 
 	// Check for required sections
-	for _, required := range RequiredSections {
+	for _, required := range v.required {
 		if !v.hasSectionCaseInsensitive(sections, required) {
 			result.MissingSections = append(result.MissingSections, required)
 			result.Valid = false
@@ -0,0 +1,251 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestRegistryPreservesUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	os.WriteFile(path, []byte(`{
+		"version": 3,
+		"generator": "flo 2.0",
+		"tasks": [{
+			"id": "t-001",
+			"title": "Future task",
+			"status": "pending",
+			"labels": ["auth", "p1"],
+			"estimate": {"hours": 3},
+			"created_at": "2026-02-05T22:00:00Z",
+			"updated_at": "2026-02-05T22:00:00Z"
+		}]
+	}`), 0644)
+
+	r := NewRegistry()
+	if err := r.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	tk, _ := r.Get("t-001")
+	tk.SetStatus(StatusInProgress)
+	r.Update(tk)
+	if err := r.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	var saved map[string]interface{}
+	data, _ := os.ReadFile(path)
+	json.Unmarshal(data, &saved)
+	if saved["generator"] != "flo 2.0" {
+		t.Errorf("expected manifest field 'generator' preserved, got %v", saved["generator"])
+	}
+	saved1 := saved["tasks"].([]interface{})[0].(map[string]interface{})
+	if saved1["status"] != "in_progress" {
+		t.Errorf("expected updated status, got %v", saved1["status"])
+	}
+	if labels, ok := saved1["labels"].([]interface{}); !ok || len(labels) != 2 {
+		t.Errorf("expected task field 'labels' preserved, got %v", saved1["labels"])
+	}
+	if saved1["estimate"] == nil {
+		t.Error("expected task field 'estimate' preserved")
+	}
+}
+
+func TestRegistrySchemaVersion(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+
+	// Legacy manifests without a schema are upgraded to the current schema
+	os.WriteFile(path, []byte(`{"version": 1, "tasks": []}`), 0644)
+	r := NewRegistry()
+	if err := r.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	r.Add(New("t-001", "A"))
+	if err := r.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	var saved map[string]interface{}
+	data, _ := os.ReadFile(path)
+	json.Unmarshal(data, &saved)
+	if saved["schema"] != float64(SchemaVersion) || saved["version"] != float64(2) {
+		t.Errorf("expected schema %d and version 2, got %v / %v", SchemaVersion, saved["schema"], saved["version"])
+	}
+
+	// Newer schemas load read-only and are never overwritten
+	newer := fmt.Sprintf(`{"schema": %d, "version": 4, "tasks": [{"id": "t-001", "title": "A", "status": "pending"}]}`, SchemaVersion+1)
+	os.WriteFile(path, []byte(newer), 0644)
+	r = NewRegistry()
+	if err := r.Load(path); err != nil {
+		t.Fatalf("expected newer schema to load for reading: %v", err)
+	}
+	if !r.ReadOnly() {
+		t.Error("expected registry to be read-only")
+	}
+	err := r.Save(path)
+	if !errors.Is(err, ErrSchemaTooNew) || !strings.Contains(err.Error(), "upgrade flo") {
+		t.Errorf("expected upgrade error, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Error("expected newer manifest to be left untouched")
+	}
+
+	// A manifest upgraded by a newer flo after we loaded is not downgraded
+	current := NewRegistry()
+	os.WriteFile(path, []byte(`{"schema": 1, "version": 1, "tasks": []}`), 0644)
+	current.Load(path)
+	os.WriteFile(path, []byte(fmt.Sprintf(`{"schema": %d, "version": 1, "tasks": []}`, SchemaVersion+1)), 0644)
+	if err := current.Save(path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("expected concurrent upgrade to be detected, got %v", err)
+	}
+}
+
+func TestTaskJSONRoundTripProperty(t *testing.T) {
+	roundTrip := func(id, title, desc, repo, specRef string, priority int, deps []string, seconds int64) bool {
+		original := &Task{
+			ID:          "t-" + id,
+			Title:       "title " + title,
+			Description: desc,
+			Status:      StatusPending,
+			Priority:    priority,
+			Repo:        repo,
+			Deps:        deps,
+			SpecRef:     specRef,
+			CreatedAt:   time.Unix(seconds%(1<<34), 0).UTC(),
+			UpdatedAt:   time.Unix(seconds%(1<<34), 0).UTC(),
+		}
+		data, err := json.Marshal(original)
+		if err != nil {
+			return false
+		}
+		var restored Task
+		if err := json.Unmarshal(data, &restored); err != nil {
+			return false
+		}
+		if len(original.Deps) == 0 {
+			original.Deps = nil // omitempty drops empty slices
+		}
+		return reflect.DeepEqual(original, &restored)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRegistrySaveLoadProperty(t *testing.T) {
+	dir := t.TempDir()
+	statuses := []Status{StatusPending, StatusInProgress, StatusComplete, StatusFailed}
+
+	property := func(seed int64) bool {
+		rng := rand.New(rand.NewSource(seed))
+		r := NewRegistry()
+		var ids []string
+		for i := 0; i < rng.Intn(8)+1; i++ {
+			tk := New("t-"+strings.Repeat("x", i+1), "Task")
+			tk.Status = statuses[rng.Intn(len(statuses))]
+			tk.Priority = rng.Intn(5)
+			if len(ids) > 0 && rng.Intn(2) == 0 {
+				tk.Deps = []string{ids[rng.Intn(len(ids))]}
+			}
+			if err := r.Add(tk); err != nil {
+				return false
+			}
+			ids = append(ids, tk.ID)
+		}
+
+		path := filepath.Join(dir, "manifest-"+time.Now().Format("150405.000000000")+".json")
+		if err := r.Save(path); err != nil {
+			return false
+		}
+		loaded := NewRegistry()
+		if err := loaded.Load(path); err != nil {
+			return false
+		}
+		for _, id := range ids {
+			want, _ := r.Get(id)
+			got, err := loaded.Get(id)
+			if err != nil {
+				return false
+			}
+			wantJSON, _ := json.Marshal(want)
+			gotJSON, _ := json.Marshal(got)
+			if string(wantJSON) != string(gotJSON) {
+				return false
+			}
+		}
+		return len(loaded.List()) == len(ids)
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzRegistryLoad(f *testing.F) {
+	f.Add([]byte(`{"version": 1, "tasks": []}`))
+	f.Add([]byte(`{"version": 2, "tasks": [{"id": "t-001", "title": "A", "status": "pending", "created_at": "2026-02-05T22:00:00Z", "updated_at": "2026-02-05T22:00:00Z"}]}`))
+	f.Add([]byte(`{"version": 1, "tasks": [{"id": "t-002", "title": "B", "status": "complete", "deps": ["t-001"], "x": 1}, {"id": "t-001", "title": "A", "status": "pending"}], "future": true}`))
+	f.Add([]byte(`{"tasks": [null]}`))
+	f.Add([]byte(`{"schema": 9, "version": 1, "tasks": []}`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "manifest.json")
+		os.WriteFile(path, data, 0644)
+
+		r := NewRegistry()
+		if err := r.Load(path); err != nil {
+			return
+		}
+		out := filepath.Join(dir, "resaved.json")
+		if r.ReadOnly() {
+			if err := r.Save(out); !errors.Is(err, ErrSchemaTooNew) {
+				t.Fatalf("expected newer schema to be refused, got %v", err)
+			}
+			return
+		}
+		if err := r.Save(out); err != nil {
+			t.Fatalf("loaded registry failed to save: %v", err)
+		}
+		reloaded := NewRegistry()
+		if err := reloaded.Load(out); err != nil {
+			t.Fatalf("saved registry failed to load: %v", err)
+		}
+		if len(reloaded.List()) != len(r.List()) {
+			t.Fatalf("task count changed on round trip: %d → %d", len(r.List()), len(reloaded.List()))
+		}
+	})
+}
+
+func FuzzTaskJSON(f *testing.F) {
+	f.Add([]byte(`{"id": "t-001", "title": "A", "status": "pending", "created_at": "2026-02-05T22:00:00+01:00"}`))
+	f.Add([]byte(`{"id": "t-001", "title": "A", "labels": ["x"], "ID": "dup"}`))
+	f.Add([]byte(`[]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var tk Task
+		if err := json.Unmarshal(data, &tk); err != nil {
+			return
+		}
+		first, err := json.Marshal(&tk)
+		if err != nil {
+			t.Fatalf("decoded task failed to encode: %v", err)
+		}
+		var again Task
+		if err := json.Unmarshal(first, &again); err != nil {
+			t.Fatalf("encoded task failed to decode: %v\n%s", err, first)
+		}
+		second, _ := json.Marshal(&again)
+		if string(first) != string(second) {
+			t.Fatalf("encoding not stable:\n%s\n%s", first, second)
+		}
+	})
+}
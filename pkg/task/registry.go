@@ -1,20 +1,145 @@
 package task
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
 	"syscall"
 
 	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/events"
+	"github.com/richgo/flo/pkg/jsoncompat"
+	"github.com/richgo/flo/pkg/telemetry"
 )
 
+// SchemaVersion is the manifest schema this version of flo reads and writes.
+// It is bumped only for changes older versions can't safely rewrite; new
+// optional fields don't need a bump because unknown fields are preserved.
+// Manifests without a schema predate versioning and are treated as schema 1.
+const SchemaVersion = 1
+
+// ErrSchemaTooNew is returned when saving over a manifest written with a
+// newer schema than this version of flo supports.
+var ErrSchemaTooNew = errors.New("manifest schema too new")
+
+// ErrTaskNotFound is returned for a task ID that isn't in the registry.
+var ErrTaskNotFound = errors.New("not found")
+
+// ErrNotFound is ErrTaskNotFound.
+//
+// Deprecated: use ErrTaskNotFound.
+var ErrNotFound = ErrTaskNotFound
+
+// ErrVersionConflict is matched by errors saving a manifest another
+// registry has saved since this one loaded it.
+var ErrVersionConflict = errors.New("version conflict")
+
+// VersionConflictError reports a manifest saved by someone else since the
+// registry loaded it: Expected is the version loaded, Found the version
+// now on disk. Reload the manifest and reapply the change to save it.
+type VersionConflictError struct {
+	Expected int
+	Found    int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("version conflict: expected %d, found %d", e.Expected, e.Found)
+}
+
+// Is reports whether target is ErrVersionConflict.
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// ErrMutexHeld is matched by errors starting a task while another task
+// with the same mutex is in progress.
+var ErrMutexHeld = errors.New("mutex held")
+
+// MutexError reports a task that can't start while Holder, another task
+// with the same Mutex, is in progress.
+type MutexError struct {
+	TaskID string
+	Mutex  string
+	Holder string
+}
+
+func (e *MutexError) Error() string {
+	return fmt.Sprintf("task %s can't start while task %s holds mutex '%s'", e.TaskID, e.Holder, e.Mutex)
+}
+
+// Is reports whether target is ErrMutexHeld.
+func (e *MutexError) Is(target error) bool {
+	return target == ErrMutexHeld
+}
+
+// SchemaError reports a manifest whose schema this version can't write.
+type SchemaError struct {
+	Found     int
+	Supported int
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("task manifest uses schema %d but this flo supports schema %d; upgrade flo to modify this workspace",
+		e.Found, e.Supported)
+}
+
+// Is reports whether target is ErrSchemaTooNew.
+func (e *SchemaError) Is(target error) bool {
+	return target == ErrSchemaTooNew
+}
+
 // Registry manages a collection of tasks with dependency tracking.
 type Registry struct {
 	tasks   map[string]*Task
 	mu      sync.RWMutex
-	version int // Optimistic concurrency control version
+	version int               // Optimistic concurrency control version
+	schema  int               // Manifest schema version as loaded
+	extra   jsoncompat.Fields // Manifest fields from newer versions, kept on save
+
+	watchers    map[int]func(id string)
+	nextWatcher int
+
+	// log receives the registry's audit events; nil uses the default
+	// logger.
+	log *audit.Logger
+	// tracer records spans of registry operations; nil records none.
+	tracer *telemetry.Provider
+}
+
+// SetAuditLogger sends the registry's audit events to l, such as the log
+// of the workspace it belongs to.
+func (r *Registry) SetAuditLogger(l *audit.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = l
+}
+
+// SetTelemetry records spans of the registry's operations with p.
+func (r *Registry) SetTelemetry(p *telemetry.Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tracer = p
+}
+
+// startSpan starts the span of a registry operation, on taskID when set.
+func (r *Registry) startSpan(name, taskID string) *telemetry.Span {
+	var attrs []telemetry.Attr
+	if taskID != "" {
+		attrs = append(attrs, telemetry.String("task.id", taskID))
+	}
+	_, span := r.tracer.Start(context.Background(), name, attrs...)
+	return span
+}
+
+// auditLog returns the logger for the registry's audit events.
+func (r *Registry) auditLog() *audit.Logger {
+	if r.log != nil {
+		return r.log
+	}
+	return audit.Default()
 }
 
 // NewRegistry creates an empty task registry.
@@ -26,11 +151,14 @@ func NewRegistry() *Registry {
 
 // Add adds a task to the registry.
 // Returns error if task ID exists, validation fails, or deps are invalid.
-func (r *Registry) Add(task *Task) error {
+func (r *Registry) Add(task *Task) (err error) {
+	span := r.startSpan("task.registry.add", task.ID)
+	defer func() { span.End(err) }()
+
 	if err := task.Validate(); err != nil {
-		audit.Error("task.registry.add", "Task validation failed", map[string]interface{}{
-			"task_id": task.ID,
-			"error":   err.Error(),
+		events.PublishTo(r.auditLog(), audit.LevelError, "Task validation failed", audit.TaskAdded{
+			TaskID: task.ID,
+			Error:  err.Error(),
 		})
 		return fmt.Errorf("invalid task: %w", err)
 	}
@@ -39,25 +167,26 @@ func (r *Registry) Add(task *Task) error {
 	defer r.mu.Unlock()
 
 	if _, exists := r.tasks[task.ID]; exists {
-		audit.Warn("task.registry.add", "Task already exists", map[string]interface{}{
-			"task_id": task.ID,
+		events.PublishTo(r.auditLog(), audit.LevelWarn, "Task already exists", audit.TaskAdded{
+			TaskID: task.ID,
 		})
 		return fmt.Errorf("task with ID '%s' already exists", task.ID)
 	}
 
 	if err := r.validateDepsLocked(task); err != nil {
-		audit.Error("task.registry.add", "Dependency validation failed", map[string]interface{}{
-			"task_id": task.ID,
-			"deps":    task.Deps,
-			"error":   err.Error(),
+		events.PublishTo(r.auditLog(), audit.LevelError, "Dependency validation failed", audit.TaskAdded{
+			TaskID: task.ID,
+			Deps:   task.Deps,
+			Error:  err.Error(),
 		})
 		return err
 	}
 
 	r.tasks[task.ID] = task
-	audit.Info("task.registry.add", "Task added to registry", map[string]interface{}{
-		"task_id": task.ID,
-		"title":   task.Title,
+	r.changedLocked(task.ID)
+	events.PublishTo(r.auditLog(), audit.LevelInfo, "Task added to registry", audit.TaskAdded{
+		TaskID: task.ID,
+		Title:  task.Title,
 	})
 	return nil
 }
@@ -69,17 +198,30 @@ func (r *Registry) Get(id string) (*Task, error) {
 
 	task, exists := r.tasks[id]
 	if !exists {
-		return nil, fmt.Errorf("task '%s' not found", id)
+		return nil, fmt.Errorf("task '%s' %w", id, ErrTaskNotFound)
 	}
 	return task, nil
 }
 
+// SetStatus changes the status of t, a task of the registry, if the
+// transition is valid, recording the change in the registry's audit log.
+// Returns a *TransitionError if the transition is not allowed.
+func (r *Registry) SetStatus(t *Task, status Status) error {
+	r.mu.RLock()
+	log := r.auditLog()
+	r.mu.RUnlock()
+	return t.setStatus(log, status)
+}
+
 // Update updates an existing task.
-func (r *Registry) Update(task *Task) error {
+func (r *Registry) Update(task *Task) (err error) {
+	span := r.startSpan("task.registry.update", task.ID)
+	defer func() { span.End(err) }()
+
 	if err := task.Validate(); err != nil {
-		audit.Error("task.registry.update", "Task validation failed", map[string]interface{}{
-			"task_id": task.ID,
-			"error":   err.Error(),
+		events.PublishTo(r.auditLog(), audit.LevelError, "Task validation failed", audit.TaskUpdated{
+			TaskID: task.ID,
+			Error:  err.Error(),
 		})
 		return fmt.Errorf("invalid task: %w", err)
 	}
@@ -88,57 +230,61 @@ func (r *Registry) Update(task *Task) error {
 	defer r.mu.Unlock()
 
 	if _, exists := r.tasks[task.ID]; !exists {
-		audit.Error("task.registry.update", "Task not found", map[string]interface{}{
-			"task_id": task.ID,
+		events.PublishTo(r.auditLog(), audit.LevelError, "Task not found", audit.TaskUpdated{
+			TaskID: task.ID,
 		})
-		return fmt.Errorf("task '%s' not found", task.ID)
+		return fmt.Errorf("task '%s' %w", task.ID, ErrTaskNotFound)
 	}
 
 	if err := r.validateDepsLocked(task); err != nil {
-		audit.Error("task.registry.update", "Dependency validation failed", map[string]interface{}{
-			"task_id": task.ID,
-			"error":   err.Error(),
+		events.PublishTo(r.auditLog(), audit.LevelError, "Dependency validation failed", audit.TaskUpdated{
+			TaskID: task.ID,
+			Error:  err.Error(),
 		})
 		return err
 	}
 
 	// Check for circular dependencies
 	if err := r.checkCircularLocked(task.ID, task.Deps, make(map[string]bool)); err != nil {
-		audit.Error("task.registry.update", "Circular dependency detected", map[string]interface{}{
-			"task_id": task.ID,
-			"error":   err.Error(),
+		events.PublishTo(r.auditLog(), audit.LevelError, "Circular dependency detected", audit.TaskUpdated{
+			TaskID: task.ID,
+			Error:  err.Error(),
 		})
 		return err
 	}
 
 	r.tasks[task.ID] = task
-	audit.Info("task.registry.update", "Task updated", map[string]interface{}{
-		"task_id": task.ID,
-		"title":   task.Title,
+	r.changedLocked(task.ID)
+	events.PublishTo(r.auditLog(), audit.LevelInfo, "Task updated", audit.TaskUpdated{
+		TaskID: task.ID,
+		Title:  task.Title,
 	})
 	return nil
 }
 
 // Delete removes a task by ID.
 // Returns error if task has dependents.
-func (r *Registry) Delete(id string) error {
+func (r *Registry) Delete(id string) (err error) {
+	span := r.startSpan("task.registry.delete", id)
+	defer func() { span.End(err) }()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.tasks[id]; !exists {
-		audit.Error("task.registry.delete", "Task not found", map[string]interface{}{
-			"task_id": id,
+		events.PublishTo(r.auditLog(), audit.LevelError, "Task not found", audit.TaskDeleted{
+			TaskID: id,
 		})
-		return fmt.Errorf("task '%s' not found", id)
+		return fmt.Errorf("task '%s' %w", id, ErrTaskNotFound)
 	}
 
 	// Check for dependents
 	for _, task := range r.tasks {
 		for _, dep := range task.Deps {
 			if dep == id {
-				audit.Warn("task.registry.delete", "Cannot delete task with dependents", map[string]interface{}{
-					"task_id":   id,
-					"dependent": task.ID,
+				events.PublishTo(r.auditLog(), audit.LevelWarn, "Cannot delete task with dependents", audit.TaskDeleted{
+					TaskID:    id,
+					Dependent: task.ID,
 				})
 				return fmt.Errorf("cannot delete task '%s': task '%s' depends on it", id, task.ID)
 			}
@@ -146,12 +292,38 @@ func (r *Registry) Delete(id string) error {
 	}
 
 	delete(r.tasks, id)
-	audit.Info("task.registry.delete", "Task deleted", map[string]interface{}{
-		"task_id": id,
+	r.changedLocked(id)
+	events.PublishTo(r.auditLog(), audit.LevelInfo, "Task deleted", audit.TaskDeleted{
+		TaskID: id,
 	})
 	return nil
 }
 
+// Watch calls fn with the ID of every task added, updated or deleted from
+// now on, such as to notify MCP clients. It returns a function that stops
+// watching. fn runs with the registry locked and must not call back into it.
+func (r *Registry) Watch(fn func(id string)) (stop func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watchers == nil {
+		r.watchers = make(map[int]func(id string))
+	}
+	key := r.nextWatcher
+	r.nextWatcher++
+	r.watchers[key] = fn
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.watchers, key)
+	}
+}
+
+func (r *Registry) changedLocked(id string) {
+	for _, fn := range r.watchers {
+		fn(id)
+	}
+}
+
 // List returns all tasks.
 func (r *Registry) List() []*Task {
 	r.mu.RLock()
@@ -193,7 +365,8 @@ func (r *Registry) ListByRepo(repo string) []*Task {
 }
 
 // GetReady returns tasks that are ready to start.
-// A task is ready if it's pending and all its dependencies are complete.
+// A task is ready if it's pending, all its dependencies are complete and
+// no task with its mutex is in progress.
 func (r *Registry) GetReady() []*Task {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -203,13 +376,42 @@ func (r *Registry) GetReady() []*Task {
 		if task.Status != StatusPending {
 			continue
 		}
-		if r.allDepsCompleteLocked(task) {
+		if r.allDepsCompleteLocked(task) && r.mutexHolderLocked(task) == nil {
 			ready = append(ready, task)
 		}
 	}
 	return ready
 }
 
+// CheckMutex returns a *MutexError if another task with the task's mutex
+// is in progress, so the task can't start.
+func (r *Registry) CheckMutex(task *Task) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if holder := r.mutexHolderLocked(task); holder != nil {
+		return &MutexError{TaskID: task.ID, Mutex: task.Mutex, Holder: holder.ID}
+	}
+	return nil
+}
+
+// mutexHolderLocked returns the in-progress task, other than task, with
+// task's mutex, or nil. It prefers the lowest ID when there are several.
+func (r *Registry) mutexHolderLocked(task *Task) *Task {
+	if task.Mutex == "" {
+		return nil
+	}
+	var holder *Task
+	for _, t := range r.tasks {
+		if t.ID == task.ID || t.Mutex != task.Mutex || t.Status != StatusInProgress {
+			continue
+		}
+		if holder == nil || t.ID < holder.ID {
+			holder = t
+		}
+	}
+	return holder
+}
+
 // GetDeps returns the tasks that the given task depends on.
 func (r *Registry) GetDeps(id string) ([]*Task, error) {
 	r.mu.RLock()
@@ -217,7 +419,7 @@ func (r *Registry) GetDeps(id string) ([]*Task, error) {
 
 	task, exists := r.tasks[id]
 	if !exists {
-		return nil, fmt.Errorf("task '%s' not found", id)
+		return nil, fmt.Errorf("task '%s' %w", id, ErrTaskNotFound)
 	}
 
 	deps := make([]*Task, 0, len(task.Deps))
@@ -235,7 +437,7 @@ func (r *Registry) GetDependents(id string) ([]*Task, error) {
 	defer r.mu.RUnlock()
 
 	if _, exists := r.tasks[id]; !exists {
-		return nil, fmt.Errorf("task '%s' not found", id)
+		return nil, fmt.Errorf("task '%s' %w", id, ErrTaskNotFound)
 	}
 
 	var dependents []*Task
@@ -257,13 +459,24 @@ func (r *Registry) ValidateDeps(task *Task) error {
 	return r.validateDepsLocked(task)
 }
 
-// validateDepsLocked checks deps without acquiring lock.
+// validateDepsLocked checks deps and the parent without acquiring lock.
 func (r *Registry) validateDepsLocked(task *Task) error {
 	for _, depID := range task.Deps {
 		if _, exists := r.tasks[depID]; !exists {
 			return fmt.Errorf("dependency '%s' not found", depID)
 		}
 	}
+	// Walk up the parents to check they exist and don't loop.
+	for id, depth := task.Parent, 0; id != ""; depth++ {
+		if id == task.ID || depth > len(r.tasks) {
+			return fmt.Errorf("task '%s' cannot be its own ancestor", task.ID)
+		}
+		parent, exists := r.tasks[id]
+		if !exists {
+			return fmt.Errorf("parent '%s' not found", id)
+		}
+		id = parent.Parent
+	}
 	return nil
 }
 
@@ -300,10 +513,41 @@ func (r *Registry) checkCircularLocked(startID string, deps []string, visited ma
 	return nil
 }
 
-// registryData is the JSON structure for persistence.
+// registryData is the JSON structure for persistence. Version is the
+// optimistic-concurrency counter; Schema is the format version.
 type registryData struct {
+	Schema  int     `json:"schema,omitempty"`
 	Version int     `json:"version"`
 	Tasks   []*Task `json:"tasks"`
+
+	extra jsoncompat.Fields
+}
+
+// registryJSON has registryData's fields without its JSON methods.
+type registryJSON registryData
+
+// UnmarshalJSON decodes the manifest, keeping fields this version doesn't know.
+func (d *registryData) UnmarshalJSON(data []byte) error {
+	var decoded registryJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	extra, err := jsoncompat.Unknown(data, decoded)
+	if err != nil {
+		return err
+	}
+	*d = registryData(decoded)
+	d.extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the manifest, including any preserved fields.
+func (d registryData) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(registryJSON(d))
+	if err != nil {
+		return nil, err
+	}
+	return jsoncompat.Merge(data, d.extra)
 }
 
 // lockFile acquires an exclusive lock on a file.
@@ -317,10 +561,17 @@ func unlockFile(file *os.File) error {
 }
 
 // Save writes the registry to a JSON file with file locking and optimistic concurrency.
-func (r *Registry) Save(path string) error {
+func (r *Registry) Save(path string) (err error) {
+	span := r.startSpan("task.registry.save", "")
+	defer func() { span.End(err) }()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.schema > SchemaVersion {
+		return &SchemaError{Found: r.schema, Supported: SchemaVersion}
+	}
+
 	// Open file for read-write, create if doesn't exist
 	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
@@ -348,9 +599,14 @@ func (r *Registry) Save(path string) error {
 			return fmt.Errorf("failed to read current version: %w", err)
 		}
 
+		// Never downgrade a manifest a newer flo has written since we loaded
+		if currentData.Schema > SchemaVersion {
+			return &SchemaError{Found: currentData.Schema, Supported: SchemaVersion}
+		}
+
 		// Version conflict check
 		if currentData.Version != r.version {
-			return fmt.Errorf("version conflict: expected %d, found %d", r.version, currentData.Version)
+			return &VersionConflictError{Expected: r.version, Found: currentData.Version}
 		}
 	}
 
@@ -358,8 +614,10 @@ func (r *Registry) Save(path string) error {
 	r.version++
 
 	data := registryData{
+		Schema:  SchemaVersion,
 		Version: r.version,
 		Tasks:   make([]*Task, 0, len(r.tasks)),
+		extra:   r.extra,
 	}
 	for _, task := range r.tasks {
 		data.Tasks = append(data.Tasks, task)
@@ -384,8 +642,25 @@ func (r *Registry) Save(path string) error {
 	return nil
 }
 
-// Load reads the registry from a JSON file with file locking.
-func (r *Registry) Load(path string) error {
+// ReadOnly reports whether the loaded manifest uses a newer schema than this
+// version can write.
+func (r *Registry) ReadOnly() bool {
+	return r.Schema() > SchemaVersion
+}
+
+// Schema returns the schema version of the loaded manifest (0 if it had none).
+func (r *Registry) Schema() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.schema
+}
+
+// Load reads the registry from a JSON file with file locking. Manifests
+// with a newer schema load for reading, but Save refuses to overwrite them.
+func (r *Registry) Load(path string) (err error) {
+	span := r.startSpan("task.registry.load", "")
+	defer func() { span.End(err) }()
+
 	// Open file for reading
 	file, err := os.Open(path)
 	if err != nil {
@@ -411,12 +686,30 @@ func (r *Registry) Load(path string) error {
 	// Clear existing and add all tasks
 	r.tasks = make(map[string]*Task)
 	r.version = data.Version
+	r.schema = data.Schema
+	r.extra = data.extra
+	if r.schema > SchemaVersion {
+		r.auditLog().Warn("task.registry.load", "Manifest schema is newer than supported; workspace is read-only", map[string]interface{}{
+			"schema":    r.schema,
+			"supported": SchemaVersion,
+		})
+	}
 
 	// First pass: add all tasks without dep validation
-	for _, task := range data.Tasks {
+	for i, task := range data.Tasks {
+		if task == nil {
+			return fmt.Errorf("invalid task at index %d: null entry", i)
+		}
 		if err := task.Validate(); err != nil {
 			return fmt.Errorf("invalid task '%s': %w", task.ID, err)
 		}
+		// Normalize timestamps written with a local offset
+		task.CreatedAt = task.CreatedAt.UTC()
+		task.UpdatedAt = task.UpdatedAt.UTC()
+		if task.CompletedAt != nil {
+			at := task.CompletedAt.UTC()
+			task.CompletedAt = &at
+		}
 		r.tasks[task.ID] = task
 	}
 
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -83,8 +84,8 @@ func TestRegistryGetNotFound(t *testing.T) {
 	reg := NewRegistry()
 
 	_, err := reg.Get("nonexistent")
-	if err == nil {
-		t.Error("expected error for nonexistent task")
+	if !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound for nonexistent task, got %v", err)
 	}
 }
 
@@ -246,6 +247,43 @@ func TestRegistryGetReady(t *testing.T) {
 	}
 }
 
+func TestRegistryMutex(t *testing.T) {
+	reg := NewRegistry()
+	for _, id := range []string{"ua-001", "ua-002", "ua-003"} {
+		reg.Add(New(id, "Migration "+id))
+	}
+	t1, _ := reg.Get("ua-001")
+	t2, _ := reg.Get("ua-002")
+	t3, _ := reg.Get("ua-003")
+	t1.Mutex, t2.Mutex = "db-migrations", "db-migrations"
+
+	t1.SetStatus(StatusInProgress)
+	reg.Update(t1)
+	var ids []string
+	for _, r := range reg.GetReady() {
+		ids = append(ids, r.ID)
+	}
+	if len(ids) != 1 || ids[0] != "ua-003" {
+		t.Errorf("expected only the task without the mutex ready, got %v", ids)
+	}
+	var held *MutexError
+	if err := reg.CheckMutex(t2); !errors.Is(err, ErrMutexHeld) || !errors.As(err, &held) || held.Holder != "ua-001" {
+		t.Errorf("expected ua-001 to hold the mutex, got %v", err)
+	}
+	if err := reg.CheckMutex(t1); err != nil {
+		t.Errorf("expected a task not to block itself, got %v", err)
+	}
+	if err := reg.CheckMutex(t3); err != nil {
+		t.Errorf("expected a task without a mutex to start, got %v", err)
+	}
+
+	t1.SetStatus(StatusComplete)
+	reg.Update(t1)
+	if err := reg.CheckMutex(t2); err != nil {
+		t.Errorf("expected the mutex released once ua-001 completed, got %v", err)
+	}
+}
+
 func TestRegistryGetDeps(t *testing.T) {
 	reg := NewRegistry()
 
@@ -494,7 +532,55 @@ func TestRegistryVersionConflict(t *testing.T) {
 	if err == nil {
 		t.Error("expected version conflict error for reg3 save")
 	}
-	if err != nil && !strings.Contains(err.Error(), "version conflict") {
+	var conflict *VersionConflictError
+	if err != nil && (!errors.As(err, &conflict) || !errors.Is(err, ErrVersionConflict)) {
 		t.Errorf("expected version conflict error, got: %v", err)
 	}
+	if conflict != nil && (conflict.Expected != 1 || conflict.Found != 2) {
+		t.Errorf("expected conflict between versions 1 and 2, got %+v", conflict)
+	}
+}
+
+func TestRegistryWatch(t *testing.T) {
+	reg := NewRegistry()
+
+	var changed []string
+	stop := reg.Watch(func(id string) { changed = append(changed, id) })
+
+	task := New("ua-001", "Implement OAuth")
+	reg.Add(task)
+	task.Title = "Implement OAuth2"
+	reg.Update(task)
+	reg.Add(New("ua-001", "Duplicate"))
+	stop()
+	reg.Delete("ua-001")
+
+	if strings.Join(changed, ",") != "ua-001,ua-001" {
+		t.Errorf("expected add and update notifications, got %v", changed)
+	}
+}
+
+func TestRegistryParent(t *testing.T) {
+	reg := NewRegistry()
+
+	parent := New("ua-001", "Parent")
+	reg.Add(parent)
+
+	orphan := New("ua-002", "Orphan")
+	orphan.Parent = "ua-404"
+	if err := reg.Add(orphan); err == nil {
+		t.Error("expected error for missing parent")
+	}
+
+	child := New("ua-003", "Child")
+	child.Parent = "ua-001"
+	if err := reg.Add(child); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// Make the parent a subtask of its own child
+	parent.Parent = "ua-003"
+	if err := reg.Update(parent); err == nil {
+		t.Error("expected error for parent cycle")
+	}
 }
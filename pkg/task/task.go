@@ -2,12 +2,17 @@
 package task
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/clock"
+	"github.com/richgo/flo/pkg/events"
+	"github.com/richgo/flo/pkg/jsoncompat"
 	"gopkg.in/yaml.v3"
 )
 
@@ -31,6 +36,14 @@ func (s Status) IsValid() bool {
 	}
 }
 
+// JSONSchema restricts the status to the known values.
+func (s Status) JSONSchema() map[string]any {
+	return map[string]any{
+		"type": "string",
+		"enum": []string{string(StatusPending), string(StatusInProgress), string(StatusComplete), string(StatusFailed)},
+	}
+}
+
 // Task represents a unit of work within a feature.
 type Task struct {
 	ID          string    `json:"id" yaml:"id"`
@@ -40,18 +53,72 @@ type Task struct {
 	Priority    int       `json:"priority,omitempty" yaml:"priority,omitempty"`
 	Repo        string    `json:"repo,omitempty" yaml:"repo,omitempty"`
 	Deps        []string  `json:"deps,omitempty" yaml:"deps,omitempty"`
+	// Parent is the task this one is a subtask of.
+	Parent      string    `json:"parent,omitempty" yaml:"parent,omitempty"`
 	SpecRef     string    `json:"spec_ref,omitempty" yaml:"spec_ref,omitempty"`
+	// SpecVersion is the spec snapshot SpecRef was last checked against.
+	SpecVersion int       `json:"spec_version,omitempty" yaml:"spec_version,omitempty"`
+	Criteria    []string  `json:"criteria,omitempty" yaml:"criteria,omitempty"`
+	// Scope are globs of the files the task may change, such as pkg/auth/;
+	// the git_commit tool refuses commits touching others. Empty allows any.
+	Scope       []string  `json:"scope,omitempty" yaml:"scope,omitempty"`
+	// Mutex names a resource the task needs to itself, such as
+	// "db-migrations": tasks with the same mutex never run at once, even
+	// when they don't depend on each other.
+	Mutex       string    `json:"mutex,omitempty" yaml:"mutex,omitempty"`
 	Model       string    `json:"model,omitempty" yaml:"model,omitempty"`
 	Fallback    string    `json:"fallback,omitempty" yaml:"fallback,omitempty"`
 	Type        string    `json:"type,omitempty" yaml:"type,omitempty"`
+	// Retries counts how often the task was retried after failing.
+	Retries     int       `json:"retries,omitempty" yaml:"retries,omitempty"`
+	// Issue is the tracker issue the task was imported from, such as
+	// "github:org/name#12".
+	Issue       string    `json:"issue,omitempty" yaml:"issue,omitempty"`
+	// Assignees are the people the imported issue is assigned to.
+	Assignees   []string  `json:"assignees,omitempty" yaml:"assignees,omitempty"`
 	CreatedAt   time.Time `json:"created_at" yaml:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" yaml:"updated_at"`
+	// CompletedAt is when the task completed; nil until it does.
+	CompletedAt *time.Time `json:"completed_at,omitempty" yaml:"completed_at,omitempty"`
+
+	// extra holds JSON fields written by newer versions, kept on rewrite.
+	extra jsoncompat.Fields
+}
+
+// taskJSON has Task's fields without its JSON methods.
+type taskJSON Task
+
+// UnmarshalJSON decodes a task, keeping fields this version doesn't know.
+func (t *Task) UnmarshalJSON(data []byte) error {
+	var decoded taskJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	extra, err := jsoncompat.Unknown(data, decoded)
+	if err != nil {
+		return err
+	}
+	*t = Task(decoded)
+	t.extra = extra
+	return nil
 }
 
+// MarshalJSON encodes a task, including any fields preserved from a newer version.
+func (t Task) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(taskJSON(t))
+	if err != nil {
+		return nil, err
+	}
+	return jsoncompat.Merge(data, t.extra)
+}
+
+// Clock stamps task timestamps. Tests and simulations can replace it.
+var Clock clock.Clock = clock.Real
+
 // New creates a new Task with the given ID and title.
-// Status defaults to pending, timestamps are set automatically.
+// Status defaults to pending, timestamps are set automatically (in UTC).
 func New(id, title string) *Task {
-	now := time.Now()
+	now := Clock.Now().UTC()
 	return &Task{
 		ID:        id,
 		Title:     title,
@@ -84,6 +151,7 @@ var validTransitions = map[Status]map[Status]bool{
 	StatusInProgress: {
 		StatusComplete: true,
 		StatusFailed:   true,
+		StatusPending:  true, // Reopen: sent back from review, or requeued
 	},
 	StatusComplete: {
 		// Terminal state - no transitions allowed
@@ -93,42 +161,77 @@ var validTransitions = map[Status]map[Status]bool{
 	},
 }
 
+// ErrInvalidTransition is matched by errors changing a task to a status
+// its current status can't move to.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// TransitionError reports a status change that isn't allowed.
+type TransitionError struct {
+	TaskID string
+	From   Status
+	To     Status
+}
+
+func (e *TransitionError) Error() string {
+	if _, ok := validTransitions[e.From]; !ok {
+		return fmt.Sprintf("unknown current status: %s", e.From)
+	}
+	return fmt.Sprintf("invalid status transition: %s -> %s", e.From, e.To)
+}
+
+// Is reports whether target is ErrInvalidTransition.
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
 // SetStatus changes the task status if the transition is valid.
-// Returns an error if the transition is not allowed.
+// Returns a *TransitionError if the transition is not allowed. The change
+// is recorded in the default audit log; Registry.SetStatus records it in
+// the registry's.
 func (t *Task) SetStatus(newStatus Status) error {
+	return t.setStatus(nil, newStatus)
+}
+
+// setStatus changes the task status, recording the change in log, or the
+// default audit log when nil.
+func (t *Task) setStatus(log *audit.Logger, newStatus Status) error {
 	if t.Status == newStatus {
 		return nil // No change
 	}
 
 	allowed, ok := validTransitions[t.Status]
 	if !ok {
-		audit.Error("task.set_status", "Unknown current status", map[string]interface{}{
-			"task_id":        t.ID,
-			"current_status": string(t.Status),
-			"new_status":     string(newStatus),
+		events.PublishTo(log, audit.LevelError, "Unknown current status", audit.StatusTransition{
+			TaskID: t.ID,
+			From:   string(t.Status),
+			To:     string(newStatus),
 		})
-		return fmt.Errorf("unknown current status: %s", t.Status)
+		return &TransitionError{TaskID: t.ID, From: t.Status, To: newStatus}
 	}
 
 	if !allowed[newStatus] {
-		audit.Warn("task.set_status", "Invalid status transition", map[string]interface{}{
-			"task_id":    t.ID,
-			"from":       string(t.Status),
-			"to":         string(newStatus),
-			"task_title": t.Title,
+		events.PublishTo(log, audit.LevelWarn, "Invalid status transition", audit.StatusTransition{
+			TaskID: t.ID,
+			Title:  t.Title,
+			From:   string(t.Status),
+			To:     string(newStatus),
 		})
-		return fmt.Errorf("invalid status transition: %s -> %s", t.Status, newStatus)
+		return &TransitionError{TaskID: t.ID, From: t.Status, To: newStatus}
 	}
 
 	oldStatus := t.Status
 	t.Status = newStatus
-	t.UpdatedAt = time.Now()
+	t.UpdatedAt = Clock.Now().UTC()
+	if newStatus == StatusComplete {
+		at := t.UpdatedAt
+		t.CompletedAt = &at
+	}
 	
-	audit.Info("task.set_status", "Task status changed", map[string]interface{}{
-		"task_id":    t.ID,
-		"task_title": t.Title,
-		"from":       string(oldStatus),
-		"to":         string(newStatus),
+	events.PublishTo(log, audit.LevelInfo, "Task status changed", audit.StatusTransition{
+		TaskID: t.ID,
+		Title:  t.Title,
+		From:   string(oldStatus),
+		To:     string(newStatus),
 	})
 	
 	return nil
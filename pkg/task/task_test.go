@@ -2,9 +2,12 @@ package task
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"testing"
 	"time"
+
+	"github.com/richgo/flo/pkg/clock"
 )
 
 func TestNewTask(t *testing.T) {
@@ -25,6 +28,9 @@ func TestNewTask(t *testing.T) {
 	if task.UpdatedAt.IsZero() {
 		t.Error("expected UpdatedAt to be set")
 	}
+	if task.CreatedAt.Location() != time.UTC {
+		t.Errorf("expected CreatedAt in UTC, got %s", task.CreatedAt.Location())
+	}
 }
 
 func TestTaskValidation(t *testing.T) {
@@ -88,6 +94,7 @@ func TestStatusTransitions(t *testing.T) {
 		{"pending to complete", StatusPending, StatusComplete, true},
 		{"in_progress to complete", StatusInProgress, StatusComplete, false},
 		{"in_progress to failed", StatusInProgress, StatusFailed, false},
+		{"in_progress to pending", StatusInProgress, StatusPending, false},
 		{"complete to pending", StatusComplete, StatusPending, true},
 		{"complete to in_progress", StatusComplete, StatusInProgress, true},
 		{"failed to pending", StatusFailed, StatusPending, false},
@@ -101,8 +108,8 @@ func TestStatusTransitions(t *testing.T) {
 				Status: tt.from,
 			}
 			err := task.SetStatus(tt.to)
-			if tt.wantErr && err == nil {
-				t.Errorf("expected error for transition %s -> %s", tt.from, tt.to)
+			if tt.wantErr && !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("expected ErrInvalidTransition for transition %s -> %s, got %v", tt.from, tt.to, err)
 			}
 			if !tt.wantErr && err != nil {
 				t.Errorf("unexpected error for transition %s -> %s: %v", tt.from, tt.to, err)
@@ -114,6 +121,20 @@ func TestStatusTransitions(t *testing.T) {
 	}
 }
 
+func TestTransitionError(t *testing.T) {
+	task := &Task{ID: "test-001", Title: "Test Task", Status: StatusComplete}
+	var transition *TransitionError
+	if err := task.SetStatus(StatusPending); !errors.As(err, &transition) || transition.TaskID != "test-001" || transition.From != StatusComplete || transition.To != StatusPending {
+		t.Errorf("expected a TransitionError from complete to pending, got %v", err)
+	}
+
+	task.Status = "archived"
+	err := task.SetStatus(StatusPending)
+	if !errors.Is(err, ErrInvalidTransition) || err.Error() != "unknown current status: archived" {
+		t.Errorf("expected an unknown status to be an invalid transition, got %v", err)
+	}
+}
+
 func TestTaskJSONSerialization(t *testing.T) {
 	original := New("ua-001", "Implement OAuth")
 	original.Description = "OAuth2 with Google"
@@ -197,14 +218,39 @@ func TestStatusIsValid(t *testing.T) {
 }
 
 func TestTaskUpdateTimestamp(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+	Clock = fake
+	defer func() { Clock = clock.Real }()
+
 	task := New("ua-001", "Test")
 	originalUpdated := task.UpdatedAt
 
-	time.Sleep(10 * time.Millisecond)
+	fake.Advance(time.Minute)
 	task.SetStatus(StatusInProgress)
 
-	if !task.UpdatedAt.After(originalUpdated) {
-		t.Error("expected UpdatedAt to be updated after status change")
+	if !task.UpdatedAt.Equal(originalUpdated.Add(time.Minute)) {
+		t.Errorf("expected UpdatedAt to move to the status change, got %v", task.UpdatedAt)
+	}
+	if !task.CreatedAt.Equal(originalUpdated) {
+		t.Errorf("expected CreatedAt to stay, got %v", task.CreatedAt)
+	}
+}
+
+func TestTaskCompletedAt(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+	Clock = fake
+	defer func() { Clock = clock.Real }()
+
+	task := New("ua-001", "Test")
+	task.SetStatus(StatusInProgress)
+	if task.CompletedAt != nil {
+		t.Fatal("expected no CompletedAt before completion")
+	}
+
+	fake.Advance(time.Hour)
+	task.SetStatus(StatusComplete)
+	if task.CompletedAt == nil || !task.CompletedAt.Equal(fake.Now()) {
+		t.Errorf("expected CompletedAt at completion, got %v", task.CompletedAt)
 	}
 }
 
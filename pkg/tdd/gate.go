@@ -0,0 +1,299 @@
+// Package tdd enforces test-driven development rules before task completion.
+package tdd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/richgo/flo/pkg/affected"
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/coverage"
+	"github.com/richgo/flo/pkg/shell"
+	"github.com/richgo/flo/pkg/testresult"
+)
+
+// coveragePattern matches the per-package summary line printed by `go test -cover`.
+var coveragePattern = regexp.MustCompile(`coverage: ([0-9]+(?:\.[0-9]+)?)% of statements`)
+
+// totalPattern matches the overall line printed by `go tool cover -func`.
+var totalPattern = regexp.MustCompile(`(?m)^total:\s+\(statements\)\s+([0-9]+(?:\.[0-9]+)?)%`)
+
+// CommandRunner executes a shell command in a directory and returns its combined output.
+// A non-nil error means the command exited unsuccessfully or could not be started.
+type CommandRunner func(ctx context.Context, dir, command string) (string, error)
+
+// Result holds the outcome of a gate evaluation.
+type Result struct {
+	Passed      bool          `json:"passed"`
+	TestsPassed bool          `json:"tests_passed"`
+	Coverage    float64       `json:"coverage"`
+	HasCoverage bool          `json:"has_coverage"`
+	Threshold   int           `json:"threshold,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+	Output      string        `json:"output"`
+	Duration    time.Duration `json:"duration"`
+	// Affected is the test selection when tdd.affected is set.
+	Affected *affected.Selection `json:"affected,omitempty"`
+	// Tests are the parsed results when the output is in a known format
+	// (go test -json, jest or pytest).
+	Tests *testresult.Summary `json:"tests,omitempty"`
+}
+
+// Brief returns a copy of the result with its output cut to the last max
+// bytes, for reporting it where the parsed results say the rest.
+func (r *Result) Brief(max int) *Result {
+	brief := *r
+	if len(brief.Output) > max {
+		brief.Output = "..." + brief.Output[len(brief.Output)-max:]
+	}
+	return &brief
+}
+
+// Gate runs the configured test command and decides whether a task may complete.
+type Gate struct {
+	config config.TDDConfig
+	dir    string
+	runner CommandRunner
+	// selector narrows the tests to those affected by the change, unless
+	// full is set.
+	selector *affected.Selector
+	full     bool
+	// log receives the gate's audit events; nil uses the default logger.
+	log *audit.Logger
+}
+
+// NewGate creates a gate that runs tests in the given worktree directory.
+func NewGate(cfg config.TDDConfig, dir string) *Gate {
+	g := &Gate{
+		config: cfg,
+		dir:    dir,
+		runner: shell.Run,
+	}
+	if cfg.Affected != "" {
+		g.selector = affected.NewSelector(cfg.Affected, dir, cfg.AffectedBase)
+	}
+	return g
+}
+
+// SetAuditLogger sends the gate's audit events to l, such as the log of
+// the workspace it belongs to.
+func (g *Gate) SetAuditLogger(l *audit.Logger) {
+	g.log = l
+}
+
+// SetRunner replaces the command runner (for testing).
+func (g *Gate) SetRunner(runner CommandRunner) {
+	g.runner = runner
+}
+
+// SetSelector replaces the affected test selector (for testing).
+func (g *Gate) SetSelector(selector *affected.Selector) {
+	g.selector = selector
+}
+
+// SetFull makes the gate run the whole test command even when
+// tdd.affected is set.
+func (g *Gate) SetFull(full bool) {
+	g.full = full
+}
+
+// Evaluate runs the test command and checks the coverage threshold.
+// The returned error is only set when the gate itself could not run;
+// test failures are reported through Result.Passed and Result.Reason.
+func (g *Gate) Evaluate(ctx context.Context) (*Result, error) {
+	if g.config.TestCommand == "" {
+		return nil, fmt.Errorf("no test command configured")
+	}
+
+	start := time.Now()
+	command := g.config.TestCommand
+	selection := g.selectTests(ctx)
+	if selection != nil {
+		if !selection.Full && len(selection.Targets) == 0 {
+			result := &Result{
+				Passed:      true,
+				TestsPassed: true,
+				Output:      "no tests affected by the change",
+				Affected:    selection,
+			}
+			g.audit(result)
+			return result, nil
+		}
+		command = selection.Command(command)
+	}
+
+	output, runErr := g.runner(ctx, g.dir, command)
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("test run cancelled: %w", ctx.Err())
+	}
+
+	result := &Result{
+		TestsPassed: runErr == nil,
+		Threshold:   g.config.CoverageThreshold,
+		Output:      output,
+		Duration:    time.Since(start),
+		Affected:    selection,
+	}
+	result.Tests, _ = testresult.Parse(output)
+	result.Coverage, result.HasCoverage = ParseCoverage(output)
+	if report, err := g.ProfileReport(); err == nil {
+		result.Coverage, result.HasCoverage = report.Percent(), true
+	}
+
+	switch {
+	case !result.TestsPassed && result.Tests != nil && result.Tests.Failed > 0:
+		result.Reason = fmt.Sprintf("%d of %d tests failed", result.Tests.Failed, result.Tests.Total())
+	case !result.TestsPassed:
+		result.Reason = "tests failed"
+	case g.config.CoverageThreshold > 0 && !result.HasCoverage:
+		result.Reason = fmt.Sprintf("coverage threshold is %d%% but test output has no overall coverage (set coverage_profile, or add -cover to test_command)", g.config.CoverageThreshold)
+	case g.config.CoverageThreshold > 0 && result.Coverage < float64(g.config.CoverageThreshold):
+		result.Reason = fmt.Sprintf("coverage %.1f%% is below threshold %d%%", result.Coverage, g.config.CoverageThreshold)
+	default:
+		result.Passed = true
+	}
+
+	g.audit(result)
+	return result, nil
+}
+
+// selectTests returns the affected test selection, or nil when every test
+// should run. When the selection can't be computed the whole suite runs.
+func (g *Gate) selectTests(ctx context.Context) *affected.Selection {
+	if g.selector == nil || g.full {
+		return nil
+	}
+	selection, err := g.selector.Select(ctx)
+	if err != nil {
+		selection = &affected.Selection{Kind: g.config.Affected, Full: true, Reason: err.Error()}
+	}
+	return selection
+}
+
+// audit records the gate outcome.
+func (g *Gate) audit(result *Result) {
+	level := audit.LevelInfo
+	if !result.Passed {
+		level = audit.LevelWarn
+	}
+	fields := map[string]interface{}{
+		"dir":          g.dir,
+		"passed":       result.Passed,
+		"tests_passed": result.TestsPassed,
+		"coverage":     result.Coverage,
+		"reason":       result.Reason,
+	}
+	if sel := result.Affected; sel != nil {
+		fields["affected_targets"] = len(sel.Targets)
+		fields["affected_full"] = sel.Full
+		if sel.Reason != "" {
+			fields["affected_reason"] = sel.Reason
+		}
+	}
+	log := g.log
+	if log == nil {
+		log = audit.Default()
+	}
+	log.Log(level, "tdd.gate", "TDD gate evaluated", fields)
+}
+
+// ProfileReport parses the configured coverage profile from the worktree.
+func (g *Gate) ProfileReport() (*coverage.Report, error) {
+	path, err := g.profilePath()
+	if err != nil {
+		return nil, err
+	}
+	return coverage.ParseFile(path)
+}
+
+// MeasureCoverage runs the whole test suite and returns the coverage
+// profile it writes. The profile left by an earlier run is removed first,
+// so a run that writes none fails rather than reporting stale coverage.
+func (g *Gate) MeasureCoverage(ctx context.Context) (*coverage.Report, error) {
+	path, err := g.profilePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale coverage profile: %w", err)
+	}
+	full := g.full
+	g.full = true
+	defer func() { g.full = full }()
+	if _, err := g.Evaluate(ctx); err != nil {
+		return nil, err
+	}
+	return coverage.ParseFile(path)
+}
+
+// profilePath returns where the test command writes its coverage profile.
+func (g *Gate) profilePath() (string, error) {
+	if g.config.CoverageProfile == "" {
+		return "", fmt.Errorf("no coverage profile configured")
+	}
+	path := g.config.CoverageProfile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.dir, path)
+	}
+	return path, nil
+}
+
+// Check evaluates the gate and returns an error if the task must not complete.
+func (g *Gate) Check(ctx context.Context) (*Result, error) {
+	result, err := g.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Passed {
+		return result, fmt.Errorf("TDD gate failed: %s", result.Reason)
+	}
+	return result, nil
+}
+
+// Run implements tools.TestRunner so the gate can back the MCP test tools.
+func (g *Gate) Run(taskID string) (bool, string, error) {
+	return g.RunContext(context.Background(), taskID)
+}
+
+// RunContext is Run with a context that stops the test command when it is
+// cancelled, such as when an MCP client cancels the tool call.
+func (g *Gate) RunContext(ctx context.Context, taskID string) (bool, string, error) {
+	result, err := g.Evaluate(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	if !result.Passed && result.TestsPassed {
+		return false, result.Output + "\n" + result.Reason, nil
+	}
+	return result.Passed, result.Output, nil
+}
+
+// ParseCoverage extracts statement coverage from test output: the
+// total line of `go tool cover -func`, or else the summary line of a
+// single package from `go test -cover`. Per-package percentages leave out
+// how many statements each package has, so output with several of them
+// and no total has no overall coverage; a coverage profile measures it.
+func ParseCoverage(output string) (float64, bool) {
+	if m := totalPattern.FindStringSubmatch(output); m != nil {
+		return parsePercent(m[1])
+	}
+	matches := coveragePattern.FindAllStringSubmatch(output, -1)
+	if len(matches) != 1 {
+		return 0, false
+	}
+	return parsePercent(matches[0][1])
+}
+
+func parsePercent(s string) (float64, bool) {
+	pct, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pct, true
+}
@@ -0,0 +1,272 @@
+package tdd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/affected"
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
+)
+
+func fakeRunner(output string, err error) CommandRunner {
+	return func(ctx context.Context, dir, command string) (string, error) {
+		return output, err
+	}
+}
+
+func TestParseCoverage(t *testing.T) {
+	pct, ok := ParseCoverage("ok  	github.com/richgo/flo/pkg/a	0.1s	coverage: 80.0% of statements")
+	if !ok || pct != 80.0 {
+		t.Errorf("expected 80.0 from a single package, got %.1f (%v)", pct, ok)
+	}
+
+	// A tiny untested package would drag an average down
+	packages := `ok  	github.com/richgo/flo/pkg/a	0.1s	coverage: 80.0% of statements
+	github.com/richgo/flo/pkg/b		coverage: 0.0% of statements`
+	if _, ok := ParseCoverage(packages); ok {
+		t.Error("expected no overall coverage from several packages without a total")
+	}
+
+	funcOutput := packages + `
+github.com/richgo/flo/pkg/a/a.go:10:	Run		80.0%
+github.com/richgo/flo/pkg/b/b.go:3:	Helper		0.0%
+total:					(statements)		78.4%`
+	pct, ok = ParseCoverage(funcOutput)
+	if !ok || pct != 78.4 {
+		t.Errorf("expected the 78.4 total, got %.1f (%v)", pct, ok)
+	}
+
+	if _, ok := ParseCoverage("ok  	pkg	0.1s"); ok {
+		t.Error("expected no coverage for output without coverage lines")
+	}
+}
+
+func TestGatePasses(t *testing.T) {
+	gate := NewGate(config.TDDConfig{Enforce: true, TestCommand: "go test ./..."}, t.TempDir())
+	gate.SetRunner(fakeRunner("ok  	pkg	0.1s", nil))
+
+	result, err := gate.Check(context.Background())
+	if err != nil {
+		t.Fatalf("expected gate to pass: %v", err)
+	}
+	if !result.Passed || !result.TestsPassed {
+		t.Errorf("expected passed result, got %+v", result)
+	}
+}
+
+func TestGateFailsOnTestFailure(t *testing.T) {
+	gate := NewGate(config.TDDConfig{Enforce: true, TestCommand: "go test ./..."}, t.TempDir())
+	gate.SetRunner(fakeRunner("--- FAIL: TestX", errors.New("exit status 1")))
+
+	result, err := gate.Check(context.Background())
+	if err == nil {
+		t.Fatal("expected gate to fail")
+	}
+	if result.TestsPassed {
+		t.Error("expected TestsPassed to be false")
+	}
+	if !strings.Contains(err.Error(), "tests failed") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGateParsesTestResults(t *testing.T) {
+	gate := NewGate(config.TDDConfig{Enforce: true, TestCommand: "go test -json ./..."}, t.TempDir())
+	gate.SetRunner(fakeRunner(`{"Action":"pass","Package":"pkg","Test":"TestA"}
+{"Action":"output","Package":"pkg","Test":"TestB","Output":"want 2\n"}
+{"Action":"fail","Package":"pkg","Test":"TestB"}
+{"Action":"fail","Package":"pkg"}
+`, errors.New("exit status 1")))
+
+	result, err := gate.Check(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 tests failed") {
+		t.Fatalf("expected the failed tests counted, got %v", err)
+	}
+	if result.Tests == nil || result.Tests.Passed != 1 || len(result.Tests.Failures) != 1 || result.Tests.Failures[0].Name != "pkg.TestB" {
+		t.Errorf("expected structured results, got %+v", result.Tests)
+	}
+	if brief := result.Brief(10); len(brief.Output) != 13 || result.Output == brief.Output {
+		t.Errorf("expected Brief to cut a copy of the output, got %q", brief.Output)
+	}
+}
+
+func TestGateCoverageThreshold(t *testing.T) {
+	cfg := config.TDDConfig{Enforce: true, TestCommand: "go test -cover ./...", CoverageThreshold: 80}
+
+	gate := NewGate(cfg, t.TempDir())
+	gate.SetRunner(fakeRunner("ok  	pkg	0.1s	coverage: 75.5% of statements", nil))
+	result, err := gate.Check(context.Background())
+	if err == nil {
+		t.Fatal("expected gate to fail below threshold")
+	}
+	if result.Coverage != 75.5 {
+		t.Errorf("expected coverage 75.5, got %.1f", result.Coverage)
+	}
+
+	gate.SetRunner(fakeRunner("ok  	pkg	0.1s	coverage: 85.0% of statements", nil))
+	if _, err := gate.Check(context.Background()); err != nil {
+		t.Errorf("expected gate to pass above threshold: %v", err)
+	}
+
+	gate.SetRunner(fakeRunner("ok  	pkg	0.1s", nil))
+	if _, err := gate.Check(context.Background()); err == nil {
+		t.Error("expected gate to fail when coverage data is missing")
+	}
+}
+
+func TestGateRunImplementsTestRunner(t *testing.T) {
+	gate := NewGate(config.TDDConfig{TestCommand: "go test ./...", CoverageThreshold: 90}, t.TempDir())
+	gate.SetRunner(fakeRunner("coverage: 50.0% of statements", nil))
+
+	pass, output, err := gate.Run("t-001")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if pass {
+		t.Error("expected Run to report failure below coverage threshold")
+	}
+	if !strings.Contains(output, "below threshold") {
+		t.Errorf("expected reason in output, got %q", output)
+	}
+}
+
+func TestGateRealCommand(t *testing.T) {
+	gate := NewGate(config.TDDConfig{TestCommand: "exit 0"}, t.TempDir())
+	if _, err := gate.Check(context.Background()); err != nil {
+		t.Errorf("expected passing shell command: %v", err)
+	}
+
+	gate = NewGate(config.TDDConfig{TestCommand: "exit 1"}, t.TempDir())
+	if _, err := gate.Check(context.Background()); err == nil {
+		t.Error("expected failing shell command to fail the gate")
+	}
+}
+
+func TestGateNoCommand(t *testing.T) {
+	gate := NewGate(config.TDDConfig{}, t.TempDir())
+	if _, err := gate.Evaluate(context.Background()); err == nil {
+		t.Error("expected error with no test command")
+	}
+}
+
+func TestGateUsesCoverageProfile(t *testing.T) {
+	dir := t.TempDir()
+	profile := "mode: set\npkg/a.go:1.1,2.2 3 1\npkg/a.go:3.1,4.2 1 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "coverage.out"), []byte(profile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.TDDConfig{TestCommand: "go test ./...", CoverageThreshold: 70, CoverageProfile: "coverage.out"}
+	gate := NewGate(cfg, dir)
+	gate.SetRunner(fakeRunner("ok", nil))
+
+	result, err := gate.Check(context.Background())
+	if err != nil {
+		t.Fatalf("expected gate to pass with 75%% profile coverage: %v", err)
+	}
+	if result.Coverage != 75 {
+		t.Errorf("expected coverage 75 from profile, got %.1f", result.Coverage)
+	}
+}
+
+func TestGateMeasureCoverage(t *testing.T) {
+	dir := t.TempDir()
+	profile := filepath.Join(dir, "coverage.out")
+	os.WriteFile(profile, []byte("mode: set\npkg/a.go:1.1,2.2 1 1\n"), 0644)
+
+	cfg := config.TDDConfig{TestCommand: "go test ./...", CoverageProfile: "coverage.out"}
+	gate := NewGate(cfg, dir)
+	gate.SetRunner(func(ctx context.Context, dir, command string) (string, error) {
+		return "ok", os.WriteFile(profile, []byte("mode: set\npkg/a.go:1.1,2.2 1 1\npkg/a.go:3.1,4.2 1 0\n"), 0644)
+	})
+	report, err := gate.MeasureCoverage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Percent() != 50 {
+		t.Errorf("expected the coverage of this run, got %.1f", report.Percent())
+	}
+
+	// A run that writes no profile doesn't report the last one's
+	gate.SetRunner(fakeRunner("ok", nil))
+	if _, err := gate.MeasureCoverage(context.Background()); err == nil {
+		t.Error("expected no coverage when the run writes no profile")
+	}
+}
+
+func TestGateRunsAffectedTests(t *testing.T) {
+	dir := t.TempDir()
+	list := `{"ImportPath": "ex/a", "Dir": "` + filepath.Join(dir, "a") + `"}
+{"ImportPath": "ex/b", "Dir": "` + filepath.Join(dir, "b") + `"}
+`
+	changed := "a/a.go\n"
+	selector := affected.NewSelector(affected.KindGo, dir, "")
+	selector.SetRunner(func(ctx context.Context, dir string, argv []string) ([]byte, error) {
+		switch argv[1] {
+		case "diff":
+			return []byte(changed), nil
+		case "list":
+			return []byte(list), nil
+		}
+		return nil, nil
+	})
+
+	gate := NewGate(config.TDDConfig{TestCommand: "go test -cover ./...", Affected: "go"}, dir)
+	gate.SetSelector(selector)
+	var ran string
+	gate.SetRunner(func(ctx context.Context, dir, command string) (string, error) {
+		ran = command
+		return "ok", nil
+	})
+
+	result, err := gate.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if ran != "go test -cover ex/a" || len(result.Affected.Targets) != 1 {
+		t.Errorf("expected only ex/a to be tested, ran %q", ran)
+	}
+
+	gate.SetFull(true)
+	gate.Check(context.Background())
+	if ran != "go test -cover ./..." {
+		t.Errorf("expected --full to run every test, ran %q", ran)
+	}
+
+	gate.SetFull(false)
+	changed, ran = "README.md\n", ""
+	result, err = gate.Check(context.Background())
+	if err != nil || ran != "" || !result.Passed {
+		t.Errorf("expected a change with no affected tests to pass without running, ran %q: %v", ran, err)
+	}
+}
+
+func TestGateAuditsToItsLogger(t *testing.T) {
+	root := t.TempDir()
+	logger, err := audit.NewLogger(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	gate := NewGate(config.TDDConfig{Enforce: true, TestCommand: "go test ./..."}, t.TempDir())
+	gate.SetRunner(fakeRunner("ok  	pkg	0.1s", nil))
+	gate.SetAuditLogger(logger)
+	if _, err := gate.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(logger.Path())
+	event, err := audit.ParseEvent([]byte(strings.TrimSpace(string(data))))
+	if err != nil {
+		t.Fatalf("expected one audit event, got %q: %v", data, err)
+	}
+	if event.Operation != "tdd.gate" {
+		t.Errorf("expected a tdd.gate event, got %+v", event)
+	}
+}
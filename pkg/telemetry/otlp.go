@@ -0,0 +1,232 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/offline"
+)
+
+// scopeName identifies flo's instrumentation in exported data.
+const scopeName = "github.com/richgo/flo"
+
+// OTLP span status codes and enum values.
+const (
+	statusError           = 2
+	spanKindInternal      = 1
+	temporalityCumulative = 2
+)
+
+// Flush exports the spans ended since the last flush and the current
+// value of every metric. Call it before the process exits. In offline
+// mode nothing is sent and the spans are dropped.
+func (p *Provider) Flush(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	spans := p.spans
+	p.spans = nil
+	metrics := p.metricsLocked(time.Now())
+	p.mu.Unlock()
+
+	if len(spans) == 0 && len(metrics) == 0 {
+		return nil
+	}
+	if offline.Enabled() {
+		return nil
+	}
+
+	var errs []error
+	if len(spans) > 0 {
+		body := map[string]interface{}{
+			"resourceSpans": []interface{}{map[string]interface{}{
+				"resource": p.resourceJSON(),
+				"scopeSpans": []interface{}{map[string]interface{}{
+					"scope": scopeJSON(),
+					"spans": spansJSON(spans),
+				}},
+			}},
+		}
+		if err := p.post(ctx, "/v1/traces", body); err != nil {
+			errs = append(errs, fmt.Errorf("failed to export traces: %w", err))
+		}
+	}
+	if len(metrics) > 0 {
+		body := map[string]interface{}{
+			"resourceMetrics": []interface{}{map[string]interface{}{
+				"resource": p.resourceJSON(),
+				"scopeMetrics": []interface{}{map[string]interface{}{
+					"scope":   scopeJSON(),
+					"metrics": metrics,
+				}},
+			}},
+		}
+		if err := p.post(ctx, "/v1/metrics", body); err != nil {
+			errs = append(errs, fmt.Errorf("failed to export metrics: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post sends an OTLP/HTTP JSON request.
+func (p *Provider) post(ctx context.Context, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimRight(p.cfg.Endpoint, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (p *Provider) resourceJSON() map[string]interface{} {
+	return map[string]interface{}{"attributes": attrsJSON(p.resource)}
+}
+
+func scopeJSON() map[string]interface{} {
+	return map[string]interface{}{"name": scopeName, "version": ServiceVersion}
+}
+
+func spansJSON(spans []*Span) []interface{} {
+	out := make([]interface{}, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := map[string]interface{}{
+			"traceId":           hex.EncodeToString(s.traceID[:]),
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              spanKindInternal,
+			"startTimeUnixNano": unixNano(s.start),
+			"endTimeUnixNano":   unixNano(s.end),
+			"attributes":        attrsJSON(s.attrs),
+		}
+		if s.parentID != ([8]byte{}) {
+			span["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+		}
+		if s.err != "" {
+			span["status"] = map[string]interface{}{"code": statusError, "message": s.err}
+		}
+		s.mu.Unlock()
+		out = append(out, span)
+	}
+	return out
+}
+
+// metricsLocked returns every metric's data points, grouped by name.
+func (p *Provider) metricsLocked(now time.Time) []interface{} {
+	start, end := unixNano(p.start), unixNano(now)
+	sums := make(map[string][]interface{})
+	var sumNames []string
+	for _, c := range p.counters {
+		if _, ok := sums[c.name]; !ok {
+			sumNames = append(sumNames, c.name)
+		}
+		sums[c.name] = append(sums[c.name], map[string]interface{}{
+			"attributes":        attrsJSON(c.attrs),
+			"startTimeUnixNano": start,
+			"timeUnixNano":      end,
+			"asInt":             strconv.FormatInt(c.value, 10),
+		})
+	}
+	hists := make(map[string][]interface{})
+	var histNames []string
+	for _, h := range p.histograms {
+		if _, ok := hists[h.name]; !ok {
+			histNames = append(histNames, h.name)
+		}
+		buckets := make([]string, len(h.buckets))
+		for i, n := range h.buckets {
+			buckets[i] = strconv.FormatUint(n, 10)
+		}
+		hists[h.name] = append(hists[h.name], map[string]interface{}{
+			"attributes":        attrsJSON(h.attrs),
+			"startTimeUnixNano": start,
+			"timeUnixNano":      end,
+			"count":             strconv.FormatUint(h.count, 10),
+			"sum":               h.sum,
+			"bucketCounts":      buckets,
+			"explicitBounds":    durationBounds,
+		})
+	}
+
+	sort.Strings(sumNames)
+	sort.Strings(histNames)
+	var out []interface{}
+	for _, name := range sumNames {
+		out = append(out, map[string]interface{}{
+			"name": name,
+			"sum": map[string]interface{}{
+				"dataPoints":             sums[name],
+				"aggregationTemporality": temporalityCumulative,
+				"isMonotonic":            true,
+			},
+		})
+	}
+	for _, name := range histNames {
+		out = append(out, map[string]interface{}{
+			"name": name,
+			"unit": "s",
+			"histogram": map[string]interface{}{
+				"dataPoints":             hists[name],
+				"aggregationTemporality": temporalityCumulative,
+			},
+		})
+	}
+	return out
+}
+
+// attrsJSON encodes attributes as OTLP KeyValues.
+func attrsJSON(attrs []Attr) []interface{} {
+	out := make([]interface{}, 0, len(attrs))
+	for _, a := range attrs {
+		var value map[string]interface{}
+		switch v := a.Value.(type) {
+		case string:
+			value = map[string]interface{}{"stringValue": v}
+		case int64:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case float64:
+			value = map[string]interface{}{"doubleValue": v}
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, map[string]interface{}{"key": a.Key, "value": value})
+	}
+	return out
+}
+
+// unixNano formats t as OTLP JSON encodes 64-bit integers.
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
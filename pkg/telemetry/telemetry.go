@@ -0,0 +1,355 @@
+// Package telemetry exports traces and metrics of flo's activity over
+// OTLP/HTTP, so runs show up in an existing OpenTelemetry stack: spans for
+// agent runs, backend sessions and task registry operations, and metrics
+// for task duration, retries and tokens.
+//
+// Telemetry is off unless an OTLP endpoint is configured. A nil *Provider
+// is valid and records nothing.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Standard OpenTelemetry environment variables, used when the workspace
+// config leaves the setting empty.
+const (
+	EndpointEnv    = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	HeadersEnv     = "OTEL_EXPORTER_OTLP_HEADERS"
+	ServiceNameEnv = "OTEL_SERVICE_NAME"
+	DisabledEnv    = "OTEL_SDK_DISABLED"
+)
+
+// Metric names.
+const (
+	// MetricTaskDuration is a histogram of agent run durations, in seconds.
+	MetricTaskDuration = "flo.task.duration"
+	// MetricRetries counts backend retries.
+	MetricRetries = "flo.backend.retries"
+	// MetricTokens counts tokens used, by type (input, output, cache_read,
+	// cache_write).
+	MetricTokens = "flo.tokens"
+)
+
+// ServiceVersion is reported as the service.version resource attribute.
+var ServiceVersion = "dev"
+
+// defaultTimeout bounds an export.
+const defaultTimeout = 10 * time.Second
+
+// maxBufferedSpans is how many ended spans are held before they are
+// exported early.
+const maxBufferedSpans = 512
+
+// durationBounds are the histogram buckets of MetricTaskDuration: from a
+// few seconds to an hour.
+var durationBounds = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+// Config configures export, under telemetry in .flo/config.yaml.
+type Config struct {
+	// Endpoint is the OTLP/HTTP collector URL, such as
+	// http://localhost:4318; traces and metrics are posted to /v1/traces
+	// and /v1/metrics under it. Empty disables telemetry.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Headers are sent with every export, such as an API key.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// ServiceName is the service.name resource attribute (default flo).
+	ServiceName string `yaml:"service_name,omitempty"`
+	// Timeout bounds each export (default 10s).
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// WithEnv fills unset fields from the standard OTEL_* environment
+// variables. OTEL_SDK_DISABLED=true turns telemetry off.
+func (c Config) WithEnv() Config {
+	if on, _ := strconv.ParseBool(os.Getenv(DisabledEnv)); on {
+		return Config{}
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = os.Getenv(EndpointEnv)
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = os.Getenv(ServiceNameEnv)
+	}
+	if env := os.Getenv(HeadersEnv); env != "" {
+		headers := make(map[string]string, len(c.Headers))
+		for _, pair := range strings.Split(env, ",") {
+			k, v, ok := strings.Cut(pair, "=")
+			if ok && strings.TrimSpace(k) != "" {
+				headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+		for k, v := range c.Headers {
+			headers[k] = v
+		}
+		c.Headers = headers
+	}
+	return c
+}
+
+// Validate checks the endpoint.
+func (c Config) Validate() error {
+	if c.Endpoint == "" {
+		return nil
+	}
+	if !strings.HasPrefix(c.Endpoint, "http://") && !strings.HasPrefix(c.Endpoint, "https://") {
+		return fmt.Errorf("endpoint must be an http or https URL, got '%s'", c.Endpoint)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	return nil
+}
+
+// Attr is a span or metric attribute. Values are strings, ints, int64s,
+// float64s or bools.
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute.
+func String(key, value string) Attr { return Attr{key, value} }
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attr { return Attr{key, int64(value)} }
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attr { return Attr{key, value} }
+
+// Float returns a floating-point attribute.
+func Float(key string, value float64) Attr { return Attr{key, value} }
+
+// Provider records spans and metrics and exports them to a collector.
+type Provider struct {
+	cfg      Config
+	client   *http.Client
+	resource []Attr
+	start    time.Time
+
+	mu         sync.Mutex
+	parent     *Span
+	spans      []*Span
+	counters   map[string]*counter
+	histograms map[string]*histogram
+}
+
+// New returns a provider exporting to cfg.Endpoint, or nil when no
+// endpoint is set. resource adds attributes describing this process, such
+// as the workspace's feature.
+func New(cfg Config, resource ...Attr) *Provider {
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "flo"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	host, _ := os.Hostname()
+	attrs := []Attr{
+		String("service.name", cfg.ServiceName),
+		String("service.version", ServiceVersion),
+		String("host.name", host),
+		Int("process.pid", os.Getpid()),
+	}
+	return &Provider{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		resource:   append(attrs, resource...),
+		start:      time.Now(),
+		counters:   make(map[string]*counter),
+		histograms: make(map[string]*histogram),
+	}
+}
+
+// SetClient replaces the HTTP client (for testing).
+func (p *Provider) SetClient(client *http.Client) {
+	if p != nil {
+		p.client = client
+	}
+}
+
+// Enabled reports whether telemetry is being recorded.
+func (p *Provider) Enabled() bool {
+	return p != nil
+}
+
+// Span is an operation being traced. A nil *Span ignores every call.
+type Span struct {
+	p        *Provider
+	name     string
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	start    time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs []Attr
+	err   string
+	ended bool
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the span carried by ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// SetParent makes spans started without a span in their context children
+// of s, so work done without a context, such as task registry operations,
+// joins the trace of the run.
+func (p *Provider) SetParent(s *Span) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.parent = s
+}
+
+// Start begins a span named name, a child of the span in ctx or of the
+// provider's parent, and returns a context carrying it. End the span when
+// the operation finishes.
+func (p *Provider) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	if p == nil {
+		return ctx, nil
+	}
+	s := &Span{p: p, name: name, start: time.Now(), attrs: attrs}
+	parent := SpanFromContext(ctx)
+	if parent == nil || parent.p != p {
+		p.mu.Lock()
+		parent = p.parent
+		p.mu.Unlock()
+	}
+	if parent != nil {
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+	} else {
+		rand.Read(s.traceID[:])
+	}
+	rand.Read(s.spanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// TraceID returns the span's trace ID in hex.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End finishes the span, marking it failed when err is non-nil. Only the
+// first call has an effect.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	if err != nil {
+		s.err = err.Error()
+	}
+	s.mu.Unlock()
+
+	p := s.p
+	p.mu.Lock()
+	p.spans = append(p.spans, s)
+	full := len(p.spans) >= maxBufferedSpans
+	p.mu.Unlock()
+	if full {
+		go p.Flush(context.Background())
+	}
+}
+
+// counter is a cumulative sum for one set of attributes.
+type counter struct {
+	name  string
+	attrs []Attr
+	value int64
+}
+
+// histogram is a cumulative distribution for one set of attributes.
+type histogram struct {
+	name    string
+	attrs   []Attr
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+// seriesKey identifies a metric's time series by name and attributes.
+func seriesKey(name string, attrs []Attr) string {
+	parts := make([]string, 0, len(attrs))
+	for _, a := range attrs {
+		parts = append(parts, fmt.Sprintf("%s=%v", a.Key, a.Value))
+	}
+	sort.Strings(parts)
+	return name + "{" + strings.Join(parts, ",") + "}"
+}
+
+// Add increases the counter name by n.
+func (p *Provider) Add(name string, n int64, attrs ...Attr) {
+	if p == nil || n == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := seriesKey(name, attrs)
+	c, ok := p.counters[key]
+	if !ok {
+		c = &counter{name: name, attrs: attrs}
+		p.counters[key] = c
+	}
+	c.value += n
+}
+
+// RecordDuration adds d, in seconds, to the histogram name.
+func (p *Provider) RecordDuration(name string, d time.Duration, attrs ...Attr) {
+	if p == nil {
+		return
+	}
+	v := d.Seconds()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := seriesKey(name, attrs)
+	h, ok := p.histograms[key]
+	if !ok {
+		h = &histogram{name: name, attrs: attrs, buckets: make([]uint64, len(durationBounds)+1)}
+		p.histograms[key] = h
+	}
+	h.count++
+	h.sum += v
+	i := sort.SearchFloat64s(durationBounds, v)
+	h.buckets[i]++
+}
@@ -0,0 +1,278 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/offline"
+)
+
+// collector records OTLP requests by path.
+type collector struct {
+	mu       sync.Mutex
+	requests map[string][]map[string]interface{}
+	headers  http.Header
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	c := &collector{requests: make(map[string][]map[string]interface{})}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid OTLP JSON: %v", err)
+		}
+		c.mu.Lock()
+		c.requests[r.URL.Path] = append(c.requests[r.URL.Path], body)
+		c.headers = r.Header.Clone()
+		c.mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+// spans returns the exported spans by name.
+func (c *collector) spans() map[string]map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]map[string]interface{})
+	for _, body := range c.requests["/v1/traces"] {
+		for _, rs := range body["resourceSpans"].([]interface{}) {
+			for _, ss := range rs.(map[string]interface{})["scopeSpans"].([]interface{}) {
+				for _, s := range ss.(map[string]interface{})["spans"].([]interface{}) {
+					span := s.(map[string]interface{})
+					out[span["name"].(string)] = span
+				}
+			}
+		}
+	}
+	return out
+}
+
+// metrics returns the exported metrics by name.
+func (c *collector) metrics() map[string]map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]map[string]interface{})
+	for _, body := range c.requests["/v1/metrics"] {
+		for _, rm := range body["resourceMetrics"].([]interface{}) {
+			for _, sm := range rm.(map[string]interface{})["scopeMetrics"].([]interface{}) {
+				for _, m := range sm.(map[string]interface{})["metrics"].([]interface{}) {
+					metric := m.(map[string]interface{})
+					out[metric["name"].(string)] = metric
+				}
+			}
+		}
+	}
+	return out
+}
+
+func attr(span map[string]interface{}, key string) map[string]interface{} {
+	for _, a := range span["attributes"].([]interface{}) {
+		kv := a.(map[string]interface{})
+		if kv["key"] == key {
+			return kv["value"].(map[string]interface{})
+		}
+	}
+	return nil
+}
+
+func TestNewDisabledWithoutEndpoint(t *testing.T) {
+	p := New(Config{})
+	if p.Enabled() {
+		t.Fatal("expected telemetry to be off without an endpoint")
+	}
+	// A nil provider and its spans are no-ops
+	ctx, span := p.Start(context.Background(), "op")
+	span.SetAttributes(String("k", "v"))
+	span.End(errors.New("failed"))
+	p.Add(MetricRetries, 1)
+	p.RecordDuration(MetricTaskDuration, time.Second)
+	if SpanFromContext(ctx) != nil {
+		t.Error("expected no span in the context")
+	}
+	if err := p.Flush(context.Background()); err != nil {
+		t.Errorf("Flush: %v", err)
+	}
+}
+
+func TestSpansExported(t *testing.T) {
+	c, srv := newCollector(t)
+	p := New(Config{Endpoint: srv.URL, Headers: map[string]string{"X-Api-Key": "k"}}, String("flo.feature", "auth"))
+
+	ctx, root := p.Start(context.Background(), "flo.work", String("task.id", "t-1"))
+	_, child := p.Start(ctx, "flo.backend.session", Int("flo.retries", 2))
+	child.End(errors.New("quota exceeded"))
+	root.SetAttributes(Bool("flo.success", false))
+	root.End(nil)
+
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	spans := c.spans()
+	work, session := spans["flo.work"], spans["flo.backend.session"]
+	if work == nil || session == nil {
+		t.Fatalf("expected both spans, got %v", spans)
+	}
+	if session["traceId"] != work["traceId"] || session["parentSpanId"] != work["spanId"] {
+		t.Error("expected the session span to be a child of the work span")
+	}
+	if _, ok := work["parentSpanId"]; ok {
+		t.Error("expected the work span to be a root span")
+	}
+	if len(work["traceId"].(string)) != 32 || len(work["spanId"].(string)) != 16 {
+		t.Errorf("expected hex IDs, got %v and %v", work["traceId"], work["spanId"])
+	}
+	if v := attr(work, "task.id"); v["stringValue"] != "t-1" {
+		t.Errorf("task.id = %v", v)
+	}
+	if v := attr(session, "flo.retries"); v["intValue"] != "2" {
+		t.Errorf("flo.retries = %v", v)
+	}
+	status, _ := session["status"].(map[string]interface{})
+	if status["code"] != float64(statusError) || status["message"] != "quota exceeded" {
+		t.Errorf("expected an error status, got %v", session["status"])
+	}
+	if c.headers.Get("X-Api-Key") != "k" {
+		t.Error("expected configured headers on the export")
+	}
+
+	// Spans are only exported once
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.requests["/v1/traces"]); n != 1 {
+		t.Errorf("expected one trace export, got %d", n)
+	}
+}
+
+func TestSetParent(t *testing.T) {
+	c, srv := newCollector(t)
+	p := New(Config{Endpoint: srv.URL})
+
+	_, run := p.Start(context.Background(), "flo.work")
+	p.SetParent(run)
+	_, op := p.Start(context.Background(), "task.registry.update")
+	op.End(nil)
+	run.End(nil)
+	p.Flush(context.Background())
+
+	spans := c.spans()
+	if spans["task.registry.update"]["parentSpanId"] != spans["flo.work"]["spanId"] {
+		t.Error("expected spans without a context parent to join the run")
+	}
+}
+
+func TestMetricsExported(t *testing.T) {
+	c, srv := newCollector(t)
+	p := New(Config{Endpoint: srv.URL})
+
+	backend := String("flo.backend", "claude")
+	p.Add(MetricTokens, 1000, backend, String("flo.token_type", "input"))
+	p.Add(MetricTokens, 500, backend, String("flo.token_type", "input"))
+	p.Add(MetricTokens, 200, backend, String("flo.token_type", "output"))
+	p.RecordDuration(MetricTaskDuration, 3*time.Second, backend)
+	p.RecordDuration(MetricTaskDuration, 90*time.Second, backend)
+
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	metrics := c.metrics()
+
+	tokens := metrics[MetricTokens]["sum"].(map[string]interface{})
+	if tokens["isMonotonic"] != true || tokens["aggregationTemporality"] != float64(temporalityCumulative) {
+		t.Errorf("unexpected sum: %v", tokens)
+	}
+	totals := make(map[string]string)
+	for _, dp := range tokens["dataPoints"].([]interface{}) {
+		point := dp.(map[string]interface{})
+		totals[attr(point, "flo.token_type")["stringValue"].(string)] = point["asInt"].(string)
+	}
+	if totals["input"] != "1500" || totals["output"] != "200" {
+		t.Errorf("unexpected token totals: %v", totals)
+	}
+
+	hist := metrics[MetricTaskDuration]["histogram"].(map[string]interface{})
+	point := hist["dataPoints"].([]interface{})[0].(map[string]interface{})
+	if point["count"] != "2" || point["sum"] != float64(93) {
+		t.Errorf("unexpected histogram point: %v", point)
+	}
+	buckets := point["bucketCounts"].([]interface{})
+	if len(buckets) != len(durationBounds)+1 || buckets[1] != "1" || buckets[5] != "1" {
+		t.Errorf("unexpected buckets: %v", buckets)
+	}
+}
+
+func TestFlushCollectorError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	p := New(Config{Endpoint: srv.URL})
+	_, span := p.Start(context.Background(), "op")
+	span.End(nil)
+
+	err := p.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected the collector's error, got %v", err)
+	}
+}
+
+func TestFlushOffline(t *testing.T) {
+	c, srv := newCollector(t)
+	p := New(Config{Endpoint: srv.URL})
+	_, span := p.Start(context.Background(), "op")
+	span.End(nil)
+
+	offline.Set(true)
+	defer offline.Set(false)
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(c.requests) != 0 {
+		t.Error("expected nothing exported in offline mode")
+	}
+}
+
+func TestConfigWithEnv(t *testing.T) {
+	t.Setenv(EndpointEnv, "http://collector:4318")
+	t.Setenv(HeadersEnv, "authorization=Bearer abc, x-team=eng")
+	t.Setenv(ServiceNameEnv, "flo-ci")
+
+	cfg := Config{Headers: map[string]string{"x-team": "platform"}}.WithEnv()
+	if cfg.Endpoint != "http://collector:4318" || cfg.ServiceName != "flo-ci" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Headers["authorization"] != "Bearer abc" || cfg.Headers["x-team"] != "platform" {
+		t.Errorf("expected env headers with config precedence, got %v", cfg.Headers)
+	}
+
+	cfg = Config{Endpoint: "https://configured:4318"}.WithEnv()
+	if cfg.Endpoint != "https://configured:4318" {
+		t.Errorf("expected the configured endpoint to win, got %s", cfg.Endpoint)
+	}
+
+	t.Setenv(DisabledEnv, "true")
+	if cfg := (Config{Endpoint: "https://configured:4318"}).WithEnv(); cfg.Endpoint != "" {
+		t.Errorf("expected %s to disable telemetry", DisabledEnv)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("empty config: %v", err)
+	}
+	if err := (Config{Endpoint: "localhost:4318"}).Validate(); err == nil {
+		t.Error("expected an endpoint without a scheme to be rejected")
+	}
+	if err := (Config{Endpoint: "http://localhost:4318", Timeout: -time.Second}).Validate(); err == nil {
+		t.Error("expected a negative timeout to be rejected")
+	}
+}
@@ -0,0 +1,250 @@
+// Package testresult parses the output of test runners into counts of
+// passed, failed and skipped tests and the failures' details, so agents
+// and the TDD gate see the same structured results.
+package testresult
+
+import (
+	"bufio"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format identifies a test runner's output format.
+type Format string
+
+const (
+	// FormatGo is go test -json output.
+	FormatGo Format = "go"
+	// FormatJest is jest --json output or its text summary.
+	FormatJest Format = "jest"
+	// FormatPytest is pytest's text output.
+	FormatPytest Format = "pytest"
+)
+
+// maxFailureOutput is how much output, in bytes, each failure keeps.
+const maxFailureOutput = 4096
+
+// Failure is a failed test.
+type Failure struct {
+	Name string `json:"name"`
+	// Output is what the test printed or its failure message, cut to its
+	// last 4KiB.
+	Output string `json:"output,omitempty"`
+}
+
+// Summary is a parsed test run.
+type Summary struct {
+	Format   Format    `json:"format"`
+	Passed   int       `json:"passed"`
+	Failed   int       `json:"failed"`
+	Skipped  int       `json:"skipped"`
+	Failures []Failure `json:"failures,omitempty"`
+}
+
+// Total returns how many tests ran or were skipped.
+func (s *Summary) Total() int {
+	return s.Passed + s.Failed + s.Skipped
+}
+
+// Parse reads test output in any known format, returning false when it is
+// in none of them.
+func Parse(output string) (*Summary, bool) {
+	for _, parse := range []func(string) (*Summary, bool){ParseGo, ParseJest, ParsePytest} {
+		if s, ok := parse(output); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// goEvent is a go test -json event.
+type goEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Output  string
+}
+
+// ParseGo reads go test -json output. Packages that fail without a failing
+// test, such as on a build error, count as one failure each.
+func ParseGo(output string) (*Summary, bool) {
+	s := &Summary{Format: FormatGo}
+	outputs := make(map[string]*strings.Builder)
+	failedTests := make(map[string]bool)
+	var failedPackages []string
+	events := 0
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var e goEvent
+		if json.Unmarshal([]byte(line), &e) != nil || e.Action == "" {
+			continue
+		}
+		events++
+		key := e.Package
+		if e.Test != "" {
+			key += "." + e.Test
+		}
+		switch e.Action {
+		case "output":
+			b, ok := outputs[key]
+			if !ok {
+				b = &strings.Builder{}
+				outputs[key] = b
+			}
+			b.WriteString(e.Output)
+		case "pass":
+			if e.Test != "" {
+				s.Passed++
+			}
+		case "skip":
+			if e.Test != "" {
+				s.Skipped++
+			}
+		case "fail":
+			if e.Test != "" {
+				s.Failed++
+				failedTests[key] = true
+				s.Failures = append(s.Failures, Failure{Name: key})
+			} else {
+				failedPackages = append(failedPackages, key)
+			}
+		}
+	}
+	if events == 0 {
+		return nil, false
+	}
+
+	for _, pkg := range failedPackages {
+		hasFailedTest := false
+		for name := range failedTests {
+			if strings.HasPrefix(name, pkg+".") {
+				hasFailedTest = true
+				break
+			}
+		}
+		if !hasFailedTest {
+			s.Failed++
+			s.Failures = append(s.Failures, Failure{Name: pkg})
+		}
+	}
+	for i, f := range s.Failures {
+		if b, ok := outputs[f.Name]; ok {
+			s.Failures[i].Output = tail(b.String())
+		}
+	}
+	return s, true
+}
+
+// jestReport is the part of jest --json output read.
+type jestReport struct {
+	NumPassedTests  *int `json:"numPassedTests"`
+	NumFailedTests  int  `json:"numFailedTests"`
+	NumPendingTests int  `json:"numPendingTests"`
+	TestResults     []struct {
+		Name             string `json:"name"`
+		AssertionResults []struct {
+			FullName        string   `json:"fullName"`
+			Status          string   `json:"status"`
+			FailureMessages []string `json:"failureMessages"`
+		} `json:"assertionResults"`
+	} `json:"testResults"`
+}
+
+// jestSummary matches jest's "Tests: 1 failed, 2 skipped, 5 passed, 8 total".
+var jestSummary = regexp.MustCompile(`(?m)^Tests:\s+(.*\d+ total)\s*$`)
+
+// jestFailure matches the heading of a failed test in jest's text output.
+var jestFailure = regexp.MustCompile(`(?m)^\s*● (.+)$`)
+
+// ParseJest reads jest --json output, or jest's text summary.
+func ParseJest(output string) (*Summary, bool) {
+	if start := strings.Index(output, "{"); start >= 0 {
+		// Reporters may print around the report
+		var r jestReport
+		if json.NewDecoder(strings.NewReader(output[start:])).Decode(&r) == nil && r.NumPassedTests != nil {
+			s := &Summary{Format: FormatJest, Passed: *r.NumPassedTests, Failed: r.NumFailedTests, Skipped: r.NumPendingTests}
+			for _, file := range r.TestResults {
+				for _, a := range file.AssertionResults {
+					if a.Status == "failed" {
+						s.Failures = append(s.Failures, Failure{Name: a.FullName, Output: tail(strings.Join(a.FailureMessages, "\n"))})
+					}
+				}
+			}
+			return s, true
+		}
+	}
+
+	m := jestSummary.FindStringSubmatch(output)
+	if m == nil {
+		return nil, false
+	}
+	s := &Summary{Format: FormatJest}
+	counts := parseCounts(m[1])
+	s.Passed, s.Failed, s.Skipped = counts["passed"], counts["failed"], counts["skipped"]+counts["todo"]
+	for _, f := range jestFailure.FindAllStringSubmatch(output, -1) {
+		if !strings.HasPrefix(f[1], "Console") {
+			s.Failures = append(s.Failures, Failure{Name: strings.TrimSpace(f[1])})
+		}
+	}
+	return s, true
+}
+
+// pytestSummary matches pytest's final "=== 2 failed, 10 passed in 0.12s ===".
+var pytestSummary = regexp.MustCompile(`(?m)^=+ (.*) in [0-9.]+s( \([0-9:]+\))? =+\s*$`)
+
+// pytestFailure matches a "FAILED path::test - message" line of pytest's
+// short test summary.
+var pytestFailure = regexp.MustCompile(`(?m)^(?:FAILED|ERROR) (\S+)(?: - (.*))?$`)
+
+// ParsePytest reads pytest's text output. Errors count as failures.
+func ParsePytest(output string) (*Summary, bool) {
+	matches := pytestSummary.FindAllStringSubmatch(output, -1)
+	if matches == nil {
+		return nil, false
+	}
+	counts := parseCounts(matches[len(matches)-1][1])
+	s := &Summary{
+		Format:  FormatPytest,
+		Passed:  counts["passed"] + counts["xpassed"],
+		Failed:  counts["failed"] + counts["error"] + counts["errors"],
+		Skipped: counts["skipped"] + counts["xfailed"] + counts["deselected"],
+	}
+	for _, f := range pytestFailure.FindAllStringSubmatch(output, -1) {
+		s.Failures = append(s.Failures, Failure{Name: f[1], Output: tail(f[2])})
+	}
+	return s, true
+}
+
+// parseCounts reads "1 failed, 5 passed, 8 total" into counts by word.
+func parseCounts(summary string) map[string]int {
+	counts := make(map[string]int)
+	for _, part := range strings.Split(summary, ",") {
+		fields := strings.Fields(part)
+		if len(fields) < 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		counts[fields[1]] += n
+	}
+	return counts
+}
+
+// tail cuts s to its last maxFailureOutput bytes.
+func tail(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= maxFailureOutput {
+		return s
+	}
+	return "..." + s[len(s)-maxFailureOutput:]
+}
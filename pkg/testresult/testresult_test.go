@@ -0,0 +1,90 @@
+package testresult
+
+import (
+	"strings"
+	"testing"
+)
+
+const goOutput = `{"Action":"run","Package":"example.com/a","Test":"TestOK"}
+{"Action":"pass","Package":"example.com/a","Test":"TestOK","Elapsed":0}
+{"Action":"run","Package":"example.com/a","Test":"TestBad"}
+{"Action":"output","Package":"example.com/a","Test":"TestBad","Output":"    a_test.go:9: got 1, want 2\n"}
+{"Action":"fail","Package":"example.com/a","Test":"TestBad","Elapsed":0}
+{"Action":"skip","Package":"example.com/a","Test":"TestLater","Elapsed":0}
+{"Action":"output","Package":"example.com/a","Output":"coverage: 50.0% of statements\n"}
+{"Action":"fail","Package":"example.com/a","Elapsed":0.1}
+{"Action":"output","Package":"example.com/b","Output":"b.go:3:1: syntax error\n"}
+{"Action":"fail","Package":"example.com/b","Elapsed":0}
+`
+
+func TestParseGo(t *testing.T) {
+	s, ok := Parse(goOutput)
+	if !ok || s.Format != FormatGo {
+		t.Fatalf("expected go test -json output to be recognized, got %+v", s)
+	}
+	if s.Passed != 1 || s.Failed != 2 || s.Skipped != 1 || s.Total() != 4 {
+		t.Errorf("unexpected counts: %+v", s)
+	}
+	if len(s.Failures) != 2 || s.Failures[0].Name != "example.com/a.TestBad" || !strings.Contains(s.Failures[0].Output, "got 1, want 2") {
+		t.Fatalf("expected TestBad with its output, got %+v", s.Failures)
+	}
+	if s.Failures[1].Name != "example.com/b" || !strings.Contains(s.Failures[1].Output, "syntax error") {
+		t.Errorf("expected the package that failed to build, got %+v", s.Failures[1])
+	}
+}
+
+func TestParseJest(t *testing.T) {
+	json := `Running tests
+{"numPassedTests":3,"numFailedTests":1,"numPendingTests":2,"testResults":[{"name":"/app/sum.test.js","assertionResults":[
+{"fullName":"sum adds","status":"passed","failureMessages":[]},
+{"fullName":"sum subtracts","status":"failed","failureMessages":["Expected: 1\nReceived: 2"]}]}]}
+Done`
+	s, ok := Parse(json)
+	if !ok || s.Format != FormatJest || s.Passed != 3 || s.Failed != 1 || s.Skipped != 2 {
+		t.Fatalf("unexpected jest --json summary: %+v", s)
+	}
+	if len(s.Failures) != 1 || s.Failures[0].Name != "sum subtracts" || !strings.Contains(s.Failures[0].Output, "Received: 2") {
+		t.Errorf("unexpected failures: %+v", s.Failures)
+	}
+
+	text := `FAIL src/sum.test.js
+  ● sum › subtracts
+
+    expect(received).toBe(expected)
+
+Test Suites: 1 failed, 1 total
+Tests:       1 failed, 1 skipped, 4 passed, 6 total
+`
+	s, ok = Parse(text)
+	if !ok || s.Format != FormatJest || s.Passed != 4 || s.Failed != 1 || s.Skipped != 1 {
+		t.Fatalf("unexpected jest text summary: %+v", s)
+	}
+	if len(s.Failures) != 1 || s.Failures[0].Name != "sum › subtracts" {
+		t.Errorf("unexpected failures: %+v", s.Failures)
+	}
+}
+
+func TestParsePytest(t *testing.T) {
+	output := `============================= test session starts ==============================
+collected 5 items
+
+tests/test_sum.py ..F.s                                                  [100%]
+
+=========================== short test summary info ============================
+FAILED tests/test_sum.py::test_sub - assert 2 == 1
+==================== 1 failed, 3 passed, 1 skipped in 0.12s ====================
+`
+	s, ok := Parse(output)
+	if !ok || s.Format != FormatPytest || s.Passed != 3 || s.Failed != 1 || s.Skipped != 1 {
+		t.Fatalf("unexpected pytest summary: %+v", s)
+	}
+	if len(s.Failures) != 1 || s.Failures[0].Name != "tests/test_sum.py::test_sub" || s.Failures[0].Output != "assert 2 == 1" {
+		t.Errorf("unexpected failures: %+v", s.Failures)
+	}
+}
+
+func TestParseUnknown(t *testing.T) {
+	if s, ok := Parse("ok  \texample.com/a\t0.1s\n"); ok {
+		t.Errorf("expected plain go test output not to be parsed, got %+v", s)
+	}
+}
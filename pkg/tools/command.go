@@ -0,0 +1,269 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/secrets"
+)
+
+// RootFunc returns the directory a call's paths are relative to and
+// confined to, such as the worktree of the caller's focused task.
+type RootFunc func(ctx context.Context) (string, error)
+
+// CommandRules gate the commands run_command may run. Both are regular
+// expressions matched against the command line: a command must match one
+// of Allow and none of Deny.
+type CommandRules struct {
+	Allow []string
+	Deny  []string
+}
+
+// DefaultCommandDeny are denied whatever the configured rules allow: flags
+// that make an allowed command run another program, such as go test
+// -exec, go build -toolexec, go vet -vettool and find -exec.
+var DefaultCommandDeny = []string{`(^|\s)--?(exec|toolexec|vettool)(=|\s|$)`}
+
+// commandRules are compiled CommandRules.
+type commandRules struct {
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+}
+
+func compileRules(field string, exprs []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern '%s': %w", field, expr, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// check returns why line may not run, or "" when it may. raw is the
+// command as given, checked against Deny too so quoting can't dodge it.
+func (r *commandRules) check(line, raw string) string {
+	for _, re := range r.deny {
+		if re.MatchString(line) || re.MatchString(raw) {
+			return fmt.Sprintf("matches denied command %s", re)
+		}
+	}
+	for _, re := range r.allow {
+		if re.MatchString(line) {
+			return ""
+		}
+	}
+	return "matches no allowed command"
+}
+
+// NewRunCommand creates the run_command tool, which runs an allowed
+// command in a directory inside root. Commands run without a shell, so
+// pipes, redirection, variables and chaining are unavailable and an
+// allowed command can't smuggle in another. DefaultCommandDeny is denied
+// on top of rules.Deny, and commands run without the credential-like
+// variables of flo's environment.
+func NewRunCommand(rules CommandRules, root RootFunc) (*Tool, error) {
+	var compiled commandRules
+	var err error
+	if compiled.allow, err = compileRules("allow", rules.Allow); err != nil {
+		return nil, err
+	}
+	deny := append(append([]string{}, DefaultCommandDeny...), rules.Deny...)
+	if compiled.deny, err = compileRules("deny", deny); err != nil {
+		return nil, err
+	}
+
+	return NewWithContext(
+		"run_command",
+		"Run an allowed command, such as a build or test command, in the task worktree. "+
+			"The command runs without a shell: no pipes, redirection or variables. "+
+			"Returns the combined stdout and stderr.",
+		map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"command": map[string]any{
+					"type":        "string",
+					"description": "Command line, e.g. 'go test ./pkg/...'; quote arguments with spaces",
+				},
+				"dir": map[string]any{
+					"type":        "string",
+					"description": "Directory to run in, relative to the worktree (default the worktree)",
+				},
+			},
+			"required": []string{"command"},
+		},
+		func(ctx context.Context, args Args) (string, error) {
+			return runCommand(ctx, &compiled, root, args)
+		},
+	).withLimits(Limits{Timeout: TestSuiteTimeout}), nil
+}
+
+func runCommand(ctx context.Context, rules *commandRules, root RootFunc, args Args) (string, error) {
+	raw := strings.TrimSpace(args["command"].(string))
+	argv, err := splitCommand(raw)
+	if err != nil {
+		return "", err
+	}
+	if len(argv) == 0 {
+		return "", fmt.Errorf("command is empty")
+	}
+	line := strings.Join(argv, " ")
+	if reason := rules.check(line, raw); reason != "" {
+		audit.Warn("tools.run_command", "Command refused", map[string]interface{}{
+			"command": line,
+			"reason":  reason,
+		})
+		return "", fmt.Errorf("command '%s' is not allowed: %s", line, reason)
+	}
+
+	base, err := root(ctx)
+	if err != nil {
+		return "", err
+	}
+	subdir, _ := args["dir"].(string)
+	dir, err := Confine(base, subdir)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	// Don't wait on background children still holding the output pipe
+	// once the command has been killed.
+	cmd.WaitDelay = time.Second
+	cmd.Env = commandEnv(os.Environ())
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	start := time.Now()
+	runErr := cmd.Run()
+	audit.Info("tools.run_command", "Command executed", map[string]interface{}{
+		"command":  line,
+		"dir":      dir,
+		"success":  runErr == nil,
+		"duration": time.Since(start).String(),
+	})
+
+	if runErr != nil {
+		return "", fmt.Errorf("command '%s' failed: %v\n%s", line, runErr, out.String())
+	}
+	return out.String(), nil
+}
+
+// commandEnv returns env without the variables that look like they hold
+// credentials, such as API keys and flo's own MCP token, so an allowed
+// command can't print them back to the agent.
+func commandEnv(env []string) []string {
+	scrubbed := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if !secrets.IsSensitiveName(name) {
+			scrubbed = append(scrubbed, kv)
+		}
+	}
+	return scrubbed
+}
+
+// SuiteRunner runs the test suite in dir, every test when full is set and
+// otherwise perhaps only those affected by the change, and returns its
+// result, which is reported as JSON.
+type SuiteRunner func(ctx context.Context, dir string, full bool) (any, error)
+
+// NewRunTests creates the run_tests tool, which runs the test suite in the
+// worktree and reports whether it passed, the parsed test results and
+// coverage.
+func NewRunTests(run SuiteRunner, root RootFunc) *Tool {
+	return NewWithContext(
+		"run_tests",
+		"Run the configured test command in the task worktree. Returns JSON with whether the tests passed, "+
+			"counts of passed, failed and skipped tests with each failure's output, and coverage.",
+		map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"full": map[string]any{
+					"type":        "boolean",
+					"description": "Run every test, not just those affected by the change",
+				},
+			},
+		},
+		func(ctx context.Context, args Args) (string, error) {
+			dir, err := root(ctx)
+			if err != nil {
+				return "", err
+			}
+			full, _ := args["full"].(bool)
+			result, err := run(ctx, dir, full)
+			if err != nil {
+				return "", fmt.Errorf("failed to run tests: %w", err)
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return "", err
+			}
+			return string(data), nil
+		},
+	).withLimits(Limits{Timeout: TestSuiteTimeout})
+}
+
+// splitCommand splits a command line into words as a POSIX shell would
+// for quotes and backslashes, without expanding anything.
+func splitCommand(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in command")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
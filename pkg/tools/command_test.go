@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"go test ./...", []string{"go", "test", "./..."}},
+		{`echo 'a b' "c \"d\"" e\ f`, []string{"echo", "a b", `c "d"`, "e f"}},
+		{`echo '' x;y`, []string{"echo", "", "x;y"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.line)
+		if err != nil {
+			t.Fatalf("splitCommand(%q) failed: %v", tt.line, err)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+	if _, err := splitCommand(`echo "open`); err == nil {
+		t.Error("expected an unterminated quote to fail")
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "sub"), 0755)
+	tool, err := NewRunCommand(CommandRules{
+		Allow: []string{`^echo `, `^pwd$`, `^false$`},
+		Deny:  []string{`secret`},
+	}, func(ctx context.Context) (string, error) { return root, nil })
+	if err != nil {
+		t.Fatalf("NewRunCommand failed: %v", err)
+	}
+	run := func(args Args) (string, error) {
+		return tool.ExecuteContext(context.Background(), args, tool.Limits)
+	}
+
+	out, err := run(Args{"command": "echo 'hello world'; rm -rf /"})
+	if err != nil {
+		t.Fatalf("echo failed: %v", err)
+	}
+	if strings.TrimSpace(out) != "hello world; rm -rf /" {
+		t.Errorf("expected the command to run without a shell, got %q", out)
+	}
+
+	out, err = run(Args{"command": "pwd", "dir": "sub"})
+	if err != nil {
+		t.Fatalf("pwd failed: %v", err)
+	}
+	if real, _ := filepath.EvalSymlinks(filepath.Join(root, "sub")); strings.TrimSpace(out) != real {
+		t.Errorf("expected to run in %s, got %q", real, out)
+	}
+
+	for _, args := range []Args{
+		{"command": "ls"},
+		{"command": "echo se'cr'et"},
+		{"command": "pwd", "dir": "../"},
+	} {
+		if _, err := run(args); err == nil {
+			t.Errorf("expected %v to be refused", args)
+		}
+	}
+	if _, err := run(Args{"command": "false"}); err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("expected a failing command to fail, got %v", err)
+	}
+
+	if _, err := NewRunCommand(CommandRules{Allow: []string{"("}}, nil); err == nil {
+		t.Error("expected an invalid pattern to fail")
+	}
+}
+
+func TestRunCommandDefaultDeny(t *testing.T) {
+	root := t.TempDir()
+	tool, err := NewRunCommand(CommandRules{
+		Allow: []string{`^go (build|test|vet)( |$)`, `^find `},
+	}, func(ctx context.Context) (string, error) { return root, nil })
+	if err != nil {
+		t.Fatalf("NewRunCommand failed: %v", err)
+	}
+	for _, command := range []string{
+		"go test -exec /tmp/x ./...",
+		"go test --exec=/tmp/x ./...",
+		"go build -toolexec=/tmp/x ./...",
+		"go build '-toolexec' /tmp/x",
+		"go vet -vettool=/tmp/x ./...",
+		"find . -exec sh ;",
+	} {
+		_, err := tool.ExecuteContext(context.Background(), Args{"command": command}, tool.Limits)
+		if err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Errorf("expected %q to be refused, got %v", command, err)
+		}
+	}
+}
+
+func TestRunCommandEnv(t *testing.T) {
+	t.Setenv("FLO_MCP_TOKEN", "mcp-token")
+	t.Setenv("ANTHROPIC_API_KEY", "api-key")
+	t.Setenv("FLO_TEST_PLAIN", "plain")
+	root := t.TempDir()
+	tool, err := NewRunCommand(CommandRules{Allow: []string{`^env$`}},
+		func(ctx context.Context) (string, error) { return root, nil })
+	if err != nil {
+		t.Fatalf("NewRunCommand failed: %v", err)
+	}
+	out, err := tool.ExecuteContext(context.Background(), Args{"command": "env"}, tool.Limits)
+	if err != nil {
+		t.Fatalf("env failed: %v", err)
+	}
+	if strings.Contains(out, "mcp-token") || strings.Contains(out, "api-key") {
+		t.Errorf("expected credentials left out of the environment, got %s", out)
+	}
+	if !strings.Contains(out, "FLO_TEST_PLAIN=plain") || !strings.Contains(out, "PATH=") {
+		t.Errorf("expected the rest of the environment kept, got %s", out)
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	var gotDir string
+	var gotFull bool
+	tool := NewRunTests(func(ctx context.Context, dir string, full bool) (any, error) {
+		gotDir, gotFull = dir, full
+		return map[string]any{"passed": true}, nil
+	}, func(ctx context.Context) (string, error) { return "/work/t-001", nil })
+
+	out, err := tool.Execute(Args{"full": true})
+	if err != nil {
+		t.Fatalf("run_tests failed: %v", err)
+	}
+	if gotDir != "/work/t-001" || !gotFull {
+		t.Errorf("expected a full run in the worktree, got %s, %v", gotDir, gotFull)
+	}
+	if !strings.Contains(out, `"passed": true`) {
+		t.Errorf("expected the result as JSON, got %s", out)
+	}
+}
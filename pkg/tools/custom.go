@@ -0,0 +1,328 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/richgo/flo/pkg/audit"
+)
+
+var toolNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// CustomToolSpec describes a tool that wraps a shell command, loaded from
+// a YAML file such as .flo/tools/migrate.yaml:
+//
+//	name: run_migration
+//	description: Apply database migrations up to a version
+//	schema:
+//	  type: object
+//	  properties:
+//	    version: {type: string, description: Target version}
+//	  required: [version]
+//	command: make migrate VERSION={{.version}}
+//	timeout: 5m
+//
+// Arguments are available in the command template shell-quoted, so
+// {{.version}} expands to a single word, and as FLO_ARG_<NAME> environment
+// variables.
+type CustomToolSpec struct {
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Schema      map[string]any `yaml:"schema,omitempty"`
+	Command     string         `yaml:"command"`
+	// Timeout and MaxOutput override the registry limits.
+	Timeout   time.Duration `yaml:"timeout,omitempty"`
+	MaxOutput int           `yaml:"max_output,omitempty"`
+	// Dir is the working directory relative to the workspace root.
+	Dir string            `yaml:"dir,omitempty"`
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// Source is the file the spec was loaded from.
+	Source string `yaml:"-"`
+}
+
+// Validate checks the spec and parses its command template.
+func (s *CustomToolSpec) Validate() error {
+	if !toolNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("invalid tool name '%s' (letters, digits, '_' and '-')", s.Name)
+	}
+	if s.Description == "" {
+		return fmt.Errorf("tool '%s': description is required", s.Name)
+	}
+	if strings.TrimSpace(s.Command) == "" {
+		return fmt.Errorf("tool '%s': command is required", s.Name)
+	}
+	if s.Timeout < 0 || s.MaxOutput < 0 {
+		return fmt.Errorf("tool '%s': timeout and max_output must be positive", s.Name)
+	}
+	if filepath.IsAbs(s.Dir) || strings.HasPrefix(filepath.Clean(s.Dir), "..") {
+		return fmt.Errorf("tool '%s': dir must be inside the workspace", s.Name)
+	}
+	if _, err := s.template(); err != nil {
+		return fmt.Errorf("tool '%s': invalid command template: %w", s.Name, err)
+	}
+	return nil
+}
+
+func (s *CustomToolSpec) template() (*template.Template, error) {
+	return template.New(s.Name).Option("missingkey=zero").Parse(s.Command)
+}
+
+// LoadCustomTools reads every *.yaml and *.yml tool spec in dir, sorted by
+// name. A missing directory yields no tools.
+func LoadCustomTools(dir string) ([]*CustomToolSpec, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read tools directory: %w", err)
+	}
+
+	var specs []*CustomToolSpec
+	seen := make(map[string]string)
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tool %s: %w", e.Name(), err)
+		}
+		spec := &CustomToolSpec{Source: path}
+		if err := yaml.Unmarshal(data, spec); err != nil {
+			return nil, fmt.Errorf("failed to parse tool %s: %w", e.Name(), err)
+		}
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		if prev, dup := seen[spec.Name]; dup {
+			return nil, fmt.Errorf("tool '%s' is defined in both %s and %s", spec.Name, filepath.Base(prev), e.Name())
+		}
+		seen[spec.Name] = path
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs, nil
+}
+
+// NewCustomTool creates a tool running spec's command in root.
+func NewCustomTool(spec *CustomToolSpec, root string) *Tool {
+	schema := spec.Schema
+	if schema == nil {
+		schema = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	tool := NewWithContext(spec.Name, spec.Description, schema, func(ctx context.Context, args Args) (string, error) {
+		return runCustomTool(ctx, spec, root, args)
+	})
+	tool.Limits = Limits{Timeout: spec.Timeout, MaxOutput: spec.MaxOutput}
+	return tool
+}
+
+// RegisterCustomTools loads the tools in dir and adds them to reg. Names
+// already registered (such as built-in tools) are rejected.
+func RegisterCustomTools(reg *Registry, dir, root string) ([]*CustomToolSpec, error) {
+	specs, err := LoadCustomTools(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range specs {
+		if _, err := reg.Get(spec.Name); err == nil {
+			return nil, fmt.Errorf("%s: tool '%s' conflicts with a built-in tool", filepath.Base(spec.Source), spec.Name)
+		}
+		reg.Register(NewCustomTool(spec, root))
+	}
+	return specs, nil
+}
+
+// ReloadCustomTools loads the tools in dir and swaps them into reg for
+// current, the tools previously registered from it, as a single change.
+// On error reg is left unchanged.
+func ReloadCustomTools(reg *Registry, dir, root string, current []*CustomToolSpec) ([]*CustomToolSpec, error) {
+	specs, err := LoadCustomTools(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	remove := make([]string, len(current))
+	own := make(map[string]bool, len(current))
+	for i, spec := range current {
+		remove[i] = spec.Name
+		own[spec.Name] = true
+	}
+	add := make([]*Tool, len(specs))
+	for i, spec := range specs {
+		if _, err := reg.Get(spec.Name); err == nil && !own[spec.Name] {
+			return nil, fmt.Errorf("%s: tool '%s' conflicts with a built-in tool", filepath.Base(spec.Source), spec.Name)
+		}
+		add[i] = NewCustomTool(spec, root)
+	}
+	reg.Replace(remove, add)
+	return specs, nil
+}
+
+// WatchCustomTools polls dir every interval and, when its tool files
+// change, reloads them into reg in place of current (see
+// ReloadCustomTools). fn is called with the new tools, or with the error
+// of a set that failed to load and was not adopted. fn runs on the
+// watcher's goroutine; stop ends the watch.
+func WatchCustomTools(reg *Registry, dir, root string, current []*CustomToolSpec, interval time.Duration, fn func(specs []*CustomToolSpec, err error)) (stop func()) {
+	last := customToolFiles(dir)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			files := customToolFiles(dir)
+			if bytes.Equal(files, last) {
+				continue
+			}
+			last = files
+			specs, err := ReloadCustomTools(reg, dir, root, current)
+			if err != nil {
+				fn(nil, err)
+				continue
+			}
+			current = specs
+			fn(specs, nil)
+		}
+	}()
+	return func() { close(done) }
+}
+
+// customToolFiles returns the names and content of the tool files in dir,
+// to tell when they change.
+func customToolFiles(dir string) []byte {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, e.Name()))
+		fmt.Fprintf(&buf, "%s\x00%d\x00", e.Name(), len(data))
+		buf.Write(data)
+	}
+	return buf.Bytes()
+}
+
+// runCustomTool renders and runs the command in its confined directory;
+// ctx carries the tool's timeout.
+func runCustomTool(ctx context.Context, spec *CustomToolSpec, root string, args Args) (string, error) {
+	command, err := renderCommand(spec, args)
+	if err != nil {
+		return "", err
+	}
+	dir, err := Confine(root, spec.Dir)
+	if err != nil {
+		return "", fmt.Errorf("tool '%s': %w", spec.Name, err)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	// Don't wait on background children still holding the output pipe
+	// once the shell has been killed.
+	cmd.WaitDelay = time.Second
+	cmd.Env = os.Environ()
+	for k, v := range spec.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	for k, v := range args {
+		cmd.Env = append(cmd.Env, "FLO_ARG_"+envName(k)+"="+argString(v))
+	}
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	start := time.Now()
+	runErr := cmd.Run()
+	output := out.String()
+
+	audit.Info("tools.custom", "Custom tool executed", map[string]interface{}{
+		"tool":     spec.Name,
+		"success":  runErr == nil,
+		"duration": time.Since(start).String(),
+	})
+
+	if runErr != nil {
+		return "", fmt.Errorf("tool '%s' failed: %v\n%s", spec.Name, runErr, output)
+	}
+	return output, nil
+}
+
+// renderCommand expands the command template with shell-quoted arguments.
+// Schema properties the caller omitted expand to an empty word.
+func renderCommand(spec *CustomToolSpec, args Args) (string, error) {
+	tmpl, err := spec.template()
+	if err != nil {
+		return "", err
+	}
+
+	data := make(map[string]string)
+	if props, ok := spec.Schema["properties"].(map[string]any); ok {
+		for name := range props {
+			data[name] = shellQuote("")
+		}
+	}
+	for k, v := range args {
+		data[k] = shellQuote(argString(v))
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render command for tool '%s': %w", spec.Name, err)
+	}
+	return buf.String(), nil
+}
+
+// argString converts an argument to text: strings as-is, other values as JSON.
+func argString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		data, _ := json.Marshal(val)
+		return string(data)
+	}
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// envName converts an argument name to an environment variable suffix.
+func envName(name string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name))
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTool(t *testing.T, dir, file, content string) {
+	t.Helper()
+	os.MkdirAll(dir, 0755)
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadCustomTools(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, ".flo", "tools")
+	writeTool(t, dir, "greet.yaml", `name: greet
+description: Say hello
+schema:
+  type: object
+  properties:
+    who: {type: string}
+    loud: {type: boolean}
+  required: [who]
+command: echo hello {{.who}} $FLO_ARG_LOUD
+`)
+	writeTool(t, dir, "pwd.yml", `name: where
+description: Print the working directory
+command: pwd
+dir: sub
+`)
+	writeTool(t, dir, "README.md", "not a tool")
+	os.MkdirAll(filepath.Join(root, "sub"), 0755)
+
+	reg := NewEASTools(setupTestRegistry(), nil)
+	specs, err := RegisterCustomTools(reg, dir, root)
+	if err != nil {
+		t.Fatalf("RegisterCustomTools failed: %v", err)
+	}
+	if len(specs) != 2 || specs[0].Name != "greet" || specs[1].Name != "where" {
+		t.Fatalf("unexpected specs: %+v", specs)
+	}
+
+	out, err := reg.Execute("greet", Args{"who": "it's me; rm -rf /", "loud": true})
+	if err != nil {
+		t.Fatalf("greet failed: %v", err)
+	}
+	if strings.TrimSpace(out) != "hello it's me; rm -rf / true" {
+		t.Errorf("expected quoted argument and env var, got %q", out)
+	}
+
+	if _, err := reg.Execute("greet", Args{}); err == nil {
+		t.Error("expected schema validation to reject missing argument")
+	}
+
+	out, err = reg.Execute("where", Args{})
+	if err != nil || !strings.HasSuffix(strings.TrimSpace(out), "sub") {
+		t.Errorf("expected command to run in dir, got %q %v", out, err)
+	}
+}
+
+func TestCustomToolFailureAndTimeout(t *testing.T) {
+	root := t.TempDir()
+	reg := NewRegistry()
+	reg.Register(NewCustomTool(&CustomToolSpec{Name: "fail", Description: "x", Command: "echo broken; exit 3"}, root))
+	reg.Register(NewCustomTool(&CustomToolSpec{Name: "slow", Description: "x", Command: "sleep 5", Timeout: 50 * time.Millisecond}, root))
+
+	_, err := reg.Execute("fail", Args{})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected failure with output, got %v", err)
+	}
+	_, err = reg.Execute("slow", Args{})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
+
+func TestLoadCustomToolsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"bad name":     "name: 'bad name'\ndescription: x\ncommand: true\n",
+		"no command":   "name: ok\ndescription: x\n",
+		"bad template": "name: ok\ndescription: x\ncommand: echo {{.x\n",
+		"escape dir":   "name: ok\ndescription: x\ncommand: true\ndir: ../elsewhere\n",
+	}
+	for name, content := range cases {
+		dir := t.TempDir()
+		writeTool(t, dir, "tool.yaml", content)
+		if _, err := LoadCustomTools(dir); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	dir := t.TempDir()
+	writeTool(t, dir, "a.yaml", "name: dup\ndescription: x\ncommand: true\n")
+	writeTool(t, dir, "b.yaml", "name: dup\ndescription: y\ncommand: true\n")
+	if _, err := LoadCustomTools(dir); err == nil {
+		t.Error("expected duplicate names to be rejected")
+	}
+
+	if specs, err := LoadCustomTools(filepath.Join(dir, "missing")); err != nil || specs != nil {
+		t.Errorf("expected missing directory to yield no tools, got %v %v", specs, err)
+	}
+
+	reg := NewEASTools(setupTestRegistry(), nil)
+	dir = t.TempDir()
+	writeTool(t, dir, "x.yaml", "name: eas_task_list\ndescription: x\ncommand: true\n")
+	if _, err := RegisterCustomTools(reg, dir, dir); err == nil {
+		t.Error("expected conflict with built-in tool")
+	}
+}
+
+func TestReloadCustomTools(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, ".flo", "tools")
+	writeTool(t, dir, "greet.yaml", "name: greet\ndescription: Say hello\ncommand: echo hello\n")
+
+	reg := NewEASTools(setupTestRegistry(), nil)
+	specs, err := RegisterCustomTools(reg, dir, root)
+	if err != nil {
+		t.Fatalf("RegisterCustomTools failed: %v", err)
+	}
+	changes := 0
+	defer reg.Watch(func() { changes++ })()
+
+	os.Remove(filepath.Join(dir, "greet.yaml"))
+	writeTool(t, dir, "wave.yaml", "name: wave\ndescription: Wave\ncommand: echo wave\n")
+	specs, err = ReloadCustomTools(reg, dir, root, specs)
+	if err != nil {
+		t.Fatalf("ReloadCustomTools failed: %v", err)
+	}
+	if len(specs) != 1 || specs[0].Name != "wave" || changes != 1 {
+		t.Errorf("expected one change to [wave], got %+v after %d changes", specs, changes)
+	}
+	if _, err := reg.Get("greet"); err == nil {
+		t.Error("expected greet to be removed")
+	}
+
+	// A clash with a built-in tool leaves the registry as it was.
+	writeTool(t, dir, "clash.yaml", "name: eas_task_list\ndescription: Clash\ncommand: echo\n")
+	if _, err := ReloadCustomTools(reg, dir, root, specs); err == nil {
+		t.Error("expected conflict with built-in tool")
+	}
+	if _, err := reg.Get("wave"); err != nil || changes != 1 {
+		t.Errorf("expected registry unchanged after failed reload: %v, %d changes", err, changes)
+	}
+}
@@ -1,8 +1,10 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/richgo/flo/pkg/task"
 )
@@ -12,6 +14,26 @@ type TestRunner interface {
 	Run(taskID string) (pass bool, output string, err error)
 }
 
+// ContextTestRunner is a TestRunner that stops when ctx is cancelled. The
+// test tools use it when available so a cancelled or timed-out call doesn't
+// leave the suite running.
+type ContextTestRunner interface {
+	TestRunner
+	RunContext(ctx context.Context, taskID string) (pass bool, output string, err error)
+}
+
+// runTests runs the tests for a task, with ctx if the runner supports it.
+func runTests(ctx context.Context, testRunner TestRunner, taskID string) (bool, string, error) {
+	if r, ok := testRunner.(ContextTestRunner); ok {
+		return r.RunContext(ctx, taskID)
+	}
+	return testRunner.Run(taskID)
+}
+
+// TestSuiteTimeout is the default timeout of the tools that run the test
+// suite, which routinely outlast the registry default.
+const TestSuiteTimeout = 15 * time.Minute
+
 // EASToolsConfig holds the configuration for EAS tools.
 type EASToolsConfig struct {
 	SpecPath string // Path to SPEC.md
@@ -63,9 +85,9 @@ func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
 	))
 
 	// eas_task_claim
-	reg.Register(New(
+	reg.Register(NewWithContext(
 		"eas_task_claim",
-		"Claim a task (sets status to in_progress). Task must be pending with all deps complete.",
+		"Claim a task (sets status to in_progress) and focus on it. Task must be pending with all deps complete.",
 		map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -76,13 +98,35 @@ func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
 			},
 			"required": []any{"task_id"},
 		},
-		func(args Args) (string, error) {
-			return handleTaskClaim(taskReg, args)
+		func(ctx context.Context, args Args) (string, error) {
+			out, err := handleTaskClaim(taskReg, args)
+			if session := SessionFrom(ctx); err == nil && session != nil {
+				session.SetFocus(args["task_id"].(string))
+			}
+			return out, err
+		},
+	))
+
+	// eas_task_focus
+	reg.Register(NewWithContext(
+		"eas_task_focus",
+		"Get or set the task this session is working on. Without task_id, returns the focused task; claiming a task also focuses it.",
+		map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"task_id": map[string]any{
+					"type":        "string",
+					"description": "Task ID to focus on",
+				},
+			},
+		},
+		func(ctx context.Context, args Args) (string, error) {
+			return handleTaskFocus(ctx, taskReg, args)
 		},
 	))
 
 	// eas_task_complete
-	reg.Register(New(
+	reg.Register(NewWithContext(
 		"eas_task_complete",
 		"Mark task as complete. Runs tests first - will fail if tests don't pass.",
 		map[string]any{
@@ -95,13 +139,13 @@ func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
 			},
 			"required": []any{"task_id"},
 		},
-		func(args Args) (string, error) {
-			return handleTaskComplete(taskReg, testRunner, args)
+		func(ctx context.Context, args Args) (string, error) {
+			return handleTaskComplete(ctx, taskReg, testRunner, args)
 		},
-	))
+	).withLimits(Limits{Timeout: TestSuiteTimeout}))
 
 	// eas_run_tests
-	reg.Register(New(
+	reg.Register(NewWithContext(
 		"eas_run_tests",
 		"Run tests for a task. Returns test output and pass/fail status.",
 		map[string]any{
@@ -114,10 +158,10 @@ func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
 			},
 			"required": []any{"task_id"},
 		},
-		func(args Args) (string, error) {
-			return handleRunTests(testRunner, args)
+		func(ctx context.Context, args Args) (string, error) {
+			return handleRunTests(ctx, testRunner, args)
 		},
-	))
+	).withLimits(Limits{Timeout: TestSuiteTimeout}))
 
 	return reg
 }
@@ -177,6 +221,26 @@ func handleTaskGet(taskReg *task.Registry, args Args) (string, error) {
 	return string(data), nil
 }
 
+func handleTaskFocus(ctx context.Context, taskReg *task.Registry, args Args) (string, error) {
+	session := SessionFrom(ctx)
+	if session == nil {
+		return "", fmt.Errorf("task focus requires a client session")
+	}
+
+	if taskID, ok := args["task_id"].(string); ok && taskID != "" {
+		if _, err := taskReg.Get(taskID); err != nil {
+			return "", err
+		}
+		session.SetFocus(taskID)
+	}
+
+	focus := session.Focus()
+	if focus == "" {
+		return "No task focused", nil
+	}
+	return handleTaskGet(taskReg, Args{"task_id": focus})
+}
+
 func handleTaskClaim(taskReg *task.Registry, args Args) (string, error) {
 	taskID, ok := args["task_id"].(string)
 	if !ok {
@@ -201,8 +265,13 @@ func handleTaskClaim(taskReg *task.Registry, args Args) (string, error) {
 		}
 	}
 
+	// Another task with its mutex may be running
+	if err := taskReg.CheckMutex(t); err != nil {
+		return "", err
+	}
+
 	// Claim the task
-	if err := t.SetStatus(task.StatusInProgress); err != nil {
+	if err := taskReg.SetStatus(t, task.StatusInProgress); err != nil {
 		return "", err
 	}
 	if err := taskReg.Update(t); err != nil {
@@ -212,7 +281,7 @@ func handleTaskClaim(taskReg *task.Registry, args Args) (string, error) {
 	return fmt.Sprintf("Task '%s' claimed successfully", taskID), nil
 }
 
-func handleTaskComplete(taskReg *task.Registry, testRunner TestRunner, args Args) (string, error) {
+func handleTaskComplete(ctx context.Context, taskReg *task.Registry, testRunner TestRunner, args Args) (string, error) {
 	taskID, ok := args["task_id"].(string)
 	if !ok {
 		return "", fmt.Errorf("task_id is required")
@@ -230,7 +299,7 @@ func handleTaskComplete(taskReg *task.Registry, testRunner TestRunner, args Args
 
 	// Run tests if test runner is configured
 	if testRunner != nil {
-		pass, output, err := testRunner.Run(taskID)
+		pass, output, err := runTests(ctx, testRunner, taskID)
 		if err != nil {
 			return "", fmt.Errorf("failed to run tests: %w", err)
 		}
@@ -240,7 +309,7 @@ func handleTaskComplete(taskReg *task.Registry, testRunner TestRunner, args Args
 	}
 
 	// Complete the task
-	if err := t.SetStatus(task.StatusComplete); err != nil {
+	if err := taskReg.SetStatus(t, task.StatusComplete); err != nil {
 		return "", err
 	}
 	if err := taskReg.Update(t); err != nil {
@@ -250,7 +319,7 @@ func handleTaskComplete(taskReg *task.Registry, testRunner TestRunner, args Args
 	return fmt.Sprintf("Task '%s' completed successfully", taskID), nil
 }
 
-func handleRunTests(testRunner TestRunner, args Args) (string, error) {
+func handleRunTests(ctx context.Context, testRunner TestRunner, args Args) (string, error) {
 	taskID, ok := args["task_id"].(string)
 	if !ok {
 		return "", fmt.Errorf("task_id is required")
@@ -260,7 +329,7 @@ func handleRunTests(testRunner TestRunner, args Args) (string, error) {
 		return "No test runner configured", nil
 	}
 
-	pass, output, err := testRunner.Run(taskID)
+	pass, output, err := runTests(ctx, testRunner, taskID)
 	if err != nil {
 		return "", fmt.Errorf("failed to run tests: %w", err)
 	}
@@ -1,7 +1,9 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
@@ -170,6 +172,22 @@ func TestEASTaskClaimDepsIncomplete(t *testing.T) {
 	}
 }
 
+func TestEASTaskClaimMutexHeld(t *testing.T) {
+	taskReg := setupTestRegistry()
+	task1, _ := taskReg.Get("ua-001")
+	task3, _ := taskReg.Get("ua-003")
+	task1.Mutex, task3.Mutex = "oauth-app", "oauth-app"
+	tools := NewEASTools(taskReg, nil)
+	tool, _ := tools.Get("eas_task_claim")
+
+	if _, err := tool.Execute(Args{"task_id": "ua-001"}); err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	if _, err := tool.Execute(Args{"task_id": "ua-003"}); !errors.Is(err, task.ErrMutexHeld) {
+		t.Errorf("expected ua-003 refused while ua-001 holds its mutex, got %v", err)
+	}
+}
+
 func TestEASTaskComplete(t *testing.T) {
 	taskReg := setupTestRegistry()
 
@@ -252,3 +270,74 @@ type MockTestRunner struct {
 func (m *MockTestRunner) Run(taskID string) (bool, string, error) {
 	return m.pass, m.output, nil
 }
+
+// contextTestRunner records the context it ran with.
+type contextTestRunner struct {
+	MockTestRunner
+	ctx context.Context
+}
+
+func (r *contextTestRunner) RunContext(ctx context.Context, taskID string) (bool, string, error) {
+	r.ctx = ctx
+	return r.pass, r.output, nil
+}
+
+func TestEASRunTestsUsesContext(t *testing.T) {
+	runner := &contextTestRunner{MockTestRunner: MockTestRunner{pass: true, output: "PASS"}}
+	reg := NewEASTools(setupTestRegistry(), runner)
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "call")
+	if _, err := reg.ExecuteContext(ctx, "eas_run_tests", Args{"task_id": "ua-001"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runner.ctx == nil || runner.ctx.Value(key{}) != "call" {
+		t.Error("expected the test runner to get the call's context")
+	}
+}
+
+type testSession struct {
+	id    string
+	focus string
+}
+
+func (s *testSession) ID() string             { return s.id }
+func (s *testSession) Focus() string          { return s.focus }
+func (s *testSession) SetFocus(taskID string) { s.focus = taskID }
+
+func TestEASTaskFocus(t *testing.T) {
+	taskReg := setupTestRegistry()
+	tools := NewEASTools(taskReg, nil)
+	focus, _ := tools.Get("eas_task_focus")
+
+	if _, err := focus.Execute(Args{}); err == nil {
+		t.Error("expected error without a session")
+	}
+
+	a, b := &testSession{id: "a"}, &testSession{id: "b"}
+	ctxA, ctxB := WithSession(context.Background(), a), WithSession(context.Background(), b)
+
+	if _, err := tools.ExecuteContext(ctxA, "eas_task_claim", Args{"task_id": "ua-001"}); err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	if a.focus != "ua-001" || b.focus != "" {
+		t.Errorf("expected only session a to focus ua-001, got a=%q b=%q", a.focus, b.focus)
+	}
+
+	output, err := tools.ExecuteContext(ctxA, "eas_task_focus", Args{})
+	if err != nil || !strings.Contains(output, "ua-001") {
+		t.Errorf("expected focused task, got %q, %v", output, err)
+	}
+	output, _ = tools.ExecuteContext(ctxB, "eas_task_focus", Args{})
+	if output != "No task focused" {
+		t.Errorf("expected no focus for session b, got %q", output)
+	}
+
+	if _, err := tools.ExecuteContext(ctxB, "eas_task_focus", Args{"task_id": "missing"}); err == nil {
+		t.Error("expected error focusing an unknown task")
+	}
+	tools.ExecuteContext(ctxB, "eas_task_focus", Args{"task_id": "ua-003"})
+	if b.focus != "ua-003" || a.focus != "ua-001" {
+		t.Errorf("unexpected focus a=%q b=%q", a.focus, b.focus)
+	}
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"path"
+	"sort"
+)
+
+// Exposure selects which tools of a registry are served to clients and
+// the names they are served under, so a workspace can restrict what its
+// agents can call. A nil Exposure serves every tool under its own name.
+type Exposure struct {
+	// Enable, when not empty, serves only the tools it matches.
+	Enable []string
+	// Disable hides the tools it matches, even enabled ones.
+	Disable []string
+	// Rename serves tools under other names, keyed by their own name.
+	Rename map[string]string
+	// Namespace prefixes every served name as <namespace>.<name>.
+	Namespace string
+}
+
+// Serves reports whether the named tool is served. Enable and Disable
+// take names or path.Match globs such as github_*.
+func (e *Exposure) Serves(name string) bool {
+	if e == nil {
+		return true
+	}
+	if len(e.Enable) > 0 && !matchAny(e.Enable, name) {
+		return false
+	}
+	return !matchAny(e.Disable, name)
+}
+
+// Name returns the name the named tool is served under.
+func (e *Exposure) Name(name string) string {
+	if e == nil {
+		return name
+	}
+	if to, ok := e.Rename[name]; ok {
+		name = to
+	}
+	if e.Namespace != "" {
+		name = e.Namespace + "." + name
+	}
+	return name
+}
+
+// Expose returns the served tools of list as copies carrying their served
+// names, sorted by them.
+func (e *Exposure) Expose(list []*Tool) []*Tool {
+	exposed := make([]*Tool, 0, len(list))
+	for _, tool := range list {
+		if !e.Serves(tool.Name) {
+			continue
+		}
+		served := *tool
+		served.Name = e.Name(tool.Name)
+		exposed = append(exposed, &served)
+	}
+	sort.Slice(exposed, func(i, j int) bool { return exposed[i].Name < exposed[j].Name })
+	return exposed
+}
+
+// Lookup returns the own name of the tool of list served as name, or false
+// when no tool is served under it.
+func (e *Exposure) Lookup(list []*Tool, name string) (string, bool) {
+	for _, tool := range list {
+		if e.Serves(tool.Name) && e.Name(tool.Name) == name {
+			return tool.Name, true
+		}
+	}
+	return "", false
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package tools
+
+import "testing"
+
+func TestExposure(t *testing.T) {
+	list := []*Tool{
+		New("eas_task_list", "List tasks", nil, nil),
+		New("eas_task_create", "Create a task", nil, nil),
+		New("github_search", "Search GitHub", nil, nil),
+		New("github_delete_repo", "Delete a repo", nil, nil),
+	}
+
+	var none *Exposure
+	if got := none.Expose(list); len(got) != 4 || got[0].Name != "eas_task_create" {
+		t.Errorf("expected a nil exposure to serve every tool, got %d", len(got))
+	}
+
+	e := &Exposure{
+		Enable:    []string{"eas_*", "github_*"},
+		Disable:   []string{"github_delete_*"},
+		Rename:    map[string]string{"eas_task_create": "task_create"},
+		Namespace: "flo",
+	}
+	var names []string
+	for _, tool := range e.Expose(list) {
+		names = append(names, tool.Name)
+	}
+	want := []string{"flo.eas_task_list", "flo.github_search", "flo.task_create"}
+	if len(names) != len(want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, names)
+		}
+	}
+	if list[1].Name != "eas_task_create" {
+		t.Error("expected Expose to leave the registered tools unchanged")
+	}
+
+	if own, ok := e.Lookup(list, "flo.task_create"); !ok || own != "eas_task_create" {
+		t.Errorf("expected flo.task_create to be eas_task_create, got %q", own)
+	}
+	for _, name := range []string{"eas_task_create", "flo.github_delete_repo", "github_search"} {
+		if _, ok := e.Lookup(list, name); ok {
+			t.Errorf("expected %s not to be served", name)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/policy"
+)
+
+// NewFileTools creates the read_file, write_file and list_dir tools, for
+// backends without file tools of their own. Paths are relative to root and
+// may not leave it, even through symlinks, nor enter .git; files pol
+// denies can't be read or written.
+func NewFileTools(root RootFunc, pol *policy.Policy) []*Tool {
+	pathProp := func(description string) map[string]any {
+		return map[string]any{"type": "string", "description": description}
+	}
+	return []*Tool{
+		NewWithContext(
+			"read_file",
+			"Read a file in the task worktree. Returns its content.",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path": pathProp("File path, relative to the worktree"),
+				},
+				"required": []string{"path"},
+			},
+			func(ctx context.Context, args Args) (string, error) {
+				dir, file, err := resolvePath(ctx, root, pol, args["path"].(string))
+				if err != nil {
+					return "", err
+				}
+				data, err := readInRoot(dir, file)
+				if err != nil {
+					return "", fmt.Errorf("failed to read %s: %w", args["path"], err)
+				}
+				return string(data), nil
+			},
+		),
+		NewWithContext(
+			"write_file",
+			"Write a file in the task worktree, creating it and its directories if needed and replacing its content otherwise.",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path":    pathProp("File path, relative to the worktree"),
+					"content": map[string]any{"type": "string", "description": "The file's new content"},
+				},
+				"required": []string{"path", "content"},
+			},
+			func(ctx context.Context, args Args) (string, error) {
+				dir, rel, err := resolvePath(ctx, root, pol, args["path"].(string))
+				if err != nil {
+					return "", err
+				}
+				content := args["content"].(string)
+				if err := writeInRoot(dir, rel, []byte(content)); err != nil {
+					return "", fmt.Errorf("failed to write %s: %w", rel, err)
+				}
+				audit.Info("tools.write_file", "File written", map[string]interface{}{
+					"path":  rel,
+					"bytes": len(content),
+				})
+				return fmt.Sprintf("Wrote %d bytes to %s", len(content), rel), nil
+			},
+		),
+		NewWithContext(
+			"list_dir",
+			"List a directory in the task worktree, one entry per line, directories ending in /.",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path": pathProp("Directory path, relative to the worktree (default the worktree)"),
+				},
+			},
+			func(ctx context.Context, args Args) (string, error) {
+				path, _ := args["path"].(string)
+				if path == "" {
+					path = "."
+				}
+				base, rel, err := resolvePath(ctx, root, nil, path)
+				if err != nil {
+					return "", err
+				}
+				entries, err := readDirInRoot(base, rel)
+				if err != nil {
+					return "", fmt.Errorf("failed to list %s: %w", rel, err)
+				}
+				names := make([]string, 0, len(entries))
+				for _, e := range entries {
+					if e.Name() == ".git" {
+						continue
+					}
+					name := e.Name()
+					if e.IsDir() {
+						name += "/"
+					}
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				if len(names) == 0 {
+					return fmt.Sprintf("%s is empty", rel), nil
+				}
+				return strings.Join(names, "\n"), nil
+			},
+		),
+	}
+}
+
+// resolvePath confines path to the call's root, returning the root with
+// its symlinks resolved and the path relative to it. pol, when set, checks
+// the relative path.
+//
+// The check is made before the file is opened, so a symlink could still
+// be swapped in between; the tools therefore open files through an
+// os.Root, which refuses any symlink leading outside the root.
+func resolvePath(ctx context.Context, root RootFunc, pol *policy.Policy, path string) (base, rel string, err error) {
+	dir, err := root(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	resolved, err := Confine(dir, path)
+	if err != nil {
+		return "", "", err
+	}
+	// Confine resolves symlinks in the root too
+	base, err = Confine(dir, ".")
+	if err != nil {
+		return "", "", err
+	}
+	rel, err = filepath.Rel(base, resolved)
+	if err != nil {
+		return "", "", err
+	}
+	if first, _, _ := strings.Cut(filepath.ToSlash(rel), "/"); first == ".git" {
+		return "", "", fmt.Errorf("path '%s' is inside .git", path)
+	}
+	if err := pol.CheckPath(rel).Err(); err != nil {
+		return "", "", fmt.Errorf("path '%s': %w", path, err)
+	}
+	return base, rel, nil
+}
+
+// readInRoot reads the file at rel within dir.
+func readInRoot(dir, rel string) ([]byte, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	f, err := root.Open(rel)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// writeInRoot writes the file at rel within dir, creating its directories.
+func writeInRoot(dir, rel string, data []byte) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	parent := "."
+	for _, name := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if name == "." {
+			continue
+		}
+		parent = filepath.Join(parent, name)
+		if err := root.Mkdir(parent, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+	f, err := root.OpenFile(rel, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readDirInRoot lists the directory at rel within dir.
+func readDirInRoot(dir, rel string) ([]fs.DirEntry, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	f, err := root.Open(rel)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.ReadDir(-1)
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/policy"
+)
+
+func TestFileTools(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	os.Symlink(outside, filepath.Join(root, "escape"))
+	os.Symlink(filepath.Join(outside, "planted.txt"), filepath.Join(root, "dangling"))
+	os.Symlink(filepath.Join(outside, "newdir"), filepath.Join(root, "dangling-dir"))
+
+	pol, _ := policy.New(policy.Config{DenyPaths: []string{"*.pem"}})
+	reg := NewRegistry()
+	for _, tool := range NewFileTools(func(ctx context.Context) (string, error) { return root, nil }, pol) {
+		reg.Register(tool)
+	}
+
+	out, err := reg.Execute("write_file", Args{"path": "pkg/a.go", "content": "package pkg\n"})
+	if err != nil {
+		t.Fatalf("write_file failed: %v", err)
+	}
+	if out != "Wrote 12 bytes to pkg/a.go" {
+		t.Errorf("unexpected write_file output %q", out)
+	}
+	out, err = reg.Execute("read_file", Args{"path": "pkg/a.go"})
+	if err != nil || out != "package pkg\n" {
+		t.Errorf("expected to read the file back, got %q, %v", out, err)
+	}
+	out, err = reg.Execute("list_dir", Args{})
+	if err != nil || out != "dangling\ndangling-dir\nescape\npkg/" {
+		t.Errorf("expected the worktree listed without .git, got %q, %v", out, err)
+	}
+
+	for _, call := range []struct {
+		tool string
+		args Args
+	}{
+		{"read_file", Args{"path": "../secret.txt"}},
+		{"read_file", Args{"path": "escape/secret.txt"}},
+		{"write_file", Args{"path": "escape/new.txt", "content": "x"}},
+		{"write_file", Args{"path": "dangling", "content": "x"}},
+		{"write_file", Args{"path": "dangling-dir/new.txt", "content": "x"}},
+		{"write_file", Args{"path": ".git/hooks/pre-commit", "content": "x"}},
+		{"write_file", Args{"path": "certs/key.pem", "content": "x"}},
+		{"list_dir", Args{"path": "escape"}},
+	} {
+		if _, err := reg.Execute(call.tool, call.args); err == nil {
+			t.Errorf("expected %s %v to be refused", call.tool, call.args)
+		}
+	}
+	for _, name := range []string{"new.txt", "planted.txt", "newdir"} {
+		if _, err := os.Stat(filepath.Join(outside, name)); err == nil {
+			t.Errorf("expected nothing written outside the worktree, found %s", name)
+		}
+	}
+	if _, err := reg.Execute("read_file", Args{"path": "missing.go"}); err == nil || !strings.Contains(err.Error(), "missing.go") {
+		t.Errorf("expected a missing file to fail, got %v", err)
+	}
+}
+
+func TestConfineDanglingSymlink(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.Symlink(filepath.Join(outside, "target"), filepath.Join(root, "link"))
+	os.Symlink("inside", filepath.Join(root, "relative"))
+
+	if _, err := Confine(root, "link"); err == nil {
+		t.Error("expected a dangling symlink out of the root to be refused")
+	}
+	got, err := Confine(root, "relative")
+	if err != nil {
+		t.Fatalf("expected a dangling symlink within the root to be allowed: %v", err)
+	}
+	if real, _ := filepath.EvalSymlinks(root); got != filepath.Join(real, "inside") {
+		t.Errorf("expected the link resolved to its target, got %s", got)
+	}
+}
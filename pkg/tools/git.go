@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/policy"
+	"github.com/richgo/flo/pkg/repos"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// DefaultCommitTypes are the conventional commit types git_commit accepts
+// unless the workspace configures others.
+var DefaultCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// TaskTrailer is the trailer naming the task a commit belongs to.
+const TaskTrailer = "Task"
+
+// maxCommitSubject is the longest subject line git_commit accepts.
+const maxCommitSubject = 72
+
+// commitSubject matches a conventional commit subject: a type, an optional
+// (scope), an optional ! for a breaking change, and a description.
+var commitSubject = regexp.MustCompile(`^([a-z]+)(\([^()\s]+\))?!?: \S`)
+
+// trailerLine matches a git trailer such as "Task: t-001".
+var trailerLine = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*: \S`)
+
+// NewGitTools creates the git_status, git_diff and git_commit tools, which
+// run in the worktree root returns. Changes under .flo are left out.
+//
+// git_commit commits every change for the session's focused task. Its
+// message must be a conventional commit of one of types, or of
+// DefaultCommitTypes when types is empty, and gets a "Task: <id>" trailer.
+// It refuses changes outside the task's scope and files pol denies.
+func NewGitTools(root RootFunc, taskReg *task.Registry, pol *policy.Policy, types []string) []*Tool {
+	if len(types) == 0 {
+		types = DefaultCommitTypes
+	}
+	return []*Tool{
+		NewWithContext(
+			"git_status",
+			"Show the branch of the task worktree and its changed, staged and untracked files.",
+			map[string]any{
+				"type":       "object",
+				"properties": map[string]any{},
+			},
+			func(ctx context.Context, args Args) (string, error) {
+				dir, err := root(ctx)
+				if err != nil {
+					return "", err
+				}
+				return git(ctx, dir, "status", "--short", "--branch", "--untracked-files=all", "--", ".", ":(exclude).flo")
+			},
+		),
+		NewWithContext(
+			"git_diff",
+			"Show the uncommitted changes in the task worktree as a patch, new files included.",
+			map[string]any{
+				"type":       "object",
+				"properties": map[string]any{},
+			},
+			func(ctx context.Context, args Args) (string, error) {
+				dir, err := root(ctx)
+				if err != nil {
+					return "", err
+				}
+				diff, err := repos.New().Diff(ctx, dir, ".flo")
+				if err != nil {
+					return "", err
+				}
+				if diff == "" {
+					return "No changes", nil
+				}
+				return diff, nil
+			},
+		),
+		NewWithContext(
+			"git_commit",
+			"Commit every change in the task worktree for the focused task. The message must be a conventional commit, "+
+				"'<type>[(scope)][!]: <description>' with type one of "+strings.Join(types, ", ")+
+				"; a 'Task: <id>' trailer is added. Changes outside the task's scope are refused.",
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"message": map[string]any{
+						"type":        "string",
+						"description": "Commit message, e.g. 'fix(auth): refresh expired tokens', optionally followed by a blank line and a body",
+					},
+				},
+				"required": []string{"message"},
+			},
+			func(ctx context.Context, args Args) (string, error) {
+				return gitCommit(ctx, root, taskReg, pol, types, args["message"].(string))
+			},
+		),
+	}
+}
+
+func gitCommit(ctx context.Context, root RootFunc, taskReg *task.Registry, pol *policy.Policy, types []string, message string) (string, error) {
+	session := SessionFrom(ctx)
+	if session == nil || session.Focus() == "" {
+		return "", fmt.Errorf("git_commit needs a focused task: claim or focus one first")
+	}
+	t, err := taskReg.Get(session.Focus())
+	if err != nil {
+		return "", err
+	}
+	message, err = CommitMessage(message, t.ID, types)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := root(ctx)
+	if err != nil {
+		return "", err
+	}
+	all, err := policy.Changes(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	var changes []policy.FileChange
+	var files []string
+	for _, c := range all {
+		if first, _, _ := strings.Cut(c.Path, "/"); first == ".flo" {
+			continue
+		}
+		changes = append(changes, c)
+		files = append(files, c.Path)
+	}
+	if len(changes) == 0 {
+		return "Nothing to commit", nil
+	}
+
+	scope, err := policy.NewScope(t.Scope)
+	if err != nil {
+		return "", fmt.Errorf("task %s: %w", t.ID, err)
+	}
+	// Changes needing approval can be committed; the task can't complete
+	// until they are approved
+	vs := append(scope.Check(changes), pol.CheckChanges(changes).Denied()...)
+	if err := vs.Err(); err != nil {
+		audit.Warn("tools.git_commit", "Commit refused", map[string]interface{}{
+			"task":       t.ID,
+			"violations": vs,
+		})
+		return "", fmt.Errorf("commit refused: %w", err)
+	}
+
+	// Literal pathspecs, so file names aren't read as globs
+	if _, err := git(ctx, dir, append([]string{"--literal-pathspecs", "add", "-A", "--"}, files...)...); err != nil {
+		return "", err
+	}
+	if _, err := git(ctx, dir, append([]string{"--literal-pathspecs", "commit", "-q", "-m", message, "--"}, files...)...); err != nil {
+		return "", err
+	}
+	commit, err := git(ctx, dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	commit = strings.TrimSpace(commit)
+	subject, _, _ := strings.Cut(message, "\n")
+	audit.Info("tools.git_commit", "Changes committed", map[string]interface{}{
+		"task":    t.ID,
+		"commit":  commit,
+		"subject": subject,
+		"files":   len(files),
+	})
+	return fmt.Sprintf("Committed %s: %s (%d files)", commit, subject, len(files)), nil
+}
+
+// CommitMessage checks that message is a conventional commit of one of
+// types, with a subject of at most 72 characters followed by a blank line
+// when there is a body, and returns it with a trailer naming taskID. A
+// message that already has the trailer must name taskID.
+func CommitMessage(message, taskID string, types []string) (string, error) {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	lines := strings.Split(message, "\n")
+	subject := lines[0]
+	m := commitSubject.FindStringSubmatch(subject)
+	if m == nil {
+		return "", fmt.Errorf("commit subject '%s' is not a conventional commit: expected '<type>[(scope)][!]: <description>', e.g. 'fix(auth): refresh expired tokens'", subject)
+	}
+	if !slices.Contains(types, m[1]) {
+		return "", fmt.Errorf("commit type '%s' must be one of %s", m[1], strings.Join(types, ", "))
+	}
+	if len(subject) > maxCommitSubject {
+		return "", fmt.Errorf("commit subject is %d characters, more than %d", len(subject), maxCommitSubject)
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		return "", fmt.Errorf("separate the commit subject from the body with a blank line")
+	}
+
+	paragraphs := strings.Split(message, "\n\n")
+	last := strings.Split(paragraphs[len(paragraphs)-1], "\n")
+	trailers := len(paragraphs) > 1
+	for _, line := range last {
+		trailers = trailers && trailerLine.MatchString(line)
+	}
+	if !trailers {
+		return message + "\n\n" + TaskTrailer + ": " + taskID + "\n", nil
+	}
+	for _, line := range last {
+		key, value, _ := strings.Cut(line, ":")
+		if !strings.EqualFold(key, TaskTrailer) {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != taskID {
+			return "", fmt.Errorf("commit names task %s, but the focused task is %s", value, taskID)
+		}
+		return message + "\n", nil
+	}
+	return message + "\n" + TaskTrailer + ": " + taskID + "\n", nil
+}
+
+// git runs git in dir and returns its output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		sub := args[0]
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") {
+				sub = arg
+				break
+			}
+		}
+		return "", fmt.Errorf("git %s failed: %w: %s", sub, err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/policy"
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestCommitMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+		errMsg  string
+	}{
+		{message: "feat(auth): add token refresh", want: "feat(auth): add token refresh\n\nTask: ua-001\n"},
+		{message: "fix!: drop legacy tokens\n\nThey expired long ago.", want: "fix!: drop legacy tokens\n\nThey expired long ago.\n\nTask: ua-001\n"},
+		{message: "fix: retry\n\nBody.\n\nRefs: #12", want: "fix: retry\n\nBody.\n\nRefs: #12\nTask: ua-001\n"},
+		{message: "docs: note scopes\n\nTask: ua-001", want: "docs: note scopes\n\nTask: ua-001\n"},
+		{message: "docs: note scopes\n\nTask: ua-002", errMsg: "names task ua-002"},
+		{message: "Add token refresh", errMsg: "not a conventional commit"},
+		{message: "feature: add token refresh", errMsg: "must be one of"},
+		{message: "feat: " + strings.Repeat("x", 70), errMsg: "more than 72"},
+		{message: "feat: add token refresh\nand storage", errMsg: "blank line"},
+	}
+	for _, tt := range tests {
+		got, err := CommitMessage(tt.message, "ua-001", DefaultCommitTypes)
+		if tt.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("CommitMessage(%q): expected error containing %q, got %v", tt.message, tt.errMsg, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("CommitMessage(%q) = %q, %v; want %q", tt.message, got, err, tt.want)
+		}
+	}
+}
+
+func TestGitTools(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("app\n"), 0644)
+	run("init", "-q")
+	run("config", "user.name", "test")
+	run("config", "user.email", "test@example.com")
+	run("add", ".")
+	run("commit", "-qm", "init")
+
+	taskReg := task.NewRegistry()
+	scoped := task.New("ua-001", "Implement OAuth")
+	scoped.Scope = []string{"pkg/auth/"}
+	taskReg.Add(scoped)
+	pol, _ := policy.New(policy.Config{DenyPaths: []string{"*.pem"}})
+	reg := NewRegistry()
+	for _, tool := range NewGitTools(func(ctx context.Context) (string, error) { return dir, nil }, taskReg, pol, nil) {
+		reg.Register(tool)
+	}
+	ctx := WithSession(context.Background(), &testSession{id: "a"})
+
+	commit := Args{"message": "feat(auth): add token refresh"}
+	if _, err := reg.ExecuteContext(ctx, "git_commit", commit); err == nil || !strings.Contains(err.Error(), "focused task") {
+		t.Errorf("expected a commit without a focused task to be refused, got %v", err)
+	}
+	SessionFrom(ctx).SetFocus("ua-001")
+
+	os.MkdirAll(filepath.Join(dir, "pkg", "auth"), 0755)
+	os.MkdirAll(filepath.Join(dir, ".flo"), 0755)
+	os.WriteFile(filepath.Join(dir, "pkg", "auth", "token.go"), []byte("package auth\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".flo", "tasks.json"), []byte("{}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("app with auth\n"), 0644)
+
+	out, err := reg.ExecuteContext(ctx, "git_status", Args{})
+	if err != nil || !strings.Contains(out, "pkg/auth/token.go") || strings.Contains(out, ".flo") {
+		t.Errorf("expected the status without .flo, got %q, %v", out, err)
+	}
+	out, err = reg.ExecuteContext(ctx, "git_diff", Args{})
+	if err != nil || !strings.Contains(out, "+app with auth") || !strings.Contains(out, "+package auth") {
+		t.Errorf("expected the diff to show both changes, got %q, %v", out, err)
+	}
+
+	if _, err := reg.ExecuteContext(ctx, "git_commit", commit); err == nil || !strings.Contains(err.Error(), "README.md") {
+		t.Errorf("expected the out-of-scope README change to be refused, got %v", err)
+	}
+	run("checkout", "-q", "--", "README.md")
+	if _, err := reg.ExecuteContext(ctx, "git_commit", Args{"message": "added token refresh"}); err == nil {
+		t.Error("expected a non-conventional message to be refused")
+	}
+
+	out, err = reg.ExecuteContext(ctx, "git_commit", commit)
+	if err != nil || !strings.Contains(out, "feat(auth): add token refresh (1 files)") {
+		t.Fatalf("expected the commit to succeed, got %q, %v", out, err)
+	}
+	if log := run("log", "-1", "--format=%B"); log != "feat(auth): add token refresh\n\nTask: ua-001\n\n" {
+		t.Errorf("unexpected commit message %q", log)
+	}
+	if status := run("status", "--porcelain"); status != "?? .flo/\n" {
+		t.Errorf("expected only .flo left uncommitted, got %q", status)
+	}
+
+	os.WriteFile(filepath.Join(dir, "pkg", "auth", "key.pem"), []byte("key\n"), 0644)
+	if _, err := reg.ExecuteContext(ctx, "git_commit", commit); err == nil || !strings.Contains(err.Error(), "denied path") {
+		t.Errorf("expected a denied file to be refused, got %v", err)
+	}
+	os.Remove(filepath.Join(dir, "pkg", "auth", "key.pem"))
+	if out, err := reg.ExecuteContext(ctx, "git_commit", commit); err != nil || out != "Nothing to commit" {
+		t.Errorf("expected nothing to commit, got %q, %v", out, err)
+	}
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Confine resolves path (relative to root unless absolute) and checks that
+// it stays inside root after following symlinks, so tools cannot be
+// pointed outside the workspace.
+func Confine(root, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace root: %w", err)
+	}
+	if realRoot, err := filepath.EvalSymlinks(absRoot); err == nil {
+		absRoot = realRoot
+	}
+
+	target := path
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(absRoot, target)
+	}
+	target = filepath.Clean(target)
+	if real, err := evalExisting(target); err == nil {
+		target = real
+	}
+
+	rel, err := filepath.Rel(absRoot, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path '%s' is outside the workspace", path)
+	}
+	return target, nil
+}
+
+// maxLinks bounds how many dangling symlinks evalExisting follows, so a
+// loop of them fails rather than recursing forever.
+const maxLinks = 40
+
+// evalExisting resolves symlinks in the longest existing prefix of path.
+// A dangling symlink is resolved to its target, so a file created through
+// it is checked where it would land.
+func evalExisting(path string) (string, error) {
+	return evalLinks(path, 0)
+}
+
+func evalLinks(path string, links int) (string, error) {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real, nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	real, err := evalLinks(parent, links)
+	if err != nil {
+		return "", err
+	}
+	path = filepath.Join(real, filepath.Base(path))
+
+	if info, err := os.Lstat(path); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return path, nil
+	}
+	if links >= maxLinks {
+		return "", fmt.Errorf("too many symlinks at '%s'", path)
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(real, target)
+	}
+	return evalLinks(filepath.Clean(target), links+1)
+}
@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfine(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "sub"), 0755)
+	outside := t.TempDir()
+	os.Symlink(outside, filepath.Join(root, "escape"))
+
+	realRoot, _ := filepath.EvalSymlinks(root)
+	for _, ok := range []string{"", ".", "sub", "sub/new/file", filepath.Join(root, "sub")} {
+		got, err := Confine(root, ok)
+		if err != nil {
+			t.Errorf("Confine(%q) failed: %v", ok, err)
+			continue
+		}
+		if !strings.HasPrefix(got, realRoot) {
+			t.Errorf("Confine(%q) = %q, want a path under %q", ok, got, realRoot)
+		}
+	}
+
+	for _, bad := range []string{"..", "../x", "sub/../../x", outside, "escape", "escape/file"} {
+		if _, err := Confine(root, bad); err == nil {
+			t.Errorf("expected Confine(%q) to be rejected", bad)
+		}
+	}
+}
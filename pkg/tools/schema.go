@@ -0,0 +1,276 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidArgs is matched by errors from arguments that don't satisfy the
+// tool's schema.
+var ErrInvalidArgs = errors.New("invalid tool arguments")
+
+// ArgProblem is one way the arguments break the schema. Path locates the
+// value, e.g. "files[2].path"; it is empty for the arguments object itself.
+type ArgProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (p ArgProblem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// ValidationError lists every problem found in a tool's arguments, so a
+// caller can fix them all at once. The handler is not run.
+type ValidationError struct {
+	Tool     string
+	Problems []ArgProblem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.String()
+	}
+	return fmt.Sprintf("argument validation failed: %s", strings.Join(msgs, "; "))
+}
+
+// Is reports whether target is ErrInvalidArgs.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidArgs
+}
+
+// validateArgs checks args against the tool's JSON Schema. It supports
+// the keywords tools use in practice: type, enum, const, properties,
+// required, additionalProperties, items, minItems/maxItems,
+// minLength/maxLength, pattern, minimum/maximum and their exclusive
+// forms. Other keywords are ignored. A null value is accepted for any
+// property so that clients may send optional fields as null.
+func (t *Tool) validateArgs(args Args) error {
+	var problems []ArgProblem
+	validateValue("", map[string]any(args), t.Schema, &problems)
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Tool: t.Name, Problems: problems}
+}
+
+func validateValue(path string, value any, schema map[string]any, problems *[]ArgProblem) {
+	add := func(format string, a ...any) {
+		*problems = append(*problems, ArgProblem{Path: path, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if value == nil && path != "" {
+		return
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, typ := range types {
+			if hasType(value, typ) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			add("must be %s", article(strings.Join(types, " or ")))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if jsonEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("must be one of %s", formatValues(enum))
+		}
+	}
+	if want, ok := schema["const"]; ok && !jsonEqual(value, want) {
+		add("must be %s", formatValues([]any{want}))
+	}
+
+	switch v := value.(type) {
+	case string:
+		n := len([]rune(v))
+		if min, ok := toFloat(schema["minLength"]); ok && float64(n) < min {
+			add("must be at least %v characters", min)
+		}
+		if max, ok := toFloat(schema["maxLength"]); ok && float64(n) > max {
+			add("must be at most %v characters", max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				add("must match pattern %s", pattern)
+			}
+		}
+	case map[string]any:
+		validateObject(path, v, schema, problems)
+	case []any:
+		if min, ok := toFloat(schema["minItems"]); ok && float64(len(v)) < min {
+			add("must have at least %v items", min)
+		}
+		if max, ok := toFloat(schema["maxItems"]); ok && float64(len(v)) > max {
+			add("must have at most %v items", max)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateValue(fmt.Sprintf("%s[%d]", path, i), item, items, problems)
+			}
+		}
+	default:
+		if n, ok := toFloat(value); ok {
+			if min, ok := toFloat(schema["minimum"]); ok && n < min {
+				add("must be at least %v", min)
+			}
+			if max, ok := toFloat(schema["maximum"]); ok && n > max {
+				add("must be at most %v", max)
+			}
+			if min, ok := toFloat(schema["exclusiveMinimum"]); ok && n <= min {
+				add("must be greater than %v", min)
+			}
+			if max, ok := toFloat(schema["exclusiveMaximum"]); ok && n >= max {
+				add("must be less than %v", max)
+			}
+		}
+	}
+}
+
+func validateObject(path string, obj map[string]any, schema map[string]any, problems *[]ArgProblem) {
+	for _, name := range schemaStrings(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			*problems = append(*problems, ArgProblem{Path: joinPath(path, name), Message: "is required"})
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propSchema, ok := properties[name].(map[string]any)
+		if !ok {
+			if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				*problems = append(*problems, ArgProblem{Path: joinPath(path, name), Message: "is not a known argument"})
+			}
+			continue
+		}
+		validateValue(joinPath(path, name), obj[name], propSchema, problems)
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// schemaTypes returns the type keyword as a list ("string" or
+// ["string", "null"]).
+func schemaTypes(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	default:
+		return schemaStrings(v)
+	}
+}
+
+// schemaStrings reads a list of strings, as decoded from JSON ([]any) or
+// written in Go ([]string).
+func schemaStrings(v any) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []any:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func hasType(value any, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		n, ok := toFloat(value)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true // unknown types are not checked
+}
+
+// jsonEqual compares values as JSON would, so 1 and 1.0 are equal.
+func jsonEqual(a, b any) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func formatValues(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			parts[i] = fmt.Sprintf("%q", s)
+		} else {
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func article(typ string) string {
+	if typ != "" && strings.ContainsRune("aeiou", rune(typ[0])) {
+		return "an " + typ
+	}
+	return "a " + typ
+}
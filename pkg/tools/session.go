@@ -0,0 +1,25 @@
+package tools
+
+import "context"
+
+// Session is the per-client state a server keeps for tool calls, so that
+// clients sharing a registry don't see each other's focus.
+type Session interface {
+	ID() string
+	// Focus returns the ID of the task the client is working on, if any.
+	Focus() string
+	SetFocus(taskID string)
+}
+
+type sessionKey struct{}
+
+// WithSession returns ctx carrying the calling client's session.
+func WithSession(ctx context.Context, s Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// SessionFrom returns the session in ctx, or nil outside a session.
+func SessionFrom(ctx context.Context) Session {
+	s, _ := ctx.Value(sessionKey{}).(Session)
+	return s
+}
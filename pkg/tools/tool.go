@@ -2,9 +2,12 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // Args represents the arguments passed to a tool handler.
@@ -13,12 +16,49 @@ type Args map[string]any
 // Handler is the function signature for tool handlers.
 type Handler func(args Args) (string, error)
 
+// ContextHandler is a handler that stops its work when ctx is cancelled,
+// such as one running a subprocess.
+type ContextHandler func(ctx context.Context, args Args) (string, error)
+
+// Limits bound a tool execution. Zero values mean no limit.
+type Limits struct {
+	Timeout   time.Duration
+	MaxOutput int
+	// MaxConcurrent is how many calls of the tool may run at once,
+	// counting handlers abandoned after a timeout until they return.
+	MaxConcurrent int
+}
+
+// DefaultLimits apply to tools in a registry unless overridden.
+var DefaultLimits = Limits{
+	Timeout:   2 * time.Minute,
+	MaxOutput: 256 * 1024,
+}
+
+// merge returns l with zero fields taken from fallback.
+func (l Limits) merge(fallback Limits) Limits {
+	if l.Timeout == 0 {
+		l.Timeout = fallback.Timeout
+	}
+	if l.MaxOutput == 0 {
+		l.MaxOutput = fallback.MaxOutput
+	}
+	if l.MaxConcurrent == 0 {
+		l.MaxConcurrent = fallback.MaxConcurrent
+	}
+	return l
+}
+
 // Tool represents an operation that agents can invoke.
 type Tool struct {
 	Name        string         `json:"name"`
 	Description string         `json:"description"`
 	Schema      map[string]any `json:"schema,omitempty"`
 	Handler     Handler        `json:"-"`
+	// ContextHandler is used instead of Handler when set.
+	ContextHandler ContextHandler `json:"-"`
+	// Limits override the registry defaults for this tool.
+	Limits Limits `json:"-"`
 }
 
 // ToolError represents an error from tool execution.
@@ -30,6 +70,45 @@ func (e *ToolError) Error() string {
 	return e.Message
 }
 
+// ErrTimeout is matched by errors from tools that exceeded their timeout.
+var ErrTimeout = errors.New("tool timed out")
+
+// TimeoutError reports a tool that did not finish within its timeout. The
+// handler is abandoned; handlers with a context are cancelled.
+type TimeoutError struct {
+	Tool    string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("tool '%s' timed out after %s", e.Tool, e.Timeout)
+}
+
+// Is reports whether target is ErrTimeout.
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// ErrBusy is matched by errors from tools already running their maximum
+// number of concurrent calls.
+var ErrBusy = errors.New("tool busy")
+
+// BusyError reports a call refused because the tool is already running
+// MaxConcurrent calls.
+type BusyError struct {
+	Tool          string
+	MaxConcurrent int
+}
+
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("tool '%s' is busy: %d calls already running", e.Tool, e.MaxConcurrent)
+}
+
+// Is reports whether target is ErrBusy.
+func (e *BusyError) Is(target error) bool {
+	return target == ErrBusy
+}
+
 // New creates a new Tool with the given parameters.
 func New(name, description string, schema map[string]any, handler Handler) *Tool {
 	return &Tool{
@@ -40,107 +119,101 @@ func New(name, description string, schema map[string]any, handler Handler) *Tool
 	}
 }
 
-// Execute runs the tool with the given arguments.
+// NewWithContext creates a tool whose handler is cancelled on timeout.
+func NewWithContext(name, description string, schema map[string]any, handler ContextHandler) *Tool {
+	return &Tool{
+		Name:           name,
+		Description:    description,
+		Schema:         schema,
+		ContextHandler: handler,
+	}
+}
+
+// withLimits sets the tool's own limits and returns it.
+func (t *Tool) withLimits(limits Limits) *Tool {
+	t.Limits = limits
+	return t
+}
+
+// Execute runs the tool with the given arguments under its own limits.
 // It validates arguments against the schema (if present) before calling the handler.
 func (t *Tool) Execute(args Args) (string, error) {
+	return t.ExecuteContext(context.Background(), args, t.Limits)
+}
+
+// ExecuteContext runs the tool with the given limits. A handler that
+// outlives the timeout yields a *TimeoutError and is left to finish in the
+// background, so a hung tool cannot block the caller. Panics are returned
+// as errors and output beyond MaxOutput is truncated.
+func (t *Tool) ExecuteContext(ctx context.Context, args Args, limits Limits) (string, error) {
+	return t.execute(ctx, args, limits, nil)
+}
+
+// execute is ExecuteContext calling release, if set, once the handler
+// has returned, which may be after execute has given up on it.
+func (t *Tool) execute(ctx context.Context, args Args, limits Limits, release func()) (string, error) {
+	if release == nil {
+		release = func() {}
+	}
 	if t.Schema != nil {
 		if err := t.validateArgs(args); err != nil {
-			return "", fmt.Errorf("argument validation failed: %w", err)
+			release()
+			return "", err
 		}
 	}
 
-	if t.Handler == nil {
+	if t.Handler == nil && t.ContextHandler == nil {
+		release()
 		return "", fmt.Errorf("tool '%s' has no handler", t.Name)
 	}
 
-	return t.Handler(args)
-}
-
-// validateArgs validates arguments against the JSON schema.
-func (t *Tool) validateArgs(args Args) error {
-	schema := t.Schema
-	
-	// Check if it's an object schema
-	schemaType, _ := schema["type"].(string)
-	if schemaType != "object" {
-		return nil // Only validate object schemas
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
 	}
 
-	// Check required fields
-	required, ok := schema["required"].([]any)
-	if ok {
-		for _, reqField := range required {
-			fieldName, _ := reqField.(string)
-			if _, exists := args[fieldName]; !exists {
-				return fmt.Errorf("missing required field: %s", fieldName)
+	type outcome struct {
+		output string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer release()
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("tool '%s' panicked: %v", t.Name, r)}
 			}
+		}()
+		var o outcome
+		if t.ContextHandler != nil {
+			o.output, o.err = t.ContextHandler(ctx, args)
+		} else {
+			o.output, o.err = t.Handler(args)
 		}
-	}
+		done <- o
+	}()
 
-	// Check field types
-	properties, ok := schema["properties"].(map[string]any)
-	if !ok {
-		return nil
-	}
-
-	for fieldName, value := range args {
-		propSchema, ok := properties[fieldName].(map[string]any)
-		if !ok {
-			continue // Unknown field, skip
+	select {
+	case o := <-done:
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", &TimeoutError{Tool: t.Name, Timeout: limits.Timeout}
 		}
-
-		expectedType, _ := propSchema["type"].(string)
-		if err := validateType(fieldName, value, expectedType); err != nil {
-			return err
+		return truncateOutput(o.output, limits.MaxOutput), o.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", &TimeoutError{Tool: t.Name, Timeout: limits.Timeout}
 		}
+		return "", ctx.Err()
 	}
-
-	return nil
 }
 
-// validateType checks if a value matches the expected JSON Schema type.
-func validateType(fieldName string, value any, expectedType string) error {
-	if value == nil {
-		return nil // null is valid for any type in JSON Schema by default
-	}
-
-	switch expectedType {
-	case "string":
-		if _, ok := value.(string); !ok {
-			return fmt.Errorf("field '%s' must be a string", fieldName)
-		}
-	case "integer":
-		switch v := value.(type) {
-		case int, int64, float64:
-			// float64 is acceptable if it's a whole number
-			if f, ok := v.(float64); ok && f != float64(int64(f)) {
-				return fmt.Errorf("field '%s' must be an integer", fieldName)
-			}
-		default:
-			return fmt.Errorf("field '%s' must be an integer", fieldName)
-		}
-	case "number":
-		switch value.(type) {
-		case int, int64, float64:
-			// All numeric types are valid
-		default:
-			return fmt.Errorf("field '%s' must be a number", fieldName)
-		}
-	case "boolean":
-		if _, ok := value.(bool); !ok {
-			return fmt.Errorf("field '%s' must be a boolean", fieldName)
-		}
-	case "array":
-		if _, ok := value.([]any); !ok {
-			return fmt.Errorf("field '%s' must be an array", fieldName)
-		}
-	case "object":
-		if _, ok := value.(map[string]any); !ok {
-			return fmt.Errorf("field '%s' must be an object", fieldName)
-		}
+// truncateOutput cuts output to max bytes, noting how much was dropped.
+func truncateOutput(output string, max int) string {
+	if max <= 0 || len(output) <= max {
+		return output
 	}
-
-	return nil
+	return fmt.Sprintf("%s\n... (output truncated: %d of %d bytes shown)", output[:max], max, len(output))
 }
 
 // ToJSON returns the tool definition as JSON (for MCP/API responses).
@@ -150,22 +223,119 @@ func (t *Tool) ToJSON() ([]byte, error) {
 
 // Registry manages a collection of tools.
 type Registry struct {
-	tools map[string]*Tool
-	mu    sync.RWMutex
+	tools     map[string]*Tool
+	defaults  Limits
+	overrides map[string]Limits
+	mu        sync.RWMutex
+
+	// running counts the calls of each tool whose handler hasn't returned.
+	runningMu sync.Mutex
+	running   map[string]int
+
+	watchers    map[int]func()
+	nextWatcher int
 }
 
-// NewRegistry creates an empty tool registry.
+// NewRegistry creates an empty tool registry using DefaultLimits.
 func NewRegistry() *Registry {
 	return &Registry{
-		tools: make(map[string]*Tool),
+		tools:     make(map[string]*Tool),
+		defaults:  DefaultLimits,
+		overrides: make(map[string]Limits),
+		running:   make(map[string]int),
+	}
+}
+
+// SetDefaultLimits sets the limits for tools without their own.
+func (r *Registry) SetDefaultLimits(limits Limits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaults = limits
+}
+
+// SetLimits overrides the limits of the named tool, including tools
+// registered later. Zero fields keep the tool's or registry's value.
+func (r *Registry) SetLimits(name string, limits Limits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides[name] = limits
+}
+
+// ResetLimits restores DefaultLimits and drops all overrides, such as
+// before applying a reloaded configuration. Calls already running keep
+// the limits they started with.
+func (r *Registry) ResetLimits() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaults = DefaultLimits
+	r.overrides = make(map[string]Limits)
+}
+
+// LimitsFor returns the effective limits of the named tool.
+func (r *Registry) LimitsFor(name string) Limits {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var own Limits
+	if tool, ok := r.tools[name]; ok {
+		own = tool.Limits
 	}
+	return r.overrides[name].merge(own).merge(r.defaults)
 }
 
-// Register adds a tool to the registry.
+// Register adds a tool to the registry, replacing any tool of the same name.
 func (r *Registry) Register(tool *Tool) {
+	r.Replace(nil, []*Tool{tool})
+}
+
+// Unregister removes the named tool. Calls already running finish.
+func (r *Registry) Unregister(name string) {
+	r.Replace([]string{name}, nil)
+}
+
+// Replace removes the named tools and adds the given ones as one change,
+// so watchers are told once, such as when reloading a set of tools.
+func (r *Registry) Replace(remove []string, add []*Tool) {
+	r.mu.Lock()
+	changed := false
+	for _, name := range remove {
+		if _, ok := r.tools[name]; ok {
+			delete(r.tools, name)
+			changed = true
+		}
+	}
+	for _, tool := range add {
+		r.tools[tool.Name] = tool
+		changed = true
+	}
+	watchers := make([]func(), 0, len(r.watchers))
+	for _, fn := range r.watchers {
+		watchers = append(watchers, fn)
+	}
+	r.mu.Unlock()
+
+	if changed {
+		for _, fn := range watchers {
+			fn()
+		}
+	}
+}
+
+// Watch calls fn whenever tools are registered or removed; stop ends the
+// watch. fn runs on the goroutine that changed the registry.
+func (r *Registry) Watch(fn func()) (stop func()) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.tools[tool.Name] = tool
+	if r.watchers == nil {
+		r.watchers = make(map[int]func())
+	}
+	key := r.nextWatcher
+	r.nextWatcher++
+	r.watchers[key] = fn
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.watchers, key)
+	}
 }
 
 // Get returns a tool by name.
@@ -192,11 +362,41 @@ func (r *Registry) List() []*Tool {
 	return tools
 }
 
-// Execute runs a tool by name with the given arguments.
+// Execute runs a tool by name with the given arguments under its limits.
 func (r *Registry) Execute(name string, args Args) (string, error) {
+	return r.ExecuteContext(context.Background(), name, args)
+}
+
+// ExecuteContext runs a tool by name under its limits and ctx. A call
+// beyond the tool's MaxConcurrent is refused with a *BusyError.
+func (r *Registry) ExecuteContext(ctx context.Context, name string, args Args) (string, error) {
 	tool, err := r.Get(name)
 	if err != nil {
 		return "", err
 	}
-	return tool.Execute(args)
+	limits := r.LimitsFor(name)
+	release, err := r.acquire(name, limits.MaxConcurrent)
+	if err != nil {
+		return "", err
+	}
+	return tool.execute(ctx, args, limits, release)
+}
+
+// acquire reserves one of max concurrent calls of the named tool (no limit
+// when max is zero). The returned function gives it back.
+func (r *Registry) acquire(name string, max int) (release func(), err error) {
+	r.runningMu.Lock()
+	defer r.runningMu.Unlock()
+	if max > 0 && r.running[name] >= max {
+		return nil, &BusyError{Tool: name, MaxConcurrent: max}
+	}
+	r.running[name]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.runningMu.Lock()
+			defer r.runningMu.Unlock()
+			r.running[name]--
+		})
+	}, nil
 }
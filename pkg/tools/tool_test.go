@@ -1,8 +1,11 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewTool(t *testing.T) {
@@ -153,6 +156,85 @@ func TestToolSchemaValidation(t *testing.T) {
 	}
 }
 
+func TestToolSchemaValidationNested(t *testing.T) {
+	schema := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"mode", "files"},
+		"properties": map[string]any{
+			"mode":  map[string]any{"type": "string", "enum": []any{"fast", "full"}},
+			"count": map[string]any{"type": "integer", "minimum": 1, "maximum": 10},
+			"label": map[string]any{"type": "string", "pattern": "^[a-z]+$", "maxLength": 8},
+			"files": map[string]any{
+				"type":     "array",
+				"minItems": 1,
+				"items": map[string]any{
+					"type":     "object",
+					"required": []any{"path"},
+					"properties": map[string]any{
+						"path": map[string]any{"type": "string", "minLength": 1},
+					},
+				},
+			},
+		},
+	}
+	called := false
+	tool := New("build", "Builds", schema, func(args Args) (string, error) {
+		called = true
+		return "ok", nil
+	})
+
+	valid := Args{"mode": "fast", "count": float64(3), "label": nil, "files": []any{map[string]any{"path": "a.go"}}}
+	if _, err := tool.Execute(valid); err != nil {
+		t.Fatalf("valid arguments rejected: %v", err)
+	}
+
+	called = false
+	_, err := tool.Execute(Args{
+		"mode":  "slow",
+		"count": float64(11),
+		"label": "Not Valid",
+		"files": []any{map[string]any{"path": ""}, map[string]any{}},
+		"extra": true,
+	})
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || !errors.Is(err, ErrInvalidArgs) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	if called {
+		t.Error("handler ran despite invalid arguments")
+	}
+	if invalid.Tool != "build" {
+		t.Errorf("expected tool build, got %q", invalid.Tool)
+	}
+
+	got := make(map[string]bool)
+	for _, p := range invalid.Problems {
+		got[p.String()] = true
+	}
+	for _, want := range []string{
+		"count: must be at most 10",
+		"extra: is not a known argument",
+		"files[0].path: must be at least 1 characters",
+		"files[1].path: is required",
+		"label: must match pattern ^[a-z]+$",
+		"label: must be at most 8 characters",
+		`mode: must be one of "fast", "full"`,
+	} {
+		if !got[want] {
+			t.Errorf("missing problem %q in %v", want, invalid.Problems)
+		}
+	}
+	if len(invalid.Problems) != 7 {
+		t.Errorf("expected 7 problems, got %d: %v", len(invalid.Problems), invalid.Problems)
+	}
+
+	_, err = tool.Execute(Args{"files": "a.go"})
+	if err == nil || !strings.Contains(err.Error(), "mode: is required") || !strings.Contains(err.Error(), "files: must be an array") {
+		t.Errorf("expected missing mode and wrong files type, got %v", err)
+	}
+}
+
 func TestToolHandlerError(t *testing.T) {
 	tool := New("failing", "Always fails", nil, func(args Args) (string, error) {
 		return "", &ToolError{Message: "intentional failure"}
@@ -166,3 +248,158 @@ func TestToolHandlerError(t *testing.T) {
 		t.Errorf("expected error message 'intentional failure', got '%s'", err.Error())
 	}
 }
+
+func TestToolTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	reg := NewRegistry()
+	reg.Register(New("hang", "Never returns", nil, func(args Args) (string, error) {
+		<-block
+		return "", nil
+	}))
+	reg.SetLimits("hang", Limits{Timeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	_, err := reg.Execute("hang", Args{})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	var te *TimeoutError
+	if !errors.As(err, &te) || te.Tool != "hang" || te.Timeout != 20*time.Millisecond {
+		t.Errorf("unexpected timeout error: %+v", te)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("expected Execute to return promptly after the timeout")
+	}
+}
+
+func TestToolContextHandlerCancelled(t *testing.T) {
+	cancelled := make(chan struct{})
+	tool := NewWithContext("wait", "Waits for cancellation", nil, func(ctx context.Context, args Args) (string, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return "", ctx.Err()
+	})
+
+	_, err := tool.ExecuteContext(context.Background(), Args{}, Limits{Timeout: 10 * time.Millisecond})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected handler context to be cancelled")
+	}
+}
+
+func TestToolOutputLimitAndPanic(t *testing.T) {
+	reg := NewRegistry()
+	reg.SetDefaultLimits(Limits{MaxOutput: 10})
+	reg.Register(New("big", "Large output", nil, func(args Args) (string, error) {
+		return strings.Repeat("x", 100), nil
+	}))
+	reg.Register(New("boom", "Panics", nil, func(args Args) (string, error) {
+		panic("kaboom")
+	}))
+
+	out, err := reg.Execute("big", Args{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out, strings.Repeat("x", 10)+"\n") || !strings.Contains(out, "10 of 100 bytes") {
+		t.Errorf("expected truncated output, got %q", out)
+	}
+
+	if _, err := reg.Execute("boom", Args{}); err == nil || !strings.Contains(err.Error(), "kaboom") {
+		t.Errorf("expected panic to be returned as an error, got %v", err)
+	}
+}
+
+func TestRegistryLimitsPrecedence(t *testing.T) {
+	reg := NewRegistry()
+	reg.SetDefaultLimits(Limits{Timeout: time.Minute, MaxOutput: 100})
+	tool := New("t", "", nil, func(args Args) (string, error) { return "", nil })
+	tool.Limits = Limits{Timeout: time.Hour}
+	reg.Register(tool)
+
+	if got := reg.LimitsFor("t"); got.Timeout != time.Hour || got.MaxOutput != 100 {
+		t.Errorf("expected tool limits over defaults, got %+v", got)
+	}
+	reg.SetLimits("t", Limits{MaxOutput: 5})
+	if got := reg.LimitsFor("t"); got.Timeout != time.Hour || got.MaxOutput != 5 {
+		t.Errorf("expected override to win per field, got %+v", got)
+	}
+
+	reg.ResetLimits()
+	if got := reg.LimitsFor("t"); got.Timeout != time.Hour || got.MaxOutput != DefaultLimits.MaxOutput {
+		t.Errorf("expected reset to drop overrides and defaults, got %+v", got)
+	}
+}
+
+func TestRegistryMaxConcurrent(t *testing.T) {
+	started := make(chan struct{}, 2)
+	unblock := make(chan struct{})
+	reg := NewRegistry()
+	reg.Register(New("stuck", "", nil, func(args Args) (string, error) {
+		started <- struct{}{}
+		<-unblock
+		return "done", nil
+	}))
+	reg.SetLimits("stuck", Limits{Timeout: 20 * time.Millisecond, MaxConcurrent: 1})
+
+	// The first call times out, but its handler is still running.
+	if _, err := reg.Execute("stuck", Args{}); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	<-started
+
+	_, err := reg.Execute("stuck", Args{})
+	var busy *BusyError
+	if !errors.As(err, &busy) || busy.MaxConcurrent != 1 || !errors.Is(err, ErrBusy) {
+		t.Fatalf("expected a busy error while the abandoned handler runs, got %v", err)
+	}
+
+	close(unblock)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		out, err := reg.Execute("stuck", Args{})
+		if err == nil && out == "done" {
+			break
+		}
+		if !errors.Is(err, ErrBusy) || time.Now().After(deadline) {
+			t.Fatalf("expected the slot to be released, got %q, %v", out, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRegistryWatch(t *testing.T) {
+	reg := NewRegistry()
+	changes := 0
+	stop := reg.Watch(func() { changes++ })
+
+	noop := func(args Args) (string, error) { return "", nil }
+	reg.Register(New("a", "A", nil, noop))
+	reg.Replace([]string{"a"}, []*Tool{New("b", "B", nil, noop), New("c", "C", nil, noop)})
+	if changes != 2 {
+		t.Errorf("expected 2 changes, got %d", changes)
+	}
+	if _, err := reg.Get("a"); err == nil {
+		t.Error("expected a to be replaced")
+	}
+
+	reg.Unregister("missing")
+	if changes != 2 {
+		t.Errorf("expected removing an unknown tool not to notify, got %d changes", changes)
+	}
+
+	stop()
+	reg.Unregister("b")
+	if changes != 2 {
+		t.Errorf("expected no notification after stop, got %d changes", changes)
+	}
+	if len(reg.List()) != 1 {
+		t.Errorf("expected 1 tool left, got %d", len(reg.List()))
+	}
+}
@@ -0,0 +1,245 @@
+// Package transcript records agent run transcripts (prompts, streamed events,
+// and results) with optional encryption at rest.
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/richgo/flo/pkg/correlation"
+	"github.com/richgo/flo/pkg/redact"
+	"github.com/richgo/flo/pkg/seal"
+)
+
+// sealedPrefix marks a transcript line encrypted with the workspace key.
+const sealedPrefix = "enc:v1:"
+
+// Entry types recorded in a transcript.
+const (
+	EntryPrompt   = "prompt"
+	EntryMessage  = "message"
+	EntryToolCall = "tool_call"
+	EntryComplete = "complete"
+	EntryError    = "error"
+	EntryResult   = "result"
+)
+
+// Entry is a single transcript record.
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Content   string    `json:"content"`
+	// RunID and ExecutionID tie the entry to an agent run and a task
+	// attempt within it.
+	RunID       string `json:"run_id,omitempty"`
+	ExecutionID string `json:"execution_id,omitempty"`
+}
+
+// Writer appends entries to a transcript file.
+type Writer struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	key  seal.Key
+	// redactor scrubs secrets from entries before they're written.
+	redactor *redact.Redactor
+	ids      correlation.IDs
+}
+
+// Create starts a new transcript for a task under dir. When key is non-nil,
+// every entry is encrypted before being written.
+func Create(dir, taskID string, key seal.Key) (*Writer, error) {
+	taskDir := filepath.Join(dir, taskID)
+	if err := os.MkdirAll(taskDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + ".jsonl"
+	path := filepath.Join(taskDir, name)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcript: %w", err)
+	}
+
+	return &Writer{
+		path: path,
+		file: file,
+		key:  key,
+	}, nil
+}
+
+// Path returns the transcript file path.
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// Encrypted reports whether entries are encrypted at rest.
+func (w *Writer) Encrypted() bool {
+	return w.key != nil
+}
+
+// SetRedactor scrubs secrets from every entry written from now on, as
+// agent output often echoes environment variables.
+func (w *Writer) SetRedactor(r *redact.Redactor) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.redactor = r
+}
+
+// SetCorrelation stamps entries written from now on with ids.
+func (w *Writer) SetCorrelation(ids correlation.IDs) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ids = ids
+}
+
+// Write appends an entry of the given type.
+func (w *Writer) Write(entryType, content string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return fmt.Errorf("transcript is closed")
+	}
+
+	data, err := json.Marshal(Entry{
+		Timestamp:   time.Now().UTC(),
+		Type:        entryType,
+		Content:     w.redactor.String(content),
+		RunID:       w.ids.RunID,
+		ExecutionID: w.ids.ExecutionID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize entry: %w", err)
+	}
+
+	line := string(data)
+	if w.key != nil {
+		sealed, err := seal.SealString(w.key, data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt entry: %w", err)
+		}
+		line = sealedPrefix + sealed
+	}
+
+	if _, err := w.file.WriteString(line + "\n"); err != nil {
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the transcript file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// Read parses a transcript file. Encrypted entries are decrypted with key;
+// if they are present and key is nil, seal.ErrNoKey is returned.
+func Read(path string, key seal.Key) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		data := []byte(line)
+		if strings.HasPrefix(line, sealedPrefix) {
+			if key == nil {
+				return nil, fmt.Errorf("transcript is encrypted: %w", seal.ErrNoKey)
+			}
+			data, err = seal.OpenString(key, strings.TrimPrefix(line, sealedPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("invalid transcript entry at line %d: %w", lineNum, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading transcript: %w", err)
+	}
+	return entries, nil
+}
+
+// Excerpt renders a transcript as plain text for an agent to read: its
+// messages, tool calls and errors, without the prompt. Longer text is cut
+// to its last limit bytes when limit > 0.
+func Excerpt(entries []Entry, limit int) string {
+	var b strings.Builder
+	for _, e := range entries {
+		switch e.Type {
+		case EntryMessage:
+			b.WriteString(e.Content)
+		case EntryToolCall, EntryError:
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "[%s] %s\n", e.Type, e.Content)
+		}
+	}
+	text := strings.TrimSpace(b.String())
+	if limit > 0 && len(text) > limit {
+		cut := len(text) - limit
+		// Don't split a UTF-8 sequence
+		for cut < len(text) && !utf8.RuneStart(text[cut]) {
+			cut++
+		}
+		text = "..." + text[cut:]
+	}
+	return text
+}
+
+// List returns the transcript files for a task, oldest first.
+func List(dir, taskID string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, taskID, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Latest returns the most recent transcript file for a task.
+func Latest(dir, taskID string) (string, error) {
+	paths, err := List(dir, taskID)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no transcripts found for task '%s'", taskID)
+	}
+	return paths[len(paths)-1], nil
+}
@@ -0,0 +1,163 @@
+package transcript
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/correlation"
+	"github.com/richgo/flo/pkg/redact"
+	"github.com/richgo/flo/pkg/seal"
+)
+
+func TestWriteAndReadPlain(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := Create(dir, "t-001", nil)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	w.Write(EntryPrompt, "Implement OAuth")
+	w.Write(EntryMessage, "Working on it")
+	w.Close()
+
+	entries, err := Read(w.Path(), nil)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Type != EntryPrompt || entries[0].Content != "Implement OAuth" {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+}
+
+func TestWriteAndReadEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := seal.GenerateKey()
+
+	w, err := Create(dir, "t-001", key)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !w.Encrypted() {
+		t.Error("expected writer to report encryption")
+	}
+	w.Write(EntryPrompt, "proprietary incident details")
+	w.Close()
+
+	raw, _ := os.ReadFile(w.Path())
+	if strings.Contains(string(raw), "proprietary") {
+		t.Error("plaintext leaked into encrypted transcript")
+	}
+
+	if _, err := Read(w.Path(), nil); !errors.Is(err, seal.ErrNoKey) {
+		t.Errorf("expected ErrNoKey without key, got %v", err)
+	}
+
+	entries, err := Read(w.Path(), key)
+	if err != nil {
+		t.Fatalf("Read with key failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Content != "proprietary incident details" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestListAndLatest(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Latest(dir, "t-001"); err == nil {
+		t.Error("expected error with no transcripts")
+	}
+
+	first, _ := Create(dir, "t-001", nil)
+	first.Close()
+	second, _ := Create(dir, "t-001", nil)
+	second.Close()
+
+	paths, err := List(dir, "t-001")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 transcripts, got %d", len(paths))
+	}
+
+	latest, err := Latest(dir, "t-001")
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if latest != second.Path() {
+		t.Errorf("expected latest %s, got %s", second.Path(), latest)
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	w, _ := Create(t.TempDir(), "t-001", nil)
+	w.Close()
+	if err := w.Write(EntryMessage, "late"); err == nil {
+		t.Error("expected error writing to closed transcript")
+	}
+}
+
+func TestWriteRedacted(t *testing.T) {
+	w, err := Create(t.TempDir(), "t-001", nil)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	r := redact.New(nil)
+	r.AddValue("hunter2-secret")
+	w.SetRedactor(r)
+	w.Write(EntryMessage, "the key is hunter2-secret")
+	w.Close()
+
+	data, _ := os.ReadFile(w.Path())
+	if strings.Contains(string(data), "hunter2-secret") {
+		t.Errorf("expected the secret scrubbed from the transcript, got %s", data)
+	}
+}
+
+func TestWriteCorrelated(t *testing.T) {
+	w, err := Create(t.TempDir(), "t-001", nil)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	w.SetCorrelation(correlation.IDs{RunID: "run-1", ExecutionID: "exec-1"})
+	w.Write(EntryPrompt, "do the task")
+	w.Close()
+
+	entries, err := Read(w.Path(), nil)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].RunID != "run-1" || entries[0].ExecutionID != "exec-1" {
+		t.Errorf("expected the entry stamped with the attempt's IDs, got %+v", entries)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	entries := []Entry{
+		{Type: EntryPrompt, Content: "Implement OAuth"},
+		{Type: EntryMessage, Content: "Adding the "},
+		{Type: EntryMessage, Content: "handler"},
+		{Type: EntryToolCall, Content: "write_file auth.go"},
+		{Type: EntryError, Content: "tests failed"},
+		{Type: EntryResult, Content: `{"success":false}`},
+	}
+
+	got := Excerpt(entries, 0)
+	want := "Adding the handler\n[tool_call] write_file auth.go\n[error] tests failed"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if got := Excerpt(entries, 12); got != "...tests failed" {
+		t.Errorf("expected the excerpt cut to its end, got %q", got)
+	}
+	if got := Excerpt([]Entry{{Type: EntryMessage, Content: "naïve"}}, 3); got != "...ve" {
+		t.Errorf("expected the cut not to split a character, got %q", got)
+	}
+}
@@ -0,0 +1,256 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/richgo/flo/pkg/approval"
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/events"
+)
+
+// Action tells the screen's driver what to do after a keypress.
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionQuit
+	ActionReject   // prompt for a rejection reason
+	ActionFeedback // prompt for change-request feedback
+)
+
+// ApprovalScreen lists pending approvals and applies single-key decisions.
+type ApprovalScreen struct {
+	queue    *approval.Queue
+	reviewer string
+	items    []*approval.Request
+	cursor   int
+	showDiff bool
+	message  string
+}
+
+// NewApprovalScreen creates a screen over the given queue. Decisions are
+// recorded as made by reviewer.
+func NewApprovalScreen(queue *approval.Queue, reviewer string) *ApprovalScreen {
+	return &ApprovalScreen{queue: queue, reviewer: reviewer}
+}
+
+// Refresh reloads pending requests from the queue.
+func (s *ApprovalScreen) Refresh() error {
+	items, err := s.queue.Pending()
+	if err != nil {
+		return err
+	}
+	s.items = items
+	if s.cursor >= len(s.items) {
+		s.cursor = len(s.items) - 1
+	}
+	if s.cursor < 0 {
+		s.cursor = 0
+	}
+	return nil
+}
+
+// Selected returns the request under the cursor, or nil when the queue is empty.
+func (s *ApprovalScreen) Selected() *approval.Request {
+	if len(s.items) == 0 {
+		return nil
+	}
+	return s.items[s.cursor]
+}
+
+// HandleKey applies a keypress and returns the follow-up action.
+func (s *ApprovalScreen) HandleKey(key Key) Action {
+	s.message = ""
+	switch key {
+	case "q", KeyEsc, KeyCtrlC:
+		return ActionQuit
+	case "j", KeyDown:
+		if s.cursor < len(s.items)-1 {
+			s.cursor++
+		}
+	case "k", KeyUp:
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case "d", KeyEnter:
+		s.showDiff = !s.showDiff
+	case "a":
+		if req := s.Selected(); req != nil {
+			s.decide(req, s.queue.Approve(req.ID, s.reviewer), "approved")
+		}
+	case "r":
+		if s.Selected() != nil {
+			return ActionReject
+		}
+	case "f":
+		if s.Selected() != nil {
+			return ActionFeedback
+		}
+	}
+	return ActionNone
+}
+
+// Reject rejects the selected request with the given reason.
+func (s *ApprovalScreen) Reject(reason string) {
+	if req := s.Selected(); req != nil {
+		s.decide(req, s.queue.Reject(req.ID, s.reviewer, reason), "rejected")
+	}
+}
+
+// RequestChanges sends the selected request back with feedback.
+func (s *ApprovalScreen) RequestChanges(feedback string) {
+	if req := s.Selected(); req != nil {
+		s.decide(req, s.queue.RequestChanges(req.ID, s.reviewer, feedback), "sent back with feedback")
+	}
+}
+
+func (s *ApprovalScreen) decide(req *approval.Request, err error, verb string) {
+	if err != nil {
+		s.message = "Error: " + err.Error()
+		return
+	}
+	s.message = fmt.Sprintf("%s (%s) %s", req.ID, req.TaskID, verb)
+	if err := s.Refresh(); err != nil {
+		s.message = "Error: " + err.Error()
+	}
+}
+
+// Render draws the screen, limited to height lines when height > 0.
+func (s *ApprovalScreen) Render(w io.Writer, height int) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Pending approvals (%d)\n\n", len(s.items))
+	if len(s.items) == 0 {
+		b.WriteString("  Nothing waiting for review.\n")
+	}
+	for i, req := range s.items {
+		marker := "  "
+		if i == s.cursor {
+			marker = "> "
+		}
+		fmt.Fprintf(&b, "%s%-6s %-8s %-10s %s %s\n",
+			marker, req.ID, req.TaskID, req.Kind, gateBadge(req.Gates), req.Summary)
+	}
+
+	if req := s.Selected(); req != nil {
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "Task:  %s\n", req.TaskID)
+		if req.Cost > 0 {
+			fmt.Fprintf(&b, "Cost:  $%.2f\n", req.Cost)
+		}
+		for _, g := range req.Gates {
+			status := "pass"
+			if !g.Passed {
+				status = "FAIL"
+			}
+			line := fmt.Sprintf("Gate:  %-12s %s", g.Name, status)
+			if g.Detail != "" {
+				line += "  " + g.Detail
+			}
+			b.WriteString(line + "\n")
+		}
+		if s.showDiff {
+			b.WriteString("\n")
+			if req.Diff == "" {
+				b.WriteString("(no diff attached)\n")
+			} else {
+				b.WriteString(strings.TrimRight(req.Diff, "\n") + "\n")
+			}
+		}
+	}
+
+	b.WriteString("\n")
+	if s.message != "" {
+		b.WriteString(s.message + "\n")
+	}
+	b.WriteString("j/k move  d diff  a approve  r reject  f feedback  q quit\n")
+
+	out := b.String()
+	if height > 0 {
+		lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+		if len(lines) > height {
+			// Keep the key help visible when the diff overflows
+			lines = append(lines[:height-2], "...", lines[len(lines)-1])
+		}
+		out = strings.Join(lines, "\n") + "\n"
+	}
+	io.WriteString(w, out)
+}
+
+// gateBadge summarizes gate results as e.g. "[2/3 gates]".
+func gateBadge(gates []approval.GateResult) string {
+	if len(gates) == 0 {
+		return "[no gates]"
+	}
+	passed := 0
+	for _, g := range gates {
+		if g.Passed {
+			passed++
+		}
+	}
+	return fmt.Sprintf("[%d/%d gates]", passed, len(gates))
+}
+
+// RunApprovalScreen drives the screen interactively until the user quits.
+// Approvals requested or decided elsewhere in the process, such as by an
+// agent run, show up at the next redraw.
+func RunApprovalScreen(term *Terminal, screen *ApprovalScreen) error {
+	if err := screen.Refresh(); err != nil {
+		return err
+	}
+	var stale atomic.Bool
+	defer events.Subscribe(func(e events.Event) {
+		switch e.Data.(type) {
+		case audit.ApprovalRequested, audit.ApprovalDecided:
+			stale.Store(true)
+		}
+	})()
+	if err := term.MakeRaw(); err != nil {
+		return err
+	}
+	defer func() {
+		term.Restore()
+		term.Clear()
+	}()
+
+	for {
+		if stale.Swap(false) {
+			if err := screen.Refresh(); err != nil {
+				return err
+			}
+		}
+		term.Clear()
+		height, _ := term.Size()
+		var b strings.Builder
+		screen.Render(&b, height-1)
+		// Raw mode does not translate newlines
+		io.WriteString(term.out, strings.ReplaceAll(b.String(), "\n", "\r\n"))
+
+		key, err := term.ReadKey()
+		if err != nil {
+			return err
+		}
+
+		switch screen.HandleKey(key) {
+		case ActionQuit:
+			return nil
+		case ActionReject:
+			reason, err := term.ReadLine("Reject reason (optional): ")
+			if err != nil {
+				return err
+			}
+			screen.Reject(reason)
+		case ActionFeedback:
+			feedback, err := term.ReadLine("Feedback for the agent: ")
+			if err != nil {
+				return err
+			}
+			if feedback != "" {
+				screen.RequestChanges(feedback)
+			}
+		}
+	}
+}
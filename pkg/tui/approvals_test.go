@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/approval"
+)
+
+func newTestScreen(t *testing.T) (*ApprovalScreen, *approval.Queue) {
+	q := approval.NewQueue(filepath.Join(t.TempDir(), "approvals.json"))
+	now := time.Now()
+	q.Add(&approval.Request{
+		TaskID:    "t-001",
+		Kind:      "review",
+		Summary:   "Add OAuth",
+		Diff:      "+func Login() {}",
+		Cost:      1.25,
+		Gates:     []approval.GateResult{{Name: "tdd", Passed: true}, {Name: "verify", Passed: false, Detail: "lint"}},
+		CreatedAt: now,
+	})
+	q.Add(&approval.Request{TaskID: "t-002", Kind: "completion", Summary: "Add logout", CreatedAt: now.Add(time.Second)})
+
+	s := NewApprovalScreen(q, "alice")
+	if err := s.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	return s, q
+}
+
+func TestApprovalScreenNavigation(t *testing.T) {
+	s, _ := newTestScreen(t)
+
+	if s.Selected().TaskID != "t-001" {
+		t.Fatalf("expected first request selected, got %s", s.Selected().TaskID)
+	}
+	s.HandleKey("j")
+	s.HandleKey(KeyDown)
+	if s.Selected().TaskID != "t-002" {
+		t.Errorf("expected cursor clamped at last request, got %s", s.Selected().TaskID)
+	}
+	s.HandleKey("k")
+	if s.Selected().TaskID != "t-001" {
+		t.Errorf("expected cursor back on first request, got %s", s.Selected().TaskID)
+	}
+	if s.HandleKey("q") != ActionQuit {
+		t.Error("expected q to quit")
+	}
+}
+
+func TestApprovalScreenDecisions(t *testing.T) {
+	s, q := newTestScreen(t)
+
+	s.HandleKey("a")
+	approved, _ := q.LatestForTask("t-001")
+	if approved.Status != approval.StatusApproved || approved.DecidedBy != "alice" {
+		t.Errorf("expected t-001 approved by alice, got %+v", approved)
+	}
+	if s.Selected().TaskID != "t-002" {
+		t.Fatalf("expected queue to advance to t-002, got %s", s.Selected().TaskID)
+	}
+
+	if s.HandleKey("f") != ActionFeedback {
+		t.Fatal("expected f to request feedback")
+	}
+	s.RequestChanges("handle expired sessions")
+	changed, _ := q.LatestForTask("t-002")
+	if changed.Status != approval.StatusChangesRequested {
+		t.Errorf("expected changes requested, got %s", changed.Status)
+	}
+	if s.Selected() != nil {
+		t.Error("expected empty queue")
+	}
+	if s.HandleKey("r") != ActionNone {
+		t.Error("expected reject to be a no-op on an empty queue")
+	}
+}
+
+func TestApprovalScreenRender(t *testing.T) {
+	s, _ := newTestScreen(t)
+
+	var b strings.Builder
+	s.Render(&b, 0)
+	out := b.String()
+	for _, want := range []string{"Pending approvals (2)", "[1/2 gates]", "$1.25", "verify", "FAIL"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected render to contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "func Login") {
+		t.Error("diff should be hidden until toggled")
+	}
+
+	s.HandleKey("d")
+	b.Reset()
+	s.Render(&b, 0)
+	if !strings.Contains(b.String(), "+func Login() {}") {
+		t.Errorf("expected diff preview after toggle:\n%s", b.String())
+	}
+}
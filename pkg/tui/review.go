@@ -0,0 +1,296 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/richgo/flo/pkg/approval"
+	"github.com/richgo/flo/pkg/repos"
+)
+
+// Decision is a reviewer's verdict on a task's changes.
+type Decision string
+
+const (
+	DecisionApprove Decision = "approve"
+	DecisionReject  Decision = "reject"
+	DecisionChanges Decision = "changes"
+)
+
+// DecideFunc records a decision on a review, with the rejection reason or
+// feedback for the agent as text, and the files the reviewer rejected.
+type DecideFunc func(decision Decision, text string, rejected []string) error
+
+// ANSI colors for diff lines.
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+// ReviewScreen shows a task's diff for review, with each file accepted
+// or rejected, and applies single-key decisions.
+type ReviewScreen struct {
+	req    *approval.Request
+	decide DecideFunc
+	lines  []string
+	// files are the indexes of the lines starting each file's diff, and
+	// paths the files' paths.
+	files    []int
+	paths    []string
+	rejected map[string]bool
+	// file is the selected file, whose diff y and x accept and reject.
+	file     int
+	offset   int
+	page     int
+	decision Decision
+	message  string
+}
+
+// NewReviewScreen creates a screen over a review request. decide records
+// the reviewer's decision.
+func NewReviewScreen(req *approval.Request, decide DecideFunc) *ReviewScreen {
+	s := &ReviewScreen{req: req, decide: decide, page: 20, rejected: map[string]bool{}}
+	for _, f := range repos.SplitDiff(req.Diff) {
+		s.files = append(s.files, len(s.lines))
+		s.paths = append(s.paths, f.Path)
+		s.lines = append(s.lines, strings.Split(strings.TrimRight(f.Patch, "\n"), "\n")...)
+	}
+	return s
+}
+
+// Decided reports whether the reviewer has decided.
+func (s *ReviewScreen) Decided() bool {
+	return s.decision != ""
+}
+
+// Decision returns the reviewer's decision, or "" before one is made.
+func (s *ReviewScreen) Decision() Decision {
+	return s.decision
+}
+
+// Rejected returns the files the reviewer rejected, in diff order.
+func (s *ReviewScreen) Rejected() []string {
+	var rejected []string
+	for _, path := range s.paths {
+		if s.rejected[path] {
+			rejected = append(rejected, path)
+		}
+	}
+	return rejected
+}
+
+// selectFile selects file i and scrolls to the start of its diff.
+func (s *ReviewScreen) selectFile(i int) {
+	if i < 0 || i >= len(s.files) {
+		return
+	}
+	s.file = i
+	s.offset = s.files[i]
+	s.scroll(0)
+}
+
+// Offset returns the first diff line shown.
+func (s *ReviewScreen) Offset() int {
+	return s.offset
+}
+
+// HandleKey applies a keypress and returns the follow-up action.
+func (s *ReviewScreen) HandleKey(key Key) Action {
+	s.message = ""
+	switch key {
+	case "q", KeyEsc, KeyCtrlC:
+		return ActionQuit
+	case "j", KeyDown:
+		s.scrollFiles(1)
+	case "k", KeyUp:
+		s.scrollFiles(-1)
+	case " ":
+		s.scrollFiles(s.page)
+	case "b":
+		s.scrollFiles(-s.page)
+	case "n":
+		s.selectFile(s.file + 1)
+	case "p":
+		s.selectFile(s.file - 1)
+	case "x", "y":
+		if len(s.paths) > 0 && !s.Decided() {
+			s.rejected[s.paths[s.file]] = key == "x"
+		}
+	case "a":
+		if !s.Decided() {
+			s.Decide(DecisionApprove, "")
+		}
+	case "r":
+		if !s.Decided() {
+			return ActionReject
+		}
+	case "f":
+		if !s.Decided() {
+			return ActionFeedback
+		}
+	}
+	return ActionNone
+}
+
+// Decide records a decision, with the rejection reason or feedback as
+// text. Approving approves the files not rejected.
+func (s *ReviewScreen) Decide(decision Decision, text string) {
+	if err := s.decide(decision, text, s.Rejected()); err != nil {
+		s.message = "Error: " + err.Error()
+		return
+	}
+	s.decision = decision
+	switch decision {
+	case DecisionApprove:
+		s.message = fmt.Sprintf("%s approved", s.req.TaskID)
+		if n := len(s.Rejected()); n > 0 {
+			s.message += fmt.Sprintf(" (%d rejected files reverted)", n)
+		}
+	case DecisionReject:
+		s.message = fmt.Sprintf("%s rejected", s.req.TaskID)
+	case DecisionChanges:
+		s.message = fmt.Sprintf("%s sent back with feedback", s.req.TaskID)
+	}
+}
+
+// scrollFiles scrolls by n lines and selects the file at the top of the
+// screen.
+func (s *ReviewScreen) scrollFiles(n int) {
+	s.scroll(n)
+	for i, start := range s.files {
+		if start <= s.offset {
+			s.file = i
+		}
+	}
+}
+
+func (s *ReviewScreen) scroll(n int) {
+	s.offset += n
+	if max := len(s.lines) - s.page; s.offset > max {
+		s.offset = max
+	}
+	if s.offset < 0 {
+		s.offset = 0
+	}
+}
+
+// Render draws the screen, limited to height lines when height > 0.
+func (s *ReviewScreen) Render(w io.Writer, height int) {
+	var header strings.Builder
+	fmt.Fprintf(&header, "Review %s: %s (%s)\n", s.req.TaskID, s.req.Summary, s.req.ID)
+	if len(s.req.Gates) > 0 || s.req.Cost > 0 {
+		fmt.Fprintf(&header, "%s", gateBadge(s.req.Gates))
+		if s.req.Cost > 0 {
+			fmt.Fprintf(&header, "  $%.2f", s.req.Cost)
+		}
+		header.WriteString("\n")
+	}
+	fmt.Fprintf(&header, "%d files changed", len(s.files))
+	if n := len(s.Rejected()); n > 0 {
+		fmt.Fprintf(&header, ", %d rejected", n)
+	}
+	if len(s.paths) > 0 {
+		fmt.Fprintf(&header, "  [file %d/%d: %s]", s.file+1, len(s.files), s.paths[s.file])
+	}
+	header.WriteString("\n\n")
+
+	var footer strings.Builder
+	footer.WriteString("\n")
+	if s.message != "" {
+		footer.WriteString(s.message + "\n")
+	}
+	if s.Decided() {
+		footer.WriteString("q quit\n")
+	} else {
+		footer.WriteString("j/k scroll  space/b page  n/p file  y/x accept/reject file  a approve  r reject  f feedback  q quit\n")
+	}
+
+	if height > 0 {
+		s.page = height - strings.Count(header.String(), "\n") - strings.Count(footer.String(), "\n")
+		if s.page < 1 {
+			s.page = 1
+		}
+		s.scroll(0)
+	}
+
+	var body strings.Builder
+	if len(s.lines) == 0 {
+		body.WriteString("(no changes)\n")
+	}
+	end := len(s.lines)
+	if height > 0 && s.offset+s.page < end {
+		end = s.offset + s.page
+	}
+	for i, line := range s.lines[s.offset:end] {
+		if n := slices.Index(s.files, s.offset+i); n >= 0 && s.rejected[s.paths[n]] {
+			body.WriteString(colorRed + "[rejected] " + colorReset)
+		}
+		body.WriteString(colorize(line) + "\n")
+	}
+
+	io.WriteString(w, header.String()+body.String()+footer.String())
+}
+
+// colorize colors a unified diff line by kind.
+func colorize(line string) string {
+	switch {
+	case strings.HasPrefix(line, "diff --git "), strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+		return colorBold + line + colorReset
+	case strings.HasPrefix(line, "@@"):
+		return colorCyan + line + colorReset
+	case strings.HasPrefix(line, "+"):
+		return colorGreen + line + colorReset
+	case strings.HasPrefix(line, "-"):
+		return colorRed + line + colorReset
+	}
+	return line
+}
+
+// RunReviewScreen drives the screen interactively until the user quits.
+func RunReviewScreen(term *Terminal, screen *ReviewScreen) error {
+	if err := term.MakeRaw(); err != nil {
+		return err
+	}
+	defer func() {
+		term.Restore()
+		term.Clear()
+	}()
+
+	for {
+		term.Clear()
+		height, _ := term.Size()
+		var b strings.Builder
+		screen.Render(&b, height-1)
+		// Raw mode does not translate newlines
+		io.WriteString(term.out, strings.ReplaceAll(b.String(), "\n", "\r\n"))
+
+		key, err := term.ReadKey()
+		if err != nil {
+			return err
+		}
+
+		switch screen.HandleKey(key) {
+		case ActionQuit:
+			return nil
+		case ActionReject:
+			reason, err := term.ReadLine("Reject reason (optional): ")
+			if err != nil {
+				return err
+			}
+			screen.Decide(DecisionReject, reason)
+		case ActionFeedback:
+			feedback, err := term.ReadLine("Feedback for the agent: ")
+			if err != nil {
+				return err
+			}
+			if feedback != "" {
+				screen.Decide(DecisionChanges, feedback)
+			}
+		}
+	}
+}
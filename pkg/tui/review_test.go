@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/approval"
+)
+
+const testDiff = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-package old
++package a
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1 +1,2 @@
+ package b
++func B() {}
+`
+
+func TestReviewScreenNavigation(t *testing.T) {
+	s := NewReviewScreen(&approval.Request{TaskID: "t-001", Diff: testDiff}, nil)
+	var b strings.Builder
+	s.Render(&b, 8)
+
+	s.HandleKey("n")
+	if s.Offset() != 6 {
+		t.Errorf("expected n to jump to the second file, got offset %d", s.Offset())
+	}
+	if !strings.Contains(b.String(), "[file 1/2: a.go]") {
+		t.Errorf("expected the first file selected:\n%s", b.String())
+	}
+	s.HandleKey("p")
+	if s.Offset() != 0 {
+		t.Errorf("expected p to jump back to the first file, got offset %d", s.Offset())
+	}
+	s.HandleKey("j")
+	if s.Offset() != 1 {
+		t.Errorf("expected j to scroll one line, got offset %d", s.Offset())
+	}
+	for i := 0; i < 20; i++ {
+		s.HandleKey(KeyDown)
+	}
+	b.Reset()
+	s.Render(&b, 8)
+	if !strings.Contains(b.String(), "+func B() {}") {
+		t.Errorf("expected scrolling to stop at the end of the diff:\n%s", b.String())
+	}
+}
+
+func TestReviewScreenDecisions(t *testing.T) {
+	var got []Decision
+	var fail error
+	var gotRejected []string
+	s := NewReviewScreen(&approval.Request{TaskID: "t-001", Diff: testDiff}, func(d Decision, text string, rejected []string) error {
+		got = append(got, d)
+		gotRejected = rejected
+		return fail
+	})
+
+	if s.HandleKey("r") != ActionReject {
+		t.Error("expected r to prompt for a reason")
+	}
+	fail = errors.New("boom")
+	s.HandleKey("a")
+	if s.Decided() {
+		t.Error("expected a failed decision to leave the review open")
+	}
+	var b strings.Builder
+	s.Render(&b, 0)
+	if !strings.Contains(b.String(), "Error: boom") {
+		t.Errorf("expected the error to be shown:\n%s", b.String())
+	}
+
+	fail = nil
+	s.HandleKey("n")
+	s.HandleKey("x")
+	s.Decide(DecisionChanges, "add tests")
+	if !s.Decided() || len(got) != 2 || got[1] != DecisionChanges || s.Decision() != DecisionChanges {
+		t.Fatalf("expected changes to be requested, got %v", got)
+	}
+	if len(gotRejected) != 1 || gotRejected[0] != "b.go" {
+		t.Errorf("expected b.go to be rejected, got %q", gotRejected)
+	}
+	if s.HandleKey("a") != ActionNone || len(got) != 2 {
+		t.Error("expected no further decisions once decided")
+	}
+}
+
+func TestReviewScreenRender(t *testing.T) {
+	s := NewReviewScreen(&approval.Request{
+		ID:      "a-001",
+		TaskID:  "t-001",
+		Summary: "Add login",
+		Diff:    testDiff,
+		Gates:   []approval.GateResult{{Name: "lint", Passed: true}},
+	}, nil)
+	var b strings.Builder
+	s.Render(&b, 0)
+	out := b.String()
+	for _, want := range []string{"Review t-001: Add login (a-001)", "[1/1 gates]", "2 files changed", colorGreen + "+package a", colorRed + "-package old"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected render to contain %q:\n%s", want, out)
+		}
+	}
+
+	empty := NewReviewScreen(&approval.Request{TaskID: "t-002"}, nil)
+	b.Reset()
+	empty.Render(&b, 10)
+	if !strings.Contains(b.String(), "(no changes)") {
+		t.Errorf("expected an empty diff to say so:\n%s", b.String())
+	}
+}
+
+func TestReviewScreenFileDecisions(t *testing.T) {
+	s := NewReviewScreen(&approval.Request{TaskID: "t-001", Diff: testDiff}, nil)
+
+	s.HandleKey("x")
+	s.HandleKey("n")
+	s.HandleKey("x")
+	s.HandleKey("y")
+	if got := s.Rejected(); len(got) != 1 || got[0] != "a.go" {
+		t.Fatalf("expected only a.go rejected, got %q", got)
+	}
+	var b strings.Builder
+	s.Render(&b, 0)
+	out := b.String()
+	for _, want := range []string{"2 files changed, 1 rejected", "[rejected] " + colorReset + colorBold + "diff --git a/a.go"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected render to contain %q:\n%s", want, out)
+		}
+	}
+}
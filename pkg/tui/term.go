@@ -0,0 +1,146 @@
+// Package tui provides lightweight interactive terminal screens.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Key is a single keypress: a printable character or a named key.
+type Key string
+
+const (
+	KeyUp    Key = "up"
+	KeyDown  Key = "down"
+	KeyEnter Key = "enter"
+	KeyEsc   Key = "esc"
+	KeyCtrlC Key = "ctrl+c"
+)
+
+// ErrNoTTY is returned when no interactive terminal is available.
+var ErrNoTTY = errors.New("no interactive terminal available")
+
+// Terminal reads single keypresses from the controlling terminal.
+type Terminal struct {
+	in     *os.File
+	reader *bufio.Reader
+	out    io.Writer
+	saved  string
+}
+
+// OpenTerminal opens the controlling terminal for interactive input.
+func OpenTerminal(out io.Writer) (*Terminal, error) {
+	in, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, ErrNoTTY
+	}
+	return &Terminal{in: in, reader: bufio.NewReader(in), out: out}, nil
+}
+
+// stty runs stty against the terminal and returns its output.
+func (t *Terminal) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.in
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s failed: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// MakeRaw switches the terminal to unbuffered, no-echo input.
+func (t *Terminal) MakeRaw() error {
+	if t.saved == "" {
+		saved, err := t.stty("-g")
+		if err != nil {
+			return err
+		}
+		t.saved = saved
+	}
+	_, err := t.stty("raw", "-echo")
+	return err
+}
+
+// Restore returns the terminal to the mode it was in before MakeRaw.
+func (t *Terminal) Restore() error {
+	if t.saved == "" {
+		return nil
+	}
+	_, err := t.stty(t.saved)
+	return err
+}
+
+// Close restores the terminal and releases it.
+func (t *Terminal) Close() error {
+	t.Restore()
+	return t.in.Close()
+}
+
+// ReadKey blocks until a key is pressed. The terminal must be in raw mode.
+func (t *Terminal) ReadKey() (Key, error) {
+	b, err := t.reader.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	switch b {
+	case 3:
+		return KeyCtrlC, nil
+	case '\r', '\n':
+		return KeyEnter, nil
+	case 27:
+		// Arrow keys arrive as ESC [ A / ESC [ B
+		if t.reader.Buffered() >= 2 {
+			seq := make([]byte, 2)
+			io.ReadFull(t.reader, seq)
+			if seq[0] == '[' {
+				switch seq[1] {
+				case 'A':
+					return KeyUp, nil
+				case 'B':
+					return KeyDown, nil
+				}
+			}
+		}
+		return KeyEsc, nil
+	}
+	return Key(string(rune(b))), nil
+}
+
+// ReadLine prompts for a line of text in cooked mode, then returns to raw mode.
+func (t *Terminal) ReadLine(prompt string) (string, error) {
+	if err := t.Restore(); err != nil {
+		return "", err
+	}
+	defer t.MakeRaw()
+
+	fmt.Fprint(t.out, prompt)
+	line, err := t.reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Size returns the terminal height and width, falling back to 24x80.
+func (t *Terminal) Size() (int, int) {
+	out, err := t.stty("size")
+	if err != nil {
+		return 24, 80
+	}
+	var rows, cols int
+	if _, err := fmt.Sscanf(out, "%d %d", &rows, &cols); err != nil || rows == 0 {
+		return 24, 80
+	}
+	return rows, cols
+}
+
+// Clear clears the screen and moves the cursor home.
+func (t *Terminal) Clear() {
+	fmt.Fprint(t.out, "\x1b[2J\x1b[H")
+}
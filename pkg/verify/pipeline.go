@@ -0,0 +1,154 @@
+// Package verify runs configurable verification steps (lint, build,
+// typecheck, custom scripts) in a task worktree.
+package verify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/events"
+	"github.com/richgo/flo/pkg/shell"
+)
+
+// CommandRunner executes a shell command in a directory and returns its combined output.
+type CommandRunner func(ctx context.Context, dir, command string) (string, error)
+
+// StepResult is the outcome of a single verification step.
+type StepResult struct {
+	Name     string        `json:"name"`
+	Command  string        `json:"command"`
+	Passed   bool          `json:"passed"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Result is the outcome of a verification pipeline.
+type Result struct {
+	Passed bool         `json:"passed"`
+	Steps  []StepResult `json:"steps"`
+}
+
+// Failed returns the steps that failed.
+func (r *Result) Failed() []StepResult {
+	var failed []StepResult
+	for _, s := range r.Steps {
+		if !s.Passed && !s.Skipped {
+			failed = append(failed, s)
+		}
+	}
+	return failed
+}
+
+// Err returns an error describing failed steps, or nil if the pipeline passed.
+func (r *Result) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	names := make([]string, len(failed))
+	for i, s := range failed {
+		names[i] = s.Name
+	}
+	return fmt.Errorf("verification failed: %s", strings.Join(names, ", "))
+}
+
+// Pipeline runs verification steps in order in a worktree.
+type Pipeline struct {
+	steps  []config.VerifyStep
+	dir    string
+	runner CommandRunner
+	// log receives the pipeline's audit events; nil uses the default
+	// logger.
+	log *audit.Logger
+}
+
+// NewPipeline creates a pipeline for the given steps and worktree.
+func NewPipeline(steps []config.VerifyStep, dir string) *Pipeline {
+	return &Pipeline{
+		steps:  steps,
+		dir:    dir,
+		runner: shell.Run,
+	}
+}
+
+// SetAuditLogger sends the pipeline's audit events to l, such as the log
+// of the workspace it belongs to.
+func (p *Pipeline) SetAuditLogger(l *audit.Logger) {
+	p.log = l
+}
+
+// SetRunner replaces the command runner (for testing).
+func (p *Pipeline) SetRunner(runner CommandRunner) {
+	p.runner = runner
+}
+
+// Empty reports whether the pipeline has no steps.
+func (p *Pipeline) Empty() bool {
+	return len(p.steps) == 0
+}
+
+// Run executes every step. A failing step stops the pipeline unless it is
+// marked continue_on_error; remaining steps are reported as skipped.
+func (p *Pipeline) Run(ctx context.Context) *Result {
+	result := &Result{Passed: true}
+	stopped := false
+
+	for _, step := range p.steps {
+		name := step.Name
+		if name == "" {
+			name = step.Command
+		}
+
+		if stopped {
+			result.Steps = append(result.Steps, StepResult{Name: name, Command: step.Command, Skipped: true})
+			continue
+		}
+
+		stepCtx := ctx
+		var cancel context.CancelFunc
+		if step.Timeout > 0 {
+			stepCtx, cancel = context.WithTimeout(ctx, step.Timeout)
+		}
+
+		start := time.Now()
+		output, err := p.runner(stepCtx, p.dir, step.Command)
+		sr := StepResult{
+			Name:     name,
+			Command:  step.Command,
+			Passed:   err == nil,
+			Output:   output,
+			Duration: time.Since(start),
+		}
+		if err != nil {
+			sr.Error = err.Error()
+			if stepCtx.Err() == context.DeadlineExceeded {
+				sr.Error = fmt.Sprintf("timed out after %s", step.Timeout)
+			}
+		}
+		if cancel != nil {
+			cancel()
+		}
+
+		result.Steps = append(result.Steps, sr)
+		if !sr.Passed {
+			result.Passed = false
+			if !step.ContinueOnError {
+				stopped = true
+			}
+		}
+
+		events.PublishTo(p.log, audit.LevelInfo, "Verification step finished", audit.VerifyStepFinished{
+			Step:     name,
+			Passed:   sr.Passed,
+			Duration: sr.Duration.String(),
+		})
+	}
+
+	return result
+}
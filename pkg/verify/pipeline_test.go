@@ -0,0 +1,110 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/config"
+)
+
+func TestPipelineAllPass(t *testing.T) {
+	p := NewPipeline([]config.VerifyStep{
+		{Name: "lint", Command: "golangci-lint run"},
+		{Name: "build", Command: "go build ./..."},
+	}, t.TempDir())
+	p.SetRunner(func(ctx context.Context, dir, command string) (string, error) {
+		return "ok: " + command, nil
+	})
+
+	result := p.Run(context.Background())
+	if !result.Passed {
+		t.Fatalf("expected pipeline to pass: %+v", result)
+	}
+	if len(result.Steps) != 2 {
+		t.Fatalf("expected 2 step results, got %d", len(result.Steps))
+	}
+	if result.Steps[1].Output != "ok: go build ./..." {
+		t.Errorf("expected step output to be attached, got %q", result.Steps[1].Output)
+	}
+	if result.Err() != nil {
+		t.Errorf("expected nil error, got %v", result.Err())
+	}
+}
+
+func TestPipelineStopsOnFailure(t *testing.T) {
+	p := NewPipeline([]config.VerifyStep{
+		{Name: "lint", Command: "lint"},
+		{Name: "build", Command: "build"},
+		{Name: "typecheck", Command: "tsc"},
+	}, t.TempDir())
+	p.SetRunner(func(ctx context.Context, dir, command string) (string, error) {
+		if command == "lint" {
+			return "lint error", errors.New("exit status 1")
+		}
+		return "", nil
+	})
+
+	result := p.Run(context.Background())
+	if result.Passed {
+		t.Fatal("expected pipeline to fail")
+	}
+	if !result.Steps[1].Skipped || !result.Steps[2].Skipped {
+		t.Error("expected remaining steps to be skipped")
+	}
+	if err := result.Err(); err == nil || !strings.Contains(err.Error(), "lint") {
+		t.Errorf("expected error naming failed step, got %v", err)
+	}
+}
+
+func TestPipelineContinueOnError(t *testing.T) {
+	p := NewPipeline([]config.VerifyStep{
+		{Name: "lint", Command: "lint", ContinueOnError: true},
+		{Name: "build", Command: "build"},
+	}, t.TempDir())
+	ran := 0
+	p.SetRunner(func(ctx context.Context, dir, command string) (string, error) {
+		ran++
+		if command == "lint" {
+			return "", errors.New("exit status 1")
+		}
+		return "", nil
+	})
+
+	result := p.Run(context.Background())
+	if ran != 2 {
+		t.Errorf("expected both steps to run, ran %d", ran)
+	}
+	if result.Passed {
+		t.Error("expected pipeline to fail overall")
+	}
+	if len(result.Failed()) != 1 {
+		t.Errorf("expected 1 failed step, got %d", len(result.Failed()))
+	}
+}
+
+func TestPipelineStepTimeout(t *testing.T) {
+	p := NewPipeline([]config.VerifyStep{
+		{Name: "slow", Command: "sleep 5", Timeout: 50 * time.Millisecond},
+	}, t.TempDir())
+
+	result := p.Run(context.Background())
+	if result.Passed {
+		t.Fatal("expected timed out step to fail")
+	}
+	if !strings.Contains(result.Steps[0].Error, "timed out") {
+		t.Errorf("expected timeout error, got %q", result.Steps[0].Error)
+	}
+}
+
+func TestEmptyPipeline(t *testing.T) {
+	p := NewPipeline(nil, t.TempDir())
+	if !p.Empty() {
+		t.Error("expected empty pipeline")
+	}
+	if !p.Run(context.Background()).Passed {
+		t.Error("expected empty pipeline to pass")
+	}
+}
@@ -0,0 +1,329 @@
+// Package webhook posts task lifecycle events to URLs teams configure, so
+// they can route them into their own systems. Deliveries are signed with
+// HMAC-SHA256 when the webhook has a secret, and retried when the receiver
+// is unavailable.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/events"
+	"github.com/richgo/flo/pkg/offline"
+)
+
+// Webhook events.
+const (
+	EventTaskComplete = "task_complete"
+	EventTaskFailed   = "task_failed"
+	EventRunComplete  = "run_complete"
+	EventBudgetAlert  = "budget_alert"
+)
+
+// Events lists every webhook event.
+var Events = []string{EventTaskComplete, EventTaskFailed, EventRunComplete, EventBudgetAlert}
+
+// Headers of a delivery.
+const (
+	HeaderEvent     = "X-Flo-Event"
+	HeaderDelivery  = "X-Flo-Delivery"
+	HeaderSignature = "X-Flo-Signature"
+)
+
+// DefaultMaxAttempts is how often a delivery is tried when the webhook
+// doesn't say.
+const DefaultMaxAttempts = 3
+
+// requestTimeout bounds each delivery attempt.
+const requestTimeout = 5 * time.Second
+
+// Hook is a URL that receives events.
+type Hook struct {
+	URL string
+	// SecretEnv names the secret deliveries are signed with; empty leaves
+	// them unsigned.
+	SecretEnv string
+	// Events are the events sent; empty sends all of them.
+	Events []string
+	// MaxAttempts is how often a delivery is tried (default 3).
+	MaxAttempts int
+}
+
+// wants reports whether the hook receives event.
+func (h Hook) wants(event string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	// ID identifies the delivery; retries send the same ID.
+	ID        string                 `json:"id"`
+	Event     string                 `json:"event"`
+	Timestamp time.Time              `json:"timestamp"`
+	Feature   string                 `json:"feature"`
+	TaskID    string                 `json:"task_id,omitempty"`
+	RunID     string                 `json:"run_id,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Sign returns the signature header value for body: "sha256=" and the
+// hex HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is body's signature with secret, for
+// receivers.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Dispatcher delivers events to webhooks in the background. Failures are
+// audited, never returned.
+type Dispatcher struct {
+	mu      sync.Mutex
+	feature string
+	hooks   []Hook
+	secret  func(name string) string
+	client  *http.Client
+	backoff time.Duration
+	pending sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher for a feature's webhooks. Secrets are
+// read from the environment until SetSecretLookup says otherwise.
+func NewDispatcher(feature string, hooks []Hook) *Dispatcher {
+	return &Dispatcher{
+		feature: feature,
+		hooks:   hooks,
+		secret:  os.Getenv,
+		client:  &http.Client{Timeout: requestTimeout},
+		backoff: time.Second,
+	}
+}
+
+// SetHooks replaces the webhooks, as when the config is reloaded.
+func (d *Dispatcher) SetHooks(feature string, hooks []Hook) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.feature, d.hooks = feature, hooks
+}
+
+// SetSecretLookup sets how secrets named by SecretEnv are resolved. The
+// lookup runs in the background, and may be slow the first time.
+func (d *Dispatcher) SetSecretLookup(lookup func(name string) string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.secret = lookup
+}
+
+// SetBackoff sets the wait before the first retry, doubling after each.
+func (d *Dispatcher) SetBackoff(backoff time.Duration) {
+	d.backoff = backoff
+}
+
+// Send delivers an event to the webhooks that want it.
+func (d *Dispatcher) Send(event, taskID, runID string, data map[string]interface{}) {
+	if d == nil {
+		return
+	}
+	// Skipped quietly rather than audited for every event
+	if offline.Enabled() {
+		return
+	}
+	d.mu.Lock()
+	hooks, feature, secret := d.hooks, d.feature, d.secret
+	d.mu.Unlock()
+
+	for _, hook := range hooks {
+		if !hook.wants(event) {
+			continue
+		}
+		payload := Payload{
+			ID:        newDeliveryID(),
+			Event:     event,
+			Timestamp: time.Now().UTC(),
+			Feature:   feature,
+			TaskID:    taskID,
+			RunID:     runID,
+			Data:      data,
+		}
+		d.pending.Add(1)
+		go func(hook Hook) {
+			defer d.pending.Done()
+			// Looked up here, as resolving secrets may log
+			key := ""
+			if hook.SecretEnv != "" {
+				key = secret(hook.SecretEnv)
+			}
+			d.deliver(hook, key, payload)
+		}(hook)
+	}
+}
+
+// Subscribe sends webhook events for the events published on bus that
+// record them, until the returned function is called.
+func (d *Dispatcher) Subscribe(bus *events.Bus) (unsubscribe func()) {
+	return bus.Subscribe(d.send)
+}
+
+// SubscribeLog is Subscribe for the events recorded in log only, such as
+// those of the workspace the webhooks are configured for.
+func (d *Dispatcher) SubscribeLog(bus *events.Bus, log *audit.Logger) (unsubscribe func()) {
+	return bus.SubscribeLog(log, d.send)
+}
+
+// send sends the webhook event e records, if any.
+func (d *Dispatcher) send(e events.Event) {
+	if event, taskID, ok := eventFor(e); ok {
+		d.Send(event, taskID, e.RunID, audit.Details(e.Data))
+	}
+}
+
+// eventFor maps an event to the webhook event it records, if any, and its
+// task.
+func eventFor(e events.Event) (event, taskID string, ok bool) {
+	switch data := e.Data.(type) {
+	case audit.StatusTransition:
+		// Refused transitions are warnings
+		if e.Level != audit.LevelInfo {
+			return "", "", false
+		}
+		switch data.To {
+		case "complete":
+			return EventTaskComplete, data.TaskID, true
+		case "failed":
+			return EventTaskFailed, data.TaskID, true
+		}
+	case audit.RunFinished:
+		return EventRunComplete, data.TaskID, true
+	case audit.BudgetExceeded:
+		return EventBudgetAlert, data.TaskID, true
+	case audit.BudgetOverridden:
+		return EventBudgetAlert, data.TaskID, true
+	}
+	return "", "", false
+}
+
+// Wait waits up to timeout for deliveries in progress, and reports whether
+// they all finished.
+func (d *Dispatcher) Wait(timeout time.Duration) bool {
+	if d == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		d.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// deliver posts payload to hook, retrying while the receiver is
+// unavailable.
+func (d *Dispatcher) deliver(hook Hook, secret string, payload Payload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	attempts := hook.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultMaxAttempts
+	}
+
+	backoff := d.backoff
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		retry, err := d.post(hook.URL, secret, payload, body)
+		if err == nil {
+			return
+		}
+		lastErr = err
+		if !retry || attempt == attempts {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	audit.Warn("webhook.deliver", "Failed to deliver webhook", map[string]interface{}{
+		"url":      redactURL(hook.URL),
+		"event":    payload.Event,
+		"delivery": payload.ID,
+		"error":    lastErr.Error(),
+	})
+}
+
+// post makes one delivery attempt, reporting whether a failure is worth
+// retrying: network errors, 429 and 5xx responses are.
+func (d *Dispatcher) post(target, secret string, payload Payload, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "flo-webhook")
+	req.Header.Set(HeaderEvent, payload.Event)
+	req.Header.Set(HeaderDelivery, payload.ID)
+	if secret != "" {
+		req.Header.Set(HeaderSignature, Sign(secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("receiver returned %s", resp.Status)
+}
+
+// redactURL drops the query and credentials of a webhook URL, which may
+// carry tokens, for the audit log.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(invalid url)"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "whd-" + hex.EncodeToString(b)
+}
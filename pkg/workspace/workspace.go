@@ -2,14 +2,36 @@
 package workspace
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/richgo/flo/pkg/approval"
 	"github.com/richgo/flo/pkg/audit"
 	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/events"
+	"github.com/richgo/flo/pkg/flags"
+	"github.com/richgo/flo/pkg/gate"
+	"github.com/richgo/flo/pkg/cost"
+	"github.com/richgo/flo/pkg/health"
+	"github.com/richgo/flo/pkg/issues"
+	"github.com/richgo/flo/pkg/policy"
+	"github.com/richgo/flo/pkg/prompt"
+	"github.com/richgo/flo/pkg/repos"
+	"github.com/richgo/flo/pkg/seal"
+	"github.com/richgo/flo/pkg/spec"
 	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/tdd"
+	"github.com/richgo/flo/pkg/telemetry"
+	"github.com/richgo/flo/pkg/transcript"
+	"github.com/richgo/flo/pkg/verify"
+	"github.com/richgo/flo/pkg/webhook"
 )
 
 const (
@@ -18,8 +40,22 @@ const (
 	specFile    = "SPEC.md"
 	tasksDir    = "tasks"
 	manifestFile = "manifest.json"
+	transcriptsDir = "transcripts"
+	keyFile      = "keys/workspace.key"
+	approvalsFile = "approvals.json"
+	promptsDir   = "prompts"
+	specHistoryDir = "spec-history"
+	toolsDir     = "tools"
+	healthFile   = "health.json"
+	costsFile    = "costs.json"
+	reportsDir   = "reports"
+	runsDir      = "runs"
+	reposDir     = "repos"
 )
 
+// ErrNoWorkspace is returned by Load for a directory without a workspace.
+var ErrNoWorkspace = errors.New("no workspace found")
+
 // Workspace represents an EAS feature workspace.
 type Workspace struct {
 	Root     string
@@ -27,7 +63,24 @@ type Workspace struct {
 	Backend  string
 	Config   *config.Config
 	Tasks    *task.Registry
+	// Audit writes the workspace's audit log, .flo/audit.log.
+	Audit *audit.Logger
+	// Telemetry exports traces and metrics when telemetry is configured,
+	// and is nil otherwise.
+	Telemetry *telemetry.Provider
+	// Webhooks posts task, run and budget events to the URLs in
+	// webhooks.
+	Webhooks *webhook.Dispatcher
+	// Issues pushes task status to the tracker issues tasks were imported
+	// from, for trackers with sync set.
+	Issues *issues.Syncer
+	// FullTests makes the TDD gate run every test even when tdd.affected
+	// selects only the affected ones.
+	FullTests bool
 	nextID   int
+	// unsubscribe stops Webhooks receiving events; nil until a
+	// webhook is configured.
+	unsubscribe func()
 }
 
 // Status holds workspace status information.
@@ -40,10 +93,28 @@ type Status struct {
 	CompleteTasks  int
 	FailedTasks    int
 	ReadyTasks     int
+	CriteriaTotal  int
+	CriteriaMet    int
+	// StaleTasks counts tasks whose spec section changed since they were created.
+	StaleTasks     int
+}
+
+// StaleTask is a task whose referenced spec section changed after its
+// baseline spec version.
+type StaleTask struct {
+	Task *task.Task
+	// Since is the spec version the task was created against.
+	Since spec.Version
 }
 
 // Init initializes a new workspace in the given directory.
 func Init(root, feature, backend string) (*Workspace, error) {
+	return InitWithTemplate(root, feature, backend, spec.DefaultTemplateName)
+}
+
+// InitWithTemplate initializes a new workspace, writing SPEC.md from the
+// named spec template.
+func InitWithTemplate(root, feature, backend, templateName string) (*Workspace, error) {
 	easPath := filepath.Join(root, easDir)
 	
 	// Check if already initialized
@@ -51,42 +122,42 @@ func Init(root, feature, backend string) (*Workspace, error) {
 		return nil, fmt.Errorf("workspace already initialized at %s", root)
 	}
 
+	// Render the spec first so an unknown template leaves nothing behind
+	cfg := config.New(feature)
+	cfg.Backend = backend
+	specContent, err := spec.DefaultTemplateLibrary().Render(templateName, spec.TemplateData{
+		Feature:  feature,
+		Sections: cfg.Spec.RequiredSections,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// Create directory structure
 	if err := os.MkdirAll(filepath.Join(easPath, tasksDir), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create workspace: %w", err)
 	}
 
 	// Create config
-	cfg := config.New(feature)
-	cfg.Backend = backend
 	if err := cfg.Save(filepath.Join(easPath, configFile)); err != nil {
 		return nil, fmt.Errorf("failed to save config: %w", err)
 	}
 
-	// Create SPEC.md template
-	specContent := fmt.Sprintf(`# Feature: %s
-
-## Overview
-
-_Describe the feature here._
-
-## User Stories
-
-1. As a user, I can...
-
-## Acceptance Criteria
-
-- [ ] Criterion 1
-- [ ] Criterion 2
-
-## Technical Notes
-
-_Add technical details here._
-`, feature)
+	// Create SPEC.md from the template
 	if err := os.WriteFile(filepath.Join(easPath, specFile), []byte(specContent), 0644); err != nil {
 		return nil, fmt.Errorf("failed to create SPEC.md: %w", err)
 	}
 
+	// Snapshot the initial spec as v1
+	if _, _, err := spec.NewHistory(filepath.Join(easPath, specHistoryDir)).Commit(specContent, "Initial spec"); err != nil {
+		return nil, err
+	}
+
+	// Keep local secrets and lock files out of version control
+	if err := os.WriteFile(filepath.Join(easPath, ".gitignore"), []byte("keys/\n.env\n*.lock\nrepos/\n"), 0644); err != nil {
+		return nil, fmt.Errorf("failed to create .gitignore: %w", err)
+	}
+
 	// Create empty task registry
 	taskReg := task.NewRegistry()
 	if err := taskReg.Save(filepath.Join(easPath, tasksDir, manifestFile)); err != nil {
@@ -94,25 +165,95 @@ _Add technical details here._
 	}
 
 	// Initialize audit logger
-	if err := audit.Init(root); err != nil {
-		// Log initialization failure but don't fail workspace init
+	logger := openAuditLog(root, cfg)
+	taskReg.SetAuditLogger(logger)
+	tracer := newTelemetry(cfg)
+	taskReg.SetTelemetry(tracer)
+	events.PublishTo(logger, audit.LevelInfo, "Workspace initialized", audit.WorkspaceInitialized{
+		Feature: feature,
+		Backend: backend,
+		Root:    root,
+	})
+
+	w := &Workspace{
+		Root:      root,
+		Feature:   feature,
+		Backend:   backend,
+		Config:    cfg,
+		Tasks:     taskReg,
+		Audit:     logger,
+		Telemetry: tracer,
+		Webhooks:  webhook.NewDispatcher(feature, nil),
+		Issues:    issues.NewSyncer(),
+		nextID:    1,
+	}
+	w.setWebhooks()
+	w.setIssueSync()
+	return w, nil
+}
+
+// openAuditLog opens the workspace's audit log and makes it the default
+// for package-level audit calls, so the workspace loaded last receives
+// them. A log that can't be opened is reported and events are discarded.
+// With logs.tamper_evident, events are chained and checkpoints signed with
+// the workspace key.
+func openAuditLog(root string, cfg *config.Config) *audit.Logger {
+	logger, err := audit.NewLogger(root)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to initialize audit log: %v\n", err)
-	} else {
-		audit.Info("workspace.init", "Workspace initialized", map[string]interface{}{
-			"feature": feature,
-			"backend": backend,
-			"root":    root,
-		})
+		return nil
+	}
+	if cfg.Logs.TamperEvident {
+		key, err := seal.LoadOrCreateKey(filepath.Join(root, easDir, keyFile))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: audit checkpoints will be unsigned: %v\n", err)
+		}
+		logger.EnableChain(key)
 	}
+	audit.SetDefault(logger)
+	return logger
+}
 
-	return &Workspace{
-		Root:    root,
-		Feature: feature,
-		Backend: backend,
-		Config:  cfg,
-		Tasks:   taskReg,
-		nextID:  1,
-	}, nil
+// VerifyAudit checks the workspace's chained audit log for tampering,
+// checking checkpoint signatures when the workspace key is available.
+func (w *Workspace) VerifyAudit() (*audit.VerifyReport, error) {
+	key, err := seal.LoadKey(w.KeyPath())
+	if err != nil && !errors.Is(err, seal.ErrNoKey) {
+		return nil, err
+	}
+	return audit.Verify(w.Root, key)
+}
+
+// newTelemetry returns the telemetry provider cfg configures, or nil.
+func newTelemetry(cfg *config.Config) *telemetry.Provider {
+	return telemetry.New(cfg.Telemetry.WithEnv(), telemetry.String("flo.feature", cfg.Feature))
+}
+
+// telemetryFlushTimeout bounds exporting telemetry when a workspace is
+// closed.
+const telemetryFlushTimeout = 5 * time.Second
+
+// webhookFlushTimeout bounds waiting for webhook deliveries, with their
+// retries, and issue status pushes when a workspace is closed.
+const webhookFlushTimeout = 20 * time.Second
+
+// Close finishes webhook deliveries and issue status pushes, exports outstanding telemetry and
+// closes the workspace's audit log, which stops being the default.
+func (w *Workspace) Close() error {
+	if w.unsubscribe != nil {
+		w.unsubscribe()
+		w.unsubscribe = nil
+	}
+	w.Webhooks.Wait(webhookFlushTimeout)
+	w.Issues.Wait(webhookFlushTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+	defer cancel()
+	flushErr := w.Telemetry.Flush(ctx)
+	if audit.Default() == w.Audit {
+		audit.SetDefault(nil)
+	}
+	return errors.Join(flushErr, w.Audit.Close())
 }
 
 // Load loads an existing workspace from the given directory.
@@ -121,7 +262,7 @@ func Load(root string) (*Workspace, error) {
 	
 	// Check if initialized
 	if _, err := os.Stat(easPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("no workspace found at %s", root)
+		return nil, fmt.Errorf("%w at %s", ErrNoWorkspace, root)
 	}
 
 	// Load config
@@ -130,8 +271,14 @@ func Load(root string) (*Workspace, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// Initialize audit logger
+	logger := openAuditLog(root, cfg)
+	tracer := newTelemetry(cfg)
+
 	// Load task registry
 	taskReg := task.NewRegistry()
+	taskReg.SetAuditLogger(logger)
+	taskReg.SetTelemetry(tracer)
 	manifestPath := filepath.Join(easPath, tasksDir, manifestFile)
 	if _, err := os.Stat(manifestPath); err == nil {
 		if err := taskReg.Load(manifestPath); err != nil {
@@ -140,8 +287,108 @@ func Load(root string) (*Workspace, error) {
 	}
 
 	// Find highest task ID for next ID generation
+	nextID := nextTaskID(taskReg)
+
+	events.PublishTo(logger, audit.LevelInfo, "Workspace loaded", audit.WorkspaceLoaded{
+		Feature:   cfg.Feature,
+		Backend:   cfg.Backend,
+		TaskCount: len(taskReg.List()),
+	})
+
+	w := &Workspace{
+		Root:      root,
+		Feature:   cfg.Feature,
+		Backend:   cfg.Backend,
+		Config:    cfg,
+		Tasks:     taskReg,
+		Audit:     logger,
+		Telemetry: tracer,
+		Webhooks:  webhook.NewDispatcher(cfg.Feature, nil),
+		Issues:    issues.NewSyncer(),
+		nextID:    nextID,
+	}
+	w.setWebhooks()
+	w.setIssueSync()
+	return w, nil
+}
+
+// setWebhooks points Webhooks at the configured webhooks, subscribing it
+// to the workspace's published events once there are any.
+func (w *Workspace) setWebhooks() {
+	hooks := make([]webhook.Hook, len(w.Config.Webhooks))
+	for i, h := range w.Config.Webhooks {
+		hooks[i] = webhook.Hook{
+			URL:         h.URL,
+			SecretEnv:   h.SecretEnv,
+			Events:      h.Events,
+			MaxAttempts: h.MaxAttempts,
+		}
+	}
+	w.Webhooks.SetHooks(w.Config.Feature, hooks)
+	if len(hooks) > 0 && w.unsubscribe == nil {
+		w.unsubscribe = w.Webhooks.SubscribeLog(events.Default(), w.Audit)
+	}
+}
+
+// GitHubTracker returns the tracker for the configured GitHub issues, or
+// nil when there are none.
+func (w *Workspace) GitHubTracker() *issues.GitHub {
+	g := w.Config.Issues.GitHub
+	if g == nil {
+		return nil
+	}
+	tokenEnv := g.Token()
+	tracker := issues.NewGitHub(g.Repo, g.Labels, func() string {
+		return w.Issues.Secret(tokenEnv)
+	})
+	if g.URL != "" {
+		tracker.SetBaseURL(g.URL)
+	}
+	return tracker
+}
+
+// LinearTracker returns the tracker for the configured Linear issues, or
+// nil when there are none.
+func (w *Workspace) LinearTracker() *issues.Linear {
+	l := w.Config.Issues.Linear
+	if l == nil {
+		return nil
+	}
+	tokenEnv := l.Token()
+	tracker := issues.NewLinear(issues.LinearFilter{
+		Team:    l.Team,
+		Project: l.Project,
+		Labels:  l.Labels,
+	}, func() string {
+		return w.Issues.Secret(tokenEnv)
+	})
+	tracker.SetStates(l.States)
+	if l.URL != "" {
+		tracker.SetURL(l.URL)
+	}
+	return tracker
+}
+
+// setIssueSync points Issues at the trackers whose issues follow their
+// tasks' status.
+func (w *Workspace) setIssueSync() {
+	if g := w.Config.Issues.GitHub; g != nil && g.Sync {
+		w.Issues.SetTracker("github", w.GitHubTracker())
+	} else {
+		w.Issues.SetTracker("github", nil)
+	}
+	if l := w.Config.Issues.Linear; l != nil && l.Sync {
+		w.Issues.SetTracker("linear", w.LinearTracker())
+	} else {
+		w.Issues.SetTracker("linear", nil)
+	}
+}
+
+// nextTaskID returns the number of the next task ID, after the highest in
+// reg.
+func nextTaskID(reg *task.Registry) int {
 	nextID := 1
-	for _, t := range taskReg.List() {
+	for _, t := range reg.List() {
 		var id int
 		if _, err := fmt.Sscanf(t.ID, "t-%d", &id); err == nil {
 			if id >= nextID {
@@ -149,27 +396,30 @@ func Load(root string) (*Workspace, error) {
 			}
 		}
 	}
+	return nextID
+}
 
-	// Initialize audit logger
-	if err := audit.Init(root); err != nil {
-		// Log initialization failure but don't fail workspace load
-		fmt.Fprintf(os.Stderr, "Warning: failed to initialize audit log: %v\n", err)
-	} else {
-		audit.Info("workspace.load", "Workspace loaded", map[string]interface{}{
-			"feature":    cfg.Feature,
-			"backend":    cfg.Backend,
-			"task_count": len(taskReg.List()),
-		})
+// Reload re-reads the config and task manifest, picking up changes other
+// flo processes made since the workspace was loaded.
+func (w *Workspace) Reload() error {
+	easPath := filepath.Join(w.Root, easDir)
+	cfg, err := config.Load(filepath.Join(easPath, configFile))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
 	}
-
-	return &Workspace{
-		Root:    root,
-		Feature: cfg.Feature,
-		Backend: cfg.Backend,
-		Config:  cfg,
-		Tasks:   taskReg,
-		nextID:  nextID,
-	}, nil
+	manifestPath := filepath.Join(easPath, tasksDir, manifestFile)
+	if _, err := os.Stat(manifestPath); err == nil {
+		if err := w.Tasks.Load(manifestPath); err != nil {
+			return fmt.Errorf("failed to load tasks: %w", err)
+		}
+	}
+	w.Config = cfg
+	w.Feature = cfg.Feature
+	w.Backend = cfg.Backend
+	w.nextID = nextTaskID(w.Tasks)
+	w.setWebhooks()
+	w.setIssueSync()
+	return nil
 }
 
 // Save persists the workspace state.
@@ -177,20 +427,20 @@ func (w *Workspace) Save() error {
 	easPath := filepath.Join(w.Root, easDir)
 	
 	if err := w.Config.Save(filepath.Join(easPath, configFile)); err != nil {
-		audit.Error("workspace.save", "Failed to save config", map[string]interface{}{
+		w.Audit.Error("workspace.save", "Failed to save config", map[string]interface{}{
 			"error": err.Error(),
 		})
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 	
 	if err := w.Tasks.Save(filepath.Join(easPath, tasksDir, manifestFile)); err != nil {
-		audit.Error("workspace.save", "Failed to save tasks", map[string]interface{}{
+		w.Audit.Error("workspace.save", "Failed to save tasks", map[string]interface{}{
 			"error": err.Error(),
 		})
 		return fmt.Errorf("failed to save tasks: %w", err)
 	}
 	
-	audit.Info("workspace.save", "Workspace saved", map[string]interface{}{
+	w.Audit.Info("workspace.save", "Workspace saved", map[string]interface{}{
 		"task_count": len(w.Tasks.List()),
 	})
 	
@@ -204,16 +454,32 @@ func (w *Workspace) CreateTask(title, repo string, deps []string, priority int)
 
 // CreateTaskWithType creates a new task with a specific type.
 func (w *Workspace) CreateTaskWithType(title, taskType, repo string, deps []string, priority int) (*task.Task, error) {
+	return w.createTask(title, taskType, repo, "", deps, priority)
+}
+
+// CreateSubtask creates a task that is a subtask of parent.
+func (w *Workspace) CreateSubtask(parent, title, taskType, repo string, deps []string, priority int) (*task.Task, error) {
+	if _, err := w.Tasks.Get(parent); err != nil {
+		return nil, fmt.Errorf("parent: %w", err)
+	}
+	return w.createTask(title, taskType, repo, parent, deps, priority)
+}
+
+func (w *Workspace) createTask(title, taskType, repo, parent string, deps []string, priority int) (*task.Task, error) {
+	if err := w.checkWritable(); err != nil {
+		return nil, err
+	}
 	id := fmt.Sprintf("t-%03d", w.nextID)
 	w.nextID++
 
 	t := task.New(id, title)
 	t.Repo = repo
 	t.Deps = deps
+	t.Parent = parent
 	t.Priority = priority
 	t.Type = taskType
-	t.CreatedAt = time.Now()
-	t.UpdatedAt = time.Now()
+	t.CreatedAt = time.Now().UTC()
+	t.UpdatedAt = t.CreatedAt
 
 	// Set model based on task type
 	if taskType != "" && w.Config.TaskTypes != nil {
@@ -224,45 +490,251 @@ func (w *Workspace) CreateTaskWithType(title, taskType, repo string, deps []stri
 
 	if err := w.Tasks.Add(t); err != nil {
 		w.nextID-- // Rollback ID
-		audit.Error("workspace.create_task", "Failed to add task", map[string]interface{}{
-			"task_id": id,
-			"title":   title,
-			"error":   err.Error(),
+		events.PublishTo(w.Audit, audit.LevelError, "Failed to add task", audit.TaskCreated{
+			TaskID: id,
+			Title:  title,
+			Error:  err.Error(),
 		})
 		return nil, err
 	}
 
 	// Write task.md file
 	if err := w.writeTaskFile(t); err != nil {
-		audit.Error("workspace.create_task", "Failed to write task file", map[string]interface{}{
-			"task_id": id,
-			"error":   err.Error(),
+		events.PublishTo(w.Audit, audit.LevelError, "Failed to write task file", audit.TaskCreated{
+			TaskID: id,
+			Error:  err.Error(),
 		})
 		// Don't fail the task creation if file write fails
 	}
 
 	// Auto-save
 	if err := w.Save(); err != nil {
-		audit.Error("workspace.create_task", "Failed to save after task creation", map[string]interface{}{
-			"task_id": id,
-			"error":   err.Error(),
+		events.PublishTo(w.Audit, audit.LevelError, "Failed to save after task creation", audit.TaskCreated{
+			TaskID: id,
+			Error:  err.Error(),
 		})
 		return nil, err
 	}
 
-	audit.Info("workspace.create_task", "Task created", map[string]interface{}{
-		"task_id":  id,
-		"title":    title,
-		"type":     taskType,
-		"model":    t.Model,
-		"repo":     repo,
-		"deps":     deps,
-		"priority": priority,
+	events.PublishTo(w.Audit, audit.LevelInfo, "Task created", audit.TaskCreated{
+		TaskID:   id,
+		Title:    title,
+		Type:     taskType,
+		Model:    t.Model,
+		Repo:     repo,
+		Deps:     deps,
+		Parent:   parent,
+		Priority: priority,
 	})
 
 	return t, nil
 }
 
+// DefaultPriority returns the priority for a new task given without one:
+// its parent's when priorities.inherit is set, else its type's default,
+// else priorities.default.
+func (w *Workspace) DefaultPriority(taskType, parent string) int {
+	if parent != "" && w.Config.Priorities.Inherit {
+		if p, err := w.Tasks.Get(parent); err == nil {
+			return p.Priority
+		}
+	}
+	if tt, ok := w.Config.TaskTypes[taskType]; ok && tt.Priority != nil {
+		return *tt.Priority
+	}
+	return w.Config.Priorities.Default
+}
+
+// EffectivePriority returns the priority a task is scheduled with and,
+// when that isn't its own, why. With priorities.cap set a subtask is never
+// scheduled ahead of its parent.
+func (w *Workspace) EffectivePriority(t *task.Task) (int, string) {
+	priority, reason := t.Priority, ""
+	if !w.Config.Priorities.Cap {
+		return priority, reason
+	}
+	seen := map[string]bool{t.ID: true}
+	for id := t.Parent; id != "" && !seen[id]; {
+		seen[id] = true
+		parent, err := w.Tasks.Get(id)
+		if err != nil {
+			break
+		}
+		if parent.Priority > priority {
+			priority = parent.Priority
+			reason = fmt.Sprintf("capped at parent %s", parent.ID)
+		}
+		id = parent.Parent
+	}
+	return priority, reason
+}
+
+// checkWritable fails if the manifest was written by a newer flo whose
+// schema this version can't safely rewrite.
+func (w *Workspace) checkWritable() error {
+	if w.Tasks.ReadOnly() {
+		return &task.SchemaError{Found: w.Tasks.Schema(), Supported: task.SchemaVersion}
+	}
+	return nil
+}
+
+// TaskTypeNames returns the configured task type names.
+func (w *Workspace) TaskTypeNames() []string {
+	var names []string
+	for name := range w.Config.TaskTypes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// ApplyProposal creates registry tasks for a reviewed spec breakdown,
+// translating proposal keys into task IDs. It returns the created tasks.
+func (w *Workspace) ApplyProposal(p *spec.Proposal) ([]*task.Task, error) {
+	if err := w.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(w.TaskTypeNames()); err != nil {
+		return nil, err
+	}
+	ordered, err := p.Ordered()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]string)
+	var created []*task.Task
+	for _, pt := range ordered {
+		var deps []string
+		for _, dep := range pt.Deps {
+			deps = append(deps, ids[dep])
+		}
+
+		t, err := w.CreateTaskWithType(pt.Title, pt.Type, "", deps, pt.Priority)
+		if err != nil {
+			return created, fmt.Errorf("failed to create task '%s': %w", pt.Title, err)
+		}
+		if pt.Description != "" {
+			t.Description = pt.Description
+			if err := w.Tasks.Update(t); err != nil {
+				return created, err
+			}
+			w.writeTaskFile(t)
+		}
+		ids[pt.Key] = t.ID
+		created = append(created, t)
+	}
+
+	if err := w.Save(); err != nil {
+		return created, err
+	}
+	w.Audit.Info("workspace.apply_proposal", "Spec breakdown applied", map[string]interface{}{
+		"tasks": len(created),
+	})
+	return created, nil
+}
+
+// ValidateSpecRef checks that ref (e.g. "SPEC.md#oauth") names a section of
+// the workspace spec. An empty ref is valid.
+func (w *Workspace) ValidateSpecRef(ref string) error {
+	if ref == "" {
+		return nil
+	}
+	content, err := w.ReadSpec()
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+	return spec.ValidateRef(ref, content)
+}
+
+// ValidateCriteria checks that ids name acceptance criteria in the spec.
+func (w *Workspace) ValidateCriteria(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	content, err := w.ReadSpec()
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+	return spec.ValidateCriteria(ids, content)
+}
+
+// UpdateTask validates and saves changes made to a task.
+func (w *Workspace) UpdateTask(t *task.Task) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+	if err := w.ValidateSpecRef(t.SpecRef); err != nil {
+		return err
+	}
+	if err := w.ValidateCriteria(t.Criteria); err != nil {
+		return err
+	}
+	if t.SpecRef == "" {
+		t.SpecVersion = 0
+	} else if t.SpecVersion == 0 {
+		// Record the spec the ref was checked against so later edits to
+		// the section can be flagged.
+		v, _, err := w.CommitSpec("")
+		if err != nil {
+			return err
+		}
+		t.SpecVersion = v.Number
+	}
+	t.UpdatedAt = time.Now().UTC()
+	if err := w.Tasks.Update(t); err != nil {
+		return err
+	}
+	if err := w.writeTaskFile(t); err != nil {
+		w.Audit.Error("workspace.update_task", "Failed to write task file", map[string]interface{}{
+			"task_id": t.ID,
+			"error":   err.Error(),
+		})
+	}
+	if err := w.Save(); err != nil {
+		return err
+	}
+
+	w.Audit.Info("workspace.update_task", "Task updated", map[string]interface{}{
+		"task_id":  t.ID,
+		"spec_ref": t.SpecRef,
+		"criteria": t.Criteria,
+	})
+	return nil
+}
+
+// CriteriaProgress reports acceptance criteria completion from SPEC.md
+// checkboxes and the tasks referencing each criterion.
+func (w *Workspace) CriteriaProgress() (*spec.Progress, error) {
+	content, err := w.ReadSpec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	refs := make(map[string][]string)
+	complete := make(map[string]bool)
+	for _, t := range w.Tasks.List() {
+		if len(t.Criteria) > 0 {
+			refs[t.ID] = t.Criteria
+		}
+		complete[t.ID] = t.Status == task.StatusComplete
+	}
+	return spec.CriteriaProgress(content, refs, complete), nil
+}
+
+// SpecCoverage reports which spec sections are referenced by tasks.
+func (w *Workspace) SpecCoverage() ([]spec.SectionCoverage, error) {
+	content, err := w.ReadSpec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	refs := make(map[string]string)
+	for _, t := range w.Tasks.List() {
+		if t.SpecRef != "" {
+			refs[t.ID] = t.SpecRef
+		}
+	}
+	return spec.Coverage(content, refs), nil
+}
+
 // GetTask returns a task by ID.
 func (w *Workspace) GetTask(id string) (*task.Task, error) {
 	return w.Tasks.Get(id)
@@ -289,20 +761,60 @@ func (w *Workspace) ListTasks(status, repo string) []*task.Task {
 	return w.Tasks.List()
 }
 
-// GetReadyTasks returns tasks that are ready to be worked on.
+// GetReadyTasks returns tasks that are ready to be worked on, highest
+// effective priority first, then by ID.
 func (w *Workspace) GetReadyTasks() []*task.Task {
-	return w.Tasks.GetReady()
+	ready := w.Tasks.GetReady()
+	priorities := make(map[string]int, len(ready))
+	for _, t := range ready {
+		priorities[t.ID], _ = w.EffectivePriority(t)
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		if pi, pj := priorities[ready[i].ID], priorities[ready[j].ID]; pi != pj {
+			return pi < pj
+		}
+		return ready[i].ID < ready[j].ID
+	})
+	return ready
 }
 
 // SetTaskStatus updates the status of a task and saves.
 func (w *Workspace) SetTaskStatus(id string, status string) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
 	t, err := w.Tasks.Get(id)
 	if err != nil {
 		return err
 	}
 	
 	oldStatus := t.Status
-	if err := t.SetStatus(task.Status(status)); err != nil {
+	if task.Status(status) == task.StatusInProgress && oldStatus != task.StatusInProgress {
+		if err := w.Tasks.CheckMutex(t); err != nil {
+			return err
+		}
+	}
+	if task.Status(status) == task.StatusComplete && oldStatus != task.StatusComplete && w.Config.TDD.Enforce {
+		if err := w.checkTDD(t); err != nil {
+			return err
+		}
+	}
+	if task.Status(status) == task.StatusComplete && oldStatus != task.StatusComplete {
+		if err := w.checkVerify(t); err != nil {
+			return err
+		}
+		if err := w.checkGates(t); err != nil {
+			return err
+		}
+		if err := w.CheckPolicy(t, nil); err != nil {
+			return fmt.Errorf("cannot complete task %s: %w", t.ID, err)
+		}
+		if err := w.CheckReview(t); err != nil {
+			return fmt.Errorf("cannot complete task %s: %w", t.ID, err)
+		}
+	}
+
+	if err := w.Tasks.SetStatus(t, task.Status(status)); err != nil {
 		return err
 	}
 	
@@ -314,61 +826,949 @@ func (w *Workspace) SetTaskStatus(id string, status string) error {
 		return err
 	}
 	
-	audit.Info("workspace.task_status", "Task status changed", map[string]interface{}{
-		"task_id":    id,
-		"old_status": oldStatus,
-		"new_status": status,
+	events.PublishTo(w.Audit, audit.LevelInfo, "Task status changed", audit.StatusChanged{
+		TaskID:    id,
+		OldStatus: string(oldStatus),
+		NewStatus: status,
 	})
+	w.Issues.Push(t.Issue, t.Status)
 	
 	return nil
 }
 
-// Status returns the current workspace status.
-func (w *Workspace) Status() *Status {
-	tasks := w.Tasks.List()
-	
-	status := &Status{
-		Feature:    w.Feature,
-		Backend:    w.Backend,
-		TotalTasks: len(tasks),
+// ImportResult lists the tasks ImportIssues created and updated.
+type ImportResult struct {
+	Created []*task.Task
+	Updated []*task.Task
+}
+
+// ImportIssues creates a task for each issue not imported before and
+// refreshes the title, description and assignees of those that were. A
+// label naming a task type sets the type, and a "priority:N" or "pN" label
+// or else the tracker's priority sets the priority. Issues an issue depends on become the task's dependencies
+// when they are imported too.
+func (w *Workspace) ImportIssues(list []issues.Issue) (*ImportResult, error) {
+	if err := w.checkWritable(); err != nil {
+		return nil, err
+	}
+	byRef := make(map[string]*task.Task)
+	for _, t := range w.Tasks.List() {
+		if t.Issue != "" {
+			byRef[t.Issue] = t
+		}
 	}
 
-	for _, t := range tasks {
-		switch t.Status {
-		case task.StatusPending:
-			status.PendingTasks++
-		case task.StatusInProgress:
-			status.InProgressTasks++
-		case task.StatusComplete:
-			status.CompleteTasks++
-		case task.StatusFailed:
-			status.FailedTasks++
+	result := &ImportResult{}
+	for _, is := range list {
+		description := issueDescription(is)
+		if t, ok := byRef[is.Ref]; ok {
+			if t.Title == is.Title && t.Description == description && slices.Equal(t.Assignees, is.Assignees) {
+				continue
+			}
+			t.Title, t.Description, t.Assignees = is.Title, description, is.Assignees
+			t.UpdatedAt = time.Now().UTC()
+			if err := w.Tasks.Update(t); err != nil {
+				return result, fmt.Errorf("failed to update task for %s: %w", is.Ref, err)
+			}
+			w.writeTaskFile(t)
+			result.Updated = append(result.Updated, t)
+			continue
+		}
+
+		m := issues.MapLabels(is.Labels, w.TaskTypeNames())
+		priority := w.DefaultPriority(m.Type, "")
+		if m.HasPriority {
+			priority = m.Priority
+		} else if is.Priority > 0 {
+			priority = is.Priority
 		}
+		t, err := w.createTask(is.Title, m.Type, "", "", nil, priority)
+		if err != nil {
+			return result, fmt.Errorf("failed to import %s: %w", is.Ref, err)
+		}
+		t.Description, t.Issue, t.Assignees = description, is.Ref, is.Assignees
+		if err := w.Tasks.Update(t); err != nil {
+			return result, err
+		}
+		w.writeTaskFile(t)
+		byRef[is.Ref] = t
+		result.Created = append(result.Created, t)
 	}
 
-	status.ReadyTasks = len(w.GetReadyTasks())
+	// Link dependencies once every issue has its task
+	for _, is := range list {
+		t := byRef[is.Ref]
+		linked := *t
+		linked.Deps = slices.Clone(t.Deps)
+		for _, ref := range is.Links {
+			if dep, ok := byRef[ref]; ok && dep.ID != t.ID && !slices.Contains(linked.Deps, dep.ID) {
+				linked.Deps = append(linked.Deps, dep.ID)
+			}
+		}
+		if len(linked.Deps) == len(t.Deps) {
+			continue
+		}
+		if err := w.Tasks.Update(&linked); err != nil {
+			w.Audit.Warn("workspace.import_issues", "Skipped issue dependencies", map[string]interface{}{
+				"task_id": t.ID,
+				"issue":   is.Ref,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		t.Deps = linked.Deps
+		byRef[is.Ref] = &linked
+		w.writeTaskFile(&linked)
+	}
 
-	return status
+	if err := w.Save(); err != nil {
+		return result, err
+	}
+	w.Audit.Info("workspace.import_issues", "Issues imported", map[string]interface{}{
+		"issues":  len(list),
+		"created": len(result.Created),
+		"updated": len(result.Updated),
+	})
+	return result, nil
 }
 
-// SpecPath returns the path to the SPEC.md file.
-func (w *Workspace) SpecPath() string {
-	return filepath.Join(w.Root, easDir, specFile)
+// issueDescription is the description of a task imported from is: the
+// issue body and a link back to it.
+func issueDescription(is issues.Issue) string {
+	description := strings.TrimSpace(is.Body)
+	if is.URL != "" {
+		if description != "" {
+			description += "\n\n"
+		}
+		description += "Imported from " + is.URL
+	}
+	return description
 }
 
-// ReadSpec reads the SPEC.md contents.
-func (w *Workspace) ReadSpec() (string, error) {
-	data, err := os.ReadFile(w.SpecPath())
+// RetryTask sends a failed task back to pending. The task runs on model
+// when it is set; otherwise it is escalated to the configured stronger
+// model once it has failed escalation.after times.
+func (w *Workspace) RetryTask(id, model string) (*task.Task, error) {
+	if err := w.checkWritable(); err != nil {
+		return nil, err
+	}
+	t, err := w.Tasks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != task.StatusFailed {
+		return nil, fmt.Errorf("task %s is %s, not failed", id, t.Status)
+	}
+	if model != "" && len(strings.Split(model, "/")) != 2 {
+		return nil, fmt.Errorf("model must be backend/model, got '%s'", model)
+	}
+	if err := w.Tasks.SetStatus(t, task.StatusPending); err != nil {
+		return nil, err
+	}
+
+	oldModel := t.Model
+	t.Retries++
+	if model != "" {
+		t.Model = model
+	} else if e, ok := w.Config.EscalationFor(t.Type); ok && t.Retries >= e.After {
+		t.Model = e.Model
+	}
+	if err := w.Tasks.Update(t); err != nil {
+		return nil, err
+	}
+	if err := w.writeTaskFile(t); err != nil {
+		w.Audit.Error("workspace.update_task", "Failed to write task file", map[string]interface{}{
+			"task_id": t.ID,
+			"error":   err.Error(),
+		})
+	}
+	if err := w.Save(); err != nil {
+		return nil, err
+	}
+
+	retried := audit.TaskRetried{TaskID: id, Retries: t.Retries}
+	if t.Model != oldModel {
+		retried.OldModel, retried.NewModel = oldModel, t.Model
+	}
+	events.PublishTo(w.Audit, audit.LevelInfo, "Task retried", retried)
+	w.Issues.Push(t.Issue, t.Status)
+	return t, nil
+}
+
+// RequeueTask returns an in-progress task whose run was interrupted, as by
+// a crash, to pending so it can be claimed again. The task isn't marked
+// failed on the way, nor is it counted as a retry.
+func (w *Workspace) RequeueTask(id string) (*task.Task, error) {
+	if err := w.checkWritable(); err != nil {
+		return nil, err
+	}
+	t, err := w.Tasks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != task.StatusInProgress {
+		return nil, fmt.Errorf("task %s is %s, not in progress", id, t.Status)
+	}
+	if err := w.Tasks.SetStatus(t, task.StatusPending); err != nil {
+		return nil, err
+	}
+	if err := w.Tasks.Update(t); err != nil {
+		return nil, err
+	}
+	if err := w.Save(); err != nil {
+		return nil, err
+	}
+	w.Issues.Push(t.Issue, t.Status)
+	return t, nil
+}
+
+// Features resolves the workspace's feature flags, with FLO_FEATURES
+// overriding the config.
+func (w *Workspace) Features() (*flags.Set, error) {
+	return flags.Resolve(w.Config.Features, os.Getenv(flags.EnvVar))
+}
+
+// TDDGate returns the TDD gate for running tests in the workspace.
+func (w *Workspace) TDDGate() *tdd.Gate {
+	gate := tdd.NewGate(w.Config.TDD, w.Root)
+	gate.SetFull(w.FullTests)
+	gate.SetAuditLogger(w.Audit)
+	return gate
+}
+
+// TaskTDDGate returns the TDD gate for running tests in the repository a
+// task targets.
+func (w *Workspace) TaskTDDGate(t *task.Task) *tdd.Gate {
+	gate := tdd.NewGate(w.Config.TDD, w.RepoRoot(t))
+	gate.SetFull(w.FullTests)
+	gate.SetAuditLogger(w.Audit)
+	return gate
+}
+
+// checkTDD runs the TDD gate and refuses completion if it fails.
+func (w *Workspace) checkTDD(t *task.Task) error {
+	result, err := w.TaskTDDGate(t).Check(context.Background())
+	if err != nil {
+		events.PublishTo(w.Audit, audit.LevelWarn, "Completion blocked by TDD gate", audit.StatusChanged{
+			TaskID:    t.ID,
+			NewStatus: string(task.StatusComplete),
+			Error:     err.Error(),
+		})
+		if result != nil && result.Output != "" {
+			return fmt.Errorf("cannot complete task %s: %w\n%s", t.ID, err, result.Output)
+		}
+		return fmt.Errorf("cannot complete task %s: %w", t.ID, err)
+	}
+	return nil
+}
+
+// VerifyPipeline returns the verification pipeline configured for a task.
+func (w *Workspace) VerifyPipeline(t *task.Task) *verify.Pipeline {
+	pipeline := verify.NewPipeline(w.Config.VerifySteps(t.Type), w.RepoRoot(t))
+	pipeline.SetAuditLogger(w.Audit)
+	return pipeline
+}
+
+// checkVerify runs the verification pipeline and refuses completion if any step fails.
+func (w *Workspace) checkVerify(t *task.Task) error {
+	pipeline := w.VerifyPipeline(t)
+	if pipeline.Empty() {
+		return nil
+	}
+	if err := pipeline.Run(context.Background()).Err(); err != nil {
+		events.PublishTo(w.Audit, audit.LevelWarn, "Completion blocked by verification", audit.StatusChanged{
+			TaskID:    t.ID,
+			NewStatus: string(task.StatusComplete),
+			Error:     err.Error(),
+		})
+		return fmt.Errorf("cannot complete task %s: %w", t.ID, err)
+	}
+	return nil
+}
+
+// Gates returns the custom gates configured for a task's type.
+func (w *Workspace) Gates(t *task.Task) (*gate.Suite, error) {
+	suite, err := gate.NewSuite(w.Config.GateConfigs(t.Type), w.RepoRoot(t))
+	if err != nil {
+		return nil, err
+	}
+	suite.SetAuditLogger(w.Audit)
+	return suite, nil
+}
+
+// GateContext describes a task to the custom gates.
+func (w *Workspace) GateContext(t *task.Task) gate.Context {
+	return gate.Context{Dir: w.RepoRoot(t), TaskID: t.ID, TaskType: t.Type, Title: t.Title}
+}
+
+// checkGates runs the custom gates and refuses completion if any fails.
+func (w *Workspace) checkGates(t *task.Task) error {
+	suite, err := w.Gates(t)
+	if err != nil {
+		return fmt.Errorf("cannot complete task %s: %w", t.ID, err)
+	}
+	if suite.Empty() {
+		return nil
+	}
+	result := suite.Run(context.Background(), w.GateContext(t))
+	if err := result.Err(); err != nil {
+		events.PublishTo(w.Audit, audit.LevelWarn, "Completion blocked by gates", audit.StatusChanged{
+			TaskID:    t.ID,
+			NewStatus: string(task.StatusComplete),
+			Error:     err.Error(),
+		})
+		var details []string
+		for _, g := range result.Failed() {
+			if g.Error != "" {
+				details = append(details, fmt.Sprintf("%s: %s", g.Name, g.Error))
+			}
+			for _, f := range g.Findings {
+				details = append(details, fmt.Sprintf("%s: %s", g.Name, f))
+			}
+		}
+		if len(details) > 0 {
+			return fmt.Errorf("cannot complete task %s: %w\n%s", t.ID, err, strings.Join(details, "\n"))
+		}
+		return fmt.Errorf("cannot complete task %s: %w", t.ID, err)
+	}
+	return nil
+}
+
+// policyApprovalKind is the kind of approval requests for operations the
+// policy holds for approval.
+const policyApprovalKind = "policy"
+
+// CheckPolicy evaluates the workspace policy over a task's uncommitted
+// changes and calls, the violations of its agent's tool calls. Every
+// violation is audited. Denied operations block completion; operations
+// that need approval block it until a reviewer approves the request filed
+// for them in the approval queue.
+func (w *Workspace) CheckPolicy(t *task.Task, calls policy.Violations) error {
+	pol, err := policy.New(w.Config.Policy)
+	if err != nil || pol == nil {
+		return err
+	}
+	dir := w.RepoRoot(t)
+	changes, err := policy.Changes(context.Background(), dir)
+	if err != nil {
+		return fmt.Errorf("failed to check policy: %w", err)
+	}
+	violations := append(slices.Clone(calls), pol.CheckChanges(changes)...)
+	for _, v := range violations {
+		events.PublishTo(w.Audit, audit.LevelWarn, "Policy violation", audit.PolicyViolation{
+			TaskID: t.ID,
+			Rule:   v.Rule,
+			Action: string(v.Action),
+			Target: v.Target,
+		})
+	}
+	if len(violations.Denied()) > 0 {
+		return violations.Err()
+	}
+	pending := violations.NeedApproval()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// Approval covers the violations it was asked for
+	summary := pending.Summary()
+	queue := w.Approvals()
+	requests, err := queue.List("")
+	if err != nil {
+		return err
+	}
+	for i := len(requests) - 1; i >= 0; i-- {
+		req := requests[i]
+		if req.TaskID != t.ID || req.Kind != policyApprovalKind || req.Summary != summary {
+			continue
+		}
+		switch req.Status {
+		case approval.StatusApproved:
+			return nil
+		case approval.StatusPending:
+			return fmt.Errorf("%w: awaiting %s (flo approvals)", policy.ErrApprovalRequired, req.ID)
+		default:
+			err := fmt.Errorf("%w: %s was %s by %s", policy.ErrApprovalRequired, req.ID, req.Status, req.DecidedBy)
+			if req.Feedback != "" {
+				err = fmt.Errorf("%w: %s", err, req.Feedback)
+			}
+			return err
+		}
+	}
+	diff, _ := repos.New().Diff(context.Background(), dir)
+	req := &approval.Request{TaskID: t.ID, Kind: policyApprovalKind, Summary: summary, Diff: diff}
+	if err := queue.Add(req); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: requested %s (flo approvals)\n%s", policy.ErrApprovalRequired, req.ID, summary)
+}
+
+// ReviewKind is the kind of approval requests holding a task's changes
+// for review.
+const ReviewKind = "review"
+
+// ErrReviewRequired is returned when a task's changes await a reviewer's
+// approval.
+var ErrReviewRequired = errors.New("changes need review")
+
+// RequestReview holds a task's uncommitted changes for review, filing a
+// request with their diff, the run's gate results and its cost in the
+// approval queue.
+func (w *Workspace) RequestReview(t *task.Task, gates []approval.GateResult, cost float64) (*approval.Request, error) {
+	// The workspace's own state isn't the agent's work
+	diff, err := repos.New().Diff(context.Background(), w.RepoRoot(t), easDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect changes for review: %w", err)
+	}
+	req := &approval.Request{
+		TaskID:  t.ID,
+		Kind:    ReviewKind,
+		Summary: t.Title,
+		Diff:    diff,
+		Gates:   gates,
+		Cost:    cost,
+	}
+	if err := w.Approvals().Add(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// Review returns the latest review request for a task, or nil when none
+// has been filed.
+func (w *Workspace) Review(taskID string) (*approval.Request, error) {
+	requests, err := w.Approvals().List("")
+	if err != nil {
+		return nil, err
+	}
+	for i := len(requests) - 1; i >= 0; i-- {
+		if requests[i].TaskID == taskID && requests[i].Kind == ReviewKind {
+			return requests[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// CheckReview refuses completion of a task whose type requires approval
+// until a reviewer has approved its latest changes.
+func (w *Workspace) CheckReview(t *task.Task) error {
+	if !w.Config.ReviewRequired(t.Type) {
+		return nil
+	}
+	req, err := w.Review(t.ID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case req == nil:
+		return fmt.Errorf("%w: run 'flo work %s' to produce changes for review", ErrReviewRequired, t.ID)
+	case req.Status == approval.StatusApproved:
+		return nil
+	case req.Status == approval.StatusPending:
+		return fmt.Errorf("%w: awaiting %s (flo review %s)", ErrReviewRequired, req.ID, t.ID)
+	default:
+		return fmt.Errorf("%w: %s was %s by %s", ErrReviewRequired, req.ID, req.Status, req.DecidedBy)
+	}
+}
+
+// ApproveReview approves a task's pending review and completes the task,
+// then, with commit set, commits the approved changes to its repository
+// as "<task id>: <title>". Files in rejected, relative to the repository,
+// have their changes reverted first; the rest are approved.
+func (w *Workspace) ApproveReview(t *task.Task, by string, commit bool, rejected []string) error {
+	req, err := w.pendingReview(t.ID)
+	if err != nil {
+		return err
+	}
+	if len(rejected) > 0 {
+		if err := w.revertReviewFiles(t, req, rejected); err != nil {
+			return err
+		}
+	}
+	if err := w.Approvals().Approve(req.ID, by); err != nil {
+		return err
+	}
+	if err := w.SetTaskStatus(t.ID, string(task.StatusComplete)); err != nil {
+		return err
+	}
+	if !commit {
+		return nil
+	}
+	return repos.New().Commit(context.Background(), w.RepoRoot(t), fmt.Sprintf("%s: %s", t.ID, t.Title))
+}
+
+// revertReviewFiles reverts the changes to some of the files of a review.
+func (w *Workspace) revertReviewFiles(t *task.Task, req *approval.Request, rejected []string) error {
+	files := map[string]bool{}
+	for _, f := range repos.SplitDiff(req.Diff) {
+		files[f.Path] = true
+	}
+	reverted := map[string]bool{}
+	for _, path := range rejected {
+		if !files[path] {
+			return fmt.Errorf("%s is not changed in review %s", path, req.ID)
+		}
+		reverted[path] = true
+	}
+	if len(reverted) == len(files) {
+		return fmt.Errorf("every file of review %s is rejected; reject the review instead", req.ID)
+	}
+	if err := repos.New().Revert(context.Background(), w.RepoRoot(t), rejected); err != nil {
+		return err
+	}
+	w.Audit.Info("workspace.review", "Rejected files reverted", map[string]interface{}{
+		"task_id": t.ID,
+		"review":  req.ID,
+		"files":   rejected,
+	})
+	return nil
+}
+
+// RejectReview rejects a task's pending review and fails the task, so it
+// can be retried.
+func (w *Workspace) RejectReview(t *task.Task, by, reason string) error {
+	req, err := w.pendingReview(t.ID)
+	if err != nil {
+		return err
+	}
+	if err := w.Approvals().Reject(req.ID, by, reason); err != nil {
+		return err
+	}
+	return w.SetTaskStatus(t.ID, string(task.StatusFailed))
+}
+
+// RequestReviewChanges sends a task's pending review back with feedback
+// for the agent, listing the files in rejected, and reopens the task: its
+// changes stay in place and the next run's prompt carries the feedback as
+// a follow-up.
+func (w *Workspace) RequestReviewChanges(t *task.Task, by, feedback string, rejected []string) error {
+	req, err := w.pendingReview(t.ID)
+	if err != nil {
+		return err
+	}
+	if len(rejected) > 0 {
+		feedback = strings.TrimSpace(feedback + "\n\nRejected files, whose changes must be undone or redone:\n- " + strings.Join(rejected, "\n- "))
+	}
+	if err := w.Approvals().RequestChanges(req.ID, by, feedback); err != nil {
+		return err
+	}
+	// Reopened directly, as the task hasn't failed
+	return w.SetTaskStatus(t.ID, string(task.StatusPending))
+}
+
+// ReviewFeedback returns the feedback of a task's latest review when a
+// reviewer sent its changes back, or "" otherwise.
+func (w *Workspace) ReviewFeedback(taskID string) string {
+	req, err := w.Review(taskID)
+	if err != nil || req == nil || req.Status != approval.StatusChangesRequested {
+		return ""
+	}
+	return req.Feedback
+}
+
+// pendingReview returns a task's review awaiting a decision.
+func (w *Workspace) pendingReview(taskID string) (*approval.Request, error) {
+	req, err := w.Review(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil || req.Status != approval.StatusPending {
+		return nil, fmt.Errorf("task %s has no pending review", taskID)
+	}
+	return req, nil
+}
+
+// Status returns the current workspace status.
+func (w *Workspace) Status() *Status {
+	tasks := w.Tasks.List()
+	
+	status := &Status{
+		Feature:    w.Feature,
+		Backend:    w.Backend,
+		TotalTasks: len(tasks),
+	}
+
+	for _, t := range tasks {
+		switch t.Status {
+		case task.StatusPending:
+			status.PendingTasks++
+		case task.StatusInProgress:
+			status.InProgressTasks++
+		case task.StatusComplete:
+			status.CompleteTasks++
+		case task.StatusFailed:
+			status.FailedTasks++
+		}
+	}
+
+	status.ReadyTasks = len(w.GetReadyTasks())
+
+	if progress, err := w.CriteriaProgress(); err == nil {
+		status.CriteriaTotal = len(progress.Criteria)
+		status.CriteriaMet = progress.Met
+	}
+	if stale, err := w.StaleTasks(); err == nil {
+		status.StaleTasks = len(stale)
+	}
+
+	return status
+}
+
+// SpecPath returns the path to the SPEC.md file.
+func (w *Workspace) SpecPath() string {
+	return filepath.Join(w.Root, easDir, specFile)
+}
+
+// ConfigPath returns the path to the workspace config file.
+func (w *Workspace) ConfigPath() string {
+	return filepath.Join(w.Root, easDir, configFile)
+}
+
+// TaskFilePath returns the path to a task's TASK-<id>.md file.
+func (w *Workspace) TaskFilePath(id string) string {
+	return filepath.Join(w.Root, easDir, tasksDir, fmt.Sprintf("TASK-%s.md", id))
+}
+
+// ReadSpec reads the SPEC.md contents.
+func (w *Workspace) ReadSpec() (string, error) {
+	data, err := os.ReadFile(w.SpecPath())
 	if err != nil {
 		return "", err
 	}
 	return string(data), nil
 }
 
+// SpecHistory returns the store of SPEC.md snapshots.
+func (w *Workspace) SpecHistory() *spec.History {
+	return spec.NewHistory(filepath.Join(w.Root, easDir, specHistoryDir))
+}
+
+// CommitSpec snapshots the current SPEC.md. When it is unchanged since the
+// latest snapshot, that version is returned with created false.
+func (w *Workspace) CommitSpec(message string) (*spec.Version, bool, error) {
+	content, err := w.ReadSpec()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read spec: %w", err)
+	}
+	v, created, err := w.SpecHistory().Commit(content, message)
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		w.Audit.Info("workspace.spec_commit", "Spec version recorded", map[string]interface{}{
+			"version": v.Number,
+			"message": message,
+		})
+	}
+	return v, created, nil
+}
+
+// StaleTasks returns tasks whose referenced spec section differs between
+// the spec version they were created against and the current SPEC.md.
+// Tasks without a recorded version use the latest snapshot taken before
+// they were created.
+func (w *Workspace) StaleTasks() ([]StaleTask, error) {
+	current, err := w.ReadSpec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	history := w.SpecHistory()
+	versions, err := history.List()
+	if err != nil {
+		return nil, err
+	}
+	byNumber := make(map[int]spec.Version)
+	for _, v := range versions {
+		byNumber[v.Number] = v
+	}
+
+	contents := make(map[int]string)
+	var stale []StaleTask
+	for _, t := range w.Tasks.List() {
+		if t.SpecRef == "" {
+			continue
+		}
+		_, anchor, err := spec.ParseRef(t.SpecRef)
+		if err != nil {
+			continue
+		}
+		base, ok := byNumber[t.SpecVersion]
+		if !ok {
+			v, err := history.At(t.CreatedAt)
+			if err != nil || v == nil {
+				continue
+			}
+			base = *v
+		}
+		old, ok := contents[base.Number]
+		if !ok {
+			if old, err = history.Read(base.Number); err != nil {
+				return nil, err
+			}
+			contents[base.Number] = old
+		}
+		if spec.SectionChanged(old, current, anchor) {
+			stale = append(stale, StaleTask{Task: t, Since: base})
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Task.ID < stale[j].Task.ID })
+	return stale, nil
+}
+
+// SpecValidator returns a validator for the workspace's required spec sections.
+func (w *Workspace) SpecValidator() *spec.Validator {
+	return spec.NewValidatorWithSections(w.Config.Spec.RequiredSections)
+}
+
+// SpecTemplates returns the spec template library.
+func (w *Workspace) SpecTemplates() *spec.TemplateLibrary {
+	return spec.DefaultTemplateLibrary()
+}
+
+// NewSpec replaces SPEC.md with the named template. An edited spec is only
+// overwritten when force is set; an empty or untouched template spec always is.
+func (w *Workspace) NewSpec(templateName string, force bool) error {
+	data := spec.TemplateData{Feature: w.Feature, Sections: w.Config.Spec.RequiredSections}
+	content, err := w.SpecTemplates().Render(templateName, data)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		current, err := w.ReadSpec()
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read spec: %w", err)
+		}
+		if strings.TrimSpace(current) != "" && !w.isTemplateSpec(current, data) {
+			return fmt.Errorf("SPEC.md has been edited; use --force to overwrite it")
+		}
+	}
+
+	if err := os.WriteFile(w.SpecPath(), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write SPEC.md: %w", err)
+	}
+	if _, _, err := w.CommitSpec(fmt.Sprintf("New spec from template %s", templateName)); err != nil {
+		return err
+	}
+	w.Audit.Info("workspace.new_spec", "Spec created from template", map[string]interface{}{
+		"template": templateName,
+		"forced":   force,
+	})
+	return nil
+}
+
+// isTemplateSpec reports whether content is an unedited rendering of any template.
+func (w *Workspace) isTemplateSpec(content string, data spec.TemplateData) bool {
+	templates, err := w.SpecTemplates().List()
+	if err != nil {
+		return false
+	}
+	for _, t := range templates {
+		if rendered, err := w.SpecTemplates().Render(t.Name, data); err == nil && rendered == content {
+			return true
+		}
+	}
+	return false
+}
+
+// ToolsDir returns the directory holding custom MCP tool definitions.
+func (w *Workspace) ToolsDir() string {
+	return filepath.Join(w.Root, easDir, toolsDir)
+}
+
+// TranscriptDir returns the directory holding run transcripts.
+func (w *Workspace) TranscriptDir() string {
+	return filepath.Join(w.Root, easDir, transcriptsDir)
+}
+
+// ReportDir returns the directory holding run reports.
+func (w *Workspace) ReportDir() string {
+	return filepath.Join(w.Root, easDir, reportsDir)
+}
+
+// RunsDir returns the directory flo run journals are saved in.
+func (w *Workspace) RunsDir() string {
+	return filepath.Join(w.Root, easDir, runsDir)
+}
+
+// KeyPath returns the path to the workspace encryption key.
+func (w *Workspace) KeyPath() string {
+	return filepath.Join(w.Root, easDir, keyFile)
+}
+
+// NewTranscript starts a transcript for a task run, encrypting it with the
+// workspace key (created on first use) when transcripts.encrypt is enabled.
+func (w *Workspace) NewTranscript(taskID string) (*transcript.Writer, error) {
+	var key seal.Key
+	if w.Config.Transcripts.Encrypt {
+		var err error
+		key, err = seal.LoadOrCreateKey(w.KeyPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workspace key: %w", err)
+		}
+	}
+	return transcript.Create(w.TranscriptDir(), taskID, key)
+}
+
+// ReadTranscript reads a transcript, decrypting it when the workspace key is available.
+func (w *Workspace) ReadTranscript(path string) ([]transcript.Entry, error) {
+	key, err := seal.LoadKey(w.KeyPath())
+	if err != nil && !errors.Is(err, seal.ErrNoKey) {
+		return nil, err
+	}
+	return transcript.Read(path, key)
+}
+
+// Prompts returns the workspace prompt template library (.flo/prompts).
+func (w *Workspace) Prompts() *prompt.Library {
+	return prompt.NewLibrary(filepath.Join(w.Root, easDir, promptsDir))
+}
+
+// RenderPrompt renders the agent prompt for a task using the template
+// selected for its task type.
+func (w *Workspace) RenderPrompt(t *task.Task) (string, error) {
+	data, err := w.promptData(t)
+	if err != nil {
+		return "", err
+	}
+	lib := w.Prompts()
+	return lib.Render(lib.Resolve(w.Config, t.Type), data)
+}
+
+// followUpContext is how much of the latest transcript, in bytes, a
+// follow-up prompt carries.
+const followUpContext = 8000
+
+// RenderFollowUpPrompt renders the prompt for continuing work on a task:
+// its usual prompt with the end of its latest transcript as context and
+// instructions as a follow-up.
+func (w *Workspace) RenderFollowUpPrompt(t *task.Task, instructions string) (string, error) {
+	data, err := w.promptData(t)
+	if err != nil {
+		return "", err
+	}
+	data.FollowUp = instructions
+	if path, err := transcript.Latest(w.TranscriptDir(), t.ID); err == nil {
+		entries, err := w.ReadTranscript(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the previous transcript: %w", err)
+		}
+		data.Previous = transcript.Excerpt(entries, followUpContext)
+	}
+	lib := w.Prompts()
+	return lib.Render(lib.Resolve(w.Config, t.Type), data)
+}
+
+// promptData gathers what a task's prompt template is executed against.
+func (w *Workspace) promptData(t *task.Task) (*prompt.Data, error) {
+	spec, _ := w.ReadSpec()
+	root := w.RepoRoot(t)
+
+	files, err := prompt.NewContextBuilder(root, w.Config.Context).Build(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &prompt.Data{
+		Feature:  w.Feature,
+		Task:     t,
+		Spec:     spec,
+		Sections: prompt.ParseSections(spec),
+		Repo:     prompt.RepoContext(root),
+		TDD:      w.Config.TDD,
+		Review:   w.Config.ReviewRequired(t.Type),
+		Feedback: w.ReviewFeedback(t.ID),
+		Files:    files,
+	}, nil
+}
+
+// RepoRoot returns the directory of the repository a task targets: the
+// checkout of the task's linked repo when it has one, otherwise the
+// workspace root.
+func (w *Workspace) RepoRoot(t *task.Task) string {
+	if _, ok := w.Config.Repos[t.Repo]; ok {
+		return w.RepoPath(t.Repo)
+	}
+	return w.Root
+}
+
+// RepoPath returns where a linked repo is checked out: its configured
+// path, relative to the workspace root unless absolute, or by default
+// .flo/repos/<name>.
+func (w *Workspace) RepoPath(name string) string {
+	repo := w.Config.Repos[name]
+	switch {
+	case repo.Path == "":
+		return filepath.Join(w.Root, easDir, reposDir, name)
+	case filepath.IsAbs(repo.Path):
+		return repo.Path
+	default:
+		return filepath.Join(w.Root, repo.Path)
+	}
+}
+
+// TaskRoot returns the directory a task's agent runs, tests and gates run
+// in, or an error if its repo isn't linked or hasn't been cloned.
+func (w *Workspace) TaskRoot(t *task.Task) (string, error) {
+	if t.Repo == "" {
+		return w.Root, nil
+	}
+	if _, ok := w.Config.Repos[t.Repo]; !ok {
+		return "", fmt.Errorf("task %s targets repo '%s', which isn't linked (flo repo add)", t.ID, t.Repo)
+	}
+	dir := w.RepoPath(t.Repo)
+	if !repos.Cloned(dir) {
+		return "", fmt.Errorf("repo '%s' of task %s isn't cloned at %s (flo repo clone %s)", t.Repo, t.ID, dir, t.Repo)
+	}
+	return dir, nil
+}
+
+// AddRepo links a repository to the workspace under name.
+func (w *Workspace) AddRepo(name string, repo config.Repo) error {
+	if !repos.ValidName(name) {
+		return fmt.Errorf("invalid repo name '%s': use letters, digits, '.', '_' and '-'", name)
+	}
+	if repo.URL == "" {
+		return fmt.Errorf("repo '%s' needs a URL", name)
+	}
+	if _, ok := w.Config.Repos[name]; ok {
+		return fmt.Errorf("repo '%s' is already linked", name)
+	}
+	if w.Config.Repos == nil {
+		w.Config.Repos = make(map[string]config.Repo)
+	}
+	w.Config.Repos[name] = repo
+	if err := w.Save(); err != nil {
+		delete(w.Config.Repos, name)
+		return err
+	}
+	w.Audit.Info("workspace.add_repo", "Repository linked", map[string]interface{}{
+		"repo": name,
+		"url":  repo.URL,
+	})
+	return nil
+}
+
+// Approvals returns the workspace's approval queue.
+func (w *Workspace) Approvals() *approval.Queue {
+	queue := approval.NewQueue(filepath.Join(w.Root, easDir, approvalsFile))
+	queue.SetAuditLogger(w.Audit)
+	return queue
+}
+
+// Health returns the workspace's backend health statistics.
+func (w *Workspace) Health() *health.Store {
+	return health.NewStore(filepath.Join(w.Root, easDir, healthFile))
+}
+
+// Costs returns the ledger of what the workspace's agent runs have cost.
+func (w *Workspace) Costs() *cost.Ledger {
+	return cost.NewLedger(filepath.Join(w.Root, easDir, costsFile))
+}
+
 // writeTaskFile writes a task.md file with YAML frontmatter.
 func (w *Workspace) writeTaskFile(t *task.Task) error {
-	easPath := filepath.Join(w.Root, easDir)
-	taskPath := filepath.Join(easPath, tasksDir, fmt.Sprintf("TASK-%s.md", t.ID))
+	taskPath := w.TaskFilePath(t.ID)
 
 	// Build YAML frontmatter
 	frontmatter := fmt.Sprintf(`---
@@ -390,6 +1790,30 @@ status: %s`, t.ID, t.Status)
 	if t.Repo != "" {
 		frontmatter += fmt.Sprintf("\nrepo: %s", t.Repo)
 	}
+	if t.SpecRef != "" {
+		frontmatter += fmt.Sprintf("\nspec_ref: %s", t.SpecRef)
+	}
+	if t.SpecVersion > 0 {
+		frontmatter += fmt.Sprintf("\nspec_version: %d", t.SpecVersion)
+	}
+	if len(t.Criteria) > 0 {
+		frontmatter += "\ncriteria:"
+		for _, id := range t.Criteria {
+			frontmatter += fmt.Sprintf("\n  - %s", id)
+		}
+	}
+	if t.Parent != "" {
+		frontmatter += fmt.Sprintf("\nparent: %s", t.Parent)
+	}
+	if t.Issue != "" {
+		frontmatter += fmt.Sprintf("\nissue: %s", t.Issue)
+	}
+	if len(t.Assignees) > 0 {
+		frontmatter += "\nassignees:"
+		for _, a := range t.Assignees {
+			frontmatter += fmt.Sprintf("\n  - %s", a)
+		}
+	}
 	if len(t.Deps) > 0 {
 		frontmatter += "\ndeps:"
 		for _, dep := range t.Deps {
@@ -1,9 +1,27 @@
 package workspace
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/richgo/flo/pkg/approval"
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/flags"
+	"github.com/richgo/flo/pkg/issues"
+	"github.com/richgo/flo/pkg/policy"
+	"github.com/richgo/flo/pkg/spec"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/telemetry"
+	"github.com/richgo/flo/pkg/transcript"
 )
 
 func TestInit(t *testing.T) {
@@ -47,6 +65,24 @@ func TestInit(t *testing.T) {
 	}
 }
 
+func TestInitSpecPassesValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, err := Init(tmpDir, "my-feature", "claude")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	content, _ := ws.ReadSpec()
+	if result := ws.SpecValidator().Validate(content); !result.Valid {
+		t.Errorf("generated spec fails validation: missing %v", result.MissingSections)
+	}
+
+	ws.Config.Spec.RequiredSections = []string{"Overview", "Rollout"}
+	if result := ws.SpecValidator().Validate(content); result.Valid {
+		t.Error("expected configured sections to be enforced")
+	}
+}
+
 func TestInitAlreadyExists(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -80,12 +116,29 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestWorkspaceFeatures(t *testing.T) {
+	ws, err := Init(t.TempDir(), "test-feature", "claude")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	ws.Config.Features = map[string]bool{flags.AutoMerge: true}
+	t.Setenv(flags.EnvVar, flags.ExperimentalBackends)
+
+	features, err := ws.Features()
+	if err != nil {
+		t.Fatalf("Features failed: %v", err)
+	}
+	if !features.Enabled(flags.AutoMerge) || !features.Enabled(flags.ExperimentalBackends) || features.Enabled(flags.DistributedWorkers) {
+		t.Errorf("unexpected flags: %+v", features.List())
+	}
+}
+
 func TestLoadNotInitialized(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	_, err := Load(tmpDir)
-	if err == nil {
-		t.Error("expected error for non-initialized workspace")
+	if !errors.Is(err, ErrNoWorkspace) {
+		t.Errorf("expected ErrNoWorkspace for non-initialized workspace, got %v", err)
 	}
 }
 
@@ -287,3 +340,1091 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestWorkspaceCompletionTDDGate(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	tk, _ := ws.CreateTask("Gated task", "", nil, 0)
+	if err := ws.SetTaskStatus(tk.ID, "in_progress"); err != nil {
+		t.Fatalf("failed to start task: %v", err)
+	}
+
+	ws.Config.TDD.TestCommand = "exit 1"
+	if err := ws.SetTaskStatus(tk.ID, "complete"); err == nil {
+		t.Fatal("expected completion to be blocked by failing tests")
+	}
+	if got, _ := ws.GetTask(tk.ID); got.Status != "in_progress" {
+		t.Errorf("expected task to remain in_progress, got %s", got.Status)
+	}
+
+	ws.Config.TDD.TestCommand = "exit 0"
+	if err := ws.SetTaskStatus(tk.ID, "complete"); err != nil {
+		t.Fatalf("expected completion with passing tests: %v", err)
+	}
+
+	// Gate is skipped when enforcement is off
+	tk2, _ := ws.CreateTask("Ungated task", "", nil, 0)
+	ws.SetTaskStatus(tk2.ID, "in_progress")
+	ws.Config.TDD.Enforce = false
+	ws.Config.TDD.TestCommand = "exit 1"
+	if err := ws.SetTaskStatus(tk2.ID, "complete"); err != nil {
+		t.Errorf("expected completion without enforcement: %v", err)
+	}
+}
+
+func TestWorkspaceEncryptedTranscript(t *testing.T) {
+	t.Setenv("FLO_WORKSPACE_KEY", "")
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+	ws.Config.Transcripts.Encrypt = true
+
+	tw, err := ws.NewTranscript("t-001")
+	if err != nil {
+		t.Fatalf("NewTranscript failed: %v", err)
+	}
+	tw.Write("prompt", "secret prompt")
+	tw.Close()
+
+	if _, err := os.Stat(ws.KeyPath()); err != nil {
+		t.Fatalf("expected workspace key to be created: %v", err)
+	}
+
+	entries, err := ws.ReadTranscript(tw.Path())
+	if err != nil {
+		t.Fatalf("ReadTranscript failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Content != "secret prompt" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	// Without the key the transcript cannot be read
+	os.Remove(ws.KeyPath())
+	if _, err := ws.ReadTranscript(tw.Path()); err == nil {
+		t.Error("expected error reading encrypted transcript without key")
+	}
+}
+
+func TestWorkspaceCompletionVerifyPipeline(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+	ws.Config.TDD.Enforce = false
+	ws.Config.Verify = []config.VerifyStep{
+		{Name: "build", Command: "exit 1"},
+	}
+
+	tk, _ := ws.CreateTask("Verified task", "", nil, 0)
+	ws.SetTaskStatus(tk.ID, "in_progress")
+
+	if err := ws.SetTaskStatus(tk.ID, "complete"); err == nil {
+		t.Fatal("expected failing verify step to block completion")
+	}
+
+	ws.Config.Verify[0].Command = "exit 0"
+	if err := ws.SetTaskStatus(tk.ID, "complete"); err != nil {
+		t.Fatalf("expected completion with passing verify steps: %v", err)
+	}
+}
+
+func TestWorkspaceCompletionCustomGates(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+	ws.Config.TDD.Enforce = false
+
+	pluginDir := filepath.Join(tmpDir, ".flo", "plugins")
+	os.MkdirAll(pluginDir, 0755)
+	script := "#!/bin/sh\ncat > /dev/null\necho '{\"findings\":[{\"severity\":\"warning\",\"rule\":\"SEC-1\",\"message\":\"weak hash\"}]}'\n"
+	os.WriteFile(filepath.Join(pluginDir, "compliance"), []byte(script), 0755)
+
+	ws.Config.Gates = []config.GateConfig{{Name: "compliance", Plugin: "compliance", FailOn: "warning"}}
+	ws.Config.TaskTypes["docs"] = config.TaskType{Gates: []config.GateConfig{{Name: "compliance", Plugin: "compliance"}}}
+
+	tk, _ := ws.CreateTask("Scanned task", "", nil, 0)
+	ws.SetTaskStatus(tk.ID, "in_progress")
+
+	err := ws.SetTaskStatus(tk.ID, "complete")
+	if err == nil {
+		t.Fatal("expected failing gate to block completion")
+	}
+	if !strings.Contains(err.Error(), "SEC-1: weak hash") {
+		t.Errorf("expected findings in error, got %v", err)
+	}
+
+	// The docs override only fails on errors, so the warning passes.
+	docs, _ := ws.CreateTaskWithType("Docs task", "docs", "", nil, 0)
+	ws.SetTaskStatus(docs.ID, "in_progress")
+	if err := ws.SetTaskStatus(docs.ID, "complete"); err != nil {
+		t.Fatalf("expected per-type gate config to allow completion: %v", err)
+	}
+}
+
+func TestWorkspaceSpecVersionsAndStaleTasks(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	versions, _ := ws.SpecHistory().List()
+	if len(versions) != 1 {
+		t.Fatalf("expected init to record v1, got %d versions", len(versions))
+	}
+
+	content, _ := ws.ReadSpec()
+	content += "\n## OAuth\n\nSupport GitHub.\n\n## Billing\n\nInvoices.\n"
+	os.WriteFile(ws.SpecPath(), []byte(content), 0644)
+
+	oauth, _ := ws.CreateTask("OAuth", "", nil, 0)
+	oauth.SpecRef = "SPEC.md#oauth"
+	if err := ws.UpdateTask(oauth); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if oauth.SpecVersion != 2 {
+		t.Errorf("expected task to be baselined on v2, got %d", oauth.SpecVersion)
+	}
+	billing, _ := ws.CreateTask("Billing", "", nil, 0)
+	billing.SpecRef = "SPEC.md#billing"
+	ws.UpdateTask(billing)
+
+	if stale, _ := ws.StaleTasks(); len(stale) != 0 {
+		t.Fatalf("expected no stale tasks, got %d", len(stale))
+	}
+
+	content = strings.Replace(content, "Support GitHub.", "Support GitHub and Google.", 1)
+	os.WriteFile(ws.SpecPath(), []byte(content), 0644)
+
+	stale, err := ws.StaleTasks()
+	if err != nil {
+		t.Fatalf("StaleTasks failed: %v", err)
+	}
+	if len(stale) != 1 || stale[0].Task.ID != oauth.ID || stale[0].Since.Number != 2 {
+		t.Fatalf("expected only the OAuth task to be stale, got %+v", stale)
+	}
+	if ws.Status().StaleTasks != 1 {
+		t.Error("expected status to count the stale task")
+	}
+
+	// Acknowledging re-baselines the task on a new version.
+	oauth.SpecVersion = 0
+	ws.UpdateTask(oauth)
+	if oauth.SpecVersion != 3 {
+		t.Errorf("expected re-baseline on v3, got %d", oauth.SpecVersion)
+	}
+	if stale, _ := ws.StaleTasks(); len(stale) != 0 {
+		t.Errorf("expected no stale tasks after acknowledging, got %d", len(stale))
+	}
+
+	if _, created, _ := ws.CommitSpec("no-op"); created {
+		t.Error("expected unchanged spec not to create a version")
+	}
+}
+
+func TestWorkspaceRenderPromptPerTaskType(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	tk, _ := ws.CreateTaskWithType("Fix crash", "bugfix", "", nil, 0)
+
+	out, err := ws.RenderPrompt(tk)
+	if err != nil {
+		t.Fatalf("RenderPrompt failed: %v", err)
+	}
+	if !strings.Contains(out, "Title: Fix crash") {
+		t.Errorf("expected built-in prompt, got:\n%s", out)
+	}
+
+	promptDir := filepath.Join(tmpDir, ".flo", "prompts")
+	os.MkdirAll(promptDir, 0755)
+	os.WriteFile(filepath.Join(promptDir, "bugfix.tmpl"), []byte("Reproduce then fix: {{.Task.Title}}"), 0644)
+
+	out, err = ws.RenderPrompt(tk)
+	if err != nil {
+		t.Fatalf("RenderPrompt failed: %v", err)
+	}
+	if out != "Reproduce then fix: Fix crash" {
+		t.Errorf("expected bugfix template, got %q", out)
+	}
+}
+
+func TestWorkspaceRenderFollowUpPrompt(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	defer ws.Close()
+	tk, _ := ws.CreateTask("Add login", "", nil, 0)
+
+	out, err := ws.RenderFollowUpPrompt(tk, "Also handle expired tokens")
+	if err != nil {
+		t.Fatalf("RenderFollowUpPrompt failed: %v", err)
+	}
+	if strings.Contains(out, "## Previous Run") || !strings.Contains(out, "Also handle expired tokens") {
+		t.Errorf("expected only the instructions without an earlier run:\n%s", out)
+	}
+
+	tw, err := ws.NewTranscript(tk.ID)
+	if err != nil {
+		t.Fatalf("NewTranscript failed: %v", err)
+	}
+	tw.Write(transcript.EntryPrompt, "Implement login")
+	tw.Write(transcript.EntryMessage, "Added the login handler")
+	tw.Close()
+
+	out, err = ws.RenderFollowUpPrompt(tk, "Also handle expired tokens")
+	if err != nil {
+		t.Fatalf("RenderFollowUpPrompt failed: %v", err)
+	}
+	if !strings.Contains(out, "## Previous Run") || !strings.Contains(out, "Added the login handler") || strings.Contains(out, "Implement login") {
+		t.Errorf("expected the previous run without its prompt:\n%s", out)
+	}
+}
+
+func TestWorkspaceApplyProposal(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	p := &spec.Proposal{Tasks: []spec.ProposedTask{
+		{Key: "api", Title: "Build API", Type: "build", Deps: []string{"db"}},
+		{Key: "db", Title: "Create schema", Type: "data-model", Description: "users table"},
+	}}
+
+	created, err := ws.ApplyProposal(p)
+	if err != nil {
+		t.Fatalf("ApplyProposal failed: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(created))
+	}
+	schema, api := created[0], created[1]
+	if schema.Title != "Create schema" || schema.Description != "users table" {
+		t.Errorf("unexpected first task: %+v", schema)
+	}
+	if len(api.Deps) != 1 || api.Deps[0] != schema.ID {
+		t.Errorf("expected API to depend on %s, got %v", schema.ID, api.Deps)
+	}
+
+	reloaded, _ := Load(tmpDir)
+	if got, _ := reloaded.GetTask(schema.ID); got.Description != "users table" {
+		t.Errorf("expected description to persist, got %q", got.Description)
+	}
+
+	bad := &spec.Proposal{Tasks: []spec.ProposedTask{{Key: "1", Title: "X", Type: "unknown"}}}
+	if _, err := ws.ApplyProposal(bad); err == nil {
+		t.Error("expected invalid proposal to be rejected")
+	}
+}
+
+func TestWorkspaceSpecRef(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+	os.WriteFile(ws.SpecPath(), []byte("# Feature: auth\n\n## OAuth\n\n## Sessions\n"), 0644)
+
+	tk, _ := ws.CreateTask("Add OAuth", "", nil, 0)
+	tk.SpecRef = "SPEC.md#billing"
+	if err := ws.UpdateTask(tk); err == nil {
+		t.Error("expected unknown spec section to be rejected")
+	}
+
+	tk.SpecRef = "SPEC.md#oauth"
+	if err := ws.UpdateTask(tk); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	reloaded, _ := Load(tmpDir)
+	if got, _ := reloaded.GetTask(tk.ID); got.SpecRef != "SPEC.md#oauth" {
+		t.Errorf("expected spec ref to persist, got %q", got.SpecRef)
+	}
+
+	sections, err := reloaded.SpecCoverage()
+	if err != nil {
+		t.Fatalf("SpecCoverage failed: %v", err)
+	}
+	if len(sections) != 2 || !sections[0].Covered() || sections[1].Covered() {
+		t.Errorf("unexpected coverage: %+v", sections)
+	}
+}
+
+func TestWorkspaceSpecTemplates(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+
+	if _, err := InitWithTemplate(tmpDir, "login", "claude", "nope"); err == nil {
+		t.Fatal("expected unknown template to fail")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".flo")); !os.IsNotExist(err) {
+		t.Error("expected failed init to leave no workspace behind")
+	}
+
+	ws, err := InitWithTemplate(tmpDir, "login", "claude", "bugfix")
+	if err != nil {
+		t.Fatalf("InitWithTemplate failed: %v", err)
+	}
+	content, _ := ws.ReadSpec()
+	if !strings.HasPrefix(content, "# Bug: login") {
+		t.Errorf("expected bugfix spec, got:\n%s", content)
+	}
+
+	// An untouched template spec can be replaced without --force
+	if err := ws.NewSpec("api", false); err != nil {
+		t.Fatalf("NewSpec failed: %v", err)
+	}
+	content, _ = ws.ReadSpec()
+	if !strings.Contains(content, "## Endpoints") {
+		t.Errorf("expected api spec, got:\n%s", content)
+	}
+
+	os.WriteFile(ws.SpecPath(), []byte(content+"\nMy notes\n"), 0644)
+	if err := ws.NewSpec("migration", false); err == nil {
+		t.Error("expected edited spec to require force")
+	}
+	if err := ws.NewSpec("migration", true); err != nil {
+		t.Fatalf("forced NewSpec failed: %v", err)
+	}
+}
+
+func TestWorkspacePreservesNewerManifestFields(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir, "test", "claude")
+
+	// A manifest written by a newer flo with fields this version doesn't know
+	manifest := filepath.Join(tmpDir, ".flo", "tasks", "manifest.json")
+	os.WriteFile(manifest, []byte(`{
+		"version": 1,
+		"format": "v2",
+		"tasks": [
+			{"id": "t-001", "title": "Login", "status": "pending", "labels": ["auth"],
+			 "review": {"required": 2}, "created_at": "2026-02-05T22:00:00Z", "updated_at": "2026-02-05T22:00:00Z"},
+			{"id": "t-002", "title": "Logout", "status": "pending", "deps": ["t-001"], "estimate": 3,
+			 "created_at": "2026-02-05T22:00:00Z", "updated_at": "2026-02-05T22:00:00Z"}
+		]
+	}`), 0644)
+
+	ws, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := ws.SetTaskStatus("t-001", "in_progress"); err != nil {
+		t.Fatalf("SetTaskStatus failed: %v", err)
+	}
+	tk, _ := ws.GetTask("t-002")
+	tk.Description = "Clear the session"
+	if err := ws.UpdateTask(tk); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if _, err := ws.CreateTask("Remember me", "", nil, 0); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	var saved struct {
+		Format string                   `json:"format"`
+		Tasks  []map[string]interface{} `json:"tasks"`
+	}
+	data, _ := os.ReadFile(manifest)
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if saved.Format != "v2" {
+		t.Errorf("expected manifest field 'format' preserved, got %q", saved.Format)
+	}
+	byID := make(map[string]map[string]interface{})
+	for _, task := range saved.Tasks {
+		byID[task["id"].(string)] = task
+	}
+	if byID["t-001"]["status"] != "in_progress" || byID["t-001"]["labels"] == nil || byID["t-001"]["review"] == nil {
+		t.Errorf("t-001 lost fields: %v", byID["t-001"])
+	}
+	if byID["t-002"]["description"] != "Clear the session" || byID["t-002"]["estimate"] != float64(3) {
+		t.Errorf("t-002 lost fields: %v", byID["t-002"])
+	}
+	if len(byID) != 3 {
+		t.Errorf("expected 3 tasks, got %d", len(byID))
+	}
+}
+
+func TestWorkspaceCriteriaProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+	ws.Config.TDD.Enforce = false
+	os.WriteFile(ws.SpecPath(), []byte("# Feature\n\n## Acceptance Criteria\n- [ ] Sign in\n- [ ] Sign out\n"), 0644)
+
+	tk, _ := ws.CreateTask("Sign in", "", nil, 0)
+	tk.Criteria = []string{"AC-3"}
+	if err := ws.UpdateTask(tk); err == nil {
+		t.Error("expected unknown criterion to be rejected")
+	}
+	tk.Criteria = []string{"AC-1"}
+	if err := ws.UpdateTask(tk); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+
+	if status := ws.Status(); status.CriteriaTotal != 2 || status.CriteriaMet != 0 {
+		t.Errorf("expected 0/2 criteria met, got %d/%d", status.CriteriaMet, status.CriteriaTotal)
+	}
+	ws.SetTaskStatus(tk.ID, "in_progress")
+	if err := ws.SetTaskStatus(tk.ID, "complete"); err != nil {
+		t.Fatalf("SetTaskStatus failed: %v", err)
+	}
+	if status := ws.Status(); status.CriteriaMet != 1 {
+		t.Errorf("expected 1 criterion met, got %d", status.CriteriaMet)
+	}
+}
+
+func TestWorkspaceRefusesNewerManifestSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir, "test", "claude")
+	manifest := filepath.Join(tmpDir, ".flo", "tasks", "manifest.json")
+	os.WriteFile(manifest, []byte(`{"schema": 99, "version": 1, "tasks": []}`), 0644)
+
+	ws, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("expected newer manifest to load read-only: %v", err)
+	}
+	if _, err := ws.CreateTask("A", "", nil, 0); !errors.Is(err, task.ErrSchemaTooNew) {
+		t.Errorf("expected schema error, got %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(tmpDir, ".flo", "tasks", "TASK-*.md")); len(files) != 0 {
+		t.Errorf("expected no task files written, got %v", files)
+	}
+}
+
+func TestWorkspacePriorityDefaults(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	docs := 5
+	ws.Config.Priorities = config.PriorityConfig{Default: 3}
+	ws.Config.TaskTypes["docs"] = config.TaskType{Priority: &docs}
+
+	if got := ws.DefaultPriority("build", ""); got != 3 {
+		t.Errorf("default priority = %d, want 3", got)
+	}
+	if got := ws.DefaultPriority("docs", ""); got != 5 {
+		t.Errorf("docs priority = %d, want 5", got)
+	}
+
+	parent, _ := ws.CreateTask("Parent", "", nil, 1)
+	if got := ws.DefaultPriority("docs", parent.ID); got != 5 {
+		t.Errorf("subtask priority without inherit = %d, want 5", got)
+	}
+	ws.Config.Priorities.Inherit = true
+	if got := ws.DefaultPriority("docs", parent.ID); got != 1 {
+		t.Errorf("inherited priority = %d, want 1", got)
+	}
+
+	if _, err := ws.CreateSubtask("t-404", "Orphan", "", "", nil, 0); err == nil {
+		t.Error("expected error for missing parent")
+	}
+}
+
+func TestWorkspaceEffectivePriority(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+
+	parent, _ := ws.CreateTask("Parent", "", nil, 2)
+	child, err := ws.CreateSubtask(parent.ID, "Child", "", "", nil, 0)
+	if err != nil {
+		t.Fatalf("CreateSubtask failed: %v", err)
+	}
+	other, _ := ws.CreateTask("Other", "", nil, 1)
+
+	if p, reason := ws.EffectivePriority(child); p != 0 || reason != "" {
+		t.Errorf("uncapped priority = %d (%q), want 0", p, reason)
+	}
+
+	ws.Config.Priorities.Cap = true
+	p, reason := ws.EffectivePriority(child)
+	if p != 2 || reason != "capped at parent "+parent.ID {
+		t.Errorf("capped priority = %d (%q), want 2 capped at parent", p, reason)
+	}
+
+	var order []string
+	for _, r := range ws.GetReadyTasks() {
+		order = append(order, r.ID)
+	}
+	want := []string{other.ID, parent.ID, child.ID}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("ready order = %v, want %v", order, want)
+	}
+}
+
+func TestWorkspaceAuditLogPerWorkspace(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+	a, err := Init(dirA, "feature-a", "claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := Init(dirB, "feature-b", "claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if _, err := a.CreateTask("Alpha task", "", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.CreateTask("Beta task", "", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	logA, _ := os.ReadFile(filepath.Join(dirA, ".flo", "audit.log"))
+	logB, _ := os.ReadFile(filepath.Join(dirB, ".flo", "audit.log"))
+	if !strings.Contains(string(logA), "Alpha task") || strings.Contains(string(logA), "Beta task") {
+		t.Errorf("workspace A log should only record its own task:\n%s", logA)
+	}
+	if !strings.Contains(string(logB), "Beta task") || strings.Contains(string(logB), "Alpha task") {
+		t.Errorf("workspace B log should only record its own task:\n%s", logB)
+	}
+}
+
+func TestWorkspaceEventsPerWorkspace(t *testing.T) {
+	var mu sync.Mutex
+	var delivered []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered = append(delivered, r.Header.Get("X-Flo-Event"))
+	}))
+	defer srv.Close()
+
+	dirA, dirB := t.TempDir(), t.TempDir()
+	a, err := Init(dirA, "feature-a", "claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := Init(dirB, "feature-b", "claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Config.Webhooks = []config.WebhookConfig{{URL: srv.URL, Events: []string{"task_failed"}}}
+	b.Save()
+	b.Close()
+	// B is loaded last, so it holds the default audit log
+	if b, err = Load(dirB); err != nil {
+		t.Fatal(err)
+	}
+
+	alpha, _ := a.CreateTask("Alpha task", "", nil, 0)
+	a.SetTaskStatus(alpha.ID, string(task.StatusInProgress))
+	a.SetTaskStatus(alpha.ID, string(task.StatusFailed))
+	a.Approvals().Add(&approval.Request{TaskID: alpha.ID, Kind: "review", Summary: "Alpha review"})
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	logA, _ := os.ReadFile(filepath.Join(dirA, ".flo", "audit.log"))
+	logB, _ := os.ReadFile(filepath.Join(dirB, ".flo", "audit.log"))
+	for _, want := range []string{"task.set_status", "approval.add"} {
+		if !strings.Contains(string(logA), want) {
+			t.Errorf("expected %s in workspace A's log:\n%s", want, logA)
+		}
+		if strings.Contains(string(logB), want) {
+			t.Errorf("expected no %s in workspace B's log:\n%s", want, logB)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delivered) != 0 {
+		t.Errorf("expected B's webhooks not to fire for A's tasks, got %q", delivered)
+	}
+}
+
+func TestWorkspaceTelemetry(t *testing.T) {
+	var mu sync.Mutex
+	var exported []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		exported = append(exported, r.URL.Path+" "+string(body))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	tmpDir := t.TempDir()
+	if _, err := Init(tmpDir, "feature", "claude"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(telemetry.EndpointEnv, srv.URL)
+	ws, err := Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ws.Telemetry.Enabled() {
+		t.Fatal("expected telemetry from the OTLP endpoint variable")
+	}
+	if _, err := ws.CreateTask("Traced task", "", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(exported) != 1 || !strings.HasPrefix(exported[0], "/v1/traces ") {
+		t.Fatalf("expected one trace export on Close, got %v", exported)
+	}
+	if !strings.Contains(exported[0], `"task.registry.add"`) || !strings.Contains(exported[0], `"flo.feature"`) {
+		t.Errorf("expected registry spans from the workspace, got %s", exported[0])
+	}
+}
+
+func TestWorkspaceRetryTask(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	ws.Config.TaskTypes["build"] = config.TaskType{Model: "claude/sonnet"}
+	ws.Config.Escalation = config.EscalationConfig{After: 2, Model: "claude/opus"}
+
+	tk, _ := ws.CreateTaskWithType("Build it", "build", "", nil, 0)
+	if _, err := ws.RetryTask(tk.ID, ""); err == nil {
+		t.Error("expected a pending task not to be retried")
+	}
+
+	fail := func() {
+		t.Helper()
+		if err := ws.SetTaskStatus(tk.ID, "in_progress"); err != nil {
+			t.Fatal(err)
+		}
+		if err := ws.SetTaskStatus(tk.ID, "failed"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fail()
+	got, err := ws.RetryTask(tk.ID, "")
+	if err != nil {
+		t.Fatalf("RetryTask failed: %v", err)
+	}
+	if got.Status != task.StatusPending || got.Retries != 1 || got.Model != "claude/sonnet" {
+		t.Errorf("first retry: status %s, retries %d, model %s", got.Status, got.Retries, got.Model)
+	}
+
+	// The second failure reaches escalation.after
+	fail()
+	if got, _ = ws.RetryTask(tk.ID, ""); got.Model != "claude/opus" {
+		t.Errorf("expected escalation to claude/opus, got %s", got.Model)
+	}
+
+	// An explicit model wins over escalation
+	fail()
+	if got, _ = ws.RetryTask(tk.ID, "copilot/gpt-4"); got.Model != "copilot/gpt-4" || got.Retries != 3 {
+		t.Errorf("explicit model: retries %d, model %s", got.Retries, got.Model)
+	}
+
+	// The model reaches the task file flo work reads it from
+	fromFile, err := task.ParseTaskFile(ws.TaskFilePath(tk.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromFile.Model != "copilot/gpt-4" {
+		t.Errorf("task file model = %s", fromFile.Model)
+	}
+}
+
+func TestWorkspaceRequeueTask(t *testing.T) {
+	dir := t.TempDir()
+	ws, _ := Init(dir, "test", "claude")
+	defer ws.Close()
+	tk, _ := ws.CreateTask("Build it", "", nil, 0)
+	if _, err := ws.RequeueTask(tk.ID); err == nil {
+		t.Error("expected a pending task not to be requeued")
+	}
+
+	if err := ws.SetTaskStatus(tk.ID, "in_progress"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ws.RequeueTask(tk.ID)
+	if err != nil {
+		t.Fatalf("RequeueTask failed: %v", err)
+	}
+	if got.Status != task.StatusPending || got.Retries != 0 {
+		t.Errorf("expected the task pending without a retry, got %s with %d retries", got.Status, got.Retries)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, ".flo", "audit.log")); strings.Contains(string(data), `"to":"failed"`) {
+		t.Errorf("expected the interrupted task never marked failed:\n%s", data)
+	}
+}
+
+func TestWorkspaceReload(t *testing.T) {
+	dir := t.TempDir()
+	ws, _ := Init(dir, "test", "claude")
+
+	// Another process adds a task
+	other, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.CreateTask("Elsewhere", "", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ws.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if len(ws.ListTasks("", "")) != 1 {
+		t.Fatalf("expected the other process's task after reloading")
+	}
+	created, err := ws.CreateTask("Here", "", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != "t-002" {
+		t.Errorf("expected the next ID after reloading, got %s", created.ID)
+	}
+}
+
+func TestWorkspaceWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, r.Header.Get("X-Flo-Event")+" "+r.Header.Get("X-Flo-Signature"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ws, _ := Init(dir, "test", "claude")
+	ws.Config.Webhooks = []config.WebhookConfig{{URL: srv.URL, SecretEnv: "TEST_WEBHOOK_SECRET", Events: []string{"task_failed"}}}
+	ws.Save()
+	ws.Close()
+
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cret")
+	ws, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, _ := ws.CreateTask("Hooked", "", nil, 0)
+	ws.SetTaskStatus(created.ID, string(task.StatusInProgress))
+	ws.SetTaskStatus(created.ID, string(task.StatusFailed))
+	if err := ws.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || !strings.HasPrefix(events[0], "task_failed sha256=") {
+		t.Errorf("expected one signed task_failed delivery, got %q", events)
+	}
+}
+
+func TestWorkspaceImportIssues(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	ws.Config.TaskTypes = map[string]config.TaskType{"bugfix": {Model: "claude/haiku"}}
+
+	found := []issues.Issue{
+		{Ref: "github:org/app#1", URL: "https://github.com/org/app/issues/1", Title: "Login", Body: "Add login",
+			Labels: []string{"bugfix", "priority:2"}, Assignees: []string{"ana"}},
+		{Ref: "github:org/app#2", Title: "Logout", Links: []string{"github:org/app#1", "github:org/app#9"}},
+	}
+	result, err := ws.ImportIssues(found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Created) != 2 || len(result.Updated) != 0 {
+		t.Fatalf("expected 2 created tasks, got %+v", result)
+	}
+	login, _ := ws.GetTask(result.Created[0].ID)
+	if login.Type != "bugfix" || login.Priority != 2 || login.Model != "claude/haiku" || login.Issue != "github:org/app#1" {
+		t.Errorf("unexpected login task %+v", login)
+	}
+	if login.Description != "Add login\n\nImported from https://github.com/org/app/issues/1" || login.Assignees[0] != "ana" {
+		t.Errorf("unexpected description %q or assignees %q", login.Description, login.Assignees)
+	}
+	logout, _ := ws.GetTask(result.Created[1].ID)
+	if len(logout.Deps) != 1 || logout.Deps[0] != login.ID {
+		t.Errorf("expected logout to depend on %s, got %v", login.ID, logout.Deps)
+	}
+
+	found[0].Title = "Sign in"
+	result, err = ws.ImportIssues(found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Created) != 0 || len(result.Updated) != 1 || result.Updated[0].Title != "Sign in" {
+		t.Errorf("expected the renamed issue's task to be updated, got %+v", result)
+	}
+	if n := len(ws.ListTasks("", "")); n != 2 {
+		t.Errorf("expected re-importing not to duplicate tasks, got %d", n)
+	}
+}
+
+func TestWorkspaceIssueSync(t *testing.T) {
+	var mu sync.Mutex
+	var closed []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		closed = append(closed, r.Method+" "+r.URL.Path)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ws, _ := Init(dir, "test", "claude")
+	ws.Config.TDD.Enforce = false
+	ws.Config.Issues.GitHub = &config.GitHubIssues{Repo: "org/app", URL: srv.URL, Sync: true}
+	ws.ImportIssues([]issues.Issue{{Ref: "github:org/app#5", Title: "Login"}})
+	ws.Close()
+
+	ws, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.SetTaskStatus("t-001", string(task.StatusInProgress))
+	if err := ws.SetTaskStatus("t-001", string(task.StatusComplete)); err != nil {
+		t.Fatal(err)
+	}
+	ws.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(closed) != 1 || closed[0] != "PATCH /repos/org/app/issues/5" {
+		t.Errorf("expected the issue to be closed once, got %q", closed)
+	}
+}
+
+func TestWorkspaceRepos(t *testing.T) {
+	dir := t.TempDir()
+	ws, _ := Init(dir, "test", "claude")
+	defer ws.Close()
+
+	if err := ws.AddRepo("api", config.Repo{URL: "git@github.com:org/api.git"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.AddRepo("api", config.Repo{URL: "git@github.com:org/api.git"}); err == nil {
+		t.Error("expected a repo linked twice to be rejected")
+	}
+	if err := ws.AddRepo("web", config.Repo{URL: "git@github.com:org/web.git", Path: "../web"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ws.RepoPath("api"), filepath.Join(dir, ".flo", "repos", "api"); got != want {
+		t.Errorf("expected api at %s, got %s", want, got)
+	}
+	if got, want := ws.RepoPath("web"), filepath.Join(dir, "..", "web"); got != want {
+		t.Errorf("expected web at %s, got %s", want, got)
+	}
+
+	task, _ := ws.CreateTaskWithType("Add endpoint", "", "api", nil, 0)
+	if _, err := ws.TaskRoot(task); err == nil {
+		t.Error("expected an uncloned repo to be an error")
+	}
+	os.MkdirAll(filepath.Join(ws.RepoPath("api"), ".git"), 0755)
+	root, err := ws.TaskRoot(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != ws.RepoPath("api") || ws.RepoRoot(task) != root {
+		t.Errorf("expected the task to run in %s, got %s", ws.RepoPath("api"), root)
+	}
+
+	other, _ := ws.CreateTaskWithType("Fix docs", "", "docs", nil, 0)
+	if _, err := ws.TaskRoot(other); err == nil {
+		t.Error("expected an unlinked repo to be an error")
+	}
+	plain, _ := ws.CreateTaskWithType("Refactor", "", "", nil, 0)
+	if root, err := ws.TaskRoot(plain); err != nil || root != dir {
+		t.Errorf("expected a task without a repo to run in the workspace root, got %s, %v", root, err)
+	}
+
+	ws.Close()
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.Close()
+	if len(loaded.Config.Repos) != 2 {
+		t.Errorf("expected the linked repos to be saved, got %v", loaded.Config.Repos)
+	}
+}
+
+func TestWorkspaceCheckPolicy(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module app\n"), 0644)
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-qm", "init")
+
+	ws, _ := Init(dir, "test", "claude")
+	defer ws.Close()
+	ws.Config.TDD.Enforce = false
+	ws.Config.Policy = policy.Config{
+		DenyPaths:       []string{"*.pem"},
+		RequireApproval: policy.ApprovalRules{Dependencies: true},
+	}
+	tk, _ := ws.CreateTaskWithType("Bump deps", "", "", nil, 0)
+	ws.SetTaskStatus(tk.ID, string(task.StatusInProgress))
+
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module app\n\nrequire example.com/lib v1.0.0\n"), 0644)
+	err := ws.SetTaskStatus(tk.ID, string(task.StatusComplete))
+	if !errors.Is(err, policy.ErrApprovalRequired) {
+		t.Fatalf("expected completion to need approval, got %v", err)
+	}
+	pending, _ := ws.Approvals().Pending()
+	if len(pending) != 1 || pending[0].Kind != "policy" || !strings.Contains(pending[0].Diff, "example.com/lib") {
+		t.Fatalf("expected one policy approval with the diff, got %+v", pending)
+	}
+	if err := ws.SetTaskStatus(tk.ID, string(task.StatusComplete)); !errors.Is(err, policy.ErrApprovalRequired) {
+		t.Errorf("expected completion to wait for approval, got %v", err)
+	}
+	if pending, _ := ws.Approvals().Pending(); len(pending) != 1 {
+		t.Errorf("expected the request not to be filed twice, got %d", len(pending))
+	}
+
+	os.WriteFile(filepath.Join(dir, "server.pem"), []byte("key"), 0600)
+	if err := ws.SetTaskStatus(tk.ID, string(task.StatusComplete)); !errors.Is(err, policy.ErrDenied) {
+		t.Errorf("expected a denied file to block completion, got %v", err)
+	}
+	os.Remove(filepath.Join(dir, "server.pem"))
+
+	ws.Approvals().Approve(pending[0].ID, "reviewer")
+	if err := ws.SetTaskStatus(tk.ID, string(task.StatusComplete)); err != nil {
+		t.Errorf("expected approved changes to complete, got %v", err)
+	}
+}
+
+func TestWorkspaceReview(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)
+	git("init", "-q")
+	git("config", "user.name", "test")
+	git("config", "user.email", "test@example.com")
+	git("add", ".")
+	git("commit", "-qm", "init")
+
+	ws, _ := Init(dir, "test", "claude")
+	defer ws.Close()
+	ws.Config.TDD.Enforce = false
+	ws.Config.TaskTypes["migration"] = config.TaskType{Approval: config.ApprovalRequired}
+	tk, _ := ws.CreateTaskWithType("Add users table", "migration", "", nil, 0)
+	ws.SetTaskStatus(tk.ID, string(task.StatusInProgress))
+
+	if err := ws.SetTaskStatus(tk.ID, string(task.StatusComplete)); !errors.Is(err, ErrReviewRequired) {
+		t.Fatalf("expected completion to need a review, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "users.sql"), []byte("CREATE TABLE users;\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	req, err := ws.RequestReview(tk, []approval.GateResult{{Name: "lint", Passed: true}}, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Kind != ReviewKind || !strings.Contains(req.Diff, "CREATE TABLE users") {
+		t.Fatalf("expected a review with the untracked file's diff, got %+v", req)
+	}
+	if err := ws.SetTaskStatus(tk.ID, string(task.StatusComplete)); !errors.Is(err, ErrReviewRequired) {
+		t.Errorf("expected completion to wait for the review, got %v", err)
+	}
+
+	if err := ws.ApproveReview(tk, "reviewer", true, []string{"main.go", "users.sql"}); err == nil {
+		t.Error("expected rejecting every file to be refused")
+	}
+	if err := ws.ApproveReview(tk, "reviewer", true, []string{"other.go"}); err == nil {
+		t.Error("expected rejecting an unchanged file to be refused")
+	}
+	if err := ws.ApproveReview(tk, "reviewer", true, []string{"main.go"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(data) != "package main\n" {
+		t.Errorf("expected the rejected file to be reverted, got %q", data)
+	}
+	if got, _ := ws.GetTask(tk.ID); got.Status != task.StatusComplete {
+		t.Errorf("expected the approved task to complete, got %s", got.Status)
+	}
+	if log := git("log", "--format=%s"); !strings.Contains(log, tk.ID+": Add users table") {
+		t.Errorf("expected the approved changes to be committed, got log:\n%s", log)
+	}
+	if err := ws.ApproveReview(tk, "reviewer", true, nil); err == nil {
+		t.Error("expected no pending review after approval")
+	}
+
+	other, _ := ws.CreateTaskWithType("Drop users table", "migration", "", nil, 0)
+	ws.SetTaskStatus(other.ID, string(task.StatusInProgress))
+	ws.RequestReview(other, nil, 0)
+	if err := ws.RequestReviewChanges(other, "reviewer", "keep the table", []string{"drop.sql"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ws.GetTask(other.ID); got.Status != task.StatusPending {
+		t.Errorf("expected a task sent back to be reopened, got %s", got.Status)
+	}
+	feedback := ws.ReviewFeedback(other.ID)
+	if !strings.HasPrefix(feedback, "keep the table") || !strings.Contains(feedback, "- drop.sql") {
+		t.Errorf("expected the feedback and rejected files to be recorded, got %q", feedback)
+	}
+	prompt, err := ws.RenderPrompt(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "## Review Feedback") || !strings.Contains(prompt, "keep the table") {
+		t.Errorf("expected the feedback in the follow-up prompt:\n%s", prompt)
+	}
+}
+
+func TestWorkspaceReviewSendBackIsNotAFailure(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	var mu sync.Mutex
+	var delivered []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered = append(delivered, r.Header.Get("X-Flo-Event"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-qm", "init"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	ws, _ := Init(dir, "test", "claude")
+	ws.Config.Webhooks = []config.WebhookConfig{{URL: srv.URL, Events: []string{"task_failed"}}}
+	ws.Save()
+	ws.Close()
+	ws, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tk, _ := ws.CreateTask("Drop users table", "", nil, 0)
+	ws.SetTaskStatus(tk.ID, string(task.StatusInProgress))
+	if _, err := ws.RequestReview(tk, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.RequestReviewChanges(tk, "reviewer", "keep the table", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := ws.GetTask(tk.ID); got.Status != task.StatusPending {
+		t.Errorf("expected the task reopened, got %s", got.Status)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delivered) != 0 {
+		t.Errorf("expected no task_failed webhook for a task sent back, got %q", delivered)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, ".flo", "audit.log"))
+	if strings.Contains(string(data), `"to":"failed"`) {
+		t.Errorf("expected the task never marked failed:\n%s", data)
+	}
+}